@@ -6,12 +6,16 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	httpAdapter "garden3/internal/adapter/primary/http"
 	"garden3/internal/adapter/primary/http/handler"
+	"garden3/internal/adapter/primary/worker"
 	"garden3/internal/adapter/secondary/ai"
+	"garden3/internal/adapter/secondary/archive"
+	"garden3/internal/adapter/secondary/bookmarkformat"
 	"garden3/internal/adapter/secondary/contentprocessor"
 	"garden3/internal/adapter/secondary/embedding"
 	"garden3/internal/adapter/secondary/httpfetch"
@@ -19,7 +23,9 @@ import (
 	"garden3/internal/adapter/secondary/postgres"
 	"garden3/internal/adapter/secondary/postgres/repository"
 	"garden3/internal/adapter/secondary/social"
+	"garden3/internal/domain/entity"
 	"garden3/internal/domain/service"
+	"garden3/internal/port/output"
 )
 
 func main() {
@@ -53,20 +59,72 @@ func main() {
 	tagRepo := repository.NewTagRepository(db.Pool)
 
 	// Initialize external service adapters
-	// Get Ollama configuration for embeddings
+	// The embedding.* configurations select the embedding provider, Ollama with the env settings by default
 	ollamaEmbedURL := os.Getenv("OLLAMA_EMBED_API_URL")
 	if ollamaEmbedURL == "" {
 		ollamaEmbedURL = os.Getenv("OLLAMA_API_URL") // Fall back to main Ollama URL
 	}
-	ollamaEmbedModel := os.Getenv("OLLAMA_EMBED_MODEL")
-	if ollamaEmbedModel == "" {
-		ollamaEmbedModel = "nomic-embed-text:latest"
+	embeddingConfig, err := embedding.LoadConfig(ctx, configRepo, embedding.Config{
+		Provider: embedding.ProviderOllama,
+		URL:      ollamaEmbedURL,
+		Model:    os.Getenv("OLLAMA_EMBED_MODEL"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to load embedding configuration: %v", err)
+	}
+	batchConfig := embedding.DefaultBatchConfig()
+	batchConfig.BatchSize = envInt("EMBED_BATCH_SIZE", batchConfig.BatchSize)
+	batchConfig.Concurrency = envInt("EMBED_CONCURRENCY", batchConfig.Concurrency)
+	batchConfig.Timeout = envDuration("EMBED_TIMEOUT", batchConfig.Timeout)
+	batchConfig.MaxRetries = envInt("EMBED_MAX_RETRIES", batchConfig.MaxRetries)
+	// Texts already embedded with a model take their vector from the embedding cache unless it is disabled
+	var embeddingCache *embedding.Cache
+	if os.Getenv("EMBED_CACHE_DISABLED") != "true" {
+		embeddingCache = embedding.NewCache(repository.NewEmbeddingCacheRepository(db.Pool))
 	}
+	// The embedding services embed with the live model, which an embedding migration switches at its cutover
+	embeddingModels, err := embedding.NewModels(embeddingConfig, batchConfig, configRepo, embeddingCache)
+	if err != nil {
+		log.Fatalf("Failed to create embedding provider: %v", err)
+	}
+	log.Printf("Embedding with %s model %s", embeddingConfig.Provider, embeddingConfig.Model)
+	embeddingBatcher := embeddingCache.Wrap(embedding.NewBatcher(embeddingModels, batchConfig))
 
-	embeddingService := embedding.NewOllamaEmbeddingService(ollamaEmbedURL, ollamaEmbedModel)
-	embeddingsService := embedding.NewOllamaEmbeddingsService(ollamaEmbedURL, ollamaEmbedModel)
+	embeddingService := embedding.NewEmbeddingService(embeddingBatcher)
+	embeddingsService := embedding.NewEmbeddingsService(embeddingBatcher)
 	socialMediaService := social.NewService(configRepo)
-	httpFetcher := httpfetch.NewFetcher()
+	fetchConfig := httpfetch.DefaultConfig()
+	fetchConfig.MaxBodySize = int64(envInt("FETCH_MAX_BODY_BYTES", int(fetchConfig.MaxBodySize)))
+	fetchConfig.HostInterval = envDuration("FETCH_HOST_INTERVAL", fetchConfig.HostInterval)
+	fetchConfig.HostConcurrency = envInt("FETCH_HOST_CONCURRENCY", fetchConfig.HostConcurrency)
+	fetchConfig.RespectRobots = os.Getenv("FETCH_RESPECT_ROBOTS") == "true"
+	fetchConfig.AllowHTTPFallback = os.Getenv("FETCH_HTTP_FALLBACK") == "true"
+	if userAgent := os.Getenv("FETCH_ROBOTS_USER_AGENT"); userAgent != "" {
+		fetchConfig.RobotsUserAgent = userAgent
+	}
+	httpFetcher := httpfetch.NewFetcher(fetchConfig)
+
+	// Page archiving stays off when disabled or when the archive directory cannot be used
+	var pageArchiver output.PageArchiver
+	var archiveStore output.ArchiveStore
+	if os.Getenv("ARCHIVE_DISABLED") != "true" {
+		archiveDir := os.Getenv("ARCHIVE_DIR")
+		if archiveDir == "" {
+			archiveDir = "archive"
+		}
+		store, err := archive.NewStore(archiveDir, int64(envInt("ARCHIVE_MAX_BYTES", 0)))
+		if err != nil {
+			log.Printf("Page archiving disabled: %v", err)
+		} else {
+			archiveConfig := archive.DefaultConfig()
+			archiveConfig.MaxResourceSize = int64(envInt("ARCHIVE_MAX_RESOURCE_BYTES", int(archiveConfig.MaxResourceSize)))
+			archiveConfig.MaxResources = envInt("ARCHIVE_MAX_RESOURCES", archiveConfig.MaxResources)
+			archiveConfig.MaxSnapshotSize = int64(envInt("ARCHIVE_MAX_SNAPSHOT_BYTES", int(archiveConfig.MaxSnapshotSize)))
+			archiveConfig.Concurrency = envInt("ARCHIVE_CONCURRENCY", archiveConfig.Concurrency)
+			pageArchiver = archive.NewArchiver(httpFetcher, archiveConfig)
+			archiveStore = store
+		}
+	}
 
 	// Initialize AI service (for summary generation)
 	aiServiceURL := os.Getenv("AI_SERVICE_URL")
@@ -91,17 +149,43 @@ func main() {
 	sessionService := service.NewSessionService(sessionRepo, embeddingService)
 	noteService := service.NewNoteService(noteRepo, embeddingsService)
 	itemService := service.NewItemService(itemRepo)
-	bookmarkService := service.NewBookmarkService(bookmarkRepo, httpFetcher, embeddingsService, aiService, contentProcessor)
+	bookmarkService := service.NewBookmarkService(bookmarkRepo, httpFetcher, embeddingsService, aiService, contentProcessor, configRepo, pageArchiver, archiveStore, envInt("HTTP_RESPONSE_RETENTION", 3))
 	entityService := service.NewEntityService(entityRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
 	socialPostService := service.NewSocialPostService(socialPostRepo, socialMediaService)
 	observationService := service.NewObservationService(observationRepo)
+	retrievalEvaluationService := service.NewRetrievalEvaluationService(observationRepo, bookmarkRepo, searchRepo, embeddingsService)
 	dashboardService := service.NewDashboardService(dashboardRepo)
 	browserHistoryService := service.NewBrowserHistoryService(browserHistoryRepo)
 	searchService := service.NewSearchService(searchRepo, embeddingService, llmService, configService)
 	utilityService := service.NewUtilityService(sessionRepo, messageRepo, configRepo, db.Pool)
 	logseqSyncService := service.NewLogseqSyncService(configService, entityRepo)
 	tagService := service.NewTagService(tagRepo)
+	bookmarkImportService := service.NewBookmarkImportService(
+		bookmarkService,
+		bookmarkRepo,
+		categoryRepo,
+		contentProcessor,
+		map[entity.ImportFormat]output.BookmarkParser{
+			entity.ImportFormatNetscape:   bookmarkformat.NewNetscapeParser(),
+			entity.ImportFormatPocketHTML: bookmarkformat.NewPocketHTMLParser(),
+			entity.ImportFormatPocketCSV:  bookmarkformat.NewPocketCSVParser(),
+			entity.ImportFormatPinboard:   bookmarkformat.NewPinboardParser(),
+			entity.ImportFormatRaindrop:   bookmarkformat.NewRaindropParser(),
+			entity.ImportFormatWallabag:   bookmarkformat.NewWallabagParser(),
+		},
+		bookmarkformat.NewNetscapeWriter(),
+	)
+	bookmarkPipelineService := service.NewBookmarkPipelineService(bookmarkService, bookmarkRepo, envInt("PIPELINE_MAX_ATTEMPTS", 3), 2*time.Second)
+	bookmarkLinkCheckService := service.NewBookmarkLinkCheckService(bookmarkRepo, httpFetcher, contentProcessor, configRepo, envDuration("LINK_CHECK_INTERVAL", 30*24*time.Hour))
+	fetchProfileService := service.NewFetchProfileService(configRepo, httpfetch.NewCookiesTxtParser())
+	bookmarkAnnotationService := service.NewBookmarkAnnotationService(bookmarkRepo, noteRepo, embeddingsService)
+	embeddingModelService := service.NewEmbeddingModelService(repository.NewEmbeddingModelRepository(db.Pool), embeddingModels, noteRepo)
+	embeddingCacheService := service.NewEmbeddingCacheService(embeddingCache, envInt("EMBED_CACHE_MAX_ENTRIES", 200000), envDuration("EMBED_CACHE_MAX_AGE", 90*24*time.Hour))
+	if err := embeddingModelService.ActivateLiveModel(ctx); err != nil {
+		log.Fatalf("Failed to activate embedding model: %v", err)
+	}
+	bookmarkCategorizationService := service.NewBookmarkCategorizationService(categoryRepo, bookmarkRepo, embeddingsService, envFloat("CATEGORIZE_THRESHOLD", 0.85))
 
 	// Initialize HTTP handlers
 	configHandler := handler.NewConfigurationHandler(configService)
@@ -111,17 +195,19 @@ func main() {
 	sessionHandler := handler.NewSessionHandler(sessionService)
 	noteHandler := handler.NewNoteHandler(noteService)
 	itemHandler := handler.NewItemHandler(itemService, tagService)
-	bookmarkHandler := handler.NewBookmarkHandler(bookmarkService)
+	bookmarkHandler := handler.NewBookmarkHandler(bookmarkService, bookmarkImportService, bookmarkLinkCheckService, bookmarkAnnotationService)
 	entityHandler := handler.NewEntityHandler(entityService)
-	categoryHandler := handler.NewCategoryHandler(categoryService)
+	categoryHandler := handler.NewCategoryHandler(categoryService, bookmarkCategorizationService)
 	socialPostHandler := handler.NewSocialPostHandler(socialPostService)
-	observationHandler := handler.NewObservationHandler(observationService)
+	observationHandler := handler.NewObservationHandler(observationService, retrievalEvaluationService)
 	dashboardHandler := handler.NewDashboardHandler(dashboardService)
 	browserHistoryHandler := handler.NewBrowserHistoryHandler(browserHistoryService)
 	searchHandler := handler.NewSearchHandler(searchService)
 	utilityHandler := handler.NewUtilityHandler(utilityService)
 	logseqHandler := handler.NewLogseqHandler(logseqSyncService, entityRepo)
 	tagHandler := handler.NewTagHandler(tagService)
+	fetchProfileHandler := handler.NewFetchProfileHandler(fetchProfileService)
+	embeddingHandler := handler.NewEmbeddingHandler(embeddingModelService, embeddingCacheService)
 
 	// Initialize HTTP server
 	server := httpAdapter.NewServer()
@@ -151,9 +237,83 @@ func main() {
 	utilityHandler.RegisterRoutes(router)
 	logseqHandler.RegisterRoutes(router)
 	tagHandler.RegisterRoutes(router)
+	fetchProfileHandler.RegisterRoutes(router)
+	embeddingHandler.RegisterRoutes(router)
 
 	log.Println("Routes registered")
 
+	// Merge bookmarks stored under different forms of one URL before anything adds more, which is a no-op once done
+	urlMigration, err := bookmarkService.MigrateBookmarkURLs(ctx)
+	if err != nil {
+		log.Printf("Bookmark URL migration stopped: %v", err)
+	}
+	if urlMigration.Canonicalized > 0 || urlMigration.Merged > 0 {
+		log.Printf("Bookmark URL migration canonicalized %d URLs and merged %d bookmarks", urlMigration.Canonicalized, urlMigration.Merged)
+	}
+
+	// Start the background ingestion pipeline for new bookmarks
+	workerCtx, stopWorker := context.WithCancel(ctx)
+	defer stopWorker()
+	var pipelineWorker *worker.BookmarkPipelineWorker
+	if os.Getenv("PIPELINE_DISABLED") != "true" {
+		pipelineWorker = worker.NewBookmarkPipelineWorker(db.Pool, bookmarkPipelineService, envInt("PIPELINE_CONCURRENCY", 2))
+		pipelineWorker.Start(workerCtx)
+		log.Println("Bookmark pipeline worker started")
+	}
+
+	// Start the link-rot monitor that re-checks bookmarked pages
+	var linkCheckWorker *worker.LinkCheckWorker
+	if os.Getenv("LINK_CHECK_DISABLED") != "true" {
+		linkCheckWorker = worker.NewLinkCheckWorker(bookmarkLinkCheckService, envDuration("LINK_CHECK_POLL", time.Hour), envInt("LINK_CHECK_BATCH", 50))
+		linkCheckWorker.Start(workerCtx)
+		log.Println("Link check worker started")
+	}
+
+	// Start the loop that replaces Q&A pairs users rejected
+	var qaRegenerationWorker *worker.QARegenerationWorker
+	if os.Getenv("QA_REGENERATION_DISABLED") != "true" {
+		qaRegenerationWorker = worker.NewQARegenerationWorker(bookmarkService, envDuration("QA_REGENERATION_POLL", time.Hour), envInt("QA_REGENERATION_BATCH", 10))
+		qaRegenerationWorker.Start(workerCtx)
+		log.Println("Q&A regeneration worker started")
+	}
+
+	// Start the categorizer that suggests categories for new bookmarks
+	var categorizationWorker *worker.CategorizationWorker
+	if os.Getenv("CATEGORIZE_DISABLED") != "true" {
+		categorizationWorker = worker.NewCategorizationWorker(bookmarkCategorizationService, envDuration("CATEGORIZE_POLL", 15*time.Minute), envInt("CATEGORIZE_BATCH", 50))
+		categorizationWorker.Start(workerCtx)
+		log.Println("Categorization worker started")
+	}
+
+	// Start the re-embedding of the corpus for embedding migrations
+	var embeddingMigrationWorker *worker.EmbeddingMigrationWorker
+	if os.Getenv("EMBED_MIGRATION_DISABLED") != "true" {
+		embeddingMigrationWorker = worker.NewEmbeddingMigrationWorker(embeddingModelService, envDuration("EMBED_MIGRATION_POLL", time.Minute), envInt("EMBED_MIGRATION_BATCH", 256))
+		embeddingMigrationWorker.Start(workerCtx)
+		log.Println("Embedding migration worker started")
+	}
+
+	// Start the eviction that keeps the embedding cache within its age and size
+	var embeddingCacheWorker *worker.EmbeddingCacheWorker
+	if embeddingCache != nil {
+		embeddingCacheWorker = worker.NewEmbeddingCacheWorker(embeddingCacheService, envDuration("EMBED_CACHE_EVICT_POLL", time.Hour))
+		embeddingCacheWorker.Start(workerCtx)
+		log.Println("Embedding cache worker started")
+	}
+
+	// Move responses stored before bodies were shared, which is a no-op once done
+	if os.Getenv("HTTP_RESPONSE_MIGRATION_DISABLED") != "true" {
+		go func() {
+			result, err := bookmarkService.MigrateHttpResponseStorage(workerCtx)
+			if err != nil {
+				log.Printf("HTTP response storage migration stopped: %v", err)
+			}
+			if result.Migrated > 0 || result.Pruned > 0 {
+				log.Printf("HTTP response storage migration moved %d responses and pruned %d", result.Migrated, result.Pruned)
+			}
+		}()
+	}
+
 	// Start server in a goroutine
 	serverErrors := make(chan error, 1)
 	go func() {
@@ -181,8 +341,54 @@ func main() {
 		// Shutdown server (this would need to be implemented in the Server type)
 		// For now, just close the database
 		_ = shutdownCtx // TODO: use this context when implementing graceful shutdown
+		stopWorker()
+		if pipelineWorker != nil {
+			pipelineWorker.Wait()
+		}
+		if linkCheckWorker != nil {
+			linkCheckWorker.Wait()
+		}
+		if qaRegenerationWorker != nil {
+			qaRegenerationWorker.Wait()
+		}
+		if categorizationWorker != nil {
+			categorizationWorker.Wait()
+		}
+		if embeddingMigrationWorker != nil {
+			embeddingMigrationWorker.Wait()
+		}
+		if embeddingCacheWorker != nil {
+			embeddingCacheWorker.Wait()
+		}
 		db.Close()
 
 		log.Println("Shutdown complete")
 	}
 }
+
+// envInt reads a positive integer from the environment, falling back to def
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 1 {
+		return def
+	}
+	return value
+}
+
+// envDuration reads a positive duration such as 720h from the environment, falling back to def
+func envDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// envFloat reads a positive number such as 0.8 from the environment, falling back to def
+func envFloat(key string, def float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
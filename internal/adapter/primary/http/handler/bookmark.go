@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,22 +15,35 @@ import (
 )
 
 type BookmarkHandler struct {
-	useCase input.BookmarkUseCase
+	useCase           input.BookmarkUseCase
+	importUseCase     input.BookmarkImportUseCase
+	linkCheckUseCase  input.BookmarkLinkCheckUseCase
+	annotationUseCase input.BookmarkAnnotationUseCase
 }
 
-func NewBookmarkHandler(useCase input.BookmarkUseCase) *BookmarkHandler {
+func NewBookmarkHandler(useCase input.BookmarkUseCase, importUseCase input.BookmarkImportUseCase, linkCheckUseCase input.BookmarkLinkCheckUseCase, annotationUseCase input.BookmarkAnnotationUseCase) *BookmarkHandler {
 	return &BookmarkHandler{
-		useCase: useCase,
+		useCase:           useCase,
+		importUseCase:     importUseCase,
+		linkCheckUseCase:  linkCheckUseCase,
+		annotationUseCase: annotationUseCase,
 	}
 }
 
 func (h *BookmarkHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/bookmarks", func(r chi.Router) {
 		r.Get("/", h.ListBookmarks)
+		r.Post("/", h.CreateBookmark)
 		r.Get("/random", h.RandomBookmark)
 		r.Get("/search", h.SearchBookmarks)
 		r.Get("/missing/http", h.MissingHttp)
 		r.Get("/missing/reader", h.MissingReader)
+		r.Post("/import", h.ImportBookmarks)
+		r.Get("/imports/{importId}", h.GetImport)
+		r.Get("/export", h.ExportBookmarks)
+		r.Get("/link-issues", h.ListLinkIssues)
+		r.Get("/evaluations/report", h.GetEvaluationReport)
+		r.Get("/questions/rejected", h.ListRejectedQuestions)
 
 		// Backwards-compatible aliases for missing endpoints
 		r.Get("/missing-http", h.MissingHttp)
@@ -36,14 +51,28 @@ func (h *BookmarkHandler) RegisterRoutes(r chi.Router) {
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.GetBookmark)
+			r.Put("/", h.UpdateBookmark)
+			r.Delete("/", h.DeleteBookmark)
 			r.Put("/question", h.UpdateQuestion)
 			r.Delete("/question/{refId}", h.DeleteQuestion)
 			r.Post("/fetch", h.FetchContent)
 			r.Post("/process/lynx", h.ProcessLynx)
 			r.Post("/process/reader", h.ProcessReader)
+			r.Post("/metadata", h.ExtractMetadata)
+			r.Post("/link-check", h.CheckLink)
+			r.Post("/archive", h.ArchiveBookmark)
+			r.Get("/archive", h.GetArchive)
+			r.Get("/archives", h.ListArchives)
+			r.Get("/annotations", h.ListBookmarkAnnotations)
+			r.Post("/annotations", h.CreateAnnotation)
+			r.Get("/evaluations", h.ListEvaluations)
+			r.Post("/evaluations", h.CreateEvaluation)
 			r.Post("/embeddings", h.CreateEmbeddings)
 			r.Post("/summary-embedding", h.CreateSummary)
 			r.Get("/title", h.GetTitle)
+			r.Get("/status", h.GetStatus)
+			r.Post("/questions/generate", h.GenerateQuestions)
+			r.Post("/questions/regenerate", h.RegenerateQuestions)
 
 			// Backwards-compatible aliases for bookmark-specific endpoints
 			r.Put("/update-question", h.UpdateQuestion)
@@ -54,6 +83,14 @@ func (h *BookmarkHandler) RegisterRoutes(r chi.Router) {
 			r.Post("/embed-summary", h.CreateSummary)
 		})
 	})
+
+	r.Route("/api/annotations", func(r chi.Router) {
+		r.Get("/", h.ListAnnotations)
+		r.Get("/search", h.SearchAnnotations)
+		r.Get("/{annotationId}", h.GetAnnotation)
+		r.Put("/{annotationId}", h.UpdateAnnotation)
+		r.Delete("/{annotationId}", h.DeleteAnnotation)
+	})
 }
 
 // ListBookmarks godoc
@@ -64,6 +101,10 @@ func (h *BookmarkHandler) RegisterRoutes(r chi.Router) {
 // @Param searchQuery query string false "Search query"
 // @Param startCreationDate query string false "Start creation date"
 // @Param endCreationDate query string false "End creation date"
+// @Param failedStage query string false "Only bookmarks whose latest run of this stage failed"
+// @Param author query string false "Author name or part of it"
+// @Param site query string false "Site name or host"
+// @Param publishedYear query int false "Year the page was published"
 // @Param page query int false "Page number"
 // @Param limit query int false "Page size"
 // @Success 200 {object} input.PaginatedResponse[entity.BookmarkWithTitle]
@@ -107,6 +148,32 @@ func (h *BookmarkHandler) ListBookmarks(w http.ResponseWriter, r *http.Request)
 		filters.EndCreationDate = &endDate
 	}
 
+	if failedStage := r.URL.Query().Get("failedStage"); failedStage != "" {
+		if !entity.IsStatusStage(failedStage) {
+			http.Error(w, "Invalid failed stage", http.StatusBadRequest)
+			return
+		}
+		filters.FailedStage = &failedStage
+	}
+
+	if author := r.URL.Query().Get("author"); author != "" {
+		filters.Author = &author
+	}
+
+	if site := r.URL.Query().Get("site"); site != "" {
+		filters.Site = &site
+	}
+
+	if yearStr := r.URL.Query().Get("publishedYear"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year < 1 {
+			http.Error(w, "Invalid published year", http.StatusBadRequest)
+			return
+		}
+		publishedYear := int32(year)
+		filters.PublishedYear = &publishedYear
+	}
+
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
 		page, err := strconv.Atoi(pageStr)
 		if err != nil || page < 1 {
@@ -180,6 +247,150 @@ func (h *BookmarkHandler) GetBookmark(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(details)
 }
 
+// CreateBookmarkRequest represents the request body for creating a bookmark
+type CreateBookmarkRequest struct {
+	URL          string          `json:"url"`
+	Title        *string         `json:"title"`
+	CategoryID   *uuid.UUID      `json:"category_id"`
+	CreationDate *time.Time      `json:"creation_date"`
+	SourceURI    *string         `json:"source_uri"`
+	RawSource    json.RawMessage `json:"raw_source"`
+}
+
+// UpdateBookmarkRequest represents the request body for updating a bookmark
+type UpdateBookmarkRequest struct {
+	URL        *string    `json:"url"`
+	Title      *string    `json:"title"`
+	CategoryID *uuid.UUID `json:"category_id"`
+}
+
+// CreateBookmark godoc
+// @Summary Create bookmark
+// @Description Create a bookmark from a canonicalized URL, returning the existing bookmark on duplicates
+// @Tags bookmarks
+// @Param input body CreateBookmarkRequest true "Bookmark data"
+// @Success 201 {object} entity.CreateBookmarkResult
+// @Success 200 {object} entity.CreateBookmarkResult
+// @Router /api/bookmarks [post]
+func (h *BookmarkHandler) CreateBookmark(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateBookmarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL == "" {
+		http.Error(w, "URL is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.useCase.CreateBookmark(ctx, entity.CreateBookmarkInput{
+		URL:          req.URL,
+		Title:        req.Title,
+		CategoryID:   req.CategoryID,
+		CreationDate: req.CreationDate,
+		SourceURI:    req.SourceURI,
+		RawSource:    req.RawSource,
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidBookmarkURL) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// UpdateBookmark godoc
+// @Summary Update bookmark
+// @Description Update a bookmark's URL, title or category
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Param input body UpdateBookmarkRequest true "Update data"
+// @Success 200 {object} entity.BookmarkWithTitle
+// @Router /api/bookmarks/{id} [put]
+func (h *BookmarkHandler) UpdateBookmark(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateBookmarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	bookmark, err := h.useCase.UpdateBookmark(ctx, bookmarkID, entity.UpdateBookmarkInput{
+		URL:        req.URL,
+		Title:      req.Title,
+		CategoryID: req.CategoryID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrInvalidBookmarkURL):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, entity.ErrDuplicateBookmark):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookmark)
+}
+
+// DeleteBookmark godoc
+// @Summary Delete bookmark
+// @Description Delete a bookmark with its responses, processed content, embeddings, titles, category and sources
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {string} string "Bookmark not found"
+// @Router /api/bookmarks/{id} [delete]
+func (h *BookmarkHandler) DeleteBookmark(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.DeleteBookmark(ctx, bookmarkID); err != nil {
+		if errors.Is(err, entity.ErrBookmarkNotFound) {
+			http.Error(w, "Bookmark not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Bookmark deleted successfully",
+	})
+}
+
 // SearchBookmarks godoc
 // @Summary Search bookmarks
 // @Description Perform vector similarity search on bookmarks
@@ -204,6 +415,10 @@ func (h *BookmarkHandler) SearchBookmarks(w http.ResponseWriter, r *http.Request
 
 	results, err := h.useCase.SearchSimilarBookmarks(ctx, query, strategy)
 	if err != nil {
+		if errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -376,9 +591,36 @@ func (h *BookmarkHandler) ProcessReader(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(result)
 }
 
+// ExtractMetadata godoc
+// @Summary Extract page metadata
+// @Description Parse the fetched page for OpenGraph, Twitter card, meta tag and JSON-LD metadata and compute reading statistics
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {object} entity.BookmarkMetadata
+// @Router /api/bookmarks/{id}/metadata [post]
+func (h *BookmarkHandler) ExtractMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.useCase.ExtractBookmarkMetadata(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 // CreateEmbeddings godoc
 // @Summary Create embeddings
-// @Description Create chunked embeddings for bookmark content
+// @Description Create chunked embeddings for the whole reader text, replacing the previous chunks. Chunks follow the Markdown structure, carry their heading breadcrumb and record their position
 // @Tags bookmarks
 // @Param id path string true "Bookmark ID"
 // @Success 200 {object} entity.EmbeddingResult
@@ -457,6 +699,76 @@ func (h *BookmarkHandler) GetTitle(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// GenerateQuestionsRequest represents the request body for generating Q&A pairs
+type GenerateQuestionsRequest struct {
+	Count int  `json:"count"`
+	Force bool `json:"force"`
+}
+
+// GenerateQuestions godoc
+// @Summary Generate bookmark Q&A pairs
+// @Description Generate, embed and store Q&A pairs for the qa-v2-passage strategy. Existing pairs from the current prompt version are returned unless force is set
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Param request body GenerateQuestionsRequest false "Generation options"
+// @Success 200 {object} entity.GenerateQuestionsResult
+// @Router /api/bookmarks/{id}/questions/generate [post]
+func (h *BookmarkHandler) GenerateQuestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	var req GenerateQuestionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.useCase.GenerateBookmarkQuestions(ctx, bookmarkID, entity.GenerateQuestionsInput{
+		Count: req.Count,
+		Force: req.Force,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetStatus godoc
+// @Summary Get bookmark processing status
+// @Description Report the state of each processing stage and the recent run history
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {object} entity.BookmarkStatus
+// @Router /api/bookmarks/{id}/status [get]
+func (h *BookmarkHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.useCase.GetBookmarkStatus(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 // MissingHttp godoc
 // @Summary Get bookmarks missing HTTP responses
 // @Description Get bookmarks that don't have HTTP responses
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// CreateAnnotation godoc
+// @Summary Annotate bookmark
+// @Description Highlight a quote or position range of the bookmark's reader text, with an optional note and tags. Notes can link to entities with [[Name]] or [[Display][Name]]
+// @Tags annotations
+// @Param id path string true "Bookmark ID"
+// @Param input body entity.CreateAnnotationInput true "Annotation"
+// @Success 201 {object} entity.BookmarkAnnotation
+// @Router /api/bookmarks/{id}/annotations [post]
+func (h *BookmarkHandler) CreateAnnotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	var input entity.CreateAnnotationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	annotation, err := h.annotationUseCase.CreateAnnotation(ctx, bookmarkID, input)
+	if err != nil {
+		annotationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// ListBookmarkAnnotations godoc
+// @Summary List bookmark annotations
+// @Description Get the annotations of a bookmark in reading order, each anchored in the current reader text
+// @Tags annotations
+// @Param id path string true "Bookmark ID"
+// @Success 200 {array} entity.BookmarkAnnotation
+// @Router /api/bookmarks/{id}/annotations [get]
+func (h *BookmarkHandler) ListBookmarkAnnotations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	annotations, err := h.annotationUseCase.ListBookmarkAnnotations(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotations)
+}
+
+// ListAnnotations godoc
+// @Summary List annotations
+// @Description Get annotations across all bookmarks, newest first
+// @Tags annotations
+// @Param search query string false "Only annotations whose quote or note contains this text"
+// @Param tag query string false "Only annotations with this tag"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} input.PaginatedResponse[entity.BookmarkAnnotation]
+// @Router /api/annotations [get]
+func (h *BookmarkHandler) ListAnnotations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters := entity.AnnotationFilters{
+		Page:  1,
+		Limit: 10,
+	}
+
+	if search := r.URL.Query().Get("search"); search != "" {
+		filters.Search = &search
+	}
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		filters.Tag = &tag
+	}
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		filters.Page = int32(page)
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filters.Limit = int32(limit)
+	}
+
+	result, err := h.annotationUseCase.ListAnnotations(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// SearchAnnotations godoc
+// @Summary Search annotations
+// @Description Find the annotations whose quote and note are most similar to a query
+// @Tags annotations
+// @Param query query string true "Search query"
+// @Success 200 {array} entity.BookmarkAnnotation
+// @Router /api/annotations/search [get]
+func (h *BookmarkHandler) SearchAnnotations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		http.Error(w, "Query is required", http.StatusBadRequest)
+		return
+	}
+
+	annotations, err := h.annotationUseCase.SearchAnnotations(ctx, query)
+	if err != nil {
+		if errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotations)
+}
+
+// GetAnnotation godoc
+// @Summary Get annotation
+// @Description Get an annotation, anchored in the current reader text of its bookmark
+// @Tags annotations
+// @Param annotationId path string true "Annotation ID"
+// @Success 200 {object} entity.BookmarkAnnotation
+// @Router /api/annotations/{annotationId} [get]
+func (h *BookmarkHandler) GetAnnotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	annotationIDStr := chi.URLParam(r, "annotationId")
+
+	annotationID, err := uuid.Parse(annotationIDStr)
+	if err != nil {
+		http.Error(w, "Invalid annotation ID", http.StatusBadRequest)
+		return
+	}
+
+	annotation, err := h.annotationUseCase.GetAnnotation(ctx, annotationID)
+	if err != nil {
+		annotationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// UpdateAnnotation godoc
+// @Summary Update annotation
+// @Description Change the note or tags of an annotation, or move its highlight by giving a new quote or position range
+// @Tags annotations
+// @Param annotationId path string true "Annotation ID"
+// @Param input body entity.UpdateAnnotationInput true "Changes"
+// @Success 200 {object} entity.BookmarkAnnotation
+// @Router /api/annotations/{annotationId} [put]
+func (h *BookmarkHandler) UpdateAnnotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	annotationIDStr := chi.URLParam(r, "annotationId")
+
+	annotationID, err := uuid.Parse(annotationIDStr)
+	if err != nil {
+		http.Error(w, "Invalid annotation ID", http.StatusBadRequest)
+		return
+	}
+
+	var input entity.UpdateAnnotationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	annotation, err := h.annotationUseCase.UpdateAnnotation(ctx, annotationID, input)
+	if err != nil {
+		annotationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// DeleteAnnotation godoc
+// @Summary Delete annotation
+// @Description Delete an annotation with its tags and entity references
+// @Tags annotations
+// @Param annotationId path string true "Annotation ID"
+// @Success 204 "No Content"
+// @Router /api/annotations/{annotationId} [delete]
+func (h *BookmarkHandler) DeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	annotationIDStr := chi.URLParam(r, "annotationId")
+
+	annotationID, err := uuid.Parse(annotationIDStr)
+	if err != nil {
+		http.Error(w, "Invalid annotation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.annotationUseCase.DeleteAnnotation(ctx, annotationID); err != nil {
+		annotationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// annotationError writes the status matching an annotation error
+func annotationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrAnnotationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, entity.ErrInvalidAnnotation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, entity.ErrQuoteNotFound), errors.Is(err, entity.ErrNoReaderContent):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// snapshotPolicy keeps archived pages from loading anything, so a snapshot served from our origin
+// can neither run scripts nor reach the live site
+const snapshotPolicy = "default-src 'none'; img-src data:; style-src 'unsafe-inline' data:; font-src data:; media-src data:; sandbox"
+
+// ArchiveBookmark godoc
+// @Summary Archive page
+// @Description Download the subresources of the fetched page and store it as a self-contained HTML snapshot and a WARC file
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {object} entity.BookmarkArchive
+// @Success 204 "Archiving is disabled or the content is not HTML"
+// @Router /api/bookmarks/{id}/archive [post]
+func (h *BookmarkHandler) ArchiveBookmark(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.useCase.ArchiveBookmark(ctx, bookmarkID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrArchiveTooLarge), errors.Is(err, entity.ErrArchiveStoreFull):
+			http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetArchive godoc
+// @Summary Get archived page
+// @Description Serve the latest archive of a bookmark, either the self-contained HTML snapshot or the WARC file
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Param format query string false "html (default) or warc"
+// @Success 200 {file} file
+// @Router /api/bookmarks/{id}/archive [get]
+func (h *BookmarkHandler) GetArchive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	format := entity.ArchiveHTML
+	if formatStr := r.URL.Query().Get("format"); formatStr != "" {
+		format = entity.ArchiveFormat(formatStr)
+		if format != entity.ArchiveHTML && format != entity.ArchiveWARC {
+			http.Error(w, "Invalid archive format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	archive, content, size, err := h.useCase.OpenBookmarkArchive(ctx, bookmarkID, format)
+	if err != nil {
+		if errors.Is(err, entity.ErrArchiveNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	if format == entity.ArchiveWARC {
+		w.Header().Set("Content-Type", "application/warc")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.warc.gz"`, bookmarkID))
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", snapshotPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Last-Modified", archive.ArchivedAt.UTC().Format(http.TimeFormat))
+
+	if _, err := io.Copy(w, content); err != nil {
+		// Headers are already sent, so the error can only be logged
+		log.Printf("Failed to send archive of bookmark %s: %v", bookmarkID, err)
+	}
+}
+
+// ListArchives godoc
+// @Summary List archives
+// @Description Get the stored archives of a bookmark, newest first
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {array} entity.BookmarkArchive
+// @Router /api/bookmarks/{id}/archives [get]
+func (h *BookmarkHandler) ListArchives(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	archives, err := h.useCase.ListBookmarkArchives(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(archives)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// CreateEvaluationRequest represents the request body for evaluating a bookmark
+type CreateEvaluationRequest struct {
+	FailedFetch     *bool       `json:"failed_fetch"`
+	SummaryID       *uuid.UUID  `json:"summary_id"`
+	SummaryEval     *int32      `json:"summary_eval"`
+	SummaryComments *string     `json:"summary_comments"`
+	QuestionsEval   *int32      `json:"questions_eval"`
+	QuestionsIDs    []uuid.UUID `json:"questions_ids"`
+}
+
+// CreateEvaluation godoc
+// @Summary Evaluate bookmark
+// @Description Score a bookmark's summary and generated questions from 1 to 5, or flag a failed fetch. Without summary_id the latest summary is scored, without questions_ids the questions of the current prompt version. The strategy, model and prompt version of the scored content are recorded with the evaluation
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Param input body CreateEvaluationRequest true "Evaluation"
+// @Success 201 {object} entity.BookmarkEvaluation
+// @Router /api/bookmarks/{id}/evaluations [post]
+func (h *BookmarkHandler) CreateEvaluation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateEvaluationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	evaluation, err := h.useCase.CreateBookmarkEvaluation(ctx, bookmarkID, entity.CreateEvaluationInput{
+		FailedFetch:     req.FailedFetch,
+		SummaryID:       req.SummaryID,
+		SummaryEval:     req.SummaryEval,
+		SummaryComments: req.SummaryComments,
+		QuestionsEval:   req.QuestionsEval,
+		QuestionsIDs:    req.QuestionsIDs,
+	})
+	if err != nil {
+		evaluationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(evaluation)
+}
+
+// ListEvaluations godoc
+// @Summary List bookmark evaluations
+// @Description Get the evaluations of a bookmark, newest first
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {array} entity.BookmarkEvaluation
+// @Router /api/bookmarks/{id}/evaluations [get]
+func (h *BookmarkHandler) ListEvaluations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	evaluations, err := h.useCase.ListBookmarkEvaluations(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(evaluations)
+}
+
+// GetEvaluationReport godoc
+// @Summary Evaluation report
+// @Description Average summary and question scores by strategy, model and prompt version, to compare prompt changes over time
+// @Tags bookmarks
+// @Param since query string false "Only evaluations created at or after this time (RFC3339)"
+// @Param until query string false "Only evaluations created before this time (RFC3339)"
+// @Param period query string false "Split the averages by day, week or month"
+// @Success 200 {object} entity.EvaluationReport
+// @Router /api/bookmarks/evaluations/report [get]
+func (h *BookmarkHandler) GetEvaluationReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var filters entity.EvaluationReportFilters
+
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			http.Error(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		filters.Since = &since
+	}
+
+	if untilStr := r.URL.Query().Get("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			http.Error(w, "Invalid until", http.StatusBadRequest)
+			return
+		}
+		filters.Until = &until
+	}
+
+	if period := r.URL.Query().Get("period"); period != "" {
+		filters.Period = &period
+	}
+
+	report, err := h.useCase.GetEvaluationReport(ctx, filters)
+	if err != nil {
+		evaluationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// evaluationError writes the status matching an evaluation error
+func evaluationError(w http.ResponseWriter, err error) {
+	if errors.Is(err, entity.ErrInvalidEvaluation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// maxImportSize limits the size of an uploaded bookmark file
+const maxImportSize = 64 << 20
+
+// ImportBookmarks godoc
+// @Summary Import bookmarks
+// @Description Import a bookmark file, sent either as the raw request body or as the "file" field of a multipart form
+// @Tags bookmarks
+// @Param format query string false "File format: netscape, pocket-html, pocket-csv, pinboard, raindrop or wallabag" default(netscape)
+// @Param source query string false "Source recorded on each imported bookmark"
+// @Param resume query string false "ID of an interrupted import of the same file to continue"
+// @Success 200 {object} entity.ImportResult
+// @Router /api/bookmarks/import [post]
+func (h *BookmarkHandler) ImportBookmarks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	format := entity.ImportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = entity.ImportFormatNetscape
+	}
+	source := r.URL.Query().Get("source")
+
+	var resumeID *uuid.UUID
+	if resume := r.URL.Query().Get("resume"); resume != "" {
+		id, err := uuid.Parse(resume)
+		if err != nil {
+			http.Error(w, "Invalid import ID", http.StatusBadRequest)
+			return
+		}
+		resumeID = &id
+	}
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+		if source == "" {
+			source = string(format) + ":" + header.Filename
+		}
+	}
+
+	result, err := h.importUseCase.ImportBookmarks(ctx, body, entity.ImportBookmarksInput{
+		Format:         format,
+		Source:         source,
+		ResumeImportID: resumeID,
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrUnsupportedImportFormat) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, entity.ErrImportNotResumable) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetImport godoc
+// @Summary Get import progress
+// @Description Get the counters, report and status of a bookmark import
+// @Tags bookmarks
+// @Param importId path string true "Import ID"
+// @Success 200 {object} entity.ImportResult
+// @Router /api/bookmarks/imports/{importId} [get]
+func (h *BookmarkHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	importID, err := uuid.Parse(chi.URLParam(r, "importId"))
+	if err != nil {
+		http.Error(w, "Invalid import ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.importUseCase.GetImport(ctx, importID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		http.Error(w, "Import not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ExportBookmarks godoc
+// @Summary Export bookmarks
+// @Description Export every bookmark grouped by category as a Netscape bookmarks.html file
+// @Tags bookmarks
+// @Produce html
+// @Success 200 {file} file
+// @Router /api/bookmarks/export [get]
+func (h *BookmarkHandler) ExportBookmarks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="bookmarks.html"`)
+
+	if err := h.importUseCase.ExportBookmarks(ctx, w); err != nil {
+		// Headers may already be sent, so the error can only be logged
+		log.Printf("Failed to export bookmarks: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// ListLinkIssues godoc
+// @Summary List dead or changed links
+// @Description Get bookmarks whose latest link check found the page gone, parked, redirected to another site, changed or failing
+// @Tags bookmarks
+// @Param status query string false "Only issues with this status (changed, redirected, parked, gone, error)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} input.PaginatedResponse[entity.LinkIssue]
+// @Router /api/bookmarks/link-issues [get]
+func (h *BookmarkHandler) ListLinkIssues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters := entity.LinkIssueFilters{
+		Page:  1,
+		Limit: 10,
+	}
+
+	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
+		if !entity.IsLinkStatus(statusStr) || statusStr == string(entity.LinkOK) {
+			http.Error(w, "Invalid link status", http.StatusBadRequest)
+			return
+		}
+		status := entity.LinkStatus(statusStr)
+		filters.Status = &status
+	}
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		filters.Page = int32(page)
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filters.Limit = int32(limit)
+	}
+
+	result, err := h.linkCheckUseCase.ListLinkIssues(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CheckLink godoc
+// @Summary Check bookmark link
+// @Description Re-fetch the bookmarked URL now, recording its status, redirects and changes to its readable text
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {object} entity.LinkCheck
+// @Router /api/bookmarks/{id}/link-check [post]
+func (h *BookmarkHandler) CheckLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.linkCheckUseCase.CheckBookmarkLink(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ListRejectedQuestions godoc
+// @Summary List bookmarks with rejected questions
+// @Description Get bookmarks whose Q&A pairs were downvoted, trashed or deleted and have not been replaced yet, oldest rejection first
+// @Tags bookmarks
+// @Param limit query int false "Number of bookmarks (default 20)"
+// @Success 200 {array} entity.QARegenerationCandidate
+// @Router /api/bookmarks/questions/rejected [get]
+func (h *BookmarkHandler) ListRejectedQuestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit := int32(20)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = int32(l)
+	}
+
+	candidates, err := h.useCase.GetRejectedQuestionBookmarks(ctx, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(candidates)
+}
+
+// RegenerateQuestions godoc
+// @Summary Regenerate rejected questions
+// @Description Replace the rejected Q&A pairs of a bookmark with new ones generated using the rejected pairs as negative examples, recorded as a qa-regeneration observation linked to the feedback. Returns 204 when nothing was rejected
+// @Tags bookmarks
+// @Param id path string true "Bookmark ID"
+// @Success 200 {object} entity.QARegenerationResult
+// @Success 204
+// @Router /api/bookmarks/{id}/questions/regenerate [post]
+func (h *BookmarkHandler) RegenerateQuestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bookmarkIDStr := chi.URLParam(r, "id")
+
+	bookmarkID, err := uuid.Parse(bookmarkIDStr)
+	if err != nil {
+		http.Error(w, "Invalid bookmark ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.useCase.RegenerateRejectedQuestions(ctx, bookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -12,12 +13,14 @@ import (
 )
 
 type CategoryHandler struct {
-	useCase input.CategoryUseCase
+	useCase               input.CategoryUseCase
+	categorizationUseCase input.BookmarkCategorizationUseCase
 }
 
-func NewCategoryHandler(useCase input.CategoryUseCase) *CategoryHandler {
+func NewCategoryHandler(useCase input.CategoryUseCase, categorizationUseCase input.BookmarkCategorizationUseCase) *CategoryHandler {
 	return &CategoryHandler{
-		useCase: useCase,
+		useCase:               useCase,
+		categorizationUseCase: categorizationUseCase,
 	}
 }
 
@@ -25,10 +28,17 @@ func (h *CategoryHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/categories", func(r chi.Router) {
 		r.Get("/", h.ListCategories)
 		r.Post("/merge", h.MergeCategories)
+		r.Post("/profiles/refresh", h.RefreshProfiles)
+		r.Get("/suggestions", h.ListSuggestions)
+		r.Post("/suggestions", h.SuggestCategory)
+		r.Post("/suggestions/{id}/accept", h.AcceptSuggestion)
+		r.Post("/suggestions/{id}/reject", h.RejectSuggestion)
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.GetCategory)
 			r.Put("/", h.UpdateCategory)
+			r.Put("/parent", h.MoveCategory)
+			r.Post("/reparent", h.ReparentChildren)
 			r.Post("/sources", h.CreateSource)
 		})
 
@@ -47,17 +57,21 @@ type CategorySourceResponse struct {
 	RawSource  json.RawMessage `json:"raw_source"`
 }
 
-// CategoryResponse represents a category with sources
+// CategoryResponse represents a category with sources and the bookmarks filed under it and its subtree
 type CategoryResponse struct {
-	CategoryID uuid.UUID                `json:"category_id"`
-	Name       string                   `json:"name"`
-	Sources    []CategorySourceResponse `json:"sources"`
+	CategoryID           uuid.UUID                `json:"category_id"`
+	Name                 string                   `json:"name"`
+	ParentID             *uuid.UUID               `json:"parent_id"`
+	BookmarkCount        int64                    `json:"bookmark_count"`
+	SubtreeBookmarkCount int64                    `json:"subtree_bookmark_count"`
+	Sources              []CategorySourceResponse `json:"sources"`
 }
 
 // SimpleCategoryResponse represents a category without sources
 type SimpleCategoryResponse struct {
-	CategoryID uuid.UUID `json:"category_id"`
-	Name       string    `json:"name"`
+	CategoryID uuid.UUID  `json:"category_id"`
+	Name       string     `json:"name"`
+	ParentID   *uuid.UUID `json:"parent_id"`
 }
 
 // CategoriesListResponse represents the API response for category list with pagination
@@ -76,7 +90,7 @@ type PaginationResponse struct {
 
 // ListCategories godoc
 // @Summary List all categories
-// @Description Get all categories with their sources
+// @Description Get all categories with their sources, parents, and the number of bookmarks filed directly under each and under its whole subtree. Parents are listed before their children
 // @Tags categories
 // @Success 200 {object} CategoriesListResponse
 // @Router /api/categories [get]
@@ -102,9 +116,12 @@ func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request)
 		}
 
 		data[i] = CategoryResponse{
-			CategoryID: cat.Category.CategoryID,
-			Name:       cat.Category.Name,
-			Sources:    sources,
+			CategoryID:           cat.Category.CategoryID,
+			Name:                 cat.Category.Name,
+			ParentID:             cat.Category.ParentID,
+			BookmarkCount:        cat.BookmarkCount,
+			SubtreeBookmarkCount: cat.SubtreeBookmarkCount,
+			Sources:              sources,
 		}
 	}
 
@@ -148,6 +165,7 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	response := SimpleCategoryResponse{
 		CategoryID: category.CategoryID,
 		Name:       category.Name,
+		ParentID:   category.ParentID,
 	}
 
 	httpAdapter.JSON(w, http.StatusOK, response)
@@ -198,7 +216,7 @@ type MergeCategoriesRequest struct {
 
 // MergeCategories godoc
 // @Summary Merge categories
-// @Description Merge two categories together
+// @Description Merge two categories together. The children of the source move under the target
 // @Tags categories
 // @Param body body MergeCategoriesRequest true "Merge request data"
 // @Success 204
@@ -225,6 +243,100 @@ func (h *CategoryHandler) MergeCategories(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// MoveCategoryRequest represents the request body for moving a category, a null parent_id means the top level
+type MoveCategoryRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// MoveCategory godoc
+// @Summary Move category
+// @Description Move a category with its subtree under another category, or to the top level when parent_id is null. A category cannot be moved under itself or one of its descendants
+// @Tags categories
+// @Param id path string true "Category ID"
+// @Param body body MoveCategoryRequest true "New parent"
+// @Success 204
+// @Failure 404 {string} string "Category or parent not found"
+// @Failure 409 {string} string "Parent lies within the category's subtree"
+// @Router /api/categories/{id}/parent [put]
+func (h *CategoryHandler) MoveCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	categoryIDStr := chi.URLParam(r, "id")
+
+	categoryID, err := uuid.Parse(categoryIDStr)
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.useCase.MoveCategory(ctx, categoryID, entity.MoveCategoryInput{ParentID: req.ParentID}); err != nil {
+		writeCategoryMoveError(w, err, "Failed to move category")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReparentChildrenResponse lists the categories moved by a reparent
+type ReparentChildrenResponse struct {
+	Moved []uuid.UUID `json:"moved"`
+}
+
+// ReparentChildren godoc
+// @Summary Reparent category children
+// @Description Move the children of a category, with their subtrees, under another category, or to the top level when parent_id is null. The new parent cannot lie within the category's subtree
+// @Tags categories
+// @Param id path string true "Category ID"
+// @Param body body MoveCategoryRequest true "New parent of the children"
+// @Success 200 {object} ReparentChildrenResponse
+// @Failure 404 {string} string "Category or parent not found"
+// @Failure 409 {string} string "Parent lies within the category's subtree"
+// @Router /api/categories/{id}/reparent [post]
+func (h *CategoryHandler) ReparentChildren(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	categoryIDStr := chi.URLParam(r, "id")
+
+	categoryID, err := uuid.Parse(categoryIDStr)
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	moved, err := h.useCase.ReparentCategoryChildren(ctx, categoryID, entity.MoveCategoryInput{ParentID: req.ParentID})
+	if err != nil {
+		writeCategoryMoveError(w, err, "Failed to reparent category children")
+		return
+	}
+	if moved == nil {
+		moved = []uuid.UUID{}
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, ReparentChildrenResponse{Moved: moved})
+}
+
+// writeCategoryMoveError maps the errors of moving categories to status codes
+func writeCategoryMoveError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, entity.ErrCategoryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, entity.ErrCategoryCycle):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
 // CreateSourceRequest represents the request body for creating a category source
 type CreateSourceRequest struct {
 	SourceURI *string         `json:"source_uri"`
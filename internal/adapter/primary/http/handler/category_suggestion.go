@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	httpAdapter "garden3/internal/adapter/primary/http"
+	"garden3/internal/domain/entity"
+)
+
+// SuggestCategoryRequest represents the request body for suggesting a bookmark category
+type SuggestCategoryRequest struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+}
+
+// RefreshProfiles godoc
+// @Summary Refresh category profiles
+// @Description Rebuild the profile of every category from the summary embeddings of its bookmarks and the embedding of its name and sources. The categorization worker does this before every batch
+// @Tags categories
+// @Success 200 {object} entity.CategoryProfileRefresh
+// @Router /api/categories/profiles/refresh [post]
+func (h *CategoryHandler) RefreshProfiles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := h.categorizationUseCase.RefreshCategoryProfiles(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, result)
+}
+
+// SuggestCategory godoc
+// @Summary Suggest bookmark category
+// @Description Suggest the category whose profile is closest to the bookmark's summary. Uncategorized bookmarks are assigned the category when the confidence reaches the threshold; other suggestions wait for review. Returns 204 when the bookmark has no summary or no category has a profile
+// @Tags categories
+// @Param body body SuggestCategoryRequest true "Bookmark to categorize"
+// @Success 201 {object} entity.CategorySuggestion
+// @Success 204
+// @Router /api/categories/suggestions [post]
+func (h *CategoryHandler) SuggestCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req SuggestCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.BookmarkID == uuid.Nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	suggestion, err := h.categorizationUseCase.SuggestBookmarkCategory(ctx, req.BookmarkID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if suggestion == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusCreated, suggestion)
+}
+
+// ListSuggestions godoc
+// @Summary List category suggestions
+// @Description Get category suggestions with a status, newest first. Pending suggestions are those below the auto-assign threshold, waiting to be accepted or rejected
+// @Tags categories
+// @Param status query string false "Suggestion status (pending, accepted, rejected, auto), default pending"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} input.PaginatedResponse[entity.CategorySuggestion]
+// @Router /api/categories/suggestions [get]
+func (h *CategoryHandler) ListSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters := entity.CategorySuggestionFilters{
+		Status: entity.SuggestionPending,
+		Page:   1,
+		Limit:  10,
+	}
+
+	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
+		if !entity.IsSuggestionStatus(statusStr) {
+			http.Error(w, "Invalid suggestion status", http.StatusBadRequest)
+			return
+		}
+		filters.Status = entity.SuggestionStatus(statusStr)
+	}
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		filters.Page = int32(page)
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filters.Limit = int32(limit)
+	}
+
+	result, err := h.categorizationUseCase.ListCategorySuggestions(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, result)
+}
+
+// AcceptSuggestion godoc
+// @Summary Accept category suggestion
+// @Description Assign the suggested category to the bookmark and add the bookmark to the category profile
+// @Tags categories
+// @Param id path string true "Suggestion ID"
+// @Success 200 {object} entity.CategorySuggestion
+// @Router /api/categories/suggestions/{id}/accept [post]
+func (h *CategoryHandler) AcceptSuggestion(w http.ResponseWriter, r *http.Request) {
+	h.reviewSuggestion(w, r, true)
+}
+
+// RejectSuggestion godoc
+// @Summary Reject category suggestion
+// @Description Reject a suggested category; it is not suggested for the bookmark again and the next closest category is suggested instead
+// @Tags categories
+// @Param id path string true "Suggestion ID"
+// @Success 200 {object} entity.CategorySuggestion
+// @Router /api/categories/suggestions/{id}/reject [post]
+func (h *CategoryHandler) RejectSuggestion(w http.ResponseWriter, r *http.Request) {
+	h.reviewSuggestion(w, r, false)
+}
+
+func (h *CategoryHandler) reviewSuggestion(w http.ResponseWriter, r *http.Request, accept bool) {
+	ctx := r.Context()
+	suggestionIDStr := chi.URLParam(r, "id")
+
+	suggestionID, err := uuid.Parse(suggestionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid suggestion ID", http.StatusBadRequest)
+		return
+	}
+
+	suggestion, err := h.categorizationUseCase.ReviewCategorySuggestion(ctx, suggestionID, accept)
+	if err != nil {
+		if errors.Is(err, entity.ErrSuggestionReviewed) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if suggestion == nil {
+		http.Error(w, "Suggestion not found", http.StatusNotFound)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, suggestion)
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	httpAdapter "garden3/internal/adapter/primary/http"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+)
+
+type EmbeddingHandler struct {
+	useCase      input.EmbeddingModelUseCase
+	cacheUseCase input.EmbeddingCacheUseCase
+}
+
+func NewEmbeddingHandler(useCase input.EmbeddingModelUseCase, cacheUseCase input.EmbeddingCacheUseCase) *EmbeddingHandler {
+	return &EmbeddingHandler{
+		useCase:      useCase,
+		cacheUseCase: cacheUseCase,
+	}
+}
+
+func (h *EmbeddingHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/embeddings", func(r chi.Router) {
+		r.Get("/models", h.GetModelStatus)
+		r.Get("/migrations", h.ListMigrations)
+		r.Post("/migrations", h.StartMigration)
+		r.Get("/migrations/{id}", h.GetMigration)
+		r.Post("/migrations/{id}/cutover", h.CutoverMigration)
+		r.Post("/migrations/{id}/cancel", h.CancelMigration)
+		r.Get("/cache", h.GetCacheStats)
+		r.Post("/cache/evict", h.EvictCache)
+	})
+}
+
+// GetModelStatus godoc
+// @Summary Get embedding model status
+// @Description Get the model similarity searches compare against, the model new texts are embedded with, the stored vectors per table, model and dimensions, and the open migration
+// @Tags embeddings
+// @Success 200 {object} entity.EmbeddingModelStatus
+// @Router /api/embeddings/models [get]
+func (h *EmbeddingHandler) GetModelStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.useCase.GetEmbeddingModelStatus(r.Context())
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, status)
+}
+
+// ListMigrations godoc
+// @Summary List embedding migrations
+// @Description Get the latest embedding migrations, newest first
+// @Tags embeddings
+// @Param limit query int false "Number of migrations, default 20"
+// @Success 200 {array} entity.EmbeddingMigration
+// @Router /api/embeddings/migrations [get]
+func (h *EmbeddingHandler) ListMigrations(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			httpAdapter.BadRequest(w, errors.New("invalid limit"))
+			return
+		}
+	}
+
+	migrations, err := h.useCase.ListEmbeddingMigrations(r.Context(), int32(limit))
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, migrations)
+}
+
+type StartEmbeddingMigrationRequest struct {
+	Provider   string `json:"provider"`
+	URL        string `json:"url"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	APIKey     string `json:"api_key"`
+}
+
+// StartMigration godoc
+// @Summary Start embedding migration
+// @Description Re-embed the corpus with another model. The model is checked with a test embedding first. The migration worker stages the new vectors next to the stored ones, which keep serving searches until the cutover. Only one migration can be open at a time
+// @Tags embeddings
+// @Accept json
+// @Param body body StartEmbeddingMigrationRequest true "Target model; empty fields take the provider defaults"
+// @Success 201 {object} entity.EmbeddingMigration
+// @Failure 400 {string} string "Invalid model"
+// @Failure 409 {string} string "A migration is already open"
+// @Router /api/embeddings/migrations [post]
+func (h *EmbeddingHandler) StartMigration(w http.ResponseWriter, r *http.Request) {
+	var req StartEmbeddingMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpAdapter.BadRequest(w, err)
+		return
+	}
+
+	migration, err := h.useCase.StartEmbeddingMigration(r.Context(), entity.EmbeddingModelConfig{
+		Provider:   req.Provider,
+		URL:        req.URL,
+		Model:      req.Model,
+		Dimensions: req.Dimensions,
+		APIKey:     req.APIKey,
+	})
+	if err != nil {
+		writeEmbeddingMigrationError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusCreated, migration)
+}
+
+// GetMigration godoc
+// @Summary Get embedding migration
+// @Description Get the progress of an embedding migration, with the estimated time left while it runs
+// @Tags embeddings
+// @Param id path string true "Migration ID"
+// @Success 200 {object} entity.EmbeddingMigration
+// @Router /api/embeddings/migrations/{id} [get]
+func (h *EmbeddingHandler) GetMigration(w http.ResponseWriter, r *http.Request) {
+	migrationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpAdapter.BadRequest(w, errors.New("invalid migration ID"))
+		return
+	}
+
+	migration, err := h.useCase.GetEmbeddingMigration(r.Context(), migrationID)
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+	if migration == nil {
+		httpAdapter.NotFound(w)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, migration)
+}
+
+// CutoverMigration godoc
+// @Summary Cut over embedding migration
+// @Description Embed the texts changed since the migration got ready, then swap the staged vectors in, make the target model the one searches compare against and embed new texts with it
+// @Tags embeddings
+// @Param id path string true "Migration ID"
+// @Success 200 {object} entity.EmbeddingCutover
+// @Failure 404 {string} string "Migration not found"
+// @Failure 409 {string} string "Migration not ready or already finished"
+// @Router /api/embeddings/migrations/{id}/cutover [post]
+func (h *EmbeddingHandler) CutoverMigration(w http.ResponseWriter, r *http.Request) {
+	migrationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpAdapter.BadRequest(w, errors.New("invalid migration ID"))
+		return
+	}
+
+	cutover, err := h.useCase.CutoverEmbeddingMigration(r.Context(), migrationID)
+	if err != nil {
+		writeEmbeddingMigrationError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, cutover)
+}
+
+// CancelMigration godoc
+// @Summary Cancel embedding migration
+// @Description Stop an open migration and drop its staged vectors. Searches keep using the stored vectors
+// @Tags embeddings
+// @Param id path string true "Migration ID"
+// @Success 200 {object} entity.EmbeddingMigration
+// @Failure 404 {string} string "Migration not found"
+// @Failure 409 {string} string "Migration already finished"
+// @Router /api/embeddings/migrations/{id}/cancel [post]
+func (h *EmbeddingHandler) CancelMigration(w http.ResponseWriter, r *http.Request) {
+	migrationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpAdapter.BadRequest(w, errors.New("invalid migration ID"))
+		return
+	}
+
+	migration, err := h.useCase.CancelEmbeddingMigration(r.Context(), migrationID)
+	if err != nil {
+		writeEmbeddingMigrationError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, migration)
+}
+
+// GetCacheStats godoc
+// @Summary Get embedding cache statistics
+// @Description Get the hits, misses and hit rate of the embedding cache since the server started, the vectors it evicted and the number, hits and oldest use of the cached vectors
+// @Tags embeddings
+// @Success 200 {object} entity.EmbeddingCacheStats
+// @Router /api/embeddings/cache [get]
+func (h *EmbeddingHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.cacheUseCase.GetEmbeddingCacheStats(r.Context())
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, stats)
+}
+
+// EvictCache godoc
+// @Summary Evict embedding cache
+// @Description Remove the cached vectors unused for longer than EMBED_CACHE_MAX_AGE, then the least recently used ones beyond EMBED_CACHE_MAX_ENTRIES, without waiting for the eviction worker
+// @Tags embeddings
+// @Success 200 {object} entity.EmbeddingCacheEviction
+// @Router /api/embeddings/cache/evict [post]
+func (h *EmbeddingHandler) EvictCache(w http.ResponseWriter, r *http.Request) {
+	eviction, err := h.cacheUseCase.EvictEmbeddingCache(r.Context())
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, eviction)
+}
+
+func writeEmbeddingMigrationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrInvalidEmbeddingModel):
+		httpAdapter.BadRequest(w, err)
+	case errors.Is(err, entity.ErrEmbeddingMigrationNotFound):
+		httpAdapter.NotFound(w)
+	case errors.Is(err, entity.ErrEmbeddingMigrationOpen),
+		errors.Is(err, entity.ErrEmbeddingMigrationFinished),
+		errors.Is(err, entity.ErrEmbeddingMigrationNotReady):
+		httpAdapter.Error(w, http.StatusConflict, err)
+	default:
+		httpAdapter.InternalError(w, err)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	httpAdapter "garden3/internal/adapter/primary/http"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+)
+
+// maxCookieFileSize limits the size of an uploaded cookies.txt file
+const maxCookieFileSize = 4 << 20
+
+type FetchProfileHandler struct {
+	useCase input.FetchProfileUseCase
+}
+
+func NewFetchProfileHandler(useCase input.FetchProfileUseCase) *FetchProfileHandler {
+	return &FetchProfileHandler{
+		useCase: useCase,
+	}
+}
+
+func (h *FetchProfileHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/fetch-profiles", func(r chi.Router) {
+		r.Get("/", h.ListFetchProfiles)
+		r.Get("/{domain}", h.GetFetchProfile)
+		r.Put("/{domain}", h.SaveFetchProfile)
+		r.Delete("/{domain}", h.DeleteFetchProfile)
+		r.Post("/{domain}/cookies", h.ImportCookies)
+	})
+}
+
+// ListFetchProfiles godoc
+// @Summary List fetch profiles
+// @Description Get the per-domain fetch profiles, with cookie values left out
+// @Tags fetch-profiles
+// @Success 200 {array} entity.FetchProfile
+// @Router /api/fetch-profiles [get]
+func (h *FetchProfileHandler) ListFetchProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.useCase.ListFetchProfiles(r.Context())
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, profiles)
+}
+
+// GetFetchProfile godoc
+// @Summary Get fetch profile
+// @Description Get the fetch profile of a domain, with cookie values left out
+// @Tags fetch-profiles
+// @Param domain path string true "Domain, e.g. medium.com"
+// @Success 200 {object} entity.FetchProfile
+// @Router /api/fetch-profiles/{domain} [get]
+func (h *FetchProfileHandler) GetFetchProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.useCase.GetFetchProfile(r.Context(), chi.URLParam(r, "domain"))
+	if err != nil {
+		writeFetchProfileError(w, err)
+		return
+	}
+	if profile == nil {
+		httpAdapter.NotFound(w)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, profile)
+}
+
+type SaveFetchProfileRequest struct {
+	UserAgent *string           `json:"user_agent"`
+	Headers   map[string]string `json:"headers"`
+	TimeoutMs *int32            `json:"timeout_ms"`
+	Proxy     *string           `json:"proxy"`
+}
+
+// SaveFetchProfile godoc
+// @Summary Save fetch profile
+// @Description Create or replace the User-Agent, extra headers, timeout and proxy used when fetching a domain and its subdomains. Imported cookies are kept
+// @Tags fetch-profiles
+// @Accept json
+// @Param domain path string true "Domain, e.g. medium.com"
+// @Param body body SaveFetchProfileRequest true "Profile settings"
+// @Success 200 {object} entity.FetchProfile
+// @Router /api/fetch-profiles/{domain} [put]
+func (h *FetchProfileHandler) SaveFetchProfile(w http.ResponseWriter, r *http.Request) {
+	var req SaveFetchProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpAdapter.BadRequest(w, err)
+		return
+	}
+
+	profile, err := h.useCase.SaveFetchProfile(r.Context(), chi.URLParam(r, "domain"), entity.FetchProfileInput{
+		UserAgent: req.UserAgent,
+		Headers:   req.Headers,
+		TimeoutMs: req.TimeoutMs,
+		Proxy:     req.Proxy,
+	})
+	if err != nil {
+		writeFetchProfileError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, profile)
+}
+
+// DeleteFetchProfile godoc
+// @Summary Delete fetch profile
+// @Description Delete the fetch profile of a domain, including its cookies
+// @Tags fetch-profiles
+// @Param domain path string true "Domain, e.g. medium.com"
+// @Success 204
+// @Router /api/fetch-profiles/{domain} [delete]
+func (h *FetchProfileHandler) DeleteFetchProfile(w http.ResponseWriter, r *http.Request) {
+	if err := h.useCase.DeleteFetchProfile(r.Context(), chi.URLParam(r, "domain")); err != nil {
+		writeFetchProfileError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportCookies godoc
+// @Summary Import fetch profile cookies
+// @Description Replace the cookies of a domain's fetch profile with those of a Netscape cookies.txt file that apply to the domain, sent either as the raw request body or as the "file" field of a multipart form. The profile is created if needed
+// @Tags fetch-profiles
+// @Param domain path string true "Domain, e.g. medium.com"
+// @Success 200 {object} entity.CookieImportResult
+// @Router /api/fetch-profiles/{domain}/cookies [post]
+func (h *FetchProfileHandler) ImportCookies(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCookieFileSize)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			httpAdapter.BadRequest(w, errors.New("missing file"))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.useCase.ImportFetchProfileCookies(r.Context(), chi.URLParam(r, "domain"), body)
+	if err != nil {
+		writeFetchProfileError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, result)
+}
+
+func writeFetchProfileError(w http.ResponseWriter, err error) {
+	if errors.Is(err, entity.ErrInvalidFetchProfile) || errors.Is(err, entity.ErrInvalidCookieFile) {
+		httpAdapter.BadRequest(w, err)
+		return
+	}
+	httpAdapter.InternalError(w, err)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -329,6 +330,10 @@ func (h *NoteHandler) SearchNotes(w http.ResponseWriter, r *http.Request) {
 
 	results, err := h.useCase.SearchSimilarNotes(ctx, query, strategy)
 	if err != nil {
+		if errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
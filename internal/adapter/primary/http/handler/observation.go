@@ -12,18 +12,22 @@ import (
 )
 
 type ObservationHandler struct {
-	useCase input.ObservationUseCase
+	useCase           input.ObservationUseCase
+	evaluationUseCase input.RetrievalEvaluationUseCase
 }
 
-func NewObservationHandler(useCase input.ObservationUseCase) *ObservationHandler {
+func NewObservationHandler(useCase input.ObservationUseCase, evaluationUseCase input.RetrievalEvaluationUseCase) *ObservationHandler {
 	return &ObservationHandler{
-		useCase: useCase,
+		useCase:           useCase,
+		evaluationUseCase: evaluationUseCase,
 	}
 }
 
 func (h *ObservationHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/observations", func(r chi.Router) {
 		r.Post("/feedback", h.StoreFeedback)
+		r.Post("/retrieval-evaluations", h.EvaluateRetrieval)
+		r.Get("/retrieval-evaluations", h.ListRetrievalEvaluations)
 	})
 	r.Route("/api/bookmarks/{id}/feedback", func(r chi.Router) {
 		r.Get("/", h.GetFeedbackStats)
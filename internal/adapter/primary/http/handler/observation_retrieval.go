@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	httpAdapter "garden3/internal/adapter/primary/http"
+	"garden3/internal/domain/entity"
+)
+
+// RetrievalEvaluationRequest represents the request body for a retrieval evaluation. Weights that are
+// not given keep their default
+type RetrievalEvaluationRequest struct {
+	Strategy         string   `json:"strategy"`
+	K                int      `json:"k"`
+	MaxQueries       int      `json:"max_queries"`
+	ExactMatchWeight *float64 `json:"exact_match_weight"`
+	SimilarityWeight *float64 `json:"similarity_weight"`
+	RecencyWeight    *float64 `json:"recency_weight"`
+}
+
+// EvaluateRetrieval godoc
+// @Summary Evaluate retrieval
+// @Description Replay the user questions recorded with Q&A feedback against the current embedding model, strategy and search weights, score the rankings against the upvoted bookmarks with recall@k, MRR and nDCG, and store the report as a retrieval-eval observation
+// @Tags observations
+// @Param input body RetrievalEvaluationRequest false "Evaluation settings"
+// @Success 201 {object} entity.RetrievalEvaluationReport
+// @Router /api/observations/retrieval-evaluations [post]
+func (h *ObservationHandler) EvaluateRetrieval(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RetrievalEvaluationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpAdapter.BadRequest(w, err)
+			return
+		}
+	}
+
+	input := entity.RetrievalEvaluationInput{
+		Strategy:   req.Strategy,
+		K:          req.K,
+		MaxQueries: req.MaxQueries,
+	}
+	if req.ExactMatchWeight != nil || req.SimilarityWeight != nil || req.RecencyWeight != nil {
+		weights := entity.DefaultSearchWeights()
+		if req.ExactMatchWeight != nil {
+			weights.ExactMatchWeight = *req.ExactMatchWeight
+		}
+		if req.SimilarityWeight != nil {
+			weights.SimilarityWeight = *req.SimilarityWeight
+		}
+		if req.RecencyWeight != nil {
+			weights.RecencyWeight = *req.RecencyWeight
+		}
+		input.Weights = &weights
+	}
+
+	report, err := h.evaluationUseCase.EvaluateRetrieval(ctx, input)
+	if err != nil {
+		if errors.Is(err, entity.ErrNoFeedbackQueries) {
+			httpAdapter.Error(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+			httpAdapter.Error(w, http.StatusConflict, err)
+			return
+		}
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusCreated, report)
+}
+
+// ListRetrievalEvaluations godoc
+// @Summary List retrieval evaluations
+// @Description Get the stored retrieval evaluation reports, newest first, to compare embedding model, chunker and weight changes
+// @Tags observations
+// @Param limit query int false "Number of reports (default 20)"
+// @Success 200 {array} entity.RetrievalEvaluationReport
+// @Router /api/observations/retrieval-evaluations [get]
+func (h *ObservationHandler) ListRetrievalEvaluations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit := int32(20)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			httpAdapter.BadRequest(w, errors.New("invalid limit"))
+			return
+		}
+		limit = int32(l)
+	}
+
+	reports, err := h.evaluationUseCase.ListRetrievalEvaluations(ctx, limit)
+	if err != nil {
+		httpAdapter.InternalError(w, err)
+		return
+	}
+
+	httpAdapter.JSON(w, http.StatusOK, reports)
+}
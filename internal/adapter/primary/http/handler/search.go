@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -149,6 +150,10 @@ func (h *SearchHandler) AdvancedSearch(w http.ResponseWriter, r *http.Request) {
 	// Perform advanced search
 	result, err := h.useCase.AdvancedSearch(ctx, queryString)
 	if err != nil {
+		if errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+)
+
+const (
+	newBookmarkChannel = "new_bookmark"
+	maxListenBackoff   = time.Minute
+)
+
+// BookmarkPipelineWorker listens for new bookmarks and runs them through the ingestion pipeline
+type BookmarkPipelineWorker struct {
+	pool        *pgxpool.Pool
+	pipeline    input.BookmarkPipelineUseCase
+	concurrency int
+	jobs        chan entity.PipelineJob
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]bool
+	wg      sync.WaitGroup
+}
+
+// NewBookmarkPipelineWorker creates a new bookmark pipeline worker
+func NewBookmarkPipelineWorker(pool *pgxpool.Pool, pipeline input.BookmarkPipelineUseCase, concurrency int) *BookmarkPipelineWorker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &BookmarkPipelineWorker{
+		pool:        pool,
+		pipeline:    pipeline,
+		concurrency: concurrency,
+		jobs:        make(chan entity.PipelineJob, concurrency*4),
+		pending:     make(map[uuid.UUID]bool),
+	}
+}
+
+// Start launches the workers, the startup backfill and the LISTEN loop
+// It returns immediately; everything stops when ctx is cancelled
+func (w *BookmarkPipelineWorker) Start(ctx context.Context) {
+	for i := 0; i < w.concurrency; i++ {
+		w.wg.Add(1)
+		go w.work(ctx)
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.listen(ctx)
+	}()
+}
+
+// Wait blocks until all workers have stopped
+func (w *BookmarkPipelineWorker) Wait() {
+	w.wg.Wait()
+}
+
+func (w *BookmarkPipelineWorker) work(ctx context.Context) {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-w.jobs:
+			result, err := w.pipeline.ProcessBookmark(ctx, job.BookmarkID, job.From)
+			w.done(job.BookmarkID)
+
+			if err != nil {
+				log.Printf("Pipeline failed for bookmark %s: %v", job.BookmarkID, err)
+				continue
+			}
+			log.Printf("Pipeline completed for bookmark %s (%d stages)", job.BookmarkID, len(result.Stages))
+		}
+	}
+}
+
+// listen keeps a connection subscribed to new_bookmark, reconnecting with backoff on failure
+func (w *BookmarkPipelineWorker) listen(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		connectedAt := time.Now()
+		err := w.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(connectedAt) > maxListenBackoff {
+			backoff = time.Second
+		}
+		log.Printf("Bookmark listener disconnected: %v, retrying in %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxListenBackoff {
+			backoff = maxListenBackoff
+		}
+	}
+}
+
+func (w *BookmarkPipelineWorker) listenOnce(ctx context.Context) error {
+	conn, err := w.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection carries LISTEN state, so it must not go back to the pool
+	defer conn.Hijack().Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+newBookmarkChannel); err != nil {
+		return err
+	}
+	log.Printf("Listening for %s notifications", newBookmarkChannel)
+
+	// Backfill on every (re)connect so bookmarks inserted while disconnected are not missed
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.backfill(ctx)
+	}()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		job, err := notificationJob(notification.Payload)
+		if err != nil {
+			log.Printf("Ignoring malformed %s payload: %v", newBookmarkChannel, err)
+			continue
+		}
+		w.enqueue(ctx, job)
+	}
+}
+
+// notificationJob returns the job for a new_bookmark payload, which runs the whole pipeline
+func notificationJob(payload string) (entity.PipelineJob, error) {
+	var notification struct {
+		BookmarkID uuid.UUID `json:"bookmark_id"`
+	}
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		return entity.PipelineJob{}, err
+	}
+	if notification.BookmarkID == uuid.Nil {
+		return entity.PipelineJob{}, errors.New("missing bookmark_id")
+	}
+	return entity.PipelineJob{
+		BookmarkID: notification.BookmarkID,
+		From:       entity.StageFetch,
+	}, nil
+}
+
+func (w *BookmarkPipelineWorker) backfill(ctx context.Context) {
+	jobs, err := w.pipeline.GetBackfillJobs(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to load pipeline backfill: %v", err)
+		}
+		return
+	}
+	if len(jobs) > 0 {
+		log.Printf("Backfilling %d bookmarks", len(jobs))
+	}
+
+	for _, job := range jobs {
+		if !w.enqueue(ctx, job) && ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// enqueue queues a job unless the bookmark is already pending, blocking while the queue is full
+func (w *BookmarkPipelineWorker) enqueue(ctx context.Context, job entity.PipelineJob) bool {
+	w.mu.Lock()
+	if w.pending[job.BookmarkID] {
+		w.mu.Unlock()
+		return false
+	}
+	w.pending[job.BookmarkID] = true
+	w.mu.Unlock()
+
+	select {
+	case w.jobs <- job:
+		return true
+	case <-ctx.Done():
+		w.done(job.BookmarkID)
+		return false
+	}
+}
+
+func (w *BookmarkPipelineWorker) done(bookmarkID uuid.UUID) {
+	w.mu.Lock()
+	delete(w.pending, bookmarkID)
+	w.mu.Unlock()
+}
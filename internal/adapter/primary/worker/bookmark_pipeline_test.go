@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// recordingPipeline is a pipeline use case recording the jobs it runs and returning fixed backfill jobs
+type recordingPipeline struct {
+	backfill []entity.PipelineJob
+
+	mu   sync.Mutex
+	jobs []entity.PipelineJob
+	ran  chan struct{}
+}
+
+func (p *recordingPipeline) ProcessBookmark(ctx context.Context, bookmarkID uuid.UUID, from entity.PipelineStage) (*entity.PipelineResult, error) {
+	p.mu.Lock()
+	p.jobs = append(p.jobs, entity.PipelineJob{BookmarkID: bookmarkID, From: from})
+	p.mu.Unlock()
+	p.ran <- struct{}{}
+	return &entity.PipelineResult{BookmarkID: bookmarkID}, nil
+}
+
+func (p *recordingPipeline) GetBackfillJobs(ctx context.Context) ([]entity.PipelineJob, error) {
+	return p.backfill, nil
+}
+
+func TestNotificationJob(t *testing.T) {
+	bookmarkID := uuid.New()
+
+	testCases := []struct {
+		name    string
+		payload string
+		want    entity.PipelineJob
+		wantErr bool
+	}{
+		{
+			name:    "new bookmark",
+			payload: `{"bookmark_id": "` + bookmarkID.String() + `", "url": "https://example.com"}`,
+			want:    entity.PipelineJob{BookmarkID: bookmarkID, From: entity.StageFetch},
+		},
+		{name: "malformed", payload: `{"bookmark_id": 12}`, wantErr: true},
+		{name: "missing id", payload: `{"url": "https://example.com"}`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job, err := notificationJob(tc.payload)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("notificationJob() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if job != tc.want {
+				t.Errorf("notificationJob() = %+v, want %+v", job, tc.want)
+			}
+		})
+	}
+}
+
+func TestEnqueueSkipsPendingBookmarks(t *testing.T) {
+	w := NewBookmarkPipelineWorker(nil, &recordingPipeline{}, 1)
+	job := entity.PipelineJob{BookmarkID: uuid.New(), From: entity.StageFetch}
+
+	if !w.enqueue(context.Background(), job) {
+		t.Fatal("expected the first job to be queued")
+	}
+	if w.enqueue(context.Background(), entity.PipelineJob{BookmarkID: job.BookmarkID, From: entity.StageReader}) {
+		t.Error("expected a job for a pending bookmark to be dropped")
+	}
+
+	<-w.jobs
+	w.done(job.BookmarkID)
+	if !w.enqueue(context.Background(), job) {
+		t.Error("expected the bookmark to be queued again once done")
+	}
+}
+
+func TestBackfillRunsJobs(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	pipeline := &recordingPipeline{
+		backfill: []entity.PipelineJob{
+			{BookmarkID: first, From: entity.StageFetch},
+			{BookmarkID: second, From: entity.StageReader},
+		},
+		ran: make(chan struct{}),
+	}
+	w := NewBookmarkPipelineWorker(nil, pipeline, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < w.concurrency; i++ {
+		w.wg.Add(1)
+		go w.work(ctx)
+	}
+	w.backfill(ctx)
+
+	for range pipeline.backfill {
+		select {
+		case <-pipeline.ran:
+		case <-time.After(5 * time.Second):
+			t.Fatal("backfill jobs did not run")
+		}
+	}
+	cancel()
+	w.Wait()
+
+	ran := make(map[uuid.UUID]entity.PipelineStage)
+	for _, job := range pipeline.jobs {
+		ran[job.BookmarkID] = job.From
+	}
+	if len(ran) != 2 || ran[first] != entity.StageFetch || ran[second] != entity.StageReader {
+		t.Errorf("ran %+v, want the backfill jobs with their stages", pipeline.jobs)
+	}
+	if len(w.pending) != 0 {
+		t.Errorf("expected no pending bookmarks after the jobs ran, got %d", len(w.pending))
+	}
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+)
+
+// CategorizationWorker periodically refreshes the category profiles and suggests categories for new bookmarks
+type CategorizationWorker struct {
+	categorization input.BookmarkCategorizationUseCase
+	batchSize      int32
+
+	periodic
+}
+
+// NewCategorizationWorker creates a worker that categorizes up to batchSize bookmarks every poll
+func NewCategorizationWorker(categorization input.BookmarkCategorizationUseCase, poll time.Duration, batchSize int) *CategorizationWorker {
+	if poll <= 0 {
+		poll = 15 * time.Minute
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &CategorizationWorker{
+		categorization: categorization,
+		batchSize:      int32(batchSize),
+		periodic:       periodic{poll: poll},
+	}
+}
+
+// Start launches the categorization loop
+func (w *CategorizationWorker) Start(ctx context.Context) {
+	w.start(ctx, w.categorize)
+}
+
+// categorize refreshes the profiles, so categories assigned since the last poll count, then suggests a
+// category for one batch of bookmarks
+func (w *CategorizationWorker) categorize(ctx context.Context) {
+	if _, err := w.categorization.RefreshCategoryProfiles(ctx); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to refresh category profiles: %v", err)
+		}
+		return
+	}
+
+	bookmarkIDs, err := w.categorization.GetBookmarksToCategorize(ctx, w.batchSize)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to load bookmarks to categorize: %v", err)
+		}
+		return
+	}
+
+	for _, bookmarkID := range bookmarkIDs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		suggestion, err := w.categorization.SuggestBookmarkCategory(ctx, bookmarkID)
+		if err != nil {
+			log.Printf("Categorization failed for bookmark %s: %v", bookmarkID, err)
+			continue
+		}
+		if suggestion != nil && suggestion.Status == entity.SuggestionAuto {
+			log.Printf("Assigned bookmark %s to %s (confidence %.2f)", bookmarkID, suggestion.CategoryName, suggestion.Confidence)
+		}
+	}
+}
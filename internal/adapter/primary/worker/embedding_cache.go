@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"garden3/internal/port/input"
+)
+
+// EmbeddingCacheWorker keeps the embedding cache within its configured age and size
+type EmbeddingCacheWorker struct {
+	cache input.EmbeddingCacheUseCase
+
+	periodic
+}
+
+// NewEmbeddingCacheWorker creates a worker that evicts from the embedding cache every poll
+func NewEmbeddingCacheWorker(cache input.EmbeddingCacheUseCase, poll time.Duration) *EmbeddingCacheWorker {
+	if poll <= 0 {
+		poll = time.Hour
+	}
+	return &EmbeddingCacheWorker{
+		cache:    cache,
+		periodic: periodic{poll: poll},
+	}
+}
+
+// Start launches the eviction loop
+func (w *EmbeddingCacheWorker) Start(ctx context.Context) {
+	w.start(ctx, w.evict)
+}
+
+func (w *EmbeddingCacheWorker) evict(ctx context.Context) {
+	eviction, err := w.cache.EvictEmbeddingCache(ctx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Embedding cache eviction failed: %v", err)
+		}
+		return
+	}
+	if eviction.Unused > 0 || eviction.Excess > 0 {
+		log.Printf("Embedding cache evicted %d unused and %d excess vectors", eviction.Unused, eviction.Excess)
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+)
+
+// EmbeddingMigrationWorker re-embeds the corpus for the running embedding migration. Progress is kept in the
+// database, so a restarted worker resumes where the last one stopped
+type EmbeddingMigrationWorker struct {
+	models    input.EmbeddingModelUseCase
+	batchSize int32
+
+	periodic
+}
+
+// NewEmbeddingMigrationWorker creates a worker that checks for a running migration every poll and stages
+// batchSize rows at a time until none is left
+func NewEmbeddingMigrationWorker(models input.EmbeddingModelUseCase, poll time.Duration, batchSize int) *EmbeddingMigrationWorker {
+	if poll <= 0 {
+		poll = time.Minute
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &EmbeddingMigrationWorker{
+		models:    models,
+		batchSize: int32(batchSize),
+		periodic:  periodic{poll: poll},
+	}
+}
+
+// Start launches the migration loop
+func (w *EmbeddingMigrationWorker) Start(ctx context.Context) {
+	w.start(ctx, w.migrate)
+}
+
+// migrate stages batches back to back until the running migration is ready, fails or is gone. A failed
+// batch is retried on the next poll
+func (w *EmbeddingMigrationWorker) migrate(ctx context.Context) {
+	for ctx.Err() == nil {
+		migration, err := w.models.ProcessEmbeddingMigration(ctx, w.batchSize)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Printf("Embedding migration batch failed: %v", err)
+			}
+			return
+		}
+		if migration == nil {
+			return
+		}
+
+		if migration.Status == entity.MigrationReady {
+			log.Printf("Embedding migration %s to %s staged every vector and is ready for the cutover", migration.MigrationID, migration.TargetModel)
+			return
+		}
+		log.Printf("Embedding migration %s to %s: %d done, %d left, about %s remaining", migration.MigrationID, migration.TargetModel, migration.Done, migration.Remaining, time.Duration(migration.ETASeconds)*time.Second)
+	}
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+)
+
+// LinkCheckWorker periodically re-checks the links of bookmarks that are due for a check
+type LinkCheckWorker struct {
+	linkChecks input.BookmarkLinkCheckUseCase
+	batchSize  int32
+
+	periodic
+}
+
+// NewLinkCheckWorker creates a worker that checks up to batchSize due bookmarks every poll
+func NewLinkCheckWorker(linkChecks input.BookmarkLinkCheckUseCase, poll time.Duration, batchSize int) *LinkCheckWorker {
+	if poll <= 0 {
+		poll = time.Hour
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &LinkCheckWorker{
+		linkChecks: linkChecks,
+		batchSize:  int32(batchSize),
+		periodic:   periodic{poll: poll},
+	}
+}
+
+// Start launches the link check loop
+func (w *LinkCheckWorker) Start(ctx context.Context) {
+	w.start(ctx, w.checkDue)
+}
+
+// checkDue checks one batch of due bookmarks, one at a time to stay gentle on the sites involved
+func (w *LinkCheckWorker) checkDue(ctx context.Context) {
+	bookmarks, err := w.linkChecks.GetDueLinkChecks(ctx, w.batchSize)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to load bookmarks due for a link check: %v", err)
+		}
+		return
+	}
+
+	for _, bookmark := range bookmarks {
+		if ctx.Err() != nil {
+			return
+		}
+
+		check, err := w.linkChecks.CheckBookmarkLink(ctx, bookmark.BookmarkID)
+		if err != nil {
+			log.Printf("Link check failed for bookmark %s: %v", bookmark.BookmarkID, err)
+			continue
+		}
+		if check.Status != entity.LinkOK {
+			log.Printf("Link check for bookmark %s: %s", bookmark.BookmarkID, check.Status)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// periodic runs a task right away and then every poll, until the context is cancelled. Workers embed it
+// for their loop and its Wait method
+type periodic struct {
+	poll time.Duration
+
+	wg sync.WaitGroup
+}
+
+// start launches the loop running task. It returns immediately
+func (p *periodic) start(ctx context.Context, task func(context.Context)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.poll)
+		defer ticker.Stop()
+
+		for {
+			task(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the loop has stopped
+func (p *periodic) Wait() {
+	p.wg.Wait()
+}
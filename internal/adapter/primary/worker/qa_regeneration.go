@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"garden3/internal/port/input"
+)
+
+// QARegenerationWorker periodically replaces Q&A pairs that users downvoted, trashed or deleted
+type QARegenerationWorker struct {
+	bookmarks input.BookmarkUseCase
+	batchSize int32
+
+	periodic
+}
+
+// NewQARegenerationWorker creates a worker that regenerates the rejected questions of up to batchSize
+// bookmarks every poll
+func NewQARegenerationWorker(bookmarks input.BookmarkUseCase, poll time.Duration, batchSize int) *QARegenerationWorker {
+	if poll <= 0 {
+		poll = time.Hour
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &QARegenerationWorker{
+		bookmarks: bookmarks,
+		batchSize: int32(batchSize),
+		periodic:  periodic{poll: poll},
+	}
+}
+
+// Start launches the regeneration loop
+func (w *QARegenerationWorker) Start(ctx context.Context) {
+	w.start(ctx, w.regenerateRejected)
+}
+
+// regenerateRejected handles one batch of bookmarks, one at a time since each one is an LLM call
+func (w *QARegenerationWorker) regenerateRejected(ctx context.Context) {
+	candidates, err := w.bookmarks.GetRejectedQuestionBookmarks(ctx, w.batchSize)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to load bookmarks with rejected questions: %v", err)
+		}
+		return
+	}
+
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			return
+		}
+
+		result, err := w.bookmarks.RegenerateRejectedQuestions(ctx, candidate.BookmarkID)
+		if err != nil {
+			log.Printf("Question regeneration failed for bookmark %s: %v", candidate.BookmarkID, err)
+			continue
+		}
+		if result != nil {
+			log.Printf("Replaced %d rejected questions of bookmark %s with %d new ones", len(result.Rejections), candidate.BookmarkID, len(result.Questions))
+		}
+	}
+}
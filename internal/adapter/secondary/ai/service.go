@@ -9,6 +9,19 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+
+	"garden3/internal/domain/entity"
+)
+
+const (
+	// generationModel is the model every prompt is sent to
+	generationModel = "current-default:latest"
+
+	// summaryPromptVersion identifies the summary prompt below, bump it whenever the prompt changes
+	summaryPromptVersion = "summary-reader-1"
+
+	// questionPromptVersion identifies the Q&A prompt below, bump it whenever the prompt changes
+	questionPromptVersion = "qa-v2-passage-1"
 )
 
 // Service implements the output.AIService interface
@@ -35,6 +48,7 @@ type ollamaGenerateRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
 	Stream bool   `json:"stream"`
+	Format string `json:"format,omitempty"`
 }
 
 // ollamaGenerateResponse represents the response from Ollama generate API
@@ -51,11 +65,101 @@ func (s *Service) GenerateSummary(ctx context.Context, content, url string, maxW
 	// Build the prompt using the same format as the base project
 	prompt := fmt.Sprintf("I have read the following article of url %s:\n\n\n===\n%s\n\n===\nNow, what would be your summary of this article? Please use less than %d words", url, content, maxWords)
 
+	response, err := s.generate(ctx, prompt, "")
+	if err != nil {
+		return "", err
+	}
+
+	// Strip <think> tags from the response
+	summary := stripThinkTags(response)
+
+	return summary, nil
+}
+
+func (s *Service) Model() string {
+	return generationModel
+}
+
+func (s *Service) SummaryPromptVersion() string {
+	return summaryPromptVersion
+}
+
+func (s *Service) QuestionPromptVersion() string {
+	return questionPromptVersion
+}
+
+func (s *Service) GenerateQuestions(ctx context.Context, content, url string, count int, rejected []entity.QuestionAnswer) ([]entity.QuestionAnswer, error) {
+	if count <= 0 {
+		count = 5
+	}
+
+	prompt := fmt.Sprintf("I have read the following article of url %s:\n\n\n===\n%s\n\n===\n"+
+		"Write %d questions that someone could ask later when trying to find this article again, each answered by a short passage from the article. "+
+		"Questions must be self-contained and must not refer to \"the article\" or \"the author\". "+
+		"%s"+
+		"Reply only with JSON in the form {\"questions\": [{\"question\": \"...\", \"answer\": \"...\"}]}", url, content, count, rejectedExamples(rejected))
+
+	response, err := s.generate(ctx, prompt, "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Questions []entity.QuestionAnswer `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(stripThinkTags(response))), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse questions: %w", err)
+	}
+
+	questions := make([]entity.QuestionAnswer, 0, len(parsed.Questions))
+	for _, qa := range parsed.Questions {
+		qa.Question = strings.TrimSpace(qa.Question)
+		qa.Answer = strings.TrimSpace(qa.Answer)
+		if qa.Question == "" || qa.Answer == "" {
+			continue
+		}
+		questions = append(questions, qa)
+		if len(questions) == count {
+			break
+		}
+	}
+
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("AI service returned no questions")
+	}
+
+	return questions, nil
+}
+
+// rejectedExamples lists the pairs readers rejected as wrong or unhelpful, so the model writes different ones
+func rejectedExamples(rejected []entity.QuestionAnswer) string {
+	if len(rejected) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Readers rejected the following questions and answers as wrong or unhelpful. Do not repeat them or their mistakes, " +
+		"and make sure every answer is stated by the article:\n")
+	for _, qa := range rejected {
+		b.WriteString("- Question: ")
+		b.WriteString(qa.Question)
+		if qa.Answer != "" {
+			b.WriteString("\n  Answer: ")
+			b.WriteString(qa.Answer)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// generate sends a prompt to the Ollama generate API and returns the raw response text
+func (s *Service) generate(ctx context.Context, prompt, format string) (string, error) {
 	// Prepare request
 	reqBody := ollamaGenerateRequest{
-		Model:  "current-default:latest",
+		Model:  generationModel,
 		Prompt: prompt,
 		Stream: false,
+		Format: format,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -94,10 +198,7 @@ func (s *Service) GenerateSummary(ctx context.Context, content, url string, maxW
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Strip <think> tags from the response
-	summary := stripThinkTags(ollamaResp.Response)
-
-	return summary, nil
+	return ollamaResp.Response, nil
 }
 
 // stripThinkTags removes <think>...</think> tags from the text
@@ -110,3 +211,15 @@ func stripThinkTags(text string) string {
 	// Trim whitespace from the result (equivalent to .trim() in JavaScript)
 	return strings.TrimSpace(cleaned)
 }
+
+// stripCodeFence removes a surrounding ```json ... ``` block that some models add around JSON output
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimPrefix(text, "json")
+	text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,694 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// resourceTimeout is the time allowed for fetching each subresource, in milliseconds
+const resourceTimeout = 15000
+
+// maxImportDepth is how deeply nested CSS @import rules are followed
+const maxImportDepth = 3
+
+var errResourceLimit = errors.New("resource limit reached")
+
+var (
+	cssURLPattern    = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)"'\s]*))\s*\)`)
+	cssImportPattern = regexp.MustCompile(`@import\s+(?:url\(\s*)?["']?([^"')\s;]+)["']?\s*\)?\s*([^;]*);`)
+)
+
+// Config holds the limits of an Archiver
+type Config struct {
+	// MaxResourceSize is the largest subresource inlined, in bytes; larger ones keep pointing at the live site
+	MaxResourceSize int64
+
+	// MaxResources caps the subresources fetched for one page
+	MaxResources int
+
+	// MaxSnapshotSize is the largest HTML snapshot or WARC file produced; larger ones fail with entity.ErrArchiveTooLarge
+	MaxSnapshotSize int64
+
+	// Concurrency is the number of subresources fetched at the same time
+	Concurrency int
+}
+
+// DefaultConfig returns the limits used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		MaxResourceSize: 5 << 20,
+		MaxResources:    200,
+		MaxSnapshotSize: 50 << 20,
+		Concurrency:     4,
+	}
+}
+
+// Archiver implements the output.PageArchiver interface
+type Archiver struct {
+	fetcher output.HTTPFetcher
+	config  Config
+}
+
+// NewArchiver creates a page archiver that downloads subresources with fetcher
+func NewArchiver(fetcher output.HTTPFetcher, config Config) *Archiver {
+	defaults := DefaultConfig()
+	if config.MaxResourceSize <= 0 {
+		config.MaxResourceSize = defaults.MaxResourceSize
+	}
+	if config.MaxResources < 1 {
+		config.MaxResources = defaults.MaxResources
+	}
+	if config.MaxSnapshotSize <= 0 {
+		config.MaxSnapshotSize = defaults.MaxSnapshotSize
+	}
+	if config.Concurrency < 1 {
+		config.Concurrency = defaults.Concurrency
+	}
+
+	return &Archiver{
+		fetcher: fetcher,
+		config:  config,
+	}
+}
+
+// resource is one downloaded subresource
+type resource struct {
+	url       string
+	mediaType string
+	content   []byte
+	err       error
+}
+
+// snapshot holds the state of archiving one page
+type snapshot struct {
+	ctx     context.Context
+	fetcher output.HTTPFetcher
+	config  Config
+	options output.FetchOptions
+	page    *url.URL
+	base    *url.URL
+
+	// resources is only used from the archiving goroutine; fetches fill in their own entry
+	resources map[string]*resource
+	fetched   int
+	failed    int
+	inlined   int64
+
+	mu   sync.Mutex
+	warc warcWriter
+}
+
+func (a *Archiver) Archive(ctx context.Context, page output.ArchivePage, options output.FetchOptions) (*output.ArchiveResult, error) {
+	pageURL, err := url.Parse(page.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %w", err)
+	}
+
+	doc, err := html.Parse(bytes.NewReader(page.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	// Subresources are always fetched in full, and only from public addresses since the page chooses them
+	options.ETag, options.LastModified = "", ""
+	options.PublicOnly = true
+
+	s := &snapshot{
+		ctx:       ctx,
+		fetcher:   a.fetcher,
+		config:    a.config,
+		options:   options,
+		page:      pageURL,
+		base:      pageURL,
+		resources: make(map[string]*resource),
+	}
+
+	var headers map[string]string
+	_ = json.Unmarshal([]byte(page.Headers), &headers)
+	if err := s.warc.writeInfo(page.FetchDate); err != nil {
+		return nil, fmt.Errorf("failed to write warc: %w", err)
+	}
+	receivedHeaders, receivedBody := asReceived(headers, page.Content, page.Original, page.OriginalContentType)
+	if err := s.warc.writeResponse(page.URL, page.FetchDate, page.StatusCode, receivedHeaders, receivedBody); err != nil {
+		return nil, fmt.Errorf("failed to write warc: %w", err)
+	}
+
+	s.clean(doc)
+
+	// Fetch what the document refers to, then what the fetched stylesheets refer to, one level at a time
+	pending := s.documentRefs(doc)
+	for depth := 0; len(pending) > 0 && depth <= maxImportDepth+1; depth++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var next []string
+		for _, r := range s.fetchAll(pending) {
+			if r.err == nil && isStylesheet(r) {
+				next = append(next, cssRefs(string(r.content), mustParse(r.url))...)
+			}
+		}
+		pending = next
+	}
+
+	s.rewrite(doc)
+	insertHeader(doc, page.URL, page.FetchDate)
+
+	var out bytes.Buffer
+	if err := html.Render(&out, doc); err != nil {
+		return nil, fmt.Errorf("failed to render snapshot: %w", err)
+	}
+	if int64(out.Len()) > a.config.MaxSnapshotSize {
+		return nil, fmt.Errorf("snapshot is %d bytes, limit is %d: %w", out.Len(), a.config.MaxSnapshotSize, entity.ErrArchiveTooLarge)
+	}
+
+	warc := s.warc.bytes()
+	if int64(len(warc)) > a.config.MaxSnapshotSize {
+		return nil, fmt.Errorf("warc is %d bytes, limit is %d: %w", len(warc), a.config.MaxSnapshotSize, entity.ErrArchiveTooLarge)
+	}
+
+	return &output.ArchiveResult{
+		HTML:            out.Bytes(),
+		WARC:            warc,
+		ResourceCount:   s.fetched,
+		FailedResources: s.failed,
+	}, nil
+}
+
+// clean removes scripts, frames and other active content, since a snapshot is served from our own
+// origin and must not run the page's code, and normalizes the elements that load subresources
+func (s *snapshot) clean(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.ElementNode && s.dropElement(child) {
+			n.RemoveChild(child)
+		} else {
+			s.clean(child)
+		}
+		child = next
+	}
+
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if strings.HasPrefix(key, "on") || key == "integrity" || key == "crossorigin" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(attr.Val)), "javascript:") {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	n.Attr = attrs
+
+	switch n.DataAtom {
+	case atom.A, atom.Area:
+		s.absolutize(n, "href")
+	case atom.Form:
+		s.absolutize(n, "action")
+	case atom.Img:
+		// Lazy-loading scripts will not run, so promote their source attributes
+		src := getAttr(n, "src")
+		if lazy := firstAttr(n, "data-src", "data-lazy-src", "data-original"); lazy != "" && (src == "" || strings.HasPrefix(src, "data:")) {
+			setAttr(n, "src", lazy)
+		}
+		if getAttr(n, "src") == "" {
+			if candidate := firstSrcsetCandidate(firstAttr(n, "srcset", "data-srcset")); candidate != "" {
+				setAttr(n, "src", candidate)
+			}
+		}
+		removeAttr(n, "srcset")
+		removeAttr(n, "data-srcset")
+		removeAttr(n, "sizes")
+		removeAttr(n, "loading")
+	}
+}
+
+// dropElement reports whether an element is removed from the snapshot. It also records a <base> href
+func (s *snapshot) dropElement(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Script, atom.Iframe, atom.Frame, atom.Frameset, atom.Object, atom.Embed, atom.Applet, atom.Template:
+		return true
+	case atom.Base:
+		if href := getAttr(n, "href"); href != "" {
+			if base, err := s.base.Parse(href); err == nil {
+				s.base = base
+			}
+		}
+		return true
+	case atom.Meta:
+		// The page was stored as UTF-8, and a fresh charset declaration is added when rendering
+		if getAttr(n, "charset") != "" {
+			return true
+		}
+		switch strings.ToLower(getAttr(n, "http-equiv")) {
+		case "refresh", "content-security-policy", "content-type":
+			return true
+		}
+	case atom.Link:
+		switch linkKind(n) {
+		case "stylesheet", "icon":
+			return false
+		}
+		rel := strings.ToLower(getAttr(n, "rel"))
+		return strings.Contains(rel, "preload") || strings.Contains(rel, "prefetch") || strings.Contains(rel, "preconnect")
+	case atom.Source:
+		// <picture> falls back to its <img>, and audio and video sources are left out of snapshots
+		return true
+	}
+	return false
+}
+
+// documentRefs lists the URLs of the subresources a cleaned document loads
+func (s *snapshot) documentRefs(n *html.Node) []string {
+	var refs []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if attr := resourceAttr(n); attr != "" {
+				if ref := s.resolve(s.base, getAttr(n, attr)); ref != "" {
+					refs = append(refs, ref)
+				}
+			}
+			if style := getAttr(n, "style"); style != "" {
+				refs = append(refs, cssRefs(style, s.base)...)
+			}
+			if n.DataAtom == atom.Style && n.FirstChild != nil {
+				refs = append(refs, cssRefs(n.FirstChild.Data, s.base)...)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return refs
+}
+
+// rewrite replaces subresource references with data URIs, and stylesheet links with inline styles
+func (s *snapshot) rewrite(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		s.rewrite(child)
+	}
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	if style := getAttr(n, "style"); style != "" {
+		setAttr(n, "style", s.rewriteCSS(style, s.base, 0))
+	}
+	if n.DataAtom == atom.Style && n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+		n.FirstChild.Data = s.rewriteCSS(n.FirstChild.Data, s.base, 0)
+	}
+
+	attr := resourceAttr(n)
+	if attr == "" {
+		return
+	}
+	ref := s.resolve(s.base, getAttr(n, attr))
+	if ref == "" {
+		return
+	}
+
+	r := s.resources[ref]
+	if r == nil || r.err != nil {
+		setAttr(n, attr, ref)
+		return
+	}
+
+	if n.DataAtom == atom.Link && linkKind(n) == "stylesheet" {
+		// Swap the link for a <style> element in place, keeping its media query
+		n.DataAtom = atom.Style
+		n.Data = "style"
+		media := getAttr(n, "media")
+		n.Attr = nil
+		if media != "" {
+			setAttr(n, "media", media)
+		}
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: s.rewriteCSS(string(r.content), mustParse(r.url), 0)})
+		return
+	}
+
+	setAttr(n, attr, s.dataURI(r))
+}
+
+// rewriteCSS inlines the imports and url() references of a stylesheet resolved against base
+func (s *snapshot) rewriteCSS(css string, base *url.URL, depth int) string {
+	var out strings.Builder
+	last := 0
+	for _, match := range cssImportPattern.FindAllStringSubmatchIndex(css, -1) {
+		out.WriteString(s.rewriteCSSURLs(css[last:match[0]], base))
+		last = match[1]
+
+		ref := s.resolve(base, css[match[2]:match[3]])
+		media := strings.TrimSpace(css[match[4]:match[5]])
+		r := s.resources[ref]
+		if ref == "" || r == nil || r.err != nil || depth >= maxImportDepth {
+			out.WriteString(css[match[0]:match[1]])
+			continue
+		}
+
+		imported := s.rewriteCSS(string(r.content), mustParse(r.url), depth+1)
+		if media != "" {
+			imported = "@media " + media + " {\n" + imported + "\n}"
+		}
+		out.WriteString(imported)
+	}
+	out.WriteString(s.rewriteCSSURLs(css[last:], base))
+	return out.String()
+}
+
+func (s *snapshot) rewriteCSSURLs(css string, base *url.URL) string {
+	return cssURLPattern.ReplaceAllStringFunc(css, func(match string) string {
+		ref := s.resolve(base, cssURLValue(match))
+		if ref == "" {
+			return match
+		}
+		if r := s.resources[ref]; r != nil && r.err == nil {
+			return `url("` + s.dataURI(r) + `")`
+		}
+		return `url("` + ref + `")`
+	})
+}
+
+// fetchAll downloads the URLs not seen before, a few at a time, and returns their resources
+func (s *snapshot) fetchAll(refs []string) []*resource {
+	var queued []*resource
+	for _, ref := range refs {
+		if _, seen := s.resources[ref]; seen {
+			continue
+		}
+		r := &resource{url: ref}
+		s.resources[ref] = r
+		if s.fetched+s.failed+len(queued) >= s.config.MaxResources {
+			r.err = errResourceLimit
+			s.failed++
+			continue
+		}
+		queued = append(queued, r)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, s.config.Concurrency)
+	for _, r := range queued {
+		wg.Add(1)
+		go func(r *resource) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			s.fetch(r)
+		}(r)
+	}
+	wg.Wait()
+
+	for _, r := range queued {
+		if r.err != nil {
+			s.failed++
+		} else {
+			s.fetched++
+		}
+	}
+	return queued
+}
+
+func (s *snapshot) fetch(r *resource) {
+	target := mustParse(r.url)
+
+	// Extra profile headers may carry credentials, so they are only sent to the page's own host
+	options := s.options
+	options.Headers = map[string]string{"Referer": s.page.String()}
+	if strings.EqualFold(target.Hostname(), s.page.Hostname()) {
+		for name, value := range s.options.Headers {
+			options.Headers[name] = value
+		}
+	}
+
+	fetchedAt := time.Now()
+	response, err := s.fetcher.Fetch(s.ctx, r.url, resourceTimeout, options)
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	var headers map[string]string
+	_ = json.Unmarshal([]byte(response.Headers), &headers)
+
+	receivedHeaders, receivedBody := asReceived(headers, response.Content, response.Original, response.OriginalContentType)
+	s.mu.Lock()
+	err = s.warc.writeResponse(r.url, fetchedAt, response.StatusCode, receivedHeaders, receivedBody)
+	s.mu.Unlock()
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	if response.StatusCode >= 400 {
+		r.err = fmt.Errorf("HTTP status %d", response.StatusCode)
+		return
+	}
+	if int64(len(response.Content)) > s.config.MaxResourceSize {
+		r.err = fmt.Errorf("resource is %d bytes, limit is %d", len(response.Content), s.config.MaxResourceSize)
+		return
+	}
+
+	r.content = response.Content
+	r.mediaType = mediaTypeOf(headers, target, response.Content)
+}
+
+// asReceived returns the headers and body of a response as the server sent them, undoing the fetcher's
+// transcoding so WARC records keep the original bytes. The headers are copied, not changed
+func asReceived(headers map[string]string, content, original []byte, originalContentType string) (map[string]string, []byte) {
+	if original == nil {
+		return headers, content
+	}
+
+	received := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if !strings.EqualFold(name, "content-type") {
+			received[name] = value
+		}
+	}
+	if originalContentType != "" {
+		received["Content-Type"] = originalContentType
+	}
+	return received, original
+}
+
+// dataURI encodes a resource for inlining, or returns its URL once the snapshot size budget is spent
+func (s *snapshot) dataURI(r *resource) string {
+	encoded := "data:" + r.mediaType + ";base64," + base64.StdEncoding.EncodeToString(r.content)
+	if s.inlined+int64(len(encoded)) > s.config.MaxSnapshotSize {
+		return r.url
+	}
+	s.inlined += int64(len(encoded))
+	return encoded
+}
+
+// resolve returns the absolute http(s) URL of a reference, or "" for anything that cannot be fetched
+func (s *snapshot) resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	resolved.Fragment = ""
+	return resolved.String()
+}
+
+func (s *snapshot) absolutize(n *html.Node, key string) {
+	value := getAttr(n, key)
+	if value == "" || strings.HasPrefix(value, "#") {
+		return
+	}
+	if resolved, err := s.base.Parse(value); err == nil {
+		setAttr(n, key, resolved.String())
+	}
+}
+
+// insertHeader declares the snapshot's charset and records where and when it was taken
+func insertHeader(doc *html.Node, pageURL string, fetchDate time.Time) {
+	head := findElement(doc, atom.Head)
+	if head == nil {
+		return
+	}
+
+	meta := &html.Node{Type: html.ElementNode, DataAtom: atom.Meta, Data: "meta", Attr: []html.Attribute{{Key: "charset", Val: "utf-8"}}}
+	head.InsertBefore(meta, head.FirstChild)
+
+	// "--" cannot appear inside a comment, and may occur in a URL
+	note := fmt.Sprintf(" Archived from %s on %s ", strings.ReplaceAll(pageURL, "--", "%2D%2D"), fetchDate.UTC().Format(time.RFC3339))
+	doc.InsertBefore(&html.Node{Type: html.CommentNode, Data: note}, doc.FirstChild)
+}
+
+// resourceAttr returns the attribute through which an element loads a subresource to inline, or ""
+func resourceAttr(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.Img:
+		return "src"
+	case atom.Input:
+		if strings.EqualFold(getAttr(n, "type"), "image") {
+			return "src"
+		}
+	case atom.Video:
+		return "poster"
+	case atom.Link:
+		if linkKind(n) != "" {
+			return "href"
+		}
+	}
+	return ""
+}
+
+// linkKind classifies a <link> as "stylesheet", "icon" or ""
+func linkKind(n *html.Node) string {
+	for _, rel := range strings.Fields(strings.ToLower(getAttr(n, "rel"))) {
+		switch rel {
+		case "stylesheet":
+			if !strings.Contains(strings.ToLower(getAttr(n, "rel")), "alternate") {
+				return "stylesheet"
+			}
+		case "icon", "apple-touch-icon":
+			return "icon"
+		}
+	}
+	return ""
+}
+
+// cssRefs lists the URLs a stylesheet imports or refers to with url()
+func cssRefs(css string, base *url.URL) []string {
+	s := &snapshot{}
+	var refs []string
+	for _, match := range cssImportPattern.FindAllStringSubmatch(css, -1) {
+		if ref := s.resolve(base, match[1]); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	for _, match := range cssURLPattern.FindAllString(css, -1) {
+		if ref := s.resolve(base, cssURLValue(match)); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func cssURLValue(match string) string {
+	groups := cssURLPattern.FindStringSubmatch(match)
+	for _, group := range groups[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
+
+func isStylesheet(r *resource) bool {
+	return r.mediaType == "text/css" || strings.HasSuffix(strings.ToLower(mustParse(r.url).Path), ".css")
+}
+
+// mediaTypeOf returns the media type of a resource from its headers, its extension or its content
+func mediaTypeOf(headers map[string]string, target *url.URL, content []byte) string {
+	for name, value := range headers {
+		if strings.EqualFold(name, "Content-Type") {
+			if mediaType, _, err := mime.ParseMediaType(value); err == nil && mediaType != "application/octet-stream" {
+				return mediaType
+			}
+		}
+	}
+	if byExtension := mime.TypeByExtension(path.Ext(target.Path)); byExtension != "" {
+		mediaType, _, _ := mime.ParseMediaType(byExtension)
+		return mediaType
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	return mediaType
+}
+
+// firstSrcsetCandidate returns the URL of the first image candidate in a srcset attribute
+func firstSrcsetCandidate(srcset string) string {
+	candidate, _, _ := strings.Cut(strings.TrimSpace(srcset), ",")
+	fields := strings.Fields(candidate)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func mustParse(raw string) *url.URL {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return &url.URL{}
+	}
+	return parsed
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func firstAttr(n *html.Node, keys ...string) string {
+	for _, key := range keys {
+		if value := getAttr(n, key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, value string) {
+	for i, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		if !strings.EqualFold(attr.Key, key) {
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attr = attrs
+}
@@ -0,0 +1,312 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// stubFetcher serves canned responses and records the requests it receives
+type stubFetcher struct {
+	responses map[string]*output.FetchResponse
+
+	mu       sync.Mutex
+	requests map[string]output.FetchOptions
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, url string, timeout int, options output.FetchOptions) (*output.FetchResponse, error) {
+	f.mu.Lock()
+	if f.requests == nil {
+		f.requests = make(map[string]output.FetchOptions)
+	}
+	f.requests[url] = options
+	f.mu.Unlock()
+
+	if response, ok := f.responses[url]; ok {
+		return response, nil
+	}
+	return &output.FetchResponse{StatusCode: 404, Headers: "{}"}, nil
+}
+
+func (f *stubFetcher) Head(ctx context.Context, url string, timeout int, options output.FetchOptions) (*output.FetchResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func respond(contentType, body string) *output.FetchResponse {
+	return &output.FetchResponse{
+		StatusCode: 200,
+		Headers:    `{"Content-Type":"` + contentType + `"}`,
+		Content:    []byte(body),
+	}
+}
+
+const testPage = `<!DOCTYPE html>
+<html><head>
+<meta charset="iso-8859-1">
+<link rel="stylesheet" href="/style.css" media="screen">
+<link rel="preload" href="/font.woff2">
+<script src="/app.js"></script>
+</head><body onload="track()">
+<img src="img/logo.png" alt="logo">
+<img data-src="/lazy.png" src="data:image/gif;base64,R0lGOD">
+<img srcset="/small.png 1x, /large.png 2x">
+<img src="/missing.png">
+<a href="/about" onclick="steal()">About</a>
+<a href="javascript:alert(1)">Bad</a>
+<iframe src="https://ads.example.com/"></iframe>
+<div style="background: url('bg.png')"></div>
+</body></html>`
+
+func TestArchiveInlinesResources(t *testing.T) {
+	fetcher := &stubFetcher{responses: map[string]*output.FetchResponse{
+		"https://example.com/style.css":          respond("text/css", `@import "base.css"; body { background: url(img/paper.png) }`),
+		"https://example.com/base.css":           respond("text/css", `h1 { font-family: serif }`),
+		"https://example.com/img/paper.png":      respond("image/png", "paper"),
+		"https://example.com/posts/img/logo.png": respond("image/png", "logo"),
+		"https://example.com/lazy.png":           respond("image/png", "lazy"),
+		"https://example.com/small.png":          respond("image/png", "small"),
+		"https://example.com/posts/bg.png":       respond("image/png", "bg"),
+	}}
+	archiver := NewArchiver(fetcher, Config{})
+
+	result, err := archiver.Archive(context.Background(), output.ArchivePage{
+		URL:        "https://example.com/posts/1",
+		StatusCode: 200,
+		Headers:    `{"Content-Type":"text/html"}`,
+		Content:    []byte(testPage),
+		FetchDate:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}, output.FetchOptions{ETag: `"abc"`, Headers: map[string]string{"Authorization": "secret"}})
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	snapshot := string(result.HTML)
+	dataURI := func(mediaType, content string) string {
+		return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString([]byte(content))
+	}
+
+	wantContains := []string{
+		`<meta charset="utf-8"/>`,
+		`<style media="screen">`,
+		`h1 { font-family: serif }`,
+		`url("` + dataURI("image/png", "paper") + `")`,
+		`src="` + dataURI("image/png", "logo") + `"`,
+		`src="` + dataURI("image/png", "lazy") + `"`,
+		`src="` + dataURI("image/png", "small") + `"`,
+		`src="https://example.com/missing.png"`,
+		`url(&#34;` + dataURI("image/png", "bg") + `&#34;)`,
+		`href="https://example.com/about"`,
+		`Archived from https://example.com/posts/1 on 2024-05-01T12:00:00Z`,
+	}
+	for _, want := range wantContains {
+		if !strings.Contains(snapshot, want) {
+			t.Errorf("snapshot does not contain %q\n%s", want, snapshot)
+		}
+	}
+
+	wantMissing := []string{"<script", "app.js", "preload", "iframe", "onload", "onclick", "javascript:", "iso-8859-1", "srcset", "large.png"}
+	for _, unwanted := range wantMissing {
+		if strings.Contains(snapshot, unwanted) {
+			t.Errorf("snapshot contains %q", unwanted)
+		}
+	}
+
+	if result.ResourceCount != 7 || result.FailedResources != 1 {
+		t.Errorf("ResourceCount, FailedResources = %d, %d, want 7, 1", result.ResourceCount, result.FailedResources)
+	}
+
+	for url, options := range fetcher.requests {
+		if options.ETag != "" {
+			t.Errorf("request for %s was conditional", url)
+		}
+		if !options.PublicOnly {
+			t.Errorf("request for %s may reach non-public addresses", url)
+		}
+		if options.Headers["Referer"] != "https://example.com/posts/1" {
+			t.Errorf("request for %s has Referer %q", url, options.Headers["Referer"])
+		}
+		if options.Headers["Authorization"] != "secret" {
+			t.Errorf("request for %s lost the profile headers", url)
+		}
+	}
+}
+
+func TestAsReceived(t *testing.T) {
+	headers := map[string]string{"content-type": "text/html; charset=utf-8", "Etag": `"v1"`}
+
+	gotHeaders, body := asReceived(headers, []byte("café"), []byte("caf\xe9"), "text/html; charset=ISO-8859-1")
+	if string(body) != "caf\xe9" {
+		t.Errorf("body = %q, want the bytes as received", body)
+	}
+	if len(gotHeaders) != 2 || gotHeaders["Content-Type"] != "text/html; charset=ISO-8859-1" || gotHeaders["Etag"] != `"v1"` {
+		t.Errorf("headers = %v, want the received content type and the other headers", gotHeaders)
+	}
+	if headers["content-type"] != "text/html; charset=utf-8" {
+		t.Errorf("the stored headers were changed: %v", headers)
+	}
+
+	gotHeaders, body = asReceived(headers, []byte("café"), nil, "")
+	if string(body) != "café" || gotHeaders["content-type"] != "text/html; charset=utf-8" {
+		t.Errorf("an untranscoded response was changed: %v, %q", gotHeaders, body)
+	}
+}
+
+func TestArchiveProfileHeadersStayOnHost(t *testing.T) {
+	fetcher := &stubFetcher{responses: map[string]*output.FetchResponse{
+		"https://cdn.example.net/logo.png": respond("image/png", "logo"),
+	}}
+	archiver := NewArchiver(fetcher, Config{})
+
+	_, err := archiver.Archive(context.Background(), output.ArchivePage{
+		URL:     "https://example.com/",
+		Content: []byte(`<html><body><img src="https://cdn.example.net/logo.png"></body></html>`),
+	}, output.FetchOptions{Headers: map[string]string{"Authorization": "secret"}})
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	if _, sent := fetcher.requests["https://cdn.example.net/logo.png"].Headers["Authorization"]; sent {
+		t.Error("profile headers were sent to another host")
+	}
+}
+
+func TestArchiveLimits(t *testing.T) {
+	page := output.ArchivePage{
+		URL:     "https://example.com/",
+		Content: []byte(`<html><body><img src="/a.png"><img src="/b.png"><img src="/big.png"></body></html>`),
+	}
+	fetcher := &stubFetcher{responses: map[string]*output.FetchResponse{
+		"https://example.com/a.png":   respond("image/png", "a"),
+		"https://example.com/b.png":   respond("image/png", "b"),
+		"https://example.com/big.png": respond("image/png", strings.Repeat("x", 100)),
+	}}
+
+	testCases := []struct {
+		name       string
+		config     Config
+		wantErr    error
+		wantCount  int
+		wantFailed int
+	}{
+		{
+			name:       "resource size",
+			config:     Config{MaxResourceSize: 10},
+			wantCount:  2,
+			wantFailed: 1,
+		},
+		{
+			name:       "resource count",
+			config:     Config{MaxResources: 2},
+			wantCount:  2,
+			wantFailed: 1,
+		},
+		{
+			name:    "snapshot size",
+			config:  Config{MaxSnapshotSize: 64},
+			wantErr: entity.ErrArchiveTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := NewArchiver(fetcher, tc.config).Archive(context.Background(), page, output.FetchOptions{})
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("Archive() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Archive() error = %v", err)
+			}
+			if result.ResourceCount != tc.wantCount || result.FailedResources != tc.wantFailed {
+				t.Errorf("ResourceCount, FailedResources = %d, %d, want %d, %d", result.ResourceCount, result.FailedResources, tc.wantCount, tc.wantFailed)
+			}
+		})
+	}
+}
+
+func TestWARCRecords(t *testing.T) {
+	var w warcWriter
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := w.writeInfo(date); err != nil {
+		t.Fatal(err)
+	}
+	headers := map[string]string{"Content-Type": "text/html", "Content-Encoding": "gzip", "Content-Length": "3"}
+	if err := w.writeResponse("https://example.com/", date, 200, headers, []byte("<p>hello</p>")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A multi-member gzip stream reads back as the concatenated records
+	reader, err := gzip.NewReader(bytes.NewReader(w.bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	records := bufio.NewReader(bytes.NewReader(content))
+	for {
+		fields := map[string]string{}
+		line, err := records.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if line != "WARC/1.1\r\n" {
+			t.Fatalf("record starts with %q", line)
+		}
+		for {
+			line, err = records.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\r\n" {
+				break
+			}
+			name, value, _ := strings.Cut(strings.TrimRight(line, "\r\n"), ": ")
+			fields[name] = value
+		}
+
+		length, err := strconv.Atoi(fields["Content-Length"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := make([]byte, length)
+		if _, err := io.ReadFull(records, block); err != nil {
+			t.Fatal(err)
+		}
+		if fields["WARC-Block-Digest"] != warcDigest(block) {
+			t.Errorf("%s record has block digest %s, want %s", fields["WARC-Type"], fields["WARC-Block-Digest"], warcDigest(block))
+		}
+		if fields["WARC-Type"] == "response" {
+			want := "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: 12\r\n\r\n<p>hello</p>"
+			if string(block) != want {
+				t.Errorf("response block = %q, want %q", block, want)
+			}
+		}
+		types = append(types, fields["WARC-Type"])
+
+		if _, err := io.ReadFull(records, make([]byte, 4)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if strings.Join(types, ",") != "warcinfo,response" {
+		t.Errorf("record types = %v, want warcinfo,response", types)
+	}
+}
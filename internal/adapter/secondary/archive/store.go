@@ -0,0 +1,155 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"garden3/internal/domain/entity"
+)
+
+// Store implements the output.ArchiveStore interface as content-addressed files on disk.
+// Each file is named after the SHA-256 of its content and sharded by the first two hex digits
+type Store struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	used int64
+}
+
+// NewStore creates the store directory if needed and measures the space already in use.
+// A maxSize of zero or less leaves the store unbounded
+func NewStore(dir string, maxSize int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	store := &Store{dir: dir, maxSize: maxSize}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		store.used += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure archive directory: %w", err)
+	}
+
+	return store, nil
+}
+
+func (s *Store) Put(ctx context.Context, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := s.path(hash)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if s.maxSize > 0 && s.used+int64(len(data)) > s.maxSize {
+		return "", fmt.Errorf("%d of %d bytes used: %w", s.used, s.maxSize, entity.ErrArchiveStoreFull)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated file under a valid hash
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store archive file: %w", err)
+	}
+
+	s.used += int64(len(data))
+	return hash, nil
+}
+
+func (s *Store) Open(ctx context.Context, hash string) (io.ReadCloser, int64, error) {
+	if !isHash(hash) {
+		return nil, 0, fmt.Errorf("invalid archive hash %q: %w", hash, entity.ErrArchiveNotFound)
+	}
+
+	file, err := os.Open(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, fmt.Errorf("archive file %s: %w", hash, entity.ErrArchiveNotFound)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open archive file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to open archive file: %w", err)
+	}
+	return file, info.Size(), nil
+}
+
+func (s *Store) Delete(ctx context.Context, hash string) error {
+	if !isHash(hash) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(hash)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete archive file: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete archive file: %w", err)
+	}
+
+	s.used -= info.Size()
+	return nil
+}
+
+func (s *Store) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// isHash reports whether s is a lowercase hex SHA-256, which also keeps it from escaping the store directory
+func isHash(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"garden3/internal/domain/entity"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewStore(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := store.Put(ctx, []byte("hello"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if hash != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Put() hash = %s", hash)
+	}
+
+	// Storing the same content again uses no more space
+	if _, err := store.Put(ctx, []byte("hello")); err != nil {
+		t.Fatalf("Put() of duplicate error = %v", err)
+	}
+	if _, err := store.Put(ctx, []byte("world!")); !errors.Is(err, entity.ErrArchiveStoreFull) {
+		t.Fatalf("Put() over the limit error = %v, want %v", err, entity.ErrArchiveStoreFull)
+	}
+
+	// A reopened store counts the files already present
+	reopened, err := NewStore(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.used != 5 {
+		t.Errorf("reopened store uses %d bytes, want 5", reopened.used)
+	}
+
+	content, size, err := reopened.Open(ctx, hash)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "hello" || size != 5 {
+		t.Errorf("Open() = %q, %d, want hello, 5", data, size)
+	}
+
+	if err := reopened.Delete(ctx, hash); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err := reopened.Open(ctx, hash); !errors.Is(err, entity.ErrArchiveNotFound) {
+		t.Errorf("Open() after Delete() error = %v, want %v", err, entity.ErrArchiveNotFound)
+	}
+	if _, _, err := reopened.Open(ctx, "../../etc/passwd"); !errors.Is(err, entity.ErrArchiveNotFound) {
+		t.Errorf("Open() of a path error = %v, want %v", err, entity.ErrArchiveNotFound)
+	}
+	if _, err := reopened.Put(ctx, []byte("world!")); err != nil {
+		t.Errorf("Put() after Delete() error = %v", err)
+	}
+}
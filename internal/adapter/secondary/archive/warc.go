@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// warcWriter writes WARC/1.1 records, compressing each one as its own gzip member as .warc.gz readers expect
+type warcWriter struct {
+	buf bytes.Buffer
+}
+
+// writeInfo writes the warcinfo record that describes the file
+func (w *warcWriter) writeInfo(date time.Time) error {
+	block := []byte("software: garden\r\nformat: WARC File Format 1.1\r\n")
+	return w.writeRecord([][2]string{
+		{"WARC-Type", "warcinfo"},
+		{"WARC-Date", warcDate(date)},
+		{"Content-Type", "application/warc-fields"},
+	}, block)
+}
+
+// writeResponse writes a response record holding the status line, headers and body of an HTTP response as
+// the server sent them. The transport has already removed any content encoding, so length and encoding
+// headers are rewritten to match the body
+func (w *warcWriter) writeResponse(targetURI string, date time.Time, statusCode int32, headers map[string]string, body []byte) error {
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(int(statusCode)))
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "content-length", "content-encoding", "transfer-encoding":
+			continue
+		}
+		// Repeated Set-Cookie headers are stored one per line
+		for _, value := range strings.Split(headers[name], "\n") {
+			fmt.Fprintf(&block, "%s: %s\r\n", name, value)
+		}
+	}
+	fmt.Fprintf(&block, "Content-Length: %d\r\n\r\n", len(body))
+	block.Write(body)
+
+	return w.writeRecord([][2]string{
+		{"WARC-Type", "response"},
+		{"WARC-Target-URI", targetURI},
+		{"WARC-Date", warcDate(date)},
+		{"WARC-Payload-Digest", warcDigest(body)},
+		{"Content-Type", "application/http;msgtype=response"},
+	}, block.Bytes())
+}
+
+func (w *warcWriter) writeRecord(fields [][2]string, block []byte) error {
+	var record bytes.Buffer
+	record.WriteString("WARC/1.1\r\n")
+	fmt.Fprintf(&record, "WARC-Record-ID: <urn:uuid:%s>\r\n", uuid.New())
+	for _, field := range fields {
+		fmt.Fprintf(&record, "%s: %s\r\n", field[0], field[1])
+	}
+	fmt.Fprintf(&record, "WARC-Block-Digest: %s\r\n", warcDigest(block))
+	fmt.Fprintf(&record, "Content-Length: %d\r\n\r\n", len(block))
+	record.Write(block)
+	record.WriteString("\r\n\r\n")
+
+	gz := gzip.NewWriter(&w.buf)
+	if _, err := gz.Write(record.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (w *warcWriter) bytes() []byte {
+	return w.buf.Bytes()
+}
+
+func warcDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// warcDigest returns the base32 SHA-1 digest conventionally used in WARC files
+func warcDigest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,283 @@
+package bookmarkformat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	nethtml "golang.org/x/net/html"
+	"garden3/internal/domain/entity"
+)
+
+// NetscapeParser implements the output.BookmarkParser interface for Netscape bookmarks.html files
+type NetscapeParser struct{}
+
+// NewNetscapeParser creates a new Netscape bookmark file parser
+func NewNetscapeParser() *NetscapeParser {
+	return &NetscapeParser{}
+}
+
+// netscapeEntry is the raw form of a parsed <A> element, stored as the bookmark source
+type netscapeEntry struct {
+	Format      string            `json:"format"`
+	Attributes  map[string]string `json:"attributes"`
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	Folders     []string          `json:"folders,omitempty"`
+}
+
+func (p *NetscapeParser) Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error {
+	tokenizer := nethtml.NewTokenizer(r)
+
+	// folders holds one element per open <DL>, empty for lists without a heading
+	var folders []string
+	var pendingFolder *string
+	var current *netscapeEntry
+	var text strings.Builder
+	inHeading, inLink, inDescription := false, false, false
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		entry := current
+		current = nil
+		if inDescription {
+			entry.Description = strings.TrimSpace(text.String())
+			inDescription = false
+		}
+		return emitEntry(entry, emit)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case nethtml.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return fmt.Errorf("failed to read bookmark file: %w", err)
+			}
+			return flush()
+
+		case nethtml.TextToken:
+			if inHeading || inLink || inDescription {
+				text.Write(tokenizer.Text())
+			}
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "dt", "dl", "h3":
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+			switch string(name) {
+			case "dl":
+				folder := ""
+				if pendingFolder != nil {
+					folder = *pendingFolder
+					pendingFolder = nil
+				}
+				folders = append(folders, folder)
+			case "h3":
+				inHeading = true
+				text.Reset()
+			case "a":
+				current = &netscapeEntry{
+					Format:     string(entity.ImportFormatNetscape),
+					Attributes: readAttributes(tokenizer, hasAttr),
+					Folders:    folderPath(folders),
+				}
+				inLink = true
+				text.Reset()
+			case "dd":
+				if current != nil {
+					inDescription = true
+					text.Reset()
+				}
+			}
+
+		case nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "h3":
+				if inHeading {
+					folder := strings.TrimSpace(text.String())
+					pendingFolder = &folder
+					inHeading = false
+				}
+			case "a":
+				if inLink && current != nil {
+					current.Title = strings.TrimSpace(text.String())
+					inLink = false
+				}
+			case "dl":
+				if err := flush(); err != nil {
+					return err
+				}
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			}
+		}
+	}
+}
+
+func readAttributes(tokenizer *nethtml.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = tokenizer.TagAttr()
+		attrs[string(key)] = string(val)
+	}
+	return attrs
+}
+
+func folderPath(folders []string) []string {
+	var path []string
+	for _, folder := range folders {
+		if folder != "" {
+			path = append(path, folder)
+		}
+	}
+	return path
+}
+
+func emitEntry(entry *netscapeEntry, emit func(entity.ImportedBookmark) error) error {
+	href := strings.TrimSpace(entry.Attributes["href"])
+	if href == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bookmark entry: %w", err)
+	}
+
+	bookmark := entity.ImportedBookmark{
+		URL:      href,
+		AddDate:  parseUnixTimestamp(entry.Attributes["add_date"]),
+		Folders:  entry.Folders,
+		RawEntry: raw,
+	}
+	if entry.Title != "" {
+		title := entry.Title
+		bookmark.Title = &title
+	}
+	if entry.Description != "" {
+		description := entry.Description
+		bookmark.Description = &description
+	}
+	bookmark.Tags = splitTags(entry.Attributes["tags"], ",")
+
+	return emit(bookmark)
+}
+
+// parseUnixTimestamp parses ADD_DATE values, which browsers write in seconds, milliseconds or microseconds
+func parseUnixTimestamp(value string) *time.Time {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n <= 0 {
+		return nil
+	}
+
+	var t time.Time
+	switch {
+	case n > 1e14:
+		t = time.UnixMicro(n)
+	case n > 1e11:
+		t = time.UnixMilli(n)
+	default:
+		t = time.Unix(n, 0)
+	}
+	t = t.UTC()
+	return &t
+}
+
+// NetscapeWriter implements the output.BookmarkWriter interface for Netscape bookmarks.html files
+type NetscapeWriter struct{}
+
+// NewNetscapeWriter creates a new Netscape bookmark file writer
+func NewNetscapeWriter() *NetscapeWriter {
+	return &NetscapeWriter{}
+}
+
+const netscapeHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`
+
+func (w *NetscapeWriter) Write(out io.Writer, folders []entity.ExportFolder) error {
+	if _, err := io.WriteString(out, netscapeHeader); err != nil {
+		return err
+	}
+
+	// Uncategorized bookmarks go at the top level after the folders
+	var uncategorized []entity.ExportBookmark
+	for _, folder := range folders {
+		if folder.Name == "" {
+			uncategorized = append(uncategorized, folder.Bookmarks...)
+			continue
+		}
+		if err := writeNetscapeFolder(out, "    ", folder); err != nil {
+			return err
+		}
+	}
+
+	for _, bookmark := range uncategorized {
+		if err := writeNetscapeBookmark(out, "    ", bookmark); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(out, "</DL><p>\n")
+	return err
+}
+
+// writeNetscapeFolder writes a folder with its subfolders before its own bookmarks
+func writeNetscapeFolder(out io.Writer, indent string, folder entity.ExportFolder) error {
+	if _, err := fmt.Fprintf(out, "%s<DT><H3>%s</H3>\n%s<DL><p>\n", indent, html.EscapeString(folder.Name), indent); err != nil {
+		return err
+	}
+	for _, child := range folder.Children {
+		if err := writeNetscapeFolder(out, indent+"    ", child); err != nil {
+			return err
+		}
+	}
+	for _, bookmark := range folder.Bookmarks {
+		if err := writeNetscapeBookmark(out, indent+"    ", bookmark); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(out, "%s</DL><p>\n", indent)
+	return err
+}
+
+func writeNetscapeBookmark(out io.Writer, indent string, bookmark entity.ExportBookmark) error {
+	title := bookmark.URL
+	if bookmark.Title != nil && *bookmark.Title != "" {
+		title = *bookmark.Title
+	}
+
+	_, err := fmt.Fprintf(out, "%s<DT><A HREF=\"%s\" ADD_DATE=\"%d\">%s</A>\n",
+		indent,
+		html.EscapeString(bookmark.URL),
+		bookmark.CreationDate.Unix(),
+		html.EscapeString(title),
+	)
+	return err
+}
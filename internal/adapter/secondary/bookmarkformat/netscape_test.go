@@ -0,0 +1,149 @@
+package bookmarkformat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+const netscapeFixture = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1600000000">Programming</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1600000001" TAGS="go,lang">The Go &amp; Gophers</A>
+        <DD>Official site
+        <DT><H3>Databases</H3>
+        <DL><p>
+            <DT><A HREF="https://www.postgresql.org/" ADD_DATE="1600000002000">PostgreSQL</A>
+        </DL><p>
+        <DT><A HREF="https://pkg.go.dev/" ADD_DATE="1600000003000000">Packages</A>
+    </DL><p>
+    <DT><A HREF="https://example.com/">Top level</A>
+</DL><p>
+`
+
+func TestNetscapeParser(t *testing.T) {
+	var entries []entity.ImportedBookmark
+	err := NewNetscapeParser().Parse(context.Background(), strings.NewReader(netscapeFixture), func(b entity.ImportedBookmark) error {
+		entries = append(entries, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	testCases := []struct {
+		url         string
+		title       string
+		folders     []string
+		addDate     int64
+		description string
+		tags        []string
+	}{
+		{url: "https://go.dev/", title: "The Go & Gophers", folders: []string{"Programming"}, addDate: 1600000001, description: "Official site", tags: []string{"go", "lang"}},
+		{url: "https://www.postgresql.org/", title: "PostgreSQL", folders: []string{"Programming", "Databases"}, addDate: 1600000002},
+		{url: "https://pkg.go.dev/", title: "Packages", folders: []string{"Programming"}, addDate: 1600000003},
+		{url: "https://example.com/", title: "Top level"},
+	}
+
+	if len(entries) != len(testCases) {
+		t.Fatalf("expected %d entries, got %d", len(testCases), len(entries))
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			got := entries[i]
+			if got.URL != tc.url {
+				t.Errorf("URL = %q, want %q", got.URL, tc.url)
+			}
+			if got.Title == nil || *got.Title != tc.title {
+				t.Errorf("Title = %v, want %q", got.Title, tc.title)
+			}
+			if strings.Join(got.Folders, "/") != strings.Join(tc.folders, "/") {
+				t.Errorf("Folders = %v, want %v", got.Folders, tc.folders)
+			}
+			if tc.addDate == 0 {
+				if got.AddDate != nil {
+					t.Errorf("AddDate = %v, want nil", got.AddDate)
+				}
+			} else if got.AddDate == nil || got.AddDate.Unix() != tc.addDate {
+				t.Errorf("AddDate = %v, want %d", got.AddDate, tc.addDate)
+			}
+			if tc.description != "" && (got.Description == nil || *got.Description != tc.description) {
+				t.Errorf("Description = %v, want %q", got.Description, tc.description)
+			}
+			if strings.Join(got.Tags, ",") != strings.Join(tc.tags, ",") {
+				t.Errorf("Tags = %v, want %v", got.Tags, tc.tags)
+			}
+
+			var raw map[string]interface{}
+			if err := json.Unmarshal(got.RawEntry, &raw); err != nil {
+				t.Fatalf("RawEntry is not valid JSON: %v", err)
+			}
+			if raw["format"] != "netscape" {
+				t.Errorf("RawEntry format = %v, want netscape", raw["format"])
+			}
+		})
+	}
+}
+
+func TestNetscapeWriterRoundTrip(t *testing.T) {
+	title := "Go <3"
+	folders := []entity.ExportFolder{
+		{
+			Name: "Programming",
+			Bookmarks: []entity.ExportBookmark{
+				{URL: "https://go.dev/?a=1&b=2", Title: &title, CreationDate: time.Unix(1600000001, 0)},
+			},
+			Children: []entity.ExportFolder{
+				{
+					Name: "Rust",
+					Bookmarks: []entity.ExportBookmark{
+						{URL: "https://www.rust-lang.org/", CreationDate: time.Unix(1600000003, 0)},
+					},
+				},
+			},
+		},
+		{
+			Name: "",
+			Bookmarks: []entity.ExportBookmark{
+				{URL: "https://example.com/", CreationDate: time.Unix(1600000002, 0)},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewNetscapeWriter().Write(&buf, folders); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var entries []entity.ImportedBookmark
+	err := NewNetscapeParser().Parse(context.Background(), &buf, func(b entity.ImportedBookmark) error {
+		entries = append(entries, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].URL != "https://www.rust-lang.org/" || strings.Join(entries[0].Folders, "/") != "Programming/Rust" {
+		t.Errorf("unexpected nested entry: %+v", entries[0])
+	}
+	if entries[1].URL != "https://go.dev/?a=1&b=2" || *entries[1].Title != title || strings.Join(entries[1].Folders, "/") != "Programming" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+	if entries[2].URL != "https://example.com/" || len(entries[2].Folders) != 0 || *entries[2].Title != "https://example.com/" {
+		t.Errorf("unexpected third entry: %+v", entries[2])
+	}
+}
@@ -0,0 +1,56 @@
+package bookmarkformat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"garden3/internal/domain/entity"
+)
+
+// PinboardParser implements the output.BookmarkParser interface for the JSON export from Pinboard
+type PinboardParser struct{}
+
+// NewPinboardParser creates a new Pinboard JSON export parser
+func NewPinboardParser() *PinboardParser {
+	return &PinboardParser{}
+}
+
+// pinboardEntry is one post of a Pinboard export, where description holds the title
+type pinboardEntry struct {
+	Href        string `json:"href"`
+	Description string `json:"description"`
+	Extended    string `json:"extended"`
+	Time        string `json:"time"`
+	Tags        string `json:"tags"`
+}
+
+func (p *PinboardParser) Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error {
+	return readJSONArray(ctx, r, func(raw json.RawMessage) error {
+		var entry pinboardEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return fmt.Errorf("failed to parse Pinboard entry: %w", err)
+		}
+
+		href := strings.TrimSpace(entry.Href)
+		if href == "" {
+			return nil
+		}
+
+		rawEntry, err := marshalRawEntry(string(entity.ImportFormatPinboard), raw)
+		if err != nil {
+			return err
+		}
+
+		return emit(entity.ImportedBookmark{
+			URL:         href,
+			Title:       optionalString(entry.Description),
+			Description: optionalString(entry.Extended),
+			AddDate:     parseTimestamp(entry.Time),
+			Tags:        splitTags(entry.Tags, " "),
+			RawEntry:    rawEntry,
+		})
+	})
+}
@@ -0,0 +1,137 @@
+package bookmarkformat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"garden3/internal/domain/entity"
+)
+
+// PocketHTMLParser implements the output.BookmarkParser interface for the ril_export.html file from Pocket
+type PocketHTMLParser struct{}
+
+// NewPocketHTMLParser creates a new Pocket HTML export parser
+func NewPocketHTMLParser() *PocketHTMLParser {
+	return &PocketHTMLParser{}
+}
+
+// pocketHTMLEntry is the raw form of a Pocket link, stored as the bookmark source
+type pocketHTMLEntry struct {
+	Attributes map[string]string `json:"attributes"`
+	Title      string            `json:"title"`
+	List       string            `json:"list,omitempty"`
+}
+
+func (p *PocketHTMLParser) Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error {
+	tokenizer := nethtml.NewTokenizer(r)
+
+	// The export groups links under "Unread" and "Read Archive" headings
+	var list string
+	var current *pocketHTMLEntry
+	var text strings.Builder
+	inHeading := false
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch tokenizer.Next() {
+		case nethtml.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return fmt.Errorf("failed to read Pocket export: %w", err)
+			}
+			return nil
+
+		case nethtml.TextToken:
+			if inHeading || current != nil {
+				text.Write(tokenizer.Text())
+			}
+
+		case nethtml.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "h1":
+				inHeading = true
+				text.Reset()
+			case "a":
+				current = &pocketHTMLEntry{
+					Attributes: readAttributes(tokenizer, hasAttr),
+					List:       list,
+				}
+				text.Reset()
+			}
+
+		case nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "h1":
+				list = strings.TrimSpace(text.String())
+				inHeading = false
+			case "a":
+				if current == nil {
+					continue
+				}
+				entry := current
+				current = nil
+				entry.Title = strings.TrimSpace(text.String())
+				if err := emitPocketHTMLEntry(entry, emit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func emitPocketHTMLEntry(entry *pocketHTMLEntry, emit func(entity.ImportedBookmark) error) error {
+	href := strings.TrimSpace(entry.Attributes["href"])
+	if href == "" {
+		return nil
+	}
+
+	raw, err := marshalRawEntry(string(entity.ImportFormatPocketHTML), entry)
+	if err != nil {
+		return err
+	}
+
+	return emit(entity.ImportedBookmark{
+		URL:      href,
+		Title:    optionalString(entry.Title),
+		AddDate:  parseUnixTimestamp(entry.Attributes["time_added"]),
+		Tags:     splitTags(entry.Attributes["tags"], ","),
+		RawEntry: raw,
+	})
+}
+
+// PocketCSVParser implements the output.BookmarkParser interface for the part_*.csv files from Pocket
+type PocketCSVParser struct{}
+
+// NewPocketCSVParser creates a new Pocket CSV export parser
+func NewPocketCSVParser() *PocketCSVParser {
+	return &PocketCSVParser{}
+}
+
+func (p *PocketCSVParser) Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error {
+	return readCSV(ctx, r, func(row map[string]string) error {
+		href := strings.TrimSpace(row["url"])
+		if href == "" {
+			return nil
+		}
+
+		raw, err := marshalRawEntry(string(entity.ImportFormatPocketCSV), row)
+		if err != nil {
+			return err
+		}
+
+		return emit(entity.ImportedBookmark{
+			URL:      href,
+			Title:    optionalString(row["title"]),
+			AddDate:  parseUnixTimestamp(row["time_added"]),
+			Tags:     splitTags(row["tags"], "|"),
+			RawEntry: raw,
+		})
+	})
+}
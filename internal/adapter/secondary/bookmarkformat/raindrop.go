@@ -0,0 +1,55 @@
+package bookmarkformat
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"garden3/internal/domain/entity"
+)
+
+// RaindropParser implements the output.BookmarkParser interface for the CSV export from Raindrop.io
+type RaindropParser struct{}
+
+// NewRaindropParser creates a new Raindrop.io CSV export parser
+func NewRaindropParser() *RaindropParser {
+	return &RaindropParser{}
+}
+
+// raindropUnsorted is the collection Raindrop.io uses for bookmarks outside any collection
+const raindropUnsorted = "Unsorted"
+
+func (p *RaindropParser) Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error {
+	return readCSV(ctx, r, func(row map[string]string) error {
+		href := strings.TrimSpace(row["url"])
+		if href == "" {
+			return nil
+		}
+
+		raw, err := marshalRawEntry(string(entity.ImportFormatRaindrop), row)
+		if err != nil {
+			return err
+		}
+
+		// Nested collections are exported as a slash-separated path
+		var folders []string
+		if folder := strings.TrimSpace(row["folder"]); folder != "" && folder != raindropUnsorted {
+			folders = splitTags(folder, "/")
+		}
+
+		description := optionalString(row["note"])
+		if description == nil {
+			description = optionalString(row["excerpt"])
+		}
+
+		return emit(entity.ImportedBookmark{
+			URL:         href,
+			Title:       optionalString(row["title"]),
+			Description: description,
+			AddDate:     parseTimestamp(row["created"]),
+			Folders:     folders,
+			Tags:        splitTags(row["tags"], ","),
+			RawEntry:    raw,
+		})
+	})
+}
@@ -0,0 +1,146 @@
+package bookmarkformat
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// rawEntry is the bookmark source stored for formats whose entries are records rather than markup
+type rawEntry struct {
+	Format string      `json:"format"`
+	Entry  interface{} `json:"entry"`
+}
+
+func marshalRawEntry(format string, entry interface{}) (json.RawMessage, error) {
+	raw, err := json.Marshal(rawEntry{Format: format, Entry: entry})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bookmark entry: %w", err)
+	}
+	return raw, nil
+}
+
+// readCSV streams the rows of a CSV file with a header row, keyed by lowercased column name
+func readCSV(ctx context.Context, r io.Reader, handle func(row map[string]string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV record: %w", err)
+		}
+
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = value
+			}
+		}
+		if err := handle(row); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSONArray streams the elements of a top-level JSON array without loading the whole file
+func readJSONArray(ctx context.Context, r io.Reader, handle func(raw json.RawMessage) error) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("failed to read JSON: expected an array of bookmarks")
+	}
+
+	for decoder.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return fmt.Errorf("failed to read JSON entry: %w", err)
+		}
+		if err := handle(raw); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to read JSON: %w", err)
+	}
+	return nil
+}
+
+// optionalString returns nil for blank values
+func optionalString(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// splitTags splits a tag list on sep, dropping blank entries
+func splitTags(value, sep string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, sep) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// timestampLayouts lists the date formats used by the supported export files
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTimestamp parses an ISO 8601 date or a Unix timestamp
+func parseTimestamp(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return parseUnixTimestamp(value)
+}
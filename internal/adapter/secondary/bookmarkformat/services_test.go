@@ -0,0 +1,133 @@
+package bookmarkformat
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+const pocketHTMLFixture = `<!DOCTYPE html>
+<html>
+<head><title>Pocket Export</title></head>
+<body>
+<h1>Unread</h1>
+<ul>
+<li><a href="https://go.dev/blog/" time_added="1600000001" tags="go,blog">The Go Blog</a></li>
+</ul>
+<h1>Read Archive</h1>
+<ul>
+<li><a href="https://example.com/" time_added="1600000002" tags="">https://example.com/</a></li>
+</ul>
+</body>
+</html>
+`
+
+const pocketCSVFixture = "title,url,time_added,cursor,tags,status\n" +
+	"The Go Blog,https://go.dev/blog/,1600000001,,go|blog,unread\n" +
+	"\"Quoted, title\",https://example.com/,1600000002,,,archive\n"
+
+const pinboardFixture = `[
+{"href":"https://go.dev/blog/","description":"The Go Blog","extended":"Posts about Go","meta":"abc","hash":"def","time":"2020-09-13T12:26:41Z","shared":"no","toread":"yes","tags":"go blog"},
+{"href":"https://example.com/","description":"","extended":"","time":"2020-09-13T12:26:42Z","shared":"yes","toread":"no","tags":""}
+]`
+
+const raindropFixture = "id,title,note,excerpt,url,folder,tags,created,cover,highlights,favorite\n" +
+	"1,The Go Blog,My note,An excerpt,https://go.dev/blog/,Programming/Go,\"go, blog\",2020-09-13T12:26:41.000Z,,,false\n" +
+	"2,Example,,An excerpt,https://example.com/,Unsorted,,2020-09-13T12:26:42.000Z,,,true\n"
+
+const wallabagFixture = `[
+{"is_archived":0,"is_starred":1,"tags":["go","blog"],"title":"The Go Blog","url":"https://go.dev/blog/","content":"<p>Article text</p>","created_at":"2020-09-13T14:26:41+02:00","mimetype":"text/html"},
+{"is_archived":1,"is_starred":0,"tags":[],"title":"Example","url":"https://example.com/","content":"","created_at":"2020-09-13T14:26:42+02:00"}
+]`
+
+func TestServiceExportParsers(t *testing.T) {
+	first := time.Date(2020, 9, 13, 12, 26, 41, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		parser      output.BookmarkParser
+		fixture     string
+		format      entity.ImportFormat
+		title       string
+		description string
+		tags        []string
+		folders     []string
+		addDate     time.Time
+		archived    string
+	}{
+		{name: "pocket html", parser: NewPocketHTMLParser(), fixture: pocketHTMLFixture, format: entity.ImportFormatPocketHTML, title: "The Go Blog", tags: []string{"go", "blog"}, addDate: time.Unix(1600000001, 0)},
+		{name: "pocket csv", parser: NewPocketCSVParser(), fixture: pocketCSVFixture, format: entity.ImportFormatPocketCSV, title: "The Go Blog", tags: []string{"go", "blog"}, addDate: time.Unix(1600000001, 0)},
+		{name: "pinboard", parser: NewPinboardParser(), fixture: pinboardFixture, format: entity.ImportFormatPinboard, title: "The Go Blog", description: "Posts about Go", tags: []string{"go", "blog"}, addDate: first},
+		{name: "raindrop", parser: NewRaindropParser(), fixture: raindropFixture, format: entity.ImportFormatRaindrop, title: "The Go Blog", description: "My note", tags: []string{"go", "blog"}, folders: []string{"Programming", "Go"}, addDate: first},
+		{name: "wallabag", parser: NewWallabagParser(), fixture: wallabagFixture, format: entity.ImportFormatWallabag, title: "The Go Blog", tags: []string{"go", "blog"}, addDate: first, archived: "<p>Article text</p>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var entries []entity.ImportedBookmark
+			err := tc.parser.Parse(context.Background(), strings.NewReader(tc.fixture), func(b entity.ImportedBookmark) error {
+				entries = append(entries, b)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("expected 2 entries, got %d", len(entries))
+			}
+
+			got := entries[0]
+			if got.URL != "https://go.dev/blog/" {
+				t.Errorf("URL = %q, want https://go.dev/blog/", got.URL)
+			}
+			if got.Title == nil || *got.Title != tc.title {
+				t.Errorf("Title = %v, want %q", got.Title, tc.title)
+			}
+			if tc.description != "" && (got.Description == nil || *got.Description != tc.description) {
+				t.Errorf("Description = %v, want %q", got.Description, tc.description)
+			}
+			if strings.Join(got.Tags, ",") != strings.Join(tc.tags, ",") {
+				t.Errorf("Tags = %v, want %v", got.Tags, tc.tags)
+			}
+			if strings.Join(got.Folders, "/") != strings.Join(tc.folders, "/") {
+				t.Errorf("Folders = %v, want %v", got.Folders, tc.folders)
+			}
+			if got.AddDate == nil || !got.AddDate.Equal(tc.addDate) {
+				t.Errorf("AddDate = %v, want %v", got.AddDate, tc.addDate)
+			}
+			if tc.archived != "" && (got.ArchivedContent == nil || *got.ArchivedContent != tc.archived || !got.ArchivedHTML) {
+				t.Errorf("ArchivedContent = %v, want %q", got.ArchivedContent, tc.archived)
+			}
+
+			var raw rawEntry
+			if err := json.Unmarshal(got.RawEntry, &raw); err != nil {
+				t.Fatalf("RawEntry is not valid JSON: %v", err)
+			}
+			if raw.Format != string(tc.format) {
+				t.Errorf("RawEntry format = %q, want %q", raw.Format, tc.format)
+			}
+			if strings.Contains(string(got.RawEntry), "Article text") {
+				t.Errorf("RawEntry should not repeat the archived content: %s", got.RawEntry)
+			}
+
+			second := entries[1]
+			if second.URL != "https://example.com/" || len(second.Tags) != 0 || len(second.Folders) != 0 || second.ArchivedContent != nil {
+				t.Errorf("unexpected second entry: %+v", second)
+			}
+		})
+	}
+}
+
+func TestReadJSONArrayRejectsObjects(t *testing.T) {
+	err := NewPinboardParser().Parse(context.Background(), strings.NewReader(`{"href":"https://go.dev/"}`), func(entity.ImportedBookmark) error {
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error for a JSON object")
+	}
+}
@@ -0,0 +1,63 @@
+package bookmarkformat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"garden3/internal/domain/entity"
+)
+
+// WallabagParser implements the output.BookmarkParser interface for the JSON export from wallabag
+type WallabagParser struct{}
+
+// NewWallabagParser creates a new wallabag JSON export parser
+func NewWallabagParser() *WallabagParser {
+	return &WallabagParser{}
+}
+
+// wallabagEntry is one article of a wallabag export, where content is the saved article HTML
+type wallabagEntry struct {
+	Title     string   `json:"title"`
+	URL       string   `json:"url"`
+	Content   string   `json:"content"`
+	CreatedAt string   `json:"created_at"`
+	Tags      []string `json:"tags"`
+}
+
+func (p *WallabagParser) Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error {
+	return readJSONArray(ctx, r, func(raw json.RawMessage) error {
+		var entry wallabagEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return fmt.Errorf("failed to parse wallabag entry: %w", err)
+		}
+
+		href := strings.TrimSpace(entry.URL)
+		if href == "" {
+			return nil
+		}
+
+		// The article is stored as reader content, so it is left out of the source
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return fmt.Errorf("failed to parse wallabag entry: %w", err)
+		}
+		delete(fields, "content")
+		rawEntry, err := marshalRawEntry(string(entity.ImportFormatWallabag), fields)
+		if err != nil {
+			return err
+		}
+
+		return emit(entity.ImportedBookmark{
+			URL:             href,
+			Title:           optionalString(entry.Title),
+			AddDate:         parseTimestamp(entry.CreatedAt),
+			Tags:            entry.Tags,
+			RawEntry:        rawEntry,
+			ArchivedContent: optionalString(entry.Content),
+			ArchivedHTML:    true,
+		})
+	})
+}
@@ -0,0 +1,350 @@
+// Package chunking cuts Markdown documents into chunks that fit the input of an embedding model. Chunks
+// follow the structure of the document, carry the headings they sit under and overlap within a section
+package chunking
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Default chunk sizes, in estimated tokens
+const (
+	DefaultMaxTokens     = 512
+	DefaultOverlapTokens = 64
+)
+
+// Options sizes chunks. MaxTokens bounds the estimated tokens of a chunk including its heading breadcrumb
+// and OverlapTokens bounds the text a chunk repeats from the end of the previous one in the same section
+type Options struct {
+	MaxTokens     int
+	OverlapTokens int
+}
+
+// ForContext returns the default options, shrunk for models that take fewer tokens at once than
+// DefaultMaxTokens. A tenth of the context is left as margin for the estimate; 0 means the context is unknown
+func ForContext(contextTokens int) Options {
+	opts := Options{MaxTokens: DefaultMaxTokens, OverlapTokens: DefaultOverlapTokens}
+	if limit := contextTokens * 9 / 10; limit > 0 && limit < opts.MaxTokens {
+		opts.MaxTokens = limit
+		opts.OverlapTokens = limit / 8
+	}
+	return opts
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxTokens < 1 {
+		o.MaxTokens = DefaultMaxTokens
+	}
+	if o.OverlapTokens < 0 {
+		o.OverlapTokens = 0
+	}
+	if o.OverlapTokens > o.MaxTokens/2 {
+		o.OverlapTokens = o.MaxTokens / 2
+	}
+	return o
+}
+
+// Chunk is a piece of a document. Text is what gets embedded: the heading breadcrumb followed by the
+// Markdown of the chunk. Start and End locate that Markdown in the document in Unicode code points, and
+// Headings is the path of headings it sits under, starting at the top level
+type Chunk struct {
+	Index    int
+	Text     string
+	Start    int
+	End      int
+	Headings []string
+	Tokens   int
+}
+
+// Split cuts a Markdown document into chunks of at most opts.MaxTokens estimated tokens. A document that
+// fits is returned whole as a single chunk. Otherwise a heading starts a new chunk once the current one
+// holds a quarter of the budget, and a block that does not fit moves whole to the next chunk. Only blocks
+// too large for a chunk of their own are split, at list items, table rows, code lines, sentences and
+// finally words; a chunk continuing a section repeats up to opts.OverlapTokens of the text before it
+func Split(doc string, opts Options) []Chunk {
+	opts = opts.withDefaults()
+	if tokens := EstimateTokens(doc); tokens <= opts.MaxTokens {
+		return []Chunk{{Text: doc, End: utf8.RuneCountInString(doc), Tokens: tokens}}
+	}
+
+	c := &chunker{doc: doc, opts: opts, runes: runeCounter{doc: doc}}
+	for _, b := range parseBlocks(doc) {
+		c.addBlock(b)
+	}
+	if len(c.units) > 0 {
+		c.emit(c.units)
+	}
+	return c.chunks
+}
+
+// heading is an entry of the heading path
+type heading struct {
+	level int
+	title string
+}
+
+// unit is a piece of a block that goes into a chunk whole
+type unit struct {
+	span
+	tokens  int
+	heading bool
+
+	code   *block
+	opens  bool
+	closes bool
+}
+
+// chunker packs the units of consecutive blocks into chunks
+type chunker struct {
+	doc   string
+	opts  Options
+	path  []heading
+	runes runeCounter
+
+	units       []unit
+	tokens      int
+	overlap     int
+	headings    []string
+	crumb       string
+	crumbTokens int
+
+	chunks []Chunk
+}
+
+func (c *chunker) addBlock(b block) {
+	overlap := b.kind != blockHeading
+	if b.kind == blockHeading {
+		if c.tokens >= c.opts.MaxTokens/4 {
+			c.flush(false)
+		}
+		for len(c.path) > 0 && c.path[len(c.path)-1].level >= b.level {
+			c.path = c.path[:len(c.path)-1]
+		}
+		c.path = append(c.path, heading{level: b.level, title: b.title})
+	}
+
+	units := c.blockUnits(b)
+	if len(units) == 0 {
+		return
+	}
+
+	if c.fits(units...) {
+		c.append(units...)
+		return
+	}
+	c.flush(overlap)
+	if !c.fits(units...) {
+		c.dropOverlap()
+	}
+	if c.fits(units...) {
+		c.append(units...)
+		return
+	}
+
+	for _, u := range units {
+		if !c.fits(u) {
+			c.flush(overlap)
+			if !c.fits(u) {
+				c.dropOverlap()
+			}
+		}
+		c.append(u)
+	}
+}
+
+// blockUnits cuts a block into units, splitting pieces that are too large for a chunk of their own
+func (c *chunker) blockUnits(b block) []unit {
+	budget := c.opts.MaxTokens - EstimateTokens(breadcrumb(c.titles(), c.opts.MaxTokens/4))
+	if b.kind == blockCode {
+		budget -= EstimateTokens(b.fence) + EstimateTokens(b.closing)
+	}
+
+	spans := blockSpans(c.doc, b)
+	units := make([]unit, 0, len(spans))
+	for i, s := range spans {
+		pieces := []span{s}
+		if EstimateTokens(c.doc[s.start:s.end]) > budget {
+			pieces = splitSpan(c.doc, s, budget)
+		}
+
+		for j, p := range pieces {
+			u := unit{span: p, tokens: EstimateTokens(c.doc[p.start:p.end]), heading: b.kind == blockHeading}
+			if b.kind == blockCode {
+				u.code = &b
+				u.opens = i == 0 && j == 0
+				u.closes = i > 0 && i == len(spans)-1 && j == len(pieces)-1 && closesFence(c.doc[s.start:s.end], b.closing)
+			}
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// fits reports whether units still fit into the pending chunk, counting the breadcrumb and the fences
+// added where the chunk would cut through a code block
+func (c *chunker) fits(units ...unit) bool {
+	tokens := c.tokens
+	for _, u := range units {
+		tokens += u.tokens
+	}
+
+	first := units
+	if len(c.units) > 0 {
+		first = c.units
+		tokens += c.crumbTokens
+	} else {
+		tokens += EstimateTokens(breadcrumb(c.titles(), c.opts.MaxTokens/4))
+	}
+	if len(first) > 0 && first[0].code != nil && !first[0].opens {
+		tokens += EstimateTokens(first[0].code.fence)
+	}
+	if last := units[len(units)-1]; last.code != nil && !last.closes {
+		tokens += EstimateTokens(last.code.closing)
+	}
+	return tokens <= c.opts.MaxTokens
+}
+
+func (c *chunker) append(units ...unit) {
+	if len(units) == 0 {
+		return
+	}
+	if len(c.units) == 0 {
+		c.headings = c.titles()
+		shown := c.headings
+		for i := 0; i < len(units) && units[i].heading && len(shown) > 0; i++ {
+			shown = shown[:len(shown)-1]
+		}
+		c.crumb = breadcrumb(shown, c.opts.MaxTokens/4)
+		c.crumbTokens = EstimateTokens(c.crumb)
+	}
+
+	c.units = append(c.units, units...)
+	for _, u := range units {
+		c.tokens += u.tokens
+	}
+}
+
+// flush emits the pending chunk. Headings it ends with move on to the next chunk, so a chunk of only
+// headings stays pending. Otherwise, with overlap, the next chunk starts with the last units of the emitted
+// one, up to OverlapTokens and never past a heading
+func (c *chunker) flush(overlap bool) {
+	keep := len(c.units)
+	for keep > 0 && c.units[keep-1].heading {
+		keep--
+	}
+	if keep == 0 {
+		return
+	}
+	c.emit(c.units[:keep])
+
+	carried := append([]unit(nil), c.units[keep:]...)
+	carriedOverlap := 0
+	if len(carried) == 0 && overlap && c.opts.OverlapTokens > 0 {
+		tokens := 0
+		i := len(c.units)
+		for i > 1 {
+			u := c.units[i-1]
+			if tokens+u.tokens > c.opts.OverlapTokens {
+				break
+			}
+			tokens += u.tokens
+			i--
+			if u.heading {
+				break
+			}
+		}
+		carried = append(carried, c.units[i:]...)
+		carriedOverlap = len(carried)
+	}
+
+	c.units = nil
+	c.tokens = 0
+	c.append(carried...)
+	c.overlap = carriedOverlap
+}
+
+// dropOverlap removes the text repeated from the previous chunk from the pending one
+func (c *chunker) dropOverlap() {
+	if c.overlap == 0 {
+		return
+	}
+	rest := c.units[c.overlap:]
+	c.units = nil
+	c.tokens = 0
+	c.overlap = 0
+	c.append(rest...)
+}
+
+// emit renders the pending chunk, reopening or closing code fences the chunk boundaries cut through
+func (c *chunker) emit(units []unit) {
+	first, last := units[0], units[len(units)-1]
+
+	body := strings.TrimSpace(c.doc[first.start:last.end])
+	if body == "" {
+		return
+	}
+	if first.code != nil && !first.opens {
+		body = first.code.fence + "\n" + body
+	}
+	if last.code != nil && !last.closes {
+		body += "\n" + last.code.closing
+	}
+
+	text := body
+	if c.crumb != "" {
+		text = c.crumb + "\n\n" + body
+	}
+
+	c.chunks = append(c.chunks, Chunk{
+		Index:    len(c.chunks),
+		Text:     text,
+		Start:    c.runes.at(first.start),
+		End:      c.runes.at(last.end),
+		Headings: c.headings,
+		Tokens:   EstimateTokens(text),
+	})
+}
+
+// titles returns the current heading path
+func (c *chunker) titles() []string {
+	if len(c.path) == 0 {
+		return nil
+	}
+	titles := make([]string, len(c.path))
+	for i, h := range c.path {
+		titles[i] = h.title
+	}
+	return titles
+}
+
+// breadcrumb joins a heading path into "Top > Section > Subsection", leaving out the outermost headings
+// when it would take more than maxTokens
+func breadcrumb(titles []string, maxTokens int) string {
+	for len(titles) > 0 {
+		crumb := strings.Join(titles, " > ")
+		if EstimateTokens(crumb) <= maxTokens {
+			return crumb
+		}
+		titles = titles[1:]
+	}
+	return ""
+}
+
+// runeCounter converts byte offsets into the document to code point offsets, counting from the last
+// offset it converted
+type runeCounter struct {
+	doc       string
+	byteIndex int
+	runeIndex int
+}
+
+func (r *runeCounter) at(offset int) int {
+	for r.byteIndex > offset {
+		_, size := utf8.DecodeLastRuneInString(r.doc[:r.byteIndex])
+		r.byteIndex -= size
+		r.runeIndex--
+	}
+	r.runeIndex += utf8.RuneCountInString(r.doc[r.byteIndex:offset])
+	r.byteIndex = offset
+	return r.runeIndex
+}
@@ -0,0 +1,190 @@
+package chunking
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sentences returns n numbered sentences of about twelve tokens each
+func sentences(topic string, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("Sentence %d explains another detail about %s in plain words.", i+1, topic)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestEstimateTokens(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "short words", text: "the cat sat", want: 3},
+		{name: "long word", text: "internationalization", want: 4},
+		{name: "punctuation", text: "Hello, world!", want: 4},
+		{name: "ideographs", text: "日本語", want: 3},
+		{name: "whitespace only", text: " \n\t ", want: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EstimateTokens(tc.text); got != tc.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestForContext(t *testing.T) {
+	testCases := []struct {
+		name  string
+		model string
+		want  Options
+	}{
+		{name: "unknown model", model: "my-model", want: Options{MaxTokens: DefaultMaxTokens, OverlapTokens: DefaultOverlapTokens}},
+		{name: "long context", model: "nomic-embed-text:latest", want: Options{MaxTokens: DefaultMaxTokens, OverlapTokens: DefaultOverlapTokens}},
+		{name: "short context", model: "all-minilm:l6-v2", want: Options{MaxTokens: 230, OverlapTokens: 28}},
+		{name: "namespaced model", model: "BAAI/bge-small-en-v1.5", want: Options{MaxTokens: 460, OverlapTokens: 57}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ForContext(ContextTokens(tc.model)); got != tc.want {
+				t.Errorf("ForContext(ContextTokens(%q)) = %+v, want %+v", tc.model, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	opts := Options{MaxTokens: 120, OverlapTokens: 20}
+
+	t.Run("short document stays whole", func(t *testing.T) {
+		doc := "# Title\n\nA short note.\n"
+		chunks := Split(doc, opts)
+		if len(chunks) != 1 || chunks[0].Text != doc || chunks[0].Start != 0 || chunks[0].End != len(doc) {
+			t.Fatalf("Split() = %+v, want the whole document", chunks)
+		}
+	})
+
+	t.Run("sections carry their headings", func(t *testing.T) {
+		doc := "# Guide\n\n" + sentences("the guide", 3) + "\n\n## Install\n\n" + sentences("installing", 6) +
+			"\n\n### Linux\n\n" + sentences("linux", 3) + "\n\n## Usage\n\n" + sentences("usage", 3) + "\n"
+		chunks := Split(doc, opts)
+
+		var usage *Chunk
+		for i := range chunks {
+			if strings.Contains(chunks[i].Text, "about usage") {
+				usage = &chunks[i]
+				break
+			}
+		}
+		if usage == nil {
+			t.Fatalf("no chunk holds the usage section: %+v", chunks)
+		}
+		if want := []string{"Guide", "Usage"}; !reflect.DeepEqual(usage.Headings, want) {
+			t.Errorf("Headings = %q, want %q", usage.Headings, want)
+		}
+		if !strings.HasPrefix(usage.Text, "Guide\n\n## Usage") {
+			t.Errorf("Text = %q, want the breadcrumb before the section heading", usage.Text)
+		}
+
+		var linux *Chunk
+		for i := range chunks {
+			if strings.Contains(chunks[i].Text, "about linux") {
+				linux = &chunks[i]
+				break
+			}
+		}
+		if linux == nil || !reflect.DeepEqual(linux.Headings[:2], []string{"Guide", "Install"}) {
+			t.Errorf("linux chunk = %+v, want it under Guide > Install", linux)
+		}
+	})
+
+	t.Run("long sections overlap", func(t *testing.T) {
+		doc := "# Notes\n\n" + sentences("overlap", 30) + "\n"
+		chunks := Split(doc, opts)
+		if len(chunks) < 3 {
+			t.Fatalf("Split() returned %d chunks, want at least 3", len(chunks))
+		}
+
+		for i := 1; i < len(chunks); i++ {
+			if chunks[i].Start >= chunks[i-1].End {
+				t.Errorf("chunk %d starts at %d, after chunk %d ends at %d", i, chunks[i].Start, i-1, chunks[i-1].End)
+			}
+			if !strings.HasPrefix(chunks[i].Text, "Notes\n\n") {
+				t.Errorf("chunk %d = %q, want the Notes breadcrumb", i, chunks[i].Text)
+			}
+		}
+	})
+
+	t.Run("blocks stay whole", func(t *testing.T) {
+		code := "```go\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n```"
+		list := "- first item of the list\n- second item of the list\n  continued on an indented line\n- third item"
+		doc := sentences("the intro", 8) + "\n\n" + code + "\n\n" + sentences("the middle", 8) + "\n\n" + list + "\n"
+		chunks := Split(doc, opts)
+
+		for name, block := range map[string]string{"code": code, "list": list} {
+			found := false
+			for _, chunk := range chunks {
+				if strings.Contains(chunk.Text, block) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("no chunk holds the whole %s block: %+v", name, chunks)
+			}
+		}
+	})
+
+	t.Run("split code blocks keep their fences", func(t *testing.T) {
+		var lines []string
+		for i := 0; i < 60; i++ {
+			lines = append(lines, fmt.Sprintf("\tvalue%d := compute(%d)", i, i))
+		}
+		doc := "```go\n" + strings.Join(lines, "\n") + "\n```\n"
+		chunks := Split(doc, opts)
+		if len(chunks) < 2 {
+			t.Fatalf("Split() returned %d chunks, want the code block split", len(chunks))
+		}
+
+		for i, chunk := range chunks {
+			if !strings.HasPrefix(chunk.Text, "```go\n") || !strings.HasSuffix(chunk.Text, "\n```") {
+				t.Errorf("chunk %d = %q, want it fenced", i, chunk.Text)
+			}
+		}
+	})
+
+	t.Run("chunks cover the document within budget", func(t *testing.T) {
+		word := strings.Repeat("x", 1000)
+		doc := "# Über\n\n" + sentences("ünïcode", 12) + "\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n" + word + "\n\n## Ende\n\n" + sentences("the end", 12)
+		chunks := Split(doc, opts)
+		runes := []rune(doc)
+
+		covered := make([]bool, len(runes))
+		for i, chunk := range chunks {
+			if chunk.Index != i {
+				t.Errorf("chunk %d has index %d", i, chunk.Index)
+			}
+			if chunk.Tokens > opts.MaxTokens {
+				t.Errorf("chunk %d has %d tokens, over %d", i, chunk.Tokens, opts.MaxTokens)
+			}
+			if piece := string(runes[chunk.Start:chunk.End]); !strings.Contains(chunk.Text, piece) {
+				t.Errorf("chunk %d text %q does not hold the document piece %q", i, chunk.Text, piece)
+			}
+			for j := chunk.Start; j < chunk.End; j++ {
+				covered[j] = true
+			}
+		}
+
+		for i, r := range runes {
+			if !covered[i] && !strings.ContainsRune(" \n", r) {
+				t.Fatalf("character %d %q is in no chunk", i, r)
+			}
+		}
+	})
+}
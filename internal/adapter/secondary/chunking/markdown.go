@@ -0,0 +1,375 @@
+package chunking
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockList
+	blockTable
+	blockCode
+)
+
+// block is a top-level Markdown element. Offsets are bytes into the document
+type block struct {
+	kind  blockKind
+	start int
+	end   int
+
+	level int
+	title string
+
+	fence   string
+	closing string
+}
+
+// line is a line of the document without its line break
+type line struct {
+	start int
+	end   int
+}
+
+// splitLines cuts text into lines, accepting \n and \r\n breaks
+func splitLines(text string) []line {
+	var lines []line
+	start := 0
+	for start <= len(text) {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			lines = append(lines, line{start: start, end: trimCR(text, start, len(text))})
+			break
+		}
+		lines = append(lines, line{start: start, end: trimCR(text, start, start+end)})
+		start += end + 1
+	}
+	return lines
+}
+
+func trimCR(text string, start, end int) int {
+	if end > start && text[end-1] == '\r' {
+		return end - 1
+	}
+	return end
+}
+
+// parseBlocks splits a Markdown document into headings, fenced code blocks, lists, tables and paragraphs.
+// Blank lines and thematic breaks separate blocks and belong to none
+func parseBlocks(doc string) []block {
+	lines := splitLines(doc)
+	text := func(i int) string { return doc[lines[i].start:lines[i].end] }
+
+	var blocks []block
+	i := 0
+	for i < len(lines) {
+		current := text(i)
+		trimmed := strings.TrimSpace(current)
+
+		switch {
+		case trimmed == "" || isThematicBreak(trimmed):
+			i++
+
+		case codeFence(current) != "":
+			fence := codeFence(current)
+			j := i + 1
+			for j < len(lines) && !closesFence(text(j), fence) {
+				j++
+			}
+			if j == len(lines) {
+				j--
+			}
+			blocks = append(blocks, block{
+				kind:    blockCode,
+				start:   lines[i].start,
+				end:     lines[j].end,
+				fence:   strings.TrimSpace(current),
+				closing: fence,
+			})
+			i = j + 1
+
+		case isATXHeading(current):
+			level, title := atxHeading(current)
+			blocks = append(blocks, block{kind: blockHeading, start: lines[i].start, end: lines[i].end, level: level, title: title})
+			i++
+
+		case isTableRow(trimmed):
+			j := i
+			for j < len(lines) && isTableRow(strings.TrimSpace(text(j))) {
+				j++
+			}
+			blocks = append(blocks, block{kind: blockTable, start: lines[i].start, end: lines[j-1].end})
+			i = j
+
+		case isListItem(current):
+			last := i
+			for j := i + 1; j < len(lines); j++ {
+				next := text(j)
+				if strings.TrimSpace(next) == "" {
+					continue
+				}
+				if !isListItem(next) && !isIndented(next) {
+					break
+				}
+				last = j
+			}
+			blocks = append(blocks, block{kind: blockList, start: lines[i].start, end: lines[last].end})
+			i = last + 1
+
+		default:
+			j := i + 1
+			for j < len(lines) && !interruptsParagraph(text(j)) {
+				j++
+			}
+			if j < len(lines) && j > i && isSetextUnderline(text(j)) {
+				titles := make([]string, 0, j-i)
+				for k := i; k < j; k++ {
+					titles = append(titles, strings.TrimSpace(text(k)))
+				}
+				level := 2
+				if strings.HasPrefix(strings.TrimSpace(text(j)), "=") {
+					level = 1
+				}
+				blocks = append(blocks, block{kind: blockHeading, start: lines[i].start, end: lines[j].end, level: level, title: strings.Join(titles, " ")})
+				i = j + 1
+				continue
+			}
+			blocks = append(blocks, block{kind: blockParagraph, start: lines[i].start, end: lines[j-1].end})
+			i = j
+		}
+	}
+	return blocks
+}
+
+// interruptsParagraph reports whether a line ends the paragraph above it
+func interruptsParagraph(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	return trimmed == "" || codeFence(text) != "" || isATXHeading(text) || isListItem(text) ||
+		isTableRow(trimmed) || isSetextUnderline(text)
+}
+
+// codeFence returns the fence opening a fenced code block on the line, or "" when it opens none
+func codeFence(text string) string {
+	trimmed := strings.TrimLeft(text, " ")
+	if len(text)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+
+	marker := trimmed[0]
+	if marker != '`' && marker != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == marker {
+		n++
+	}
+	if n < 3 || (marker == '`' && strings.Contains(trimmed[n:], "`")) {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// closesFence reports whether the line closes a code block opened with fence
+func closesFence(text, fence string) bool {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, fence) {
+		return false
+	}
+	return strings.Trim(trimmed, fence[:1]) == ""
+}
+
+func isATXHeading(text string) bool {
+	level, _ := atxHeading(text)
+	return level > 0
+}
+
+// atxHeading returns the level and text of a "# Heading" line, or level 0 when the line is no heading
+func atxHeading(text string) (int, string) {
+	trimmed := strings.TrimLeft(text, " ")
+	if len(text)-len(trimmed) > 3 {
+		return 0, ""
+	}
+
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+
+	title := strings.TrimSpace(rest)
+	if closing := strings.TrimRight(title, "#"); closing == "" || strings.HasSuffix(closing, " ") {
+		title = strings.TrimSpace(closing)
+	}
+	return level, title
+}
+
+func isSetextUnderline(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || len(text)-len(strings.TrimLeft(text, " ")) > 3 {
+		return false
+	}
+	return strings.Trim(trimmed, "=") == "" || strings.Trim(trimmed, "-") == ""
+}
+
+func isThematicBreak(trimmed string) bool {
+	compact := strings.ReplaceAll(trimmed, " ", "")
+	if len(compact) < 3 {
+		return false
+	}
+	return strings.Trim(compact, "-") == "" || strings.Trim(compact, "*") == "" || strings.Trim(compact, "_") == ""
+}
+
+func isTableRow(trimmed string) bool {
+	return len(trimmed) > 1 && trimmed[0] == '|'
+}
+
+// isListItem reports whether the line starts a bullet or ordered list item, at any indentation
+func isListItem(text string) bool {
+	trimmed := strings.TrimLeft(text, " \t")
+	if trimmed == "" {
+		return false
+	}
+
+	switch trimmed[0] {
+	case '-', '*', '+':
+		return len(trimmed) > 1 && (trimmed[1] == ' ' || trimmed[1] == '\t')
+	}
+
+	digits := 0
+	for digits < len(trimmed) && digits < 9 && trimmed[digits] >= '0' && trimmed[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || digits+1 >= len(trimmed) {
+		return false
+	}
+	return (trimmed[digits] == '.' || trimmed[digits] == ')') && (trimmed[digits+1] == ' ' || trimmed[digits+1] == '\t')
+}
+
+func isIndented(text string) bool {
+	return strings.HasPrefix(text, "  ") || strings.HasPrefix(text, "\t")
+}
+
+// span is a piece of the document between two byte offsets
+type span struct {
+	start int
+	end   int
+}
+
+// blockSpans cuts a block into the pieces it may be split at: list items, table rows, code lines and the
+// sentences of paragraphs
+func blockSpans(doc string, b block) []span {
+	if b.kind == blockHeading {
+		return []span{{b.start, b.end}}
+	}
+
+	var spans []span
+	for _, l := range splitLines(doc[b.start:b.end]) {
+		l.start += b.start
+		l.end += b.start
+
+		switch b.kind {
+		case blockCode, blockTable:
+			spans = append(spans, span{l.start, l.end})
+		case blockList:
+			if len(spans) == 0 || isListItem(doc[l.start:l.end]) {
+				spans = append(spans, span{l.start, l.end})
+			} else {
+				spans[len(spans)-1].end = l.end
+			}
+		default:
+			spans = append(spans, sentenceSpans(doc, l.start, l.end)...)
+		}
+	}
+
+	var trimmed []span
+	for _, s := range spans {
+		if b.kind != blockCode {
+			s = trimSpan(doc, s)
+		}
+		if s.end > s.start || b.kind == blockCode {
+			trimmed = append(trimmed, s)
+		}
+	}
+	return trimmed
+}
+
+// sentenceSpans splits a line after every '.', '!' or '?' that is followed by whitespace
+func sentenceSpans(doc string, start, end int) []span {
+	var spans []span
+	from := start
+	for i := start; i < end; i++ {
+		switch doc[i] {
+		case '.', '!', '?':
+			if i+1 < end && (doc[i+1] == ' ' || doc[i+1] == '\t') {
+				spans = append(spans, span{from, i + 1})
+				from = i + 1
+			}
+		}
+	}
+	return append(spans, span{from, end})
+}
+
+// splitSpan cuts a span into pieces of at most budget estimated tokens, at whitespace where it can and
+// inside words where a word alone is over budget
+func splitSpan(doc string, s span, budget int) []span {
+	if budget < 1 {
+		budget = 1
+	}
+
+	var pieces []span
+	for s.start < s.end {
+		var counter tokenCounter
+		cut := s.end
+		lastSpace := -1
+		for i := s.start; i < s.end; {
+			r, size := utf8.DecodeRuneInString(doc[i:s.end])
+			counter.add(r)
+			if counter.tokens > budget {
+				cut = i
+				if lastSpace > s.start {
+					cut = lastSpace
+				}
+				break
+			}
+			if unicode.IsSpace(r) {
+				lastSpace = i
+			}
+			i += size
+		}
+
+		if piece := trimSpan(doc, span{s.start, cut}); piece.end > piece.start {
+			pieces = append(pieces, piece)
+		}
+		s.start = cut
+	}
+	return pieces
+}
+
+// trimSpan moves the ends of a span past surrounding whitespace
+func trimSpan(doc string, s span) span {
+	for s.start < s.end {
+		r, size := utf8.DecodeRuneInString(doc[s.start:s.end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		s.start += size
+	}
+	for s.end > s.start {
+		r, size := utf8.DecodeLastRuneInString(doc[s.start:s.end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		s.end -= size
+	}
+	return s
+}
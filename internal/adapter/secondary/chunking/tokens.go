@@ -0,0 +1,84 @@
+package chunking
+
+import (
+	"strings"
+	"unicode"
+)
+
+// contextWindows lists how many tokens common embedding models take at once, by name prefix. More specific
+// prefixes come first
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"paraphrase-multilingual", 128},
+	{"all-minilm", 256},
+	{"bge-m3", 8192},
+	{"bge-", 512},
+	{"mxbai-embed-large", 512},
+	{"snowflake-arctic-embed2", 8192},
+	{"snowflake-arctic-embed", 512},
+	{"granite-embedding", 512},
+	{"multilingual-e5", 512},
+	{"e5-", 512},
+	{"nomic-embed-text", 8192},
+	{"jina-embeddings-v2", 8192},
+	{"text-embedding-3", 8191},
+	{"text-embedding-ada-002", 8191},
+}
+
+// ContextTokens returns how many tokens a model embeds at once, or 0 for models it does not know. Namespaces
+// such as "BAAI/" and tags such as ":latest" are ignored
+func ContextTokens(model string) int {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	for _, window := range contextWindows {
+		if strings.HasPrefix(name, window.prefix) {
+			return window.tokens
+		}
+	}
+	return 0
+}
+
+// EstimateTokens approximates how many tokens a subword tokenizer cuts text into, without knowing the
+// model's vocabulary. A run of letters and digits counts one token per five characters started, CJK
+// characters and other symbols count one token each and whitespace is free
+func EstimateTokens(text string) int {
+	var counter tokenCounter
+	for _, r := range text {
+		counter.add(r)
+	}
+	return counter.tokens
+}
+
+// tokenCounter keeps the estimate of EstimateTokens while text is read one rune at a time
+type tokenCounter struct {
+	tokens int
+	word   int
+}
+
+func (c *tokenCounter) add(r rune) {
+	switch {
+	case isIdeograph(r):
+		c.word = 0
+		c.tokens++
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+		c.word++
+		if (c.word-1)%5 == 0 {
+			c.tokens++
+		}
+	case unicode.IsSpace(r):
+		c.word = 0
+	default:
+		c.word = 0
+		c.tokens++
+	}
+}
+
+// isIdeograph reports whether r belongs to a script that tokenizers split about one character per token
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
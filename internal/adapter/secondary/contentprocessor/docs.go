@@ -0,0 +1,74 @@
+package contentprocessor
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// docsContentSelectors locate the page body of common documentation generators, most specific first
+var docsContentSelectors = []string{
+	"article.md-content__inner",      // MkDocs Material
+	".theme-doc-markdown",            // Docusaurus
+	`div[itemprop="articleBody"]`,    // Sphinx Read the Docs theme
+	`div.body[role="main"]`,          // Sphinx default themes
+	"main .main-page-content",        // MDN
+	`#content[role="main"] .content`, // Hugo Docsy
+	"main article",
+	`[role="main"]`,
+}
+
+// docsExtractor reads the body of documentation pages without their navigation, version pickers and edit links
+type docsExtractor struct{}
+
+func (e *docsExtractor) Name() string {
+	return "docs"
+}
+
+func (e *docsExtractor) Match(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	return strings.HasPrefix(host, "docs.") ||
+		strings.HasSuffix(host, ".readthedocs.io") ||
+		strings.HasSuffix(host, ".readthedocs.org") ||
+		hostIs(u, "developer.mozilla.org") ||
+		strings.HasPrefix(u.Path, "/docs/")
+}
+
+func (e *docsExtractor) Extract(doc *html.Node, u *url.URL) (*Extraction, error) {
+	var content *html.Node
+	for _, selector := range docsContentSelectors {
+		if content = query(doc, selector); content != nil {
+			break
+		}
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	// Permalink markers, edit buttons and in-page navigation
+	removeAll(content, "a.headerlink, a.hash-link, a.md-content__button, nav, .toc, .pagination-nav, script, style")
+
+	// The page heading becomes the document title instead of being repeated
+	title := textContent(query(doc, "title"))
+	if heading := query(content, "h1"); heading != nil {
+		title = textContent(heading)
+		heading.Parent.RemoveChild(heading)
+	}
+
+	markdown, err := toMarkdown(content, u)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{}
+	if generator := attr(query(doc, `meta[name="generator"]`), "content"); generator != "" {
+		metadata["generator"] = generator
+	}
+
+	return &Extraction{
+		Title:    title,
+		Markdown: markdown,
+		Metadata: metadata,
+	}, nil
+}
@@ -0,0 +1,306 @@
+package contentprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/net/html/charset"
+)
+
+// maxPDFPages bounds extraction for very long documents, whose later pages never reach the embeddings anyway
+const maxPDFPages = 500
+
+// maxEPUBEntrySize and maxEPUBSize bound the decompressed size of each EPUB file read and of all of them,
+// since a small archive can expand to far more than the fetched body
+const (
+	maxEPUBEntrySize = 16 << 20
+	maxEPUBSize      = 64 << 20
+)
+
+// blankLines matches runs of blank lines left behind by page and chapter extraction
+var blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+\n`)
+
+func (p *Processor) ProcessPDF(ctx context.Context, content []byte, sourceURL string) (text string, err error) {
+	// The PDF reader panics on some malformed files instead of returning an error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pages := reader.NumPage()
+	if pages > maxPDFPages {
+		pages = maxPDFPages
+	}
+
+	var body strings.Builder
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= pages; i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text from page %d: %w", i, err)
+		}
+		body.WriteString(strings.TrimSpace(pageText))
+		body.WriteString("\n\n")
+	}
+
+	text = strings.TrimSpace(blankLines.ReplaceAllString(body.String(), "\n\n"))
+	if text == "" {
+		return "", fmt.Errorf("PDF contains no extractable text")
+	}
+
+	title := strings.TrimSpace(reader.Trailer().Key("Info").Key("Title").Text())
+	if title == "" {
+		title = firstLine(text)
+	}
+
+	return formatDocument(title, sourceURL, text), nil
+}
+
+func (p *Processor) ProcessPlainText(ctx context.Context, content []byte, contentType, sourceURL string) (string, error) {
+	text, err := decodeText(content, contentType)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("document is empty")
+	}
+
+	return formatDocument(firstLine(text), sourceURL, text), nil
+}
+
+func (p *Processor) ProcessMarkdown(ctx context.Context, content []byte, contentType, sourceURL string) (string, error) {
+	text, err := decodeText(content, contentType)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("document is empty")
+	}
+
+	// A leading level-one heading becomes the document title instead of being repeated
+	title := firstLine(text)
+	if heading, ok := strings.CutPrefix(title, "# "); ok {
+		title = strings.TrimSpace(heading)
+		_, rest, _ := strings.Cut(text, "\n")
+		text = strings.TrimSpace(rest)
+	}
+
+	return formatDocument(title, sourceURL, text), nil
+}
+
+// epubContainer is META-INF/container.xml, which points at the package document
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the OPF package document listing the book metadata and reading order
+type epubPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func (p *Processor) ProcessEPUB(ctx context.Context, content []byte, sourceURL string) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open EPUB: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var container epubContainer
+	if err := readZipXML(files, "META-INF/container.xml", &container); err != nil {
+		return "", err
+	}
+	if len(container.Rootfiles) == 0 {
+		return "", fmt.Errorf("EPUB container lists no package document")
+	}
+
+	packagePath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readZipXML(files, packagePath, &pkg); err != nil {
+		return "", err
+	}
+
+	// Manifest hrefs are URLs relative to the package document, so escaped names are decoded to match the
+	// names in the archive
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if !strings.Contains(item.MediaType, "html") {
+			continue
+		}
+		href := item.Href
+		if ref, err := url.Parse(item.Href); err == nil {
+			href = ref.Path
+		}
+		hrefs[item.ID] = href
+	}
+
+	read := 0
+	var body strings.Builder
+	for _, itemRef := range pkg.Spine {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		href, ok := hrefs[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		chapter, err := readZipFile(files, path.Join(path.Dir(packagePath), href))
+		if err != nil {
+			return "", err
+		}
+		read += len(chapter)
+		if read > maxEPUBSize {
+			return "", fmt.Errorf("EPUB chapters exceed %d bytes", maxEPUBSize)
+		}
+
+		markdown, err := md.ConvertString(string(chapter))
+		if err != nil {
+			return "", fmt.Errorf("failed to convert chapter %s to markdown: %w", href, err)
+		}
+		body.WriteString(strings.TrimSpace(markdown))
+		body.WriteString("\n\n")
+	}
+
+	text := strings.TrimSpace(blankLines.ReplaceAllString(body.String(), "\n\n"))
+	if text == "" {
+		return "", fmt.Errorf("EPUB contains no readable chapters")
+	}
+
+	title := ""
+	if len(pkg.Title) > 0 {
+		title = strings.TrimSpace(pkg.Title[0])
+	}
+	if title == "" {
+		title = firstLine(text)
+	}
+
+	return formatDocument(title, sourceURL, text), nil
+}
+
+func readZipFile(files map[string]*zip.File, name string) ([]byte, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("EPUB is missing %s", name)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxEPUBEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(data) > maxEPUBEntrySize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", name, maxEPUBEntrySize)
+	}
+	return data, nil
+}
+
+func readZipXML(files map[string]*zip.File, name string, v interface{}) error {
+	data, err := readZipFile(files, name)
+	if err != nil {
+		return err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// decodeText converts a text document to UTF-8 using the Content-Type charset or the content itself
+func decodeText(content []byte, contentType string) (string, error) {
+	encoding, _, _ := charset.DetermineEncoding(content, contentType)
+	decoded, err := encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode text: %w", err)
+	}
+
+	text := strings.TrimPrefix(string(decoded), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.TrimSpace(text), nil
+}
+
+// firstLine returns the first non-blank line of a document, used as its title when there is no better one
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if runes := []rune(line); len(runes) > 200 {
+				line = string(runes[:200])
+			}
+			return line
+		}
+	}
+	return ""
+}
+
+// formatDocument lays out extracted text the same way as reader output, so titles and chunks are found the same way
+func formatDocument(title, sourceURL, body string) string {
+	var result strings.Builder
+
+	result.WriteString("# ")
+	result.WriteString(title)
+	result.WriteString("\n")
+
+	if sourceURL != "" {
+		result.WriteString("(extracted from **")
+		result.WriteString(sourceURL)
+		result.WriteString("**)")
+		result.WriteString("\n")
+	}
+
+	result.WriteString("\n")
+	result.WriteString(body)
+
+	return result.String()
+}
@@ -0,0 +1,254 @@
+package contentprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF writes a minimal single-font PDF with one page per entry of pages
+func buildPDF(title string, pages []string) []byte {
+	var objects []string
+	pageCount := len(pages)
+	fontID := 3 + 2*pageCount
+	infoID := fontID + 1
+
+	kids := make([]string, pageCount)
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 3+2*i)
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount),
+	)
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>", fontID, 4+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+	objects = append(objects,
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) >>", title),
+	)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, infoID, xref)
+
+	return buf.Bytes()
+}
+
+// epubFile is one entry of a test EPUB archive
+type epubFile struct {
+	name    string
+	content string
+}
+
+func buildEPUB(t *testing.T) []byte {
+	t.Helper()
+
+	return zipEPUB(t, []epubFile{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>A Small Book</dc:title></metadata>
+  <manifest>
+    <item id="c2" href="text/two.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/one.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine><itemref idref="c1"/><itemref idref="c2"/></spine>
+</package>`},
+		{"OEBPS/text/one.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter One</h1><p>It was a <em>dark</em> night.</p></body></html>`},
+		{"OEBPS/text/two.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Chapter Two</h1><p>The end.</p></body></html>`},
+		{"OEBPS/style.css", `body { margin: 0 }`},
+	})
+}
+
+// buildEscapedEPUB builds an EPUB with one chapter of the given content, whose name has a space that
+// the manifest escapes
+func buildEscapedEPUB(t *testing.T, chapter string) []byte {
+	t.Helper()
+
+	return zipEPUB(t, []epubFile{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		{"content.opf", `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Escaped</dc:title></metadata>
+  <manifest><item id="c1" href="Chapter%201.xhtml#start" media-type="application/xhtml+xml"/></manifest>
+  <spine><itemref idref="c1"/></spine>
+</package>`},
+		{"Chapter 1.xhtml", chapter},
+	})
+}
+
+func zipEPUB(t *testing.T, files []epubFile) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", file.name, err)
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			t.Fatalf("failed to write %s: %v", file.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close EPUB: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessPDF(t *testing.T) {
+	processor := NewProcessor()
+	content := buildPDF("Attention Is All You Need", []string{"First page text", "Second page text"})
+
+	result, err := processor.ProcessPDF(context.Background(), content, "https://arxiv.org/pdf/1706.03762")
+	if err != nil {
+		t.Fatalf("ProcessPDF failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"# Attention Is All You Need\n",
+		"(extracted from **https://arxiv.org/pdf/1706.03762**)",
+		"First page text",
+		"Second page text",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in result:\n%s", want, result)
+		}
+	}
+	if strings.Index(result, "First page") > strings.Index(result, "Second page") {
+		t.Errorf("pages are out of order:\n%s", result)
+	}
+}
+
+func TestProcessPDFRejectsGarbage(t *testing.T) {
+	if _, err := NewProcessor().ProcessPDF(context.Background(), []byte("%PDF-1.4 not really"), ""); err == nil {
+		t.Fatal("expected an error for a broken PDF")
+	}
+}
+
+func TestProcessEPUB(t *testing.T) {
+	result, err := NewProcessor().ProcessEPUB(context.Background(), buildEPUB(t), "https://example.com/book.epub")
+	if err != nil {
+		t.Fatalf("ProcessEPUB failed: %v", err)
+	}
+
+	if !strings.HasPrefix(result, "# A Small Book\n") {
+		t.Errorf("expected the book title as heading:\n%s", result)
+	}
+	one, two := strings.Index(result, "Chapter One"), strings.Index(result, "Chapter Two")
+	if one < 0 || two < 0 || one > two {
+		t.Errorf("expected both chapters in spine order:\n%s", result)
+	}
+	if !strings.Contains(result, "*dark*") {
+		t.Errorf("expected chapter markup converted to markdown:\n%s", result)
+	}
+	if strings.Contains(result, "margin") {
+		t.Errorf("stylesheets should not be extracted:\n%s", result)
+	}
+}
+
+func TestProcessEPUBEscapedHref(t *testing.T) {
+	chapter := `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Found by its escaped name.</p></body></html>`
+	result, err := NewProcessor().ProcessEPUB(context.Background(), buildEscapedEPUB(t, chapter), "https://example.com/book.epub")
+	if err != nil {
+		t.Fatalf("ProcessEPUB failed: %v", err)
+	}
+	if !strings.Contains(result, "Found by its escaped name.") {
+		t.Errorf("expected the chapter named by an escaped href:\n%s", result)
+	}
+}
+
+func TestProcessEPUBRejectsOversizedEntries(t *testing.T) {
+	chapter := "<html><body><p>" + strings.Repeat("a", maxEPUBEntrySize) + "</p></body></html>"
+	if _, err := NewProcessor().ProcessEPUB(context.Background(), buildEscapedEPUB(t, chapter), "https://example.com/book.epub"); err == nil {
+		t.Fatal("expected an error for a chapter larger than the entry limit")
+	}
+}
+
+func TestProcessTextDocuments(t *testing.T) {
+	processor := NewProcessor()
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		process     func() (string, error)
+		wantTitle   string
+		wantContent string
+		notWant     string
+	}{
+		{
+			name: "plain text uses the first line as title",
+			process: func() (string, error) {
+				return processor.ProcessPlainText(ctx, []byte("\r\nRFC 9110\r\nHTTP Semantics\r\n"), "text/plain", "https://example.com/rfc9110.txt")
+			},
+			wantTitle:   "# RFC 9110\n",
+			wantContent: "RFC 9110\nHTTP Semantics",
+		},
+		{
+			name: "plain text honours the charset",
+			process: func() (string, error) {
+				return processor.ProcessPlainText(ctx, []byte("Caf\xe9 menu"), "text/plain; charset=iso-8859-1", "")
+			},
+			wantTitle:   "# Café menu\n",
+			wantContent: "Café menu",
+		},
+		{
+			name: "markdown heading becomes the title",
+			process: func() (string, error) {
+				return processor.ProcessMarkdown(ctx, []byte("# Project\n\nSome *docs*.\n"), "text/markdown", "https://example.com/README.md")
+			},
+			wantTitle:   "# Project\n",
+			wantContent: "Some *docs*.",
+			notWant:     "# Project\n(extracted from **https://example.com/README.md**)\n\n# Project",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.process()
+			if err != nil {
+				t.Fatalf("processing failed: %v", err)
+			}
+			if !strings.HasPrefix(result, tc.wantTitle) {
+				t.Errorf("expected title %q:\n%s", tc.wantTitle, result)
+			}
+			if !strings.Contains(result, tc.wantContent) {
+				t.Errorf("expected content %q:\n%s", tc.wantContent, result)
+			}
+			if tc.notWant != "" && strings.Contains(result, tc.notWant) {
+				t.Errorf("unexpected %q:\n%s", tc.notWant, result)
+			}
+		})
+	}
+}
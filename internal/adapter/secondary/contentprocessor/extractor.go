@@ -0,0 +1,169 @@
+package contentprocessor
+
+import (
+	"net/url"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Extractor converts the pages of one kind of site into readable markdown, taking the place of the generic reader for them
+type Extractor interface {
+	// Name identifies the extractor in processing results
+	Name() string
+
+	// Match reports whether the extractor handles the page at u
+	Match(u *url.URL) bool
+
+	// Extract returns the page content, or nil when the page does not have the layout the extractor expects
+	Extract(doc *html.Node, u *url.URL) (*Extraction, error)
+}
+
+// Extraction is the readable content an extractor found on a page
+type Extraction struct {
+	Title    string
+	Markdown string
+	Metadata map[string]string
+}
+
+// Registry holds site extractors, which are tried in registration order
+type Registry struct {
+	extractors []Extractor
+}
+
+// NewRegistry creates a registry with the given extractors
+func NewRegistry(extractors ...Extractor) *Registry {
+	return &Registry{extractors: extractors}
+}
+
+// Register adds an extractor after the ones already registered
+func (r *Registry) Register(extractor Extractor) {
+	r.extractors = append(r.extractors, extractor)
+}
+
+// Lookup returns the extractors matching u in registration order
+func (r *Registry) Lookup(u *url.URL) []Extractor {
+	var matched []Extractor
+	for _, extractor := range r.extractors {
+		if extractor.Match(u) {
+			matched = append(matched, extractor)
+		}
+	}
+	return matched
+}
+
+// DefaultExtractors returns the built-in site extractors
+func DefaultExtractors() []Extractor {
+	return []Extractor{
+		&gitHubExtractor{},
+		&hackerNewsExtractor{},
+		&redditExtractor{},
+		&stackExchangeExtractor{},
+		&docsExtractor{},
+	}
+}
+
+// hostIs reports whether u is on one of the hosts, ignoring a www. prefix
+func hostIs(u *url.URL, hosts ...string) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, h := range hosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+func query(n *html.Node, selector string) *html.Node {
+	return cascadia.Query(n, cascadia.MustCompile(selector))
+}
+
+func queryAll(n *html.Node, selector string) []*html.Node {
+	return cascadia.QueryAll(n, cascadia.MustCompile(selector))
+}
+
+func attr(n *html.Node, key string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns the text below n with whitespace collapsed
+func textContent(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+// removeAll detaches every node below n matching the selector
+func removeAll(n *html.Node, selector string) {
+	for _, match := range queryAll(n, selector) {
+		if match.Parent != nil {
+			match.Parent.RemoveChild(match)
+		}
+	}
+}
+
+// toMarkdown converts the HTML below n, resolving relative links against the page
+func toMarkdown(n *html.Node, u *url.URL) (string, error) {
+	if n == nil {
+		return "", nil
+	}
+
+	var content strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&content, c); err != nil {
+			return "", err
+		}
+	}
+
+	markdown, err := md.ConvertString(content.String(), converter.WithDomain(u.Scheme+"://"+u.Host))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(markdown), nil
+}
+
+// indent prefixes every non-blank line after the first, so multi-paragraph text stays inside a list item
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
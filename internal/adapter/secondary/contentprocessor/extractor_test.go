@@ -0,0 +1,236 @@
+package contentprocessor
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractSiteFixtures(t *testing.T) {
+	processor := NewProcessor()
+
+	testCases := []struct {
+		name          string
+		fixture       string
+		url           string
+		wantExtractor string
+		wantTitle     string
+		wantContent   []string
+		notWant       []string
+		wantMetadata  map[string]string
+	}{
+		{
+			name:          "github repository readme",
+			fixture:       "github_repo.html",
+			url:           "https://github.com/golang/example",
+			wantExtractor: "github",
+			wantTitle:     "golang/example",
+			wantContent: []string{
+				"# Go example projects",
+				"demonstrate the language",
+				"$ git clone https://go.googlesource.com/example",
+				"[hello](https://github.com/golang/example/blob/master/hello/README.md)",
+			},
+			notWant: []string{"Pricing", "Terms", "Permalink"},
+			wantMetadata: map[string]string{
+				"repository": "golang/example",
+				"stars":      "10,482",
+				"forks":      "1,321",
+				"language":   "Go",
+				"topics":     "go, examples",
+			},
+		},
+		{
+			name:          "hacker news item",
+			fixture:       "hackernews_item.html",
+			url:           "https://news.ycombinator.com/item?id=41000001",
+			wantExtractor: "hackernews",
+			wantTitle:     "Show HN: A bookmark manager with embeddings",
+			wantContent: []string{
+				"Link: <https://garden.example.org/>",
+				"128 points by gardener",
+				"keep the links I save searchable",
+				"- **alice**: How do you handle paywalled pages?\n\n  Most of mine are news sites.",
+				"  - **gardener**: Per-domain cookies, see [the docs](https://garden.example.org/docs/fetch).",
+				"- **bob**: Nice work.",
+			},
+			notWant: []string{"reply", "past"},
+			wantMetadata: map[string]string{
+				"points":   "128",
+				"author":   "gardener",
+				"comments": "3",
+				"link":     "https://garden.example.org/",
+			},
+		},
+		{
+			name:          "reddit thread",
+			fixture:       "reddit_thread.html",
+			url:           "https://www.reddit.com/r/golang/comments/1abcde/what_is_your_favourite_way/",
+			wantExtractor: "reddit",
+			wantTitle:     "What is your favourite way to structure a Go service?",
+			wantContent: []string{
+				"Posted in r/golang by u/gopher42",
+				"between **flat packages** and hexagonal layouts",
+				"- **ports_and_adapters**: Hexagonal, with the domain in the middle.",
+				"  - **gopher42**: Do you keep the SQL in the adapters?\n\n    Asking for a friend.",
+			},
+			notWant: []string{"Popular", "Link:"},
+			wantMetadata: map[string]string{
+				"subreddit": "r/golang",
+				"author":    "gopher42",
+				"score":     "314",
+				"comments":  "2",
+			},
+		},
+		{
+			name:          "old reddit thread",
+			fixture:       "reddit_old_thread.html",
+			url:           "https://old.reddit.com/r/golang/comments/1abcde/what_is_your_favourite_way/",
+			wantExtractor: "reddit",
+			wantTitle:     "What is your favourite way to structure a Go service?",
+			wantContent: []string{
+				"between **flat packages** and hexagonal layouts",
+				"- **ports_and_adapters**: Hexagonal, with the domain in the middle.",
+				"  - **gopher42**: Do you keep the SQL in the adapters?",
+			},
+			notWant: []string{"Sidebar rules"},
+			wantMetadata: map[string]string{
+				"subreddit": "r/golang",
+				"score":     "314",
+				"comments":  "2",
+			},
+		},
+		{
+			name:          "stack overflow question",
+			fixture:       "stackoverflow_question.html",
+			url:           "https://stackoverflow.com/questions/2050391/how-to-check-if-a-map-contains-a-key-in-go",
+			wantExtractor: "stackexchange",
+			wantTitle:     "How do I check if a map contains a key?",
+			wantContent: []string{
+				"is there a more efficient way?",
+				"Tags: go, dictionary",
+				"## Accepted answer (score 2391)",
+				`if val, ok := dict["foo"]; ok {`,
+				"## Answer (score 170)",
+			},
+			notWant: []string{"Linked", "someone"},
+			wantMetadata: map[string]string{
+				"site":     "stackoverflow.com",
+				"score":    "1203",
+				"answers":  "2",
+				"accepted": "true",
+				"tags":     "go, dictionary",
+			},
+		},
+		{
+			name:          "sphinx documentation",
+			fixture:       "docs_sphinx.html",
+			url:           "https://requests.readthedocs.io/en/latest/user/quickstart/",
+			wantExtractor: "docs",
+			wantTitle:     "Quickstart",
+			wantContent: []string{
+				"Eager to get started?",
+				"## Make a Request",
+				"requests.get('https://api.github.com/events')",
+			},
+			notWant: []string{"¶", "API", "Copyright"},
+			wantMetadata: map[string]string{
+				"generator": "Docutils 0.18.1: http://docutils.sourceforge.net/",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			doc, err := processor.Extract(context.Background(), content, tc.url)
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+
+			if doc.Extractor != tc.wantExtractor {
+				t.Errorf("Extractor = %q, want %q", doc.Extractor, tc.wantExtractor)
+			}
+			if doc.Title != tc.wantTitle {
+				t.Errorf("Title = %q, want %q", doc.Title, tc.wantTitle)
+			}
+			if !strings.HasPrefix(doc.Content, "# "+tc.wantTitle+"\n(extracted from **"+tc.url+"**)\n\n") {
+				t.Errorf("expected the reader layout, got:\n%s", doc.Content)
+			}
+			for _, want := range tc.wantContent {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("expected %q in content:\n%s", want, doc.Content)
+				}
+			}
+			for _, notWant := range tc.notWant {
+				if strings.Contains(doc.Content, notWant) {
+					t.Errorf("unexpected %q in content:\n%s", notWant, doc.Content)
+				}
+			}
+			for key, want := range tc.wantMetadata {
+				if got := doc.Metadata[key]; got != want {
+					t.Errorf("Metadata[%q] = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractFallsBackToReader(t *testing.T) {
+	page := []byte(`<html><head><title>Issue</title></head><body><article><h1>Issue</h1>
+<p>This page has no rendered README, so the repository extractor cannot read it.</p>
+<p>Readability should take over and keep this paragraph.</p></article></body></html>`)
+
+	doc, err := NewProcessor().Extract(context.Background(), page, "https://github.com/golang/go/issues/1")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if doc.Extractor != "reader" {
+		t.Errorf("Extractor = %q, want reader", doc.Extractor)
+	}
+	if !strings.Contains(doc.Content, "Readability should take over") {
+		t.Errorf("expected reader content, got:\n%s", doc.Content)
+	}
+}
+
+// staticExtractor claims every page on one host
+type staticExtractor struct {
+	host       string
+	extraction *Extraction
+}
+
+func (e *staticExtractor) Name() string          { return "static" }
+func (e *staticExtractor) Match(u *url.URL) bool { return u.Host == e.host }
+func (e *staticExtractor) Extract(*html.Node, *url.URL) (*Extraction, error) {
+	return e.extraction, nil
+}
+
+func TestRegistryOrder(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&staticExtractor{host: "example.com"})
+	registry.Register(&staticExtractor{host: "example.com", extraction: &Extraction{Title: "Custom", Markdown: "Custom body"}})
+	processor := NewProcessorWithRegistry(registry)
+
+	u, _ := url.Parse("https://example.com/page")
+	if got := len(registry.Lookup(u)); got != 2 {
+		t.Fatalf("Lookup returned %d extractors, want 2", got)
+	}
+
+	// The first extractor declines the page, so the second one is used
+	doc, err := processor.Extract(context.Background(), []byte("<p>ignored</p>"), u.String())
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if doc.Extractor != "static" || doc.Content != "# Custom\n(extracted from **https://example.com/page**)\n\nCustom body" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
@@ -0,0 +1,97 @@
+package contentprocessor
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// gitHubReservedPaths are top-level github.com paths that are not user or organization names
+var gitHubReservedPaths = map[string]bool{
+	"about": true, "collections": true, "explore": true, "features": true, "login": true,
+	"marketplace": true, "notifications": true, "orgs": true, "pricing": true, "search": true,
+	"settings": true, "sponsors": true, "topics": true, "trending": true,
+}
+
+// gitHubExtractor reads the rendered README or markdown file of a repository page
+type gitHubExtractor struct{}
+
+func (e *gitHubExtractor) Name() string {
+	return "github"
+}
+
+func (e *gitHubExtractor) Match(u *url.URL) bool {
+	owner, _, ok := gitHubRepository(u)
+	return ok && hostIs(u, "github.com") && !gitHubReservedPaths[strings.ToLower(owner)]
+}
+
+func (e *gitHubExtractor) Extract(doc *html.Node, u *url.URL) (*Extraction, error) {
+	article := query(doc, "article.markdown-body")
+	if article == nil {
+		return nil, nil
+	}
+
+	// Heading anchors render as empty links next to every heading
+	removeAll(article, "a.anchor")
+	markdown, err := toMarkdown(article, u)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, repo, _ := gitHubRepository(u)
+	repository := owner + "/" + repo
+	metadata := map[string]string{"repository": repository}
+
+	description := attr(query(doc, `meta[property="og:description"]`), "content")
+	if description != "" {
+		metadata["description"] = description
+	}
+	if stars := gitHubCounter(doc, "#repo-stars-counter-star"); stars != "" {
+		metadata["stars"] = stars
+	}
+	if forks := gitHubCounter(doc, "#repo-network-counter"); forks != "" {
+		metadata["forks"] = forks
+	}
+	if language := textContent(query(doc, `[itemprop="programmingLanguage"]`)); language != "" {
+		metadata["language"] = language
+	}
+
+	var topics []string
+	for _, topic := range queryAll(doc, "a.topic-tag") {
+		topics = append(topics, textContent(topic))
+	}
+	if len(topics) > 0 {
+		metadata["topics"] = strings.Join(topics, ", ")
+	}
+
+	// Files below the repository root are titled by their path
+	title := repository
+	if parts := strings.Split(strings.Trim(u.Path, "/"), "/"); len(parts) > 4 && parts[2] == "blob" {
+		title += ": " + strings.Join(parts[4:], "/")
+	}
+
+	return &Extraction{
+		Title:    title,
+		Markdown: markdown,
+		Metadata: metadata,
+	}, nil
+}
+
+// gitHubRepository returns the owner and repository named by the first two path segments
+func gitHubRepository(u *url.URL) (string, string, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// gitHubCounter reads a repository counter, preferring the exact count in its title over the abbreviated text
+func gitHubCounter(doc *html.Node, selector string) string {
+	counter := query(doc, selector)
+	if count := attr(counter, "title"); count != "" {
+		return count
+	}
+	return textContent(counter)
+}
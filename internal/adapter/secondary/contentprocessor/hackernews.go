@@ -0,0 +1,105 @@
+package contentprocessor
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// hackerNewsExtractor reads a Hacker News item page as the story followed by its comment tree
+type hackerNewsExtractor struct{}
+
+func (e *hackerNewsExtractor) Name() string {
+	return "hackernews"
+}
+
+func (e *hackerNewsExtractor) Match(u *url.URL) bool {
+	return hostIs(u, "news.ycombinator.com") && u.Path == "/item"
+}
+
+func (e *hackerNewsExtractor) Extract(doc *html.Node, u *url.URL) (*Extraction, error) {
+	story := query(doc, ".fatitem")
+	if story == nil {
+		return nil, nil
+	}
+
+	metadata := map[string]string{}
+	var markdown strings.Builder
+
+	titleLink := query(story, ".titleline > a")
+	title := textContent(titleLink)
+	if title == "" {
+		// Comment permalinks have no story title, only the comment itself
+		title = "Comment by " + textContent(query(story, ".hnuser"))
+	}
+	if link := attr(titleLink, "href"); link != "" {
+		if resolved, err := u.Parse(link); err == nil && resolved.Host != u.Host {
+			metadata["link"] = resolved.String()
+			markdown.WriteString(fmt.Sprintf("Link: <%s>\n\n", resolved.String()))
+		}
+	}
+
+	if score := textContent(query(story, ".score")); score != "" {
+		metadata["points"] = strings.TrimSuffix(strings.TrimSuffix(score, " points"), " point")
+	}
+	if author := textContent(query(story, ".hnuser")); author != "" {
+		metadata["author"] = author
+	}
+	if metadata["points"] != "" && metadata["author"] != "" {
+		markdown.WriteString(fmt.Sprintf("%s points by %s\n\n", metadata["points"], metadata["author"]))
+	}
+
+	text := query(story, ".toptext")
+	if text == nil {
+		text = query(story, ".commtext")
+	}
+	if body, err := toMarkdown(text, u); err != nil {
+		return nil, err
+	} else if body != "" {
+		markdown.WriteString(body)
+		markdown.WriteString("\n\n")
+	}
+
+	comments := queryAll(doc, "tr.athing.comtr")
+	metadata["comments"] = strconv.Itoa(len(comments))
+	if len(comments) > 0 {
+		markdown.WriteString("## Comments\n\n")
+	}
+	for _, comment := range comments {
+		// Deleted and flagged comments keep their place in the tree but have no text
+		text := query(comment, ".commtext")
+		if text == nil {
+			continue
+		}
+		// Older markup renders the reply link inside the comment text
+		removeAll(text, ".reply")
+		body, err := toMarkdown(text, u)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := strings.Repeat("  ", hackerNewsDepth(comment))
+		markdown.WriteString(fmt.Sprintf("%s- **%s**: %s\n", prefix, textContent(query(comment, ".hnuser")), indent(body, prefix+"  ")))
+	}
+
+	return &Extraction{
+		Title:    title,
+		Markdown: strings.TrimSpace(markdown.String()),
+		Metadata: metadata,
+	}, nil
+}
+
+// hackerNewsDepth reads the nesting level of a comment, from the indent attribute or the width of its spacer image
+func hackerNewsDepth(comment *html.Node) int {
+	ind := query(comment, "td.ind")
+	if depth, err := strconv.Atoi(attr(ind, "indent")); err == nil {
+		return depth
+	}
+	if width, err := strconv.Atoi(attr(query(ind, "img"), "width")); err == nil {
+		return width / 40
+	}
+	return 0
+}
@@ -0,0 +1,284 @@
+package contentprocessor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"garden3/internal/domain/entity"
+)
+
+// publishedLayouts are the date formats found in published-date meta tags and JSON-LD
+var publishedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// jsonLDObject holds the schema.org properties used for bookmark metadata
+type jsonLDObject struct {
+	Type          json.RawMessage `json:"@type"`
+	Headline      string          `json:"headline"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	DatePublished string          `json:"datePublished"`
+	InLanguage    json.RawMessage `json:"inLanguage"`
+	Author        json.RawMessage `json:"author"`
+	Publisher     json.RawMessage `json:"publisher"`
+	Image         json.RawMessage `json:"image"`
+}
+
+func (p *Processor) ExtractMetadata(ctx context.Context, htmlContent []byte, sourceURL string) (*entity.PageMetadata, error) {
+	doc, err := html.Parse(bytes.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	base, err := url.Parse(sourceURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+
+	// OpenGraph and friends use property, Twitter cards and standard tags use name; the first value wins
+	properties := map[string]string{}
+	named := map[string]string{}
+	for _, meta := range queryAll(doc, "meta") {
+		content := attr(meta, "content")
+		if content == "" {
+			continue
+		}
+		if property := strings.ToLower(attr(meta, "property")); property != "" {
+			setFirst(properties, property, content)
+		}
+		if name := strings.ToLower(attr(meta, "name")); name != "" {
+			if strings.HasPrefix(name, "twitter:") {
+				setFirst(properties, name, content)
+			} else {
+				setFirst(named, name, content)
+			}
+		}
+		if equiv := strings.ToLower(attr(meta, "http-equiv")); equiv == "content-language" {
+			setFirst(named, equiv, content)
+		}
+	}
+
+	var objects []json.RawMessage
+	for _, script := range queryAll(doc, `script[type="application/ld+json"]`) {
+		if script.FirstChild != nil {
+			objects = append(objects, jsonLDObjects([]byte(script.FirstChild.Data))...)
+		}
+	}
+	primary := primaryJSONLD(objects)
+
+	metadata := &entity.PageMetadata{
+		CanonicalURL: resolve(base, attr(query(doc, `link[rel="canonical"]`), "href"), properties["og:url"]),
+		Title:        firstOf(properties["og:title"], properties["twitter:title"], primary.Headline, textContent(query(doc, "head title"))),
+		Description:  firstOf(properties["og:description"], properties["twitter:description"], named["description"], primary.Description),
+		SiteName:     firstOf(properties["og:site_name"], jsonLDName(primary.Publisher), named["application-name"]),
+		Author:       firstOf(jsonLDName(primary.Author), named["author"], named["citation_author"], named["dc.creator"], named["parsely-author"], notURL(properties["article:author"])),
+		PublishedAt:  parsePublished(primary.DatePublished, properties["article:published_time"], named["citation_publication_date"], named["dc.date"], named["date"], named["parsely-pub-date"]),
+		Language:     language(attr(query(doc, "html"), "lang"), properties["og:locale"], named["content-language"], jsonLDText(primary.InLanguage)),
+		FaviconURL:   resolve(base, attr(query(doc, `link[rel="icon"], link[rel="shortcut icon"]`), "href"), attr(query(doc, `link[rel="apple-touch-icon"]`), "href")),
+		ImageURL:     resolve(base, properties["og:image"], properties["og:image:url"], properties["twitter:image"], properties["twitter:image:src"], jsonLDURL(primary.Image)),
+		ContentType:  firstOf(properties["og:type"], jsonLDText(primary.Type)),
+		JSONLD:       objects,
+	}
+	if len(properties) > 0 {
+		metadata.Properties = properties
+	}
+
+	return metadata, nil
+}
+
+func setFirst(values map[string]string, key, value string) {
+	if _, ok := values[key]; !ok {
+		values[key] = value
+	}
+}
+
+// firstOf returns the first non-blank value
+func firstOf(values ...string) *string {
+	for _, value := range values {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			return &value
+		}
+	}
+	return nil
+}
+
+// notURL drops values that are profile links rather than names, as article:author often is
+func notURL(value string) string {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return ""
+	}
+	return value
+}
+
+// resolve returns the first non-blank reference as an absolute URL
+func resolve(base *url.URL, refs ...string) *string {
+	ref := firstOf(refs...)
+	if ref == nil {
+		return nil
+	}
+	resolved, err := base.Parse(*ref)
+	if err != nil {
+		return ref
+	}
+	absolute := resolved.String()
+	return &absolute
+}
+
+func parsePublished(values ...string) *time.Time {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		for _, layout := range publishedLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				t = t.UTC()
+				return &t
+			}
+		}
+	}
+	return nil
+}
+
+// language normalizes locales such as en_US to language tags such as en-US
+func language(values ...string) *string {
+	lang := firstOf(values...)
+	if lang == nil {
+		return nil
+	}
+	normalized := strings.ReplaceAll(*lang, "_", "-")
+	return &normalized
+}
+
+// jsonLDObjects flattens a JSON-LD script into its top-level objects, unwrapping arrays and @graph
+func jsonLDObjects(data []byte) []json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch data[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil
+		}
+		var objects []json.RawMessage
+		for _, item := range items {
+			objects = append(objects, jsonLDObjects(item)...)
+		}
+		return objects
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil
+		}
+		if graph, ok := object["@graph"]; ok {
+			return jsonLDObjects(graph)
+		}
+		return []json.RawMessage{json.RawMessage(data)}
+	}
+	return nil
+}
+
+// primaryJSONLD picks the object describing the page itself, preferring articles and posts over sites and breadcrumbs
+func primaryJSONLD(objects []json.RawMessage) jsonLDObject {
+	var fallback *jsonLDObject
+	for _, raw := range objects {
+		var object jsonLDObject
+		if err := json.Unmarshal(raw, &object); err != nil {
+			continue
+		}
+		kind := jsonLDText(object.Type)
+		if strings.HasSuffix(kind, "Article") || strings.HasSuffix(kind, "Posting") || kind == "Report" || kind == "VideoObject" {
+			return object
+		}
+		if fallback == nil && object.DatePublished != "" {
+			fallback = &object
+		}
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return jsonLDObject{}
+}
+
+// jsonLDText reads a value that is either a string or a list whose first entry is used
+func jsonLDText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil && len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+// jsonLDName reads a Person or Organization, a list of them or a plain name, joining several names with commas
+func jsonLDName(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	if text := jsonLDText(raw); text != "" {
+		return notURL(text)
+	}
+
+	var object struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil && object.Name != "" {
+		return object.Name
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var names []string
+		for _, item := range list {
+			if name := jsonLDName(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// jsonLDURL reads an ImageObject, a list of images or a plain URL
+func jsonLDURL(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	if text := jsonLDText(raw); text != "" {
+		return text
+	}
+
+	var object struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(raw, &object); err == nil && object.URL != "" {
+		return object.URL
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil && len(list) > 0 {
+		return jsonLDURL(list[0])
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package contentprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const articleWithMetadata = `<!DOCTYPE html>
+<html lang="en_GB">
+<head>
+<title>Fallback title | Example News</title>
+<meta name="description" content="Plain description">
+<meta property="og:title" content="How link rot happens">
+<meta property="og:description" content="Most links die within a decade.">
+<meta property="og:site_name" content="Example News">
+<meta property="og:type" content="article">
+<meta property="og:image" content="/images/rot.png">
+<meta property="article:author" content="https://news.example.com/staff/jane">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:creator" content="@jane">
+<link rel="canonical" href="/2024/05/link-rot">
+<link rel="apple-touch-icon" href="/apple-icon.png">
+<link rel="icon" href="/favicon.svg">
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+  {"@type": "WebSite", "name": "Example News", "url": "https://news.example.com/"},
+  {"@type": "NewsArticle", "headline": "How link rot happens",
+   "datePublished": "2024-05-02T09:30:00+02:00",
+   "author": [{"@type": "Person", "name": "Jane Doe"}, {"@type": "Person", "name": "John Roe"}],
+   "publisher": {"@type": "Organization", "name": "Example News Ltd"}}
+]}
+</script>
+</head>
+<body><article><p>Body</p></article></body>
+</html>`
+
+func TestExtractMetadata(t *testing.T) {
+	metadata, err := NewProcessor().ExtractMetadata(context.Background(), []byte(articleWithMetadata), "https://news.example.com/2024/05/link-rot?utm_source=feed")
+	if err != nil {
+		t.Fatalf("ExtractMetadata failed: %v", err)
+	}
+
+	testCases := []struct {
+		field string
+		got   *string
+		want  string
+	}{
+		{"canonical_url", metadata.CanonicalURL, "https://news.example.com/2024/05/link-rot"},
+		{"title", metadata.Title, "How link rot happens"},
+		{"description", metadata.Description, "Most links die within a decade."},
+		{"site_name", metadata.SiteName, "Example News"},
+		{"author", metadata.Author, "Jane Doe, John Roe"},
+		{"language", metadata.Language, "en-GB"},
+		{"favicon_url", metadata.FaviconURL, "https://news.example.com/favicon.svg"},
+		{"image_url", metadata.ImageURL, "https://news.example.com/images/rot.png"},
+		{"content_type", metadata.ContentType, "article"},
+	}
+	for _, tc := range testCases {
+		if tc.got == nil || *tc.got != tc.want {
+			t.Errorf("%s = %v, want %q", tc.field, tc.got, tc.want)
+		}
+	}
+
+	wantPublished := time.Date(2024, 5, 2, 7, 30, 0, 0, time.UTC)
+	if metadata.PublishedAt == nil || !metadata.PublishedAt.Equal(wantPublished) {
+		t.Errorf("published_at = %v, want %v", metadata.PublishedAt, wantPublished)
+	}
+	if metadata.Properties["twitter:creator"] != "@jane" {
+		t.Errorf("expected twitter card properties, got %v", metadata.Properties)
+	}
+	if len(metadata.JSONLD) != 2 {
+		t.Errorf("expected the @graph to be flattened into 2 objects, got %d", len(metadata.JSONLD))
+	}
+}
+
+func TestExtractMetadataFallbacks(t *testing.T) {
+	page := `<html><head><title> Plain   page </title>
+<meta name="author" content="Sam Smith">
+<meta name="citation_publication_date" content="2019/11/05">
+<script type="application/ld+json">not json</script>
+</head><body><p>Text</p></body></html>`
+
+	metadata, err := NewProcessor().ExtractMetadata(context.Background(), []byte(page), "https://example.org/paper")
+	if err != nil {
+		t.Fatalf("ExtractMetadata failed: %v", err)
+	}
+
+	if metadata.Title == nil || *metadata.Title != "Plain page" {
+		t.Errorf("title = %v, want the <title> text", metadata.Title)
+	}
+	if metadata.Author == nil || *metadata.Author != "Sam Smith" {
+		t.Errorf("author = %v, want Sam Smith", metadata.Author)
+	}
+	if metadata.PublishedAt == nil || metadata.PublishedAt.Year() != 2019 {
+		t.Errorf("published_at = %v, want 2019-11-05", metadata.PublishedAt)
+	}
+	if metadata.CanonicalURL != nil || metadata.FaviconURL != nil || metadata.Properties != nil || metadata.JSONLD != nil {
+		t.Errorf("expected no canonical URL, favicon, properties or JSON-LD, got %+v", metadata)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -14,14 +15,23 @@ import (
 
 	md "github.com/JohannesKaufmann/html-to-markdown/v2"
 	readability "github.com/go-shiori/go-readability"
+	"golang.org/x/net/html"
+	"garden3/internal/domain/entity"
 )
 
 // Processor implements the output.ContentProcessor interface
-type Processor struct{}
+type Processor struct {
+	extractors *Registry
+}
 
-// NewProcessor creates a new content processor
+// NewProcessor creates a new content processor with the built-in site extractors
 func NewProcessor() *Processor {
-	return &Processor{}
+	return NewProcessorWithRegistry(NewRegistry(DefaultExtractors()...))
+}
+
+// NewProcessorWithRegistry creates a new content processor using the given site extractors
+func NewProcessorWithRegistry(extractors *Registry) *Processor {
+	return &Processor{extractors: extractors}
 }
 
 func (p *Processor) ProcessWithLynx(ctx context.Context, htmlContent string) (string, error) {
@@ -46,45 +56,53 @@ func (p *Processor) ProcessWithLynx(ctx context.Context, htmlContent string) (st
 }
 
 func (p *Processor) ProcessWithReader(ctx context.Context, htmlContent []byte, sourceURL string) (string, error) {
-	// Parse the URL for readability
-	parsedURL, err := url.Parse(sourceURL)
-	if err != nil || sourceURL == "" {
-		// Use a dummy URL if none provided or invalid
-		parsedURL, _ = url.Parse("https://example.com")
-	}
-
-	// Parse the HTML with readability
-	article, err := readability.FromReader(bytes.NewReader(htmlContent), parsedURL)
-	if err != nil {
-		return "", fmt.Errorf("could not parse content: Readability failed")
-	}
-
-	// Convert HTML content to Markdown using default converter
-	markdown, err := md.ConvertString(article.Content)
+	title, markdown, err := readArticle(htmlContent, sourceURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to convert HTML to markdown: %w", err)
+		return "", err
 	}
 
-	// Build output similar to TypeScript version
-	var result strings.Builder
-
-	// Add title
-	result.WriteString("# ")
-	result.WriteString(article.Title)
-	result.WriteString("\n")
+	return formatDocument(title, sourceURL, markdown), nil
+}
 
-	// Add URL attribution if provided
-	if sourceURL != "" {
-		result.WriteString("(extracted from **")
-		result.WriteString(sourceURL)
-		result.WriteString("**)")
-		result.WriteString("\n")
+func (p *Processor) Extract(ctx context.Context, htmlContent []byte, sourceURL string) (*entity.ExtractedDocument, error) {
+	if parsedURL, err := url.Parse(sourceURL); err == nil && sourceURL != "" {
+		if extractors := p.extractors.Lookup(parsedURL); len(extractors) > 0 {
+			doc, err := html.Parse(bytes.NewReader(htmlContent))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse HTML: %w", err)
+			}
+
+			for _, extractor := range extractors {
+				extraction, err := extractor.Extract(doc, parsedURL)
+				if err != nil {
+					log.Printf("%s extractor failed for %s: %v", extractor.Name(), sourceURL, err)
+					continue
+				}
+				if extraction == nil || strings.TrimSpace(extraction.Markdown) == "" {
+					continue
+				}
+
+				return &entity.ExtractedDocument{
+					Extractor: extractor.Name(),
+					Title:     extraction.Title,
+					Content:   formatDocument(extraction.Title, sourceURL, extraction.Markdown),
+					Metadata:  extraction.Metadata,
+				}, nil
+			}
+		}
 	}
 
-	result.WriteString("\n")
-	result.WriteString(markdown)
+	// Readability handles every page no site extractor understood
+	title, markdown, err := readArticle(htmlContent, sourceURL)
+	if err != nil {
+		return nil, err
+	}
 
-	return result.String(), nil
+	return &entity.ExtractedDocument{
+		Extractor: "reader",
+		Title:     title,
+		Content:   formatDocument(title, sourceURL, markdown),
+	}, nil
 }
 
 func (p *Processor) ProcessURL(ctx context.Context, urlStr string) (string, error) {
@@ -115,3 +133,27 @@ func (p *Processor) ProcessURL(ctx context.Context, urlStr string) (string, erro
 	// Process the content
 	return p.ProcessWithReader(ctx, htmlContent, urlStr)
 }
+
+// readArticle extracts the main article of a page with readability and converts it to markdown
+func readArticle(htmlContent []byte, sourceURL string) (string, string, error) {
+	// Parse the URL for readability
+	parsedURL, err := url.Parse(sourceURL)
+	if err != nil || sourceURL == "" {
+		// Use a dummy URL if none provided or invalid
+		parsedURL, _ = url.Parse("https://example.com")
+	}
+
+	// Parse the HTML with readability
+	article, err := readability.FromReader(bytes.NewReader(htmlContent), parsedURL)
+	if err != nil {
+		return "", "", fmt.Errorf("could not parse content: Readability failed")
+	}
+
+	// Convert HTML content to Markdown using default converter
+	markdown, err := md.ConvertString(article.Content)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to convert HTML to markdown: %w", err)
+	}
+
+	return article.Title, markdown, nil
+}
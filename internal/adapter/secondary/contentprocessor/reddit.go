@@ -0,0 +1,170 @@
+package contentprocessor
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// redditExtractor reads a Reddit thread as the post followed by its comment tree, from the current or the old layout
+type redditExtractor struct{}
+
+func (e *redditExtractor) Name() string {
+	return "reddit"
+}
+
+func (e *redditExtractor) Match(u *url.URL) bool {
+	return hostIs(u, "reddit.com", "old.reddit.com", "new.reddit.com") && strings.Contains(u.Path, "/comments/")
+}
+
+func (e *redditExtractor) Extract(doc *html.Node, u *url.URL) (*Extraction, error) {
+	if post := query(doc, "shreddit-post"); post != nil {
+		return e.extractCurrent(doc, post, u)
+	}
+	if post := query(doc, "#siteTable div.thing.link"); post != nil {
+		return e.extractOld(doc, post, u)
+	}
+	return nil, nil
+}
+
+// extractCurrent reads the web component layout, where post and comment details are element attributes
+func (e *redditExtractor) extractCurrent(doc, post *html.Node, u *url.URL) (*Extraction, error) {
+	thread := redditThread{
+		title:     attr(post, "post-title"),
+		author:    attr(post, "author"),
+		subreddit: attr(post, "subreddit-prefixed-name"),
+		score:     attr(post, "score"),
+		link:      attr(post, "content-href"),
+		body:      query(post, `[slot="text-body"]`),
+	}
+
+	for _, comment := range queryAll(doc, "shreddit-comment") {
+		depth, _ := strconv.Atoi(attr(comment, "depth"))
+		thread.comments = append(thread.comments, redditComment{
+			author: attr(comment, "author"),
+			depth:  depth,
+			body:   query(comment, `[slot="comment"]`),
+		})
+	}
+
+	return thread.extraction(u)
+}
+
+// extractOld reads old.reddit.com, where comments nest as child listings
+func (e *redditExtractor) extractOld(doc, post *html.Node, u *url.URL) (*Extraction, error) {
+	thread := redditThread{
+		title:     textContent(query(post, "a.title")),
+		author:    attr(post, "data-author"),
+		subreddit: attr(post, "data-subreddit-prefixed"),
+		score:     attr(post, "data-score"),
+		link:      attr(post, "data-url"),
+		body:      query(post, ".expando .usertext-body .md"),
+	}
+
+	for _, comment := range queryAll(doc, ".commentarea div.thing.comment") {
+		depth := 0
+		for parent := comment.Parent; parent != nil; parent = parent.Parent {
+			if hasClass(parent, "comment") {
+				depth++
+			}
+		}
+
+		// The first text in a comment is its own, replies follow in its child listing
+		var body *html.Node
+		for child := comment.FirstChild; child != nil; child = child.NextSibling {
+			if hasClass(child, "entry") {
+				body = query(child, ".usertext-body .md")
+				break
+			}
+		}
+
+		thread.comments = append(thread.comments, redditComment{
+			author: attr(comment, "data-author"),
+			depth:  depth,
+			body:   body,
+		})
+	}
+
+	return thread.extraction(u)
+}
+
+type redditThread struct {
+	title     string
+	author    string
+	subreddit string
+	score     string
+	link      string
+	body      *html.Node
+	comments  []redditComment
+}
+
+type redditComment struct {
+	author string
+	depth  int
+	body   *html.Node
+}
+
+func (t redditThread) extraction(u *url.URL) (*Extraction, error) {
+	if t.title == "" {
+		return nil, nil
+	}
+
+	metadata := map[string]string{
+		"comments": strconv.Itoa(len(t.comments)),
+	}
+	var markdown strings.Builder
+
+	if t.subreddit != "" {
+		metadata["subreddit"] = t.subreddit
+	}
+	if t.author != "" {
+		metadata["author"] = t.author
+	}
+	if t.score != "" {
+		metadata["score"] = t.score
+	}
+	if t.subreddit != "" && t.author != "" {
+		markdown.WriteString(fmt.Sprintf("Posted in %s by u/%s\n\n", t.subreddit, t.author))
+	}
+
+	// Self posts link to their own thread
+	if link, err := u.Parse(t.link); err == nil && t.link != "" && !strings.Contains(link.Path, "/comments/") {
+		metadata["link"] = link.String()
+		markdown.WriteString(fmt.Sprintf("Link: <%s>\n\n", link.String()))
+	}
+
+	body, err := toMarkdown(t.body, u)
+	if err != nil {
+		return nil, err
+	}
+	if body != "" {
+		markdown.WriteString(body)
+		markdown.WriteString("\n\n")
+	}
+
+	if len(t.comments) > 0 {
+		markdown.WriteString("## Comments\n\n")
+	}
+	for _, comment := range t.comments {
+		// Deleted comments keep their place in the tree but have no text
+		if comment.body == nil {
+			continue
+		}
+		body, err := toMarkdown(comment.body, u)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := strings.Repeat("  ", comment.depth)
+		markdown.WriteString(fmt.Sprintf("%s- **%s**: %s\n", prefix, comment.author, indent(body, prefix+"  ")))
+	}
+
+	return &Extraction{
+		Title:    t.title,
+		Markdown: strings.TrimSpace(markdown.String()),
+		Metadata: metadata,
+	}, nil
+}
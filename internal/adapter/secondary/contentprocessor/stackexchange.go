@@ -0,0 +1,83 @@
+package contentprocessor
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// stackExchangeExtractor reads a Stack Overflow or other Stack Exchange question with its answers
+type stackExchangeExtractor struct{}
+
+func (e *stackExchangeExtractor) Name() string {
+	return "stackexchange"
+}
+
+func (e *stackExchangeExtractor) Match(u *url.URL) bool {
+	if !strings.HasPrefix(u.Path, "/questions/") {
+		return false
+	}
+	return hostIs(u, "stackoverflow.com", "serverfault.com", "superuser.com", "askubuntu.com", "mathoverflow.net", "stackapps.com") ||
+		strings.HasSuffix(strings.ToLower(u.Hostname()), ".stackexchange.com")
+}
+
+func (e *stackExchangeExtractor) Extract(doc *html.Node, u *url.URL) (*Extraction, error) {
+	question := query(doc, "#question")
+	title := textContent(query(doc, "#question-header h1"))
+	if question == nil || title == "" {
+		return nil, nil
+	}
+
+	body, err := toMarkdown(query(question, ".js-post-body"), u)
+	if err != nil {
+		return nil, err
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString(body)
+	markdown.WriteString("\n\n")
+
+	metadata := map[string]string{
+		"site": strings.ToLower(u.Hostname()),
+	}
+	if score := attr(question, "data-score"); score != "" {
+		metadata["score"] = score
+	}
+
+	var tags []string
+	for _, tag := range queryAll(question, ".post-tag") {
+		tags = append(tags, textContent(tag))
+	}
+	if len(tags) > 0 {
+		metadata["tags"] = strings.Join(tags, ", ")
+		markdown.WriteString("Tags: " + metadata["tags"] + "\n\n")
+	}
+
+	answers := queryAll(doc, "#answers div.answer")
+	metadata["answers"] = strconv.Itoa(len(answers))
+	for _, answer := range answers {
+		body, err := toMarkdown(query(answer, ".js-post-body"), u)
+		if err != nil {
+			return nil, err
+		}
+
+		heading := "## Answer"
+		if hasClass(answer, "accepted-answer") {
+			metadata["accepted"] = "true"
+			heading = "## Accepted answer"
+		}
+		if score := attr(answer, "data-score"); score != "" {
+			heading += fmt.Sprintf(" (score %s)", score)
+		}
+		markdown.WriteString(heading + "\n\n" + body + "\n\n")
+	}
+
+	return &Extraction{
+		Title:    title,
+		Markdown: strings.TrimSpace(markdown.String()),
+		Metadata: metadata,
+	}, nil
+}
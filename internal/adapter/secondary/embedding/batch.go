@@ -0,0 +1,245 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+// BatchConfig controls how a Batcher sends texts to its provider
+type BatchConfig struct {
+	// BatchSize is the number of texts sent in one request
+	BatchSize int
+
+	// Concurrency bounds the requests in flight across all callers
+	Concurrency int
+
+	// Timeout bounds every request attempt
+	Timeout time.Duration
+
+	// MaxRetries is how often a request failing with a 5xx status, a 429, a timeout or a refused or reset
+	// connection is retried
+	MaxRetries int
+
+	// RetryDelay is the base delay before a retry, doubled with every attempt and jittered
+	RetryDelay time.Duration
+}
+
+// DefaultBatchConfig returns the batch settings used when none are configured
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		BatchSize:   32,
+		Concurrency: 4,
+		Timeout:     60 * time.Second,
+		MaxRetries:  3,
+		RetryDelay:  500 * time.Millisecond,
+	}
+}
+
+// Batcher implements the Provider interface on top of another provider, splitting texts into batches that
+// are embedded concurrently with per-request timeouts and retries
+type Batcher struct {
+	provider Provider
+	config   BatchConfig
+	slots    chan struct{}
+}
+
+// NewBatcher creates a new batcher, replacing unset settings with their defaults. A MaxRetries of 0 disables retries
+func NewBatcher(provider Provider, config BatchConfig) *Batcher {
+	defaults := DefaultBatchConfig()
+	if config.BatchSize < 1 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Concurrency < 1 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+
+	return &Batcher{
+		provider: provider,
+		config:   config,
+		slots:    make(chan struct{}, config.Concurrency),
+	}
+}
+
+// ContextTokens returns how many tokens the model of the wrapped provider embeds at once, 0 when unknown
+func (b *Batcher) ContextTokens() int {
+	return contextTokens(b.provider)
+}
+
+// ModelName returns the name of the model of the wrapped provider, "" when it cannot tell
+func (b *Batcher) ModelName() string {
+	return modelName(b.provider)
+}
+
+// ModelID identifies the vectors of the wrapped provider, "" when it cannot tell
+func (b *Batcher) ModelID() string {
+	return modelID(b.provider)
+}
+
+// CheckQuery reports whether the vectors of the wrapped provider can be compared with the stored ones
+func (b *Batcher) CheckQuery() error {
+	return checkQuery(b.provider)
+}
+
+// Embed generates one embedding vector per text
+func (b *Batcher) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return b.EmbedWithProgress(ctx, texts, nil)
+}
+
+// EmbedWithProgress generates one embedding vector per text, calling progress after every finished batch
+// when it is not nil. Progress calls do not overlap. The first failing batch cancels the others
+func (b *Batcher) EmbedWithProgress(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	if len(texts) == 0 {
+		return vectors, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int
+		start    = time.Now()
+	)
+
+	for offset := 0; offset < len(texts); offset += b.config.BatchSize {
+		end := min(offset+b.config.BatchSize, len(texts))
+
+		acquired := false
+		select {
+		case b.slots <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+		if !acquired {
+			break
+		}
+
+		wg.Add(1)
+		go func(offset, end int) {
+			defer wg.Done()
+			defer func() { <-b.slots }()
+
+			batch, err := b.embedBatch(ctx, texts[offset:end])
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			copy(vectors[offset:end], batch)
+
+			done += end - offset
+			if progress != nil {
+				progress(embeddingProgress(done, len(texts), time.Since(start)))
+			}
+		}(offset, end)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// embedBatch embeds one batch, retrying failures that may pass on another attempt
+func (b *Batcher) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, b.config.Timeout)
+		vectors, err := b.provider.Embed(attemptCtx, texts)
+		cancel()
+		if err == nil {
+			return vectors, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= b.config.MaxRetries || !retryable(err) {
+			if attempt > 0 {
+				return nil, fmt.Errorf("failed after %d attempts: %w", attempt+1, err)
+			}
+			return nil, err
+		}
+
+		delay := retryDelay(b.config.RetryDelay, attempt)
+		log.Printf("Embedding request of %d texts failed, retrying in %s: %v", len(texts), delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed request may succeed when sent again: server errors, rate limits,
+// attempts that ran into their timeout and connections that were refused or reset. Other failures, such as
+// an unknown host or a rejected certificate, fail the same way every time
+func retryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= http.StatusInternalServerError || statusErr.statusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// retryDelay doubles the base delay with every attempt and picks a random delay between half of it and all
+// of it, so that concurrent batches failing together do not retry in lockstep
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := base << min(attempt, 10)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// embeddingProgress estimates the remaining time from the pace so far
+func embeddingProgress(done, total int, elapsed time.Duration) entity.EmbeddingProgress {
+	progress := entity.EmbeddingProgress{
+		Done:    done,
+		Total:   total,
+		Elapsed: elapsed,
+	}
+	if done > 0 {
+		progress.Remaining = time.Duration(float64(elapsed) / float64(done) * float64(total-done))
+	}
+	return progress
+}
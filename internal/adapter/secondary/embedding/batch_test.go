@@ -0,0 +1,204 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+// countingProvider embeds a text as its number and tracks the calls in flight
+type countingProvider struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    int
+}
+
+func (p *countingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	p.mu.Lock()
+	p.inFlight++
+	p.calls++
+	p.peak = max(p.peak, p.inFlight)
+	p.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = []float32{float32(n)}
+	}
+	return vectors, nil
+}
+
+func TestBatcherEmbedWithProgress(t *testing.T) {
+	provider := &countingProvider{}
+	batcher := NewBatcher(provider, BatchConfig{BatchSize: 3, Concurrency: 2})
+
+	texts := make([]string, 20)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+
+	var reports []entity.EmbeddingProgress
+	vectors, err := batcher.EmbedWithProgress(context.Background(), texts, func(progress entity.EmbeddingProgress) {
+		reports = append(reports, progress)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, vector := range vectors {
+		if vector[0] != float32(i) {
+			t.Fatalf("vector %d out of order: %v", i, vector)
+		}
+	}
+	if provider.calls != 7 {
+		t.Errorf("expected 7 requests, got %d", provider.calls)
+	}
+	if provider.peak > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", provider.peak)
+	}
+	if len(reports) != 7 {
+		t.Fatalf("expected 7 progress reports, got %d", len(reports))
+	}
+	last := reports[len(reports)-1]
+	if last.Done != 20 || last.Total != 20 || last.Remaining != 0 {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+}
+
+func TestBatcherRetries(t *testing.T) {
+	testCases := []struct {
+		name      string
+		failures  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{
+			name:      "server error is retried",
+			failures:  []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			wantCalls: 3,
+		},
+		{
+			name:      "client error is not retried",
+			failures:  []int{http.StatusBadRequest},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "retries run out",
+			failures:  []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := int(calls.Add(1))
+				if call <= len(tc.failures) {
+					w.WriteHeader(tc.failures[call-1])
+					return
+				}
+				w.Write([]byte(`{"embeddings": [[1, 0]]}`))
+			}))
+			defer server.Close()
+
+			batcher := NewBatcher(NewOllamaProvider(Config{URL: server.URL}), BatchConfig{MaxRetries: 2, RetryDelay: time.Millisecond})
+			_, err := batcher.Embed(context.Background(), []string{"text"})
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls.Load() != tc.wantCalls {
+				t.Errorf("expected %d calls, got %d", tc.wantCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: &statusError{statusCode: http.StatusBadGateway}, want: true},
+		{name: "rate limit", err: &statusError{statusCode: http.StatusTooManyRequests}, want: true},
+		{name: "client error", err: &statusError{statusCode: http.StatusNotFound}, want: false},
+		{name: "attempt timeout", err: fmt.Errorf("request failed: %w", context.DeadlineExceeded), want: true},
+		{name: "dial timeout", err: &url.Error{Op: "Post", URL: "http://ollama", Err: &net.OpError{Op: "dial", Err: timeoutError{}}}, want: true},
+		{name: "connection refused", err: &url.Error{Op: "Post", URL: "http://ollama", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, want: true},
+		{name: "connection reset", err: &url.Error{Op: "Post", URL: "http://ollama", Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, want: true},
+		{name: "unknown host", err: &url.Error{Op: "Post", URL: "http://ollama", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "ollama", IsNotFound: true}}}, want: false},
+		{name: "unsupported scheme", err: &url.Error{Op: "Post", URL: "ftp://ollama", Err: errors.New("unsupported protocol scheme")}, want: false},
+		{name: "bad response", err: errors.New("failed to decode response"), want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := retryable(tc.err); got != tc.want {
+				t.Errorf("retryable(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error that timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestBatcherTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The connection is only watched for the client going away once the body was read
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"embeddings": [[1, 0]]}`))
+	}))
+	defer server.Close()
+
+	batcher := NewBatcher(NewOllamaProvider(Config{URL: server.URL}), BatchConfig{Timeout: 50 * time.Millisecond, MaxRetries: 1, RetryDelay: time.Millisecond})
+	if _, err := batcher.Embed(context.Background(), []string{"text"}); err != nil {
+		t.Fatalf("expected the timed out request to be retried, got %v", err)
+	}
+}
+
+func TestBatcherStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewBatcher(&countingProvider{}, BatchConfig{}).Embed(ctx, []string{"1", "2"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
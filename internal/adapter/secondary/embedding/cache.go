@@ -0,0 +1,227 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// Cache implements the output.EmbeddingCache interface. It keeps the vectors of embedded texts in a
+// repository, keyed by a hash of the model and the normalized text, and counts hits and misses since it was
+// created. A nil Cache caches nothing and reports itself disabled
+type Cache struct {
+	repo  output.EmbeddingCacheRepository
+	since time.Time
+
+	hits    atomic.Int64
+	misses  atomic.Int64
+	evicted atomic.Int64
+}
+
+// NewCache creates a new embedding cache on top of repo
+func NewCache(repo output.EmbeddingCacheRepository) *Cache {
+	return &Cache{
+		repo:  repo,
+		since: time.Now(),
+	}
+}
+
+// Wrap returns a provider that looks texts up in the cache before embedding them with provider and caches
+// the vectors it makes. Texts are embedded without the cache while the provider cannot name its model, and
+// the provider is returned as it is when the cache is nil
+func (c *Cache) Wrap(provider Provider) Provider {
+	if c == nil {
+		return provider
+	}
+	return &cachedProvider{cache: c, provider: provider}
+}
+
+// Stats reports the hit rate since the cache was created and describes the cached vectors
+func (c *Cache) Stats(ctx context.Context) (entity.EmbeddingCacheStats, error) {
+	if c == nil {
+		return entity.EmbeddingCacheStats{}, nil
+	}
+
+	usage, err := c.repo.GetUsage(ctx)
+	if err != nil {
+		return entity.EmbeddingCacheStats{}, fmt.Errorf("failed to get embedding cache usage: %w", err)
+	}
+
+	stats := entity.EmbeddingCacheStats{
+		Enabled: true,
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Evicted: c.evicted.Load(),
+		Since:   c.since,
+		Usage:   usage,
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats, nil
+}
+
+// Evict removes the vectors unused for longer than maxAge, then all but the maxEntries most recently used
+// ones. A zero maxAge or maxEntries skips that step
+func (c *Cache) Evict(ctx context.Context, maxEntries int, maxAge time.Duration) (entity.EmbeddingCacheEviction, error) {
+	var eviction entity.EmbeddingCacheEviction
+	if c == nil {
+		return eviction, nil
+	}
+
+	if maxAge > 0 {
+		unused, err := c.repo.EvictUnused(ctx, time.Now().Add(-maxAge))
+		if err != nil {
+			return eviction, fmt.Errorf("failed to evict unused embeddings: %w", err)
+		}
+		eviction.Unused = unused
+		c.evicted.Add(unused)
+	}
+
+	if maxEntries > 0 {
+		excess, err := c.repo.EvictExcess(ctx, int32(min(maxEntries, math.MaxInt32)))
+		if err != nil {
+			return eviction, fmt.Errorf("failed to evict excess embeddings: %w", err)
+		}
+		eviction.Excess = excess
+		c.evicted.Add(excess)
+	}
+
+	return eviction, nil
+}
+
+// cacheKey hashes the model and the text. Texts are normalized to NFC and their whitespace runs to single
+// spaces, as neither changes what the text means
+func cacheKey(model, text string) string {
+	normalized := strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+	sum := sha256.Sum256([]byte(model + "\x00" + normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedProvider implements the Provider interface on top of another provider, embedding only the texts
+// the cache has no vector for
+type cachedProvider struct {
+	cache    *Cache
+	provider Provider
+}
+
+// ContextTokens returns how many tokens the model of the wrapped provider embeds at once, 0 when unknown
+func (p *cachedProvider) ContextTokens() int {
+	return contextTokens(p.provider)
+}
+
+// ModelName returns the name of the model of the wrapped provider, "" when it cannot tell
+func (p *cachedProvider) ModelName() string {
+	return modelName(p.provider)
+}
+
+// ModelID identifies the vectors of the wrapped provider, "" when it cannot tell
+func (p *cachedProvider) ModelID() string {
+	return modelID(p.provider)
+}
+
+// CheckQuery reports whether the vectors of the wrapped provider can be compared with the stored ones
+func (p *cachedProvider) CheckQuery() error {
+	return checkQuery(p.provider)
+}
+
+// Embed generates one embedding vector per text
+func (p *cachedProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return p.EmbedWithProgress(ctx, texts, nil)
+}
+
+// EmbedWithProgress generates one embedding vector per text, taking those it can from the cache and
+// embedding the rest with the wrapped provider. Cached texts count as done from the start. A cache that
+// cannot be read or written is logged and bypassed, it never fails the embedding
+func (p *cachedProvider) EmbedWithProgress(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	model := p.ModelID()
+	if model == "" || len(texts) == 0 {
+		return embedWithProgress(ctx, p.provider, texts, progress)
+	}
+
+	keys := make([]string, len(texts))
+	unique := make([]string, 0, len(texts))
+	seen := make(map[string]bool, len(texts))
+	for i, text := range texts {
+		keys[i] = cacheKey(model, text)
+		if !seen[keys[i]] {
+			seen[keys[i]] = true
+			unique = append(unique, keys[i])
+		}
+	}
+
+	cached, err := p.cache.repo.TouchEmbeddings(ctx, unique)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("Embedding cache lookup of %d texts failed, embedding them all: %v", len(texts), err)
+		cached = nil
+	}
+
+	// Texts missing from the cache are embedded once, however often they occur
+	vectors := make([][]float32, len(texts))
+	var missing, missingKeys []string
+	missingAt := make(map[string]int)
+	hits := 0
+	for i, key := range keys {
+		if vector, ok := cached[key]; ok {
+			vectors[i] = vector
+			hits++
+			continue
+		}
+		if _, ok := missingAt[key]; !ok {
+			missingAt[key] = len(missing)
+			missing = append(missing, texts[i])
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	p.cache.hits.Add(int64(hits))
+	p.cache.misses.Add(int64(len(texts) - hits))
+
+	if len(missing) == 0 {
+		if progress != nil {
+			progress(embeddingProgress(len(texts), len(texts), 0))
+		}
+		return vectors, nil
+	}
+
+	var missingProgress func(entity.EmbeddingProgress)
+	if progress != nil {
+		skipped := len(texts) - len(missing)
+		missingProgress = func(prog entity.EmbeddingProgress) {
+			prog.Done += skipped
+			prog.Total += skipped
+			progress(prog)
+		}
+	}
+	embedded, err := embedWithProgress(ctx, p.provider, missing, missingProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	toStore := make([]entity.CachedEmbedding, len(missing))
+	for i, key := range missingKeys {
+		toStore[i] = entity.CachedEmbedding{Key: key, Model: model, Embedding: embedded[i]}
+	}
+	for i, key := range keys {
+		if vectors[i] == nil {
+			vectors[i] = embedded[missingAt[key]]
+		}
+	}
+
+	if err := p.cache.repo.StoreEmbeddings(ctx, toStore); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to cache %d embeddings: %v", len(toStore), err)
+	}
+	return vectors, nil
+}
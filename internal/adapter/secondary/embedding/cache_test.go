@@ -0,0 +1,208 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+// cacheEntries is an in-memory embedding cache repository
+type cacheEntries struct {
+	vectors map[string][]float32
+	models  map[string]string
+	fail    bool
+}
+
+func newCacheEntries() *cacheEntries {
+	return &cacheEntries{vectors: map[string][]float32{}, models: map[string]string{}}
+}
+
+func (c *cacheEntries) TouchEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error) {
+	if c.fail {
+		return nil, errors.New("cache unavailable")
+	}
+	found := make(map[string][]float32)
+	for _, key := range keys {
+		if vector, ok := c.vectors[key]; ok {
+			found[key] = vector
+		}
+	}
+	return found, nil
+}
+
+func (c *cacheEntries) StoreEmbeddings(ctx context.Context, embeddings []entity.CachedEmbedding) error {
+	if c.fail {
+		return errors.New("cache unavailable")
+	}
+	for _, cached := range embeddings {
+		c.vectors[cached.Key] = cached.Embedding
+		c.models[cached.Key] = cached.Model
+	}
+	return nil
+}
+
+func (c *cacheEntries) GetUsage(ctx context.Context) (entity.EmbeddingCacheUsage, error) {
+	return entity.EmbeddingCacheUsage{Entries: int64(len(c.vectors))}, nil
+}
+
+func (c *cacheEntries) EvictUnused(ctx context.Context, usedBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (c *cacheEntries) EvictExcess(ctx context.Context, maxEntries int32) (int64, error) {
+	evicted := int64(0)
+	for key := range c.vectors {
+		if len(c.vectors) <= int(maxEntries) {
+			break
+		}
+		delete(c.vectors, key)
+		evicted++
+	}
+	return evicted, nil
+}
+
+// modelProvider is a counting provider that names its model
+type modelProvider struct {
+	countingProvider
+	model string
+	texts []string
+}
+
+func (p *modelProvider) ModelID() string {
+	return p.model
+}
+
+func (p *modelProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	p.texts = append(p.texts, texts...)
+	return p.countingProvider.Embed(ctx, texts)
+}
+
+func TestCacheKey(t *testing.T) {
+	testCases := []struct {
+		name  string
+		a, b  string
+		model string
+		same  bool
+	}{
+		{name: "whitespace runs", a: "a  short\n\ttext ", b: "a short text", model: "m", same: true},
+		{name: "unicode normalization", a: "cafe\u0301", b: "caf\u00e9", model: "m", same: true},
+		{name: "different text", a: "a short text", b: "a longer text", model: "m", same: false},
+		{name: "case", a: "Text", b: "text", model: "m", same: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if same := cacheKey(tc.model, tc.a) == cacheKey(tc.model, tc.b); same != tc.same {
+				t.Errorf("cacheKey(%q) == cacheKey(%q) is %v, want %v", tc.a, tc.b, same, tc.same)
+			}
+		})
+	}
+
+	if cacheKey("ollama:a", "text") == cacheKey("ollama:b", "text") {
+		t.Error("expected the model to be part of the key")
+	}
+}
+
+func TestCachedProviderEmbed(t *testing.T) {
+	entries := newCacheEntries()
+	cache := NewCache(entries)
+	provider := &modelProvider{model: "ollama:numbers"}
+	cached := cache.Wrap(provider)
+
+	vectors, err := cached.Embed(context.Background(), []string{"1", "2", "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(vectors, [][]float32{{1}, {2}, {1}}) {
+		t.Fatalf("unexpected vectors %v", vectors)
+	}
+	if !reflect.DeepEqual(provider.texts, []string{"1", "2"}) {
+		t.Errorf("expected each text to be embedded once, got %q", provider.texts)
+	}
+	if len(entries.vectors) != 2 {
+		t.Errorf("expected 2 cached vectors, got %d", len(entries.vectors))
+	}
+	for key, model := range entries.models {
+		if model != "ollama:numbers" {
+			t.Errorf("vector %s cached for model %q", key, model)
+		}
+	}
+
+	provider.texts = nil
+	var reports []entity.EmbeddingProgress
+	vectors, err = cached.(*cachedProvider).EmbedWithProgress(context.Background(), []string{"2", "3", " 1 "}, func(progress entity.EmbeddingProgress) {
+		reports = append(reports, progress)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(vectors, [][]float32{{2}, {3}, {1}}) {
+		t.Fatalf("unexpected vectors %v", vectors)
+	}
+	if !reflect.DeepEqual(provider.texts, []string{"3"}) {
+		t.Errorf("expected only the new text to be embedded, got %q", provider.texts)
+	}
+	if len(reports) != 1 || reports[0].Done != 3 || reports[0].Total != 3 {
+		t.Errorf("expected the cached texts to count as done, got %+v", reports)
+	}
+
+	stats, err := cache.Stats(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stats.Enabled || stats.Hits != 2 || stats.Misses != 4 || stats.HitRate != 1.0/3 || stats.Usage.Entries != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	eviction, err := cache.Evict(context.Background(), 1, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eviction.Excess != 2 || len(entries.vectors) != 1 {
+		t.Errorf("expected 2 vectors evicted down to 1, got %+v with %d left", eviction, len(entries.vectors))
+	}
+}
+
+func TestCachedProviderBypass(t *testing.T) {
+	t.Run("unavailable cache", func(t *testing.T) {
+		entries := newCacheEntries()
+		entries.fail = true
+		provider := &modelProvider{model: "ollama:numbers"}
+
+		vectors, err := NewCache(entries).Wrap(provider).Embed(context.Background(), []string{"4", "5"})
+		if err != nil {
+			t.Fatalf("expected a failing cache to be bypassed, got %v", err)
+		}
+		if !reflect.DeepEqual(vectors, [][]float32{{4}, {5}}) {
+			t.Errorf("unexpected vectors %v", vectors)
+		}
+	})
+
+	t.Run("unnamed model", func(t *testing.T) {
+		entries := newCacheEntries()
+		vectors, err := NewCache(entries).Wrap(NewHashProvider(8)).Embed(context.Background(), []string{strings.Repeat("word ", 10)})
+		if err != nil || len(vectors) != 1 {
+			t.Fatalf("unexpected result %v, %v", vectors, err)
+		}
+		if len(entries.vectors) != 0 {
+			t.Errorf("expected nothing cached for a provider without a model ID, got %d vectors", len(entries.vectors))
+		}
+	})
+
+	t.Run("nil cache", func(t *testing.T) {
+		var cache *Cache
+		provider := NewHashProvider(8)
+		if cache.Wrap(provider) != Provider(provider) {
+			t.Error("expected a nil cache to return the provider as it is")
+		}
+		stats, err := cache.Stats(context.Background())
+		if err != nil || stats.Enabled {
+			t.Errorf("expected a disabled cache, got %+v, %v", stats, err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"garden3/internal/adapter/secondary/chunking"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// EmbeddingService implements the EmbeddingService interface on top of a provider
+type EmbeddingService struct {
+	provider Provider
+}
+
+// NewEmbeddingService creates a new embedding service for single embeddings
+func NewEmbeddingService(provider Provider) output.EmbeddingService {
+	return &EmbeddingService{
+		provider: provider,
+	}
+}
+
+// GetEmbedding generates a single embedding vector for the given query. It fails while the provider's vectors
+// cannot be compared with the stored ones
+func (s *EmbeddingService) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := checkQuery(s.provider); err != nil {
+		return nil, err
+	}
+	vectors, err := s.provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbeddingsService implements the EmbeddingsService interface on top of a provider with text chunking
+type EmbeddingsService struct {
+	provider Provider
+}
+
+// NewEmbeddingsService creates a new embeddings service for chunked embeddings
+func NewEmbeddingsService(provider Provider) output.EmbeddingsService {
+	return &EmbeddingsService{
+		provider: provider,
+	}
+}
+
+// GetEmbedding generates chunked embeddings for the given text, embedding all chunks in one request.
+// Texts are cut along their Markdown structure into chunks sized for the provider's model
+func (s *EmbeddingsService) GetEmbedding(ctx context.Context, text string) ([]entity.Embedding, error) {
+	chunks := chunking.Split(text, chunking.ForContext(contextTokens(s.provider)))
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+
+	vectors, err := s.provider.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding for chunks: %w", err)
+	}
+
+	embeddings := make([]entity.Embedding, len(chunks))
+	for i, chunk := range chunks {
+		embeddings[i] = entity.Embedding{
+			Text:      chunk.Text,
+			Embedding: vectors[i],
+			Chunk: &entity.ChunkPosition{
+				Index:    chunk.Index,
+				Start:    chunk.Start,
+				End:      chunk.End,
+				Headings: chunk.Headings,
+				Tokens:   chunk.Tokens,
+			},
+		}
+	}
+
+	return embeddings, nil
+}
+
+// EmbedBatch generates one embedding per text without chunking. Progress is reported after every batch
+// when the provider is a Batcher or a cached one, and once at the end otherwise
+func (s *EmbeddingsService) EmbedBatch(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	return embedWithProgress(ctx, s.provider, texts, progress)
+}
+
+// EmbedQuery generates the embedding of a search query as a whole. It fails while the provider's vectors
+// cannot be compared with the stored ones
+func (s *EmbeddingsService) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if err := checkQuery(s.provider); err != nil {
+		return nil, err
+	}
+	vectors, err := s.provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// Model returns the name of the provider's model, "" when it cannot tell
+func (s *EmbeddingsService) Model() string {
+	return modelName(s.provider)
+}
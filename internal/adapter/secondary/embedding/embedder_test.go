@@ -0,0 +1,70 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"garden3/internal/adapter/secondary/chunking"
+)
+
+// limitedProvider is a hashing provider for a model with a short context
+type limitedProvider struct {
+	*HashProvider
+	tokens int
+}
+
+func (p limitedProvider) ContextTokens() int {
+	return p.tokens
+}
+
+func TestEmbeddingsServiceGetEmbedding(t *testing.T) {
+	service := NewEmbeddingsService(NewBatcher(limitedProvider{HashProvider: NewHashProvider(8), tokens: 256}, BatchConfig{}))
+	maxTokens := chunking.ForContext(256).MaxTokens
+
+	embeddings, err := service.GetEmbedding(context.Background(), "a short query")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(embeddings) != 1 || embeddings[0].Text != "a short query" {
+		t.Fatalf("expected a short text to be embedded whole, got %+v", embeddings)
+	}
+
+	var doc strings.Builder
+	doc.WriteString("# Article\n\n")
+	for section := 1; section <= 4; section++ {
+		fmt.Fprintf(&doc, "## Part %d\n\n", section)
+		for i := 0; i < 30; i++ {
+			fmt.Fprintf(&doc, "Sentence %d of part %d goes on about the topic. ", i, section)
+		}
+		doc.WriteString("\n\n")
+	}
+
+	embeddings, err = service.GetEmbedding(context.Background(), doc.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(embeddings) < 8 {
+		t.Fatalf("expected the whole article in chunks sized for the model, got %d chunks", len(embeddings))
+	}
+
+	last := embeddings[len(embeddings)-1]
+	if last.Chunk == nil || last.Chunk.End < len([]rune(strings.TrimSpace(doc.String()))) {
+		t.Errorf("expected the last chunk to reach the end of the article, got %+v", last.Chunk)
+	}
+	for i, embedding := range embeddings {
+		if embedding.Chunk == nil || embedding.Chunk.Index != i {
+			t.Fatalf("chunk %d has position %+v", i, embedding.Chunk)
+		}
+		if embedding.Chunk.Tokens > maxTokens {
+			t.Errorf("chunk %d has %d tokens, over %d", i, embedding.Chunk.Tokens, maxTokens)
+		}
+		if i > 0 && (len(embedding.Chunk.Headings) != 2 || embedding.Chunk.Headings[0] != "Article") {
+			t.Errorf("chunk %d has headings %q, expected Article and its part", i, embedding.Chunk.Headings)
+		}
+		if len(embedding.Embedding) != 8 {
+			t.Errorf("chunk %d has %d dimensions", i, len(embedding.Embedding))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// HashProvider implements the Provider interface without a model by hashing words and word pairs into a
+// fixed number of dimensions. Texts sharing words get similar vectors, which is enough for offline tests
+// and development but not for real semantic search
+type HashProvider struct {
+	dimensions int
+}
+
+// NewHashProvider creates a new hashing provider, with 1024 dimensions by default
+func NewHashProvider(dimensions int) *HashProvider {
+	if dimensions <= 0 {
+		dimensions = defaultHashDimensions
+	}
+	return &HashProvider{dimensions: dimensions}
+}
+
+// Embed generates one normalized vector per text, always the same for the same text
+func (p *HashProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = p.embed(text)
+	}
+	return vectors, nil
+}
+
+func (p *HashProvider) embed(text string) []float32 {
+	vector := make([]float32, p.dimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		// Hash the text itself so that no vector is all zeros, which has no cosine distance
+		p.add(vector, text)
+	}
+	for i, word := range words {
+		p.add(vector, word)
+		if i > 0 {
+			p.add(vector, words[i-1]+" "+word)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		// Every feature was cancelled out by a collision
+		vector[0] = 1
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}
+
+// add counts a feature in the dimension its hash selects, with a sign from the hash so that collisions
+// tend to cancel out
+func (p *HashProvider) add(vector []float32, feature string) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	index := sum % uint64(p.dimensions)
+	if sum>>63 == 0 {
+		vector[index]++
+	} else {
+		vector[index]--
+	}
+}
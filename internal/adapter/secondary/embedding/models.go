@@ -0,0 +1,174 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// Models implements the output.EmbeddingModels interface. It is also the provider behind the live embedding
+// services, forwarding to the provider of the live model so that Switch changes the model of all of them.
+// Search queries are refused while the live model is not the active one
+type Models struct {
+	mu       sync.RWMutex
+	provider Provider
+	config   Config
+	active   string
+
+	batchConfig BatchConfig
+	configRepo  output.ConfigurationRepository
+	cache       *Cache
+}
+
+// NewModels creates the models with cfg as the live one. Models opened for migrations are embedded with
+// batchConfig through cache, which may be nil, and switching the live model stores it in configRepo
+func NewModels(cfg Config, batchConfig BatchConfig, configRepo output.ConfigurationRepository, cache *Cache) (*Models, error) {
+	cfg = cfg.withDefaults()
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Models{
+		provider:    provider,
+		config:      cfg,
+		batchConfig: batchConfig,
+		configRepo:  configRepo,
+		cache:       cache,
+	}, nil
+}
+
+// Embed generates one embedding vector per text with the live model
+func (m *Models) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	m.mu.RLock()
+	provider := m.provider
+	m.mu.RUnlock()
+
+	return provider.Embed(ctx, texts)
+}
+
+// ContextTokens returns how many tokens the live model embeds at once, 0 when unknown
+func (m *Models) ContextTokens() int {
+	m.mu.RLock()
+	provider := m.provider
+	m.mu.RUnlock()
+
+	return contextTokens(provider)
+}
+
+// ModelName returns the name of the live model
+func (m *Models) ModelName() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.config.Model
+}
+
+// ModelID identifies the vectors of the live model, "" when its provider cannot tell
+func (m *Models) ModelID() string {
+	m.mu.RLock()
+	provider := m.provider
+	m.mu.RUnlock()
+
+	return modelID(provider)
+}
+
+// CheckQuery returns entity.ErrEmbeddingModelMismatch while the live model is not the active one
+func (m *Models) CheckQuery() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.active != "" && m.active != m.config.Model {
+		return fmt.Errorf("%w: searches compare against %s, the live model is %s until a migration cuts over", entity.ErrEmbeddingModelMismatch, m.active, m.config.Model)
+	}
+	return nil
+}
+
+// Activate records the model the stored vectors searches compare against are made with
+func (m *Models) Activate(model string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.active = model
+}
+
+// Live returns the configuration of the live model
+func (m *Models) Live() entity.EmbeddingModelConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return modelConfig(m.config)
+}
+
+// Open creates an embeddings service for a model configuration with its own batcher, so a migration does
+// not take request slots from the live model. Vectors of texts the model already embedded come from the cache
+func (m *Models) Open(config entity.EmbeddingModelConfig) (entity.EmbeddingModelConfig, output.EmbeddingsService, error) {
+	cfg := providerConfig(config).withDefaults()
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return entity.EmbeddingModelConfig{}, nil, err
+	}
+
+	return modelConfig(cfg), NewEmbeddingsService(m.cache.Wrap(NewBatcher(provider, m.batchConfig))), nil
+}
+
+// Switch replaces the live model, makes it the active one and stores its configuration in the embedding.*
+// configurations, which LoadConfig reads on the next start
+func (m *Models) Switch(ctx context.Context, config entity.EmbeddingModelConfig) error {
+	cfg := providerConfig(config).withDefaults()
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.provider = provider
+	m.config = cfg
+	m.active = cfg.Model
+	m.mu.Unlock()
+
+	values := []struct {
+		key      string
+		value    string
+		isSecret bool
+	}{
+		{configProvider, cfg.Provider, false},
+		{configURL, cfg.URL, false},
+		{configModel, cfg.Model, false},
+		{configDimensions, strconv.Itoa(cfg.Dimensions), false},
+		{configAPIKey, cfg.APIKey, true},
+	}
+	for _, v := range values {
+		if _, err := m.configRepo.Upsert(ctx, v.key, v.value, v.isSecret, time.Now()); err != nil {
+			return fmt.Errorf("failed to store %s: %w", v.key, err)
+		}
+	}
+	return nil
+}
+
+// providerConfig converts a model configuration of the domain to a provider configuration
+func providerConfig(config entity.EmbeddingModelConfig) Config {
+	return Config{
+		Provider:   config.Provider,
+		URL:        config.URL,
+		Model:      config.Model,
+		Dimensions: config.Dimensions,
+		APIKey:     config.APIKey,
+	}
+}
+
+// modelConfig converts a provider configuration to a model configuration of the domain
+func modelConfig(cfg Config) entity.EmbeddingModelConfig {
+	return entity.EmbeddingModelConfig{
+		Provider:   cfg.Provider,
+		URL:        cfg.URL,
+		Model:      cfg.Model,
+		Dimensions: cfg.Dimensions,
+		APIKey:     cfg.APIKey,
+	}
+}
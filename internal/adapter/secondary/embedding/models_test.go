@@ -0,0 +1,89 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"garden3/internal/domain/entity"
+)
+
+func TestModelsSwitch(t *testing.T) {
+	configs := configValues{}
+	models, err := NewModels(Config{Provider: ProviderHash, Dimensions: 8}, BatchConfig{}, configs, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	live := NewEmbeddingsService(NewBatcher(models, BatchConfig{}))
+	if live.Model() != "hash-8" {
+		t.Errorf("expected the live services to name hash-8, got %q", live.Model())
+	}
+
+	target, opened, err := models.Open(entity.EmbeddingModelConfig{Provider: ProviderHash, Dimensions: 16})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target.Model != "hash-16" {
+		t.Errorf("expected the opened model to default to hash-16, got %q", target.Model)
+	}
+
+	vectors, err := opened.EmbedBatch(context.Background(), []string{"text"}, nil)
+	if err != nil || len(vectors[0]) != 16 {
+		t.Fatalf("expected the opened model to embed with 16 dimensions, got %v, %v", vectors, err)
+	}
+	vectors, err = live.EmbedBatch(context.Background(), []string{"text"}, nil)
+	if err != nil || len(vectors[0]) != 8 {
+		t.Fatalf("expected opening a model to leave the live one alone, got %v, %v", vectors, err)
+	}
+
+	if err := models.Switch(context.Background(), target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vectors, err = live.EmbedBatch(context.Background(), []string{"text"}, nil)
+	if err != nil || len(vectors[0]) != 16 {
+		t.Fatalf("expected the live services to embed with the new model, got %v, %v", vectors, err)
+	}
+	if models.Live().Model != "hash-16" || live.Model() != "hash-16" {
+		t.Errorf("expected hash-16 to be live, got %q and services naming %q", models.Live().Model, live.Model())
+	}
+
+	loaded, err := LoadConfig(context.Background(), configs, Config{Provider: ProviderOllama})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded != (Config{Provider: ProviderHash, Model: "hash-16", Dimensions: 16}) {
+		t.Errorf("expected the switch to be loaded on the next start, got %+v", loaded)
+	}
+}
+
+func TestModelsCheckQuery(t *testing.T) {
+	models, err := NewModels(Config{Provider: ProviderHash, Dimensions: 8}, BatchConfig{}, configValues{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	live := NewEmbeddingsService(NewCache(newCacheEntries()).Wrap(NewBatcher(models, BatchConfig{})))
+	queries := NewEmbeddingService(NewBatcher(models, BatchConfig{}))
+
+	models.Activate("hash-8")
+	if _, err := live.EmbedQuery(context.Background(), "query"); err != nil {
+		t.Fatalf("expected queries to be embedded with the active model, got %v", err)
+	}
+
+	models.Activate("hash-16")
+	if _, err := live.EmbedQuery(context.Background(), "query"); !errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+		t.Errorf("expected a query to be refused while hash-8 is live and hash-16 active, got %v", err)
+	}
+	if _, err := queries.GetEmbedding(context.Background(), "query"); !errors.Is(err, entity.ErrEmbeddingModelMismatch) {
+		t.Errorf("expected the single embedding service to refuse the query too, got %v", err)
+	}
+	if _, err := live.EmbedBatch(context.Background(), []string{"text"}, nil); err != nil {
+		t.Errorf("expected texts to store to still be embedded, got %v", err)
+	}
+
+	if err := models.Switch(context.Background(), entity.EmbeddingModelConfig{Provider: ProviderHash, Dimensions: 16}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := live.EmbedQuery(context.Background(), "query"); err != nil {
+		t.Errorf("expected queries to be embedded again after the cutover, got %v", err)
+	}
+}
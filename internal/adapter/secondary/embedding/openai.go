@@ -0,0 +1,104 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"garden3/internal/adapter/secondary/chunking"
+)
+
+// OpenAIProvider implements the Provider interface using the OpenAI-compatible /v1/embeddings endpoint
+// served by OpenAI, llama.cpp server, vLLM and LocalAI
+type OpenAIProvider struct {
+	url        string
+	model      string
+	dimensions int
+	apiKey     string
+	client     *http.Client
+}
+
+// NewOpenAIProvider creates a new OpenAI-compatible embedding provider. The URL may be the server root or
+// already end in /v1
+func NewOpenAIProvider(cfg Config) *OpenAIProvider {
+	cfg.Provider = ProviderOpenAI
+	cfg = cfg.withDefaults()
+
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+
+	return &OpenAIProvider{
+		url:        baseURL + "/embeddings",
+		model:      cfg.Model,
+		dimensions: cfg.Dimensions,
+		apiKey:     cfg.APIKey,
+		client:     &http.Client{},
+	}
+}
+
+// openAIEmbedRequest represents the request payload for the embeddings API
+type openAIEmbedRequest struct {
+	Model          string   `json:"model,omitempty"`
+	Input          []string `json:"input"`
+	Dimensions     int      `json:"dimensions,omitempty"`
+	EncodingFormat string   `json:"encoding_format"`
+}
+
+// openAIEmbedResponse represents the response from the embeddings API
+type openAIEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// ContextTokens returns how many tokens the model embeds at once, 0 when unknown
+func (p *OpenAIProvider) ContextTokens() int {
+	return chunking.ContextTokens(p.model)
+}
+
+// ModelName returns the name of the model
+func (p *OpenAIProvider) ModelName() string {
+	return p.model
+}
+
+// ModelID identifies the vectors of the model
+func (p *OpenAIProvider) ModelID() string {
+	return providerModelID(ProviderOpenAI, p.model, p.dimensions)
+}
+
+// Embed generates one embedding vector per text in a single request
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	reqBody := openAIEmbedRequest{
+		Model:          p.model,
+		Input:          texts,
+		Dimensions:     p.dimensions,
+		EncodingFormat: "float",
+	}
+
+	var resp openAIEmbedResponse
+	if err := postJSON(ctx, p.client, p.url, p.apiKey, reqBody, &resp); err != nil {
+		return nil, err
+	}
+
+	// Results carry the index of their input and are not guaranteed to be in order
+	vectors := make([][]float32, len(resp.Data))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(vectors) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("embedding API returned an unexpected index %d", item.Index)
+		}
+		vectors[item.Index] = toFloat32(item.Embedding)
+	}
+
+	if err := checkVectors(vectors, texts, p.dimensions); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
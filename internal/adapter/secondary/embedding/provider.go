@@ -0,0 +1,289 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// Providers selectable with the embedding.provider configuration
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+	ProviderHash   = "hash"
+)
+
+// Configuration keys read by LoadConfig
+const (
+	configProvider   = "embedding.provider"
+	configURL        = "embedding.url"
+	configModel      = "embedding.model"
+	configDimensions = "embedding.dimensions"
+	configAPIKey     = "embedding.api_key"
+)
+
+// defaultHashDimensions matches the size of the vector columns
+const defaultHashDimensions = 1024
+
+// Provider turns texts into embedding vectors, returning one vector per text in the same order
+type Provider interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// contextLimited is implemented by providers that know how many tokens their model embeds at once
+type contextLimited interface {
+	ContextTokens() int
+}
+
+// contextTokens returns how many tokens the model behind a provider embeds at once, 0 when unknown
+func contextTokens(provider Provider) int {
+	if limited, ok := provider.(contextLimited); ok {
+		return limited.ContextTokens()
+	}
+	return 0
+}
+
+// modelIdentified is implemented by providers that can name the model and settings their vectors are made
+// with, so that vectors of the same text can be reused
+type modelIdentified interface {
+	ModelID() string
+}
+
+// modelID returns what identifies the vectors of a provider, "" when the provider cannot tell
+func modelID(provider Provider) string {
+	if identified, ok := provider.(modelIdentified); ok {
+		return identified.ModelID()
+	}
+	return ""
+}
+
+// modelNamed is implemented by providers that can name their model as the embedding models are recorded
+type modelNamed interface {
+	ModelName() string
+}
+
+// modelName returns the name of the model behind a provider, "" when the provider cannot tell
+func modelName(provider Provider) string {
+	if named, ok := provider.(modelNamed); ok {
+		return named.ModelName()
+	}
+	return ""
+}
+
+// queryChecked is implemented by providers that know whether the vectors they make can be compared with
+// the stored ones
+type queryChecked interface {
+	CheckQuery() error
+}
+
+// checkQuery returns an error when the vectors of a provider cannot be compared with the stored ones, nil
+// when they can or the provider cannot tell
+func checkQuery(provider Provider) error {
+	if checked, ok := provider.(queryChecked); ok {
+		return checked.CheckQuery()
+	}
+	return nil
+}
+
+// providerModelID identifies the vectors of a model served by a provider. Dimensions are part of it when
+// the model is asked for vectors of a given size
+func providerModelID(provider, model string, dimensions int) string {
+	if dimensions > 0 {
+		return fmt.Sprintf("%s:%s/%d", provider, model, dimensions)
+	}
+	return fmt.Sprintf("%s:%s", provider, model)
+}
+
+// progressEmbedder is implemented by providers that report progress while embedding many texts
+type progressEmbedder interface {
+	EmbedWithProgress(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error)
+}
+
+// embedWithProgress embeds texts with a provider, reporting progress as it goes when the provider supports it
+// and once at the end otherwise
+func embedWithProgress(ctx context.Context, provider Provider, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	if embedder, ok := provider.(progressEmbedder); ok {
+		return embedder.EmbedWithProgress(ctx, texts, progress)
+	}
+
+	start := time.Now()
+	vectors, err := provider.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(embeddingProgress(len(texts), len(texts), time.Since(start)))
+	}
+	return vectors, nil
+}
+
+// Config selects and configures a provider. Dimensions asks the backend for vectors of that size where it
+// supports it and is checked against every returned vector, 0 accepts whatever the model produces.
+// APIKey is sent as a bearer token when set
+type Config struct {
+	Provider   string
+	URL        string
+	Model      string
+	Dimensions int
+	APIKey     string
+}
+
+// withDefaults fills in the URL, model and dimensions a provider uses when they are not configured
+func (c Config) withDefaults() Config {
+	if c.Provider == "" {
+		c.Provider = ProviderOllama
+	}
+
+	switch c.Provider {
+	case ProviderOllama:
+		if c.URL == "" {
+			c.URL = "http://localhost:11434"
+		}
+		if c.Model == "" {
+			c.Model = "nomic-embed-text:latest"
+		}
+	case ProviderOpenAI:
+		if c.URL == "" {
+			c.URL = "http://localhost:8080"
+		}
+	case ProviderHash:
+		if c.Dimensions == 0 {
+			c.Dimensions = defaultHashDimensions
+		}
+		if c.Model == "" {
+			c.Model = fmt.Sprintf("hash-%d", c.Dimensions)
+		}
+	}
+	return c
+}
+
+// LoadConfig applies the embedding.* configurations over cfg and fills in the provider defaults. Selecting
+// another provider than cfg's drops cfg's URL and model, as they belong to the provider it replaces
+func LoadConfig(ctx context.Context, repo output.ConfigurationRepository, cfg Config) (Config, error) {
+	values := make(map[string]string)
+	for _, key := range []string{configProvider, configURL, configModel, configDimensions, configAPIKey} {
+		config, err := repo.GetByKey(ctx, key)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to get %s: %w", key, err)
+		}
+		if config != nil && strings.TrimSpace(config.Value) != "" {
+			values[key] = strings.TrimSpace(config.Value)
+		}
+	}
+
+	if provider, ok := values[configProvider]; ok && provider != cfg.Provider {
+		cfg = Config{Provider: provider}
+	}
+	if url, ok := values[configURL]; ok {
+		cfg.URL = url
+	}
+	if model, ok := values[configModel]; ok {
+		cfg.Model = model
+	}
+	if apiKey, ok := values[configAPIKey]; ok {
+		cfg.APIKey = apiKey
+	}
+	if value, ok := values[configDimensions]; ok {
+		dimensions, err := strconv.Atoi(value)
+		if err != nil || dimensions < 0 {
+			return Config{}, fmt.Errorf("invalid %s: %q", configDimensions, value)
+		}
+		cfg.Dimensions = dimensions
+	}
+
+	return cfg.withDefaults(), nil
+}
+
+// NewProvider creates the provider selected by cfg
+func NewProvider(cfg Config) (Provider, error) {
+	cfg = cfg.withDefaults()
+
+	switch cfg.Provider {
+	case ProviderOllama:
+		return NewOllamaProvider(cfg), nil
+	case ProviderOpenAI:
+		return NewOpenAIProvider(cfg), nil
+	case ProviderHash:
+		return NewHashProvider(cfg.Dimensions), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", cfg.Provider)
+	}
+}
+
+// statusError is returned when an embedding API answers with another status than 200
+type statusError struct {
+	statusCode int
+	body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("embedding API error: status %d, body: %s", e.statusCode, e.body)
+}
+
+// postJSON sends body to url and decodes the JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body, out any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call embedding API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &statusError{statusCode: resp.StatusCode, body: string(body)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// checkVectors verifies that a backend returned one vector per text, each of the configured size
+func checkVectors(vectors [][]float32, texts []string, dimensions int) error {
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedding API returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	for _, vector := range vectors {
+		if len(vector) == 0 {
+			return fmt.Errorf("embedding API returned an empty vector")
+		}
+		if dimensions > 0 && len(vector) != dimensions {
+			return fmt.Errorf("embedding API returned %d dimensions, expected %d", len(vector), dimensions)
+		}
+	}
+	return nil
+}
+
+// toFloat32 converts a decoded vector to the precision stored in the database
+func toFloat32(values []float64) []float32 {
+	vector := make([]float32, len(values))
+	for i, v := range values {
+		vector[i] = float32(v)
+	}
+	return vector
+}
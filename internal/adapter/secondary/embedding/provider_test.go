@@ -0,0 +1,230 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+func TestOllamaProviderEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req ollamaEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Model != "all-minilm" || len(req.Input) != 2 || req.Dimensions != 2 {
+			t.Errorf("unexpected request %+v", req)
+		}
+		w.Write([]byte(`{"embeddings": [[0.5, 1], [1, 0.5]]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(Config{URL: server.URL + "/", Model: "all-minilm", Dimensions: 2})
+	vectors, err := provider.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 0.5 || vectors[1][0] != 1 {
+		t.Errorf("unexpected vectors %v", vectors)
+	}
+}
+
+func TestOpenAIProviderEmbed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		response string
+		wantErr  string
+	}{
+		{
+			name:     "results out of order",
+			path:     "",
+			response: `{"data": [{"index": 1, "embedding": [0, 1, 0]}, {"index": 0, "embedding": [1, 0, 0]}]}`,
+		},
+		{
+			name:     "url ending in v1",
+			path:     "/v1",
+			response: `{"data": [{"index": 0, "embedding": [1, 0, 0]}, {"index": 1, "embedding": [0, 1, 0]}]}`,
+		},
+		{
+			name:     "missing result",
+			response: `{"data": [{"index": 0, "embedding": [1, 0, 0]}]}`,
+			wantErr:  "returned 1 vectors for 2 texts",
+		},
+		{
+			name:     "wrong dimensions",
+			response: `{"data": [{"index": 0, "embedding": [1, 0]}, {"index": 1, "embedding": [0, 1]}]}`,
+			wantErr:  "returned 2 dimensions, expected 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/embeddings" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+					t.Errorf("unexpected authorization %q", auth)
+				}
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			provider := NewOpenAIProvider(Config{URL: server.URL + tc.path, Model: "bge-m3", Dimensions: 3, APIKey: "secret"})
+			vectors, err := provider.Embed(context.Background(), []string{"first", "second"})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if vectors[0][0] != 1 || vectors[1][1] != 1 {
+				t.Errorf("vectors not in input order: %v", vectors)
+			}
+		})
+	}
+}
+
+func TestHashProviderEmbed(t *testing.T) {
+	provider := NewHashProvider(64)
+	vectors, err := provider.Embed(context.Background(), []string{
+		"Go channels and goroutines",
+		"go channels and goroutines!",
+		"Baking sourdough bread",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, vector := range vectors {
+		if len(vector) != 64 {
+			t.Fatalf("vector %d has %d dimensions", i, len(vector))
+		}
+		if norm := cosine(vector, vector); math.Abs(norm-1) > 1e-5 {
+			t.Errorf("vector %d is not normalized: %f", i, norm)
+		}
+	}
+	if cosine(vectors[0], vectors[1]) < 0.999 {
+		t.Errorf("texts differing in case and punctuation should embed alike")
+	}
+	if cosine(vectors[0], vectors[2]) >= cosine(vectors[0], vectors[1]) {
+		t.Errorf("unrelated text should be less similar")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	defaults := Config{Provider: ProviderOllama, URL: "http://ollama:11434", Model: "mxbai-embed-large"}
+
+	testCases := []struct {
+		name    string
+		values  map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: defaults,
+		},
+		{
+			name:   "model override",
+			values: map[string]string{"embedding.model": "all-minilm", "embedding.dimensions": "384"},
+			want:   Config{Provider: ProviderOllama, URL: "http://ollama:11434", Model: "all-minilm", Dimensions: 384},
+		},
+		{
+			name:   "other provider drops defaults",
+			values: map[string]string{"embedding.provider": "openai", "embedding.api_key": "secret"},
+			want:   Config{Provider: ProviderOpenAI, URL: "http://localhost:8080", APIKey: "secret"},
+		},
+		{
+			name:   "hash provider",
+			values: map[string]string{"embedding.provider": "hash"},
+			want:   Config{Provider: ProviderHash, Model: "hash-1024", Dimensions: 1024},
+		},
+		{
+			name:    "invalid dimensions",
+			values:  map[string]string{"embedding.dimensions": "many"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := LoadConfig(context.Background(), configValues(tc.values), defaults)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("LoadConfig() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// configValues serves configurations from a map
+type configValues map[string]string
+
+var _ output.ConfigurationRepository = configValues(nil)
+
+func (c configValues) GetByKey(ctx context.Context, key string) (*entity.Configuration, error) {
+	value, ok := c[key]
+	if !ok {
+		return nil, nil
+	}
+	return &entity.Configuration{Key: key, Value: value}, nil
+}
+
+func (c configValues) ListConfigurations(ctx context.Context, filter entity.ConfigurationFilter) ([]entity.Configuration, error) {
+	return nil, nil
+}
+
+func (c configValues) GetByPrefix(ctx context.Context, prefix string, includeSecrets bool) ([]entity.Configuration, error) {
+	return nil, nil
+}
+
+func (c configValues) Create(ctx context.Context, config entity.NewConfiguration) (*entity.Configuration, error) {
+	return nil, nil
+}
+
+func (c configValues) Update(ctx context.Context, key string, update entity.ConfigurationUpdate) (*entity.Configuration, error) {
+	return nil, nil
+}
+
+func (c configValues) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+func (c configValues) Upsert(ctx context.Context, key, value string, isSecret bool, updatedAt interface{}) (*entity.Configuration, error) {
+	c[key] = value
+	return &entity.Configuration{Key: key, Value: value, IsSecret: isSecret}, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(normA*normB)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"garden3/internal/domain/entity"
 )
@@ -118,3 +119,38 @@ func (s *Service) GetEmbedding(ctx context.Context, text string) ([]entity.Embed
 
 	return embeddings, nil
 }
+
+// EmbedBatch embeds every text with its own request, keeping the first embedding the service returns for it
+func (s *Service) EmbedBatch(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	start := time.Now()
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings, err := s.GetEmbedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) == 0 {
+			return nil, fmt.Errorf("no embedding generated for text %d", i)
+		}
+		vectors[i] = embeddings[0].Embedding
+
+		if progress != nil {
+			progress(embeddingProgress(i+1, len(texts), time.Since(start)))
+		}
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds a search query with its own request, keeping the first embedding the service returns
+func (s *Service) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := s.EmbedBatch(ctx, []string{text}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// Model returns "", as the embedding service does not name its model
+func (s *Service) Model() string {
+	return ""
+}
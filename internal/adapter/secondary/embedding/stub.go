@@ -44,3 +44,18 @@ func (s *StubEmbeddingsService) GetEmbedding(ctx context.Context, text string) (
 	// In production, this would chunk text and call an external embedding API
 	return nil, fmt.Errorf("embeddings service not implemented")
 }
+
+// EmbedBatch implements output.EmbeddingsService
+func (s *StubEmbeddingsService) EmbedBatch(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	return nil, fmt.Errorf("embeddings service not implemented")
+}
+
+// EmbedQuery implements output.EmbeddingsService
+func (s *StubEmbeddingsService) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return nil, fmt.Errorf("embeddings service not implemented")
+}
+
+// Model implements output.EmbeddingsService
+func (s *StubEmbeddingsService) Model() string {
+	return ""
+}
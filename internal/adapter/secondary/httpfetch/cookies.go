@@ -0,0 +1,80 @@
+package httpfetch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in the files written by curl and most browser extensions
+const httpOnlyPrefix = "#HttpOnly_"
+
+// CookiesTxtParser implements the output.CookieParser interface for Netscape cookies.txt files
+type CookiesTxtParser struct{}
+
+// NewCookiesTxtParser creates a new cookies.txt parser
+func NewCookiesTxtParser() *CookiesTxtParser {
+	return &CookiesTxtParser{}
+}
+
+// Parse reads the tab-separated lines of a cookies.txt file: domain, include subdomains, path, secure,
+// expiry as a Unix timestamp (0 for session cookies), name and value
+func (p *CookiesTxtParser) Parse(r io.Reader) ([]entity.FetchCookie, error) {
+	var cookies []entity.FetchCookie
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// Some exporters drop the value column of cookies without a value
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d has %d fields, expected 7: %w", number, len(fields), entity.ErrInvalidCookieFile)
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d has an invalid expiry %q: %w", number, fields[4], entity.ErrInvalidCookieFile)
+		}
+
+		domain := strings.ToLower(fields[0])
+		cookie := entity.FetchCookie{
+			Domain:   strings.TrimPrefix(domain, "."),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+		}
+		if cookie.Domain == "" || cookie.Name == "" {
+			return nil, fmt.Errorf("line %d has no domain or name: %w", number, entity.ErrInvalidCookieFile)
+		}
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+		if expiry > 0 {
+			expires := time.Unix(expiry, 0).UTC()
+			cookie.Expires = &expires
+		}
+
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookies: %w", err)
+	}
+
+	return cookies, nil
+}
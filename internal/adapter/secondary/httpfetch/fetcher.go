@@ -1,67 +1,179 @@
 package httpfetch
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/net/html/charset"
+	"golang.org/x/net/publicsuffix"
+	"garden3/internal/domain/entity"
 	"garden3/internal/port/output"
 )
 
+// defaultUserAgent is the User-Agent header sent unless a fetch profile sets its own
+const defaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/136.0"
+
+// maxRedirects is the number of redirects followed before a fetch fails
+const maxRedirects = 10
+
+// Config holds the limits and politeness settings of a Fetcher
+type Config struct {
+	// MaxBodySize is the largest response body read, in bytes; larger responses fail with entity.ErrFetchTooLarge
+	MaxBodySize int64
+
+	// HostInterval is the minimum delay between the starts of two requests to the same host
+	HostInterval time.Duration
+
+	// HostConcurrency is the maximum number of requests in flight to the same host
+	HostConcurrency int
+
+	// RespectRobots makes the fetcher refuse URLs disallowed by the site's robots.txt
+	RespectRobots bool
+
+	// RobotsUserAgent is the product token matched against robots.txt user-agent lines
+	RobotsUserAgent string
+
+	// AllowHTTPFallback retries over plain HTTP when an HTTPS request fails with a certificate error
+	AllowHTTPFallback bool
+}
+
+// DefaultConfig returns the settings used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		MaxBodySize:     32 << 20,
+		HostInterval:    time.Second,
+		HostConcurrency: 2,
+		RobotsUserAgent: "garden",
+	}
+}
+
 // Fetcher implements the output.HTTPFetcher interface
 type Fetcher struct {
 	client *http.Client
+	config Config
+	hosts  *hostLimiter
+	robots *robotsCache
+
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+// transportKey identifies a transport by the proxy it sends requests through and whether it only
+// connects to public addresses
+type transportKey struct {
+	proxy      string
+	publicOnly bool
 }
 
 // NewFetcher creates a new HTTP fetcher
-func NewFetcher() *Fetcher {
+func NewFetcher(config Config) *Fetcher {
+	defaults := DefaultConfig()
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaults.MaxBodySize
+	}
+	if config.HostConcurrency < 1 {
+		config.HostConcurrency = defaults.HostConcurrency
+	}
+	if config.RobotsUserAgent == "" {
+		config.RobotsUserAgent = defaults.RobotsUserAgent
+	}
+
+	// Requests are bounded by the timeout passed to each fetch, which fetch profiles may raise, and
+	// each fetch follows redirects with its own checks
 	return &Fetcher{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= 10 {
-					return fmt.Errorf("too many redirects")
-				}
-				return nil
-			},
-		},
+		client:  &http.Client{},
+		config:  config,
+		hosts:   newHostLimiter(config.HostInterval, config.HostConcurrency),
+		robots:     newRobotsCache(config.RobotsUserAgent),
+		transports: make(map[transportKey]*http.Transport),
 	}
 }
 
-func (f *Fetcher) Fetch(ctx context.Context, url string, timeoutMs int) (*output.FetchResponse, error) {
-	timeout := time.Duration(timeoutMs) * time.Millisecond
+func (f *Fetcher) Fetch(ctx context.Context, url string, timeoutMs int, options output.FetchOptions) (*output.FetchResponse, error) {
+	return f.do(ctx, http.MethodGet, url, timeoutMs, options)
+}
 
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+func (f *Fetcher) Head(ctx context.Context, url string, timeoutMs int, options output.FetchOptions) (*output.FetchResponse, error) {
+	return f.do(ctx, http.MethodHead, url, timeoutMs, options)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+func (f *Fetcher) do(ctx context.Context, method, rawURL string, timeoutMs int, options output.FetchOptions) (*output.FetchResponse, error) {
+	target, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/136.0")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	client, err := f.clientFor(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := f.checkRobots(ctx, client, options, target); err != nil {
+		return nil, err
+	}
+
+	host := strings.ToLower(target.Host)
+	release, err := f.hosts.acquire(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer func() { release() }()
+
+	// The timeout starts once the host has a free slot, so time spent queued behind other requests to
+	// the same host does not count against it
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+
+	// Redirects are held to robots.txt and the host limits like the URL they come from
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("too many redirects")
+		}
+		if err := f.checkRobots(req.Context(), client, options, req.URL); err != nil {
+			return err
+		}
+
+		next := strings.ToLower(req.URL.Host)
+		if next == host {
+			return nil
+		}
+		release()
+		release = func() {}
+		nextRelease, err := f.hosts.acquire(req.Context(), next)
+		if err != nil {
+			return err
+		}
+		host, release = next, nextRelease
+		return nil
+	}
+
+	req, err := newRequest(ctx, method, rawURL, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-	resp, err := f.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		// Try HTTP if HTTPS fails with certificate issues
-		if strings.HasPrefix(url, "https://") && isCertificateError(err) {
-			httpURL := strings.Replace(url, "https://", "http://", 1)
-			req, err = http.NewRequestWithContext(ctx, "GET", httpURL, nil)
+		// Only fall back to HTTP when asked to, as it silently drops transport security
+		if f.config.AllowHTTPFallback && strings.HasPrefix(rawURL, "https://") && isCertificateError(err) {
+			httpURL := strings.Replace(rawURL, "https://", "http://", 1)
+			req, err = newRequest(ctx, method, httpURL, options)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create fallback request: %w", err)
 			}
 
-			req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/136.0")
-			req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
-			req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-
-			resp, err = f.client.Do(req)
+			resp, err = client.Do(req)
 			if err != nil {
 				return nil, fmt.Errorf("failed to fetch (http fallback): %w", err)
 			}
@@ -71,16 +183,27 @@ func (f *Fetcher) Fetch(ctx context.Context, url string, timeoutMs int) (*output
 	}
 	defer resp.Body.Close()
 
-	content, err := io.ReadAll(resp.Body)
+	if resp.ContentLength > f.config.MaxBodySize {
+		return nil, fmt.Errorf("%d bytes announced, limit is %d: %w", resp.ContentLength, f.config.MaxBodySize, entity.ErrFetchTooLarge)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, f.config.MaxBodySize+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if int64(len(content)) > f.config.MaxBodySize {
+		return nil, fmt.Errorf("limit is %d bytes: %w", f.config.MaxBodySize, entity.ErrFetchTooLarge)
+	}
+
+	received, receivedContentType := content, resp.Header.Get("Content-Type")
+	content, sourceCharset, err := toUTF8(resp.Header, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
 
 	headers := make(map[string]string)
 	for key, values := range resp.Header {
-		if len(values) > 0 {
-			headers[key] = values[0]
-		}
+		headers[key] = joinHeader(key, values)
 	}
 
 	headersJSON, err := json.Marshal(headers)
@@ -88,11 +211,213 @@ func (f *Fetcher) Fetch(ctx context.Context, url string, timeoutMs int) (*output
 		return nil, fmt.Errorf("failed to marshal headers: %w", err)
 	}
 
-	return &output.FetchResponse{
+	response := &output.FetchResponse{
 		StatusCode: int32(resp.StatusCode),
 		Headers:    string(headersJSON),
 		Content:    content,
-	}, nil
+		URL:        resp.Request.URL.String(),
+		Charset:    sourceCharset,
+	}
+	if sourceCharset != "" {
+		response.Original = received
+		response.OriginalContentType = receivedContentType
+	}
+	return response, nil
+}
+
+// checkRobots returns entity.ErrFetchDisallowed when robots.txt is respected and disallows u. The robots.txt
+// is requested through the transport and cookies of client with the User-Agent of the fetch, following
+// redirects on its own
+func (f *Fetcher) checkRobots(ctx context.Context, client *http.Client, options output.FetchOptions, u *url.URL) error {
+	if !f.config.RespectRobots {
+		return nil
+	}
+
+	robotsClient := &http.Client{Transport: client.Transport, Jar: client.Jar}
+	allowed, err := f.robots.allowed(ctx, robotsClient, userAgent(options), u)
+	if err != nil {
+		return fmt.Errorf("failed to read robots.txt: %w", err)
+	}
+	if !allowed {
+		return fmt.Errorf("%s: %w", u, entity.ErrFetchDisallowed)
+	}
+	return nil
+}
+
+// userAgent returns the User-Agent header a fetch is sent with
+func userAgent(options output.FetchOptions) string {
+	if options.UserAgent != "" {
+		return options.UserAgent
+	}
+	return defaultUserAgent
+}
+
+func newRequest(ctx context.Context, method, url string, options output.FetchOptions) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent(options))
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	if options.ETag != "" {
+		req.Header.Set("If-None-Match", options.ETag)
+	}
+	if options.LastModified != "" {
+		req.Header.Set("If-Modified-Since", options.LastModified)
+	}
+	for name, value := range options.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// clientFor returns a copy of the client to send a request with, adding the cookie jar, proxy and address
+// checks its options ask for
+func (f *Fetcher) clientFor(options output.FetchOptions) (*http.Client, error) {
+	client := *f.client
+	if options.Proxy != "" || options.PublicOnly {
+		transport, err := f.transport(transportKey{proxy: options.Proxy, publicOnly: options.PublicOnly})
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = transport
+	}
+	if len(options.Cookies) > 0 {
+		client.Jar = newCookieJar(options.Cookies)
+	}
+	return &client, nil
+}
+
+// transport returns the transport for a proxy and address policy, creating it on first use so connections
+// are reused
+func (f *Fetcher) transport(key transportKey) (*http.Transport, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if transport, ok := f.transports[key]; ok {
+		return transport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if key.proxy != "" {
+		proxyURL, err := url.Parse(key.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if key.publicOnly {
+		if key.proxy != "" {
+			// The proxy connects on our behalf, so each target, redirects included, is checked before
+			// it is handed over
+			proxy := transport.Proxy
+			transport.Proxy = func(req *http.Request) (*url.URL, error) {
+				if err := checkPublicHost(req.Context(), req.URL.Hostname()); err != nil {
+					return nil, err
+				}
+				return proxy(req)
+			}
+		} else {
+			// Proxies from the environment would be refused by the address check, so connections are direct
+			dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: refuseNonPublic}
+			transport.Proxy = nil
+			transport.DialContext = dialer.DialContext
+		}
+	}
+
+	f.transports[key] = transport
+	return transport, nil
+}
+
+// newCookieJar loads cookies into a jar, which picks the ones each request and redirect should carry
+func newCookieJar(cookies []entity.FetchCookie) http.CookieJar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	for _, cookie := range cookies {
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		owner := &url.URL{Scheme: scheme, Host: cookie.Domain, Path: cookie.Path}
+
+		httpCookie := &http.Cookie{
+			Name:   cookie.Name,
+			Value:  cookie.Value,
+			Path:   cookie.Path,
+			Secure: cookie.Secure,
+		}
+		if !cookie.HostOnly {
+			httpCookie.Domain = cookie.Domain
+		}
+		if cookie.Expires != nil {
+			httpCookie.Expires = *cookie.Expires
+		}
+		jar.SetCookies(owner, []*http.Cookie{httpCookie})
+	}
+	return jar
+}
+
+// joinHeader combines repeated header fields into one value. Set-Cookie values may contain
+// commas themselves, so they are kept on separate lines instead
+func joinHeader(key string, values []string) string {
+	if key == "Set-Cookie" {
+		return strings.Join(values, "\n")
+	}
+	return strings.Join(values, ", ")
+}
+
+// toUTF8 transcodes text responses to UTF-8 using the charset of the Content-Type header, a byte order
+// mark or an HTML meta tag. The header is rewritten to declare UTF-8 so later decoding does not apply
+// the original charset twice. It returns the charset converted from, or "" when nothing changed
+func toUTF8(header http.Header, content []byte) ([]byte, string, error) {
+	if len(content) == 0 {
+		return content, "", nil
+	}
+
+	contentType := header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0])), map[string]string{}
+	}
+	if mediaType == "" {
+		mediaType = strings.Split(http.DetectContentType(content), ";")[0]
+	}
+	if !isText(mediaType) {
+		return content, "", nil
+	}
+
+	encoding, name, _ := charset.DetermineEncoding(content, contentType)
+	if name == "utf-8" {
+		return content, "", nil
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params["charset"] = "utf-8"
+	if formatted := mime.FormatMediaType(mediaType, params); formatted != "" {
+		header.Set("Content-Type", formatted)
+	} else {
+		header.Set("Content-Type", mediaType+"; charset=utf-8")
+	}
+	return bytes.TrimPrefix(decoded, []byte("\ufeff")), name, nil
+}
+
+// isText reports whether a media type carries text that downstream processing reads as UTF-8
+func isText(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.Contains(mediaType, "html") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "application/xml"
 }
 
 func isCertificateError(err error) bool {
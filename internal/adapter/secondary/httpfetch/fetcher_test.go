@@ -0,0 +1,411 @@
+package httpfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+func newTestFetcher(config Config) *Fetcher {
+	config.HostInterval = 0
+	return NewFetcher(config)
+}
+
+func TestFetchBodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flushing first drops the Content-Length, so the limit has to catch the body while reading
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("a", 2048)))
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher(Config{MaxBodySize: 1024})
+	_, err := fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{})
+	if !errors.Is(err, entity.ErrFetchTooLarge) {
+		t.Fatalf("expected ErrFetchTooLarge, got %v", err)
+	}
+
+	fetcher = newTestFetcher(Config{MaxBodySize: 2048})
+	response, err := fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(response.Content) != 2048 {
+		t.Errorf("expected 2048 bytes, got %d", len(response.Content))
+	}
+}
+
+func TestFetchTranscodesToUTF8(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Cookie")
+		w.Write([]byte("<p>caf\xe9</p>"))
+	}))
+	defer server.Close()
+
+	response, err := newTestFetcher(Config{}).Fetch(context.Background(), server.URL, 5000, output.FetchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(response.Content) != "<p>café</p>" {
+		t.Errorf("unexpected content %q", response.Content)
+	}
+	if response.Charset != "windows-1252" {
+		t.Errorf("expected charset windows-1252, got %q", response.Charset)
+	}
+	if string(response.Original) != "<p>caf\xe9</p>" || response.OriginalContentType != "text/html; charset=ISO-8859-1" {
+		t.Errorf("expected the body as received, got %q with content type %q", response.Original, response.OriginalContentType)
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(response.Headers), &headers); err != nil {
+		t.Fatalf("failed to parse headers: %v", err)
+	}
+	if headers["Content-Type"] != "text/html; charset=utf-8" {
+		t.Errorf("expected rewritten content type, got %q", headers["Content-Type"])
+	}
+	if headers["Vary"] != "Accept, Cookie" {
+		t.Errorf("expected joined header values, got %q", headers["Vary"])
+	}
+}
+
+func TestFetchConditional(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher(Config{})
+	response, err := fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", response.StatusCode)
+	}
+
+	response, err = fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{ETag: `"v1"`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != http.StatusNotModified || len(response.Content) != 0 {
+		t.Errorf("expected empty 304, got %d with %d bytes", response.StatusCode, len(response.Content))
+	}
+}
+
+func TestFetchRespectsRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher(Config{RespectRobots: true})
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/private/page", 5000, output.FetchOptions{}); !errors.Is(err, entity.ErrFetchDisallowed) {
+		t.Errorf("expected ErrFetchDisallowed, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/public", 5000, output.FetchOptions{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	fetcher = newTestFetcher(Config{})
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/private/page", 5000, output.FetchOptions{}); err != nil {
+		t.Errorf("robots.txt should be ignored unless enabled, got %v", err)
+	}
+}
+
+func TestFetchRedirectRespectsRobots(t *testing.T) {
+	var robotsAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsAgent = r.Header.Get("User-Agent")
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/moved":
+			http.Redirect(w, r, "/private/page", http.StatusFound)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher(Config{RespectRobots: true})
+	_, err := fetcher.Fetch(context.Background(), server.URL+"/moved", 5000, output.FetchOptions{UserAgent: "garden-test"})
+	if !errors.Is(err, entity.ErrFetchDisallowed) {
+		t.Errorf("expected ErrFetchDisallowed for a redirect into a disallowed path, got %v", err)
+	}
+	if robotsAgent != "garden-test" {
+		t.Errorf("expected robots.txt to be requested with the profile user agent, got %q", robotsAgent)
+	}
+}
+
+func TestFetchTimeoutStartsAfterQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Each request takes 150ms, so the second one queued behind the first would time out at 200ms
+	// if the wait counted
+	fetcher := newTestFetcher(Config{HostConcurrency: 1})
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = fetcher.Fetch(context.Background(), server.URL, 200, output.FetchOptions{})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("fetch %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestHostLimiterEvictsIdleHosts(t *testing.T) {
+	limiter := newHostLimiter(20*time.Millisecond, 1)
+
+	release, err := limiter.acquire(context.Background(), "Example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	// The slot is kept while it still spaces out requests
+	limiter.mu.Lock()
+	kept := len(limiter.hosts)
+	limiter.mu.Unlock()
+	if kept != 1 {
+		t.Errorf("expected the slot to be kept until the interval passes, got %d slots", kept)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	limiter.mu.Lock()
+	left := len(limiter.hosts)
+	limiter.mu.Unlock()
+	if left != 0 {
+		t.Errorf("expected the idle slot to be evicted, got %d slots", left)
+	}
+
+	// A request giving up while the slot is taken does not keep it around either
+	release, err = limiter.acquire(context.Background(), "example.org")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limiter.acquire(ctx, "example.org"); err == nil {
+		t.Fatal("expected a cancelled acquire of a taken slot to fail")
+	}
+	release()
+
+	time.Sleep(50 * time.Millisecond)
+	limiter.mu.Lock()
+	left = len(limiter.hosts)
+	limiter.mu.Unlock()
+	if left != 0 {
+		t.Errorf("expected the slot to be evicted after a cancelled acquire, got %d slots", left)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:4700::6810:84e5", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if got := isPublic(netip.MustParseAddr(tt.address)); got != tt.expected {
+				t.Errorf("isPublic(%s) = %v, expected %v", tt.address, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFetchPublicOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// A proxy that would relay anything, to check the target is refused before it is handed over
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+		w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+
+	fetcher := newTestFetcher(Config{})
+	if _, err := fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{PublicOnly: true}); !errors.Is(err, errNonPublicAddress) {
+		t.Errorf("expected a loopback address to be refused, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{PublicOnly: true, Proxy: proxy.URL}); !errors.Is(err, errNonPublicAddress) {
+		t.Errorf("expected a loopback address to be refused through a proxy, got %v", err)
+	}
+	if proxied {
+		t.Error("expected the refused request not to reach the proxy")
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL, 5000, output.FetchOptions{}); err != nil {
+		t.Errorf("expected user-given URLs to reach any address, got %v", err)
+	}
+}
+
+func TestFetchNoImplicitHTTPFallback(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// The test server's certificate is not trusted by the default client
+	_, err := newTestFetcher(Config{}).Fetch(context.Background(), server.URL, 5000, output.FetchOptions{})
+	if err == nil || !isCertificateError(err) {
+		t.Errorf("expected a certificate error, got %v", err)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `# example
+User-agent: *
+Disallow: /
+
+User-agent: Garden
+User-agent: other
+Disallow: /tmp/
+Allow: /tmp/public
+Disallow: /*.pdf$
+`
+
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		expected  bool
+	}{
+		{"wildcard group", "somebot", "/page", false},
+		{"named group allows", "garden", "/page", true},
+		{"named group disallows", "garden", "/tmp/file", false},
+		{"longer allow wins", "garden", "/tmp/public/file", true},
+		{"anchored pattern", "garden", "/docs/file.pdf", false},
+		{"anchored pattern end", "garden", "/docs/file.pdf?x=1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots([]byte(robots), tt.userAgent)
+			if got := rules.allowed(tt.path); got != tt.expected {
+				t.Errorf("allowed(%q) = %v, expected %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+
+	// A named group with an empty disallow allows everything rather than falling back to *
+	rules := parseRobots([]byte("User-agent: *\nDisallow: /\n\nUser-agent: garden\nDisallow:\n"), "garden")
+	if !rules.allowed("/page") {
+		t.Error("expected the empty named group to allow everything")
+	}
+}
+
+func TestFetchAppliesProfileOptions(t *testing.T) {
+	var userAgent, header, cookie string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		header = r.Header.Get("X-Test")
+		cookie = r.Header.Get("Cookie")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	options := output.FetchOptions{
+		UserAgent: "garden-test",
+		Headers:   map[string]string{"X-Test": "yes"},
+		Cookies: []entity.FetchCookie{
+			{Domain: "127.0.0.1", HostOnly: true, Path: "/", Name: "session", Value: "abc"},
+			{Domain: "example.com", Path: "/", Name: "other", Value: "nope"},
+		},
+	}
+	if _, err := newTestFetcher(Config{}).Fetch(context.Background(), server.URL+"/page", 5000, options); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if userAgent != "garden-test" {
+		t.Errorf("expected the profile user agent, got %q", userAgent)
+	}
+	if header != "yes" {
+		t.Errorf("expected the extra header, got %q", header)
+	}
+	if cookie != "session=abc" {
+		t.Errorf("expected only the matching cookie, got %q", cookie)
+	}
+}
+
+func TestCookiesTxtParser(t *testing.T) {
+	file := "# Netscape HTTP Cookie File\n" +
+		".medium.com\tTRUE\t/\tTRUE\t1900000000\tsid\tabc\n" +
+		"#HttpOnly_lwn.net\tFALSE\t/\tFALSE\t0\tLWN\txyz\n" +
+		"\n" +
+		"example.com\tFALSE\t/\tFALSE\t0\tempty\n"
+
+	cookies, err := NewCookiesTxtParser().Parse(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("expected 3 cookies, got %d", len(cookies))
+	}
+
+	if c := cookies[0]; c.Domain != "medium.com" || c.HostOnly || !c.Secure || c.Expires == nil || c.Name != "sid" || c.Value != "abc" {
+		t.Errorf("unexpected first cookie %+v", c)
+	}
+	if c := cookies[1]; c.Domain != "lwn.net" || !c.HostOnly || c.Expires != nil || c.Name != "LWN" {
+		t.Errorf("unexpected HttpOnly cookie %+v", c)
+	}
+	if c := cookies[2]; c.Name != "empty" || c.Value != "" {
+		t.Errorf("unexpected valueless cookie %+v", c)
+	}
+
+	if _, err := NewCookiesTxtParser().Parse(strings.NewReader("not a cookie file\n")); !errors.Is(err, entity.ErrInvalidCookieFile) {
+		t.Errorf("expected ErrInvalidCookieFile, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package httpfetch
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter spaces out and caps the concurrent requests made to each host
+type hostLimiter struct {
+	interval    time.Duration
+	concurrency int
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+// hostSlot tracks the requests in flight to one host and when the next one may start. users counts the
+// requests holding or waiting for the slot, which is dropped once it reaches zero and next has passed
+type hostSlot struct {
+	inFlight chan struct{}
+	next     time.Time
+	users    int
+}
+
+func newHostLimiter(interval time.Duration, concurrency int) *hostLimiter {
+	return &hostLimiter{
+		interval:    interval,
+		concurrency: concurrency,
+		hosts:       make(map[string]*hostSlot),
+	}
+}
+
+// acquire waits for a free request slot on host and for its turn after the previous request started.
+// The returned function releases the slot and must be called once the response has been read
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	host = strings.ToLower(host)
+
+	l.mu.Lock()
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = &hostSlot{inFlight: make(chan struct{}, l.concurrency)}
+		l.hosts[host] = slot
+	}
+	slot.users++
+	l.mu.Unlock()
+
+	select {
+	case slot.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		l.leave(host, slot)
+		return nil, ctx.Err()
+	}
+	release := func() {
+		<-slot.inFlight
+		l.leave(host, slot)
+	}
+
+	// Reserve the next start time up front so concurrent callers queue behind each other
+	l.mu.Lock()
+	now := time.Now()
+	start := slot.next
+	if start.Before(now) {
+		start = now
+	}
+	slot.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// leave drops a user of the slot of host, evicting the slot once it is idle and its spacing has passed.
+// A slot still spacing out requests is evicted when the spacing ends, unless it is in use again by then
+func (l *hostLimiter) leave(host string, slot *hostSlot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot.users--
+	if slot.users > 0 {
+		return
+	}
+	if wait := time.Until(slot.next); wait > 0 {
+		time.AfterFunc(wait, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if slot.users == 0 && l.hosts[host] == slot {
+				delete(l.hosts, host)
+			}
+		})
+		return
+	}
+	if l.hosts[host] == slot {
+		delete(l.hosts, host)
+	}
+}
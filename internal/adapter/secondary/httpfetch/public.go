@@ -0,0 +1,76 @@
+package httpfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+var errNonPublicAddress = errors.New("address is not public")
+
+// nonPublicPrefixes are the ranges not covered by the netip.Addr checks in isPublic that are still not
+// reachable on the public internet
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// isPublic reports whether an address is reachable on the public internet
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseNonPublic is a net.Dialer Control hook that refuses connections to non-public addresses. It runs
+// on the resolved address, so a host name cannot point around it
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addr) {
+		return fmt.Errorf("%s: %w", address, errNonPublicAddress)
+	}
+	return nil
+}
+
+// checkPublicHost resolves host and fails unless all its addresses are public. It guards requests sent
+// through a proxy, which connects on our behalf
+func checkPublicHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublic(addr) {
+			return fmt.Errorf("%s: %w", host, errNonPublicAddress)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr) {
+			return fmt.Errorf("%s resolves to %s: %w", host, addr, errNonPublicAddress)
+		}
+	}
+	return nil
+}
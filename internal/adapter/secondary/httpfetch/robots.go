@@ -0,0 +1,225 @@
+package httpfetch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// robotsTTL is how long a robots.txt stays cached
+	robotsTTL = 24 * time.Hour
+
+	// robotsRetryTTL is how long an unreachable robots.txt blocks the site before it is requested again
+	robotsRetryTTL = time.Hour
+
+	// robotsTimeout bounds the request for a robots.txt
+	robotsTimeout = 15 * time.Second
+
+	// maxRobotsSize is the part of a robots.txt that is parsed, as RFC 9309 allows crawlers to stop at 500 KiB
+	maxRobotsSize = 500 << 10
+)
+
+// robotsCache fetches and caches the robots.txt rules of each site
+type robotsCache struct {
+	userAgent string
+
+	mu    sync.Mutex
+	sites map[string]*robotsEntry
+}
+
+// robotsEntry holds the rules of one site; ready is closed once they have been loaded
+type robotsEntry struct {
+	ready   chan struct{}
+	rules   robotsRules
+	expires time.Time
+}
+
+func newRobotsCache(userAgent string) *robotsCache {
+	return &robotsCache{
+		userAgent: strings.ToLower(userAgent),
+		sites:     make(map[string]*robotsEntry),
+	}
+}
+
+// allowed reports whether the site of u lets our user agent fetch u. A robots.txt not cached yet is
+// requested through client with the given User-Agent header, so it takes the same route as the fetch
+func (c *robotsCache) allowed(ctx context.Context, client *http.Client, userAgent string, u *url.URL) (bool, error) {
+	site := u.Scheme + "://" + strings.ToLower(u.Host)
+
+	c.mu.Lock()
+	entry, ok := c.sites[site]
+	if !ok || (isClosed(entry.ready) && time.Now().After(entry.expires)) {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		c.sites[site] = entry
+		c.mu.Unlock()
+
+		entry.rules, entry.expires = c.load(ctx, client, userAgent, site)
+		close(entry.ready)
+	} else {
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.rules.allowed(path), nil
+}
+
+// load requests a robots.txt, treating a missing file as allowing everything and an unreachable one as
+// disallowing everything, as RFC 9309 asks
+func (c *robotsCache) load(ctx context.Context, client *http.Client, userAgent, site string) (robotsRules, time.Time) {
+	// Other requests may be waiting for the rules, so cancelling the first caller must not cut the load short
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), robotsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return disallowAll, time.Now().Add(robotsRetryTTL)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return disallowAll, time.Now().Add(robotsRetryTTL)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll, time.Now().Add(robotsRetryTTL)
+	case resp.StatusCode >= 400:
+		return robotsRules{}, time.Now().Add(robotsTTL)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return disallowAll, time.Now().Add(robotsRetryTTL)
+	}
+	return parseRobots(content, c.userAgent), time.Now().Add(robotsTTL)
+}
+
+// robotsRule is one allow or disallow line of the group matching our user agent
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsRules are the rules that apply to our user agent; no rules allow everything
+type robotsRules []robotsRule
+
+var disallowAll = robotsRules{{allow: false, length: 1, pattern: regexp.MustCompile("^/")}}
+
+// allowed applies the longest matching rule, preferring allow when an allow and a disallow rule are equally long
+func (r robotsRules) allowed(path string) bool {
+	best := -1
+	allow := true
+	for _, rule := range r {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best = rule.length
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots returns the rules of the groups naming userAgent, or of the * groups when none does
+func parseRobots(content []byte, userAgent string) robotsRules {
+	var specific, wildcard robotsRules
+	var matchesAgent, matchesWildcard, inAgents, named bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the rules that follow them
+			if !inAgents {
+				matchesAgent, matchesWildcard = false, false
+				inAgents = true
+			}
+			token := strings.ToLower(value)
+			if token == "*" {
+				matchesWildcard = true
+			} else if token != "" && strings.Contains(userAgent, token) {
+				matchesAgent = true
+				named = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: robotsPattern(value),
+			}
+			if matchesAgent {
+				specific = append(specific, rule)
+			}
+			if matchesWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if named {
+		return specific
+	}
+	return wildcard
+}
+
+// robotsPattern compiles a path pattern, where * matches any characters and a trailing $ anchors the end
+func robotsPattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = strings.TrimSuffix(value, "$")
+
+	expression := "^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, ".*")
+	if anchored {
+		expression += "$"
+	}
+	return regexp.MustCompile(expression)
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
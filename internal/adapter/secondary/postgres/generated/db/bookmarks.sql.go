@@ -13,16 +13,112 @@ import (
 	"github.com/pgvector/pgvector-go"
 )
 
+const countAnnotations = `-- name: CountAnnotations :one
+SELECT COUNT(*)
+FROM bookmark_annotations a
+WHERE ($1::text IS NULL
+       OR a.quote_exact ILIKE '%' || $1 || '%'
+       OR a.note ILIKE '%' || $1 || '%')
+  AND ($2::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id AND t.name = $2
+      ))
+`
+
+type CountAnnotationsParams struct {
+	Search *string `json:"search"`
+	Tag    *string `json:"tag"`
+}
+
+func (q *Queries) CountAnnotations(ctx context.Context, arg CountAnnotationsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAnnotations, arg.Search, arg.Tag)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countArchiveBlobReferences = `-- name: CountArchiveBlobReferences :one
+SELECT COUNT(*)
+FROM bookmark_archives
+WHERE html_hash = $1 OR warc_hash = $1
+`
+
+func (q *Queries) CountArchiveBlobReferences(ctx context.Context, htmlHash string) (int64, error) {
+	row := q.db.QueryRow(ctx, countArchiveBlobReferences, htmlHash)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countBookmarkEvaluations = `-- name: CountBookmarkEvaluations :one
+SELECT
+    COUNT(*) AS evaluations,
+    COUNT(*) FILTER (WHERE failed_fetch) AS failed_fetches
+FROM bookmark_evaluations
+WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+  AND ($2::timestamptz IS NULL OR created_at < $2)
+`
+
+type CountBookmarkEvaluationsParams struct {
+	Since pgtype.Timestamptz `json:"since"`
+	Until pgtype.Timestamptz `json:"until"`
+}
+
+type CountBookmarkEvaluationsRow struct {
+	Evaluations   int64 `json:"evaluations"`
+	FailedFetches int64 `json:"failed_fetches"`
+}
+
+func (q *Queries) CountBookmarkEvaluations(ctx context.Context, arg CountBookmarkEvaluationsParams) (CountBookmarkEvaluationsRow, error) {
+	row := q.db.QueryRow(ctx, countBookmarkEvaluations, arg.Since, arg.Until)
+	var i CountBookmarkEvaluationsRow
+	err := row.Scan(&i.Evaluations, &i.FailedFetches)
+	return i, err
+}
+
 const countBookmarks = `-- name: CountBookmarks :one
+WITH RECURSIVE subtree AS (
+    SELECT category_id FROM categories WHERE category_id = $1::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
 SELECT COUNT(DISTINCT b.bookmark_id)
 FROM bookmarks b
 LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN bookmark_metadata bm ON b.bookmark_id = bm.bookmark_id
 WHERE
-    ($1::uuid IS NULL OR bc.category_id = $1)
+    ($1::uuid IS NULL OR bc.category_id IN (SELECT category_id FROM subtree))
     AND ($2::text IS NULL OR bt.title ILIKE '%' || $2 || '%')
     AND ($3::timestamp IS NULL OR b.creation_date >= $3)
     AND ($4::timestamp IS NULL OR b.creation_date <= $4)
+    AND ($6::text = '' OR bm.author ILIKE '%' || $6 || '%')
+    AND ($7::text = '' OR lower(bm.site_name) = lower($7)
+        OR lower(substring(b.url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)')) = lower($7))
+    AND ($8::int = 0 OR EXTRACT(YEAR FROM bm.published_at) = $8)
+    AND ($5::text = ''
+        OR (
+            SELECT r.status
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = $5
+            ORDER BY r.started_at DESC
+            LIMIT 1
+        ) = 'failed'
+        OR ($5 = 'fetch' AND NOT EXISTS (
+            SELECT 1
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = 'fetch'
+        ) AND (
+            SELECT hr.status_code
+            FROM http_responses hr
+            WHERE hr.bookmark_id = b.bookmark_id
+            ORDER BY hr.fetch_date DESC NULLS LAST
+            LIMIT 1
+        ) >= 400))
 `
 
 type CountBookmarksParams struct {
@@ -30,31 +126,123 @@ type CountBookmarksParams struct {
 	Column2 string           `json:"column_2"`
 	Column3 pgtype.Timestamp `json:"column_3"`
 	Column4 pgtype.Timestamp `json:"column_4"`
+	Column5 string           `json:"column_5"`
+	Column6 string           `json:"column_6"`
+	Column7 string           `json:"column_7"`
+	Column8 int32            `json:"column_8"`
 }
 
+// The category filter includes the descendants of the category
+// The failed-stage filter applies the rule of GetBookmarkStatus: the latest run of the stage decides, and a
+// bookmark fetched before runs were recorded failed when its latest response has an error status
 func (q *Queries) CountBookmarks(ctx context.Context, arg CountBookmarksParams) (int64, error) {
 	row := q.db.QueryRow(ctx, countBookmarks,
 		arg.Column1,
 		arg.Column2,
 		arg.Column3,
 		arg.Column4,
+		arg.Column5,
+		arg.Column6,
+		arg.Column7,
+		arg.Column8,
 	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const countLinkIssues = `-- name: CountLinkIssues :one
+SELECT COUNT(*)
+FROM (
+    SELECT DISTINCT ON (c.bookmark_id) c.status
+    FROM bookmark_link_checks c
+    ORDER BY c.bookmark_id, c.checked_at DESC
+) latest
+WHERE latest.status <> 'ok'
+  AND ($1::text IS NULL OR latest.status = $1)
+`
+
+func (q *Queries) CountLinkIssues(ctx context.Context, status *string) (int64, error) {
+	row := q.db.QueryRow(ctx, countLinkIssues, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createBookmark = `-- name: CreateBookmark :one
+INSERT INTO bookmarks (url, creation_date)
+VALUES ($1, $2)
+ON CONFLICT (url) DO NOTHING
+RETURNING bookmark_id, url, creation_date
+`
+
+type CreateBookmarkParams struct {
+	Url          string           `json:"url"`
+	CreationDate pgtype.Timestamp `json:"creation_date"`
+}
+
+// Returns no row when another bookmark already has the URL
+func (q *Queries) CreateBookmark(ctx context.Context, arg CreateBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRow(ctx, createBookmark, arg.Url, arg.CreationDate)
+	var i Bookmark
+	err := row.Scan(&i.BookmarkID, &i.Url, &i.CreationDate)
+	return i, err
+}
+
+const createBookmarkImport = `-- name: CreateBookmarkImport :one
+INSERT INTO bookmark_imports (format, source, status)
+VALUES ($1, $2, $3)
+RETURNING import_id, format, source, status, processed, created, duplicates, failed, report, error, started_at, updated_at, finished_at
+`
+
+type CreateBookmarkImportParams struct {
+	Format string  `json:"format"`
+	Source *string `json:"source"`
+	Status string  `json:"status"`
+}
+
+func (q *Queries) CreateBookmarkImport(ctx context.Context, arg CreateBookmarkImportParams) (BookmarkImport, error) {
+	row := q.db.QueryRow(ctx, createBookmarkImport, arg.Format, arg.Source, arg.Status)
+	var i BookmarkImport
+	err := row.Scan(
+		&i.ImportID,
+		&i.Format,
+		&i.Source,
+		&i.Status,
+		&i.Processed,
+		&i.Created,
+		&i.Duplicates,
+		&i.Failed,
+		&i.Report,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const createBookmarkURLIndex = `-- name: CreateBookmarkURLIndex :exec
+CREATE UNIQUE INDEX IF NOT EXISTS bookmarks_url_idx ON bookmarks (url)
+`
+
+func (q *Queries) CreateBookmarkURLIndex(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, createBookmarkURLIndex)
+	return err
+}
+
 const createEmbeddingChunk = `-- name: CreateEmbeddingChunk :one
-INSERT INTO bookmark_content_references (bookmark_id, content, strategy, embedding)
-VALUES ($1, $2, $3, $4::vector)
+INSERT INTO bookmark_content_references (bookmark_id, content, strategy, embedding, embedding_model)
+VALUES ($1, $2, $3, $4::vector, $5)
 RETURNING id
 `
 
 type CreateEmbeddingChunkParams struct {
-	BookmarkID pgtype.UUID      `json:"bookmark_id"`
-	Content    *string          `json:"content"`
-	Strategy   *string          `json:"strategy"`
-	Column4    *pgvector.Vector `json:"column_4"`
+	BookmarkID     pgtype.UUID      `json:"bookmark_id"`
+	Content        *string          `json:"content"`
+	Strategy       *string          `json:"strategy"`
+	Column4        *pgvector.Vector `json:"column_4"`
+	EmbeddingModel *string          `json:"embedding_model"`
 }
 
 func (q *Queries) CreateEmbeddingChunk(ctx context.Context, arg CreateEmbeddingChunkParams) (uuid.UUID, error) {
@@ -63,12 +251,71 @@ func (q *Queries) CreateEmbeddingChunk(ctx context.Context, arg CreateEmbeddingC
 		arg.Content,
 		arg.Strategy,
 		arg.Column4,
+		arg.EmbeddingModel,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createEmbeddingChunkWithExtra = `-- name: CreateEmbeddingChunkWithExtra :one
+INSERT INTO bookmark_content_references (bookmark_id, content, strategy, embedding, extra, embedding_model)
+VALUES ($1, $2, $3, $4::vector, $5, $6)
+RETURNING id
+`
+
+type CreateEmbeddingChunkWithExtraParams struct {
+	BookmarkID     pgtype.UUID      `json:"bookmark_id"`
+	Content        *string          `json:"content"`
+	Strategy       *string          `json:"strategy"`
+	Column4        *pgvector.Vector `json:"column_4"`
+	Extra          []byte           `json:"extra"`
+	EmbeddingModel *string          `json:"embedding_model"`
+}
+
+func (q *Queries) CreateEmbeddingChunkWithExtra(ctx context.Context, arg CreateEmbeddingChunkWithExtraParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createEmbeddingChunkWithExtra,
+		arg.BookmarkID,
+		arg.Content,
+		arg.Strategy,
+		arg.Column4,
+		arg.Extra,
+		arg.EmbeddingModel,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
+const createLinkedObservation = `-- name: CreateLinkedObservation :one
+INSERT INTO observations (data, type, source, tags, ref, parent)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING observation_id
+`
+
+type CreateLinkedObservationParams struct {
+	Data   []byte      `json:"data"`
+	Type   *string     `json:"type"`
+	Source *string     `json:"source"`
+	Tags   *string     `json:"tags"`
+	Ref    pgtype.UUID `json:"ref"`
+	Parent pgtype.UUID `json:"parent"`
+}
+
+func (q *Queries) CreateLinkedObservation(ctx context.Context, arg CreateLinkedObservationParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createLinkedObservation,
+		arg.Data,
+		arg.Type,
+		arg.Source,
+		arg.Tags,
+		arg.Ref,
+		arg.Parent,
+	)
+	var observation_id uuid.UUID
+	err := row.Scan(&observation_id)
+	return observation_id, err
+}
+
 const createObservation = `-- name: CreateObservation :exec
 INSERT INTO observations (data, type, source, tags, ref)
 VALUES ($1, $2, $3, $4, $5)
@@ -93,151 +340,119 @@ func (q *Queries) CreateObservation(ctx context.Context, arg CreateObservationPa
 	return err
 }
 
-const deleteBookmarkQuestion = `-- name: DeleteBookmarkQuestion :exec
-DELETE FROM bookmark_content_references
-WHERE id = $1 AND bookmark_id = $2
+const deleteAnnotationEntityReferences = `-- name: DeleteAnnotationEntityReferences :exec
+DELETE FROM entity_references
+WHERE source_type = 'annotation' AND source_id = $1
 `
 
-type DeleteBookmarkQuestionParams struct {
-	ID         uuid.UUID   `json:"id"`
-	BookmarkID pgtype.UUID `json:"bookmark_id"`
-}
-
-func (q *Queries) DeleteBookmarkQuestion(ctx context.Context, arg DeleteBookmarkQuestionParams) error {
-	_, err := q.db.Exec(ctx, deleteBookmarkQuestion, arg.ID, arg.BookmarkID)
+func (q *Queries) DeleteAnnotationEntityReferences(ctx context.Context, sourceID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteAnnotationEntityReferences, sourceID)
 	return err
 }
 
-const getBookmark = `-- name: GetBookmark :one
-SELECT
-    bookmark_id,
-    url,
-    creation_date
-FROM bookmarks
+const deleteBookmark = `-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
 WHERE bookmark_id = $1
 `
 
-func (q *Queries) GetBookmark(ctx context.Context, bookmarkID uuid.UUID) (Bookmark, error) {
-	row := q.db.QueryRow(ctx, getBookmark, bookmarkID)
-	var i Bookmark
-	err := row.Scan(&i.BookmarkID, &i.Url, &i.CreationDate)
-	return i, err
+func (q *Queries) DeleteBookmark(ctx context.Context, bookmarkID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBookmark, bookmarkID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const getBookmarkDetails = `-- name: GetBookmarkDetails :one
-SELECT
-    b.bookmark_id,
-    b.url,
-    b.creation_date,
-    c.name as category_name,
-    bs.source_uri,
-    bs.raw_source,
-    bt.title,
-    pc_lynx.processed_content as lynx_content,
-    pc_reader.processed_content as reader_content,
-    hr.status_code,
-    hr.headers,
-    hr.content as http_content,
-    hr.fetch_date,
-    bcr_summary.content as summary
-FROM bookmarks b
-LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
-LEFT JOIN categories c ON bc.category_id = c.category_id
-LEFT JOIN bookmark_sources bs ON b.bookmark_id = bs.bookmark_id
-LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
-LEFT JOIN processed_contents pc_lynx ON b.bookmark_id = pc_lynx.bookmark_id AND pc_lynx.strategy_used = 'lynx'
-LEFT JOIN processed_contents pc_reader ON b.bookmark_id = pc_reader.bookmark_id AND pc_reader.strategy_used = 'reader'
-LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
-LEFT JOIN bookmark_content_references bcr_summary ON b.bookmark_id = bcr_summary.bookmark_id AND bcr_summary.strategy = 'summary-reader'
-WHERE b.bookmark_id = $1
+const deleteBookmarkAnnotation = `-- name: DeleteBookmarkAnnotation :execrows
+DELETE FROM bookmark_annotations
+WHERE annotation_id = $1
 `
 
-type GetBookmarkDetailsRow struct {
-	BookmarkID    uuid.UUID        `json:"bookmark_id"`
-	Url           string           `json:"url"`
-	CreationDate  pgtype.Timestamp `json:"creation_date"`
-	CategoryName  *string          `json:"category_name"`
-	SourceUri     *string          `json:"source_uri"`
-	RawSource     []byte           `json:"raw_source"`
-	Title         *string          `json:"title"`
-	LynxContent   *string          `json:"lynx_content"`
-	ReaderContent *string          `json:"reader_content"`
-	StatusCode    *int32           `json:"status_code"`
-	Headers       *string          `json:"headers"`
-	HttpContent   []byte           `json:"http_content"`
-	FetchDate     pgtype.Timestamp `json:"fetch_date"`
-	Summary       *string          `json:"summary"`
+func (q *Queries) DeleteBookmarkAnnotation(ctx context.Context, annotationID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBookmarkAnnotation, annotationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-func (q *Queries) GetBookmarkDetails(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkDetailsRow, error) {
-	row := q.db.QueryRow(ctx, getBookmarkDetails, bookmarkID)
-	var i GetBookmarkDetailsRow
-	err := row.Scan(
-		&i.BookmarkID,
-		&i.Url,
-		&i.CreationDate,
-		&i.CategoryName,
-		&i.SourceUri,
-		&i.RawSource,
-		&i.Title,
-		&i.LynxContent,
-		&i.ReaderContent,
-		&i.StatusCode,
-		&i.Headers,
-		&i.HttpContent,
-		&i.FetchDate,
-		&i.Summary,
-	)
-	return i, err
+const deleteBookmarkAnnotationTags = `-- name: DeleteBookmarkAnnotationTags :exec
+DELETE FROM bookmark_annotation_tags
+WHERE annotation_id = $1
+`
+
+func (q *Queries) DeleteBookmarkAnnotationTags(ctx context.Context, annotationID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkAnnotationTags, annotationID)
+	return err
 }
 
-const getBookmarkForQuestion = `-- name: GetBookmarkForQuestion :one
-SELECT
-    b.bookmark_id,
-    bt.title,
-    bcr.content as summary
-FROM bookmarks b
-LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
-LEFT JOIN bookmark_content_references bcr ON b.bookmark_id = bcr.bookmark_id AND bcr.strategy = 'summary-reader'
-WHERE b.bookmark_id = $1
+const deleteBookmarkAnnotations = `-- name: DeleteBookmarkAnnotations :exec
+WITH deleted AS (
+    DELETE FROM bookmark_annotations
+    WHERE bookmark_id = $1
+    RETURNING annotation_id
+)
+DELETE FROM entity_references
+WHERE source_type = 'annotation'
+  AND source_id IN (SELECT annotation_id FROM deleted)
 `
 
-type GetBookmarkForQuestionRow struct {
-	BookmarkID uuid.UUID `json:"bookmark_id"`
-	Title      *string   `json:"title"`
-	Summary    *string   `json:"summary"`
+func (q *Queries) DeleteBookmarkAnnotations(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkAnnotations, bookmarkID)
+	return err
 }
 
-func (q *Queries) GetBookmarkForQuestion(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkForQuestionRow, error) {
-	row := q.db.QueryRow(ctx, getBookmarkForQuestion, bookmarkID)
-	var i GetBookmarkForQuestionRow
-	err := row.Scan(&i.BookmarkID, &i.Title, &i.Summary)
-	return i, err
+const deleteBookmarkArchives = `-- name: DeleteBookmarkArchives :exec
+DELETE FROM bookmark_archives
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkArchives, bookmarkID)
+	return err
 }
 
-const getBookmarkQuestions = `-- name: GetBookmarkQuestions :many
-SELECT
-    id,
-    content
-FROM bookmark_content_references
-WHERE bookmark_id = $1 AND strategy = 'question-inference'
+const deleteBookmarkCategories = `-- name: DeleteBookmarkCategories :exec
+DELETE FROM bookmark_category
+WHERE bookmark_id = $1
 `
 
-type GetBookmarkQuestionsRow struct {
-	ID      uuid.UUID `json:"id"`
-	Content *string   `json:"content"`
+func (q *Queries) DeleteBookmarkCategories(ctx context.Context, bookmarkID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkCategories, bookmarkID)
+	return err
 }
 
-func (q *Queries) GetBookmarkQuestions(ctx context.Context, bookmarkID pgtype.UUID) ([]GetBookmarkQuestionsRow, error) {
-	rows, err := q.db.Query(ctx, getBookmarkQuestions, bookmarkID)
+const deleteBookmarkContentReferences = `-- name: DeleteBookmarkContentReferences :exec
+DELETE FROM bookmark_content_references
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkContentReferences(ctx context.Context, bookmarkID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkContentReferences, bookmarkID)
+	return err
+}
+
+const deleteBookmarkHttpResponses = `-- name: DeleteBookmarkHttpResponses :many
+DELETE FROM http_responses
+WHERE bookmark_id = $1
+RETURNING content_hash, original_content_hash
+`
+
+type DeleteBookmarkHttpResponsesRow struct {
+	ContentHash         *string `json:"content_hash"`
+	OriginalContentHash *string `json:"original_content_hash"`
+}
+
+func (q *Queries) DeleteBookmarkHttpResponses(ctx context.Context, bookmarkID pgtype.UUID) ([]DeleteBookmarkHttpResponsesRow, error) {
+	rows, err := q.db.Query(ctx, deleteBookmarkHttpResponses, bookmarkID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []GetBookmarkQuestionsRow{}
+	items := []DeleteBookmarkHttpResponsesRow{}
 	for rows.Next() {
-		var i GetBookmarkQuestionsRow
-		if err := rows.Scan(&i.ID, &i.Content); err != nil {
+		var i DeleteBookmarkHttpResponsesRow
+		if err := rows.Scan(&i.ContentHash, &i.OriginalContentHash); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -248,102 +463,2500 @@ func (q *Queries) GetBookmarkQuestions(ctx context.Context, bookmarkID pgtype.UU
 	return items, nil
 }
 
-const getBookmarkTitle = `-- name: GetBookmarkTitle :one
-SELECT
-    b.bookmark_id,
-    b.url,
-    b.creation_date,
-    bt.title as existing_title,
-    hr.content as raw_content,
-    substring(pc.processed_content FROM '#([^\n]*)')::text as reader_title
-FROM bookmarks b
-LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
-LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
-LEFT JOIN processed_contents pc ON b.bookmark_id = pc.bookmark_id AND pc.strategy_used = 'reader'
-WHERE b.bookmark_id = $1
+const deleteBookmarkLinkChecks = `-- name: DeleteBookmarkLinkChecks :exec
+DELETE FROM bookmark_link_checks
+WHERE bookmark_id = $1
 `
 
-type GetBookmarkTitleRow struct {
-	BookmarkID    uuid.UUID        `json:"bookmark_id"`
-	Url           string           `json:"url"`
-	CreationDate  pgtype.Timestamp `json:"creation_date"`
-	ExistingTitle *string          `json:"existing_title"`
-	RawContent    []byte           `json:"raw_content"`
-	ReaderTitle   string           `json:"reader_title"`
+func (q *Queries) DeleteBookmarkLinkChecks(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkLinkChecks, bookmarkID)
+	return err
 }
 
-func (q *Queries) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkTitleRow, error) {
-	row := q.db.QueryRow(ctx, getBookmarkTitle, bookmarkID)
-	var i GetBookmarkTitleRow
-	err := row.Scan(
-		&i.BookmarkID,
-		&i.Url,
-		&i.CreationDate,
-		&i.ExistingTitle,
-		&i.RawContent,
-		&i.ReaderTitle,
-	)
-	return i, err
+const deleteBookmarkMetadata = `-- name: DeleteBookmarkMetadata :exec
+DELETE FROM bookmark_metadata
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkMetadata, bookmarkID)
+	return err
 }
 
-const getLatestHttpResponse = `-- name: GetLatestHttpResponse :one
+const deleteBookmarkProcessedContents = `-- name: DeleteBookmarkProcessedContents :exec
+DELETE FROM processed_contents
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkProcessedContents(ctx context.Context, bookmarkID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkProcessedContents, bookmarkID)
+	return err
+}
+
+const deleteBookmarkQuestion = `-- name: DeleteBookmarkQuestion :exec
+DELETE FROM bookmark_content_references
+WHERE id = $1 AND bookmark_id = $2
+`
+
+type DeleteBookmarkQuestionParams struct {
+	ID         uuid.UUID   `json:"id"`
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+}
+
+func (q *Queries) DeleteBookmarkQuestion(ctx context.Context, arg DeleteBookmarkQuestionParams) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkQuestion, arg.ID, arg.BookmarkID)
+	return err
+}
+
+const deleteBookmarkSources = `-- name: DeleteBookmarkSources :exec
+DELETE FROM bookmark_sources
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkSources(ctx context.Context, bookmarkID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkSources, bookmarkID)
+	return err
+}
+
+const deleteBookmarkStageRuns = `-- name: DeleteBookmarkStageRuns :exec
+DELETE FROM bookmark_stage_runs
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkStageRuns(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkStageRuns, bookmarkID)
+	return err
+}
+
+const deleteBookmarkTags = `-- name: DeleteBookmarkTags :exec
+DELETE FROM bookmark_tags
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkTags(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkTags, bookmarkID)
+	return err
+}
+
+const deleteBookmarkTitles = `-- name: DeleteBookmarkTitles :exec
+DELETE FROM bookmark_titles
+WHERE bookmark_id = $1
+`
+
+func (q *Queries) DeleteBookmarkTitles(ctx context.Context, bookmarkID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBookmarkTitles, bookmarkID)
+	return err
+}
+
+const deleteContentReferencesByStrategy = `-- name: DeleteContentReferencesByStrategy :exec
+DELETE FROM bookmark_content_references
+WHERE bookmark_id = $1 AND strategy = $2
+`
+
+type DeleteContentReferencesByStrategyParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Strategy   *string     `json:"strategy"`
+}
+
+func (q *Queries) DeleteContentReferencesByStrategy(ctx context.Context, arg DeleteContentReferencesByStrategyParams) error {
+	_, err := q.db.Exec(ctx, deleteContentReferencesByStrategy, arg.BookmarkID, arg.Strategy)
+	return err
+}
+
+const deleteMergedBookmarks = `-- name: DeleteMergedBookmarks :exec
+DELETE FROM bookmarks
+WHERE bookmark_id = ANY($1::uuid[])
+`
+
+func (q *Queries) DeleteMergedBookmarks(ctx context.Context, duplicateIds []uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteMergedBookmarks, duplicateIds)
+	return err
+}
+
+const deleteQuestionReferences = `-- name: DeleteQuestionReferences :many
+DELETE FROM bookmark_content_references
+WHERE bookmark_id = $1
+  AND strategy = $2
+  AND (id = ANY($3::uuid[]) OR content = ANY($4::text[]))
+RETURNING id
+`
+
+type DeleteQuestionReferencesParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Strategy   *string     `json:"strategy"`
+	Ids        []uuid.UUID `json:"ids"`
+	Contents   []string    `json:"contents"`
+}
+
+func (q *Queries) DeleteQuestionReferences(ctx context.Context, arg DeleteQuestionReferencesParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, deleteQuestionReferences,
+		arg.BookmarkID,
+		arg.Strategy,
+		arg.Ids,
+		arg.Contents,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteReplaceableQuestions = `-- name: DeleteReplaceableQuestions :exec
+DELETE FROM bookmark_content_references r
+WHERE r.bookmark_id = $1
+    AND r.strategy = $2
+    AND NOT COALESCE((r.extra->>'edited')::boolean, false)
+    AND NOT EXISTS (
+        SELECT 1 FROM observations o
+        WHERE o.ref = r.bookmark_id
+            AND o.type = 'qa-feedback'
+            AND o.data->>'referenceId' = r.id::text
+    )
+`
+
+type DeleteReplaceableQuestionsParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Strategy   *string     `json:"strategy"`
+}
+
+// Pairs the user edited or left feedback on are kept when the generated pairs are replaced
+func (q *Queries) DeleteReplaceableQuestions(ctx context.Context, arg DeleteReplaceableQuestionsParams) error {
+	_, err := q.db.Exec(ctx, deleteReplaceableQuestions, arg.BookmarkID, arg.Strategy)
+	return err
+}
+
+const deleteUnreferencedHttpResponseBodies = `-- name: DeleteUnreferencedHttpResponseBodies :execrows
+DELETE FROM http_response_bodies hb
+WHERE hb.content_hash = ANY($1::text[])
+  AND NOT EXISTS (
+      SELECT 1 FROM http_responses hr WHERE hr.content_hash = hb.content_hash
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM http_responses hr WHERE hr.original_content_hash = hb.content_hash
+  )
+`
+
+func (q *Queries) DeleteUnreferencedHttpResponseBodies(ctx context.Context, dollar_1 []string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUnreferencedHttpResponseBodies, dollar_1)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const dropMergedBookmarkCategories = `-- name: DropMergedBookmarkCategories :exec
+DELETE FROM bookmark_category c
+WHERE c.bookmark_id = ANY($1::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_category o
+      WHERE o.category_id IS NOT DISTINCT FROM c.category_id
+        AND (o.bookmark_id = $2::uuid
+             OR (o.bookmark_id = ANY($1::uuid[]) AND o.bookmark_id < c.bookmark_id))
+  )
+`
+
+type DropMergedBookmarkCategoriesParams struct {
+	DuplicateIds []uuid.UUID `json:"duplicate_ids"`
+	KeepID       uuid.UUID   `json:"keep_id"`
+}
+
+func (q *Queries) DropMergedBookmarkCategories(ctx context.Context, arg DropMergedBookmarkCategoriesParams) error {
+	_, err := q.db.Exec(ctx, dropMergedBookmarkCategories, arg.DuplicateIds, arg.KeepID)
+	return err
+}
+
+const dropMergedBookmarkMetadata = `-- name: DropMergedBookmarkMetadata :exec
+DELETE FROM bookmark_metadata m
+WHERE m.bookmark_id = ANY($1::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_metadata o
+      WHERE o.bookmark_id = $2::uuid
+         OR (o.bookmark_id = ANY($1::uuid[]) AND o.bookmark_id < m.bookmark_id)
+  )
+`
+
+type DropMergedBookmarkMetadataParams struct {
+	DuplicateIds []uuid.UUID `json:"duplicate_ids"`
+	KeepID       uuid.UUID   `json:"keep_id"`
+}
+
+func (q *Queries) DropMergedBookmarkMetadata(ctx context.Context, arg DropMergedBookmarkMetadataParams) error {
+	_, err := q.db.Exec(ctx, dropMergedBookmarkMetadata, arg.DuplicateIds, arg.KeepID)
+	return err
+}
+
+const dropMergedBookmarkTags = `-- name: DropMergedBookmarkTags :exec
+DELETE FROM bookmark_tags t
+WHERE t.bookmark_id = ANY($1::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_tags o
+      WHERE o.tag_id = t.tag_id
+        AND (o.bookmark_id = $2::uuid
+             OR (o.bookmark_id = ANY($1::uuid[]) AND o.bookmark_id < t.bookmark_id))
+  )
+`
+
+type DropMergedBookmarkTagsParams struct {
+	DuplicateIds []uuid.UUID `json:"duplicate_ids"`
+	KeepID       uuid.UUID   `json:"keep_id"`
+}
+
+// Rows of duplicates that the kept bookmark, or a duplicate before them, already has an equivalent of are
+// dropped before the rows are moved to the kept bookmark, so no unique key is violated
+func (q *Queries) DropMergedBookmarkTags(ctx context.Context, arg DropMergedBookmarkTagsParams) error {
+	_, err := q.db.Exec(ctx, dropMergedBookmarkTags, arg.DuplicateIds, arg.KeepID)
+	return err
+}
+
+const dropMergedBookmarkTitles = `-- name: DropMergedBookmarkTitles :exec
+DELETE FROM bookmark_titles t
+WHERE t.bookmark_id = ANY($1::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_titles o
+      WHERE o.title IS NOT DISTINCT FROM t.title
+        AND o.source IS NOT DISTINCT FROM t.source
+        AND (o.bookmark_id = $2::uuid
+             OR (o.bookmark_id = ANY($1::uuid[]) AND o.bookmark_id < t.bookmark_id))
+  )
+`
+
+type DropMergedBookmarkTitlesParams struct {
+	DuplicateIds []uuid.UUID `json:"duplicate_ids"`
+	KeepID       uuid.UUID   `json:"keep_id"`
+}
+
+func (q *Queries) DropMergedBookmarkTitles(ctx context.Context, arg DropMergedBookmarkTitlesParams) error {
+	_, err := q.db.Exec(ctx, dropMergedBookmarkTitles, arg.DuplicateIds, arg.KeepID)
+	return err
+}
+
+const dropMergedCategorySuggestions = `-- name: DropMergedCategorySuggestions :exec
+DELETE FROM bookmark_category_suggestions s
+WHERE s.bookmark_id = ANY($1::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_category_suggestions o
+      WHERE o.category_id = s.category_id
+        AND (o.bookmark_id = $2::uuid
+             OR (o.bookmark_id = ANY($1::uuid[]) AND o.bookmark_id < s.bookmark_id))
+  )
+`
+
+type DropMergedCategorySuggestionsParams struct {
+	DuplicateIds []uuid.UUID `json:"duplicate_ids"`
+	KeepID       uuid.UUID   `json:"keep_id"`
+}
+
+func (q *Queries) DropMergedCategorySuggestions(ctx context.Context, arg DropMergedCategorySuggestionsParams) error {
+	_, err := q.db.Exec(ctx, dropMergedCategorySuggestions, arg.DuplicateIds, arg.KeepID)
+	return err
+}
+
+const getBookmark = `-- name: GetBookmark :one
 SELECT
-    response_id,
-    status_code,
-    headers,
-    content,
-    fetch_date
-FROM http_responses
+    bookmark_id,
+    url,
+    creation_date
+FROM bookmarks
 WHERE bookmark_id = $1
-ORDER BY fetch_date DESC
+`
+
+func (q *Queries) GetBookmark(ctx context.Context, bookmarkID uuid.UUID) (Bookmark, error) {
+	row := q.db.QueryRow(ctx, getBookmark, bookmarkID)
+	var i Bookmark
+	err := row.Scan(&i.BookmarkID, &i.Url, &i.CreationDate)
+	return i, err
+}
+
+const getBookmarkAnnotation = `-- name: GetBookmarkAnnotation :one
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags
+FROM bookmark_annotations a
+WHERE a.annotation_id = $1
+`
+
+type GetBookmarkAnnotationRow struct {
+	AnnotationID  uuid.UUID        `json:"annotation_id"`
+	BookmarkID    uuid.UUID        `json:"bookmark_id"`
+	QuoteExact    string           `json:"quote_exact"`
+	QuotePrefix   string           `json:"quote_prefix"`
+	QuoteSuffix   string           `json:"quote_suffix"`
+	PositionStart int32            `json:"position_start"`
+	PositionEnd   int32            `json:"position_end"`
+	Note          *string          `json:"note"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+	Tags          []string         `json:"tags"`
+}
+
+func (q *Queries) GetBookmarkAnnotation(ctx context.Context, annotationID uuid.UUID) (GetBookmarkAnnotationRow, error) {
+	row := q.db.QueryRow(ctx, getBookmarkAnnotation, annotationID)
+	var i GetBookmarkAnnotationRow
+	err := row.Scan(
+		&i.AnnotationID,
+		&i.BookmarkID,
+		&i.QuoteExact,
+		&i.QuotePrefix,
+		&i.QuoteSuffix,
+		&i.PositionStart,
+		&i.PositionEnd,
+		&i.Note,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Tags,
+	)
+	return i, err
+}
+
+const getBookmarkByURL = `-- name: GetBookmarkByURL :one
+SELECT
+    bookmark_id,
+    url,
+    creation_date
+FROM bookmarks
+WHERE url = $1
+ORDER BY creation_date ASC
 LIMIT 1
 `
 
-type GetLatestHttpResponseRow struct {
-	ResponseID uuid.UUID        `json:"response_id"`
-	StatusCode *int32           `json:"status_code"`
-	Headers    *string          `json:"headers"`
-	Content    []byte           `json:"content"`
-	FetchDate  pgtype.Timestamp `json:"fetch_date"`
+func (q *Queries) GetBookmarkByURL(ctx context.Context, url string) (Bookmark, error) {
+	row := q.db.QueryRow(ctx, getBookmarkByURL, url)
+	var i Bookmark
+	err := row.Scan(&i.BookmarkID, &i.Url, &i.CreationDate)
+	return i, err
 }
 
-func (q *Queries) GetLatestHttpResponse(ctx context.Context, bookmarkID pgtype.UUID) (GetLatestHttpResponseRow, error) {
-	row := q.db.QueryRow(ctx, getLatestHttpResponse, bookmarkID)
-	var i GetLatestHttpResponseRow
+const getBookmarkDetails = `-- name: GetBookmarkDetails :one
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    c.name as category_name,
+    bs.source_uri,
+    bs.raw_source,
+    bt.title,
+    pc_lynx.processed_content as lynx_content,
+    pc_reader.processed_content as reader_content,
+    pc_reader.strategy_used as content_strategy,
+    hr.status_code,
+    hr.headers,
+    hr.content as http_content,
+    hb.content as stored_http_content,
+    hr.fetch_date,
+    hr.fetch_profile,
+    bcr_summary.content as summary
+FROM bookmarks b
+LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN categories c ON bc.category_id = c.category_id
+LEFT JOIN bookmark_sources bs ON b.bookmark_id = bs.bookmark_id
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+LEFT JOIN processed_contents pc_lynx ON b.bookmark_id = pc_lynx.bookmark_id AND pc_lynx.strategy_used = 'lynx'
+LEFT JOIN LATERAL (
+    SELECT processed_content, strategy_used
+    FROM processed_contents
+    WHERE bookmark_id = b.bookmark_id
+      AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ORDER BY created_at DESC NULLS LAST, array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+    LIMIT 1
+) pc_reader ON true
+LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+LEFT JOIN bookmark_content_references bcr_summary ON b.bookmark_id = bcr_summary.bookmark_id AND bcr_summary.strategy = 'summary-reader'
+WHERE b.bookmark_id = $1
+`
+
+type GetBookmarkDetailsRow struct {
+	BookmarkID        uuid.UUID        `json:"bookmark_id"`
+	Url               string           `json:"url"`
+	CreationDate      pgtype.Timestamp `json:"creation_date"`
+	CategoryName      *string          `json:"category_name"`
+	SourceUri         *string          `json:"source_uri"`
+	RawSource         []byte           `json:"raw_source"`
+	Title             *string          `json:"title"`
+	LynxContent       *string          `json:"lynx_content"`
+	ReaderContent     *string          `json:"reader_content"`
+	ContentStrategy   *string          `json:"content_strategy"`
+	StatusCode        *int32           `json:"status_code"`
+	Headers           *string          `json:"headers"`
+	HttpContent       []byte           `json:"http_content"`
+	StoredHttpContent []byte           `json:"stored_http_content"`
+	FetchDate         pgtype.Timestamp `json:"fetch_date"`
+	FetchProfile      *string          `json:"fetch_profile"`
+	Summary           *string          `json:"summary"`
+}
+
+func (q *Queries) GetBookmarkDetails(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkDetailsRow, error) {
+	row := q.db.QueryRow(ctx, getBookmarkDetails, bookmarkID)
+	var i GetBookmarkDetailsRow
 	err := row.Scan(
-		&i.ResponseID,
+		&i.BookmarkID,
+		&i.Url,
+		&i.CreationDate,
+		&i.CategoryName,
+		&i.SourceUri,
+		&i.RawSource,
+		&i.Title,
+		&i.LynxContent,
+		&i.ReaderContent,
+		&i.ContentStrategy,
 		&i.StatusCode,
 		&i.Headers,
-		&i.Content,
+		&i.HttpContent,
+		&i.StoredHttpContent,
 		&i.FetchDate,
+		&i.FetchProfile,
+		&i.Summary,
+	)
+	return i, err
+}
+
+const getBookmarkForQuestion = `-- name: GetBookmarkForQuestion :one
+SELECT
+    b.bookmark_id,
+    bt.title,
+    bcr.content as summary
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+LEFT JOIN bookmark_content_references bcr ON b.bookmark_id = bcr.bookmark_id AND bcr.strategy = 'summary-reader'
+WHERE b.bookmark_id = $1
+`
+
+type GetBookmarkForQuestionRow struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	Title      *string   `json:"title"`
+	Summary    *string   `json:"summary"`
+}
+
+func (q *Queries) GetBookmarkForQuestion(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkForQuestionRow, error) {
+	row := q.db.QueryRow(ctx, getBookmarkForQuestion, bookmarkID)
+	var i GetBookmarkForQuestionRow
+	err := row.Scan(&i.BookmarkID, &i.Title, &i.Summary)
+	return i, err
+}
+
+const getBookmarkImport = `-- name: GetBookmarkImport :one
+SELECT import_id, format, source, status, processed, created, duplicates, failed, report, error, started_at, updated_at, finished_at
+FROM bookmark_imports
+WHERE import_id = $1
+`
+
+func (q *Queries) GetBookmarkImport(ctx context.Context, importID uuid.UUID) (BookmarkImport, error) {
+	row := q.db.QueryRow(ctx, getBookmarkImport, importID)
+	var i BookmarkImport
+	err := row.Scan(
+		&i.ImportID,
+		&i.Format,
+		&i.Source,
+		&i.Status,
+		&i.Processed,
+		&i.Created,
+		&i.Duplicates,
+		&i.Failed,
+		&i.Report,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
 	)
 	return i, err
 }
 
-const getMissingHttpResponses = `-- name: GetMissingHttpResponses :many
-SELECT
-    b.bookmark_id,
-    b.url,
-    b.creation_date
-FROM bookmarks b
-LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
-WHERE hr.response_id IS NULL
-ORDER BY b.creation_date DESC
+const getBookmarkMetadata = `-- name: GetBookmarkMetadata :one
+SELECT
+    bookmark_id,
+    canonical_url,
+    title,
+    description,
+    site_name,
+    author,
+    published_at,
+    language,
+    favicon_url,
+    image_url,
+    content_type,
+    properties,
+    json_ld,
+    word_count,
+    reading_time_minutes,
+    extractor,
+    extractor_metadata,
+    updated_at
+FROM bookmark_metadata
+WHERE bookmark_id = $1
+`
+
+type GetBookmarkMetadataRow struct {
+	BookmarkID         uuid.UUID        `json:"bookmark_id"`
+	CanonicalUrl       *string          `json:"canonical_url"`
+	Title              *string          `json:"title"`
+	Description        *string          `json:"description"`
+	SiteName           *string          `json:"site_name"`
+	Author             *string          `json:"author"`
+	PublishedAt        pgtype.Timestamp `json:"published_at"`
+	Language           *string          `json:"language"`
+	FaviconUrl         *string          `json:"favicon_url"`
+	ImageUrl           *string          `json:"image_url"`
+	ContentType        *string          `json:"content_type"`
+	Properties         []byte           `json:"properties"`
+	JsonLd             []byte           `json:"json_ld"`
+	WordCount          int32            `json:"word_count"`
+	ReadingTimeMinutes int32            `json:"reading_time_minutes"`
+	Extractor          *string          `json:"extractor"`
+	ExtractorMetadata  []byte           `json:"extractor_metadata"`
+	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) GetBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkMetadataRow, error) {
+	row := q.db.QueryRow(ctx, getBookmarkMetadata, bookmarkID)
+	var i GetBookmarkMetadataRow
+	err := row.Scan(
+		&i.BookmarkID,
+		&i.CanonicalUrl,
+		&i.Title,
+		&i.Description,
+		&i.SiteName,
+		&i.Author,
+		&i.PublishedAt,
+		&i.Language,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.ContentType,
+		&i.Properties,
+		&i.JsonLd,
+		&i.WordCount,
+		&i.ReadingTimeMinutes,
+		&i.Extractor,
+		&i.ExtractorMetadata,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getBookmarkQuestions = `-- name: GetBookmarkQuestions :many
+SELECT
+    id,
+    content
+FROM bookmark_content_references
+WHERE bookmark_id = $1 AND strategy IN ('question-inference', 'qa-v2-passage')
+`
+
+type GetBookmarkQuestionsRow struct {
+	ID      uuid.UUID `json:"id"`
+	Content *string   `json:"content"`
+}
+
+func (q *Queries) GetBookmarkQuestions(ctx context.Context, bookmarkID pgtype.UUID) ([]GetBookmarkQuestionsRow, error) {
+	rows, err := q.db.Query(ctx, getBookmarkQuestions, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetBookmarkQuestionsRow{}
+	for rows.Next() {
+		var i GetBookmarkQuestionsRow
+		if err := rows.Scan(&i.ID, &i.Content); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarkStageArtifacts = `-- name: GetBookmarkStageArtifacts :many
+SELECT
+    'fetch'::text AS stage,
+    COUNT(*)::int AS artifact_count,
+    MAX(hr.fetch_date)::timestamp AS last_produced_at
+FROM http_responses hr
+WHERE hr.bookmark_id = $1
+UNION ALL
+SELECT
+    CASE WHEN pc.strategy_used IN ('pdf', 'epub', 'text', 'markdown') THEN 'reader' ELSE pc.strategy_used END,
+    COUNT(*)::int,
+    MAX(pc.created_at)::timestamp
+FROM processed_contents pc
+WHERE pc.bookmark_id = $1
+GROUP BY 1
+UNION ALL
+SELECT
+    'title'::text,
+    COUNT(*)::int,
+    NULL::timestamp
+FROM bookmark_titles bt
+WHERE bt.bookmark_id = $1
+UNION ALL
+SELECT
+    'metadata'::text,
+    COUNT(*)::int,
+    MAX(bm.updated_at)::timestamp
+FROM bookmark_metadata bm
+WHERE bm.bookmark_id = $1
+UNION ALL
+SELECT
+    'archive'::text,
+    COUNT(*)::int,
+    MAX(ba.archived_at)::timestamp
+FROM bookmark_archives ba
+WHERE ba.bookmark_id = $1
+UNION ALL
+SELECT
+    bcr.strategy,
+    COUNT(*)::int,
+    MAX(bcr.created_at)::timestamp
+FROM bookmark_content_references bcr
+WHERE bcr.bookmark_id = $1
+GROUP BY bcr.strategy
+`
+
+type GetBookmarkStageArtifactsRow struct {
+	Stage          string           `json:"stage"`
+	ArtifactCount  int32            `json:"artifact_count"`
+	LastProducedAt pgtype.Timestamp `json:"last_produced_at"`
+}
+
+func (q *Queries) GetBookmarkStageArtifacts(ctx context.Context, bookmarkID pgtype.UUID) ([]GetBookmarkStageArtifactsRow, error) {
+	rows, err := q.db.Query(ctx, getBookmarkStageArtifacts, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetBookmarkStageArtifactsRow{}
+	for rows.Next() {
+		var i GetBookmarkStageArtifactsRow
+		if err := rows.Scan(&i.Stage, &i.ArtifactCount, &i.LastProducedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarkStageRunSummaries = `-- name: GetBookmarkStageRunSummaries :many
+SELECT
+    latest.run_id,
+    latest.stage,
+    latest.status,
+    latest.message,
+    latest.error,
+    latest.started_at,
+    latest.finished_at,
+    totals.attempts,
+    totals.failures,
+    totals.last_success_at
+FROM (
+    SELECT DISTINCT ON (r.stage)
+        r.run_id,
+        r.stage,
+        r.status,
+        r.message,
+        r.error,
+        r.started_at,
+        r.finished_at
+    FROM bookmark_stage_runs r
+    WHERE r.bookmark_id = $1
+    ORDER BY r.stage, r.started_at DESC
+) latest
+JOIN (
+    SELECT
+        stage,
+        COUNT(*)::int AS attempts,
+        (COUNT(*) FILTER (WHERE status = 'failed'))::int AS failures,
+        (MAX(finished_at) FILTER (WHERE status = 'succeeded'))::timestamp AS last_success_at
+    FROM bookmark_stage_runs
+    WHERE bookmark_id = $1
+    GROUP BY stage
+) totals ON totals.stage = latest.stage
+`
+
+type GetBookmarkStageRunSummariesRow struct {
+	RunID         uuid.UUID        `json:"run_id"`
+	Stage         string           `json:"stage"`
+	Status        string           `json:"status"`
+	Message       *string          `json:"message"`
+	Error         *string          `json:"error"`
+	StartedAt     pgtype.Timestamp `json:"started_at"`
+	FinishedAt    pgtype.Timestamp `json:"finished_at"`
+	Attempts      int32            `json:"attempts"`
+	Failures      int32            `json:"failures"`
+	LastSuccessAt pgtype.Timestamp `json:"last_success_at"`
+}
+
+func (q *Queries) GetBookmarkStageRunSummaries(ctx context.Context, bookmarkID uuid.UUID) ([]GetBookmarkStageRunSummariesRow, error) {
+	rows, err := q.db.Query(ctx, getBookmarkStageRunSummaries, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetBookmarkStageRunSummariesRow{}
+	for rows.Next() {
+		var i GetBookmarkStageRunSummariesRow
+		if err := rows.Scan(
+			&i.RunID,
+			&i.Stage,
+			&i.Status,
+			&i.Message,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Attempts,
+			&i.Failures,
+			&i.LastSuccessAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarkTags = `-- name: GetBookmarkTags :many
+SELECT t.name
+FROM bookmark_tags bt
+JOIN tags t ON bt.tag_id = t.id
+WHERE bt.bookmark_id = $1
+ORDER BY t.name
+`
+
+func (q *Queries) GetBookmarkTags(ctx context.Context, bookmarkID uuid.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, getBookmarkTags, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarkTitle = `-- name: GetBookmarkTitle :one
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    bt.title as existing_title,
+    hr.content as raw_content,
+    hb.content as stored_raw_content,
+    substring(pc.processed_content FROM '#([^\n]*)')::text as reader_title
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+LEFT JOIN LATERAL (
+    SELECT processed_content
+    FROM processed_contents
+    WHERE bookmark_id = b.bookmark_id
+      AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ORDER BY array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+    LIMIT 1
+) pc ON true
+WHERE b.bookmark_id = $1
+`
+
+type GetBookmarkTitleRow struct {
+	BookmarkID       uuid.UUID        `json:"bookmark_id"`
+	Url              string           `json:"url"`
+	CreationDate     pgtype.Timestamp `json:"creation_date"`
+	ExistingTitle    *string          `json:"existing_title"`
+	RawContent       []byte           `json:"raw_content"`
+	StoredRawContent []byte           `json:"stored_raw_content"`
+	ReaderTitle      string           `json:"reader_title"`
+}
+
+func (q *Queries) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkTitleRow, error) {
+	row := q.db.QueryRow(ctx, getBookmarkTitle, bookmarkID)
+	var i GetBookmarkTitleRow
+	err := row.Scan(
+		&i.BookmarkID,
+		&i.Url,
+		&i.CreationDate,
+		&i.ExistingTitle,
+		&i.RawContent,
+		&i.StoredRawContent,
+		&i.ReaderTitle,
+	)
+	return i, err
+}
+
+const getBookmarkWithTitle = `-- name: GetBookmarkWithTitle :one
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    bt.title
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+WHERE b.bookmark_id = $1
+LIMIT 1
+`
+
+type GetBookmarkWithTitleRow struct {
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Url          string           `json:"url"`
+	CreationDate pgtype.Timestamp `json:"creation_date"`
+	Title        *string          `json:"title"`
+}
+
+func (q *Queries) GetBookmarkWithTitle(ctx context.Context, bookmarkID uuid.UUID) (GetBookmarkWithTitleRow, error) {
+	row := q.db.QueryRow(ctx, getBookmarkWithTitle, bookmarkID)
+	var i GetBookmarkWithTitleRow
+	err := row.Scan(
+		&i.BookmarkID,
+		&i.Url,
+		&i.CreationDate,
+		&i.Title,
+	)
+	return i, err
+}
+
+const getContentReferenceGenerations = `-- name: GetContentReferenceGenerations :many
+SELECT
+    id,
+    strategy,
+    COALESCE(extra->>'model', '')::text AS model,
+    COALESCE(extra->>'prompt_version', '')::text AS prompt_version
+FROM bookmark_content_references
+WHERE bookmark_id = $1
+  AND id = ANY($2::uuid[])
+`
+
+type GetContentReferenceGenerationsParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Ids        []uuid.UUID `json:"ids"`
+}
+
+type GetContentReferenceGenerationsRow struct {
+	ID            uuid.UUID `json:"id"`
+	Strategy      *string   `json:"strategy"`
+	Model         string    `json:"model"`
+	PromptVersion string    `json:"prompt_version"`
+}
+
+func (q *Queries) GetContentReferenceGenerations(ctx context.Context, arg GetContentReferenceGenerationsParams) ([]GetContentReferenceGenerationsRow, error) {
+	rows, err := q.db.Query(ctx, getContentReferenceGenerations, arg.BookmarkID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetContentReferenceGenerationsRow{}
+	for rows.Next() {
+		var i GetContentReferenceGenerationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Strategy,
+			&i.Model,
+			&i.PromptVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDocumentContent = `-- name: GetDocumentContent :one
+SELECT
+    processed_content,
+    strategy_used
+FROM processed_contents
+WHERE bookmark_id = $1
+  AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+ORDER BY created_at DESC NULLS LAST, array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+LIMIT 1
+`
+
+type GetDocumentContentRow struct {
+	ProcessedContent *string `json:"processed_content"`
+	StrategyUsed     *string `json:"strategy_used"`
+}
+
+// The newest extraction wins, so a page that now serves a document replaces its older reader text
+func (q *Queries) GetDocumentContent(ctx context.Context, bookmarkID pgtype.UUID) (GetDocumentContentRow, error) {
+	row := q.db.QueryRow(ctx, getDocumentContent, bookmarkID)
+	var i GetDocumentContentRow
+	err := row.Scan(&i.ProcessedContent, &i.StrategyUsed)
+	return i, err
+}
+
+const getExtractedDocument = `-- name: GetExtractedDocument :one
+SELECT
+    processed_content,
+    extractor,
+    metadata
+FROM processed_contents
+WHERE bookmark_id = $1
+  AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+ORDER BY created_at DESC NULLS LAST, array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+LIMIT 1
+`
+
+type GetExtractedDocumentRow struct {
+	ProcessedContent *string `json:"processed_content"`
+	Extractor        *string `json:"extractor"`
+	Metadata         []byte  `json:"metadata"`
+}
+
+func (q *Queries) GetExtractedDocument(ctx context.Context, bookmarkID pgtype.UUID) (GetExtractedDocumentRow, error) {
+	row := q.db.QueryRow(ctx, getExtractedDocument, bookmarkID)
+	var i GetExtractedDocumentRow
+	err := row.Scan(&i.ProcessedContent, &i.Extractor, &i.Metadata)
+	return i, err
+}
+
+const getGeneratedQuestions = `-- name: GetGeneratedQuestions :many
+SELECT
+    id,
+    content
+FROM bookmark_content_references
+WHERE bookmark_id = $1
+    AND strategy = $2
+    AND extra->>'prompt_version' = $3::text
+ORDER BY created_at ASC
+`
+
+type GetGeneratedQuestionsParams struct {
+	BookmarkID    pgtype.UUID `json:"bookmark_id"`
+	Strategy      *string     `json:"strategy"`
+	PromptVersion string      `json:"prompt_version"`
+}
+
+type GetGeneratedQuestionsRow struct {
+	ID      uuid.UUID `json:"id"`
+	Content *string   `json:"content"`
+}
+
+func (q *Queries) GetGeneratedQuestions(ctx context.Context, arg GetGeneratedQuestionsParams) ([]GetGeneratedQuestionsRow, error) {
+	rows, err := q.db.Query(ctx, getGeneratedQuestions, arg.BookmarkID, arg.Strategy, arg.PromptVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetGeneratedQuestionsRow{}
+	for rows.Next() {
+		var i GetGeneratedQuestionsRow
+		if err := rows.Scan(&i.ID, &i.Content); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInlineHttpResponseContent = `-- name: GetInlineHttpResponseContent :one
+SELECT content, original_content
+FROM http_responses
+WHERE response_id = $1
+`
+
+type GetInlineHttpResponseContentRow struct {
+	Content         []byte `json:"content"`
+	OriginalContent []byte `json:"original_content"`
+}
+
+func (q *Queries) GetInlineHttpResponseContent(ctx context.Context, responseID uuid.UUID) (GetInlineHttpResponseContentRow, error) {
+	row := q.db.QueryRow(ctx, getInlineHttpResponseContent, responseID)
+	var i GetInlineHttpResponseContentRow
+	err := row.Scan(&i.Content, &i.OriginalContent)
+	return i, err
+}
+
+const getLatestContentReference = `-- name: GetLatestContentReference :one
+SELECT id
+FROM bookmark_content_references
+WHERE bookmark_id = $1 AND strategy = $2
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetLatestContentReferenceParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Strategy   *string     `json:"strategy"`
+}
+
+func (q *Queries) GetLatestContentReference(ctx context.Context, arg GetLatestContentReferenceParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, getLatestContentReference, arg.BookmarkID, arg.Strategy)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getLatestFetchStatus = `-- name: GetLatestFetchStatus :one
+SELECT
+    hr.status_code,
+    hr.fetch_date,
+    (CASE WHEN hr.status_code = 500 AND hr.headers = '{}' THEN hr.content END)::bytea AS fetch_error,
+    (CASE WHEN hr.status_code = 500 AND hr.headers = '{}' THEN hb.content END)::bytea AS stored_fetch_error
+FROM http_responses hr
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+WHERE hr.bookmark_id = $1
+ORDER BY hr.fetch_date DESC NULLS LAST
+LIMIT 1
+`
+
+type GetLatestFetchStatusRow struct {
+	StatusCode       *int32           `json:"status_code"`
+	FetchDate        pgtype.Timestamp `json:"fetch_date"`
+	FetchError       []byte           `json:"fetch_error"`
+	StoredFetchError []byte           `json:"stored_fetch_error"`
+}
+
+func (q *Queries) GetLatestFetchStatus(ctx context.Context, bookmarkID pgtype.UUID) (GetLatestFetchStatusRow, error) {
+	row := q.db.QueryRow(ctx, getLatestFetchStatus, bookmarkID)
+	var i GetLatestFetchStatusRow
+	err := row.Scan(
+		&i.StatusCode,
+		&i.FetchDate,
+		&i.FetchError,
+		&i.StoredFetchError,
+	)
+	return i, err
+}
+
+const getLatestHttpResponse = `-- name: GetLatestHttpResponse :one
+SELECT
+    hr.response_id,
+    hr.status_code,
+    hr.headers,
+    hr.content,
+    hb.content AS stored_content,
+    hr.fetch_date,
+    hr.fetch_profile
+FROM http_responses hr
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+WHERE hr.bookmark_id = $1
+ORDER BY hr.fetch_date DESC NULLS LAST
+LIMIT 1
+`
+
+type GetLatestHttpResponseRow struct {
+	ResponseID    uuid.UUID        `json:"response_id"`
+	StatusCode    *int32           `json:"status_code"`
+	Headers       *string          `json:"headers"`
+	Content       []byte           `json:"content"`
+	StoredContent []byte           `json:"stored_content"`
+	FetchDate     pgtype.Timestamp `json:"fetch_date"`
+	FetchProfile  *string          `json:"fetch_profile"`
+}
+
+func (q *Queries) GetLatestHttpResponse(ctx context.Context, bookmarkID pgtype.UUID) (GetLatestHttpResponseRow, error) {
+	row := q.db.QueryRow(ctx, getLatestHttpResponse, bookmarkID)
+	var i GetLatestHttpResponseRow
+	err := row.Scan(
+		&i.ResponseID,
+		&i.StatusCode,
+		&i.Headers,
+		&i.Content,
+		&i.StoredContent,
+		&i.FetchDate,
+		&i.FetchProfile,
+	)
+	return i, err
+}
+
+const getLatestLinkCheckContent = `-- name: GetLatestLinkCheckContent :one
+SELECT content
+FROM bookmark_link_checks
+WHERE bookmark_id = $1
+  AND content IS NOT NULL
+ORDER BY checked_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestLinkCheckContent(ctx context.Context, bookmarkID uuid.UUID) (*string, error) {
+	row := q.db.QueryRow(ctx, getLatestLinkCheckContent, bookmarkID)
+	var content *string
+	err := row.Scan(&content)
+	return content, err
+}
+
+const getMissingHttpResponses = `-- name: GetMissingHttpResponses :many
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date
+FROM bookmarks b
+LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
+WHERE hr.response_id IS NULL
+ORDER BY b.creation_date DESC
+`
+
+func (q *Queries) GetMissingHttpResponses(ctx context.Context) ([]Bookmark, error) {
+	rows, err := q.db.Query(ctx, getMissingHttpResponses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bookmark{}
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(&i.BookmarkID, &i.Url, &i.CreationDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissingReaderContent = `-- name: GetMissingReaderContent :many
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date
+FROM bookmarks b
+LEFT JOIN processed_contents pc ON b.bookmark_id = pc.bookmark_id AND pc.strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+WHERE pc.processed_content_id IS NULL
+ORDER BY b.creation_date DESC
+`
+
+func (q *Queries) GetMissingReaderContent(ctx context.Context) ([]Bookmark, error) {
+	rows, err := q.db.Query(ctx, getMissingReaderContent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bookmark{}
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(&i.BookmarkID, &i.Url, &i.CreationDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOriginalHttpResponseBody = `-- name: GetOriginalHttpResponseBody :one
+SELECT
+    hr.original_content,
+    hb.content AS stored_original_content,
+    hr.original_content_type
+FROM http_responses hr
+LEFT JOIN http_response_bodies hb ON hr.original_content_hash = hb.content_hash
+WHERE hr.response_id = $1
+  AND (hr.original_content IS NOT NULL OR hr.original_content_hash IS NOT NULL)
+`
+
+type GetOriginalHttpResponseBodyRow struct {
+	OriginalContent       []byte  `json:"original_content"`
+	StoredOriginalContent []byte  `json:"stored_original_content"`
+	OriginalContentType   *string `json:"original_content_type"`
+}
+
+func (q *Queries) GetOriginalHttpResponseBody(ctx context.Context, responseID uuid.UUID) (GetOriginalHttpResponseBodyRow, error) {
+	row := q.db.QueryRow(ctx, getOriginalHttpResponseBody, responseID)
+	var i GetOriginalHttpResponseBodyRow
+	err := row.Scan(&i.OriginalContent, &i.StoredOriginalContent, &i.OriginalContentType)
+	return i, err
+}
+
+const getProcessedContentByStrategy = `-- name: GetProcessedContentByStrategy :one
+SELECT
+    processed_content_id,
+    processed_content
+FROM processed_contents
+WHERE bookmark_id = $1 AND strategy_used = $2
+ORDER BY created_at DESC NULLS LAST
+LIMIT 1
+`
+
+type GetProcessedContentByStrategyParams struct {
+	BookmarkID   pgtype.UUID `json:"bookmark_id"`
+	StrategyUsed *string     `json:"strategy_used"`
+}
+
+type GetProcessedContentByStrategyRow struct {
+	ProcessedContentID uuid.UUID `json:"processed_content_id"`
+	ProcessedContent   *string   `json:"processed_content"`
+}
+
+func (q *Queries) GetProcessedContentByStrategy(ctx context.Context, arg GetProcessedContentByStrategyParams) (GetProcessedContentByStrategyRow, error) {
+	row := q.db.QueryRow(ctx, getProcessedContentByStrategy, arg.BookmarkID, arg.StrategyUsed)
+	var i GetProcessedContentByStrategyRow
+	err := row.Scan(&i.ProcessedContentID, &i.ProcessedContent)
+	return i, err
+}
+
+const getRandomBookmark = `-- name: GetRandomBookmark :one
+SELECT bookmark_id
+FROM bookmarks
+ORDER BY RANDOM()
+LIMIT 1
+`
+
+func (q *Queries) GetRandomBookmark(ctx context.Context) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, getRandomBookmark)
+	var bookmark_id uuid.UUID
+	err := row.Scan(&bookmark_id)
+	return bookmark_id, err
+}
+
+const hasArchivedContent = `-- name: HasArchivedContent :one
+SELECT
+    NOT EXISTS (SELECT 1 FROM http_responses hr WHERE hr.bookmark_id = $1)
+    AND EXISTS (
+        SELECT 1 FROM processed_contents pc
+        WHERE pc.bookmark_id = $1
+          AND pc.strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ) AS archived
+`
+
+func (q *Queries) HasArchivedContent(ctx context.Context, bookmarkID pgtype.UUID) (*bool, error) {
+	row := q.db.QueryRow(ctx, hasArchivedContent, bookmarkID)
+	var archived *bool
+	err := row.Scan(&archived)
+	return archived, err
+}
+
+const insertBookmarkAnnotation = `-- name: InsertBookmarkAnnotation :one
+INSERT INTO bookmark_annotations (
+    bookmark_id,
+    quote_exact,
+    quote_prefix,
+    quote_suffix,
+    position_start,
+    position_end,
+    note
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING annotation_id, created_at, updated_at
+`
+
+type InsertBookmarkAnnotationParams struct {
+	BookmarkID    uuid.UUID `json:"bookmark_id"`
+	QuoteExact    string    `json:"quote_exact"`
+	QuotePrefix   string    `json:"quote_prefix"`
+	QuoteSuffix   string    `json:"quote_suffix"`
+	PositionStart int32     `json:"position_start"`
+	PositionEnd   int32     `json:"position_end"`
+	Note          *string   `json:"note"`
+}
+
+type InsertBookmarkAnnotationRow struct {
+	AnnotationID uuid.UUID        `json:"annotation_id"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) InsertBookmarkAnnotation(ctx context.Context, arg InsertBookmarkAnnotationParams) (InsertBookmarkAnnotationRow, error) {
+	row := q.db.QueryRow(ctx, insertBookmarkAnnotation,
+		arg.BookmarkID,
+		arg.QuoteExact,
+		arg.QuotePrefix,
+		arg.QuoteSuffix,
+		arg.PositionStart,
+		arg.PositionEnd,
+		arg.Note,
+	)
+	var i InsertBookmarkAnnotationRow
+	err := row.Scan(&i.AnnotationID, &i.CreatedAt, &i.UpdatedAt)
+	return i, err
+}
+
+const insertBookmarkAnnotationTag = `-- name: InsertBookmarkAnnotationTag :exec
+INSERT INTO bookmark_annotation_tags (annotation_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type InsertBookmarkAnnotationTagParams struct {
+	AnnotationID uuid.UUID `json:"annotation_id"`
+	TagID        uuid.UUID `json:"tag_id"`
+}
+
+func (q *Queries) InsertBookmarkAnnotationTag(ctx context.Context, arg InsertBookmarkAnnotationTagParams) error {
+	_, err := q.db.Exec(ctx, insertBookmarkAnnotationTag, arg.AnnotationID, arg.TagID)
+	return err
+}
+
+const insertBookmarkArchive = `-- name: InsertBookmarkArchive :one
+INSERT INTO bookmark_archives (
+    bookmark_id,
+    url,
+    html_hash,
+    html_size,
+    warc_hash,
+    warc_size,
+    resource_count,
+    failed_resources
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING archive_id, archived_at
+`
+
+type InsertBookmarkArchiveParams struct {
+	BookmarkID      uuid.UUID `json:"bookmark_id"`
+	Url             string    `json:"url"`
+	HtmlHash        string    `json:"html_hash"`
+	HtmlSize        int64     `json:"html_size"`
+	WarcHash        *string   `json:"warc_hash"`
+	WarcSize        *int64    `json:"warc_size"`
+	ResourceCount   int32     `json:"resource_count"`
+	FailedResources int32     `json:"failed_resources"`
+}
+
+type InsertBookmarkArchiveRow struct {
+	ArchiveID  uuid.UUID        `json:"archive_id"`
+	ArchivedAt pgtype.Timestamp `json:"archived_at"`
+}
+
+func (q *Queries) InsertBookmarkArchive(ctx context.Context, arg InsertBookmarkArchiveParams) (InsertBookmarkArchiveRow, error) {
+	row := q.db.QueryRow(ctx, insertBookmarkArchive,
+		arg.BookmarkID,
+		arg.Url,
+		arg.HtmlHash,
+		arg.HtmlSize,
+		arg.WarcHash,
+		arg.WarcSize,
+		arg.ResourceCount,
+		arg.FailedResources,
+	)
+	var i InsertBookmarkArchiveRow
+	err := row.Scan(&i.ArchiveID, &i.ArchivedAt)
+	return i, err
+}
+
+const insertBookmarkCategory = `-- name: InsertBookmarkCategory :exec
+INSERT INTO bookmark_category (bookmark_id, category_id)
+VALUES ($1, $2)
+`
+
+type InsertBookmarkCategoryParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	CategoryID pgtype.UUID `json:"category_id"`
+}
+
+func (q *Queries) InsertBookmarkCategory(ctx context.Context, arg InsertBookmarkCategoryParams) error {
+	_, err := q.db.Exec(ctx, insertBookmarkCategory, arg.BookmarkID, arg.CategoryID)
+	return err
+}
+
+const insertBookmarkEvaluation = `-- name: InsertBookmarkEvaluation :one
+INSERT INTO bookmark_evaluations (
+    bookmark_id,
+    failed_fetch,
+    summary_id,
+    summary_comments,
+    summary_eval,
+    summary_strategy,
+    summary_model,
+    summary_prompt_version,
+    questions_eval,
+    questions_ids,
+    questions_strategy,
+    questions_model,
+    questions_prompt_version
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, created_at
+`
+
+type InsertBookmarkEvaluationParams struct {
+	BookmarkID             uuid.UUID `json:"bookmark_id"`
+	FailedFetch            *bool     `json:"failed_fetch"`
+	SummaryID              *string   `json:"summary_id"`
+	SummaryComments        *string   `json:"summary_comments"`
+	SummaryEval            *int32    `json:"summary_eval"`
+	SummaryStrategy        *string   `json:"summary_strategy"`
+	SummaryModel           *string   `json:"summary_model"`
+	SummaryPromptVersion   *string   `json:"summary_prompt_version"`
+	QuestionsEval          *int32    `json:"questions_eval"`
+	QuestionsIds           []byte    `json:"questions_ids"`
+	QuestionsStrategy      *string   `json:"questions_strategy"`
+	QuestionsModel         *string   `json:"questions_model"`
+	QuestionsPromptVersion *string   `json:"questions_prompt_version"`
+}
+
+type InsertBookmarkEvaluationRow struct {
+	ID        uuid.UUID          `json:"id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) InsertBookmarkEvaluation(ctx context.Context, arg InsertBookmarkEvaluationParams) (InsertBookmarkEvaluationRow, error) {
+	row := q.db.QueryRow(ctx, insertBookmarkEvaluation,
+		arg.BookmarkID,
+		arg.FailedFetch,
+		arg.SummaryID,
+		arg.SummaryComments,
+		arg.SummaryEval,
+		arg.SummaryStrategy,
+		arg.SummaryModel,
+		arg.SummaryPromptVersion,
+		arg.QuestionsEval,
+		arg.QuestionsIds,
+		arg.QuestionsStrategy,
+		arg.QuestionsModel,
+		arg.QuestionsPromptVersion,
+	)
+	var i InsertBookmarkEvaluationRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const insertBookmarkLinkCheck = `-- name: InsertBookmarkLinkCheck :one
+INSERT INTO bookmark_link_checks (
+    bookmark_id,
+    status,
+    method,
+    status_code,
+    final_url,
+    error,
+    etag,
+    last_modified,
+    content_hash,
+    content,
+    change_ratio,
+    diff,
+    checked_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13
+)
+RETURNING check_id
+`
+
+type InsertBookmarkLinkCheckParams struct {
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Status       string           `json:"status"`
+	Method       string           `json:"method"`
+	StatusCode   *int32           `json:"status_code"`
+	FinalUrl     *string          `json:"final_url"`
+	Error        *string          `json:"error"`
+	Etag         *string          `json:"etag"`
+	LastModified *string          `json:"last_modified"`
+	ContentHash  *string          `json:"content_hash"`
+	Content      *string          `json:"content"`
+	ChangeRatio  *float64         `json:"change_ratio"`
+	Diff         *string          `json:"diff"`
+	CheckedAt    pgtype.Timestamp `json:"checked_at"`
+}
+
+func (q *Queries) InsertBookmarkLinkCheck(ctx context.Context, arg InsertBookmarkLinkCheckParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, insertBookmarkLinkCheck,
+		arg.BookmarkID,
+		arg.Status,
+		arg.Method,
+		arg.StatusCode,
+		arg.FinalUrl,
+		arg.Error,
+		arg.Etag,
+		arg.LastModified,
+		arg.ContentHash,
+		arg.Content,
+		arg.ChangeRatio,
+		arg.Diff,
+		arg.CheckedAt,
+	)
+	var check_id uuid.UUID
+	err := row.Scan(&check_id)
+	return check_id, err
+}
+
+const insertBookmarkSource = `-- name: InsertBookmarkSource :exec
+INSERT INTO bookmark_sources (bookmark_id, source_uri, raw_source)
+VALUES ($1, $2, $3)
+`
+
+type InsertBookmarkSourceParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	SourceUri  *string     `json:"source_uri"`
+	RawSource  []byte      `json:"raw_source"`
+}
+
+func (q *Queries) InsertBookmarkSource(ctx context.Context, arg InsertBookmarkSourceParams) error {
+	_, err := q.db.Exec(ctx, insertBookmarkSource, arg.BookmarkID, arg.SourceUri, arg.RawSource)
+	return err
+}
+
+const insertBookmarkStageRun = `-- name: InsertBookmarkStageRun :exec
+INSERT INTO bookmark_stage_runs (bookmark_id, stage, status, message, error, started_at, finished_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type InsertBookmarkStageRunParams struct {
+	BookmarkID uuid.UUID        `json:"bookmark_id"`
+	Stage      string           `json:"stage"`
+	Status     string           `json:"status"`
+	Message    *string          `json:"message"`
+	Error      *string          `json:"error"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+}
+
+func (q *Queries) InsertBookmarkStageRun(ctx context.Context, arg InsertBookmarkStageRunParams) error {
+	_, err := q.db.Exec(ctx, insertBookmarkStageRun,
+		arg.BookmarkID,
+		arg.Stage,
+		arg.Status,
+		arg.Message,
+		arg.Error,
+		arg.StartedAt,
+		arg.FinishedAt,
+	)
+	return err
+}
+
+const insertBookmarkTag = `-- name: InsertBookmarkTag :exec
+INSERT INTO bookmark_tags (bookmark_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type InsertBookmarkTagParams struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	TagID      uuid.UUID `json:"tag_id"`
+}
+
+func (q *Queries) InsertBookmarkTag(ctx context.Context, arg InsertBookmarkTagParams) error {
+	_, err := q.db.Exec(ctx, insertBookmarkTag, arg.BookmarkID, arg.TagID)
+	return err
+}
+
+const insertBookmarkTitle = `-- name: InsertBookmarkTitle :exec
+INSERT INTO bookmark_titles (bookmark_id, title, source)
+VALUES ($1, $2, $3)
+`
+
+type InsertBookmarkTitleParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Title      *string     `json:"title"`
+	Source     *string     `json:"source"`
+}
+
+func (q *Queries) InsertBookmarkTitle(ctx context.Context, arg InsertBookmarkTitleParams) error {
+	_, err := q.db.Exec(ctx, insertBookmarkTitle, arg.BookmarkID, arg.Title, arg.Source)
+	return err
+}
+
+const insertExtractedContent = `-- name: InsertExtractedContent :exec
+INSERT INTO processed_contents (bookmark_id, strategy_used, processed_content, extractor, metadata)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type InsertExtractedContentParams struct {
+	BookmarkID       pgtype.UUID `json:"bookmark_id"`
+	StrategyUsed     *string     `json:"strategy_used"`
+	ProcessedContent *string     `json:"processed_content"`
+	Extractor        *string     `json:"extractor"`
+	Metadata         []byte      `json:"metadata"`
+}
+
+func (q *Queries) InsertExtractedContent(ctx context.Context, arg InsertExtractedContentParams) error {
+	_, err := q.db.Exec(ctx, insertExtractedContent,
+		arg.BookmarkID,
+		arg.StrategyUsed,
+		arg.ProcessedContent,
+		arg.Extractor,
+		arg.Metadata,
+	)
+	return err
+}
+
+const insertHttpResponse = `-- name: InsertHttpResponse :exec
+INSERT INTO http_responses (bookmark_id, status_code, headers, content_hash, fetch_date, fetch_profile, original_content_hash, original_content_type)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type InsertHttpResponseParams struct {
+	BookmarkID          pgtype.UUID      `json:"bookmark_id"`
+	StatusCode          *int32           `json:"status_code"`
+	Headers             *string          `json:"headers"`
+	ContentHash         *string          `json:"content_hash"`
+	FetchDate           pgtype.Timestamp `json:"fetch_date"`
+	FetchProfile        *string          `json:"fetch_profile"`
+	OriginalContentHash *string          `json:"original_content_hash"`
+	OriginalContentType *string          `json:"original_content_type"`
+}
+
+func (q *Queries) InsertHttpResponse(ctx context.Context, arg InsertHttpResponseParams) error {
+	_, err := q.db.Exec(ctx, insertHttpResponse,
+		arg.BookmarkID,
+		arg.StatusCode,
+		arg.Headers,
+		arg.ContentHash,
+		arg.FetchDate,
+		arg.FetchProfile,
+		arg.OriginalContentHash,
+		arg.OriginalContentType,
+	)
+	return err
+}
+
+const insertHttpResponseBody = `-- name: InsertHttpResponseBody :exec
+INSERT INTO http_response_bodies (content_hash, content, size)
+VALUES ($1, $2, $3)
+ON CONFLICT (content_hash) DO NOTHING
+`
+
+type InsertHttpResponseBodyParams struct {
+	ContentHash string `json:"content_hash"`
+	Content     []byte `json:"content"`
+	Size        int64  `json:"size"`
+}
+
+func (q *Queries) InsertHttpResponseBody(ctx context.Context, arg InsertHttpResponseBodyParams) error {
+	_, err := q.db.Exec(ctx, insertHttpResponseBody, arg.ContentHash, arg.Content, arg.Size)
+	return err
+}
+
+const insertProcessedContent = `-- name: InsertProcessedContent :exec
+INSERT INTO processed_contents (bookmark_id, strategy_used, processed_content)
+VALUES ($1, $2, $3)
+`
+
+type InsertProcessedContentParams struct {
+	BookmarkID       pgtype.UUID `json:"bookmark_id"`
+	StrategyUsed     *string     `json:"strategy_used"`
+	ProcessedContent *string     `json:"processed_content"`
+}
+
+func (q *Queries) InsertProcessedContent(ctx context.Context, arg InsertProcessedContentParams) error {
+	_, err := q.db.Exec(ctx, insertProcessedContent, arg.BookmarkID, arg.StrategyUsed, arg.ProcessedContent)
+	return err
+}
+
+const listAnnotations = `-- name: ListAnnotations :many
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags,
+    b.url,
+    bt.title
+FROM bookmark_annotations a
+JOIN bookmarks b ON b.bookmark_id = a.bookmark_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = b.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE ($1::text IS NULL
+       OR a.quote_exact ILIKE '%' || $1 || '%'
+       OR a.note ILIKE '%' || $1 || '%')
+  AND ($2::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id AND t.name = $2
+      ))
+ORDER BY a.created_at DESC
+LIMIT $4
+OFFSET $3
+`
+
+type ListAnnotationsParams struct {
+	Search    *string `json:"search"`
+	Tag       *string `json:"tag"`
+	RowOffset int32   `json:"row_offset"`
+	RowLimit  int32   `json:"row_limit"`
+}
+
+type ListAnnotationsRow struct {
+	AnnotationID  uuid.UUID        `json:"annotation_id"`
+	BookmarkID    uuid.UUID        `json:"bookmark_id"`
+	QuoteExact    string           `json:"quote_exact"`
+	QuotePrefix   string           `json:"quote_prefix"`
+	QuoteSuffix   string           `json:"quote_suffix"`
+	PositionStart int32            `json:"position_start"`
+	PositionEnd   int32            `json:"position_end"`
+	Note          *string          `json:"note"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+	Tags          []string         `json:"tags"`
+	Url           string           `json:"url"`
+	Title         *string          `json:"title"`
+}
+
+func (q *Queries) ListAnnotations(ctx context.Context, arg ListAnnotationsParams) ([]ListAnnotationsRow, error) {
+	rows, err := q.db.Query(ctx, listAnnotations,
+		arg.Search,
+		arg.Tag,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAnnotationsRow{}
+	for rows.Next() {
+		var i ListAnnotationsRow
+		if err := rows.Scan(
+			&i.AnnotationID,
+			&i.BookmarkID,
+			&i.QuoteExact,
+			&i.QuotePrefix,
+			&i.QuoteSuffix,
+			&i.PositionStart,
+			&i.PositionEnd,
+			&i.Note,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Tags,
+			&i.Url,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkAnnotations = `-- name: ListBookmarkAnnotations :many
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags
+FROM bookmark_annotations a
+WHERE a.bookmark_id = $1
+ORDER BY a.position_start, a.created_at
+`
+
+type ListBookmarkAnnotationsRow struct {
+	AnnotationID  uuid.UUID        `json:"annotation_id"`
+	BookmarkID    uuid.UUID        `json:"bookmark_id"`
+	QuoteExact    string           `json:"quote_exact"`
+	QuotePrefix   string           `json:"quote_prefix"`
+	QuoteSuffix   string           `json:"quote_suffix"`
+	PositionStart int32            `json:"position_start"`
+	PositionEnd   int32            `json:"position_end"`
+	Note          *string          `json:"note"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+	Tags          []string         `json:"tags"`
+}
+
+func (q *Queries) ListBookmarkAnnotations(ctx context.Context, bookmarkID uuid.UUID) ([]ListBookmarkAnnotationsRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarkAnnotations, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarkAnnotationsRow{}
+	for rows.Next() {
+		var i ListBookmarkAnnotationsRow
+		if err := rows.Scan(
+			&i.AnnotationID,
+			&i.BookmarkID,
+			&i.QuoteExact,
+			&i.QuotePrefix,
+			&i.QuoteSuffix,
+			&i.PositionStart,
+			&i.PositionEnd,
+			&i.Note,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkArchives = `-- name: ListBookmarkArchives :many
+SELECT
+    archive_id,
+    bookmark_id,
+    url,
+    html_hash,
+    html_size,
+    warc_hash,
+    warc_size,
+    resource_count,
+    failed_resources,
+    archived_at
+FROM bookmark_archives
+WHERE bookmark_id = $1
+ORDER BY archived_at DESC
+`
+
+func (q *Queries) ListBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) ([]BookmarkArchive, error) {
+	rows, err := q.db.Query(ctx, listBookmarkArchives, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BookmarkArchive{}
+	for rows.Next() {
+		var i BookmarkArchive
+		if err := rows.Scan(
+			&i.ArchiveID,
+			&i.BookmarkID,
+			&i.Url,
+			&i.HtmlHash,
+			&i.HtmlSize,
+			&i.WarcHash,
+			&i.WarcSize,
+			&i.ResourceCount,
+			&i.FailedResources,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkEvaluations = `-- name: ListBookmarkEvaluations :many
+SELECT
+    id,
+    bookmark_id,
+    failed_fetch,
+    summary_id,
+    summary_comments,
+    summary_eval,
+    summary_strategy,
+    summary_model,
+    summary_prompt_version,
+    questions_eval,
+    questions_ids,
+    questions_strategy,
+    questions_model,
+    questions_prompt_version,
+    created_at
+FROM bookmark_evaluations
+WHERE bookmark_id = $1
+ORDER BY created_at DESC
+`
+
+type ListBookmarkEvaluationsRow struct {
+	ID                     uuid.UUID          `json:"id"`
+	BookmarkID             uuid.UUID          `json:"bookmark_id"`
+	FailedFetch            *bool              `json:"failed_fetch"`
+	SummaryID              *string            `json:"summary_id"`
+	SummaryComments        *string            `json:"summary_comments"`
+	SummaryEval            *int32             `json:"summary_eval"`
+	SummaryStrategy        *string            `json:"summary_strategy"`
+	SummaryModel           *string            `json:"summary_model"`
+	SummaryPromptVersion   *string            `json:"summary_prompt_version"`
+	QuestionsEval          *int32             `json:"questions_eval"`
+	QuestionsIds           []byte             `json:"questions_ids"`
+	QuestionsStrategy      *string            `json:"questions_strategy"`
+	QuestionsModel         *string            `json:"questions_model"`
+	QuestionsPromptVersion *string            `json:"questions_prompt_version"`
+	CreatedAt              pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) ListBookmarkEvaluations(ctx context.Context, bookmarkID uuid.UUID) ([]ListBookmarkEvaluationsRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarkEvaluations, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarkEvaluationsRow{}
+	for rows.Next() {
+		var i ListBookmarkEvaluationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookmarkID,
+			&i.FailedFetch,
+			&i.SummaryID,
+			&i.SummaryComments,
+			&i.SummaryEval,
+			&i.SummaryStrategy,
+			&i.SummaryModel,
+			&i.SummaryPromptVersion,
+			&i.QuestionsEval,
+			&i.QuestionsIds,
+			&i.QuestionsStrategy,
+			&i.QuestionsModel,
+			&i.QuestionsPromptVersion,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkLinkChecks = `-- name: ListBookmarkLinkChecks :many
+SELECT
+    check_id,
+    bookmark_id,
+    status,
+    method,
+    status_code,
+    final_url,
+    error,
+    etag,
+    last_modified,
+    content_hash,
+    change_ratio,
+    diff,
+    checked_at
+FROM bookmark_link_checks
+WHERE bookmark_id = $1
+ORDER BY checked_at DESC
+LIMIT $2
+`
+
+type ListBookmarkLinkChecksParams struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	Limit      int32     `json:"limit"`
+}
+
+type ListBookmarkLinkChecksRow struct {
+	CheckID      uuid.UUID        `json:"check_id"`
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Status       string           `json:"status"`
+	Method       string           `json:"method"`
+	StatusCode   *int32           `json:"status_code"`
+	FinalUrl     *string          `json:"final_url"`
+	Error        *string          `json:"error"`
+	Etag         *string          `json:"etag"`
+	LastModified *string          `json:"last_modified"`
+	ContentHash  *string          `json:"content_hash"`
+	ChangeRatio  *float64         `json:"change_ratio"`
+	Diff         *string          `json:"diff"`
+	CheckedAt    pgtype.Timestamp `json:"checked_at"`
+}
+
+func (q *Queries) ListBookmarkLinkChecks(ctx context.Context, arg ListBookmarkLinkChecksParams) ([]ListBookmarkLinkChecksRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarkLinkChecks, arg.BookmarkID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarkLinkChecksRow{}
+	for rows.Next() {
+		var i ListBookmarkLinkChecksRow
+		if err := rows.Scan(
+			&i.CheckID,
+			&i.BookmarkID,
+			&i.Status,
+			&i.Method,
+			&i.StatusCode,
+			&i.FinalUrl,
+			&i.Error,
+			&i.Etag,
+			&i.LastModified,
+			&i.ContentHash,
+			&i.ChangeRatio,
+			&i.Diff,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkStageRuns = `-- name: ListBookmarkStageRuns :many
+SELECT
+    run_id,
+    stage,
+    status,
+    message,
+    error,
+    started_at,
+    finished_at
+FROM bookmark_stage_runs
+WHERE bookmark_id = $1
+ORDER BY started_at DESC
+LIMIT $2
+`
+
+type ListBookmarkStageRunsParams struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	Limit      int32     `json:"limit"`
+}
+
+type ListBookmarkStageRunsRow struct {
+	RunID      uuid.UUID        `json:"run_id"`
+	Stage      string           `json:"stage"`
+	Status     string           `json:"status"`
+	Message    *string          `json:"message"`
+	Error      *string          `json:"error"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+}
+
+func (q *Queries) ListBookmarkStageRuns(ctx context.Context, arg ListBookmarkStageRunsParams) ([]ListBookmarkStageRunsRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarkStageRuns, arg.BookmarkID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarkStageRunsRow{}
+	for rows.Next() {
+		var i ListBookmarkStageRunsRow
+		if err := rows.Scan(
+			&i.RunID,
+			&i.Stage,
+			&i.Status,
+			&i.Message,
+			&i.Error,
+			&i.StartedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarkURLs = `-- name: ListBookmarkURLs :many
+SELECT
+    bookmark_id,
+    url,
+    creation_date
+FROM bookmarks
+ORDER BY creation_date, bookmark_id
+`
+
+func (q *Queries) ListBookmarkURLs(ctx context.Context) ([]Bookmark, error) {
+	rows, err := q.db.Query(ctx, listBookmarkURLs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bookmark{}
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(&i.BookmarkID, &i.Url, &i.CreationDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarks = `-- name: ListBookmarks :many
+WITH RECURSIVE subtree AS (
+    SELECT category_id FROM categories WHERE category_id = $1::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+SELECT DISTINCT
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    bt.title
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN bookmark_metadata bm ON b.bookmark_id = bm.bookmark_id
+WHERE
+    ($1::uuid IS NULL OR bc.category_id IN (SELECT category_id FROM subtree))
+    AND ($2::text IS NULL OR bt.title ILIKE '%' || $2 || '%')
+    AND ($3::timestamp IS NULL OR b.creation_date >= $3)
+    AND ($4::timestamp IS NULL OR b.creation_date <= $4)
+    AND ($8::text = '' OR bm.author ILIKE '%' || $8 || '%')
+    AND ($9::text = '' OR lower(bm.site_name) = lower($9)
+        OR lower(substring(b.url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)')) = lower($9))
+    AND ($10::int = 0 OR EXTRACT(YEAR FROM bm.published_at) = $10)
+    AND ($7::text = ''
+        OR (
+            SELECT r.status
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = $7
+            ORDER BY r.started_at DESC
+            LIMIT 1
+        ) = 'failed'
+        OR ($7 = 'fetch' AND NOT EXISTS (
+            SELECT 1
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = 'fetch'
+        ) AND (
+            SELECT hr.status_code
+            FROM http_responses hr
+            WHERE hr.bookmark_id = b.bookmark_id
+            ORDER BY hr.fetch_date DESC NULLS LAST
+            LIMIT 1
+        ) >= 400))
+ORDER BY b.creation_date DESC
+LIMIT $5
+OFFSET $6
+`
+
+type ListBookmarksParams struct {
+	Column1  uuid.UUID        `json:"column_1"`
+	Column2  string           `json:"column_2"`
+	Column3  pgtype.Timestamp `json:"column_3"`
+	Column4  pgtype.Timestamp `json:"column_4"`
+	Limit    int32            `json:"limit"`
+	Offset   int32            `json:"offset"`
+	Column7  string           `json:"column_7"`
+	Column8  string           `json:"column_8"`
+	Column9  string           `json:"column_9"`
+	Column10 int32            `json:"column_10"`
+}
+
+type ListBookmarksRow struct {
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Url          string           `json:"url"`
+	CreationDate pgtype.Timestamp `json:"creation_date"`
+	Title        *string          `json:"title"`
+}
+
+// The category filter includes the descendants of the category
+// The failed-stage filter applies the rule of GetBookmarkStatus: the latest run of the stage decides, and a
+// bookmark fetched before runs were recorded failed when its latest response has an error status
+func (q *Queries) ListBookmarks(ctx context.Context, arg ListBookmarksParams) ([]ListBookmarksRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarks,
+		arg.Column1,
+		arg.Column2,
+		arg.Column3,
+		arg.Column4,
+		arg.Limit,
+		arg.Offset,
+		arg.Column7,
+		arg.Column8,
+		arg.Column9,
+		arg.Column10,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarksRow{}
+	for rows.Next() {
+		var i ListBookmarksRow
+		if err := rows.Scan(
+			&i.BookmarkID,
+			&i.Url,
+			&i.CreationDate,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarksDueForLinkCheck = `-- name: ListBookmarksDueForLinkCheck :many
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date
+FROM bookmarks b
+LEFT JOIN LATERAL (
+    SELECT c.checked_at
+    FROM bookmark_link_checks c
+    WHERE c.bookmark_id = b.bookmark_id
+    ORDER BY c.checked_at DESC
+    LIMIT 1
+) latest ON true
+WHERE COALESCE(latest.checked_at, b.creation_date) < $1::timestamp
+ORDER BY latest.checked_at NULLS FIRST, b.creation_date
+LIMIT $2
+`
+
+type ListBookmarksDueForLinkCheckParams struct {
+	CheckedBefore pgtype.Timestamp `json:"checked_before"`
+	RowLimit      int32            `json:"row_limit"`
+}
+
+// Bookmarks that were never checked become due one interval after they were saved
+func (q *Queries) ListBookmarksDueForLinkCheck(ctx context.Context, arg ListBookmarksDueForLinkCheckParams) ([]Bookmark, error) {
+	rows, err := q.db.Query(ctx, listBookmarksDueForLinkCheck, arg.CheckedBefore, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bookmark{}
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(&i.BookmarkID, &i.Url, &i.CreationDate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarksForExport = `-- name: ListBookmarksForExport :many
+SELECT DISTINCT ON (b.bookmark_id)
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    bt.title,
+    c.category_id,
+    c.name AS category_name
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN categories c ON bc.category_id = c.category_id
+ORDER BY b.bookmark_id, c.name, bt.title
+`
+
+type ListBookmarksForExportRow struct {
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Url          string           `json:"url"`
+	CreationDate pgtype.Timestamp `json:"creation_date"`
+	Title        *string          `json:"title"`
+	CategoryID   pgtype.UUID      `json:"category_id"`
+	CategoryName *string          `json:"category_name"`
+}
+
+func (q *Queries) ListBookmarksForExport(ctx context.Context) ([]ListBookmarksForExportRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarksForExport)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarksForExportRow{}
+	for rows.Next() {
+		var i ListBookmarksForExportRow
+		if err := rows.Scan(
+			&i.BookmarkID,
+			&i.Url,
+			&i.CreationDate,
+			&i.Title,
+			&i.CategoryID,
+			&i.CategoryName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarksOverResponseRetention = `-- name: ListBookmarksOverResponseRetention :many
+SELECT bookmark_id::uuid
+FROM http_responses
+WHERE bookmark_id IS NOT NULL
+GROUP BY bookmark_id
+HAVING COUNT(*) > $1::bigint
+`
+
+func (q *Queries) ListBookmarksOverResponseRetention(ctx context.Context, dollar_1 int64) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listBookmarksOverResponseRetention, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var bookmark_id uuid.UUID
+		if err := rows.Scan(&bookmark_id); err != nil {
+			return nil, err
+		}
+		items = append(items, bookmark_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarksWithRejectedQuestions = `-- name: ListBookmarksWithRejectedQuestions :many
+SELECT
+    o.ref::uuid AS bookmark_id,
+    COUNT(*) AS rejections,
+    MIN(o.creation_date)::bigint AS first_rejected_at
+FROM observations o
+INNER JOIN bookmarks b ON b.bookmark_id = o.ref
+WHERE (o.type = 'qa-delete' OR (o.type = 'qa-feedback' AND o.data->>'feedbackType' IN ('downvote', 'trash')))
+  AND NOT EXISTS (
+      SELECT 1 FROM observations r
+      WHERE r.type = 'qa-regeneration'
+        AND r.data->>'status' = 'succeeded'
+        AND r.data->'feedbackIds' @> jsonb_build_array(o.observation_id::text)
+  )
+  AND (
+      SELECT COUNT(*) FROM observations r
+      WHERE r.type = 'qa-regeneration'
+        AND r.data->>'status' = 'failed'
+        AND r.data->'feedbackIds' @> jsonb_build_array(o.observation_id::text)
+  ) < $1::int
+GROUP BY o.ref
+ORDER BY first_rejected_at
+LIMIT $2
+`
+
+type ListBookmarksWithRejectedQuestionsParams struct {
+	MaxAttempts int32 `json:"max_attempts"`
+	RowLimit    int32 `json:"row_limit"`
+}
+
+type ListBookmarksWithRejectedQuestionsRow struct {
+	BookmarkID      uuid.UUID `json:"bookmark_id"`
+	Rejections      int64     `json:"rejections"`
+	FirstRejectedAt int64     `json:"first_rejected_at"`
+}
+
+func (q *Queries) ListBookmarksWithRejectedQuestions(ctx context.Context, arg ListBookmarksWithRejectedQuestionsParams) ([]ListBookmarksWithRejectedQuestionsRow, error) {
+	rows, err := q.db.Query(ctx, listBookmarksWithRejectedQuestions, arg.MaxAttempts, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookmarksWithRejectedQuestionsRow{}
+	for rows.Next() {
+		var i ListBookmarksWithRejectedQuestionsRow
+		if err := rows.Scan(&i.BookmarkID, &i.Rejections, &i.FirstRejectedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInlineHttpResponses = `-- name: ListInlineHttpResponses :many
+SELECT response_id
+FROM http_responses
+WHERE content IS NOT NULL
+  AND response_id > $1
+ORDER BY response_id
+LIMIT $2
 `
 
-func (q *Queries) GetMissingHttpResponses(ctx context.Context) ([]Bookmark, error) {
-	rows, err := q.db.Query(ctx, getMissingHttpResponses)
+type ListInlineHttpResponsesParams struct {
+	ResponseID uuid.UUID `json:"response_id"`
+	Limit      int32     `json:"limit"`
+}
+
+// Pages by response id along http_responses_inline_content_idx, so each batch starts after the
+// previous one instead of scanning past the rows already moved
+func (q *Queries) ListInlineHttpResponses(ctx context.Context, arg ListInlineHttpResponsesParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listInlineHttpResponses, arg.ResponseID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Bookmark{}
+	items := []uuid.UUID{}
 	for rows.Next() {
-		var i Bookmark
-		if err := rows.Scan(&i.BookmarkID, &i.Url, &i.CreationDate); err != nil {
+		var response_id uuid.UUID
+		if err := rows.Scan(&response_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, response_id)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -351,27 +2964,92 @@ func (q *Queries) GetMissingHttpResponses(ctx context.Context) ([]Bookmark, erro
 	return items, nil
 }
 
-const getMissingReaderContent = `-- name: GetMissingReaderContent :many
+const listLinkIssues = `-- name: ListLinkIssues :many
 SELECT
     b.bookmark_id,
     b.url,
-    b.creation_date
-FROM bookmarks b
-LEFT JOIN processed_contents pc ON b.bookmark_id = pc.bookmark_id AND pc.strategy_used = 'reader'
-WHERE pc.processed_content_id IS NULL
-ORDER BY b.creation_date DESC
+    bt.title,
+    latest.check_id,
+    latest.status,
+    latest.method,
+    latest.status_code,
+    latest.final_url,
+    latest.error,
+    latest.etag,
+    latest.last_modified,
+    latest.content_hash,
+    latest.change_ratio,
+    latest.diff,
+    latest.checked_at
+FROM (
+    SELECT DISTINCT ON (c.bookmark_id) c.check_id, c.bookmark_id, c.status, c.method, c.status_code, c.final_url, c.error, c.etag, c.last_modified, c.content_hash, c.content, c.change_ratio, c.diff, c.checked_at
+    FROM bookmark_link_checks c
+    ORDER BY c.bookmark_id, c.checked_at DESC
+) latest
+JOIN bookmarks b ON b.bookmark_id = latest.bookmark_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = b.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE latest.status <> 'ok'
+  AND ($1::text IS NULL OR latest.status = $1)
+ORDER BY latest.checked_at DESC
+LIMIT $3
+OFFSET $2
 `
 
-func (q *Queries) GetMissingReaderContent(ctx context.Context) ([]Bookmark, error) {
-	rows, err := q.db.Query(ctx, getMissingReaderContent)
+type ListLinkIssuesParams struct {
+	Status    *string `json:"status"`
+	RowOffset int32   `json:"row_offset"`
+	RowLimit  int32   `json:"row_limit"`
+}
+
+type ListLinkIssuesRow struct {
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Url          string           `json:"url"`
+	Title        *string          `json:"title"`
+	CheckID      uuid.UUID        `json:"check_id"`
+	Status       string           `json:"status"`
+	Method       string           `json:"method"`
+	StatusCode   *int32           `json:"status_code"`
+	FinalUrl     *string          `json:"final_url"`
+	Error        *string          `json:"error"`
+	Etag         *string          `json:"etag"`
+	LastModified *string          `json:"last_modified"`
+	ContentHash  *string          `json:"content_hash"`
+	ChangeRatio  *float64         `json:"change_ratio"`
+	Diff         *string          `json:"diff"`
+	CheckedAt    pgtype.Timestamp `json:"checked_at"`
+}
+
+func (q *Queries) ListLinkIssues(ctx context.Context, arg ListLinkIssuesParams) ([]ListLinkIssuesRow, error) {
+	rows, err := q.db.Query(ctx, listLinkIssues, arg.Status, arg.RowOffset, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Bookmark{}
+	items := []ListLinkIssuesRow{}
 	for rows.Next() {
-		var i Bookmark
-		if err := rows.Scan(&i.BookmarkID, &i.Url, &i.CreationDate); err != nil {
+		var i ListLinkIssuesRow
+		if err := rows.Scan(
+			&i.BookmarkID,
+			&i.Url,
+			&i.Title,
+			&i.CheckID,
+			&i.Status,
+			&i.Method,
+			&i.StatusCode,
+			&i.FinalUrl,
+			&i.Error,
+			&i.Etag,
+			&i.LastModified,
+			&i.ContentHash,
+			&i.ChangeRatio,
+			&i.Diff,
+			&i.CheckedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -382,158 +3060,357 @@ func (q *Queries) GetMissingReaderContent(ctx context.Context) ([]Bookmark, erro
 	return items, nil
 }
 
-const getProcessedContentByStrategy = `-- name: GetProcessedContentByStrategy :one
+const listRejectedQuestions = `-- name: ListRejectedQuestions :many
 SELECT
-    processed_content_id,
-    processed_content
-FROM processed_contents
-WHERE bookmark_id = $1 AND strategy_used = $2
-LIMIT 1
+    o.observation_id,
+    o.type,
+    o.data,
+    o.creation_date
+FROM observations o
+WHERE o.ref = $1
+  AND (o.type = 'qa-delete' OR (o.type = 'qa-feedback' AND o.data->>'feedbackType' IN ('downvote', 'trash')))
+  AND NOT EXISTS (
+      SELECT 1 FROM observations r
+      WHERE r.type = 'qa-regeneration'
+        AND r.data->>'status' = 'succeeded'
+        AND r.data->'feedbackIds' @> jsonb_build_array(o.observation_id::text)
+  )
+ORDER BY o.creation_date, o.observation_id
 `
 
-type GetProcessedContentByStrategyParams struct {
-	BookmarkID   pgtype.UUID `json:"bookmark_id"`
-	StrategyUsed *string     `json:"strategy_used"`
+type ListRejectedQuestionsRow struct {
+	ObservationID uuid.UUID `json:"observation_id"`
+	Type          *string   `json:"type"`
+	Data          []byte    `json:"data"`
+	CreationDate  int64     `json:"creation_date"`
 }
 
-type GetProcessedContentByStrategyRow struct {
-	ProcessedContentID uuid.UUID `json:"processed_content_id"`
-	ProcessedContent   *string   `json:"processed_content"`
+func (q *Queries) ListRejectedQuestions(ctx context.Context, bookmarkID pgtype.UUID) ([]ListRejectedQuestionsRow, error) {
+	rows, err := q.db.Query(ctx, listRejectedQuestions, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRejectedQuestionsRow{}
+	for rows.Next() {
+		var i ListRejectedQuestionsRow
+		if err := rows.Scan(
+			&i.ObservationID,
+			&i.Type,
+			&i.Data,
+			&i.CreationDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetProcessedContentByStrategy(ctx context.Context, arg GetProcessedContentByStrategyParams) (GetProcessedContentByStrategyRow, error) {
-	row := q.db.QueryRow(ctx, getProcessedContentByStrategy, arg.BookmarkID, arg.StrategyUsed)
-	var i GetProcessedContentByStrategyRow
-	err := row.Scan(&i.ProcessedContentID, &i.ProcessedContent)
-	return i, err
+const lockArchiveBlob = `-- name: LockArchiveBlob :exec
+SELECT pg_advisory_lock(hashtextextended('archive-blob:' || $1::text, 0))
+`
+
+func (q *Queries) LockArchiveBlob(ctx context.Context, hash string) error {
+	_, err := q.db.Exec(ctx, lockArchiveBlob, hash)
+	return err
 }
 
-const getRandomBookmark = `-- name: GetRandomBookmark :one
-SELECT bookmark_id
-FROM bookmarks
-ORDER BY RANDOM()
-LIMIT 1
+const lockBookmarkQuestions = `-- name: LockBookmarkQuestions :exec
+SELECT pg_advisory_lock(hashtextextended('bookmark-questions:' || $1::uuid::text, 0))
 `
 
-func (q *Queries) GetRandomBookmark(ctx context.Context) (uuid.UUID, error) {
-	row := q.db.QueryRow(ctx, getRandomBookmark)
-	var bookmark_id uuid.UUID
-	err := row.Scan(&bookmark_id)
-	return bookmark_id, err
+func (q *Queries) LockBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, lockBookmarkQuestions, bookmarkID)
+	return err
 }
 
-const insertBookmarkTitle = `-- name: InsertBookmarkTitle :exec
-INSERT INTO bookmark_titles (bookmark_id, title, source)
-VALUES ($1, $2, $3)
+const moveHttpResponseContent = `-- name: MoveHttpResponseContent :exec
+UPDATE http_responses
+SET content_hash = $2, original_content_hash = $3, content = NULL, original_content = NULL
+WHERE response_id = $1
 `
 
-type InsertBookmarkTitleParams struct {
-	BookmarkID pgtype.UUID `json:"bookmark_id"`
-	Title      *string     `json:"title"`
-	Source     *string     `json:"source"`
+type MoveHttpResponseContentParams struct {
+	ResponseID          uuid.UUID `json:"response_id"`
+	ContentHash         *string   `json:"content_hash"`
+	OriginalContentHash *string   `json:"original_content_hash"`
 }
 
-func (q *Queries) InsertBookmarkTitle(ctx context.Context, arg InsertBookmarkTitleParams) error {
-	_, err := q.db.Exec(ctx, insertBookmarkTitle, arg.BookmarkID, arg.Title, arg.Source)
+func (q *Queries) MoveHttpResponseContent(ctx context.Context, arg MoveHttpResponseContentParams) error {
+	_, err := q.db.Exec(ctx, moveHttpResponseContent, arg.ResponseID, arg.ContentHash, arg.OriginalContentHash)
 	return err
 }
 
-const insertHttpResponse = `-- name: InsertHttpResponse :exec
-INSERT INTO http_responses (bookmark_id, status_code, headers, content, fetch_date)
-VALUES ($1, $2, $3, $4, $5)
+const moveMergedBookmarkRows = `-- name: MoveMergedBookmarkRows :exec
+WITH tags AS (
+    UPDATE bookmark_tags SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), categories AS (
+    UPDATE bookmark_category SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), suggestions AS (
+    UPDATE bookmark_category_suggestions SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), metadata AS (
+    UPDATE bookmark_metadata SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), titles AS (
+    UPDATE bookmark_titles SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), sources AS (
+    UPDATE bookmark_sources SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), responses AS (
+    UPDATE http_responses SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), contents AS (
+    UPDATE processed_contents SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), refs AS (
+    UPDATE bookmark_content_references SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), runs AS (
+    UPDATE bookmark_stage_runs SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), checks AS (
+    UPDATE bookmark_link_checks SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), archives AS (
+    UPDATE bookmark_archives SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), annotations AS (
+    UPDATE bookmark_annotations SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+), evaluations AS (
+    UPDATE bookmark_evaluations SET bookmark_id = $1::uuid WHERE bookmark_id = ANY($2::uuid[])
+)
+UPDATE observations SET ref = $1::uuid WHERE ref = ANY($2::uuid[])
 `
 
-type InsertHttpResponseParams struct {
-	BookmarkID pgtype.UUID      `json:"bookmark_id"`
-	StatusCode *int32           `json:"status_code"`
-	Headers    *string          `json:"headers"`
-	Content    []byte           `json:"content"`
-	FetchDate  pgtype.Timestamp `json:"fetch_date"`
+type MoveMergedBookmarkRowsParams struct {
+	KeepID       uuid.UUID   `json:"keep_id"`
+	DuplicateIds []uuid.UUID `json:"duplicate_ids"`
 }
 
-func (q *Queries) InsertHttpResponse(ctx context.Context, arg InsertHttpResponseParams) error {
-	_, err := q.db.Exec(ctx, insertHttpResponse,
-		arg.BookmarkID,
-		arg.StatusCode,
-		arg.Headers,
-		arg.Content,
-		arg.FetchDate,
-	)
+func (q *Queries) MoveMergedBookmarkRows(ctx context.Context, arg MoveMergedBookmarkRowsParams) error {
+	_, err := q.db.Exec(ctx, moveMergedBookmarkRows, arg.KeepID, arg.DuplicateIds)
 	return err
 }
 
-const insertProcessedContent = `-- name: InsertProcessedContent :exec
-INSERT INTO processed_contents (bookmark_id, strategy_used, processed_content)
-VALUES ($1, $2, $3)
+const pruneHttpResponses = `-- name: PruneHttpResponses :many
+DELETE FROM http_responses
+WHERE http_responses.bookmark_id = $1
+  AND http_responses.response_id NOT IN (
+      SELECT kept.response_id
+      FROM http_responses kept
+      WHERE kept.bookmark_id = $1
+      ORDER BY kept.fetch_date DESC NULLS LAST
+      LIMIT $2
+  )
+  AND http_responses.response_id IS DISTINCT FROM (
+      SELECT ok.response_id
+      FROM http_responses ok
+      WHERE ok.bookmark_id = $1 AND ok.status_code < 400
+      ORDER BY ok.fetch_date DESC NULLS LAST
+      LIMIT 1
+  )
+RETURNING content_hash, original_content_hash
 `
 
-type InsertProcessedContentParams struct {
-	BookmarkID       pgtype.UUID `json:"bookmark_id"`
-	StrategyUsed     *string     `json:"strategy_used"`
-	ProcessedContent *string     `json:"processed_content"`
+type PruneHttpResponsesParams struct {
+	BookmarkID pgtype.UUID `json:"bookmark_id"`
+	Limit      int32       `json:"limit"`
 }
 
-func (q *Queries) InsertProcessedContent(ctx context.Context, arg InsertProcessedContentParams) error {
-	_, err := q.db.Exec(ctx, insertProcessedContent, arg.BookmarkID, arg.StrategyUsed, arg.ProcessedContent)
-	return err
+type PruneHttpResponsesRow struct {
+	ContentHash         *string `json:"content_hash"`
+	OriginalContentHash *string `json:"original_content_hash"`
 }
 
-const listBookmarks = `-- name: ListBookmarks :many
-SELECT DISTINCT
-    b.bookmark_id,
+func (q *Queries) PruneHttpResponses(ctx context.Context, arg PruneHttpResponsesParams) ([]PruneHttpResponsesRow, error) {
+	rows, err := q.db.Query(ctx, pruneHttpResponses, arg.BookmarkID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PruneHttpResponsesRow{}
+	for rows.Next() {
+		var i PruneHttpResponsesRow
+		if err := rows.Scan(&i.ContentHash, &i.OriginalContentHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reportBookmarkEvaluations = `-- name: ReportBookmarkEvaluations :many
+SELECT
+    'summary'::text AS kind,
+    e.summary_strategy AS strategy,
+    e.summary_model AS model,
+    e.summary_prompt_version AS prompt_version,
+    (CASE WHEN $1::text IS NULL THEN NULL ELSE date_trunc($1::text, e.created_at) END)::timestamptz AS period_start,
+    COUNT(*) AS evaluations,
+    AVG(e.summary_eval)::float8 AS average_score,
+    MIN(e.summary_eval)::integer AS min_score,
+    MAX(e.summary_eval)::integer AS max_score,
+    MIN(e.created_at)::timestamptz AS first_evaluated_at,
+    MAX(e.created_at)::timestamptz AS last_evaluated_at
+FROM bookmark_evaluations e
+WHERE e.summary_eval IS NOT NULL
+  AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+  AND ($3::timestamptz IS NULL OR e.created_at < $3)
+GROUP BY 2, 3, 4, 5
+UNION ALL
+SELECT
+    'questions'::text AS kind,
+    e.questions_strategy AS strategy,
+    e.questions_model AS model,
+    e.questions_prompt_version AS prompt_version,
+    (CASE WHEN $1::text IS NULL THEN NULL ELSE date_trunc($1::text, e.created_at) END)::timestamptz AS period_start,
+    COUNT(*) AS evaluations,
+    AVG(e.questions_eval)::float8 AS average_score,
+    MIN(e.questions_eval)::integer AS min_score,
+    MAX(e.questions_eval)::integer AS max_score,
+    MIN(e.created_at)::timestamptz AS first_evaluated_at,
+    MAX(e.created_at)::timestamptz AS last_evaluated_at
+FROM bookmark_evaluations e
+WHERE e.questions_eval IS NOT NULL
+  AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+  AND ($3::timestamptz IS NULL OR e.created_at < $3)
+GROUP BY 2, 3, 4, 5
+ORDER BY 1, 5 NULLS FIRST, 2, 3, 4
+`
+
+type ReportBookmarkEvaluationsParams struct {
+	Period *string            `json:"period"`
+	Since  pgtype.Timestamptz `json:"since"`
+	Until  pgtype.Timestamptz `json:"until"`
+}
+
+type ReportBookmarkEvaluationsRow struct {
+	Kind             string             `json:"kind"`
+	Strategy         *string            `json:"strategy"`
+	Model            *string            `json:"model"`
+	PromptVersion    *string            `json:"prompt_version"`
+	PeriodStart      pgtype.Timestamptz `json:"period_start"`
+	Evaluations      int64              `json:"evaluations"`
+	AverageScore     float64            `json:"average_score"`
+	MinScore         int32              `json:"min_score"`
+	MaxScore         int32              `json:"max_score"`
+	FirstEvaluatedAt pgtype.Timestamptz `json:"first_evaluated_at"`
+	LastEvaluatedAt  pgtype.Timestamptz `json:"last_evaluated_at"`
+}
+
+func (q *Queries) ReportBookmarkEvaluations(ctx context.Context, arg ReportBookmarkEvaluationsParams) ([]ReportBookmarkEvaluationsRow, error) {
+	rows, err := q.db.Query(ctx, reportBookmarkEvaluations, arg.Period, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReportBookmarkEvaluationsRow{}
+	for rows.Next() {
+		var i ReportBookmarkEvaluationsRow
+		if err := rows.Scan(
+			&i.Kind,
+			&i.Strategy,
+			&i.Model,
+			&i.PromptVersion,
+			&i.PeriodStart,
+			&i.Evaluations,
+			&i.AverageScore,
+			&i.MinScore,
+			&i.MaxScore,
+			&i.FirstEvaluatedAt,
+			&i.LastEvaluatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSimilarAnnotations = `-- name: SearchSimilarAnnotations :many
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags,
     b.url,
-    b.creation_date,
-    bt.title
-FROM bookmarks b
-LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
-LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
-WHERE
-    ($1::uuid IS NULL OR bc.category_id = $1)
-    AND ($2::text IS NULL OR bt.title ILIKE '%' || $2 || '%')
-    AND ($3::timestamp IS NULL OR b.creation_date >= $3)
-    AND ($4::timestamp IS NULL OR b.creation_date <= $4)
-ORDER BY b.creation_date DESC
-LIMIT $5
-OFFSET $6
+    bt.title,
+    (1 - (a.embedding <=> $1::vector))::float8 AS similarity
+FROM bookmark_annotations a
+JOIN bookmarks b ON b.bookmark_id = a.bookmark_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = b.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE a.embedding IS NOT NULL
+  AND a.embedding_model = public.active_embedding_model()
+  AND a.embedding_dimensions = vector_dims($1::vector)
+ORDER BY a.embedding <=> $1::vector
+LIMIT $2
 `
 
-type ListBookmarksParams struct {
-	Column1 uuid.UUID        `json:"column_1"`
-	Column2 string           `json:"column_2"`
-	Column3 pgtype.Timestamp `json:"column_3"`
-	Column4 pgtype.Timestamp `json:"column_4"`
-	Limit   int32            `json:"limit"`
-	Offset  int32            `json:"offset"`
+type SearchSimilarAnnotationsParams struct {
+	Embedding *pgvector.Vector `json:"embedding"`
+	RowLimit  int32            `json:"row_limit"`
 }
 
-type ListBookmarksRow struct {
-	BookmarkID   uuid.UUID        `json:"bookmark_id"`
-	Url          string           `json:"url"`
-	CreationDate pgtype.Timestamp `json:"creation_date"`
-	Title        *string          `json:"title"`
+type SearchSimilarAnnotationsRow struct {
+	AnnotationID  uuid.UUID        `json:"annotation_id"`
+	BookmarkID    uuid.UUID        `json:"bookmark_id"`
+	QuoteExact    string           `json:"quote_exact"`
+	QuotePrefix   string           `json:"quote_prefix"`
+	QuoteSuffix   string           `json:"quote_suffix"`
+	PositionStart int32            `json:"position_start"`
+	PositionEnd   int32            `json:"position_end"`
+	Note          *string          `json:"note"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+	Tags          []string         `json:"tags"`
+	Url           string           `json:"url"`
+	Title         *string          `json:"title"`
+	Similarity    float64          `json:"similarity"`
 }
 
-func (q *Queries) ListBookmarks(ctx context.Context, arg ListBookmarksParams) ([]ListBookmarksRow, error) {
-	rows, err := q.db.Query(ctx, listBookmarks,
-		arg.Column1,
-		arg.Column2,
-		arg.Column3,
-		arg.Column4,
-		arg.Limit,
-		arg.Offset,
-	)
+func (q *Queries) SearchSimilarAnnotations(ctx context.Context, arg SearchSimilarAnnotationsParams) ([]SearchSimilarAnnotationsRow, error) {
+	rows, err := q.db.Query(ctx, searchSimilarAnnotations, arg.Embedding, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListBookmarksRow{}
+	items := []SearchSimilarAnnotationsRow{}
 	for rows.Next() {
-		var i ListBookmarksRow
+		var i SearchSimilarAnnotationsRow
 		if err := rows.Scan(
+			&i.AnnotationID,
 			&i.BookmarkID,
+			&i.QuoteExact,
+			&i.QuotePrefix,
+			&i.QuoteSuffix,
+			&i.PositionStart,
+			&i.PositionEnd,
+			&i.Note,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Tags,
 			&i.Url,
-			&i.CreationDate,
 			&i.Title,
+			&i.Similarity,
 		); err != nil {
 			return nil, err
 		}
@@ -551,12 +3428,15 @@ SELECT
     b.url,
     b.creation_date,
     bt.title,
-    bcr_summary.content as summary
+    bcr_summary.content as summary,
+    bcr.extra
 FROM bookmarks b
 INNER JOIN bookmark_content_references bcr ON b.bookmark_id = bcr.bookmark_id
 INNER JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN bookmark_content_references bcr_summary ON b.bookmark_id = bcr_summary.bookmark_id AND bcr_summary.strategy = 'summary-reader'
 WHERE bcr.strategy = $1
+  AND bcr.embedding_model = public.active_embedding_model()
+  AND bcr.embedding_dimensions = vector_dims($2::vector)
 ORDER BY bcr.embedding <=> $2::vector
 LIMIT $3
 `
@@ -573,6 +3453,7 @@ type SearchSimilarBookmarksRow struct {
 	CreationDate pgtype.Timestamp `json:"creation_date"`
 	Title        *string          `json:"title"`
 	Summary      *string          `json:"summary"`
+	Extra        []byte           `json:"extra"`
 }
 
 func (q *Queries) SearchSimilarBookmarks(ctx context.Context, arg SearchSimilarBookmarksParams) ([]SearchSimilarBookmarksRow, error) {
@@ -590,6 +3471,7 @@ func (q *Queries) SearchSimilarBookmarks(ctx context.Context, arg SearchSimilarB
 			&i.CreationDate,
 			&i.Title,
 			&i.Summary,
+			&i.Extra,
 		); err != nil {
 			return nil, err
 		}
@@ -601,19 +3483,141 @@ func (q *Queries) SearchSimilarBookmarks(ctx context.Context, arg SearchSimilarB
 	return items, nil
 }
 
+const setBookmarkAnnotationEmbedding = `-- name: SetBookmarkAnnotationEmbedding :exec
+UPDATE bookmark_annotations
+SET embedding = $1::vector,
+    embedding_model = $2::text
+WHERE annotation_id = $3
+`
+
+type SetBookmarkAnnotationEmbeddingParams struct {
+	Embedding      *pgvector.Vector `json:"embedding"`
+	EmbeddingModel *string          `json:"embedding_model"`
+	AnnotationID   uuid.UUID        `json:"annotation_id"`
+}
+
+func (q *Queries) SetBookmarkAnnotationEmbedding(ctx context.Context, arg SetBookmarkAnnotationEmbeddingParams) error {
+	_, err := q.db.Exec(ctx, setBookmarkAnnotationEmbedding, arg.Embedding, arg.EmbeddingModel, arg.AnnotationID)
+	return err
+}
+
+const unlockArchiveBlob = `-- name: UnlockArchiveBlob :exec
+SELECT pg_advisory_unlock(hashtextextended('archive-blob:' || $1::text, 0))
+`
+
+func (q *Queries) UnlockArchiveBlob(ctx context.Context, hash string) error {
+	_, err := q.db.Exec(ctx, unlockArchiveBlob, hash)
+	return err
+}
+
+const unlockBookmarkQuestions = `-- name: UnlockBookmarkQuestions :exec
+SELECT pg_advisory_unlock(hashtextextended('bookmark-questions:' || $1::uuid::text, 0))
+`
+
+func (q *Queries) UnlockBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, unlockBookmarkQuestions, bookmarkID)
+	return err
+}
+
+const updateBookmarkAnnotation = `-- name: UpdateBookmarkAnnotation :one
+UPDATE bookmark_annotations
+SET
+    quote_exact = $2,
+    quote_prefix = $3,
+    quote_suffix = $4,
+    position_start = $5,
+    position_end = $6,
+    note = $7,
+    updated_at = now()
+WHERE annotation_id = $1
+RETURNING updated_at
+`
+
+type UpdateBookmarkAnnotationParams struct {
+	AnnotationID  uuid.UUID `json:"annotation_id"`
+	QuoteExact    string    `json:"quote_exact"`
+	QuotePrefix   string    `json:"quote_prefix"`
+	QuoteSuffix   string    `json:"quote_suffix"`
+	PositionStart int32     `json:"position_start"`
+	PositionEnd   int32     `json:"position_end"`
+	Note          *string   `json:"note"`
+}
+
+func (q *Queries) UpdateBookmarkAnnotation(ctx context.Context, arg UpdateBookmarkAnnotationParams) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, updateBookmarkAnnotation,
+		arg.AnnotationID,
+		arg.QuoteExact,
+		arg.QuotePrefix,
+		arg.QuoteSuffix,
+		arg.PositionStart,
+		arg.PositionEnd,
+		arg.Note,
+	)
+	var updated_at pgtype.Timestamp
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
+
+const updateBookmarkImport = `-- name: UpdateBookmarkImport :one
+UPDATE bookmark_imports
+SET status = $2,
+    processed = $3,
+    created = $4,
+    duplicates = $5,
+    failed = $6,
+    report = $7,
+    error = $8,
+    finished_at = $9,
+    updated_at = now()
+WHERE import_id = $1
+RETURNING updated_at
+`
+
+type UpdateBookmarkImportParams struct {
+	ImportID   uuid.UUID        `json:"import_id"`
+	Status     string           `json:"status"`
+	Processed  int32            `json:"processed"`
+	Created    int32            `json:"created"`
+	Duplicates int32            `json:"duplicates"`
+	Failed     int32            `json:"failed"`
+	Report     []byte           `json:"report"`
+	Error      *string          `json:"error"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+}
+
+func (q *Queries) UpdateBookmarkImport(ctx context.Context, arg UpdateBookmarkImportParams) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, updateBookmarkImport,
+		arg.ImportID,
+		arg.Status,
+		arg.Processed,
+		arg.Created,
+		arg.Duplicates,
+		arg.Failed,
+		arg.Report,
+		arg.Error,
+		arg.FinishedAt,
+	)
+	var updated_at pgtype.Timestamp
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
+
 const updateBookmarkQuestion = `-- name: UpdateBookmarkQuestion :exec
 UPDATE bookmark_content_references
 SET
     content = $1,
-    embedding = $2::vector
+    embedding = $2::vector,
+    embedding_model = $5,
+    extra = COALESCE(extra, '{}'::jsonb) || '{"edited": true}'::jsonb
 WHERE id = $3 AND bookmark_id = $4
 `
 
 type UpdateBookmarkQuestionParams struct {
-	Content    *string          `json:"content"`
-	Column2    *pgvector.Vector `json:"column_2"`
-	ID         uuid.UUID        `json:"id"`
-	BookmarkID pgtype.UUID      `json:"bookmark_id"`
+	Content        *string          `json:"content"`
+	Column2        *pgvector.Vector `json:"column_2"`
+	ID             uuid.UUID        `json:"id"`
+	BookmarkID     pgtype.UUID      `json:"bookmark_id"`
+	EmbeddingModel *string          `json:"embedding_model"`
 }
 
 func (q *Queries) UpdateBookmarkQuestion(ctx context.Context, arg UpdateBookmarkQuestionParams) error {
@@ -622,6 +3626,129 @@ func (q *Queries) UpdateBookmarkQuestion(ctx context.Context, arg UpdateBookmark
 		arg.Column2,
 		arg.ID,
 		arg.BookmarkID,
+		arg.EmbeddingModel,
 	)
 	return err
 }
+
+const updateBookmarkURL = `-- name: UpdateBookmarkURL :exec
+UPDATE bookmarks
+SET url = $2
+WHERE bookmark_id = $1
+`
+
+type UpdateBookmarkURLParams struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	Url        string    `json:"url"`
+}
+
+func (q *Queries) UpdateBookmarkURL(ctx context.Context, arg UpdateBookmarkURLParams) error {
+	_, err := q.db.Exec(ctx, updateBookmarkURL, arg.BookmarkID, arg.Url)
+	return err
+}
+
+const upsertBookmarkMetadata = `-- name: UpsertBookmarkMetadata :one
+INSERT INTO bookmark_metadata (
+    bookmark_id,
+    canonical_url,
+    title,
+    description,
+    site_name,
+    author,
+    published_at,
+    language,
+    favicon_url,
+    image_url,
+    content_type,
+    properties,
+    json_ld,
+    word_count,
+    reading_time_minutes,
+    extractor,
+    extractor_metadata,
+    updated_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    now()
+)
+ON CONFLICT (bookmark_id) DO UPDATE SET
+    canonical_url = EXCLUDED.canonical_url,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    site_name = EXCLUDED.site_name,
+    author = EXCLUDED.author,
+    published_at = EXCLUDED.published_at,
+    language = EXCLUDED.language,
+    favicon_url = EXCLUDED.favicon_url,
+    image_url = EXCLUDED.image_url,
+    content_type = EXCLUDED.content_type,
+    properties = EXCLUDED.properties,
+    json_ld = EXCLUDED.json_ld,
+    word_count = EXCLUDED.word_count,
+    reading_time_minutes = EXCLUDED.reading_time_minutes,
+    extractor = EXCLUDED.extractor,
+    extractor_metadata = EXCLUDED.extractor_metadata,
+    updated_at = EXCLUDED.updated_at
+RETURNING updated_at
+`
+
+type UpsertBookmarkMetadataParams struct {
+	BookmarkID         uuid.UUID        `json:"bookmark_id"`
+	CanonicalUrl       *string          `json:"canonical_url"`
+	Title              *string          `json:"title"`
+	Description        *string          `json:"description"`
+	SiteName           *string          `json:"site_name"`
+	Author             *string          `json:"author"`
+	PublishedAt        pgtype.Timestamp `json:"published_at"`
+	Language           *string          `json:"language"`
+	FaviconUrl         *string          `json:"favicon_url"`
+	ImageUrl           *string          `json:"image_url"`
+	ContentType        *string          `json:"content_type"`
+	Properties         []byte           `json:"properties"`
+	JsonLd             []byte           `json:"json_ld"`
+	WordCount          int32            `json:"word_count"`
+	ReadingTimeMinutes int32            `json:"reading_time_minutes"`
+	Extractor          *string          `json:"extractor"`
+	ExtractorMetadata  []byte           `json:"extractor_metadata"`
+}
+
+func (q *Queries) UpsertBookmarkMetadata(ctx context.Context, arg UpsertBookmarkMetadataParams) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, upsertBookmarkMetadata,
+		arg.BookmarkID,
+		arg.CanonicalUrl,
+		arg.Title,
+		arg.Description,
+		arg.SiteName,
+		arg.Author,
+		arg.PublishedAt,
+		arg.Language,
+		arg.FaviconUrl,
+		arg.ImageUrl,
+		arg.ContentType,
+		arg.Properties,
+		arg.JsonLd,
+		arg.WordCount,
+		arg.ReadingTimeMinutes,
+		arg.Extractor,
+		arg.ExtractorMetadata,
+	)
+	var updated_at pgtype.Timestamp
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
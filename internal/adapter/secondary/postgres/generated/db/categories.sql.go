@@ -10,8 +10,40 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pgvector/pgvector-go"
 )
 
+const countCategorySuggestions = `-- name: CountCategorySuggestions :one
+SELECT COUNT(*)
+FROM bookmark_category_suggestions
+WHERE status = $1
+`
+
+func (q *Queries) CountCategorySuggestions(ctx context.Context, status string) (int64, error) {
+	row := q.db.QueryRow(ctx, countCategorySuggestions, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (name, parent_id)
+VALUES ($1, $2::uuid)
+RETURNING category_id, name, parent_id
+`
+
+type CreateCategoryParams struct {
+	Name     string      `json:"name"`
+	ParentID pgtype.UUID `json:"parent_id"`
+}
+
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.db.QueryRow(ctx, createCategory, arg.Name, arg.ParentID)
+	var i Category
+	err := row.Scan(&i.CategoryID, &i.Name, &i.ParentID)
+	return i, err
+}
+
 const createCategorySource = `-- name: CreateCategorySource :one
 INSERT INTO category_sources (
     category_id,
@@ -40,6 +72,16 @@ func (q *Queries) CreateCategorySource(ctx context.Context, arg CreateCategorySo
 	return i, err
 }
 
+const deleteCategoryProfile = `-- name: DeleteCategoryProfile :exec
+DELETE FROM category_profiles
+WHERE category_id = $1
+`
+
+func (q *Queries) DeleteCategoryProfile(ctx context.Context, categoryID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCategoryProfile, categoryID)
+	return err
+}
+
 const deleteCategorySource = `-- name: DeleteCategorySource :exec
 DELETE FROM category_sources
 WHERE id = $1
@@ -50,10 +92,22 @@ func (q *Queries) DeleteCategorySource(ctx context.Context, id uuid.UUID) error
 	return err
 }
 
+const deletePendingCategorySuggestions = `-- name: DeletePendingCategorySuggestions :exec
+DELETE FROM bookmark_category_suggestions
+WHERE bookmark_id = $1
+  AND status = 'pending'
+`
+
+func (q *Queries) DeletePendingCategorySuggestions(ctx context.Context, bookmarkID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deletePendingCategorySuggestions, bookmarkID)
+	return err
+}
+
 const getCategory = `-- name: GetCategory :one
 SELECT
     category_id,
-    name
+    name,
+    parent_id
 FROM categories
 WHERE category_id = $1
 `
@@ -61,7 +115,31 @@ WHERE category_id = $1
 func (q *Queries) GetCategory(ctx context.Context, categoryID uuid.UUID) (Category, error) {
 	row := q.db.QueryRow(ctx, getCategory, categoryID)
 	var i Category
-	err := row.Scan(&i.CategoryID, &i.Name)
+	err := row.Scan(&i.CategoryID, &i.Name, &i.ParentID)
+	return i, err
+}
+
+const getCategoryByName = `-- name: GetCategoryByName :one
+SELECT
+    category_id,
+    name,
+    parent_id
+FROM categories
+WHERE lower(name) = lower($1)
+  AND parent_id IS NOT DISTINCT FROM $2::uuid
+ORDER BY name
+LIMIT 1
+`
+
+type GetCategoryByNameParams struct {
+	Name     string      `json:"name"`
+	ParentID pgtype.UUID `json:"parent_id"`
+}
+
+func (q *Queries) GetCategoryByName(ctx context.Context, arg GetCategoryByNameParams) (Category, error) {
+	row := q.db.QueryRow(ctx, getCategoryByName, arg.Name, arg.ParentID)
+	var i Category
+	err := row.Scan(&i.CategoryID, &i.Name, &i.ParentID)
 	return i, err
 }
 
@@ -87,10 +165,162 @@ func (q *Queries) GetCategorySource(ctx context.Context, id uuid.UUID) (Category
 	return i, err
 }
 
+const getCategorySubtreeIDs = `-- name: GetCategorySubtreeIDs :many
+WITH RECURSIVE subtree AS (
+    SELECT category_id
+    FROM categories
+    WHERE category_id = $1::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+SELECT category_id FROM subtree
+`
+
+// The category and all of its descendants
+func (q *Queries) GetCategorySubtreeIDs(ctx context.Context, categoryID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getCategorySubtreeIDs, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var category_id uuid.UUID
+		if err := rows.Scan(&category_id); err != nil {
+			return nil, err
+		}
+		items = append(items, category_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategorySuggestion = `-- name: GetCategorySuggestion :one
+SELECT
+    s.suggestion_id,
+    s.bookmark_id,
+    b.url,
+    bt.title,
+    s.category_id,
+    c.name AS category_name,
+    s.confidence,
+    s.status,
+    s.created_at,
+    s.reviewed_at
+FROM bookmark_category_suggestions s
+JOIN bookmarks b ON b.bookmark_id = s.bookmark_id
+JOIN categories c ON c.category_id = s.category_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = s.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE s.suggestion_id = $1
+`
+
+type GetCategorySuggestionRow struct {
+	SuggestionID uuid.UUID        `json:"suggestion_id"`
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Url          string           `json:"url"`
+	Title        *string          `json:"title"`
+	CategoryID   uuid.UUID        `json:"category_id"`
+	CategoryName string           `json:"category_name"`
+	Confidence   float64          `json:"confidence"`
+	Status       string           `json:"status"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	ReviewedAt   pgtype.Timestamp `json:"reviewed_at"`
+}
+
+func (q *Queries) GetCategorySuggestion(ctx context.Context, suggestionID uuid.UUID) (GetCategorySuggestionRow, error) {
+	row := q.db.QueryRow(ctx, getCategorySuggestion, suggestionID)
+	var i GetCategorySuggestionRow
+	err := row.Scan(
+		&i.SuggestionID,
+		&i.BookmarkID,
+		&i.Url,
+		&i.Title,
+		&i.CategoryID,
+		&i.CategoryName,
+		&i.Confidence,
+		&i.Status,
+		&i.CreatedAt,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
+
+const listBookmarksToCategorize = `-- name: ListBookmarksToCategorize :many
+SELECT b.bookmark_id
+FROM bookmarks b
+WHERE NOT EXISTS (SELECT 1 FROM bookmark_category bc WHERE bc.bookmark_id = b.bookmark_id)
+  AND EXISTS (
+      SELECT 1 FROM bookmark_content_references bcr
+      WHERE bcr.bookmark_id = b.bookmark_id
+        AND bcr.strategy = 'summary-reader'
+        AND bcr.embedding IS NOT NULL
+        AND bcr.embedding_model = public.active_embedding_model()
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM bookmark_category_suggestions s
+      WHERE s.bookmark_id = b.bookmark_id
+        AND s.status IN ('pending', 'accepted', 'auto')
+  )
+ORDER BY b.creation_date DESC
+LIMIT $1
+`
+
+// Uncategorized bookmarks with a summary embedding and no suggestion awaiting review
+func (q *Queries) ListBookmarksToCategorize(ctx context.Context, rowLimit int32) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listBookmarksToCategorize, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var bookmark_id uuid.UUID
+		if err := rows.Scan(&bookmark_id); err != nil {
+			return nil, err
+		}
+		items = append(items, bookmark_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCategoriesWithSources = `-- name: ListCategoriesWithSources :many
+WITH RECURSIVE tree AS (
+    SELECT category_id AS root_id, category_id
+    FROM categories
+    UNION
+    SELECT t.root_id, child.category_id
+    FROM categories child
+    JOIN tree t ON child.parent_id = t.category_id
+),
+direct_counts AS (
+    SELECT category_id, COUNT(DISTINCT bookmark_id) AS bookmarks
+    FROM bookmark_category
+    GROUP BY category_id
+),
+subtree_counts AS (
+    SELECT t.root_id, COUNT(DISTINCT bc.bookmark_id) AS bookmarks
+    FROM tree t
+    JOIN bookmark_category bc ON bc.category_id = t.category_id
+    GROUP BY t.root_id
+)
 SELECT
     c.category_id,
     c.name,
+    c.parent_id,
+    COALESCE(dc.bookmarks, 0)::bigint AS bookmark_count,
+    COALESCE(sc.bookmarks, 0)::bigint AS subtree_bookmark_count,
     COALESCE(
         json_agg(
             json_build_object(
@@ -104,16 +334,22 @@ SELECT
     ) as sources
 FROM categories c
 LEFT JOIN category_sources s ON c.category_id = s.category_id
-GROUP BY c.category_id, c.name
+LEFT JOIN direct_counts dc ON dc.category_id = c.category_id
+LEFT JOIN subtree_counts sc ON sc.root_id = c.category_id
+GROUP BY c.category_id, c.name, c.parent_id, dc.bookmarks, sc.bookmarks
 ORDER BY c.name
 `
 
 type ListCategoriesWithSourcesRow struct {
-	CategoryID uuid.UUID   `json:"category_id"`
-	Name       string      `json:"name"`
-	Sources    interface{} `json:"sources"`
+	CategoryID           uuid.UUID   `json:"category_id"`
+	Name                 string      `json:"name"`
+	ParentID             pgtype.UUID `json:"parent_id"`
+	BookmarkCount        int64       `json:"bookmark_count"`
+	SubtreeBookmarkCount int64       `json:"subtree_bookmark_count"`
+	Sources              interface{} `json:"sources"`
 }
 
+// Counts the bookmarks filed directly under each category and anywhere in its subtree
 func (q *Queries) ListCategoriesWithSources(ctx context.Context) ([]ListCategoriesWithSourcesRow, error) {
 	rows, err := q.db.Query(ctx, listCategoriesWithSources)
 	if err != nil {
@@ -123,7 +359,61 @@ func (q *Queries) ListCategoriesWithSources(ctx context.Context) ([]ListCategori
 	items := []ListCategoriesWithSourcesRow{}
 	for rows.Next() {
 		var i ListCategoriesWithSourcesRow
-		if err := rows.Scan(&i.CategoryID, &i.Name, &i.Sources); err != nil {
+		if err := rows.Scan(
+			&i.CategoryID,
+			&i.Name,
+			&i.ParentID,
+			&i.BookmarkCount,
+			&i.SubtreeBookmarkCount,
+			&i.Sources,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryProfiles = `-- name: ListCategoryProfiles :many
+SELECT
+    p.category_id,
+    c.name,
+    p.source_text,
+    p.bookmark_count,
+    p.updated_at
+FROM category_profiles p
+JOIN categories c ON c.category_id = p.category_id
+WHERE p.embedding_model = public.active_embedding_model()
+ORDER BY c.name
+`
+
+type ListCategoryProfilesRow struct {
+	CategoryID    uuid.UUID        `json:"category_id"`
+	Name          string           `json:"name"`
+	SourceText    string           `json:"source_text"`
+	BookmarkCount int32            `json:"bookmark_count"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) ListCategoryProfiles(ctx context.Context) ([]ListCategoryProfilesRow, error) {
+	rows, err := q.db.Query(ctx, listCategoryProfiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListCategoryProfilesRow{}
+	for rows.Next() {
+		var i ListCategoryProfilesRow
+		if err := rows.Scan(
+			&i.CategoryID,
+			&i.Name,
+			&i.SourceText,
+			&i.BookmarkCount,
+			&i.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -134,6 +424,93 @@ func (q *Queries) ListCategoriesWithSources(ctx context.Context) ([]ListCategori
 	return items, nil
 }
 
+const listCategorySuggestions = `-- name: ListCategorySuggestions :many
+SELECT
+    s.suggestion_id,
+    s.bookmark_id,
+    b.url,
+    bt.title,
+    s.category_id,
+    c.name AS category_name,
+    s.confidence,
+    s.status,
+    s.created_at,
+    s.reviewed_at
+FROM bookmark_category_suggestions s
+JOIN bookmarks b ON b.bookmark_id = s.bookmark_id
+JOIN categories c ON c.category_id = s.category_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = s.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE s.status = $1
+ORDER BY s.created_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListCategorySuggestionsParams struct {
+	Status    string `json:"status"`
+	RowOffset int32  `json:"row_offset"`
+	RowLimit  int32  `json:"row_limit"`
+}
+
+type ListCategorySuggestionsRow struct {
+	SuggestionID uuid.UUID        `json:"suggestion_id"`
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Url          string           `json:"url"`
+	Title        *string          `json:"title"`
+	CategoryID   uuid.UUID        `json:"category_id"`
+	CategoryName string           `json:"category_name"`
+	Confidence   float64          `json:"confidence"`
+	Status       string           `json:"status"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	ReviewedAt   pgtype.Timestamp `json:"reviewed_at"`
+}
+
+func (q *Queries) ListCategorySuggestions(ctx context.Context, arg ListCategorySuggestionsParams) ([]ListCategorySuggestionsRow, error) {
+	rows, err := q.db.Query(ctx, listCategorySuggestions, arg.Status, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListCategorySuggestionsRow{}
+	for rows.Next() {
+		var i ListCategorySuggestionsRow
+		if err := rows.Scan(
+			&i.SuggestionID,
+			&i.BookmarkID,
+			&i.Url,
+			&i.Title,
+			&i.CategoryID,
+			&i.CategoryName,
+			&i.Confidence,
+			&i.Status,
+			&i.CreatedAt,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockCategoryTree = `-- name: LockCategoryTree :exec
+SELECT pg_advisory_xact_lock(hashtextextended('category-tree', 0))
+`
+
+// Held until the end of the transaction by every change of parents, so that two concurrent moves cannot each
+// pass the cycle check against a tree the other is changing
+func (q *Queries) LockCategoryTree(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockCategoryTree)
+	return err
+}
+
 const mergeCategories = `-- name: MergeCategories :exec
 CALL merge_categories($1::uuid, $2::uuid)
 `
@@ -148,6 +525,236 @@ func (q *Queries) MergeCategories(ctx context.Context, arg MergeCategoriesParams
 	return err
 }
 
+const moveCategory = `-- name: MoveCategory :one
+WITH RECURSIVE subtree AS (
+    SELECT category_id
+    FROM categories
+    WHERE category_id = $2::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+UPDATE categories
+SET parent_id = $1::uuid
+WHERE category_id = $2::uuid
+  AND ($1::uuid IS NULL
+       OR $1::uuid NOT IN (SELECT category_id FROM subtree))
+RETURNING category_id
+`
+
+type MoveCategoryParams struct {
+	ParentID   pgtype.UUID `json:"parent_id"`
+	CategoryID uuid.UUID   `json:"category_id"`
+}
+
+// Moves a category under a new parent, or to the top level for a NULL parent. Nothing is updated when the
+// parent is the category itself or one of its descendants
+func (q *Queries) MoveCategory(ctx context.Context, arg MoveCategoryParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, moveCategory, arg.ParentID, arg.CategoryID)
+	var category_id uuid.UUID
+	err := row.Scan(&category_id)
+	return category_id, err
+}
+
+const rankCategoryProfiles = `-- name: RankCategoryProfiles :many
+WITH target AS (
+    SELECT embedding
+    FROM bookmark_content_references
+    WHERE bookmark_id = $1::uuid
+      AND strategy = 'summary-reader'
+      AND embedding IS NOT NULL
+      AND embedding_model = public.active_embedding_model()
+    ORDER BY created_at DESC
+    LIMIT 1
+)
+SELECT
+    p.category_id,
+    c.name,
+    (1 - (p.embedding <=> t.embedding))::float8 AS similarity
+FROM category_profiles p
+JOIN categories c ON c.category_id = p.category_id
+CROSS JOIN target t
+WHERE p.embedding_model = public.active_embedding_model()
+  AND NOT EXISTS (
+    SELECT 1 FROM bookmark_category_suggestions s
+    WHERE s.bookmark_id = $1::uuid
+      AND s.category_id = p.category_id
+      AND s.status = 'rejected'
+)
+ORDER BY p.embedding <=> t.embedding
+LIMIT $2
+`
+
+type RankCategoryProfilesParams struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	RowLimit   int32     `json:"row_limit"`
+}
+
+type RankCategoryProfilesRow struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	Similarity float64   `json:"similarity"`
+}
+
+// Ranks category profiles by similarity to the latest summary embedding of the bookmark, leaving out the
+// categories already rejected for it
+func (q *Queries) RankCategoryProfiles(ctx context.Context, arg RankCategoryProfilesParams) ([]RankCategoryProfilesRow, error) {
+	rows, err := q.db.Query(ctx, rankCategoryProfiles, arg.BookmarkID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RankCategoryProfilesRow{}
+	for rows.Next() {
+		var i RankCategoryProfilesRow
+		if err := rows.Scan(&i.CategoryID, &i.Name, &i.Similarity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refreshCategoryProfile = `-- name: RefreshCategoryProfile :one
+WITH members AS (
+    SELECT DISTINCT ON (bcr.bookmark_id) bcr.embedding
+    FROM bookmark_category bc
+    JOIN bookmark_content_references bcr ON bcr.bookmark_id = bc.bookmark_id
+    WHERE bc.category_id = $1::uuid
+      AND bcr.strategy = 'summary-reader'
+      AND bcr.embedding IS NOT NULL
+      AND bcr.embedding_model = $2::text
+    ORDER BY bcr.bookmark_id, bcr.created_at DESC
+),
+source AS (
+    SELECT COALESCE(
+        $4::vector,
+        (
+            SELECT p.source_embedding FROM category_profiles p
+            WHERE p.category_id = $1::uuid AND p.embedding_model = $2::text
+        )
+    ) AS embedding
+),
+vectors AS (
+    SELECT embedding FROM members
+    UNION ALL
+    SELECT embedding FROM source WHERE embedding IS NOT NULL
+)
+INSERT INTO category_profiles (category_id, embedding, embedding_model, source_text, source_embedding, bookmark_count, updated_at)
+SELECT
+    $1::uuid,
+    avg(vectors.embedding),
+    $2::text,
+    $3::text,
+    (SELECT embedding FROM source),
+    (SELECT count(*) FROM members)::integer,
+    now()
+FROM vectors
+HAVING count(*) > 0
+ON CONFLICT (category_id) DO UPDATE SET
+    embedding = EXCLUDED.embedding,
+    embedding_model = EXCLUDED.embedding_model,
+    source_text = EXCLUDED.source_text,
+    source_embedding = EXCLUDED.source_embedding,
+    bookmark_count = EXCLUDED.bookmark_count,
+    updated_at = EXCLUDED.updated_at
+RETURNING category_id, source_text, bookmark_count, updated_at
+`
+
+type RefreshCategoryProfileParams struct {
+	CategoryID      uuid.UUID        `json:"category_id"`
+	EmbeddingModel  *string          `json:"embedding_model"`
+	SourceText      string           `json:"source_text"`
+	SourceEmbedding *pgvector.Vector `json:"source_embedding"`
+}
+
+type RefreshCategoryProfileRow struct {
+	CategoryID    uuid.UUID        `json:"category_id"`
+	SourceText    string           `json:"source_text"`
+	BookmarkCount int32            `json:"bookmark_count"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+// The profile is the mean of the latest summary embedding of every bookmark in the category and of the
+// embedding of its name and sources, all made by the given model. A NULL source embedding keeps the stored one
+// unless it was made by another model
+func (q *Queries) RefreshCategoryProfile(ctx context.Context, arg RefreshCategoryProfileParams) (RefreshCategoryProfileRow, error) {
+	row := q.db.QueryRow(ctx, refreshCategoryProfile,
+		arg.CategoryID,
+		arg.EmbeddingModel,
+		arg.SourceText,
+		arg.SourceEmbedding,
+	)
+	var i RefreshCategoryProfileRow
+	err := row.Scan(
+		&i.CategoryID,
+		&i.SourceText,
+		&i.BookmarkCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const reopenCategorySuggestion = `-- name: ReopenCategorySuggestion :exec
+UPDATE bookmark_category_suggestions
+SET status = 'pending',
+    reviewed_at = NULL
+WHERE suggestion_id = $1
+`
+
+func (q *Queries) ReopenCategorySuggestion(ctx context.Context, suggestionID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, reopenCategorySuggestion, suggestionID)
+	return err
+}
+
+const reparentCategoryChildren = `-- name: ReparentCategoryChildren :many
+WITH RECURSIVE subtree AS (
+    SELECT category_id
+    FROM categories
+    WHERE category_id = $2::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+UPDATE categories
+SET parent_id = $1::uuid
+WHERE parent_id = $2::uuid
+  AND ($1::uuid IS NULL
+       OR $1::uuid NOT IN (SELECT category_id FROM subtree))
+RETURNING category_id
+`
+
+type ReparentCategoryChildrenParams struct {
+	ParentID   pgtype.UUID `json:"parent_id"`
+	CategoryID uuid.UUID   `json:"category_id"`
+}
+
+// Moves the children of a category under a new parent, unless that parent lies within the category's subtree
+func (q *Queries) ReparentCategoryChildren(ctx context.Context, arg ReparentCategoryChildrenParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, reparentCategoryChildren, arg.ParentID, arg.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var category_id uuid.UUID
+		if err := rows.Scan(&category_id); err != nil {
+			return nil, err
+		}
+		items = append(items, category_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateCategory = `-- name: UpdateCategory :exec
 UPDATE categories
 SET name = $2
@@ -182,3 +789,60 @@ func (q *Queries) UpdateCategorySource(ctx context.Context, arg UpdateCategorySo
 	_, err := q.db.Exec(ctx, updateCategorySource, arg.ID, arg.SourceUri, arg.RawSource)
 	return err
 }
+
+const updateCategorySuggestionStatus = `-- name: UpdateCategorySuggestionStatus :execrows
+UPDATE bookmark_category_suggestions
+SET status = $1,
+    reviewed_at = now()
+WHERE suggestion_id = $2
+  AND status = 'pending'
+`
+
+type UpdateCategorySuggestionStatusParams struct {
+	Status       string    `json:"status"`
+	SuggestionID uuid.UUID `json:"suggestion_id"`
+}
+
+func (q *Queries) UpdateCategorySuggestionStatus(ctx context.Context, arg UpdateCategorySuggestionStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateCategorySuggestionStatus, arg.Status, arg.SuggestionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertCategorySuggestion = `-- name: UpsertCategorySuggestion :one
+INSERT INTO bookmark_category_suggestions (bookmark_id, category_id, confidence, status, reviewed_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    CASE WHEN $4::text = 'pending' THEN NULL ELSE now() END
+)
+ON CONFLICT (bookmark_id, category_id) DO UPDATE SET
+    confidence = EXCLUDED.confidence,
+    status = EXCLUDED.status,
+    created_at = now(),
+    reviewed_at = EXCLUDED.reviewed_at
+RETURNING suggestion_id
+`
+
+type UpsertCategorySuggestionParams struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Confidence float64   `json:"confidence"`
+	Status     string    `json:"status"`
+}
+
+func (q *Queries) UpsertCategorySuggestion(ctx context.Context, arg UpsertCategorySuggestionParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, upsertCategorySuggestion,
+		arg.BookmarkID,
+		arg.CategoryID,
+		arg.Confidence,
+		arg.Status,
+	)
+	var suggestion_id uuid.UUID
+	err := row.Scan(&suggestion_id)
+	return suggestion_id, err
+}
@@ -0,0 +1,712 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: embeddings.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pgvector/pgvector-go"
+)
+
+const activateEmbeddingModel = `-- name: ActivateEmbeddingModel :exec
+INSERT INTO embedding_models (name, active, activated_at)
+VALUES ($1, true, now())
+ON CONFLICT (name) DO UPDATE SET
+    active = true,
+    activated_at = now()
+WHERE NOT embedding_models.active
+`
+
+func (q *Queries) ActivateEmbeddingModel(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, activateEmbeddingModel, name)
+	return err
+}
+
+const applyStagedAnnotationEmbeddings = `-- name: ApplyStagedAnnotationEmbeddings :execrows
+UPDATE bookmark_annotations t
+SET embedding = v.embedding, embedding_model = $1::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = $2
+  AND v.table_name = 'bookmark_annotations'
+  AND t.annotation_id = v.row_id
+`
+
+type ApplyStagedAnnotationEmbeddingsParams struct {
+	TargetModel string    `json:"target_model"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+func (q *Queries) ApplyStagedAnnotationEmbeddings(ctx context.Context, arg ApplyStagedAnnotationEmbeddingsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, applyStagedAnnotationEmbeddings, arg.TargetModel, arg.MigrationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const applyStagedContentReferenceEmbeddings = `-- name: ApplyStagedContentReferenceEmbeddings :execrows
+UPDATE bookmark_content_references t
+SET embedding = v.embedding, embedding_model = $1::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = $2
+  AND v.table_name = 'bookmark_content_references'
+  AND t.id = v.row_id
+`
+
+type ApplyStagedContentReferenceEmbeddingsParams struct {
+	TargetModel string    `json:"target_model"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+// Staged vectors only replace the stored ones while the text they were made from is unchanged
+func (q *Queries) ApplyStagedContentReferenceEmbeddings(ctx context.Context, arg ApplyStagedContentReferenceEmbeddingsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, applyStagedContentReferenceEmbeddings, arg.TargetModel, arg.MigrationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const applyStagedItemEmbeddings = `-- name: ApplyStagedItemEmbeddings :execrows
+UPDATE item_semantic_index t
+SET embedding = v.embedding, embedding_model = $1::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = $2
+  AND v.table_name = 'item_semantic_index'
+  AND t.id = v.row_id
+`
+
+type ApplyStagedItemEmbeddingsParams struct {
+	TargetModel string    `json:"target_model"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+func (q *Queries) ApplyStagedItemEmbeddings(ctx context.Context, arg ApplyStagedItemEmbeddingsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, applyStagedItemEmbeddings, arg.TargetModel, arg.MigrationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const applyStagedSessionSummaryEmbeddings = `-- name: ApplyStagedSessionSummaryEmbeddings :execrows
+UPDATE session_summaries t
+SET embedding = v.embedding, embedding_model = $1::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = $2
+  AND v.table_name = 'session_summaries'
+  AND t.id = v.row_id
+`
+
+type ApplyStagedSessionSummaryEmbeddingsParams struct {
+	TargetModel string    `json:"target_model"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+func (q *Queries) ApplyStagedSessionSummaryEmbeddings(ctx context.Context, arg ApplyStagedSessionSummaryEmbeddingsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, applyStagedSessionSummaryEmbeddings, arg.TargetModel, arg.MigrationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimUntaggedEmbeddings = `-- name: ClaimUntaggedEmbeddings :one
+WITH refs AS (
+    UPDATE bookmark_content_references SET embedding_model = $1::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+annotations AS (
+    UPDATE bookmark_annotations SET embedding_model = $1::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+sessions AS (
+    UPDATE session_summaries SET embedding_model = $1::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+items AS (
+    UPDATE item_semantic_index SET embedding_model = $1::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+profiles AS (
+    UPDATE category_profiles SET embedding_model = $1::text
+    WHERE embedding_model IS NULL
+    RETURNING 1
+)
+SELECT (
+    (SELECT count(*) FROM refs) +
+    (SELECT count(*) FROM annotations) +
+    (SELECT count(*) FROM sessions) +
+    (SELECT count(*) FROM items) +
+    (SELECT count(*) FROM profiles)
+)::bigint AS claimed
+`
+
+// Vectors stored before their model was recorded are taken to be of the given model
+func (q *Queries) ClaimUntaggedEmbeddings(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, claimUntaggedEmbeddings, name)
+	var claimed int64
+	err := row.Scan(&claimed)
+	return claimed, err
+}
+
+const countEmbeddingMigrationRows = `-- name: CountEmbeddingMigrationRows :one
+SELECT count(*)::bigint
+FROM embedding_corpus c
+WHERE c.embedding_model IS DISTINCT FROM $1::text
+  AND NOT EXISTS (
+      SELECT 1 FROM embedding_migration_vectors v
+      WHERE v.migration_id = $2
+        AND v.table_name = c.table_name
+        AND v.row_id = c.row_id
+        AND v.source_hash = c.source_hash
+  )
+`
+
+type CountEmbeddingMigrationRowsParams struct {
+	TargetModel string    `json:"target_model"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+// Rows of the corpus still to be embedded with the target model: those of another model without a staged
+// vector made from their current text
+func (q *Queries) CountEmbeddingMigrationRows(ctx context.Context, arg CountEmbeddingMigrationRowsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEmbeddingMigrationRows, arg.TargetModel, arg.MigrationID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createEmbeddingMigration = `-- name: CreateEmbeddingMigration :one
+INSERT INTO embedding_migrations (
+    source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, remaining
+)
+VALUES (
+    public.active_embedding_model(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING migration_id, source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, status, done, remaining, error, started_at, updated_at, finished_at
+`
+
+type CreateEmbeddingMigrationParams struct {
+	TargetProvider   string `json:"target_provider"`
+	TargetUrl        string `json:"target_url"`
+	TargetModel      string `json:"target_model"`
+	TargetDimensions int32  `json:"target_dimensions"`
+	TargetApiKey     string `json:"target_api_key"`
+	Remaining        int32  `json:"remaining"`
+}
+
+func (q *Queries) CreateEmbeddingMigration(ctx context.Context, arg CreateEmbeddingMigrationParams) (EmbeddingMigration, error) {
+	row := q.db.QueryRow(ctx, createEmbeddingMigration,
+		arg.TargetProvider,
+		arg.TargetUrl,
+		arg.TargetModel,
+		arg.TargetDimensions,
+		arg.TargetApiKey,
+		arg.Remaining,
+	)
+	var i EmbeddingMigration
+	err := row.Scan(
+		&i.MigrationID,
+		&i.SourceModel,
+		&i.TargetProvider,
+		&i.TargetUrl,
+		&i.TargetModel,
+		&i.TargetDimensions,
+		&i.TargetApiKey,
+		&i.Status,
+		&i.Done,
+		&i.Remaining,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const deactivateEmbeddingModels = `-- name: DeactivateEmbeddingModels :exec
+UPDATE embedding_models
+SET active = false
+WHERE active AND name <> $1
+`
+
+func (q *Queries) DeactivateEmbeddingModels(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, deactivateEmbeddingModels, name)
+	return err
+}
+
+const deleteEmbeddingMigrationVectors = `-- name: DeleteEmbeddingMigrationVectors :exec
+DELETE FROM embedding_migration_vectors
+WHERE migration_id = $1
+`
+
+func (q *Queries) DeleteEmbeddingMigrationVectors(ctx context.Context, migrationID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEmbeddingMigrationVectors, migrationID)
+	return err
+}
+
+const evictExcessCachedEmbeddings = `-- name: EvictExcessCachedEmbeddings :execrows
+DELETE FROM embedding_cache
+WHERE cache_key IN (
+    SELECT cache_key FROM embedding_cache
+    ORDER BY last_used_at DESC
+    OFFSET $1
+)
+`
+
+// Keeps the max_entries most recently used vectors
+func (q *Queries) EvictExcessCachedEmbeddings(ctx context.Context, maxEntries int32) (int64, error) {
+	result, err := q.db.Exec(ctx, evictExcessCachedEmbeddings, maxEntries)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const evictUnusedCachedEmbeddings = `-- name: EvictUnusedCachedEmbeddings :execrows
+DELETE FROM embedding_cache
+WHERE last_used_at < $1
+`
+
+func (q *Queries) EvictUnusedCachedEmbeddings(ctx context.Context, usedBefore pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, evictUnusedCachedEmbeddings, usedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const finishEmbeddingMigration = `-- name: FinishEmbeddingMigration :one
+UPDATE embedding_migrations
+SET
+    status = $1::text,
+    remaining = $2::integer,
+    error = NULL,
+    updated_at = now(),
+    finished_at = now()
+WHERE migration_id = $3 AND status IN ('running', 'ready')
+RETURNING migration_id, source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, status, done, remaining, error, started_at, updated_at, finished_at
+`
+
+type FinishEmbeddingMigrationParams struct {
+	Status      string    `json:"status"`
+	Remaining   int32     `json:"remaining"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+func (q *Queries) FinishEmbeddingMigration(ctx context.Context, arg FinishEmbeddingMigrationParams) (EmbeddingMigration, error) {
+	row := q.db.QueryRow(ctx, finishEmbeddingMigration, arg.Status, arg.Remaining, arg.MigrationID)
+	var i EmbeddingMigration
+	err := row.Scan(
+		&i.MigrationID,
+		&i.SourceModel,
+		&i.TargetProvider,
+		&i.TargetUrl,
+		&i.TargetModel,
+		&i.TargetDimensions,
+		&i.TargetApiKey,
+		&i.Status,
+		&i.Done,
+		&i.Remaining,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getActiveEmbeddingModel = `-- name: GetActiveEmbeddingModel :one
+SELECT name
+FROM embedding_models
+WHERE active
+`
+
+func (q *Queries) GetActiveEmbeddingModel(ctx context.Context) (string, error) {
+	row := q.db.QueryRow(ctx, getActiveEmbeddingModel)
+	var name string
+	err := row.Scan(&name)
+	return name, err
+}
+
+const getEmbeddingCacheUsage = `-- name: GetEmbeddingCacheUsage :one
+SELECT
+    count(*)::bigint AS entries,
+    COALESCE(sum(hits), 0)::bigint AS hits,
+    min(last_used_at)::timestamp AS oldest_use
+FROM embedding_cache
+`
+
+type GetEmbeddingCacheUsageRow struct {
+	Entries   int64            `json:"entries"`
+	Hits      int64            `json:"hits"`
+	OldestUse pgtype.Timestamp `json:"oldest_use"`
+}
+
+func (q *Queries) GetEmbeddingCacheUsage(ctx context.Context) (GetEmbeddingCacheUsageRow, error) {
+	row := q.db.QueryRow(ctx, getEmbeddingCacheUsage)
+	var i GetEmbeddingCacheUsageRow
+	err := row.Scan(&i.Entries, &i.Hits, &i.OldestUse)
+	return i, err
+}
+
+const getEmbeddingMigration = `-- name: GetEmbeddingMigration :one
+SELECT migration_id, source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, status, done, remaining, error, started_at, updated_at, finished_at
+FROM embedding_migrations
+WHERE migration_id = $1
+`
+
+func (q *Queries) GetEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (EmbeddingMigration, error) {
+	row := q.db.QueryRow(ctx, getEmbeddingMigration, migrationID)
+	var i EmbeddingMigration
+	err := row.Scan(
+		&i.MigrationID,
+		&i.SourceModel,
+		&i.TargetProvider,
+		&i.TargetUrl,
+		&i.TargetModel,
+		&i.TargetDimensions,
+		&i.TargetApiKey,
+		&i.Status,
+		&i.Done,
+		&i.Remaining,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getOpenEmbeddingMigration = `-- name: GetOpenEmbeddingMigration :one
+SELECT migration_id, source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, status, done, remaining, error, started_at, updated_at, finished_at
+FROM embedding_migrations
+WHERE status IN ('running', 'ready')
+ORDER BY started_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetOpenEmbeddingMigration(ctx context.Context) (EmbeddingMigration, error) {
+	row := q.db.QueryRow(ctx, getOpenEmbeddingMigration)
+	var i EmbeddingMigration
+	err := row.Scan(
+		&i.MigrationID,
+		&i.SourceModel,
+		&i.TargetProvider,
+		&i.TargetUrl,
+		&i.TargetModel,
+		&i.TargetDimensions,
+		&i.TargetApiKey,
+		&i.Status,
+		&i.Done,
+		&i.Remaining,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const listEmbeddingMigrationRows = `-- name: ListEmbeddingMigrationRows :many
+SELECT c.table_name, c.row_id, c.text, c.extra_text, c.source_hash
+FROM embedding_corpus c
+WHERE c.embedding_model IS DISTINCT FROM $1::text
+  AND NOT EXISTS (
+      SELECT 1 FROM embedding_migration_vectors v
+      WHERE v.migration_id = $2
+        AND v.table_name = c.table_name
+        AND v.row_id = c.row_id
+        AND v.source_hash = c.source_hash
+  )
+ORDER BY c.table_name, c.row_id
+LIMIT $3
+`
+
+type ListEmbeddingMigrationRowsParams struct {
+	TargetModel string    `json:"target_model"`
+	MigrationID uuid.UUID `json:"migration_id"`
+	RowLimit    int32     `json:"row_limit"`
+}
+
+type ListEmbeddingMigrationRowsRow struct {
+	TableName  string    `json:"table_name"`
+	RowID      uuid.UUID `json:"row_id"`
+	Text       *string   `json:"text"`
+	ExtraText  string    `json:"extra_text"`
+	SourceHash string    `json:"source_hash"`
+}
+
+func (q *Queries) ListEmbeddingMigrationRows(ctx context.Context, arg ListEmbeddingMigrationRowsParams) ([]ListEmbeddingMigrationRowsRow, error) {
+	rows, err := q.db.Query(ctx, listEmbeddingMigrationRows, arg.TargetModel, arg.MigrationID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEmbeddingMigrationRowsRow{}
+	for rows.Next() {
+		var i ListEmbeddingMigrationRowsRow
+		if err := rows.Scan(
+			&i.TableName,
+			&i.RowID,
+			&i.Text,
+			&i.ExtraText,
+			&i.SourceHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmbeddingMigrations = `-- name: ListEmbeddingMigrations :many
+SELECT migration_id, source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, status, done, remaining, error, started_at, updated_at, finished_at
+FROM embedding_migrations
+ORDER BY started_at DESC
+LIMIT $1
+`
+
+func (q *Queries) ListEmbeddingMigrations(ctx context.Context, rowLimit int32) ([]EmbeddingMigration, error) {
+	rows, err := q.db.Query(ctx, listEmbeddingMigrations, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EmbeddingMigration{}
+	for rows.Next() {
+		var i EmbeddingMigration
+		if err := rows.Scan(
+			&i.MigrationID,
+			&i.SourceModel,
+			&i.TargetProvider,
+			&i.TargetUrl,
+			&i.TargetModel,
+			&i.TargetDimensions,
+			&i.TargetApiKey,
+			&i.Status,
+			&i.Done,
+			&i.Remaining,
+			&i.Error,
+			&i.StartedAt,
+			&i.UpdatedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmbeddingModelUsage = `-- name: ListEmbeddingModelUsage :many
+SELECT
+    v.table_name,
+    v.embedding_model,
+    v.embedding_dimensions,
+    count(*)::bigint AS vectors
+FROM (
+    SELECT 'bookmark_content_references'::text AS table_name, embedding_model, embedding_dimensions
+    FROM bookmark_content_references WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'bookmark_annotations'::text, embedding_model, embedding_dimensions
+    FROM bookmark_annotations WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'session_summaries'::text, embedding_model, embedding_dimensions
+    FROM session_summaries WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'item_semantic_index'::text, embedding_model, embedding_dimensions
+    FROM item_semantic_index WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'category_profiles'::text, embedding_model, vector_dims(embedding)
+    FROM category_profiles
+) v
+GROUP BY v.table_name, v.embedding_model, v.embedding_dimensions
+ORDER BY v.table_name, vectors DESC
+`
+
+type ListEmbeddingModelUsageRow struct {
+	TableName           string  `json:"table_name"`
+	EmbeddingModel      *string `json:"embedding_model"`
+	EmbeddingDimensions *int32  `json:"embedding_dimensions"`
+	Vectors             int64   `json:"vectors"`
+}
+
+func (q *Queries) ListEmbeddingModelUsage(ctx context.Context) ([]ListEmbeddingModelUsageRow, error) {
+	rows, err := q.db.Query(ctx, listEmbeddingModelUsage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEmbeddingModelUsageRow{}
+	for rows.Next() {
+		var i ListEmbeddingModelUsageRow
+		if err := rows.Scan(
+			&i.TableName,
+			&i.EmbeddingModel,
+			&i.EmbeddingDimensions,
+			&i.Vectors,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const stageEmbeddingMigrationVector = `-- name: StageEmbeddingMigrationVector :exec
+INSERT INTO embedding_migration_vectors (migration_id, table_name, row_id, source_hash, embedding)
+VALUES ($1, $2, $3, $4, $5::vector)
+ON CONFLICT (migration_id, table_name, row_id) DO UPDATE SET
+    source_hash = EXCLUDED.source_hash,
+    embedding = EXCLUDED.embedding,
+    created_at = now()
+`
+
+type StageEmbeddingMigrationVectorParams struct {
+	MigrationID uuid.UUID        `json:"migration_id"`
+	TableName   string           `json:"table_name"`
+	RowID       uuid.UUID        `json:"row_id"`
+	SourceHash  string           `json:"source_hash"`
+	Embedding   *pgvector.Vector `json:"embedding"`
+}
+
+func (q *Queries) StageEmbeddingMigrationVector(ctx context.Context, arg StageEmbeddingMigrationVectorParams) error {
+	_, err := q.db.Exec(ctx, stageEmbeddingMigrationVector,
+		arg.MigrationID,
+		arg.TableName,
+		arg.RowID,
+		arg.SourceHash,
+		arg.Embedding,
+	)
+	return err
+}
+
+const storeCachedEmbedding = `-- name: StoreCachedEmbedding :exec
+INSERT INTO embedding_cache (cache_key, model, embedding)
+VALUES ($1, $2, $3::vector)
+ON CONFLICT (cache_key) DO UPDATE SET
+    embedding = EXCLUDED.embedding,
+    last_used_at = now()
+`
+
+type StoreCachedEmbeddingParams struct {
+	CacheKey  string           `json:"cache_key"`
+	Model     string           `json:"model"`
+	Embedding *pgvector.Vector `json:"embedding"`
+}
+
+func (q *Queries) StoreCachedEmbedding(ctx context.Context, arg StoreCachedEmbeddingParams) error {
+	_, err := q.db.Exec(ctx, storeCachedEmbedding, arg.CacheKey, arg.Model, arg.Embedding)
+	return err
+}
+
+const touchCachedEmbeddings = `-- name: TouchCachedEmbeddings :many
+UPDATE embedding_cache
+SET hits = hits + 1, last_used_at = now()
+WHERE cache_key = ANY($1::text[])
+RETURNING cache_key, embedding::text AS embedding
+`
+
+type TouchCachedEmbeddingsRow struct {
+	CacheKey  string `json:"cache_key"`
+	Embedding string `json:"embedding"`
+}
+
+// Counts a hit on every cached vector found and returns them in their text form
+func (q *Queries) TouchCachedEmbeddings(ctx context.Context, cacheKeys []string) ([]TouchCachedEmbeddingsRow, error) {
+	rows, err := q.db.Query(ctx, touchCachedEmbeddings, cacheKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TouchCachedEmbeddingsRow{}
+	for rows.Next() {
+		var i TouchCachedEmbeddingsRow
+		if err := rows.Scan(&i.CacheKey, &i.Embedding); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateEmbeddingMigrationProgress = `-- name: UpdateEmbeddingMigrationProgress :one
+UPDATE embedding_migrations
+SET
+    done = done + $1::integer,
+    remaining = $2::integer,
+    status = $3::text,
+    error = $4::text,
+    updated_at = now()
+WHERE migration_id = $5 AND status IN ('running', 'ready')
+RETURNING migration_id, source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, status, done, remaining, error, started_at, updated_at, finished_at
+`
+
+type UpdateEmbeddingMigrationProgressParams struct {
+	Staged      int32     `json:"staged"`
+	Remaining   int32     `json:"remaining"`
+	Status      string    `json:"status"`
+	Error       *string   `json:"error"`
+	MigrationID uuid.UUID `json:"migration_id"`
+}
+
+func (q *Queries) UpdateEmbeddingMigrationProgress(ctx context.Context, arg UpdateEmbeddingMigrationProgressParams) (EmbeddingMigration, error) {
+	row := q.db.QueryRow(ctx, updateEmbeddingMigrationProgress,
+		arg.Staged,
+		arg.Remaining,
+		arg.Status,
+		arg.Error,
+		arg.MigrationID,
+	)
+	var i EmbeddingMigration
+	err := row.Scan(
+		&i.MigrationID,
+		&i.SourceModel,
+		&i.TargetProvider,
+		&i.TargetUrl,
+		&i.TargetModel,
+		&i.TargetDimensions,
+		&i.TargetApiKey,
+		&i.Status,
+		&i.Done,
+		&i.Remaining,
+		&i.Error,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
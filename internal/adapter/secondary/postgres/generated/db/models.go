@@ -37,32 +37,138 @@ type Bookmark struct {
 	CreationDate pgtype.Timestamp `json:"creation_date"`
 }
 
+type BookmarkAnnotation struct {
+	AnnotationID        uuid.UUID        `json:"annotation_id"`
+	BookmarkID          uuid.UUID        `json:"bookmark_id"`
+	QuoteExact          string           `json:"quote_exact"`
+	QuotePrefix         string           `json:"quote_prefix"`
+	QuoteSuffix         string           `json:"quote_suffix"`
+	PositionStart       int32            `json:"position_start"`
+	PositionEnd         int32            `json:"position_end"`
+	Note                *string          `json:"note"`
+	Embedding           interface{}      `json:"embedding"`
+	EmbeddingModel      *string          `json:"embedding_model"`
+	EmbeddingDimensions *int32           `json:"embedding_dimensions"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	UpdatedAt           pgtype.Timestamp `json:"updated_at"`
+}
+
+type BookmarkAnnotationTag struct {
+	AnnotationID uuid.UUID `json:"annotation_id"`
+	TagID        uuid.UUID `json:"tag_id"`
+}
+
+type BookmarkArchive struct {
+	ArchiveID       uuid.UUID        `json:"archive_id"`
+	BookmarkID      uuid.UUID        `json:"bookmark_id"`
+	Url             string           `json:"url"`
+	HtmlHash        string           `json:"html_hash"`
+	HtmlSize        int64            `json:"html_size"`
+	WarcHash        *string          `json:"warc_hash"`
+	WarcSize        *int64           `json:"warc_size"`
+	ResourceCount   int32            `json:"resource_count"`
+	FailedResources int32            `json:"failed_resources"`
+	ArchivedAt      pgtype.Timestamp `json:"archived_at"`
+}
+
 type BookmarkCategory struct {
 	ID         uuid.UUID   `json:"id"`
 	BookmarkID pgtype.UUID `json:"bookmark_id"`
 	CategoryID pgtype.UUID `json:"category_id"`
 }
 
+type BookmarkCategorySuggestion struct {
+	SuggestionID uuid.UUID        `json:"suggestion_id"`
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	CategoryID   uuid.UUID        `json:"category_id"`
+	Confidence   float64          `json:"confidence"`
+	Status       string           `json:"status"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	ReviewedAt   pgtype.Timestamp `json:"reviewed_at"`
+}
+
 type BookmarkContentReference struct {
-	ID         uuid.UUID        `json:"id"`
-	BookmarkID pgtype.UUID      `json:"bookmark_id"`
-	Content    *string          `json:"content"`
-	Strategy   *string          `json:"strategy"`
-	Embedding  interface{}      `json:"embedding"`
-	CreatedAt  pgtype.Timestamp `json:"created_at"`
-	Extra      []byte           `json:"extra"`
+	ID                  uuid.UUID        `json:"id"`
+	BookmarkID          pgtype.UUID      `json:"bookmark_id"`
+	Content             *string          `json:"content"`
+	Strategy            *string          `json:"strategy"`
+	Embedding           interface{}      `json:"embedding"`
+	EmbeddingModel      *string          `json:"embedding_model"`
+	EmbeddingDimensions *int32           `json:"embedding_dimensions"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	Extra               []byte           `json:"extra"`
 }
 
 type BookmarkEvaluation struct {
-	ID              uuid.UUID          `json:"id"`
-	BookmarkID      uuid.UUID          `json:"bookmark_id"`
-	FailedFetch     *bool              `json:"failed_fetch"`
-	SummaryID       *string            `json:"summary_id"`
-	SummaryComments *string            `json:"summary_comments"`
-	SummaryEval     *int32             `json:"summary_eval"`
-	QuestionsEval   *int32             `json:"questions_eval"`
-	QuestionsIds    []byte             `json:"questions_ids"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	ID                     uuid.UUID          `json:"id"`
+	BookmarkID             uuid.UUID          `json:"bookmark_id"`
+	FailedFetch            *bool              `json:"failed_fetch"`
+	SummaryID              *string            `json:"summary_id"`
+	SummaryComments        *string            `json:"summary_comments"`
+	SummaryEval            *int32             `json:"summary_eval"`
+	QuestionsEval          *int32             `json:"questions_eval"`
+	QuestionsIds           []byte             `json:"questions_ids"`
+	CreatedAt              pgtype.Timestamptz `json:"created_at"`
+	SummaryStrategy        *string            `json:"summary_strategy"`
+	SummaryModel           *string            `json:"summary_model"`
+	SummaryPromptVersion   *string            `json:"summary_prompt_version"`
+	QuestionsStrategy      *string            `json:"questions_strategy"`
+	QuestionsModel         *string            `json:"questions_model"`
+	QuestionsPromptVersion *string            `json:"questions_prompt_version"`
+}
+
+type BookmarkImport struct {
+	ImportID   uuid.UUID        `json:"import_id"`
+	Format     string           `json:"format"`
+	Source     *string          `json:"source"`
+	Status     string           `json:"status"`
+	Processed  int32            `json:"processed"`
+	Created    int32            `json:"created"`
+	Duplicates int32            `json:"duplicates"`
+	Failed     int32            `json:"failed"`
+	Report     []byte           `json:"report"`
+	Error      *string          `json:"error"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+}
+
+type BookmarkLinkCheck struct {
+	CheckID      uuid.UUID        `json:"check_id"`
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	Status       string           `json:"status"`
+	Method       string           `json:"method"`
+	StatusCode   *int32           `json:"status_code"`
+	FinalUrl     *string          `json:"final_url"`
+	Error        *string          `json:"error"`
+	Etag         *string          `json:"etag"`
+	LastModified *string          `json:"last_modified"`
+	ContentHash  *string          `json:"content_hash"`
+	Content      *string          `json:"content"`
+	ChangeRatio  *float64         `json:"change_ratio"`
+	Diff         *string          `json:"diff"`
+	CheckedAt    pgtype.Timestamp `json:"checked_at"`
+}
+
+type BookmarkMetadatum struct {
+	BookmarkID         uuid.UUID        `json:"bookmark_id"`
+	CanonicalUrl       *string          `json:"canonical_url"`
+	Title              *string          `json:"title"`
+	Description        *string          `json:"description"`
+	SiteName           *string          `json:"site_name"`
+	Author             *string          `json:"author"`
+	PublishedAt        pgtype.Timestamp `json:"published_at"`
+	Language           *string          `json:"language"`
+	FaviconUrl         *string          `json:"favicon_url"`
+	ImageUrl           *string          `json:"image_url"`
+	ContentType        *string          `json:"content_type"`
+	Properties         []byte           `json:"properties"`
+	JsonLd             []byte           `json:"json_ld"`
+	WordCount          int32            `json:"word_count"`
+	ReadingTimeMinutes int32            `json:"reading_time_minutes"`
+	UpdatedAt          pgtype.Timestamp `json:"updated_at"`
+	Extractor          *string          `json:"extractor"`
+	ExtractorMetadata  []byte           `json:"extractor_metadata"`
 }
 
 type BookmarkSource struct {
@@ -72,6 +178,22 @@ type BookmarkSource struct {
 	RawSource  []byte      `json:"raw_source"`
 }
 
+type BookmarkStageRun struct {
+	RunID      uuid.UUID        `json:"run_id"`
+	BookmarkID uuid.UUID        `json:"bookmark_id"`
+	Stage      string           `json:"stage"`
+	Status     string           `json:"status"`
+	Message    *string          `json:"message"`
+	Error      *string          `json:"error"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+}
+
+type BookmarkTag struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	TagID      uuid.UUID `json:"tag_id"`
+}
+
 type BookmarkTitle struct {
 	ID         uuid.UUID   `json:"id"`
 	BookmarkID pgtype.UUID `json:"bookmark_id"`
@@ -93,8 +215,19 @@ type BrowserHistory struct {
 }
 
 type Category struct {
-	CategoryID uuid.UUID `json:"category_id"`
-	Name       string    `json:"name"`
+	CategoryID uuid.UUID   `json:"category_id"`
+	Name       string      `json:"name"`
+	ParentID   pgtype.UUID `json:"parent_id"`
+}
+
+type CategoryProfile struct {
+	CategoryID      uuid.UUID        `json:"category_id"`
+	Embedding       interface{}      `json:"embedding"`
+	EmbeddingModel  *string          `json:"embedding_model"`
+	SourceText      string           `json:"source_text"`
+	SourceEmbedding interface{}      `json:"source_embedding"`
+	BookmarkCount   int32            `json:"bookmark_count"`
+	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
 }
 
 type CategorySource struct {
@@ -244,6 +377,57 @@ type DispatchTranscription struct {
 	Extra             []byte           `json:"extra"`
 }
 
+type EmbeddingCache struct {
+	CacheKey   string           `json:"cache_key"`
+	Model      string           `json:"model"`
+	Embedding  interface{}      `json:"embedding"`
+	Hits       int64            `json:"hits"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	LastUsedAt pgtype.Timestamp `json:"last_used_at"`
+}
+
+type EmbeddingCorpu struct {
+	TableName      string    `json:"table_name"`
+	RowID          uuid.UUID `json:"row_id"`
+	Text           *string   `json:"text"`
+	ExtraText      string    `json:"extra_text"`
+	SourceHash     string    `json:"source_hash"`
+	EmbeddingModel *string   `json:"embedding_model"`
+}
+
+type EmbeddingMigration struct {
+	MigrationID      uuid.UUID        `json:"migration_id"`
+	SourceModel      *string          `json:"source_model"`
+	TargetProvider   string           `json:"target_provider"`
+	TargetUrl        string           `json:"target_url"`
+	TargetModel      string           `json:"target_model"`
+	TargetDimensions int32            `json:"target_dimensions"`
+	TargetApiKey     string           `json:"target_api_key"`
+	Status           string           `json:"status"`
+	Done             int32            `json:"done"`
+	Remaining        int32            `json:"remaining"`
+	Error            *string          `json:"error"`
+	StartedAt        pgtype.Timestamp `json:"started_at"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	FinishedAt       pgtype.Timestamp `json:"finished_at"`
+}
+
+type EmbeddingMigrationVector struct {
+	MigrationID uuid.UUID        `json:"migration_id"`
+	TableName   string           `json:"table_name"`
+	RowID       uuid.UUID        `json:"row_id"`
+	SourceHash  string           `json:"source_hash"`
+	Embedding   interface{}      `json:"embedding"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type EmbeddingModel struct {
+	Name        string           `json:"name"`
+	Active      bool             `json:"active"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	ActivatedAt pgtype.Timestamp `json:"activated_at"`
+}
+
 type Entity struct {
 	EntityID    uuid.UUID        `json:"entity_id"`
 	Name        string           `json:"name"`
@@ -286,12 +470,24 @@ type FullItem struct {
 }
 
 type HttpResponse struct {
-	ResponseID uuid.UUID        `json:"response_id"`
-	BookmarkID pgtype.UUID      `json:"bookmark_id"`
-	StatusCode *int32           `json:"status_code"`
-	Headers    *string          `json:"headers"`
-	Content    []byte           `json:"content"`
-	FetchDate  pgtype.Timestamp `json:"fetch_date"`
+	ResponseID          uuid.UUID        `json:"response_id"`
+	BookmarkID          pgtype.UUID      `json:"bookmark_id"`
+	StatusCode          *int32           `json:"status_code"`
+	Headers             *string          `json:"headers"`
+	Content             []byte           `json:"content"`
+	FetchDate           pgtype.Timestamp `json:"fetch_date"`
+	FetchProfile        *string          `json:"fetch_profile"`
+	OriginalContent     []byte           `json:"original_content"`
+	OriginalContentType *string          `json:"original_content_type"`
+	ContentHash         *string          `json:"content_hash"`
+	OriginalContentHash *string          `json:"original_content_hash"`
+}
+
+type HttpResponseBody struct {
+	ContentHash string           `json:"content_hash"`
+	Content     []byte           `json:"content"`
+	Size        int64            `json:"size"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
 }
 
 type Item struct {
@@ -304,9 +500,11 @@ type Item struct {
 }
 
 type ItemSemanticIndex struct {
-	ID        uuid.UUID   `json:"id"`
-	ItemID    pgtype.UUID `json:"item_id"`
-	Embedding interface{} `json:"embedding"`
+	ID                  uuid.UUID   `json:"id"`
+	ItemID              pgtype.UUID `json:"item_id"`
+	Embedding           interface{} `json:"embedding"`
+	EmbeddingModel      *string     `json:"embedding_model"`
+	EmbeddingDimensions *int32      `json:"embedding_dimensions"`
 }
 
 type ItemTag struct {
@@ -426,10 +624,13 @@ type Observation struct {
 }
 
 type ProcessedContent struct {
-	ProcessedContentID uuid.UUID   `json:"processed_content_id"`
-	BookmarkID         pgtype.UUID `json:"bookmark_id"`
-	StrategyUsed       *string     `json:"strategy_used"`
-	ProcessedContent   *string     `json:"processed_content"`
+	ProcessedContentID uuid.UUID        `json:"processed_content_id"`
+	BookmarkID         pgtype.UUID      `json:"bookmark_id"`
+	StrategyUsed       *string          `json:"strategy_used"`
+	ProcessedContent   *string          `json:"processed_content"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	Extractor          *string          `json:"extractor"`
+	Metadata           []byte           `json:"metadata"`
 }
 
 type RawMessage struct {
@@ -508,12 +709,14 @@ type SessionMessage struct {
 }
 
 type SessionSummary struct {
-	ID        uuid.UUID        `json:"id"`
-	SessionID uuid.UUID        `json:"session_id"`
-	Summary   *string          `json:"summary"`
-	Embedding interface{}      `json:"embedding"`
-	Strategy  *string          `json:"strategy"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
+	ID                  uuid.UUID        `json:"id"`
+	SessionID           uuid.UUID        `json:"session_id"`
+	Summary             *string          `json:"summary"`
+	Embedding           interface{}      `json:"embedding"`
+	EmbeddingModel      *string          `json:"embedding_model"`
+	EmbeddingDimensions *int32           `json:"embedding_dimensions"`
+	Strategy            *string          `json:"strategy"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
 }
 
 type SocialPost struct {
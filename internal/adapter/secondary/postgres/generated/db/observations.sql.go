@@ -84,3 +84,72 @@ func (q *Queries) GetFeedbackStats(ctx context.Context, ref pgtype.UUID) (GetFee
 	err := row.Scan(&i.Upvotes, &i.Downvotes, &i.Trash)
 	return i, err
 }
+
+const listFeedbackObservations = `-- name: ListFeedbackObservations :many
+SELECT data
+FROM observations
+WHERE type = 'qa-feedback'
+ORDER BY creation_date, observation_id
+`
+
+func (q *Queries) ListFeedbackObservations(ctx context.Context) ([][]byte, error) {
+	rows, err := q.db.Query(ctx, listFeedbackObservations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := [][]byte{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		items = append(items, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listObservationsByType = `-- name: ListObservationsByType :many
+SELECT observation_id, data, type, source, tags, parent, ref, creation_date
+FROM observations
+WHERE type = $1
+ORDER BY creation_date DESC
+LIMIT $2
+`
+
+type ListObservationsByTypeParams struct {
+	Type     *string `json:"type"`
+	RowLimit int32   `json:"row_limit"`
+}
+
+func (q *Queries) ListObservationsByType(ctx context.Context, arg ListObservationsByTypeParams) ([]Observation, error) {
+	rows, err := q.db.Query(ctx, listObservationsByType, arg.Type, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Observation{}
+	for rows.Next() {
+		var i Observation
+		if err := rows.Scan(
+			&i.ObservationID,
+			&i.Data,
+			&i.Type,
+			&i.Source,
+			&i.Tags,
+			&i.Parent,
+			&i.Ref,
+			&i.CreationDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,6 +7,16 @@ FROM bookmarks
 WHERE bookmark_id = $1;
 
 -- name: ListBookmarks :many
+-- The category filter includes the descendants of the category
+-- The failed-stage filter applies the rule of GetBookmarkStatus: the latest run of the stage decides, and a
+-- bookmark fetched before runs were recorded failed when its latest response has an error status
+WITH RECURSIVE subtree AS (
+    SELECT category_id FROM categories WHERE category_id = $1::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
 SELECT DISTINCT
     b.bookmark_id,
     b.url,
@@ -15,25 +25,83 @@ SELECT DISTINCT
 FROM bookmarks b
 LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN bookmark_metadata bm ON b.bookmark_id = bm.bookmark_id
 WHERE
-    ($1::uuid IS NULL OR bc.category_id = $1)
+    ($1::uuid IS NULL OR bc.category_id IN (SELECT category_id FROM subtree))
     AND ($2::text IS NULL OR bt.title ILIKE '%' || $2 || '%')
     AND ($3::timestamp IS NULL OR b.creation_date >= $3)
     AND ($4::timestamp IS NULL OR b.creation_date <= $4)
+    AND ($8::text = '' OR bm.author ILIKE '%' || $8 || '%')
+    AND ($9::text = '' OR lower(bm.site_name) = lower($9)
+        OR lower(substring(b.url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)')) = lower($9))
+    AND ($10::int = 0 OR EXTRACT(YEAR FROM bm.published_at) = $10)
+    AND ($7::text = ''
+        OR (
+            SELECT r.status
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = $7
+            ORDER BY r.started_at DESC
+            LIMIT 1
+        ) = 'failed'
+        OR ($7 = 'fetch' AND NOT EXISTS (
+            SELECT 1
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = 'fetch'
+        ) AND (
+            SELECT hr.status_code
+            FROM http_responses hr
+            WHERE hr.bookmark_id = b.bookmark_id
+            ORDER BY hr.fetch_date DESC NULLS LAST
+            LIMIT 1
+        ) >= 400))
 ORDER BY b.creation_date DESC
 LIMIT $5
 OFFSET $6;
 
 -- name: CountBookmarks :one
+-- The category filter includes the descendants of the category
+-- The failed-stage filter applies the rule of GetBookmarkStatus: the latest run of the stage decides, and a
+-- bookmark fetched before runs were recorded failed when its latest response has an error status
+WITH RECURSIVE subtree AS (
+    SELECT category_id FROM categories WHERE category_id = $1::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
 SELECT COUNT(DISTINCT b.bookmark_id)
 FROM bookmarks b
 LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN bookmark_metadata bm ON b.bookmark_id = bm.bookmark_id
 WHERE
-    ($1::uuid IS NULL OR bc.category_id = $1)
+    ($1::uuid IS NULL OR bc.category_id IN (SELECT category_id FROM subtree))
     AND ($2::text IS NULL OR bt.title ILIKE '%' || $2 || '%')
     AND ($3::timestamp IS NULL OR b.creation_date >= $3)
-    AND ($4::timestamp IS NULL OR b.creation_date <= $4);
+    AND ($4::timestamp IS NULL OR b.creation_date <= $4)
+    AND ($6::text = '' OR bm.author ILIKE '%' || $6 || '%')
+    AND ($7::text = '' OR lower(bm.site_name) = lower($7)
+        OR lower(substring(b.url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)')) = lower($7))
+    AND ($8::int = 0 OR EXTRACT(YEAR FROM bm.published_at) = $8)
+    AND ($5::text = ''
+        OR (
+            SELECT r.status
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = $5
+            ORDER BY r.started_at DESC
+            LIMIT 1
+        ) = 'failed'
+        OR ($5 = 'fetch' AND NOT EXISTS (
+            SELECT 1
+            FROM bookmark_stage_runs r
+            WHERE r.bookmark_id = b.bookmark_id AND r.stage = 'fetch'
+        ) AND (
+            SELECT hr.status_code
+            FROM http_responses hr
+            WHERE hr.bookmark_id = b.bookmark_id
+            ORDER BY hr.fetch_date DESC NULLS LAST
+            LIMIT 1
+        ) >= 400));
 
 -- name: GetRandomBookmark :one
 SELECT bookmark_id
@@ -52,10 +120,13 @@ SELECT
     bt.title,
     pc_lynx.processed_content as lynx_content,
     pc_reader.processed_content as reader_content,
+    pc_reader.strategy_used as content_strategy,
     hr.status_code,
     hr.headers,
     hr.content as http_content,
+    hb.content as stored_http_content,
     hr.fetch_date,
+    hr.fetch_profile,
     bcr_summary.content as summary
 FROM bookmarks b
 LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
@@ -63,8 +134,16 @@ LEFT JOIN categories c ON bc.category_id = c.category_id
 LEFT JOIN bookmark_sources bs ON b.bookmark_id = bs.bookmark_id
 LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN processed_contents pc_lynx ON b.bookmark_id = pc_lynx.bookmark_id AND pc_lynx.strategy_used = 'lynx'
-LEFT JOIN processed_contents pc_reader ON b.bookmark_id = pc_reader.bookmark_id AND pc_reader.strategy_used = 'reader'
+LEFT JOIN LATERAL (
+    SELECT processed_content, strategy_used
+    FROM processed_contents
+    WHERE bookmark_id = b.bookmark_id
+      AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ORDER BY created_at DESC NULLS LAST, array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+    LIMIT 1
+) pc_reader ON true
 LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
 LEFT JOIN bookmark_content_references bcr_summary ON b.bookmark_id = bcr_summary.bookmark_id AND bcr_summary.strategy = 'summary-reader'
 WHERE b.bookmark_id = $1;
 
@@ -73,7 +152,7 @@ SELECT
     id,
     content
 FROM bookmark_content_references
-WHERE bookmark_id = $1 AND strategy = 'question-inference';
+WHERE bookmark_id = $1 AND strategy IN ('question-inference', 'qa-v2-passage');
 
 -- name: SearchSimilarBookmarks :many
 SELECT
@@ -81,12 +160,15 @@ SELECT
     b.url,
     b.creation_date,
     bt.title,
-    bcr_summary.content as summary
+    bcr_summary.content as summary,
+    bcr.extra
 FROM bookmarks b
 INNER JOIN bookmark_content_references bcr ON b.bookmark_id = bcr.bookmark_id
 INNER JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN bookmark_content_references bcr_summary ON b.bookmark_id = bcr_summary.bookmark_id AND bcr_summary.strategy = 'summary-reader'
 WHERE bcr.strategy = $1
+  AND bcr.embedding_model = public.active_embedding_model()
+  AND bcr.embedding_dimensions = vector_dims($2::vector)
 ORDER BY bcr.embedding <=> $2::vector
 LIMIT $3;
 
@@ -94,7 +176,9 @@ LIMIT $3;
 UPDATE bookmark_content_references
 SET
     content = $1,
-    embedding = $2::vector
+    embedding = $2::vector,
+    embedding_model = $5,
+    extra = COALESCE(extra, '{}'::jsonb) || '{"edited": true}'::jsonb
 WHERE id = $3 AND bookmark_id = $4;
 
 -- name: DeleteBookmarkQuestion :exec
@@ -115,37 +199,138 @@ WHERE b.bookmark_id = $1;
 INSERT INTO observations (data, type, source, tags, ref)
 VALUES ($1, $2, $3, $4, $5);
 
+-- name: InsertHttpResponseBody :exec
+INSERT INTO http_response_bodies (content_hash, content, size)
+VALUES ($1, $2, $3)
+ON CONFLICT (content_hash) DO NOTHING;
+
 -- name: InsertHttpResponse :exec
-INSERT INTO http_responses (bookmark_id, status_code, headers, content, fetch_date)
-VALUES ($1, $2, $3, $4, $5);
+INSERT INTO http_responses (bookmark_id, status_code, headers, content_hash, fetch_date, fetch_profile, original_content_hash, original_content_type)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
 
 -- name: GetLatestHttpResponse :one
 SELECT
-    response_id,
-    status_code,
-    headers,
-    content,
-    fetch_date
-FROM http_responses
-WHERE bookmark_id = $1
-ORDER BY fetch_date DESC
+    hr.response_id,
+    hr.status_code,
+    hr.headers,
+    hr.content,
+    hb.content AS stored_content,
+    hr.fetch_date,
+    hr.fetch_profile
+FROM http_responses hr
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+WHERE hr.bookmark_id = $1
+ORDER BY hr.fetch_date DESC NULLS LAST
 LIMIT 1;
 
+-- name: GetOriginalHttpResponseBody :one
+SELECT
+    hr.original_content,
+    hb.content AS stored_original_content,
+    hr.original_content_type
+FROM http_responses hr
+LEFT JOIN http_response_bodies hb ON hr.original_content_hash = hb.content_hash
+WHERE hr.response_id = $1
+  AND (hr.original_content IS NOT NULL OR hr.original_content_hash IS NOT NULL);
+
+-- name: PruneHttpResponses :many
+DELETE FROM http_responses
+WHERE http_responses.bookmark_id = $1
+  AND http_responses.response_id NOT IN (
+      SELECT kept.response_id
+      FROM http_responses kept
+      WHERE kept.bookmark_id = $1
+      ORDER BY kept.fetch_date DESC NULLS LAST
+      LIMIT $2
+  )
+  AND http_responses.response_id IS DISTINCT FROM (
+      SELECT ok.response_id
+      FROM http_responses ok
+      WHERE ok.bookmark_id = $1 AND ok.status_code < 400
+      ORDER BY ok.fetch_date DESC NULLS LAST
+      LIMIT 1
+  )
+RETURNING content_hash, original_content_hash;
+
+-- name: DeleteUnreferencedHttpResponseBodies :execrows
+DELETE FROM http_response_bodies hb
+WHERE hb.content_hash = ANY($1::text[])
+  AND NOT EXISTS (
+      SELECT 1 FROM http_responses hr WHERE hr.content_hash = hb.content_hash
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM http_responses hr WHERE hr.original_content_hash = hb.content_hash
+  );
+
+-- name: ListInlineHttpResponses :many
+-- Pages by response id along http_responses_inline_content_idx, so each batch starts after the
+-- previous one instead of scanning past the rows already moved
+SELECT response_id
+FROM http_responses
+WHERE content IS NOT NULL
+  AND response_id > $1
+ORDER BY response_id
+LIMIT $2;
+
+-- name: GetInlineHttpResponseContent :one
+SELECT content, original_content
+FROM http_responses
+WHERE response_id = $1;
+
+-- name: MoveHttpResponseContent :exec
+UPDATE http_responses
+SET content_hash = $2, original_content_hash = $3, content = NULL, original_content = NULL
+WHERE response_id = $1;
+
+-- name: ListBookmarksOverResponseRetention :many
+SELECT bookmark_id::uuid
+FROM http_responses
+WHERE bookmark_id IS NOT NULL
+GROUP BY bookmark_id
+HAVING COUNT(*) > $1::bigint;
+
 -- name: InsertProcessedContent :exec
 INSERT INTO processed_contents (bookmark_id, strategy_used, processed_content)
 VALUES ($1, $2, $3);
 
+-- name: InsertExtractedContent :exec
+INSERT INTO processed_contents (bookmark_id, strategy_used, processed_content, extractor, metadata)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetDocumentContent :one
+-- The newest extraction wins, so a page that now serves a document replaces its older reader text
+SELECT
+    processed_content,
+    strategy_used
+FROM processed_contents
+WHERE bookmark_id = $1
+  AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+ORDER BY created_at DESC NULLS LAST, array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+LIMIT 1;
+
+-- name: GetExtractedDocument :one
+SELECT
+    processed_content,
+    extractor,
+    metadata
+FROM processed_contents
+WHERE bookmark_id = $1
+  AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+ORDER BY created_at DESC NULLS LAST, array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+LIMIT 1;
+
 -- name: GetProcessedContentByStrategy :one
 SELECT
     processed_content_id,
     processed_content
 FROM processed_contents
 WHERE bookmark_id = $1 AND strategy_used = $2
+ORDER BY created_at DESC NULLS LAST
 LIMIT 1;
 
 -- name: CreateEmbeddingChunk :one
-INSERT INTO bookmark_content_references (bookmark_id, content, strategy, embedding)
-VALUES ($1, $2, $3, $4::vector)
+INSERT INTO bookmark_content_references (bookmark_id, content, strategy, embedding, embedding_model)
+VALUES ($1, $2, $3, $4::vector, $5)
 RETURNING id;
 
 -- name: GetBookmarkTitle :one
@@ -155,11 +340,20 @@ SELECT
     b.creation_date,
     bt.title as existing_title,
     hr.content as raw_content,
+    hb.content as stored_raw_content,
     substring(pc.processed_content FROM '#([^\n]*)')::text as reader_title
 FROM bookmarks b
 LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
 LEFT JOIN http_responses hr ON b.bookmark_id = hr.bookmark_id
-LEFT JOIN processed_contents pc ON b.bookmark_id = pc.bookmark_id AND pc.strategy_used = 'reader'
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+LEFT JOIN LATERAL (
+    SELECT processed_content
+    FROM processed_contents
+    WHERE bookmark_id = b.bookmark_id
+      AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ORDER BY array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+    LIMIT 1
+) pc ON true
 WHERE b.bookmark_id = $1;
 
 -- name: InsertBookmarkTitle :exec
@@ -182,6 +376,997 @@ SELECT
     b.url,
     b.creation_date
 FROM bookmarks b
-LEFT JOIN processed_contents pc ON b.bookmark_id = pc.bookmark_id AND pc.strategy_used = 'reader'
+LEFT JOIN processed_contents pc ON b.bookmark_id = pc.bookmark_id AND pc.strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
 WHERE pc.processed_content_id IS NULL
 ORDER BY b.creation_date DESC;
+
+-- name: GetBookmarkByURL :one
+SELECT
+    bookmark_id,
+    url,
+    creation_date
+FROM bookmarks
+WHERE url = $1
+ORDER BY creation_date ASC
+LIMIT 1;
+
+-- name: GetBookmarkWithTitle :one
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    bt.title
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+WHERE b.bookmark_id = $1
+LIMIT 1;
+
+-- name: CreateBookmark :one
+-- Returns no row when another bookmark already has the URL
+INSERT INTO bookmarks (url, creation_date)
+VALUES ($1, $2)
+ON CONFLICT (url) DO NOTHING
+RETURNING bookmark_id, url, creation_date;
+
+-- name: UpdateBookmarkURL :exec
+UPDATE bookmarks
+SET url = $2
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkTitles :exec
+DELETE FROM bookmark_titles
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkCategories :exec
+DELETE FROM bookmark_category
+WHERE bookmark_id = $1;
+
+-- name: InsertBookmarkCategory :exec
+INSERT INTO bookmark_category (bookmark_id, category_id)
+VALUES ($1, $2);
+
+-- name: InsertBookmarkSource :exec
+INSERT INTO bookmark_sources (bookmark_id, source_uri, raw_source)
+VALUES ($1, $2, $3);
+
+-- name: DeleteBookmarkHttpResponses :many
+DELETE FROM http_responses
+WHERE bookmark_id = $1
+RETURNING content_hash, original_content_hash;
+
+-- name: DeleteBookmarkProcessedContents :exec
+DELETE FROM processed_contents
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkContentReferences :exec
+DELETE FROM bookmark_content_references
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkSources :exec
+DELETE FROM bookmark_sources
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkStageRuns :exec
+DELETE FROM bookmark_stage_runs
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkMetadata :exec
+DELETE FROM bookmark_metadata
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkLinkChecks :exec
+DELETE FROM bookmark_link_checks
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmarkArchives :exec
+DELETE FROM bookmark_archives
+WHERE bookmark_id = $1;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE bookmark_id = $1;
+
+-- name: ListBookmarkURLs :many
+SELECT
+    bookmark_id,
+    url,
+    creation_date
+FROM bookmarks
+ORDER BY creation_date, bookmark_id;
+
+-- name: DropMergedBookmarkTags :exec
+-- Rows of duplicates that the kept bookmark, or a duplicate before them, already has an equivalent of are
+-- dropped before the rows are moved to the kept bookmark, so no unique key is violated
+DELETE FROM bookmark_tags t
+WHERE t.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_tags o
+      WHERE o.tag_id = t.tag_id
+        AND (o.bookmark_id = sqlc.arg(keep_id)::uuid
+             OR (o.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[]) AND o.bookmark_id < t.bookmark_id))
+  );
+
+-- name: DropMergedBookmarkCategories :exec
+DELETE FROM bookmark_category c
+WHERE c.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_category o
+      WHERE o.category_id IS NOT DISTINCT FROM c.category_id
+        AND (o.bookmark_id = sqlc.arg(keep_id)::uuid
+             OR (o.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[]) AND o.bookmark_id < c.bookmark_id))
+  );
+
+-- name: DropMergedCategorySuggestions :exec
+DELETE FROM bookmark_category_suggestions s
+WHERE s.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_category_suggestions o
+      WHERE o.category_id = s.category_id
+        AND (o.bookmark_id = sqlc.arg(keep_id)::uuid
+             OR (o.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[]) AND o.bookmark_id < s.bookmark_id))
+  );
+
+-- name: DropMergedBookmarkMetadata :exec
+DELETE FROM bookmark_metadata m
+WHERE m.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_metadata o
+      WHERE o.bookmark_id = sqlc.arg(keep_id)::uuid
+         OR (o.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[]) AND o.bookmark_id < m.bookmark_id)
+  );
+
+-- name: DropMergedBookmarkTitles :exec
+DELETE FROM bookmark_titles t
+WHERE t.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+  AND EXISTS (
+      SELECT 1 FROM bookmark_titles o
+      WHERE o.title IS NOT DISTINCT FROM t.title
+        AND o.source IS NOT DISTINCT FROM t.source
+        AND (o.bookmark_id = sqlc.arg(keep_id)::uuid
+             OR (o.bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[]) AND o.bookmark_id < t.bookmark_id))
+  );
+
+-- name: MoveMergedBookmarkRows :exec
+WITH tags AS (
+    UPDATE bookmark_tags SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), categories AS (
+    UPDATE bookmark_category SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), suggestions AS (
+    UPDATE bookmark_category_suggestions SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), metadata AS (
+    UPDATE bookmark_metadata SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), titles AS (
+    UPDATE bookmark_titles SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), sources AS (
+    UPDATE bookmark_sources SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), responses AS (
+    UPDATE http_responses SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), contents AS (
+    UPDATE processed_contents SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), refs AS (
+    UPDATE bookmark_content_references SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), runs AS (
+    UPDATE bookmark_stage_runs SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), checks AS (
+    UPDATE bookmark_link_checks SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), archives AS (
+    UPDATE bookmark_archives SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), annotations AS (
+    UPDATE bookmark_annotations SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+), evaluations AS (
+    UPDATE bookmark_evaluations SET bookmark_id = sqlc.arg(keep_id)::uuid WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[])
+)
+UPDATE observations SET ref = sqlc.arg(keep_id)::uuid WHERE ref = ANY(sqlc.arg(duplicate_ids)::uuid[]);
+
+-- name: DeleteMergedBookmarks :exec
+DELETE FROM bookmarks
+WHERE bookmark_id = ANY(sqlc.arg(duplicate_ids)::uuid[]);
+
+-- name: CreateBookmarkURLIndex :exec
+CREATE UNIQUE INDEX IF NOT EXISTS bookmarks_url_idx ON bookmarks (url);
+
+-- name: InsertBookmarkStageRun :exec
+INSERT INTO bookmark_stage_runs (bookmark_id, stage, status, message, error, started_at, finished_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListBookmarkStageRuns :many
+SELECT
+    run_id,
+    stage,
+    status,
+    message,
+    error,
+    started_at,
+    finished_at
+FROM bookmark_stage_runs
+WHERE bookmark_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: GetBookmarkStageRunSummaries :many
+SELECT
+    latest.run_id,
+    latest.stage,
+    latest.status,
+    latest.message,
+    latest.error,
+    latest.started_at,
+    latest.finished_at,
+    totals.attempts,
+    totals.failures,
+    totals.last_success_at
+FROM (
+    SELECT DISTINCT ON (r.stage)
+        r.run_id,
+        r.stage,
+        r.status,
+        r.message,
+        r.error,
+        r.started_at,
+        r.finished_at
+    FROM bookmark_stage_runs r
+    WHERE r.bookmark_id = $1
+    ORDER BY r.stage, r.started_at DESC
+) latest
+JOIN (
+    SELECT
+        stage,
+        COUNT(*)::int AS attempts,
+        (COUNT(*) FILTER (WHERE status = 'failed'))::int AS failures,
+        (MAX(finished_at) FILTER (WHERE status = 'succeeded'))::timestamp AS last_success_at
+    FROM bookmark_stage_runs
+    WHERE bookmark_id = $1
+    GROUP BY stage
+) totals ON totals.stage = latest.stage;
+
+-- name: GetBookmarkStageArtifacts :many
+SELECT
+    'fetch'::text AS stage,
+    COUNT(*)::int AS artifact_count,
+    MAX(hr.fetch_date)::timestamp AS last_produced_at
+FROM http_responses hr
+WHERE hr.bookmark_id = $1
+UNION ALL
+SELECT
+    CASE WHEN pc.strategy_used IN ('pdf', 'epub', 'text', 'markdown') THEN 'reader' ELSE pc.strategy_used END,
+    COUNT(*)::int,
+    MAX(pc.created_at)::timestamp
+FROM processed_contents pc
+WHERE pc.bookmark_id = $1
+GROUP BY 1
+UNION ALL
+SELECT
+    'title'::text,
+    COUNT(*)::int,
+    NULL::timestamp
+FROM bookmark_titles bt
+WHERE bt.bookmark_id = $1
+UNION ALL
+SELECT
+    'metadata'::text,
+    COUNT(*)::int,
+    MAX(bm.updated_at)::timestamp
+FROM bookmark_metadata bm
+WHERE bm.bookmark_id = $1
+UNION ALL
+SELECT
+    'archive'::text,
+    COUNT(*)::int,
+    MAX(ba.archived_at)::timestamp
+FROM bookmark_archives ba
+WHERE ba.bookmark_id = $1
+UNION ALL
+SELECT
+    bcr.strategy,
+    COUNT(*)::int,
+    MAX(bcr.created_at)::timestamp
+FROM bookmark_content_references bcr
+WHERE bcr.bookmark_id = $1
+GROUP BY bcr.strategy;
+
+-- name: HasArchivedContent :one
+SELECT
+    NOT EXISTS (SELECT 1 FROM http_responses hr WHERE hr.bookmark_id = $1)
+    AND EXISTS (
+        SELECT 1 FROM processed_contents pc
+        WHERE pc.bookmark_id = $1
+          AND pc.strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ) AS archived;
+
+-- name: GetLatestFetchStatus :one
+SELECT
+    hr.status_code,
+    hr.fetch_date,
+    (CASE WHEN hr.status_code = 500 AND hr.headers = '{}' THEN hr.content END)::bytea AS fetch_error,
+    (CASE WHEN hr.status_code = 500 AND hr.headers = '{}' THEN hb.content END)::bytea AS stored_fetch_error
+FROM http_responses hr
+LEFT JOIN http_response_bodies hb ON hr.content_hash = hb.content_hash
+WHERE hr.bookmark_id = $1
+ORDER BY hr.fetch_date DESC NULLS LAST
+LIMIT 1;
+
+-- name: GetGeneratedQuestions :many
+SELECT
+    id,
+    content
+FROM bookmark_content_references
+WHERE bookmark_id = $1
+    AND strategy = $2
+    AND extra->>'prompt_version' = sqlc.arg(prompt_version)::text
+ORDER BY created_at ASC;
+
+-- name: DeleteContentReferencesByStrategy :exec
+DELETE FROM bookmark_content_references
+WHERE bookmark_id = $1 AND strategy = $2;
+
+-- name: DeleteReplaceableQuestions :exec
+-- Pairs the user edited or left feedback on are kept when the generated pairs are replaced
+DELETE FROM bookmark_content_references r
+WHERE r.bookmark_id = $1
+    AND r.strategy = $2
+    AND NOT COALESCE((r.extra->>'edited')::boolean, false)
+    AND NOT EXISTS (
+        SELECT 1 FROM observations o
+        WHERE o.ref = r.bookmark_id
+            AND o.type = 'qa-feedback'
+            AND o.data->>'referenceId' = r.id::text
+    );
+
+-- name: LockBookmarkQuestions :exec
+SELECT pg_advisory_lock(hashtextextended('bookmark-questions:' || sqlc.arg(bookmark_id)::uuid::text, 0));
+
+-- name: UnlockBookmarkQuestions :exec
+SELECT pg_advisory_unlock(hashtextextended('bookmark-questions:' || sqlc.arg(bookmark_id)::uuid::text, 0));
+
+-- name: CreateEmbeddingChunkWithExtra :one
+INSERT INTO bookmark_content_references (bookmark_id, content, strategy, embedding, extra, embedding_model)
+VALUES ($1, $2, $3, $4::vector, $5, $6)
+RETURNING id;
+
+-- name: ListBookmarksForExport :many
+SELECT DISTINCT ON (b.bookmark_id)
+    b.bookmark_id,
+    b.url,
+    b.creation_date,
+    bt.title,
+    c.category_id,
+    c.name AS category_name
+FROM bookmarks b
+LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
+LEFT JOIN bookmark_category bc ON b.bookmark_id = bc.bookmark_id
+LEFT JOIN categories c ON bc.category_id = c.category_id
+ORDER BY b.bookmark_id, c.name, bt.title;
+
+-- name: InsertBookmarkTag :exec
+INSERT INTO bookmark_tags (bookmark_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetBookmarkTags :many
+SELECT t.name
+FROM bookmark_tags bt
+JOIN tags t ON bt.tag_id = t.id
+WHERE bt.bookmark_id = $1
+ORDER BY t.name;
+
+-- name: DeleteBookmarkTags :exec
+DELETE FROM bookmark_tags
+WHERE bookmark_id = $1;
+
+-- name: CreateBookmarkImport :one
+INSERT INTO bookmark_imports (format, source, status)
+VALUES ($1, $2, $3)
+RETURNING import_id, format, source, status, processed, created, duplicates, failed, report, error, started_at, updated_at, finished_at;
+
+-- name: GetBookmarkImport :one
+SELECT import_id, format, source, status, processed, created, duplicates, failed, report, error, started_at, updated_at, finished_at
+FROM bookmark_imports
+WHERE import_id = $1;
+
+-- name: UpdateBookmarkImport :one
+UPDATE bookmark_imports
+SET status = $2,
+    processed = $3,
+    created = $4,
+    duplicates = $5,
+    failed = $6,
+    report = $7,
+    error = $8,
+    finished_at = $9,
+    updated_at = now()
+WHERE import_id = $1
+RETURNING updated_at;
+
+-- name: UpsertBookmarkMetadata :one
+INSERT INTO bookmark_metadata (
+    bookmark_id,
+    canonical_url,
+    title,
+    description,
+    site_name,
+    author,
+    published_at,
+    language,
+    favicon_url,
+    image_url,
+    content_type,
+    properties,
+    json_ld,
+    word_count,
+    reading_time_minutes,
+    extractor,
+    extractor_metadata,
+    updated_at
+) VALUES (
+    sqlc.arg(bookmark_id),
+    sqlc.narg(canonical_url),
+    sqlc.narg(title),
+    sqlc.narg(description),
+    sqlc.narg(site_name),
+    sqlc.narg(author),
+    sqlc.narg(published_at),
+    sqlc.narg(language),
+    sqlc.narg(favicon_url),
+    sqlc.narg(image_url),
+    sqlc.narg(content_type),
+    sqlc.arg(properties),
+    sqlc.arg(json_ld),
+    sqlc.arg(word_count),
+    sqlc.arg(reading_time_minutes),
+    sqlc.narg(extractor),
+    sqlc.arg(extractor_metadata),
+    now()
+)
+ON CONFLICT (bookmark_id) DO UPDATE SET
+    canonical_url = EXCLUDED.canonical_url,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    site_name = EXCLUDED.site_name,
+    author = EXCLUDED.author,
+    published_at = EXCLUDED.published_at,
+    language = EXCLUDED.language,
+    favicon_url = EXCLUDED.favicon_url,
+    image_url = EXCLUDED.image_url,
+    content_type = EXCLUDED.content_type,
+    properties = EXCLUDED.properties,
+    json_ld = EXCLUDED.json_ld,
+    word_count = EXCLUDED.word_count,
+    reading_time_minutes = EXCLUDED.reading_time_minutes,
+    extractor = EXCLUDED.extractor,
+    extractor_metadata = EXCLUDED.extractor_metadata,
+    updated_at = EXCLUDED.updated_at
+RETURNING updated_at;
+
+-- name: GetBookmarkMetadata :one
+SELECT
+    bookmark_id,
+    canonical_url,
+    title,
+    description,
+    site_name,
+    author,
+    published_at,
+    language,
+    favicon_url,
+    image_url,
+    content_type,
+    properties,
+    json_ld,
+    word_count,
+    reading_time_minutes,
+    extractor,
+    extractor_metadata,
+    updated_at
+FROM bookmark_metadata
+WHERE bookmark_id = $1;
+
+-- name: InsertBookmarkLinkCheck :one
+INSERT INTO bookmark_link_checks (
+    bookmark_id,
+    status,
+    method,
+    status_code,
+    final_url,
+    error,
+    etag,
+    last_modified,
+    content_hash,
+    content,
+    change_ratio,
+    diff,
+    checked_at
+) VALUES (
+    sqlc.arg(bookmark_id),
+    sqlc.arg(status),
+    sqlc.arg(method),
+    sqlc.narg(status_code),
+    sqlc.narg(final_url),
+    sqlc.narg(error),
+    sqlc.narg(etag),
+    sqlc.narg(last_modified),
+    sqlc.narg(content_hash),
+    sqlc.narg(content),
+    sqlc.narg(change_ratio),
+    sqlc.narg(diff),
+    sqlc.arg(checked_at)
+)
+RETURNING check_id;
+
+-- name: ListBookmarkLinkChecks :many
+SELECT
+    check_id,
+    bookmark_id,
+    status,
+    method,
+    status_code,
+    final_url,
+    error,
+    etag,
+    last_modified,
+    content_hash,
+    change_ratio,
+    diff,
+    checked_at
+FROM bookmark_link_checks
+WHERE bookmark_id = $1
+ORDER BY checked_at DESC
+LIMIT $2;
+
+-- name: GetLatestLinkCheckContent :one
+SELECT content
+FROM bookmark_link_checks
+WHERE bookmark_id = $1
+  AND content IS NOT NULL
+ORDER BY checked_at DESC
+LIMIT 1;
+
+-- name: ListBookmarksDueForLinkCheck :many
+-- Bookmarks that were never checked become due one interval after they were saved
+SELECT
+    b.bookmark_id,
+    b.url,
+    b.creation_date
+FROM bookmarks b
+LEFT JOIN LATERAL (
+    SELECT c.checked_at
+    FROM bookmark_link_checks c
+    WHERE c.bookmark_id = b.bookmark_id
+    ORDER BY c.checked_at DESC
+    LIMIT 1
+) latest ON true
+WHERE COALESCE(latest.checked_at, b.creation_date) < sqlc.arg(checked_before)::timestamp
+ORDER BY latest.checked_at NULLS FIRST, b.creation_date
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListLinkIssues :many
+SELECT
+    b.bookmark_id,
+    b.url,
+    bt.title,
+    latest.check_id,
+    latest.status,
+    latest.method,
+    latest.status_code,
+    latest.final_url,
+    latest.error,
+    latest.etag,
+    latest.last_modified,
+    latest.content_hash,
+    latest.change_ratio,
+    latest.diff,
+    latest.checked_at
+FROM (
+    SELECT DISTINCT ON (c.bookmark_id) c.*
+    FROM bookmark_link_checks c
+    ORDER BY c.bookmark_id, c.checked_at DESC
+) latest
+JOIN bookmarks b ON b.bookmark_id = latest.bookmark_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = b.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE latest.status <> 'ok'
+  AND (sqlc.narg(status)::text IS NULL OR latest.status = sqlc.narg(status))
+ORDER BY latest.checked_at DESC
+LIMIT sqlc.arg(row_limit)
+OFFSET sqlc.arg(row_offset);
+
+-- name: CountLinkIssues :one
+SELECT COUNT(*)
+FROM (
+    SELECT DISTINCT ON (c.bookmark_id) c.status
+    FROM bookmark_link_checks c
+    ORDER BY c.bookmark_id, c.checked_at DESC
+) latest
+WHERE latest.status <> 'ok'
+  AND (sqlc.narg(status)::text IS NULL OR latest.status = sqlc.narg(status));
+
+-- name: InsertBookmarkArchive :one
+INSERT INTO bookmark_archives (
+    bookmark_id,
+    url,
+    html_hash,
+    html_size,
+    warc_hash,
+    warc_size,
+    resource_count,
+    failed_resources
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING archive_id, archived_at;
+
+-- name: ListBookmarkArchives :many
+SELECT
+    archive_id,
+    bookmark_id,
+    url,
+    html_hash,
+    html_size,
+    warc_hash,
+    warc_size,
+    resource_count,
+    failed_resources,
+    archived_at
+FROM bookmark_archives
+WHERE bookmark_id = $1
+ORDER BY archived_at DESC;
+
+-- name: CountArchiveBlobReferences :one
+SELECT COUNT(*)
+FROM bookmark_archives
+WHERE html_hash = $1 OR warc_hash = $1;
+
+-- name: LockArchiveBlob :exec
+SELECT pg_advisory_lock(hashtextextended('archive-blob:' || sqlc.arg(hash)::text, 0));
+
+-- name: UnlockArchiveBlob :exec
+SELECT pg_advisory_unlock(hashtextextended('archive-blob:' || sqlc.arg(hash)::text, 0));
+
+-- name: InsertBookmarkAnnotation :one
+INSERT INTO bookmark_annotations (
+    bookmark_id,
+    quote_exact,
+    quote_prefix,
+    quote_suffix,
+    position_start,
+    position_end,
+    note
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING annotation_id, created_at, updated_at;
+
+-- name: UpdateBookmarkAnnotation :one
+UPDATE bookmark_annotations
+SET
+    quote_exact = $2,
+    quote_prefix = $3,
+    quote_suffix = $4,
+    position_start = $5,
+    position_end = $6,
+    note = $7,
+    updated_at = now()
+WHERE annotation_id = $1
+RETURNING updated_at;
+
+-- name: SetBookmarkAnnotationEmbedding :exec
+UPDATE bookmark_annotations
+SET embedding = sqlc.arg(embedding)::vector,
+    embedding_model = sqlc.narg(embedding_model)::text
+WHERE annotation_id = sqlc.arg(annotation_id);
+
+-- name: GetBookmarkAnnotation :one
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags
+FROM bookmark_annotations a
+WHERE a.annotation_id = $1;
+
+-- name: ListBookmarkAnnotations :many
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags
+FROM bookmark_annotations a
+WHERE a.bookmark_id = $1
+ORDER BY a.position_start, a.created_at;
+
+-- name: ListAnnotations :many
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags,
+    b.url,
+    bt.title
+FROM bookmark_annotations a
+JOIN bookmarks b ON b.bookmark_id = a.bookmark_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = b.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE (sqlc.narg(search)::text IS NULL
+       OR a.quote_exact ILIKE '%' || sqlc.narg(search) || '%'
+       OR a.note ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id AND t.name = sqlc.narg(tag)
+      ))
+ORDER BY a.created_at DESC
+LIMIT sqlc.arg(row_limit)
+OFFSET sqlc.arg(row_offset);
+
+-- name: CountAnnotations :one
+SELECT COUNT(*)
+FROM bookmark_annotations a
+WHERE (sqlc.narg(search)::text IS NULL
+       OR a.quote_exact ILIKE '%' || sqlc.narg(search) || '%'
+       OR a.note ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+        SELECT 1
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id AND t.name = sqlc.narg(tag)
+      ));
+
+-- name: SearchSimilarAnnotations :many
+SELECT
+    a.annotation_id,
+    a.bookmark_id,
+    a.quote_exact,
+    a.quote_prefix,
+    a.quote_suffix,
+    a.position_start,
+    a.position_end,
+    a.note,
+    a.created_at,
+    a.updated_at,
+    COALESCE((
+        SELECT array_agg(t.name ORDER BY t.name)
+        FROM bookmark_annotation_tags bat
+        JOIN tags t ON bat.tag_id = t.id
+        WHERE bat.annotation_id = a.annotation_id
+    ), '{}')::text[] AS tags,
+    b.url,
+    bt.title,
+    (1 - (a.embedding <=> sqlc.arg(embedding)::vector))::float8 AS similarity
+FROM bookmark_annotations a
+JOIN bookmarks b ON b.bookmark_id = a.bookmark_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = b.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE a.embedding IS NOT NULL
+  AND a.embedding_model = public.active_embedding_model()
+  AND a.embedding_dimensions = vector_dims(sqlc.arg(embedding)::vector)
+ORDER BY a.embedding <=> sqlc.arg(embedding)::vector
+LIMIT sqlc.arg(row_limit);
+
+-- name: InsertBookmarkAnnotationTag :exec
+INSERT INTO bookmark_annotation_tags (annotation_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmarkAnnotationTags :exec
+DELETE FROM bookmark_annotation_tags
+WHERE annotation_id = $1;
+
+-- name: DeleteBookmarkAnnotation :execrows
+DELETE FROM bookmark_annotations
+WHERE annotation_id = $1;
+
+-- name: DeleteAnnotationEntityReferences :exec
+DELETE FROM entity_references
+WHERE source_type = 'annotation' AND source_id = $1;
+
+-- name: DeleteBookmarkAnnotations :exec
+WITH deleted AS (
+    DELETE FROM bookmark_annotations
+    WHERE bookmark_id = $1
+    RETURNING annotation_id
+)
+DELETE FROM entity_references
+WHERE source_type = 'annotation'
+  AND source_id IN (SELECT annotation_id FROM deleted);
+
+-- name: InsertBookmarkEvaluation :one
+INSERT INTO bookmark_evaluations (
+    bookmark_id,
+    failed_fetch,
+    summary_id,
+    summary_comments,
+    summary_eval,
+    summary_strategy,
+    summary_model,
+    summary_prompt_version,
+    questions_eval,
+    questions_ids,
+    questions_strategy,
+    questions_model,
+    questions_prompt_version
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, created_at;
+
+-- name: ListBookmarkEvaluations :many
+SELECT
+    id,
+    bookmark_id,
+    failed_fetch,
+    summary_id,
+    summary_comments,
+    summary_eval,
+    summary_strategy,
+    summary_model,
+    summary_prompt_version,
+    questions_eval,
+    questions_ids,
+    questions_strategy,
+    questions_model,
+    questions_prompt_version,
+    created_at
+FROM bookmark_evaluations
+WHERE bookmark_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetLatestContentReference :one
+SELECT id
+FROM bookmark_content_references
+WHERE bookmark_id = $1 AND strategy = $2
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetContentReferenceGenerations :many
+SELECT
+    id,
+    strategy,
+    COALESCE(extra->>'model', '')::text AS model,
+    COALESCE(extra->>'prompt_version', '')::text AS prompt_version
+FROM bookmark_content_references
+WHERE bookmark_id = sqlc.arg(bookmark_id)
+  AND id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: ReportBookmarkEvaluations :many
+SELECT
+    'summary'::text AS kind,
+    e.summary_strategy AS strategy,
+    e.summary_model AS model,
+    e.summary_prompt_version AS prompt_version,
+    (CASE WHEN sqlc.narg(period)::text IS NULL THEN NULL ELSE date_trunc(sqlc.narg(period)::text, e.created_at) END)::timestamptz AS period_start,
+    COUNT(*) AS evaluations,
+    AVG(e.summary_eval)::float8 AS average_score,
+    MIN(e.summary_eval)::integer AS min_score,
+    MAX(e.summary_eval)::integer AS max_score,
+    MIN(e.created_at)::timestamptz AS first_evaluated_at,
+    MAX(e.created_at)::timestamptz AS last_evaluated_at
+FROM bookmark_evaluations e
+WHERE e.summary_eval IS NOT NULL
+  AND (sqlc.narg(since)::timestamptz IS NULL OR e.created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR e.created_at < sqlc.narg(until))
+GROUP BY 2, 3, 4, 5
+UNION ALL
+SELECT
+    'questions'::text AS kind,
+    e.questions_strategy AS strategy,
+    e.questions_model AS model,
+    e.questions_prompt_version AS prompt_version,
+    (CASE WHEN sqlc.narg(period)::text IS NULL THEN NULL ELSE date_trunc(sqlc.narg(period)::text, e.created_at) END)::timestamptz AS period_start,
+    COUNT(*) AS evaluations,
+    AVG(e.questions_eval)::float8 AS average_score,
+    MIN(e.questions_eval)::integer AS min_score,
+    MAX(e.questions_eval)::integer AS max_score,
+    MIN(e.created_at)::timestamptz AS first_evaluated_at,
+    MAX(e.created_at)::timestamptz AS last_evaluated_at
+FROM bookmark_evaluations e
+WHERE e.questions_eval IS NOT NULL
+  AND (sqlc.narg(since)::timestamptz IS NULL OR e.created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR e.created_at < sqlc.narg(until))
+GROUP BY 2, 3, 4, 5
+ORDER BY 1, 5 NULLS FIRST, 2, 3, 4;
+
+-- name: CountBookmarkEvaluations :one
+SELECT
+    COUNT(*) AS evaluations,
+    COUNT(*) FILTER (WHERE failed_fetch) AS failed_fetches
+FROM bookmark_evaluations
+WHERE (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until));
+
+-- name: ListBookmarksWithRejectedQuestions :many
+SELECT
+    o.ref::uuid AS bookmark_id,
+    COUNT(*) AS rejections,
+    MIN(o.creation_date)::bigint AS first_rejected_at
+FROM observations o
+INNER JOIN bookmarks b ON b.bookmark_id = o.ref
+WHERE (o.type = 'qa-delete' OR (o.type = 'qa-feedback' AND o.data->>'feedbackType' IN ('downvote', 'trash')))
+  AND NOT EXISTS (
+      SELECT 1 FROM observations r
+      WHERE r.type = 'qa-regeneration'
+        AND r.data->>'status' = 'succeeded'
+        AND r.data->'feedbackIds' @> jsonb_build_array(o.observation_id::text)
+  )
+  AND (
+      SELECT COUNT(*) FROM observations r
+      WHERE r.type = 'qa-regeneration'
+        AND r.data->>'status' = 'failed'
+        AND r.data->'feedbackIds' @> jsonb_build_array(o.observation_id::text)
+  ) < sqlc.arg(max_attempts)::int
+GROUP BY o.ref
+ORDER BY first_rejected_at
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListRejectedQuestions :many
+SELECT
+    o.observation_id,
+    o.type,
+    o.data,
+    o.creation_date
+FROM observations o
+WHERE o.ref = sqlc.arg(bookmark_id)
+  AND (o.type = 'qa-delete' OR (o.type = 'qa-feedback' AND o.data->>'feedbackType' IN ('downvote', 'trash')))
+  AND NOT EXISTS (
+      SELECT 1 FROM observations r
+      WHERE r.type = 'qa-regeneration'
+        AND r.data->>'status' = 'succeeded'
+        AND r.data->'feedbackIds' @> jsonb_build_array(o.observation_id::text)
+  )
+ORDER BY o.creation_date, o.observation_id;
+
+-- name: CreateLinkedObservation :one
+INSERT INTO observations (data, type, source, tags, ref, parent)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING observation_id;
+
+-- name: DeleteQuestionReferences :many
+DELETE FROM bookmark_content_references
+WHERE bookmark_id = sqlc.arg(bookmark_id)
+  AND strategy = sqlc.arg(strategy)
+  AND (id = ANY(sqlc.arg(ids)::uuid[]) OR content = ANY(sqlc.arg(contents)::text[]))
+RETURNING id;
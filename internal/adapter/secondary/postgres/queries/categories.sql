@@ -1,7 +1,30 @@
 -- name: ListCategoriesWithSources :many
+-- Counts the bookmarks filed directly under each category and anywhere in its subtree
+WITH RECURSIVE tree AS (
+    SELECT category_id AS root_id, category_id
+    FROM categories
+    UNION
+    SELECT t.root_id, child.category_id
+    FROM categories child
+    JOIN tree t ON child.parent_id = t.category_id
+),
+direct_counts AS (
+    SELECT category_id, COUNT(DISTINCT bookmark_id) AS bookmarks
+    FROM bookmark_category
+    GROUP BY category_id
+),
+subtree_counts AS (
+    SELECT t.root_id, COUNT(DISTINCT bc.bookmark_id) AS bookmarks
+    FROM tree t
+    JOIN bookmark_category bc ON bc.category_id = t.category_id
+    GROUP BY t.root_id
+)
 SELECT
     c.category_id,
     c.name,
+    c.parent_id,
+    COALESCE(dc.bookmarks, 0)::bigint AS bookmark_count,
+    COALESCE(sc.bookmarks, 0)::bigint AS subtree_bookmark_count,
     COALESCE(
         json_agg(
             json_build_object(
@@ -15,13 +38,16 @@ SELECT
     ) as sources
 FROM categories c
 LEFT JOIN category_sources s ON c.category_id = s.category_id
-GROUP BY c.category_id, c.name
+LEFT JOIN direct_counts dc ON dc.category_id = c.category_id
+LEFT JOIN subtree_counts sc ON sc.root_id = c.category_id
+GROUP BY c.category_id, c.name, c.parent_id, dc.bookmarks, sc.bookmarks
 ORDER BY c.name;
 
 -- name: GetCategory :one
 SELECT
     category_id,
-    name
+    name,
+    parent_id
 FROM categories
 WHERE category_id = $1;
 
@@ -33,6 +59,61 @@ WHERE category_id = $1;
 -- name: MergeCategories :exec
 CALL merge_categories($1::uuid, $2::uuid);
 
+-- name: GetCategorySubtreeIDs :many
+-- The category and all of its descendants
+WITH RECURSIVE subtree AS (
+    SELECT category_id
+    FROM categories
+    WHERE category_id = sqlc.arg(category_id)::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+SELECT category_id FROM subtree;
+
+-- name: LockCategoryTree :exec
+-- Held until the end of the transaction by every change of parents, so that two concurrent moves cannot each
+-- pass the cycle check against a tree the other is changing
+SELECT pg_advisory_xact_lock(hashtextextended('category-tree', 0));
+
+-- name: MoveCategory :one
+-- Moves a category under a new parent, or to the top level for a NULL parent. Nothing is updated when the
+-- parent is the category itself or one of its descendants
+WITH RECURSIVE subtree AS (
+    SELECT category_id
+    FROM categories
+    WHERE category_id = sqlc.arg(category_id)::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+UPDATE categories
+SET parent_id = sqlc.narg(parent_id)::uuid
+WHERE category_id = sqlc.arg(category_id)::uuid
+  AND (sqlc.narg(parent_id)::uuid IS NULL
+       OR sqlc.narg(parent_id)::uuid NOT IN (SELECT category_id FROM subtree))
+RETURNING category_id;
+
+-- name: ReparentCategoryChildren :many
+-- Moves the children of a category under a new parent, unless that parent lies within the category's subtree
+WITH RECURSIVE subtree AS (
+    SELECT category_id
+    FROM categories
+    WHERE category_id = sqlc.arg(category_id)::uuid
+    UNION
+    SELECT child.category_id
+    FROM categories child
+    JOIN subtree s ON child.parent_id = s.category_id
+)
+UPDATE categories
+SET parent_id = sqlc.narg(parent_id)::uuid
+WHERE parent_id = sqlc.arg(category_id)::uuid
+  AND (sqlc.narg(parent_id)::uuid IS NULL
+       OR sqlc.narg(parent_id)::uuid NOT IN (SELECT category_id FROM subtree))
+RETURNING category_id;
+
 -- name: CreateCategorySource :one
 INSERT INTO category_sources (
     category_id,
@@ -61,3 +142,220 @@ SELECT
     raw_source
 FROM category_sources
 WHERE id = $1;
+
+-- name: GetCategoryByName :one
+SELECT
+    category_id,
+    name,
+    parent_id
+FROM categories
+WHERE lower(name) = lower(sqlc.arg(name))
+  AND parent_id IS NOT DISTINCT FROM sqlc.narg(parent_id)::uuid
+ORDER BY name
+LIMIT 1;
+
+-- name: CreateCategory :one
+INSERT INTO categories (name, parent_id)
+VALUES (sqlc.arg(name), sqlc.narg(parent_id)::uuid)
+RETURNING category_id, name, parent_id;
+
+-- name: ListCategoryProfiles :many
+SELECT
+    p.category_id,
+    c.name,
+    p.source_text,
+    p.bookmark_count,
+    p.updated_at
+FROM category_profiles p
+JOIN categories c ON c.category_id = p.category_id
+WHERE p.embedding_model = public.active_embedding_model()
+ORDER BY c.name;
+
+-- name: RefreshCategoryProfile :one
+-- The profile is the mean of the latest summary embedding of every bookmark in the category and of the
+-- embedding of its name and sources, all made by the given model. A NULL source embedding keeps the stored one
+-- unless it was made by another model
+WITH members AS (
+    SELECT DISTINCT ON (bcr.bookmark_id) bcr.embedding
+    FROM bookmark_category bc
+    JOIN bookmark_content_references bcr ON bcr.bookmark_id = bc.bookmark_id
+    WHERE bc.category_id = sqlc.arg(category_id)::uuid
+      AND bcr.strategy = 'summary-reader'
+      AND bcr.embedding IS NOT NULL
+      AND bcr.embedding_model = sqlc.narg(embedding_model)::text
+    ORDER BY bcr.bookmark_id, bcr.created_at DESC
+),
+source AS (
+    SELECT COALESCE(
+        sqlc.narg(source_embedding)::vector,
+        (
+            SELECT p.source_embedding FROM category_profiles p
+            WHERE p.category_id = sqlc.arg(category_id)::uuid AND p.embedding_model = sqlc.narg(embedding_model)::text
+        )
+    ) AS embedding
+),
+vectors AS (
+    SELECT embedding FROM members
+    UNION ALL
+    SELECT embedding FROM source WHERE embedding IS NOT NULL
+)
+INSERT INTO category_profiles (category_id, embedding, embedding_model, source_text, source_embedding, bookmark_count, updated_at)
+SELECT
+    sqlc.arg(category_id)::uuid,
+    avg(vectors.embedding),
+    sqlc.narg(embedding_model)::text,
+    sqlc.arg(source_text)::text,
+    (SELECT embedding FROM source),
+    (SELECT count(*) FROM members)::integer,
+    now()
+FROM vectors
+HAVING count(*) > 0
+ON CONFLICT (category_id) DO UPDATE SET
+    embedding = EXCLUDED.embedding,
+    embedding_model = EXCLUDED.embedding_model,
+    source_text = EXCLUDED.source_text,
+    source_embedding = EXCLUDED.source_embedding,
+    bookmark_count = EXCLUDED.bookmark_count,
+    updated_at = EXCLUDED.updated_at
+RETURNING category_id, source_text, bookmark_count, updated_at;
+
+-- name: DeleteCategoryProfile :exec
+DELETE FROM category_profiles
+WHERE category_id = $1;
+
+-- name: RankCategoryProfiles :many
+-- Ranks category profiles by similarity to the latest summary embedding of the bookmark, leaving out the
+-- categories already rejected for it
+WITH target AS (
+    SELECT embedding
+    FROM bookmark_content_references
+    WHERE bookmark_id = sqlc.arg(bookmark_id)::uuid
+      AND strategy = 'summary-reader'
+      AND embedding IS NOT NULL
+      AND embedding_model = public.active_embedding_model()
+    ORDER BY created_at DESC
+    LIMIT 1
+)
+SELECT
+    p.category_id,
+    c.name,
+    (1 - (p.embedding <=> t.embedding))::float8 AS similarity
+FROM category_profiles p
+JOIN categories c ON c.category_id = p.category_id
+CROSS JOIN target t
+WHERE p.embedding_model = public.active_embedding_model()
+  AND NOT EXISTS (
+    SELECT 1 FROM bookmark_category_suggestions s
+    WHERE s.bookmark_id = sqlc.arg(bookmark_id)::uuid
+      AND s.category_id = p.category_id
+      AND s.status = 'rejected'
+)
+ORDER BY p.embedding <=> t.embedding
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListBookmarksToCategorize :many
+-- Uncategorized bookmarks with a summary embedding and no suggestion awaiting review
+SELECT b.bookmark_id
+FROM bookmarks b
+WHERE NOT EXISTS (SELECT 1 FROM bookmark_category bc WHERE bc.bookmark_id = b.bookmark_id)
+  AND EXISTS (
+      SELECT 1 FROM bookmark_content_references bcr
+      WHERE bcr.bookmark_id = b.bookmark_id
+        AND bcr.strategy = 'summary-reader'
+        AND bcr.embedding IS NOT NULL
+        AND bcr.embedding_model = public.active_embedding_model()
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM bookmark_category_suggestions s
+      WHERE s.bookmark_id = b.bookmark_id
+        AND s.status IN ('pending', 'accepted', 'auto')
+  )
+ORDER BY b.creation_date DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeletePendingCategorySuggestions :exec
+DELETE FROM bookmark_category_suggestions
+WHERE bookmark_id = $1
+  AND status = 'pending';
+
+-- name: UpsertCategorySuggestion :one
+INSERT INTO bookmark_category_suggestions (bookmark_id, category_id, confidence, status, reviewed_at)
+VALUES (
+    sqlc.arg(bookmark_id),
+    sqlc.arg(category_id),
+    sqlc.arg(confidence),
+    sqlc.arg(status),
+    CASE WHEN sqlc.arg(status)::text = 'pending' THEN NULL ELSE now() END
+)
+ON CONFLICT (bookmark_id, category_id) DO UPDATE SET
+    confidence = EXCLUDED.confidence,
+    status = EXCLUDED.status,
+    created_at = now(),
+    reviewed_at = EXCLUDED.reviewed_at
+RETURNING suggestion_id;
+
+-- name: GetCategorySuggestion :one
+SELECT
+    s.suggestion_id,
+    s.bookmark_id,
+    b.url,
+    bt.title,
+    s.category_id,
+    c.name AS category_name,
+    s.confidence,
+    s.status,
+    s.created_at,
+    s.reviewed_at
+FROM bookmark_category_suggestions s
+JOIN bookmarks b ON b.bookmark_id = s.bookmark_id
+JOIN categories c ON c.category_id = s.category_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = s.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE s.suggestion_id = $1;
+
+-- name: ListCategorySuggestions :many
+SELECT
+    s.suggestion_id,
+    s.bookmark_id,
+    b.url,
+    bt.title,
+    s.category_id,
+    c.name AS category_name,
+    s.confidence,
+    s.status,
+    s.created_at,
+    s.reviewed_at
+FROM bookmark_category_suggestions s
+JOIN bookmarks b ON b.bookmark_id = s.bookmark_id
+JOIN categories c ON c.category_id = s.category_id
+LEFT JOIN LATERAL (
+    SELECT t.title
+    FROM bookmark_titles t
+    WHERE t.bookmark_id = s.bookmark_id
+    LIMIT 1
+) bt ON true
+WHERE s.status = sqlc.arg(status)
+ORDER BY s.created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountCategorySuggestions :one
+SELECT COUNT(*)
+FROM bookmark_category_suggestions
+WHERE status = sqlc.arg(status);
+
+-- name: UpdateCategorySuggestionStatus :execrows
+UPDATE bookmark_category_suggestions
+SET status = sqlc.arg(status),
+    reviewed_at = now()
+WHERE suggestion_id = sqlc.arg(suggestion_id)
+  AND status = 'pending';
+
+-- name: ReopenCategorySuggestion :exec
+UPDATE bookmark_category_suggestions
+SET status = 'pending',
+    reviewed_at = NULL
+WHERE suggestion_id = sqlc.arg(suggestion_id);
@@ -0,0 +1,243 @@
+-- name: GetActiveEmbeddingModel :one
+SELECT name
+FROM embedding_models
+WHERE active;
+
+-- name: DeactivateEmbeddingModels :exec
+UPDATE embedding_models
+SET active = false
+WHERE active AND name <> sqlc.arg(name);
+
+-- name: ActivateEmbeddingModel :exec
+INSERT INTO embedding_models (name, active, activated_at)
+VALUES (sqlc.arg(name), true, now())
+ON CONFLICT (name) DO UPDATE SET
+    active = true,
+    activated_at = now()
+WHERE NOT embedding_models.active;
+
+-- name: ClaimUntaggedEmbeddings :one
+-- Vectors stored before their model was recorded are taken to be of the given model
+WITH refs AS (
+    UPDATE bookmark_content_references SET embedding_model = sqlc.arg(name)::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+annotations AS (
+    UPDATE bookmark_annotations SET embedding_model = sqlc.arg(name)::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+sessions AS (
+    UPDATE session_summaries SET embedding_model = sqlc.arg(name)::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+items AS (
+    UPDATE item_semantic_index SET embedding_model = sqlc.arg(name)::text
+    WHERE embedding IS NOT NULL AND embedding_model IS NULL
+    RETURNING 1
+),
+profiles AS (
+    UPDATE category_profiles SET embedding_model = sqlc.arg(name)::text
+    WHERE embedding_model IS NULL
+    RETURNING 1
+)
+SELECT (
+    (SELECT count(*) FROM refs) +
+    (SELECT count(*) FROM annotations) +
+    (SELECT count(*) FROM sessions) +
+    (SELECT count(*) FROM items) +
+    (SELECT count(*) FROM profiles)
+)::bigint AS claimed;
+
+-- name: ListEmbeddingModelUsage :many
+SELECT
+    v.table_name,
+    v.embedding_model,
+    v.embedding_dimensions,
+    count(*)::bigint AS vectors
+FROM (
+    SELECT 'bookmark_content_references'::text AS table_name, embedding_model, embedding_dimensions
+    FROM bookmark_content_references WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'bookmark_annotations'::text, embedding_model, embedding_dimensions
+    FROM bookmark_annotations WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'session_summaries'::text, embedding_model, embedding_dimensions
+    FROM session_summaries WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'item_semantic_index'::text, embedding_model, embedding_dimensions
+    FROM item_semantic_index WHERE embedding IS NOT NULL
+    UNION ALL
+    SELECT 'category_profiles'::text, embedding_model, vector_dims(embedding)
+    FROM category_profiles
+) v
+GROUP BY v.table_name, v.embedding_model, v.embedding_dimensions
+ORDER BY v.table_name, vectors DESC;
+
+-- name: CreateEmbeddingMigration :one
+INSERT INTO embedding_migrations (
+    source_model, target_provider, target_url, target_model, target_dimensions, target_api_key, remaining
+)
+VALUES (
+    public.active_embedding_model(),
+    sqlc.arg(target_provider),
+    sqlc.arg(target_url),
+    sqlc.arg(target_model),
+    sqlc.arg(target_dimensions),
+    sqlc.arg(target_api_key),
+    sqlc.arg(remaining)
+)
+RETURNING *;
+
+-- name: GetEmbeddingMigration :one
+SELECT *
+FROM embedding_migrations
+WHERE migration_id = $1;
+
+-- name: GetOpenEmbeddingMigration :one
+SELECT *
+FROM embedding_migrations
+WHERE status IN ('running', 'ready')
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: ListEmbeddingMigrations :many
+SELECT *
+FROM embedding_migrations
+ORDER BY started_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdateEmbeddingMigrationProgress :one
+UPDATE embedding_migrations
+SET
+    done = done + sqlc.arg(staged)::integer,
+    remaining = sqlc.arg(remaining)::integer,
+    status = sqlc.arg(status)::text,
+    error = sqlc.narg(error)::text,
+    updated_at = now()
+WHERE migration_id = sqlc.arg(migration_id) AND status IN ('running', 'ready')
+RETURNING *;
+
+-- name: FinishEmbeddingMigration :one
+UPDATE embedding_migrations
+SET
+    status = sqlc.arg(status)::text,
+    remaining = sqlc.arg(remaining)::integer,
+    error = NULL,
+    updated_at = now(),
+    finished_at = now()
+WHERE migration_id = sqlc.arg(migration_id) AND status IN ('running', 'ready')
+RETURNING *;
+
+-- name: CountEmbeddingMigrationRows :one
+-- Rows of the corpus still to be embedded with the target model: those of another model without a staged
+-- vector made from their current text
+SELECT count(*)::bigint
+FROM embedding_corpus c
+WHERE c.embedding_model IS DISTINCT FROM sqlc.arg(target_model)::text
+  AND NOT EXISTS (
+      SELECT 1 FROM embedding_migration_vectors v
+      WHERE v.migration_id = sqlc.arg(migration_id)
+        AND v.table_name = c.table_name
+        AND v.row_id = c.row_id
+        AND v.source_hash = c.source_hash
+  );
+
+-- name: ListEmbeddingMigrationRows :many
+SELECT c.table_name, c.row_id, c.text, c.extra_text, c.source_hash
+FROM embedding_corpus c
+WHERE c.embedding_model IS DISTINCT FROM sqlc.arg(target_model)::text
+  AND NOT EXISTS (
+      SELECT 1 FROM embedding_migration_vectors v
+      WHERE v.migration_id = sqlc.arg(migration_id)
+        AND v.table_name = c.table_name
+        AND v.row_id = c.row_id
+        AND v.source_hash = c.source_hash
+  )
+ORDER BY c.table_name, c.row_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: StageEmbeddingMigrationVector :exec
+INSERT INTO embedding_migration_vectors (migration_id, table_name, row_id, source_hash, embedding)
+VALUES (sqlc.arg(migration_id), sqlc.arg(table_name), sqlc.arg(row_id), sqlc.arg(source_hash), sqlc.arg(embedding)::vector)
+ON CONFLICT (migration_id, table_name, row_id) DO UPDATE SET
+    source_hash = EXCLUDED.source_hash,
+    embedding = EXCLUDED.embedding,
+    created_at = now();
+
+-- name: ApplyStagedContentReferenceEmbeddings :execrows
+-- Staged vectors only replace the stored ones while the text they were made from is unchanged
+UPDATE bookmark_content_references t
+SET embedding = v.embedding, embedding_model = sqlc.arg(target_model)::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = sqlc.arg(migration_id)
+  AND v.table_name = 'bookmark_content_references'
+  AND t.id = v.row_id;
+
+-- name: ApplyStagedAnnotationEmbeddings :execrows
+UPDATE bookmark_annotations t
+SET embedding = v.embedding, embedding_model = sqlc.arg(target_model)::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = sqlc.arg(migration_id)
+  AND v.table_name = 'bookmark_annotations'
+  AND t.annotation_id = v.row_id;
+
+-- name: ApplyStagedSessionSummaryEmbeddings :execrows
+UPDATE session_summaries t
+SET embedding = v.embedding, embedding_model = sqlc.arg(target_model)::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = sqlc.arg(migration_id)
+  AND v.table_name = 'session_summaries'
+  AND t.id = v.row_id;
+
+-- name: ApplyStagedItemEmbeddings :execrows
+UPDATE item_semantic_index t
+SET embedding = v.embedding, embedding_model = sqlc.arg(target_model)::text
+FROM embedding_migration_vectors v
+JOIN embedding_corpus c ON c.table_name = v.table_name AND c.row_id = v.row_id AND c.source_hash = v.source_hash
+WHERE v.migration_id = sqlc.arg(migration_id)
+  AND v.table_name = 'item_semantic_index'
+  AND t.id = v.row_id;
+
+-- name: DeleteEmbeddingMigrationVectors :exec
+DELETE FROM embedding_migration_vectors
+WHERE migration_id = $1;
+
+-- name: TouchCachedEmbeddings :many
+-- Counts a hit on every cached vector found and returns them in their text form
+UPDATE embedding_cache
+SET hits = hits + 1, last_used_at = now()
+WHERE cache_key = ANY(sqlc.arg(cache_keys)::text[])
+RETURNING cache_key, embedding::text AS embedding;
+
+-- name: StoreCachedEmbedding :exec
+INSERT INTO embedding_cache (cache_key, model, embedding)
+VALUES (sqlc.arg(cache_key), sqlc.arg(model), sqlc.arg(embedding)::vector)
+ON CONFLICT (cache_key) DO UPDATE SET
+    embedding = EXCLUDED.embedding,
+    last_used_at = now();
+
+-- name: GetEmbeddingCacheUsage :one
+SELECT
+    count(*)::bigint AS entries,
+    COALESCE(sum(hits), 0)::bigint AS hits,
+    min(last_used_at)::timestamp AS oldest_use
+FROM embedding_cache;
+
+-- name: EvictUnusedCachedEmbeddings :execrows
+DELETE FROM embedding_cache
+WHERE last_used_at < sqlc.arg(used_before);
+
+-- name: EvictExcessCachedEmbeddings :execrows
+-- Keeps the max_entries most recently used vectors
+DELETE FROM embedding_cache
+WHERE cache_key IN (
+    SELECT cache_key FROM embedding_cache
+    ORDER BY last_used_at DESC
+    OFFSET sqlc.arg(max_entries)
+);
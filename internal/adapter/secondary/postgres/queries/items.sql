@@ -119,6 +119,8 @@ FROM items i
 INNER JOIN item_semantic_index isi ON i.id = isi.item_id
 LEFT JOIN item_tags it ON i.id = it.item_id
 LEFT JOIN tags t ON it.tag_id = t.id
+WHERE isi.embedding_model = public.active_embedding_model()
+  AND isi.embedding_dimensions = vector_dims($1::vector)
 GROUP BY i.id, i.title, i.created, i.modified, isi.embedding
 ORDER BY isi.embedding <=> $1::vector
 LIMIT $2;
@@ -14,3 +14,16 @@ WHERE ref = $1 AND type = 'qa-feedback';
 -- name: DeleteBookmarkContentReference :exec
 DELETE FROM bookmark_content_references
 WHERE id = $1 AND bookmark_id = $2;
+
+-- name: ListFeedbackObservations :many
+SELECT data
+FROM observations
+WHERE type = 'qa-feedback'
+ORDER BY creation_date, observation_id;
+
+-- name: ListObservationsByType :many
+SELECT observation_id, data, type, source, tags, parent, ref, creation_date
+FROM observations
+WHERE type = sqlc.arg(type)
+ORDER BY creation_date DESC
+LIMIT sqlc.arg(row_limit);
@@ -96,6 +96,8 @@ WITH similar_qa AS (
         1 - (bcr.embedding <=> sqlc.arg(embedding)::vector) as similarity
     FROM bookmark_content_references as bcr
     WHERE bcr.strategy = 'qa-v2-passage'
+      AND bcr.embedding_model = public.active_embedding_model()
+      AND bcr.embedding_dimensions = vector_dims(sqlc.arg(embedding)::vector)
     ORDER BY similarity DESC
     LIMIT sqlc.arg(search_limit)::int
 )
@@ -110,5 +112,12 @@ SELECT
 FROM similar_qa sqa
 INNER JOIN bookmarks b ON sqa.bookmark_id = b.bookmark_id
 LEFT JOIN bookmark_titles bt ON b.bookmark_id = bt.bookmark_id
-LEFT JOIN processed_contents reader ON b.bookmark_id = reader.bookmark_id AND reader.strategy_used = 'reader'
+LEFT JOIN LATERAL (
+    SELECT processed_content
+    FROM processed_contents
+    WHERE bookmark_id = b.bookmark_id
+      AND strategy_used IN ('reader', 'pdf', 'epub', 'text', 'markdown')
+    ORDER BY array_position(ARRAY['reader', 'pdf', 'epub', 'text', 'markdown'], strategy_used)
+    LIMIT 1
+) reader ON true
 ORDER BY sqa.similarity DESC;
@@ -12,6 +12,8 @@ FROM session_summaries ss
 JOIN sessions s ON ss.session_id = s.session_id
 JOIN rooms r ON s.room_id = r.room_id
 WHERE ss.summary <> '' AND ss.summary IS NOT NULL
+  AND ss.embedding_model = public.active_embedding_model()
+  AND ss.embedding_dimensions = vector_dims($1::vector)
 ORDER BY similarity DESC
 LIMIT $2;
 
@@ -2,9 +2,15 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pgvector/pgvector-go"
@@ -45,6 +51,10 @@ func (r *BookmarkRepository) ListBookmarks(
 	searchQuery *string,
 	startDate *time.Time,
 	endDate *time.Time,
+	failedStage *string,
+	author *string,
+	site *string,
+	publishedYear *int32,
 	limit, offset int32,
 ) ([]entity.BookmarkWithTitle, error) {
 	queries := db.New(r.pool)
@@ -69,13 +79,35 @@ func (r *BookmarkRepository) ListBookmarks(
 		endDatePg = pgtype.Timestamp{Time: *endDate, Valid: true}
 	}
 
+	var failedStageVal string
+	if failedStage != nil {
+		failedStageVal = *failedStage
+	}
+
+	var authorVal, siteVal string
+	if author != nil {
+		authorVal = *author
+	}
+	if site != nil {
+		siteVal = *site
+	}
+
+	var publishedYearVal int32
+	if publishedYear != nil {
+		publishedYearVal = *publishedYear
+	}
+
 	dbBookmarks, err := queries.ListBookmarks(ctx, db.ListBookmarksParams{
 		Column1: categoryIDVal,
 		Column2: searchQueryVal,
 		Column3: startDatePg,
 		Column4: endDatePg,
 		Limit:   limit,
-		Offset:  offset,
+		Offset:   offset,
+		Column7:  failedStageVal,
+		Column8:  authorVal,
+		Column9:  siteVal,
+		Column10: publishedYearVal,
 	})
 	if err != nil {
 		return nil, err
@@ -100,6 +132,10 @@ func (r *BookmarkRepository) CountBookmarks(
 	searchQuery *string,
 	startDate *time.Time,
 	endDate *time.Time,
+	failedStage *string,
+	author *string,
+	site *string,
+	publishedYear *int32,
 ) (int64, error) {
 	queries := db.New(r.pool)
 
@@ -123,11 +159,33 @@ func (r *BookmarkRepository) CountBookmarks(
 		endDatePg = pgtype.Timestamp{Time: *endDate, Valid: true}
 	}
 
+	var failedStageVal string
+	if failedStage != nil {
+		failedStageVal = *failedStage
+	}
+
+	var authorVal, siteVal string
+	if author != nil {
+		authorVal = *author
+	}
+	if site != nil {
+		siteVal = *site
+	}
+
+	var publishedYearVal int32
+	if publishedYear != nil {
+		publishedYearVal = *publishedYear
+	}
+
 	count, err := queries.CountBookmarks(ctx, db.CountBookmarksParams{
 		Column1: categoryIDVal,
 		Column2: searchQueryVal,
 		Column3: startDatePg,
 		Column4: endDatePg,
+		Column5: failedStageVal,
+		Column6: authorVal,
+		Column7: siteVal,
+		Column8: publishedYearVal,
 	})
 	if err != nil {
 		return 0, err
@@ -158,21 +216,28 @@ func (r *BookmarkRepository) GetBookmarkDetails(ctx context.Context, bookmarkID
 		rawSource = &s
 	}
 
+	httpContent, err := responseBody(dbDetails.HttpContent, dbDetails.StoredHttpContent)
+	if err != nil {
+		return nil, err
+	}
+
 	return &entity.BookmarkDetails{
-		BookmarkID:    dbDetails.BookmarkID,
-		URL:           dbDetails.Url,
-		CreationDate:  dbDetails.CreationDate.Time,
-		CategoryName:  dbDetails.CategoryName,
-		SourceURI:     dbDetails.SourceUri,
-		RawSource:     rawSource,
-		Title:         dbDetails.Title,
-		LynxContent:   dbDetails.LynxContent,
-		ReaderContent: dbDetails.ReaderContent,
-		Summary:       dbDetails.Summary,
-		StatusCode:    dbDetails.StatusCode,
-		Headers:       dbDetails.Headers,
-		HTTPContent:   dbDetails.HttpContent,
-		FetchDate:     &dbDetails.FetchDate.Time,
+		BookmarkID:      dbDetails.BookmarkID,
+		URL:             dbDetails.Url,
+		CreationDate:    dbDetails.CreationDate.Time,
+		CategoryName:    dbDetails.CategoryName,
+		SourceURI:       dbDetails.SourceUri,
+		RawSource:       rawSource,
+		Title:           dbDetails.Title,
+		LynxContent:     dbDetails.LynxContent,
+		ReaderContent:   dbDetails.ReaderContent,
+		ContentStrategy: dbDetails.ContentStrategy,
+		Summary:         dbDetails.Summary,
+		StatusCode:      dbDetails.StatusCode,
+		Headers:         dbDetails.Headers,
+		HTTPContent:     httpContent,
+		FetchDate:       &dbDetails.FetchDate.Time,
+		FetchProfile:    dbDetails.FetchProfile,
 	}, nil
 }
 
@@ -229,6 +294,14 @@ func (r *BookmarkRepository) SearchSimilarBookmarks(
 			Title:        dbBookmark.Title,
 			Summary:      dbBookmark.Summary,
 		}
+
+		// Chunks embedded before positions were recorded have an empty extra
+		if strategy == string(entity.StageChunkedReader) && len(dbBookmark.Extra) > 0 {
+			var chunk entity.ChunkPosition
+			if err := json.Unmarshal(dbBookmark.Extra, &chunk); err == nil && chunk.End > 0 {
+				bookmarks[i].Chunk = &chunk
+			}
+		}
 	}
 
 	return bookmarks, nil
@@ -238,6 +311,7 @@ func (r *BookmarkRepository) UpdateBookmarkQuestion(
 	ctx context.Context,
 	content string,
 	embedding []float32,
+	model string,
 	referenceID, bookmarkID uuid.UUID,
 ) error {
 	queries := db.New(r.pool)
@@ -247,10 +321,11 @@ func (r *BookmarkRepository) UpdateBookmarkQuestion(
 	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
 
 	return queries.UpdateBookmarkQuestion(ctx, db.UpdateBookmarkQuestionParams{
-		Content:    &content,
-		Column2:    &embeddingVec,
-		ID:         referenceID,
-		BookmarkID: bookmarkIDPg,
+		Content:        &content,
+		Column2:        &embeddingVec,
+		ID:             referenceID,
+		BookmarkID:     bookmarkIDPg,
+		EmbeddingModel: convertStringToPtr(model),
 	})
 }
 
@@ -301,17 +376,48 @@ func (r *BookmarkRepository) InsertHttpResponse(
 	statusCode int32,
 	headers string,
 	content []byte,
+	original []byte,
+	originalContentType string,
 	fetchDate time.Time,
+	fetchProfile *string,
 ) error {
-	queries := db.New(r.pool)
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	hash, err := storeResponseBody(ctx, queries, content)
+	if err != nil {
+		return err
+	}
+
+	var originalHash, originalType *string
+	if original != nil {
+		stored, err := storeResponseBody(ctx, queries, original)
+		if err != nil {
+			return err
+		}
+		originalHash, originalType = &stored, &originalContentType
+	}
+
 	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
-	return queries.InsertHttpResponse(ctx, db.InsertHttpResponseParams{
-		BookmarkID: bookmarkIDPg,
-		StatusCode: &statusCode,
-		Headers:    &headers,
-		Content:    content,
-		FetchDate:  pgtype.Timestamp{Time: fetchDate, Valid: true},
+	err = queries.InsertHttpResponse(ctx, db.InsertHttpResponseParams{
+		BookmarkID:          bookmarkIDPg,
+		StatusCode:          &statusCode,
+		Headers:             &headers,
+		ContentHash:         &hash,
+		FetchDate:           pgtype.Timestamp{Time: fetchDate, Valid: true},
+		FetchProfile:        fetchProfile,
+		OriginalContentHash: originalHash,
+		OriginalContentType: originalType,
 	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *BookmarkRepository) GetLatestHttpResponse(ctx context.Context, bookmarkID uuid.UUID) (*output.HTTPResponse, error) {
@@ -332,15 +438,43 @@ func (r *BookmarkRepository) GetLatestHttpResponse(ctx context.Context, bookmark
 		headers = *dbResp.Headers
 	}
 
+	content, err := responseBody(dbResp.Content, dbResp.StoredContent)
+	if err != nil {
+		return nil, err
+	}
+
 	return &output.HTTPResponse{
-		ResponseID: dbResp.ResponseID,
-		StatusCode: statusCode,
-		Headers:    headers,
-		Content:    dbResp.Content,
-		FetchDate:  dbResp.FetchDate.Time,
+		ResponseID:   dbResp.ResponseID,
+		StatusCode:   statusCode,
+		Headers:      headers,
+		Content:      content,
+		FetchDate:    dbResp.FetchDate.Time,
+		FetchProfile: dbResp.FetchProfile,
 	}, nil
 }
 
+func (r *BookmarkRepository) GetOriginalHttpResponseBody(ctx context.Context, responseID uuid.UUID) ([]byte, string, error) {
+	queries := db.New(r.pool)
+	dbBody, err := queries.GetOriginalHttpResponseBody(ctx, responseID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	content, err := responseBody(dbBody.OriginalContent, dbBody.StoredOriginalContent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var contentType string
+	if dbBody.OriginalContentType != nil {
+		contentType = *dbBody.OriginalContentType
+	}
+	return content, contentType, nil
+}
+
 func (r *BookmarkRepository) InsertProcessedContent(
 	ctx context.Context,
 	bookmarkID uuid.UUID,
@@ -355,6 +489,31 @@ func (r *BookmarkRepository) InsertProcessedContent(
 	})
 }
 
+func (r *BookmarkRepository) InsertExtractedContent(
+	ctx context.Context,
+	bookmarkID uuid.UUID,
+	strategyUsed string,
+	document *entity.ExtractedDocument,
+) error {
+	metadata, err := json.Marshal(document.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal extractor metadata: %w", err)
+	}
+	if document.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	return queries.InsertExtractedContent(ctx, db.InsertExtractedContentParams{
+		BookmarkID:       bookmarkIDPg,
+		StrategyUsed:     &strategyUsed,
+		ProcessedContent: &document.Content,
+		Extractor:        &document.Extractor,
+		Metadata:         metadata,
+	})
+}
+
 func (r *BookmarkRepository) GetProcessedContentByStrategy(
 	ctx context.Context,
 	bookmarkID uuid.UUID,
@@ -373,11 +532,51 @@ func (r *BookmarkRepository) GetProcessedContentByStrategy(
 	return dbContent.ProcessedContent, nil
 }
 
+func (r *BookmarkRepository) GetDocumentContent(ctx context.Context, bookmarkID uuid.UUID) (*string, error) {
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	dbContent, err := queries.GetDocumentContent(ctx, bookmarkIDPg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return dbContent.ProcessedContent, nil
+}
+
+func (r *BookmarkRepository) GetExtractedDocument(ctx context.Context, bookmarkID uuid.UUID) (*entity.ExtractedDocument, error) {
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	dbDocument, err := queries.GetExtractedDocument(ctx, bookmarkIDPg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	document := &entity.ExtractedDocument{}
+	if dbDocument.ProcessedContent != nil {
+		document.Content = *dbDocument.ProcessedContent
+	}
+	if dbDocument.Extractor != nil {
+		document.Extractor = *dbDocument.Extractor
+	}
+	if err := json.Unmarshal(dbDocument.Metadata, &document.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse extractor metadata: %w", err)
+	}
+
+	return document, nil
+}
+
 func (r *BookmarkRepository) CreateEmbeddingChunk(
 	ctx context.Context,
 	bookmarkID uuid.UUID,
 	content, strategy string,
 	embedding []float32,
+	model string,
 ) (uuid.UUID, error) {
 	queries := db.New(r.pool)
 
@@ -386,10 +585,11 @@ func (r *BookmarkRepository) CreateEmbeddingChunk(
 	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
 
 	id, err := queries.CreateEmbeddingChunk(ctx, db.CreateEmbeddingChunkParams{
-		BookmarkID: bookmarkIDPg,
-		Content:    &content,
-		Strategy:   &strategy,
-		Column4:    &embeddingVec,
+		BookmarkID:     bookmarkIDPg,
+		Content:        &content,
+		Strategy:       &strategy,
+		Column4:        &embeddingVec,
+		EmbeddingModel: convertStringToPtr(model),
 	})
 	if err != nil {
 		return uuid.Nil, err
@@ -398,6 +598,29 @@ func (r *BookmarkRepository) CreateEmbeddingChunk(
 	return id, nil
 }
 
+func (r *BookmarkRepository) CreateEmbeddingChunkWithExtra(
+	ctx context.Context,
+	bookmarkID uuid.UUID,
+	content, strategy string,
+	embedding []float32,
+	model string,
+	extra json.RawMessage,
+) (uuid.UUID, error) {
+	queries := db.New(r.pool)
+
+	embeddingVec := pgvector.NewVector(embedding)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	return queries.CreateEmbeddingChunkWithExtra(ctx, db.CreateEmbeddingChunkWithExtraParams{
+		BookmarkID:     bookmarkIDPg,
+		Content:        &content,
+		Strategy:       &strategy,
+		Column4:        &embeddingVec,
+		Extra:          extra,
+		EmbeddingModel: convertStringToPtr(model),
+	})
+}
+
 func (r *BookmarkRepository) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (*output.TitleData, error) {
 	queries := db.New(r.pool)
 	dbTitle, err := queries.GetBookmarkTitle(ctx, bookmarkID)
@@ -411,12 +634,17 @@ func (r *BookmarkRepository) GetBookmarkTitle(ctx context.Context, bookmarkID uu
 		readerTitle = &dbTitle.ReaderTitle
 	}
 
+	rawContent, err := responseBody(dbTitle.RawContent, dbTitle.StoredRawContent)
+	if err != nil {
+		return nil, err
+	}
+
 	return &output.TitleData{
 		BookmarkID:    dbTitle.BookmarkID,
 		URL:           dbTitle.Url,
 		CreationDate:  dbTitle.CreationDate.Time,
 		ExistingTitle: dbTitle.ExistingTitle,
-		RawContent:    dbTitle.RawContent,
+		RawContent:    rawContent,
 		ReaderTitle:   readerTitle,
 	}, nil
 }
@@ -469,9 +697,1153 @@ func (r *BookmarkRepository) GetMissingReaderContent(ctx context.Context) ([]ent
 	return bookmarks, nil
 }
 
-// Helper function to convert float32 slice to pgvector format
-func embeddingToString(embedding []float32) string {
-	// This will be replaced by proper pgvector handling in sqlc
-	// For now, return a placeholder
-	return ""
+func (r *BookmarkRepository) GetBookmarkByURL(ctx context.Context, url string) (*entity.Bookmark, error) {
+	queries := db.New(r.pool)
+	dbBookmark, err := queries.GetBookmarkByURL(ctx, url)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entity.Bookmark{
+		BookmarkID:   dbBookmark.BookmarkID,
+		URL:          dbBookmark.Url,
+		CreationDate: dbBookmark.CreationDate.Time,
+	}, nil
+}
+
+func (r *BookmarkRepository) GetBookmarkWithTitle(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkWithTitle, error) {
+	queries := db.New(r.pool)
+	dbBookmark, err := queries.GetBookmarkWithTitle(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.BookmarkWithTitle{
+		BookmarkID:   dbBookmark.BookmarkID,
+		URL:          dbBookmark.Url,
+		CreationDate: dbBookmark.CreationDate.Time,
+		Title:        dbBookmark.Title,
+	}, nil
+}
+
+func (r *BookmarkRepository) CreateBookmark(ctx context.Context, bookmark entity.NewBookmark) (*entity.Bookmark, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+
+	// The new_bookmark notification is only delivered on commit, so the
+	// pipeline sees the bookmark together with its title, tags and content
+	dbBookmark, err := queries.CreateBookmark(ctx, db.CreateBookmarkParams{
+		Url:          bookmark.URL,
+		CreationDate: pgtype.Timestamp{Time: bookmark.CreationDate, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entity.ErrDuplicateBookmark
+	}
+	if err != nil {
+		return nil, err
+	}
+	bookmarkIDPg := pgtype.UUID{Bytes: dbBookmark.BookmarkID, Valid: true}
+
+	if bookmark.Title != nil {
+		source := "user"
+		if err := queries.InsertBookmarkTitle(ctx, db.InsertBookmarkTitleParams{
+			BookmarkID: bookmarkIDPg,
+			Title:      bookmark.Title,
+			Source:     &source,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to insert title: %w", err)
+		}
+	}
+
+	if bookmark.CategoryID != nil {
+		if err := queries.InsertBookmarkCategory(ctx, db.InsertBookmarkCategoryParams{
+			BookmarkID: bookmarkIDPg,
+			CategoryID: pgtype.UUID{Bytes: *bookmark.CategoryID, Valid: true},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to insert category: %w", err)
+		}
+	}
+
+	if bookmark.SourceURI != nil || len(bookmark.RawSource) > 0 {
+		if err := queries.InsertBookmarkSource(ctx, db.InsertBookmarkSourceParams{
+			BookmarkID: bookmarkIDPg,
+			SourceUri:  bookmark.SourceURI,
+			RawSource:  bookmark.RawSource,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to insert source: %w", err)
+		}
+	}
+
+	if len(bookmark.Tags) > 0 {
+		now := time.Now().Unix()
+		for _, name := range bookmark.Tags {
+			tag, err := queries.UpsertTagRecord(ctx, db.UpsertTagRecordParams{
+				Name:    name,
+				Created: &now,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to upsert tag %q: %w", name, err)
+			}
+			if err := queries.InsertBookmarkTag(ctx, db.InsertBookmarkTagParams{
+				BookmarkID: dbBookmark.BookmarkID,
+				TagID:      tag.ID,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to insert tag %q: %w", name, err)
+			}
+		}
+	}
+
+	if bookmark.ReaderContent != nil {
+		strategy := "reader"
+		if err := queries.InsertProcessedContent(ctx, db.InsertProcessedContentParams{
+			BookmarkID:       bookmarkIDPg,
+			StrategyUsed:     &strategy,
+			ProcessedContent: bookmark.ReaderContent,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to insert reader content: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &entity.Bookmark{
+		BookmarkID:   dbBookmark.BookmarkID,
+		URL:          dbBookmark.Url,
+		CreationDate: dbBookmark.CreationDate.Time,
+	}, nil
+}
+
+func (r *BookmarkRepository) UpdateBookmarkURL(ctx context.Context, bookmarkID uuid.UUID, url string) error {
+	queries := db.New(r.pool)
+	err := queries.UpdateBookmarkURL(ctx, db.UpdateBookmarkURLParams{
+		BookmarkID: bookmarkID,
+		Url:        url,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return entity.ErrDuplicateBookmark
+	}
+	return err
+}
+
+func (r *BookmarkRepository) ReplaceBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID, title, source string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	if err := queries.DeleteBookmarkTitles(ctx, bookmarkIDPg); err != nil {
+		return err
+	}
+	if err := queries.InsertBookmarkTitle(ctx, db.InsertBookmarkTitleParams{
+		BookmarkID: bookmarkIDPg,
+		Title:      &title,
+		Source:     &source,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *BookmarkRepository) SetBookmarkCategory(ctx context.Context, bookmarkID uuid.UUID, categoryID *uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	if err := queries.DeleteBookmarkCategories(ctx, bookmarkIDPg); err != nil {
+		return err
+	}
+	if categoryID != nil {
+		if err := queries.InsertBookmarkCategory(ctx, db.InsertBookmarkCategoryParams{
+			BookmarkID: bookmarkIDPg,
+			CategoryID: pgtype.UUID{Bytes: *categoryID, Valid: true},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *BookmarkRepository) DeleteBookmark(ctx context.Context, bookmarkID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	// bookmark_content_references has no ON DELETE CASCADE, and the other
+	// tables are cleared explicitly so the delete does not depend on FK setup
+	responses, err := queries.DeleteBookmarkHttpResponses(ctx, bookmarkIDPg)
+	if err != nil {
+		return fmt.Errorf("failed to delete http responses: %w", err)
+	}
+	bodyHashes := make([]*string, 0, 2*len(responses))
+	for _, response := range responses {
+		bodyHashes = append(bodyHashes, response.ContentHash, response.OriginalContentHash)
+	}
+	if err := queries.DeleteBookmarkProcessedContents(ctx, bookmarkIDPg); err != nil {
+		return fmt.Errorf("failed to delete processed contents: %w", err)
+	}
+	if err := queries.DeleteBookmarkContentReferences(ctx, bookmarkIDPg); err != nil {
+		return fmt.Errorf("failed to delete content references: %w", err)
+	}
+	if err := queries.DeleteBookmarkTitles(ctx, bookmarkIDPg); err != nil {
+		return fmt.Errorf("failed to delete titles: %w", err)
+	}
+	if err := queries.DeleteBookmarkCategories(ctx, bookmarkIDPg); err != nil {
+		return fmt.Errorf("failed to delete categories: %w", err)
+	}
+	if err := queries.DeleteBookmarkSources(ctx, bookmarkIDPg); err != nil {
+		return fmt.Errorf("failed to delete sources: %w", err)
+	}
+	if err := queries.DeleteBookmarkTags(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+	if err := queries.DeleteBookmarkStageRuns(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete stage runs: %w", err)
+	}
+	if err := queries.DeleteBookmarkMetadata(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete metadata: %w", err)
+	}
+	if err := queries.DeleteBookmarkLinkChecks(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete link checks: %w", err)
+	}
+	if err := queries.DeleteBookmarkArchives(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete archives: %w", err)
+	}
+	if err := queries.DeleteBookmarkAnnotations(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete annotations: %w", err)
+	}
+
+	rows, err := queries.DeleteBookmark(ctx, bookmarkID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return entity.ErrBookmarkNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	// Bodies are shared between bookmarks, so only those left unreferenced are removed
+	if err := releaseResponseBodies(ctx, db.New(r.pool), bodyHashes); err != nil {
+		return fmt.Errorf("failed to delete response bodies: %w", err)
+	}
+	return nil
+}
+
+func (r *BookmarkRepository) ListBookmarkURLs(ctx context.Context) ([]entity.Bookmark, error) {
+	queries := db.New(r.pool)
+	dbBookmarks, err := queries.ListBookmarkURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]entity.Bookmark, len(dbBookmarks))
+	for i, dbBookmark := range dbBookmarks {
+		bookmarks[i] = entity.Bookmark{
+			BookmarkID:   dbBookmark.BookmarkID,
+			URL:          dbBookmark.Url,
+			CreationDate: dbBookmark.CreationDate.Time,
+		}
+	}
+	return bookmarks, nil
+}
+
+func (r *BookmarkRepository) MergeBookmarks(ctx context.Context, keepID uuid.UUID, url string, duplicateIDs []uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+
+	if len(duplicateIDs) > 0 {
+		if err := queries.DropMergedBookmarkTags(ctx, db.DropMergedBookmarkTagsParams{DuplicateIds: duplicateIDs, KeepID: keepID}); err != nil {
+			return fmt.Errorf("failed to merge tags: %w", err)
+		}
+		if err := queries.DropMergedBookmarkCategories(ctx, db.DropMergedBookmarkCategoriesParams{DuplicateIds: duplicateIDs, KeepID: keepID}); err != nil {
+			return fmt.Errorf("failed to merge categories: %w", err)
+		}
+		if err := queries.DropMergedCategorySuggestions(ctx, db.DropMergedCategorySuggestionsParams{DuplicateIds: duplicateIDs, KeepID: keepID}); err != nil {
+			return fmt.Errorf("failed to merge category suggestions: %w", err)
+		}
+		if err := queries.DropMergedBookmarkMetadata(ctx, db.DropMergedBookmarkMetadataParams{DuplicateIds: duplicateIDs, KeepID: keepID}); err != nil {
+			return fmt.Errorf("failed to merge metadata: %w", err)
+		}
+		if err := queries.DropMergedBookmarkTitles(ctx, db.DropMergedBookmarkTitlesParams{DuplicateIds: duplicateIDs, KeepID: keepID}); err != nil {
+			return fmt.Errorf("failed to merge titles: %w", err)
+		}
+		if err := queries.MoveMergedBookmarkRows(ctx, db.MoveMergedBookmarkRowsParams{KeepID: keepID, DuplicateIds: duplicateIDs}); err != nil {
+			return fmt.Errorf("failed to move rows of duplicates: %w", err)
+		}
+		if err := queries.DeleteMergedBookmarks(ctx, duplicateIDs); err != nil {
+			return fmt.Errorf("failed to delete duplicates: %w", err)
+		}
+	}
+
+	if err := queries.UpdateBookmarkURL(ctx, db.UpdateBookmarkURLParams{
+		BookmarkID: keepID,
+		Url:        url,
+	}); err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *BookmarkRepository) CreateBookmarkURLIndex(ctx context.Context) error {
+	queries := db.New(r.pool)
+	return queries.CreateBookmarkURLIndex(ctx)
+}
+
+func (r *BookmarkRepository) InsertStageRun(ctx context.Context, run entity.StageRunInput) error {
+	queries := db.New(r.pool)
+	return queries.InsertBookmarkStageRun(ctx, db.InsertBookmarkStageRunParams{
+		BookmarkID: run.BookmarkID,
+		Stage:      string(run.Stage),
+		Status:     string(run.Status),
+		Message:    run.Message,
+		Error:      run.Error,
+		StartedAt:  pgtype.Timestamp{Time: run.StartedAt, Valid: true},
+		FinishedAt: pgtype.Timestamp{Time: run.FinishedAt, Valid: true},
+	})
+}
+
+func (r *BookmarkRepository) ListStageRuns(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.StageRun, error) {
+	queries := db.New(r.pool)
+	dbRuns, err := queries.ListBookmarkStageRuns(ctx, db.ListBookmarkStageRunsParams{
+		BookmarkID: bookmarkID,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]entity.StageRun, len(dbRuns))
+	for i, dbRun := range dbRuns {
+		runs[i] = entity.StageRun{
+			RunID:      dbRun.RunID,
+			Stage:      entity.PipelineStage(dbRun.Stage),
+			Status:     entity.StageStatus(dbRun.Status),
+			Message:    dbRun.Message,
+			Error:      dbRun.Error,
+			StartedAt:  dbRun.StartedAt.Time,
+			FinishedAt: dbRun.FinishedAt.Time,
+		}
+	}
+
+	return runs, nil
+}
+
+func (r *BookmarkRepository) GetStageRunSummaries(ctx context.Context, bookmarkID uuid.UUID) ([]entity.StageRunSummary, error) {
+	queries := db.New(r.pool)
+	dbSummaries, err := queries.GetBookmarkStageRunSummaries(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]entity.StageRunSummary, len(dbSummaries))
+	for i, dbSummary := range dbSummaries {
+		summaries[i] = entity.StageRunSummary{
+			Latest: entity.StageRun{
+				RunID:      dbSummary.RunID,
+				Stage:      entity.PipelineStage(dbSummary.Stage),
+				Status:     entity.StageStatus(dbSummary.Status),
+				Message:    dbSummary.Message,
+				Error:      dbSummary.Error,
+				StartedAt:  dbSummary.StartedAt.Time,
+				FinishedAt: dbSummary.FinishedAt.Time,
+			},
+			Attempts: int(dbSummary.Attempts),
+			Failures: int(dbSummary.Failures),
+		}
+		if dbSummary.LastSuccessAt.Valid {
+			summaries[i].LastSuccessAt = &dbSummary.LastSuccessAt.Time
+		}
+	}
+
+	return summaries, nil
+}
+
+func (r *BookmarkRepository) GetStageArtifacts(ctx context.Context, bookmarkID uuid.UUID) ([]entity.StageArtifacts, error) {
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	dbArtifacts, err := queries.GetBookmarkStageArtifacts(ctx, bookmarkIDPg)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]entity.StageArtifacts, len(dbArtifacts))
+	for i, dbArtifact := range dbArtifacts {
+		artifacts[i] = entity.StageArtifacts{
+			Stage: entity.PipelineStage(dbArtifact.Stage),
+			Count: int(dbArtifact.ArtifactCount),
+		}
+		if dbArtifact.LastProducedAt.Valid {
+			artifacts[i].LastProducedAt = &dbArtifact.LastProducedAt.Time
+		}
+	}
+
+	return artifacts, nil
+}
+
+func (r *BookmarkRepository) HasArchivedContent(ctx context.Context, bookmarkID uuid.UUID) (bool, error) {
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	archived, err := queries.HasArchivedContent(ctx, bookmarkIDPg)
+	if err != nil {
+		return false, err
+	}
+	return archived != nil && *archived, nil
+}
+
+func (r *BookmarkRepository) GetLatestFetchStatus(ctx context.Context, bookmarkID uuid.UUID) (*entity.FetchStatus, error) {
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	dbStatus, err := queries.GetLatestFetchStatus(ctx, bookmarkIDPg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	status := &entity.FetchStatus{
+		StatusCode: dbStatus.StatusCode,
+	}
+	if dbStatus.FetchDate.Valid {
+		status.FetchDate = &dbStatus.FetchDate.Time
+	}
+
+	fetchError, err := responseBody(dbStatus.FetchError, dbStatus.StoredFetchError)
+	if err != nil {
+		return nil, err
+	}
+	if len(fetchError) > 0 {
+		message := string(fetchError)
+		status.Error = &message
+	}
+
+	return status, nil
+}
+
+func (r *BookmarkRepository) GetGeneratedQuestions(ctx context.Context, bookmarkID uuid.UUID, strategy, promptVersion string) ([]entity.BookmarkQuestion, error) {
+	queries := db.New(r.pool)
+	dbQuestions, err := queries.GetGeneratedQuestions(ctx, db.GetGeneratedQuestionsParams{
+		BookmarkID:    pgtype.UUID{Bytes: bookmarkID, Valid: true},
+		Strategy:      &strategy,
+		PromptVersion: promptVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	questions := make([]entity.BookmarkQuestion, len(dbQuestions))
+	for i, dbQ := range dbQuestions {
+		content := ""
+		if dbQ.Content != nil {
+			content = *dbQ.Content
+		}
+		questions[i] = entity.BookmarkQuestion{
+			ID:      dbQ.ID,
+			Content: content,
+		}
+	}
+
+	return questions, nil
+}
+
+func (r *BookmarkRepository) ReplaceGeneratedQuestions(
+	ctx context.Context,
+	bookmarkID uuid.UUID,
+	strategy string,
+	model string,
+	extra json.RawMessage,
+	questions []entity.Embedding,
+) ([]uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	if err := queries.DeleteReplaceableQuestions(ctx, db.DeleteReplaceableQuestionsParams{
+		BookmarkID: bookmarkIDPg,
+		Strategy:   &strategy,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to delete previous questions: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(questions))
+	for _, question := range questions {
+		content := question.Text
+		embeddingVec := pgvector.NewVector(question.Embedding)
+		id, err := queries.CreateEmbeddingChunkWithExtra(ctx, db.CreateEmbeddingChunkWithExtraParams{
+			BookmarkID:     bookmarkIDPg,
+			Content:        &content,
+			Strategy:       &strategy,
+			Column4:        &embeddingVec,
+			Extra:          extra,
+			EmbeddingModel: convertStringToPtr(model),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert question: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *BookmarkRepository) LockBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID) (func(), error) {
+	// The session lock belongs to a connection, so one is held until it is released
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := db.New(conn)
+	if err := queries.LockBookmarkQuestions(ctx, bookmarkID); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		// The request may be cancelled by now, and the lock must be released regardless
+		ctx := context.WithoutCancel(ctx)
+		if err := queries.UnlockBookmarkQuestions(ctx, bookmarkID); err != nil {
+			// Closing the connection ends its session and with it the lock
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}, nil
+}
+
+func (r *BookmarkRepository) ReplaceContentChunks(
+	ctx context.Context,
+	bookmarkID uuid.UUID,
+	strategy string,
+	model string,
+	chunks []entity.Embedding,
+) ([]uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	if err := queries.DeleteContentReferencesByStrategy(ctx, db.DeleteContentReferencesByStrategyParams{
+		BookmarkID: bookmarkIDPg,
+		Strategy:   &strategy,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to delete previous chunks: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(chunks))
+	for _, chunk := range chunks {
+		extra := json.RawMessage("{}")
+		if chunk.Chunk != nil {
+			extra, err = json.Marshal(chunk.Chunk)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal chunk position: %w", err)
+			}
+		}
+
+		content := chunk.Text
+		embeddingVec := pgvector.NewVector(chunk.Embedding)
+		id, err := queries.CreateEmbeddingChunkWithExtra(ctx, db.CreateEmbeddingChunkWithExtraParams{
+			BookmarkID:     bookmarkIDPg,
+			Content:        &content,
+			Strategy:       &strategy,
+			Column4:        &embeddingVec,
+			Extra:          extra,
+			EmbeddingModel: convertStringToPtr(model),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert chunk: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *BookmarkRepository) ListBookmarksForExport(ctx context.Context) ([]entity.ExportBookmark, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListBookmarksForExport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]entity.ExportBookmark, len(rows))
+	for i, row := range rows {
+		bookmarks[i] = entity.ExportBookmark{
+			BookmarkID:   row.BookmarkID,
+			URL:          row.Url,
+			Title:        row.Title,
+			CategoryID:   convertPgUUIDToUUIDPtr(row.CategoryID),
+			CategoryName: row.CategoryName,
+			CreationDate: row.CreationDate.Time,
+		}
+	}
+
+	return bookmarks, nil
+}
+
+// Helper function to convert float32 slice to pgvector format
+func embeddingToString(embedding []float32) string {
+	// This will be replaced by proper pgvector handling in sqlc
+	// For now, return a placeholder
+	return ""
+}
+
+func (r *BookmarkRepository) GetBookmarkTags(ctx context.Context, bookmarkID uuid.UUID) ([]string, error) {
+	queries := db.New(r.pool)
+	return queries.GetBookmarkTags(ctx, bookmarkID)
+}
+
+// importReport is the JSON stored in bookmark_imports.report
+type importReport struct {
+	Errors     []entity.ImportError     `json:"errors,omitempty"`
+	Duplicates []entity.ImportDuplicate `json:"duplicates,omitempty"`
+}
+
+func (r *BookmarkRepository) CreateImport(ctx context.Context, format entity.ImportFormat, source *string) (*entity.ImportResult, error) {
+	queries := db.New(r.pool)
+	dbImport, err := queries.CreateBookmarkImport(ctx, db.CreateBookmarkImportParams{
+		Format: string(format),
+		Source: source,
+		Status: string(entity.ImportRunning),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toImportResult(dbImport)
+}
+
+func (r *BookmarkRepository) GetImport(ctx context.Context, importID uuid.UUID) (*entity.ImportResult, error) {
+	queries := db.New(r.pool)
+	dbImport, err := queries.GetBookmarkImport(ctx, importID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return toImportResult(dbImport)
+}
+
+func (r *BookmarkRepository) UpdateImport(ctx context.Context, result *entity.ImportResult) error {
+	report, err := json.Marshal(importReport{
+		Errors:     result.Errors,
+		Duplicates: result.DuplicateEntries,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal import report: %w", err)
+	}
+
+	var finishedAt pgtype.Timestamp
+	if result.FinishedAt != nil {
+		finishedAt = pgtype.Timestamp{Time: *result.FinishedAt, Valid: true}
+	}
+
+	queries := db.New(r.pool)
+	updatedAt, err := queries.UpdateBookmarkImport(ctx, db.UpdateBookmarkImportParams{
+		ImportID:   result.ImportID,
+		Status:     string(result.Status),
+		Processed:  int32(result.Total),
+		Created:    int32(result.Created),
+		Duplicates: int32(result.Duplicates),
+		Failed:     int32(result.Failed),
+		Report:     report,
+		Error:      result.Error,
+		FinishedAt: finishedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	result.UpdatedAt = updatedAt.Time
+	return nil
+}
+
+func toImportResult(dbImport db.BookmarkImport) (*entity.ImportResult, error) {
+	var report importReport
+	if len(dbImport.Report) > 0 {
+		if err := json.Unmarshal(dbImport.Report, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse import report: %w", err)
+		}
+	}
+
+	result := &entity.ImportResult{
+		ImportID:         dbImport.ImportID,
+		Format:           entity.ImportFormat(dbImport.Format),
+		Source:           dbImport.Source,
+		Status:           entity.ImportStatus(dbImport.Status),
+		Total:            int(dbImport.Processed),
+		Created:          int(dbImport.Created),
+		Duplicates:       int(dbImport.Duplicates),
+		Failed:           int(dbImport.Failed),
+		Errors:           report.Errors,
+		DuplicateEntries: report.Duplicates,
+		Error:            dbImport.Error,
+		StartedAt:        dbImport.StartedAt.Time,
+		UpdatedAt:        dbImport.UpdatedAt.Time,
+	}
+	if dbImport.FinishedAt.Valid {
+		result.FinishedAt = &dbImport.FinishedAt.Time
+	}
+
+	return result, nil
+}
+
+func (r *BookmarkRepository) SaveBookmarkMetadata(ctx context.Context, metadata *entity.BookmarkMetadata) error {
+	properties, err := json.Marshal(metadata.Properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata properties: %w", err)
+	}
+	if metadata.Properties == nil {
+		properties = []byte("{}")
+	}
+
+	jsonLD, err := json.Marshal(metadata.JSONLD)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON-LD: %w", err)
+	}
+	if metadata.JSONLD == nil {
+		jsonLD = []byte("[]")
+	}
+
+	extractorMetadata, err := json.Marshal(metadata.ExtractorMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal extractor metadata: %w", err)
+	}
+	if metadata.ExtractorMetadata == nil {
+		extractorMetadata = []byte("{}")
+	}
+
+	var publishedAt pgtype.Timestamp
+	if metadata.PublishedAt != nil {
+		publishedAt = pgtype.Timestamp{Time: *metadata.PublishedAt, Valid: true}
+	}
+
+	queries := db.New(r.pool)
+	updatedAt, err := queries.UpsertBookmarkMetadata(ctx, db.UpsertBookmarkMetadataParams{
+		BookmarkID:         metadata.BookmarkID,
+		CanonicalUrl:       metadata.CanonicalURL,
+		Title:              metadata.Title,
+		Description:        metadata.Description,
+		SiteName:           metadata.SiteName,
+		Author:             metadata.Author,
+		PublishedAt:        publishedAt,
+		Language:           metadata.Language,
+		FaviconUrl:         metadata.FaviconURL,
+		ImageUrl:           metadata.ImageURL,
+		ContentType:        metadata.ContentType,
+		Properties:         properties,
+		JsonLd:             jsonLD,
+		WordCount:          metadata.WordCount,
+		ReadingTimeMinutes: metadata.ReadingTimeMinutes,
+		Extractor:          metadata.Extractor,
+		ExtractorMetadata:  extractorMetadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	metadata.UpdatedAt = updatedAt.Time
+	return nil
+}
+
+func (r *BookmarkRepository) GetBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkMetadata, error) {
+	queries := db.New(r.pool)
+	dbMetadata, err := queries.GetBookmarkMetadata(ctx, bookmarkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	metadata := &entity.BookmarkMetadata{
+		BookmarkID: dbMetadata.BookmarkID,
+		PageMetadata: entity.PageMetadata{
+			CanonicalURL: dbMetadata.CanonicalUrl,
+			Title:        dbMetadata.Title,
+			Description:  dbMetadata.Description,
+			SiteName:     dbMetadata.SiteName,
+			Author:       dbMetadata.Author,
+			Language:     dbMetadata.Language,
+			FaviconURL:   dbMetadata.FaviconUrl,
+			ImageURL:     dbMetadata.ImageUrl,
+			ContentType:  dbMetadata.ContentType,
+		},
+		WordCount:          dbMetadata.WordCount,
+		ReadingTimeMinutes: dbMetadata.ReadingTimeMinutes,
+		Extractor:          dbMetadata.Extractor,
+		UpdatedAt:          dbMetadata.UpdatedAt.Time,
+	}
+	if dbMetadata.PublishedAt.Valid {
+		metadata.PublishedAt = &dbMetadata.PublishedAt.Time
+	}
+	if err := json.Unmarshal(dbMetadata.Properties, &metadata.Properties); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata properties: %w", err)
+	}
+	if err := json.Unmarshal(dbMetadata.JsonLd, &metadata.JSONLD); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON-LD: %w", err)
+	}
+	if err := json.Unmarshal(dbMetadata.ExtractorMetadata, &metadata.ExtractorMetadata); err != nil {
+		return nil, fmt.Errorf("failed to parse extractor metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+func (r *BookmarkRepository) InsertLinkCheck(ctx context.Context, check *entity.LinkCheck) error {
+	queries := db.New(r.pool)
+	checkID, err := queries.InsertBookmarkLinkCheck(ctx, db.InsertBookmarkLinkCheckParams{
+		BookmarkID:   check.BookmarkID,
+		Status:       string(check.Status),
+		Method:       check.Method,
+		StatusCode:   check.StatusCode,
+		FinalUrl:     check.FinalURL,
+		Error:        check.Error,
+		Etag:         check.ETag,
+		LastModified: check.LastModified,
+		ContentHash:  check.ContentHash,
+		Content:      check.Content,
+		ChangeRatio:  check.ChangeRatio,
+		Diff:         check.Diff,
+		CheckedAt:    pgtype.Timestamp{Time: check.CheckedAt, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	check.CheckID = checkID
+	return nil
+}
+
+func (r *BookmarkRepository) ListLinkChecks(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.LinkCheck, error) {
+	queries := db.New(r.pool)
+	dbChecks, err := queries.ListBookmarkLinkChecks(ctx, db.ListBookmarkLinkChecksParams{
+		BookmarkID: bookmarkID,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]entity.LinkCheck, len(dbChecks))
+	for i, dbCheck := range dbChecks {
+		checks[i] = entity.LinkCheck{
+			CheckID:      dbCheck.CheckID,
+			BookmarkID:   dbCheck.BookmarkID,
+			Status:       entity.LinkStatus(dbCheck.Status),
+			Method:       dbCheck.Method,
+			StatusCode:   dbCheck.StatusCode,
+			FinalURL:     dbCheck.FinalUrl,
+			Error:        dbCheck.Error,
+			ETag:         dbCheck.Etag,
+			LastModified: dbCheck.LastModified,
+			ContentHash:  dbCheck.ContentHash,
+			ChangeRatio:  dbCheck.ChangeRatio,
+			Diff:         dbCheck.Diff,
+			CheckedAt:    dbCheck.CheckedAt.Time,
+		}
+	}
+
+	return checks, nil
+}
+
+func (r *BookmarkRepository) GetLatestLinkCheckContent(ctx context.Context, bookmarkID uuid.UUID) (*string, error) {
+	queries := db.New(r.pool)
+	content, err := queries.GetLatestLinkCheckContent(ctx, bookmarkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return content, nil
+}
+
+func (r *BookmarkRepository) GetBookmarksDueForLinkCheck(ctx context.Context, checkedBefore time.Time, limit int32) ([]entity.Bookmark, error) {
+	queries := db.New(r.pool)
+	dbBookmarks, err := queries.ListBookmarksDueForLinkCheck(ctx, db.ListBookmarksDueForLinkCheckParams{
+		CheckedBefore: pgtype.Timestamp{Time: checkedBefore, Valid: true},
+		RowLimit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]entity.Bookmark, len(dbBookmarks))
+	for i, dbBookmark := range dbBookmarks {
+		bookmarks[i] = entity.Bookmark{
+			BookmarkID:   dbBookmark.BookmarkID,
+			URL:          dbBookmark.Url,
+			CreationDate: dbBookmark.CreationDate.Time,
+		}
+	}
+
+	return bookmarks, nil
+}
+
+func (r *BookmarkRepository) ListLinkIssues(ctx context.Context, status *string, limit, offset int32) ([]entity.LinkIssue, error) {
+	queries := db.New(r.pool)
+	dbIssues, err := queries.ListLinkIssues(ctx, db.ListLinkIssuesParams{
+		Status:    status,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]entity.LinkIssue, len(dbIssues))
+	for i, dbIssue := range dbIssues {
+		issues[i] = entity.LinkIssue{
+			BookmarkID: dbIssue.BookmarkID,
+			URL:        dbIssue.Url,
+			Title:      dbIssue.Title,
+			Check: entity.LinkCheck{
+				CheckID:      dbIssue.CheckID,
+				BookmarkID:   dbIssue.BookmarkID,
+				Status:       entity.LinkStatus(dbIssue.Status),
+				Method:       dbIssue.Method,
+				StatusCode:   dbIssue.StatusCode,
+				FinalURL:     dbIssue.FinalUrl,
+				Error:        dbIssue.Error,
+				ETag:         dbIssue.Etag,
+				LastModified: dbIssue.LastModified,
+				ContentHash:  dbIssue.ContentHash,
+				ChangeRatio:  dbIssue.ChangeRatio,
+				Diff:         dbIssue.Diff,
+				CheckedAt:    dbIssue.CheckedAt.Time,
+			},
+		}
+	}
+
+	return issues, nil
+}
+
+func (r *BookmarkRepository) CountLinkIssues(ctx context.Context, status *string) (int64, error) {
+	queries := db.New(r.pool)
+	return queries.CountLinkIssues(ctx, status)
+}
+
+func (r *BookmarkRepository) InsertBookmarkArchive(ctx context.Context, archive *entity.BookmarkArchive) error {
+	queries := db.New(r.pool)
+	row, err := queries.InsertBookmarkArchive(ctx, db.InsertBookmarkArchiveParams{
+		BookmarkID:      archive.BookmarkID,
+		Url:             archive.URL,
+		HtmlHash:        archive.HTMLHash,
+		HtmlSize:        archive.HTMLSize,
+		WarcHash:        archive.WARCHash,
+		WarcSize:        archive.WARCSize,
+		ResourceCount:   archive.ResourceCount,
+		FailedResources: archive.FailedResources,
+	})
+	if err != nil {
+		return err
+	}
+
+	archive.ArchiveID = row.ArchiveID
+	archive.ArchivedAt = row.ArchivedAt.Time
+	return nil
+}
+
+func (r *BookmarkRepository) ListBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkArchive, error) {
+	queries := db.New(r.pool)
+	dbArchives, err := queries.ListBookmarkArchives(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	archives := make([]entity.BookmarkArchive, len(dbArchives))
+	for i, dbArchive := range dbArchives {
+		archives[i] = entity.BookmarkArchive{
+			ArchiveID:       dbArchive.ArchiveID,
+			BookmarkID:      dbArchive.BookmarkID,
+			URL:             dbArchive.Url,
+			HTMLHash:        dbArchive.HtmlHash,
+			HTMLSize:        dbArchive.HtmlSize,
+			WARCHash:        dbArchive.WarcHash,
+			WARCSize:        dbArchive.WarcSize,
+			ResourceCount:   dbArchive.ResourceCount,
+			FailedResources: dbArchive.FailedResources,
+			ArchivedAt:      dbArchive.ArchivedAt.Time,
+		}
+	}
+
+	return archives, nil
+}
+
+func (r *BookmarkRepository) CountArchiveBlobReferences(ctx context.Context, hash string) (int64, error) {
+	queries := db.New(r.pool)
+	return queries.CountArchiveBlobReferences(ctx, hash)
+}
+
+func (r *BookmarkRepository) LockArchiveBlobs(ctx context.Context, hashes []string) (func(), error) {
+	// Session locks belong to a connection, so one is held until every lock is released
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Locking in a fixed order keeps two callers sharing files from deadlocking
+	sorted := slices.Compact(slices.Sorted(slices.Values(hashes)))
+	queries := db.New(conn)
+	locked := 0
+	unlock := func() {
+		// The request may be cancelled by now, and the locks must be released regardless
+		ctx := context.WithoutCancel(ctx)
+		for _, hash := range sorted[:locked] {
+			if err := queries.UnlockArchiveBlob(ctx, hash); err != nil {
+				// Closing the connection ends its session and with it the locks it still holds
+				conn.Conn().Close(ctx)
+				break
+			}
+		}
+		conn.Release()
+	}
+
+	for _, hash := range sorted {
+		if err := queries.LockArchiveBlob(ctx, hash); err != nil {
+			unlock()
+			return nil, err
+		}
+		locked++
+	}
+	return unlock, nil
+}
+
+func (r *BookmarkRepository) PruneHttpResponses(ctx context.Context, bookmarkID uuid.UUID, keep int) (int64, error) {
+	queries := db.New(r.pool)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+	pruned, err := queries.PruneHttpResponses(ctx, db.PruneHttpResponsesParams{
+		BookmarkID: bookmarkIDPg,
+		Limit:      int32(keep),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	bodyHashes := make([]*string, 0, 2*len(pruned))
+	for _, response := range pruned {
+		bodyHashes = append(bodyHashes, response.ContentHash, response.OriginalContentHash)
+	}
+	if err := releaseResponseBodies(ctx, queries, bodyHashes); err != nil {
+		return 0, fmt.Errorf("failed to delete response bodies: %w", err)
+	}
+	return int64(len(pruned)), nil
+}
+
+func (r *BookmarkRepository) ListBookmarksOverResponseRetention(ctx context.Context, keep int) ([]uuid.UUID, error) {
+	queries := db.New(r.pool)
+	return queries.ListBookmarksOverResponseRetention(ctx, int64(keep))
+}
+
+func (r *BookmarkRepository) MigrateInlineHttpResponses(ctx context.Context, after uuid.UUID, limit int) (int, uuid.UUID, error) {
+	queries := db.New(r.pool)
+	responseIDs, err := queries.ListInlineHttpResponses(ctx, db.ListInlineHttpResponsesParams{
+		ResponseID: after,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return 0, after, err
+	}
+
+	// Bodies can be large, so each response is loaded and moved on its own
+	for i, responseID := range responseIDs {
+		if err := r.moveInlineHttpResponse(ctx, responseID); err != nil {
+			return i, after, fmt.Errorf("failed to move response %s: %w", responseID, err)
+		}
+		after = responseID
+	}
+	return len(responseIDs), after, nil
+}
+
+func (r *BookmarkRepository) moveInlineHttpResponse(ctx context.Context, responseID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	inline, err := queries.GetInlineHttpResponseContent(ctx, responseID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := storeResponseBody(ctx, queries, inline.Content)
+	if err != nil {
+		return err
+	}
+
+	var originalHash *string
+	if inline.OriginalContent != nil {
+		stored, err := storeResponseBody(ctx, queries, inline.OriginalContent)
+		if err != nil {
+			return err
+		}
+		originalHash = &stored
+	}
+
+	err = queries.MoveHttpResponseContent(ctx, db.MoveHttpResponseContentParams{
+		ResponseID:          responseID,
+		ContentHash:         &hash,
+		OriginalContentHash: originalHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
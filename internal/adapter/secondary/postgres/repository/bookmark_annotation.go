@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+	"garden3/internal/domain/entity"
+)
+
+func (r *BookmarkRepository) InsertBookmarkAnnotation(ctx context.Context, annotation *entity.BookmarkAnnotation) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	row, err := queries.InsertBookmarkAnnotation(ctx, db.InsertBookmarkAnnotationParams{
+		BookmarkID:    annotation.BookmarkID,
+		QuoteExact:    annotation.Selector.Exact,
+		QuotePrefix:   annotation.Selector.Prefix,
+		QuoteSuffix:   annotation.Selector.Suffix,
+		PositionStart: int32(annotation.Selector.Start),
+		PositionEnd:   int32(annotation.Selector.End),
+		Note:          annotation.Note,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := insertAnnotationTags(ctx, queries, row.AnnotationID, annotation.Tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	annotation.AnnotationID = row.AnnotationID
+	annotation.CreatedAt = row.CreatedAt.Time
+	annotation.UpdatedAt = row.UpdatedAt.Time
+	return nil
+}
+
+func (r *BookmarkRepository) UpdateBookmarkAnnotation(ctx context.Context, annotation *entity.BookmarkAnnotation, tags []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	updatedAt, err := queries.UpdateBookmarkAnnotation(ctx, db.UpdateBookmarkAnnotationParams{
+		AnnotationID:  annotation.AnnotationID,
+		QuoteExact:    annotation.Selector.Exact,
+		QuotePrefix:   annotation.Selector.Prefix,
+		QuoteSuffix:   annotation.Selector.Suffix,
+		PositionStart: int32(annotation.Selector.Start),
+		PositionEnd:   int32(annotation.Selector.End),
+		Note:          annotation.Note,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ErrAnnotationNotFound
+		}
+		return err
+	}
+
+	if tags != nil {
+		if err := queries.DeleteBookmarkAnnotationTags(ctx, annotation.AnnotationID); err != nil {
+			return fmt.Errorf("failed to delete tags: %w", err)
+		}
+		if err := insertAnnotationTags(ctx, queries, annotation.AnnotationID, tags); err != nil {
+			return err
+		}
+		annotation.Tags = tags
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	annotation.UpdatedAt = updatedAt.Time
+	return nil
+}
+
+func insertAnnotationTags(ctx context.Context, queries *db.Queries, annotationID uuid.UUID, tags []string) error {
+	now := time.Now().Unix()
+	for _, name := range tags {
+		tag, err := queries.UpsertTagRecord(ctx, db.UpsertTagRecordParams{
+			Name:    name,
+			Created: &now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert tag %q: %w", name, err)
+		}
+		if err := queries.InsertBookmarkAnnotationTag(ctx, db.InsertBookmarkAnnotationTagParams{
+			AnnotationID: annotationID,
+			TagID:        tag.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to insert tag %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (r *BookmarkRepository) SetBookmarkAnnotationEmbedding(ctx context.Context, annotationID uuid.UUID, embedding []float32, model string) error {
+	queries := db.New(r.pool)
+	embeddingVec := pgvector.NewVector(embedding)
+	return queries.SetBookmarkAnnotationEmbedding(ctx, db.SetBookmarkAnnotationEmbeddingParams{
+		Embedding:      &embeddingVec,
+		EmbeddingModel: convertStringToPtr(model),
+		AnnotationID:   annotationID,
+	})
+}
+
+func (r *BookmarkRepository) GetBookmarkAnnotation(ctx context.Context, annotationID uuid.UUID) (*entity.BookmarkAnnotation, error) {
+	queries := db.New(r.pool)
+	row, err := queries.GetBookmarkAnnotation(ctx, annotationID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	annotation := annotationFromRow(row)
+	return &annotation, nil
+}
+
+func (r *BookmarkRepository) ListBookmarkAnnotations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkAnnotation, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListBookmarkAnnotations(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]entity.BookmarkAnnotation, len(rows))
+	for i, row := range rows {
+		annotations[i] = annotationFromRow(db.GetBookmarkAnnotationRow(row))
+	}
+	return annotations, nil
+}
+
+func (r *BookmarkRepository) ListAnnotations(ctx context.Context, search, tag *string, limit, offset int32) ([]entity.BookmarkAnnotation, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListAnnotations(ctx, db.ListAnnotationsParams{
+		Search:    search,
+		Tag:       tag,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]entity.BookmarkAnnotation, len(rows))
+	for i, row := range rows {
+		annotations[i] = annotationFromRow(db.GetBookmarkAnnotationRow{
+			AnnotationID:  row.AnnotationID,
+			BookmarkID:    row.BookmarkID,
+			QuoteExact:    row.QuoteExact,
+			QuotePrefix:   row.QuotePrefix,
+			QuoteSuffix:   row.QuoteSuffix,
+			PositionStart: row.PositionStart,
+			PositionEnd:   row.PositionEnd,
+			Note:          row.Note,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+			Tags:          row.Tags,
+		})
+		annotations[i].BookmarkURL = &row.Url
+		annotations[i].BookmarkTitle = row.Title
+	}
+	return annotations, nil
+}
+
+func (r *BookmarkRepository) CountAnnotations(ctx context.Context, search, tag *string) (int64, error) {
+	queries := db.New(r.pool)
+	return queries.CountAnnotations(ctx, db.CountAnnotationsParams{
+		Search: search,
+		Tag:    tag,
+	})
+}
+
+func (r *BookmarkRepository) SearchSimilarAnnotations(ctx context.Context, embedding []float32, limit int32) ([]entity.BookmarkAnnotation, error) {
+	queries := db.New(r.pool)
+	embeddingVec := pgvector.NewVector(embedding)
+	rows, err := queries.SearchSimilarAnnotations(ctx, db.SearchSimilarAnnotationsParams{
+		Embedding: &embeddingVec,
+		RowLimit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make([]entity.BookmarkAnnotation, len(rows))
+	for i, row := range rows {
+		annotations[i] = annotationFromRow(db.GetBookmarkAnnotationRow{
+			AnnotationID:  row.AnnotationID,
+			BookmarkID:    row.BookmarkID,
+			QuoteExact:    row.QuoteExact,
+			QuotePrefix:   row.QuotePrefix,
+			QuoteSuffix:   row.QuoteSuffix,
+			PositionStart: row.PositionStart,
+			PositionEnd:   row.PositionEnd,
+			Note:          row.Note,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+			Tags:          row.Tags,
+		})
+		similarity := row.Similarity
+		annotations[i].BookmarkURL = &row.Url
+		annotations[i].BookmarkTitle = row.Title
+		annotations[i].Similarity = &similarity
+	}
+	return annotations, nil
+}
+
+func (r *BookmarkRepository) DeleteBookmarkAnnotation(ctx context.Context, annotationID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	if err := queries.DeleteAnnotationEntityReferences(ctx, annotationID); err != nil {
+		return fmt.Errorf("failed to delete entity references: %w", err)
+	}
+
+	rows, err := queries.DeleteBookmarkAnnotation(ctx, annotationID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return entity.ErrAnnotationNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *BookmarkRepository) DeleteAnnotationEntityReferences(ctx context.Context, annotationID uuid.UUID) error {
+	queries := db.New(r.pool)
+	return queries.DeleteAnnotationEntityReferences(ctx, annotationID)
+}
+
+func annotationFromRow(row db.GetBookmarkAnnotationRow) entity.BookmarkAnnotation {
+	return entity.BookmarkAnnotation{
+		AnnotationID: row.AnnotationID,
+		BookmarkID:   row.BookmarkID,
+		Selector: entity.AnnotationSelector{
+			Exact:  row.QuoteExact,
+			Prefix: row.QuotePrefix,
+			Suffix: row.QuoteSuffix,
+			Start:  int(row.PositionStart),
+			End:    int(row.PositionEnd),
+		},
+		Note:      row.Note,
+		Tags:      row.Tags,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+}
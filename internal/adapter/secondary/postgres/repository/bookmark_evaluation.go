@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+	"garden3/internal/domain/entity"
+)
+
+func (r *BookmarkRepository) InsertBookmarkEvaluation(ctx context.Context, evaluation *entity.BookmarkEvaluation) error {
+	queries := db.New(r.pool)
+
+	var summaryID *string
+	if evaluation.SummaryID != nil {
+		id := evaluation.SummaryID.String()
+		summaryID = &id
+	}
+
+	var questionsIDs []byte
+	if len(evaluation.QuestionsIDs) > 0 {
+		var err error
+		questionsIDs, err = json.Marshal(evaluation.QuestionsIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	failedFetch := evaluation.FailedFetch
+	row, err := queries.InsertBookmarkEvaluation(ctx, db.InsertBookmarkEvaluationParams{
+		BookmarkID:             evaluation.BookmarkID,
+		FailedFetch:            &failedFetch,
+		SummaryID:              summaryID,
+		SummaryComments:        evaluation.SummaryComments,
+		SummaryEval:            evaluation.SummaryEval,
+		SummaryStrategy:        evaluation.SummaryStrategy,
+		SummaryModel:           evaluation.SummaryModel,
+		SummaryPromptVersion:   evaluation.SummaryPromptVersion,
+		QuestionsEval:          evaluation.QuestionsEval,
+		QuestionsIds:           questionsIDs,
+		QuestionsStrategy:      evaluation.QuestionsStrategy,
+		QuestionsModel:         evaluation.QuestionsModel,
+		QuestionsPromptVersion: evaluation.QuestionsPromptVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	evaluation.ID = row.ID
+	evaluation.CreatedAt = row.CreatedAt.Time
+	return nil
+}
+
+func (r *BookmarkRepository) ListBookmarkEvaluations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkEvaluation, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListBookmarkEvaluations(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluations := make([]entity.BookmarkEvaluation, len(rows))
+	for i, row := range rows {
+		evaluation := entity.BookmarkEvaluation{
+			ID:                     row.ID,
+			BookmarkID:             row.BookmarkID,
+			FailedFetch:            row.FailedFetch != nil && *row.FailedFetch,
+			SummaryEval:            row.SummaryEval,
+			SummaryComments:        row.SummaryComments,
+			SummaryStrategy:        row.SummaryStrategy,
+			SummaryModel:           row.SummaryModel,
+			SummaryPromptVersion:   row.SummaryPromptVersion,
+			QuestionsEval:          row.QuestionsEval,
+			QuestionsStrategy:      row.QuestionsStrategy,
+			QuestionsModel:         row.QuestionsModel,
+			QuestionsPromptVersion: row.QuestionsPromptVersion,
+			CreatedAt:              row.CreatedAt.Time,
+		}
+
+		// Rows written before the API may hold IDs in other shapes; those are left out
+		if row.SummaryID != nil {
+			if id, err := uuid.Parse(*row.SummaryID); err == nil {
+				evaluation.SummaryID = &id
+			}
+		}
+		if len(row.QuestionsIds) > 0 {
+			var ids []uuid.UUID
+			if err := json.Unmarshal(row.QuestionsIds, &ids); err == nil {
+				evaluation.QuestionsIDs = ids
+			}
+		}
+
+		evaluations[i] = evaluation
+	}
+	return evaluations, nil
+}
+
+func (r *BookmarkRepository) GetLatestContentReferenceID(ctx context.Context, bookmarkID uuid.UUID, strategy string) (*uuid.UUID, error) {
+	queries := db.New(r.pool)
+	id, err := queries.GetLatestContentReference(ctx, db.GetLatestContentReferenceParams{
+		BookmarkID: pgtype.UUID{Bytes: bookmarkID, Valid: true},
+		Strategy:   &strategy,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *BookmarkRepository) GetContentGenerations(ctx context.Context, bookmarkID uuid.UUID, ids []uuid.UUID) ([]entity.ContentGeneration, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.GetContentReferenceGenerations(ctx, db.GetContentReferenceGenerationsParams{
+		BookmarkID: pgtype.UUID{Bytes: bookmarkID, Valid: true},
+		Ids:        ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	generations := make([]entity.ContentGeneration, len(rows))
+	for i, row := range rows {
+		generations[i] = entity.ContentGeneration{
+			ID:            row.ID,
+			Strategy:      row.Strategy,
+			Model:         emptyToNil(row.Model),
+			PromptVersion: emptyToNil(row.PromptVersion),
+		}
+	}
+	return generations, nil
+}
+
+// emptyToNil treats generation metadata missing from a reference's extra as unknown
+func emptyToNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (r *BookmarkRepository) GetEvaluationReport(ctx context.Context, filters entity.EvaluationReportFilters) (*entity.EvaluationReport, error) {
+	queries := db.New(r.pool)
+
+	var since, until pgtype.Timestamptz
+	if filters.Since != nil {
+		since = pgtype.Timestamptz{Time: *filters.Since, Valid: true}
+	}
+	if filters.Until != nil {
+		until = pgtype.Timestamptz{Time: *filters.Until, Valid: true}
+	}
+
+	counts, err := queries.CountBookmarkEvaluations(ctx, db.CountBookmarkEvaluationsParams{
+		Since: since,
+		Until: until,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := queries.ReportBookmarkEvaluations(ctx, db.ReportBookmarkEvaluationsParams{
+		Period: filters.Period,
+		Since:  since,
+		Until:  until,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entity.EvaluationReport{
+		Summary:       []entity.EvaluationAggregate{},
+		Questions:     []entity.EvaluationAggregate{},
+		Evaluations:   counts.Evaluations,
+		FailedFetches: counts.FailedFetches,
+	}
+	for _, row := range rows {
+		aggregate := entity.EvaluationAggregate{
+			Strategy:         row.Strategy,
+			Model:            row.Model,
+			PromptVersion:    row.PromptVersion,
+			Evaluations:      row.Evaluations,
+			AverageScore:     row.AverageScore,
+			MinScore:         row.MinScore,
+			MaxScore:         row.MaxScore,
+			FirstEvaluatedAt: row.FirstEvaluatedAt.Time,
+			LastEvaluatedAt:  row.LastEvaluatedAt.Time,
+		}
+		if row.PeriodStart.Valid {
+			periodStart := row.PeriodStart.Time
+			aggregate.PeriodStart = &periodStart
+		}
+
+		if row.Kind == "summary" {
+			report.Summary = append(report.Summary, aggregate)
+		} else {
+			report.Questions = append(report.Questions, aggregate)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pgvector/pgvector-go"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+	"garden3/internal/domain/entity"
+)
+
+func (r *BookmarkRepository) ListBookmarksWithRejectedQuestions(ctx context.Context, maxAttempts, limit int32) ([]entity.QARegenerationCandidate, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListBookmarksWithRejectedQuestions(ctx, db.ListBookmarksWithRejectedQuestionsParams{
+		MaxAttempts: maxAttempts,
+		RowLimit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]entity.QARegenerationCandidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = entity.QARegenerationCandidate{
+			BookmarkID:      row.BookmarkID,
+			Rejections:      row.Rejections,
+			FirstRejectedAt: time.Unix(row.FirstRejectedAt, 0),
+		}
+	}
+	return candidates, nil
+}
+
+func (r *BookmarkRepository) ListRejectedQuestions(ctx context.Context, bookmarkID uuid.UUID) ([]entity.QARejection, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListRejectedQuestions(ctx, pgtype.UUID{Bytes: bookmarkID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	rejections := make([]entity.QARejection, 0, len(rows))
+	for _, row := range rows {
+		// qa-feedback and qa-delete observations share these keys
+		var data struct {
+			Question     string `json:"question"`
+			Answer       string `json:"answer"`
+			ReferenceID  string `json:"referenceId"`
+			FeedbackType string `json:"feedbackType"`
+		}
+		if err := json.Unmarshal(row.Data, &data); err != nil {
+			continue
+		}
+
+		kind := "delete"
+		if row.Type != nil && *row.Type == "qa-feedback" {
+			kind = data.FeedbackType
+		}
+
+		rejection := entity.QARejection{
+			ObservationID: row.ObservationID,
+			Kind:          kind,
+			Question:      data.Question,
+			Answer:        data.Answer,
+			RejectedAt:    time.Unix(row.CreationDate, 0),
+		}
+		if id, err := uuid.Parse(data.ReferenceID); err == nil {
+			rejection.ReferenceID = &id
+		}
+		rejections = append(rejections, rejection)
+	}
+	return rejections, nil
+}
+
+func (r *BookmarkRepository) ReplaceRejectedQuestions(
+	ctx context.Context,
+	bookmarkID uuid.UUID,
+	strategy string,
+	model string,
+	extra json.RawMessage,
+	questions []entity.Embedding,
+	rejectedIDs []uuid.UUID,
+	rejectedContents []string,
+) ([]uuid.UUID, []uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	bookmarkIDPg := pgtype.UUID{Bytes: bookmarkID, Valid: true}
+
+	if rejectedIDs == nil {
+		rejectedIDs = []uuid.UUID{}
+	}
+	if rejectedContents == nil {
+		rejectedContents = []string{}
+	}
+	removed, err := queries.DeleteQuestionReferences(ctx, db.DeleteQuestionReferencesParams{
+		BookmarkID: bookmarkIDPg,
+		Strategy:   &strategy,
+		Ids:        rejectedIDs,
+		Contents:   rejectedContents,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to delete rejected questions: %w", err)
+	}
+
+	added := make([]uuid.UUID, 0, len(questions))
+	for _, question := range questions {
+		content := question.Text
+		embeddingVec := pgvector.NewVector(question.Embedding)
+		id, err := queries.CreateEmbeddingChunkWithExtra(ctx, db.CreateEmbeddingChunkWithExtraParams{
+			BookmarkID:     bookmarkIDPg,
+			Content:        &content,
+			Strategy:       &strategy,
+			Column4:        &embeddingVec,
+			Extra:          extra,
+			EmbeddingModel: convertStringToPtr(model),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to insert question: %w", err)
+		}
+		added = append(added, id)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return added, removed, nil
+}
+
+func (r *BookmarkRepository) CreateLinkedObservation(ctx context.Context, data []byte, observationType, source, tags string, ref, parent uuid.UUID) (uuid.UUID, error) {
+	queries := db.New(r.pool)
+	return queries.CreateLinkedObservation(ctx, db.CreateLinkedObservationParams{
+		Data:   data,
+		Type:   &observationType,
+		Source: &source,
+		Tags:   &tags,
+		Ref:    pgtype.UUID{Bytes: ref, Valid: true},
+		Parent: pgtype.UUID{Bytes: parent, Valid: true},
+	})
+}
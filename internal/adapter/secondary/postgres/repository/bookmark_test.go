@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"garden3/internal/domain/entity"
+)
+
+// testPool connects to the database named by TEST_DATABASE_URL, which must have schema.sql applied,
+// and skips the test when it is not set
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), connStr)
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// testBookmark creates a bookmark that is deleted with its responses when the test ends
+func testBookmark(t *testing.T, repo *BookmarkRepository) uuid.UUID {
+	t.Helper()
+	bookmark, err := repo.CreateBookmark(context.Background(), entity.NewBookmark{
+		URL:          "https://example.com/" + uuid.NewString(),
+		CreationDate: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create bookmark: %v", err)
+	}
+	t.Cleanup(func() {
+		repo.DeleteBookmark(context.Background(), bookmark.BookmarkID)
+	})
+	return bookmark.BookmarkID
+}
+
+func TestReplaceGeneratedQuestions(t *testing.T) {
+	pool := testPool(t)
+	repo := NewBookmarkRepository(pool)
+	ctx := context.Background()
+	bookmarkID := testBookmark(t, repo)
+	strategy := string(entity.StageQAPassage)
+
+	questions := func(texts ...string) []entity.Embedding {
+		embeddings := make([]entity.Embedding, len(texts))
+		for i, text := range texts {
+			embeddings[i] = entity.Embedding{Text: text, Embedding: []float32{1, 0}}
+		}
+		return embeddings
+	}
+
+	ids, err := repo.ReplaceGeneratedQuestions(ctx, bookmarkID, strategy, "embedder", json.RawMessage(`{"prompt_version": "v1"}`), questions("edited", "reviewed", "plain"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := repo.UpdateBookmarkQuestion(ctx, "edited by the user", []float32{0, 1}, "embedder", ids[0], bookmarkID); err != nil {
+		t.Fatalf("failed to edit question: %v", err)
+	}
+	var observationID uuid.UUID
+	err = pool.QueryRow(ctx, "INSERT INTO observations (data, type, ref) VALUES ($1, 'qa-feedback', $2) RETURNING observation_id",
+		map[string]string{"referenceId": ids[1].String(), "feedbackType": "upvote"}, bookmarkID).Scan(&observationID)
+	if err != nil {
+		t.Fatalf("failed to record feedback: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), "DELETE FROM observations WHERE observation_id = $1", observationID)
+	})
+
+	if _, err := repo.ReplaceGeneratedQuestions(ctx, bookmarkID, strategy, "embedder", json.RawMessage(`{"prompt_version": "v2"}`), questions("new")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, err := repo.GetBookmarkQuestions(ctx, bookmarkID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contents := make([]string, len(stored))
+	for i, question := range stored {
+		contents[i] = question.Content
+	}
+	sort.Strings(contents)
+	if want := []string{"edited by the user", "new", "reviewed"}; !reflect.DeepEqual(contents, want) {
+		t.Errorf("stored %q, want the edited and reviewed pairs kept next to the new one %q", contents, want)
+	}
+}
+
+func TestFailedStageFilter(t *testing.T) {
+	pool := testPool(t)
+	repo := NewBookmarkRepository(pool)
+	ctx := context.Background()
+
+	// The bookmarks share a creation date of their own so the date filters keep other rows out of scope
+	created := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(rand.Int63n(1<<20)) * time.Minute)
+	bookmark := func(path string) uuid.UUID {
+		b, err := repo.CreateBookmark(ctx, entity.NewBookmark{URL: "https://example.com/" + uuid.NewString() + "/" + path, CreationDate: created})
+		if err != nil {
+			t.Fatalf("failed to create bookmark: %v", err)
+		}
+		t.Cleanup(func() {
+			repo.DeleteBookmark(context.Background(), b.BookmarkID)
+		})
+		return b.BookmarkID
+	}
+	run := func(bookmarkID uuid.UUID, status entity.StageStatus, at time.Time) {
+		err := repo.InsertStageRun(ctx, entity.StageRunInput{BookmarkID: bookmarkID, Stage: entity.StageFetch, Status: status, StartedAt: at, FinishedAt: at})
+		if err != nil {
+			t.Fatalf("failed to insert stage run: %v", err)
+		}
+	}
+	response := func(bookmarkID uuid.UUID, statusCode int32, at time.Time) {
+		if err := repo.InsertHttpResponse(ctx, bookmarkID, statusCode, "{}", []byte("body"), nil, "", at, nil); err != nil {
+			t.Fatalf("failed to insert response: %v", err)
+		}
+	}
+
+	now := time.Now()
+	failedRun := bookmark("failed-run")
+	run(failedRun, entity.StageSucceeded, now.Add(-time.Hour))
+	run(failedRun, entity.StageFailed, now)
+
+	recovered := bookmark("recovered")
+	run(recovered, entity.StageFailed, now.Add(-time.Hour))
+	run(recovered, entity.StageSucceeded, now)
+
+	// A later error response without a failed run does not fail the stage
+	checked := bookmark("checked")
+	run(checked, entity.StageSucceeded, now.Add(-time.Hour))
+	response(checked, 404, now)
+
+	legacyFailed := bookmark("legacy-failed")
+	response(legacyFailed, 200, now.Add(-time.Hour))
+	response(legacyFailed, 500, now)
+
+	legacyFetched := bookmark("legacy-fetched")
+	response(legacyFetched, 500, now.Add(-time.Hour))
+	response(legacyFetched, 200, now)
+
+	bookmark("unfetched")
+
+	failedStage := string(entity.StageFetch)
+	bookmarks, err := repo.ListBookmarks(ctx, nil, nil, &created, &created, &failedStage, nil, nil, nil, 100, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []uuid.UUID
+	for _, b := range bookmarks {
+		got = append(got, b.BookmarkID)
+	}
+	want := []uuid.UUID{failedRun, legacyFailed}
+	sort.Slice(got, func(i, j int) bool { return got[i].String() < got[j].String() })
+	sort.Slice(want, func(i, j int) bool { return want[i].String() < want[j].String() })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("failed fetches = %v, want %v", got, want)
+	}
+
+	count, err := repo.CountBookmarks(ctx, nil, nil, &created, &created, &failedStage, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != int64(len(want)) {
+		t.Errorf("counted %d failed fetches, want %d", count, len(want))
+	}
+}
@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"garden3/internal/adapter/secondary/postgres/generated/db"
@@ -69,8 +72,11 @@ func (r *CategoryRepository) ListCategoriesWithSources(ctx context.Context) ([]e
 			Category: entity.Category{
 				CategoryID: row.CategoryID,
 				Name:       row.Name,
+				ParentID:   convertPgUUIDToUUIDPtr(row.ParentID),
 			},
-			Sources: sources,
+			Sources:              sources,
+			BookmarkCount:        row.BookmarkCount,
+			SubtreeBookmarkCount: row.SubtreeBookmarkCount,
 		}
 	}
 
@@ -87,6 +93,44 @@ func (r *CategoryRepository) GetCategory(ctx context.Context, categoryID uuid.UU
 	return &entity.Category{
 		CategoryID: dbCategory.CategoryID,
 		Name:       dbCategory.Name,
+		ParentID:   convertPgUUIDToUUIDPtr(dbCategory.ParentID),
+	}, nil
+}
+
+func (r *CategoryRepository) GetCategoryByName(ctx context.Context, name string, parentID *uuid.UUID) (*entity.Category, error) {
+	queries := db.New(r.pool)
+	dbCategory, err := queries.GetCategoryByName(ctx, db.GetCategoryByNameParams{
+		Name:     name,
+		ParentID: convertUUIDPtrToPgUUID(parentID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entity.Category{
+		CategoryID: dbCategory.CategoryID,
+		Name:       dbCategory.Name,
+		ParentID:   convertPgUUIDToUUIDPtr(dbCategory.ParentID),
+	}, nil
+}
+
+func (r *CategoryRepository) CreateCategory(ctx context.Context, name string, parentID *uuid.UUID) (*entity.Category, error) {
+	queries := db.New(r.pool)
+	dbCategory, err := queries.CreateCategory(ctx, db.CreateCategoryParams{
+		Name:     name,
+		ParentID: convertUUIDPtrToPgUUID(parentID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Category{
+		CategoryID: dbCategory.CategoryID,
+		Name:       dbCategory.Name,
+		ParentID:   convertPgUUIDToUUIDPtr(dbCategory.ParentID),
 	}, nil
 }
 
@@ -99,11 +143,113 @@ func (r *CategoryRepository) UpdateCategory(ctx context.Context, categoryID uuid
 }
 
 func (r *CategoryRepository) MergeCategories(ctx context.Context, sourceID, targetID uuid.UUID) error {
-	queries := db.New(r.pool)
-	return queries.MergeCategories(ctx, db.MergeCategoriesParams{
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	if err := queries.LockCategoryTree(ctx); err != nil {
+		return err
+	}
+
+	source, err := queries.GetCategory(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+
+	subtree, err := queries.GetCategorySubtreeIDs(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	if sourceID != targetID && slices.Contains(subtree, targetID) {
+		// Lift the target out of the source's subtree so the source's children can move under it
+		if _, err := queries.MoveCategory(ctx, db.MoveCategoryParams{
+			CategoryID: targetID,
+			ParentID:   source.ParentID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if sourceID != targetID {
+		if _, err := queries.ReparentCategoryChildren(ctx, db.ReparentCategoryChildrenParams{
+			CategoryID: sourceID,
+			ParentID:   convertUUIDToPgUUID(targetID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := queries.MergeCategories(ctx, db.MergeCategoriesParams{
 		Column1: sourceID,
 		Column2: targetID,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *CategoryRepository) GetCategorySubtreeIDs(ctx context.Context, categoryID uuid.UUID) ([]uuid.UUID, error) {
+	queries := db.New(r.pool)
+	return queries.GetCategorySubtreeIDs(ctx, categoryID)
+}
+
+func (r *CategoryRepository) MoveCategory(ctx context.Context, categoryID uuid.UUID, parentID *uuid.UUID) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	if err := queries.LockCategoryTree(ctx); err != nil {
+		return false, err
+	}
+
+	_, err = queries.MoveCategory(ctx, db.MoveCategoryParams{
+		CategoryID: categoryID,
+		ParentID:   convertUUIDPtrToPgUUID(parentID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *CategoryRepository) ReparentCategoryChildren(ctx context.Context, categoryID uuid.UUID, parentID *uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	if err := queries.LockCategoryTree(ctx); err != nil {
+		return nil, err
+	}
+
+	moved, err := queries.ReparentCategoryChildren(ctx, db.ReparentCategoryChildrenParams{
+		CategoryID: categoryID,
+		ParentID:   convertUUIDPtrToPgUUID(parentID),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return moved, nil
 }
 
 func (r *CategoryRepository) CreateCategorySource(ctx context.Context, categoryID uuid.UUID, sourceURI *string, rawSource json.RawMessage) (*entity.CategorySource, error) {
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+	"garden3/internal/domain/entity"
+)
+
+func (r *CategoryRepository) ListCategoryProfiles(ctx context.Context) ([]entity.CategoryProfile, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListCategoryProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]entity.CategoryProfile, len(rows))
+	for i, row := range rows {
+		profiles[i] = entity.CategoryProfile{
+			CategoryID:    row.CategoryID,
+			Name:          row.Name,
+			SourceText:    row.SourceText,
+			BookmarkCount: row.BookmarkCount,
+			UpdatedAt:     row.UpdatedAt.Time,
+		}
+	}
+	return profiles, nil
+}
+
+func (r *CategoryRepository) RefreshCategoryProfile(ctx context.Context, categoryID uuid.UUID, sourceText string, sourceEmbedding []float32, model string) (*entity.CategoryProfile, error) {
+	queries := db.New(r.pool)
+
+	var sourceVec *pgvector.Vector
+	if sourceEmbedding != nil {
+		vec := pgvector.NewVector(sourceEmbedding)
+		sourceVec = &vec
+	}
+
+	row, err := queries.RefreshCategoryProfile(ctx, db.RefreshCategoryProfileParams{
+		CategoryID:      categoryID,
+		EmbeddingModel:  convertStringToPtr(model),
+		SourceText:      sourceText,
+		SourceEmbedding: sourceVec,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, queries.DeleteCategoryProfile(ctx, categoryID)
+		}
+		return nil, err
+	}
+
+	return &entity.CategoryProfile{
+		CategoryID:    row.CategoryID,
+		SourceText:    row.SourceText,
+		BookmarkCount: row.BookmarkCount,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}, nil
+}
+
+func (r *CategoryRepository) RankCategoryProfiles(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.CategoryMatch, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.RankCategoryProfiles(ctx, db.RankCategoryProfilesParams{
+		BookmarkID: bookmarkID,
+		RowLimit:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]entity.CategoryMatch, len(rows))
+	for i, row := range rows {
+		matches[i] = entity.CategoryMatch{
+			CategoryID: row.CategoryID,
+			Name:       row.Name,
+			Similarity: row.Similarity,
+		}
+	}
+	return matches, nil
+}
+
+func (r *CategoryRepository) ListBookmarksToCategorize(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	queries := db.New(r.pool)
+	return queries.ListBookmarksToCategorize(ctx, limit)
+}
+
+func (r *CategoryRepository) SaveCategorySuggestion(ctx context.Context, bookmarkID, categoryID uuid.UUID, confidence float64, status entity.SuggestionStatus) (uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+
+	if err := queries.DeletePendingCategorySuggestions(ctx, bookmarkID); err != nil {
+		return uuid.Nil, err
+	}
+
+	suggestionID, err := queries.UpsertCategorySuggestion(ctx, db.UpsertCategorySuggestionParams{
+		BookmarkID: bookmarkID,
+		CategoryID: categoryID,
+		Confidence: confidence,
+		Status:     string(status),
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	return suggestionID, nil
+}
+
+func (r *CategoryRepository) GetCategorySuggestion(ctx context.Context, suggestionID uuid.UUID) (*entity.CategorySuggestion, error) {
+	queries := db.New(r.pool)
+	row, err := queries.GetCategorySuggestion(ctx, suggestionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entity.CategorySuggestion{
+		SuggestionID: row.SuggestionID,
+		BookmarkID:   row.BookmarkID,
+		URL:          row.Url,
+		Title:        row.Title,
+		CategoryID:   row.CategoryID,
+		CategoryName: row.CategoryName,
+		Confidence:   row.Confidence,
+		Status:       entity.SuggestionStatus(row.Status),
+		CreatedAt:    row.CreatedAt.Time,
+		ReviewedAt:   convertPgTimestampToTimePtr(row.ReviewedAt),
+	}, nil
+}
+
+func (r *CategoryRepository) ListCategorySuggestions(ctx context.Context, status entity.SuggestionStatus, limit, offset int32) ([]entity.CategorySuggestion, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListCategorySuggestions(ctx, db.ListCategorySuggestionsParams{
+		Status:    string(status),
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]entity.CategorySuggestion, len(rows))
+	for i, row := range rows {
+		suggestions[i] = entity.CategorySuggestion{
+			SuggestionID: row.SuggestionID,
+			BookmarkID:   row.BookmarkID,
+			URL:          row.Url,
+			Title:        row.Title,
+			CategoryID:   row.CategoryID,
+			CategoryName: row.CategoryName,
+			Confidence:   row.Confidence,
+			Status:       entity.SuggestionStatus(row.Status),
+			CreatedAt:    row.CreatedAt.Time,
+			ReviewedAt:   convertPgTimestampToTimePtr(row.ReviewedAt),
+		}
+	}
+	return suggestions, nil
+}
+
+func (r *CategoryRepository) CountCategorySuggestions(ctx context.Context, status entity.SuggestionStatus) (int64, error) {
+	queries := db.New(r.pool)
+	return queries.CountCategorySuggestions(ctx, string(status))
+}
+
+func (r *CategoryRepository) UpdateCategorySuggestionStatus(ctx context.Context, suggestionID uuid.UUID, status entity.SuggestionStatus) (bool, error) {
+	queries := db.New(r.pool)
+	updated, err := queries.UpdateCategorySuggestionStatus(ctx, db.UpdateCategorySuggestionStatusParams{
+		SuggestionID: suggestionID,
+		Status:       string(status),
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+func (r *CategoryRepository) ReopenCategorySuggestion(ctx context.Context, suggestionID uuid.UUID) error {
+	queries := db.New(r.pool)
+	return queries.ReopenCategorySuggestion(ctx, suggestionID)
+}
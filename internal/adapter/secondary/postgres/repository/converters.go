@@ -65,6 +65,14 @@ func convertUUIDToPgUUID(id uuid.UUID) pgtype.UUID {
 	}
 }
 
+// convertUUIDPtrToPgUUID converts *uuid.UUID to pgtype.UUID, nil becoming NULL
+func convertUUIDPtrToPgUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return convertUUIDToPgUUID(*id)
+}
+
 // convertPgUUIDToUUIDPtr converts pgtype.UUID to *uuid.UUID
 func convertPgUUIDToUUIDPtr(id pgtype.UUID) *uuid.UUID {
 	if id.Valid {
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+	"garden3/internal/domain/entity"
+)
+
+// EmbeddingCacheRepository implements the output.EmbeddingCacheRepository interface
+type EmbeddingCacheRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewEmbeddingCacheRepository creates a new embedding cache repository
+func NewEmbeddingCacheRepository(pool *pgxpool.Pool) *EmbeddingCacheRepository {
+	return &EmbeddingCacheRepository{
+		pool: pool,
+	}
+}
+
+func (r *EmbeddingCacheRepository) TouchEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.TouchCachedEmbeddings(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make(map[string][]float32, len(rows))
+	for _, row := range rows {
+		var vector pgvector.Vector
+		if err := vector.Parse(row.Embedding); err != nil {
+			return nil, fmt.Errorf("failed to parse cached embedding %s: %w", row.CacheKey, err)
+		}
+		vectors[row.CacheKey] = vector.Slice()
+	}
+	return vectors, nil
+}
+
+func (r *EmbeddingCacheRepository) StoreEmbeddings(ctx context.Context, embeddings []entity.CachedEmbedding) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	for _, cached := range embeddings {
+		embeddingVec := pgvector.NewVector(cached.Embedding)
+		err := queries.StoreCachedEmbedding(ctx, db.StoreCachedEmbeddingParams{
+			CacheKey:  cached.Key,
+			Model:     cached.Model,
+			Embedding: &embeddingVec,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to cache embedding %s: %w", cached.Key, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *EmbeddingCacheRepository) GetUsage(ctx context.Context) (entity.EmbeddingCacheUsage, error) {
+	queries := db.New(r.pool)
+	row, err := queries.GetEmbeddingCacheUsage(ctx)
+	if err != nil {
+		return entity.EmbeddingCacheUsage{}, err
+	}
+
+	usage := entity.EmbeddingCacheUsage{
+		Entries: row.Entries,
+		Hits:    row.Hits,
+	}
+	if row.OldestUse.Valid {
+		usage.OldestUse = &row.OldestUse.Time
+	}
+	return usage, nil
+}
+
+func (r *EmbeddingCacheRepository) EvictUnused(ctx context.Context, usedBefore time.Time) (int64, error) {
+	queries := db.New(r.pool)
+	return queries.EvictUnusedCachedEmbeddings(ctx, pgtype.Timestamp{Time: usedBefore, Valid: true})
+}
+
+func (r *EmbeddingCacheRepository) EvictExcess(ctx context.Context, maxEntries int32) (int64, error) {
+	queries := db.New(r.pool)
+	return queries.EvictExcessCachedEmbeddings(ctx, maxEntries)
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+	"garden3/internal/domain/entity"
+)
+
+// EmbeddingModelRepository implements the output.EmbeddingModelRepository interface
+type EmbeddingModelRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewEmbeddingModelRepository creates a new embedding model repository
+func NewEmbeddingModelRepository(pool *pgxpool.Pool) *EmbeddingModelRepository {
+	return &EmbeddingModelRepository{
+		pool: pool,
+	}
+}
+
+func (r *EmbeddingModelRepository) GetActiveModel(ctx context.Context) (string, error) {
+	queries := db.New(r.pool)
+	model, err := queries.GetActiveEmbeddingModel(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return model, err
+}
+
+func (r *EmbeddingModelRepository) ActivateModel(ctx context.Context, model string) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	if err := queries.DeactivateEmbeddingModels(ctx, model); err != nil {
+		return 0, fmt.Errorf("failed to deactivate embedding models: %w", err)
+	}
+	if err := queries.ActivateEmbeddingModel(ctx, model); err != nil {
+		return 0, fmt.Errorf("failed to activate embedding model: %w", err)
+	}
+	claimed, err := queries.ClaimUntaggedEmbeddings(ctx, model)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record the model of existing embeddings: %w", err)
+	}
+
+	return claimed, tx.Commit(ctx)
+}
+
+func (r *EmbeddingModelRepository) ListModelUsage(ctx context.Context) ([]entity.EmbeddingModelUsage, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListEmbeddingModelUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]entity.EmbeddingModelUsage, len(rows))
+	for i, row := range rows {
+		usage[i] = entity.EmbeddingModelUsage{
+			Table:      row.TableName,
+			Model:      row.EmbeddingModel,
+			Dimensions: row.EmbeddingDimensions,
+			Vectors:    row.Vectors,
+		}
+	}
+	return usage, nil
+}
+
+func (r *EmbeddingModelRepository) CreateMigration(ctx context.Context, target entity.EmbeddingModelConfig, remaining int) (*entity.EmbeddingMigration, error) {
+	queries := db.New(r.pool)
+	migration, err := queries.CreateEmbeddingMigration(ctx, db.CreateEmbeddingMigrationParams{
+		TargetProvider:   target.Provider,
+		TargetUrl:        target.URL,
+		TargetModel:      target.Model,
+		TargetDimensions: int32(target.Dimensions),
+		TargetApiKey:     target.APIKey,
+		Remaining:        int32(remaining),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEmbeddingMigration(migration), nil
+}
+
+func (r *EmbeddingModelRepository) GetMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error) {
+	queries := db.New(r.pool)
+	migration, err := queries.GetEmbeddingMigration(ctx, migrationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toEmbeddingMigration(migration), nil
+}
+
+func (r *EmbeddingModelRepository) GetOpenMigration(ctx context.Context) (*entity.EmbeddingMigration, error) {
+	queries := db.New(r.pool)
+	migration, err := queries.GetOpenEmbeddingMigration(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toEmbeddingMigration(migration), nil
+}
+
+func (r *EmbeddingModelRepository) ListMigrations(ctx context.Context, limit int32) ([]entity.EmbeddingMigration, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListEmbeddingMigrations(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]entity.EmbeddingMigration, len(rows))
+	for i, row := range rows {
+		migrations[i] = *toEmbeddingMigration(row)
+	}
+	return migrations, nil
+}
+
+func (r *EmbeddingModelRepository) CountMigrationRows(ctx context.Context, migrationID uuid.UUID, targetModel string) (int, error) {
+	queries := db.New(r.pool)
+	count, err := queries.CountEmbeddingMigrationRows(ctx, db.CountEmbeddingMigrationRowsParams{
+		TargetModel: targetModel,
+		MigrationID: migrationID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (r *EmbeddingModelRepository) ListMigrationRows(ctx context.Context, migrationID uuid.UUID, targetModel string, limit int32) ([]entity.EmbeddingMigrationRow, error) {
+	queries := db.New(r.pool)
+	rows, err := queries.ListEmbeddingMigrationRows(ctx, db.ListEmbeddingMigrationRowsParams{
+		TargetModel: targetModel,
+		MigrationID: migrationID,
+		RowLimit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.EmbeddingMigrationRow, len(rows))
+	for i, row := range rows {
+		result[i] = entity.EmbeddingMigrationRow{
+			Table:      row.TableName,
+			RowID:      row.RowID,
+			ExtraText:  row.ExtraText,
+			SourceHash: row.SourceHash,
+		}
+		if row.Text != nil {
+			result[i].Text = *row.Text
+		}
+	}
+	return result, nil
+}
+
+func (r *EmbeddingModelRepository) StageEmbeddings(ctx context.Context, migrationID uuid.UUID, embeddings []entity.StagedEmbedding) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	for _, staged := range embeddings {
+		embeddingVec := pgvector.NewVector(staged.Embedding)
+		err := queries.StageEmbeddingMigrationVector(ctx, db.StageEmbeddingMigrationVectorParams{
+			MigrationID: migrationID,
+			TableName:   staged.Table,
+			RowID:       staged.RowID,
+			SourceHash:  staged.SourceHash,
+			Embedding:   &embeddingVec,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to stage embedding of %s %s: %w", staged.Table, staged.RowID, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *EmbeddingModelRepository) UpdateMigrationProgress(ctx context.Context, migrationID uuid.UUID, staged, remaining int, status entity.EmbeddingMigrationStatus, errMsg *string) (*entity.EmbeddingMigration, error) {
+	queries := db.New(r.pool)
+	migration, err := queries.UpdateEmbeddingMigrationProgress(ctx, db.UpdateEmbeddingMigrationProgressParams{
+		Staged:      int32(staged),
+		Remaining:   int32(remaining),
+		Status:      string(status),
+		Error:       errMsg,
+		MigrationID: migrationID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toEmbeddingMigration(migration), nil
+}
+
+func (r *EmbeddingModelRepository) CompleteMigration(ctx context.Context, migrationID uuid.UUID, targetModel string) (*entity.EmbeddingMigration, int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	migration, err := queries.FinishEmbeddingMigration(ctx, db.FinishEmbeddingMigrationParams{
+		Status:      string(entity.MigrationCompleted),
+		Remaining:   0,
+		MigrationID: migrationID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to complete migration: %w", err)
+	}
+
+	apply := []struct {
+		table string
+		query func(context.Context) (int64, error)
+	}{
+		{entity.EmbeddingTableContentReferences, func(ctx context.Context) (int64, error) {
+			return queries.ApplyStagedContentReferenceEmbeddings(ctx, db.ApplyStagedContentReferenceEmbeddingsParams{TargetModel: targetModel, MigrationID: migrationID})
+		}},
+		{entity.EmbeddingTableAnnotations, func(ctx context.Context) (int64, error) {
+			return queries.ApplyStagedAnnotationEmbeddings(ctx, db.ApplyStagedAnnotationEmbeddingsParams{TargetModel: targetModel, MigrationID: migrationID})
+		}},
+		{entity.EmbeddingTableSessionSummaries, func(ctx context.Context) (int64, error) {
+			return queries.ApplyStagedSessionSummaryEmbeddings(ctx, db.ApplyStagedSessionSummaryEmbeddingsParams{TargetModel: targetModel, MigrationID: migrationID})
+		}},
+		{entity.EmbeddingTableItems, func(ctx context.Context) (int64, error) {
+			return queries.ApplyStagedItemEmbeddings(ctx, db.ApplyStagedItemEmbeddingsParams{TargetModel: targetModel, MigrationID: migrationID})
+		}},
+	}
+
+	var applied int64
+	for _, a := range apply {
+		n, err := a.query(ctx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to apply staged embeddings of %s: %w", a.table, err)
+		}
+		applied += n
+	}
+
+	if err := queries.DeactivateEmbeddingModels(ctx, targetModel); err != nil {
+		return nil, 0, fmt.Errorf("failed to deactivate embedding models: %w", err)
+	}
+	if err := queries.ActivateEmbeddingModel(ctx, targetModel); err != nil {
+		return nil, 0, fmt.Errorf("failed to activate embedding model: %w", err)
+	}
+	if err := queries.DeleteEmbeddingMigrationVectors(ctx, migrationID); err != nil {
+		return nil, 0, fmt.Errorf("failed to delete staged embeddings: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, err
+	}
+	return toEmbeddingMigration(migration), applied, nil
+}
+
+func (r *EmbeddingModelRepository) CancelMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(r.pool).WithTx(tx)
+	migration, err := queries.FinishEmbeddingMigration(ctx, db.FinishEmbeddingMigrationParams{
+		Status:      string(entity.MigrationCancelled),
+		MigrationID: migrationID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel migration: %w", err)
+	}
+	if err := queries.DeleteEmbeddingMigrationVectors(ctx, migrationID); err != nil {
+		return nil, fmt.Errorf("failed to delete staged embeddings: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return toEmbeddingMigration(migration), nil
+}
+
+// toEmbeddingMigration converts a migration row to its entity
+func toEmbeddingMigration(row db.EmbeddingMigration) *entity.EmbeddingMigration {
+	return &entity.EmbeddingMigration{
+		MigrationID:      row.MigrationID,
+		SourceModel:      row.SourceModel,
+		TargetProvider:   row.TargetProvider,
+		TargetURL:        row.TargetUrl,
+		TargetModel:      row.TargetModel,
+		TargetDimensions: int(row.TargetDimensions),
+		TargetAPIKey:     row.TargetApiKey,
+		Status:           entity.EmbeddingMigrationStatus(row.Status),
+		Done:             int(row.Done),
+		Remaining:        int(row.Remaining),
+		Error:            row.Error,
+		StartedAt:        convertPgTimestampToTime(row.StartedAt),
+		UpdatedAt:        convertPgTimestampToTime(row.UpdatedAt),
+		FinishedAt:       convertPgTimestampToTimePtr(row.FinishedAt),
+	}
+}
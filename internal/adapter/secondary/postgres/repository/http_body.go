@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/klauspost/compress/zstd"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+)
+
+// maxBodySize bounds decompressed response bodies, well above the fetcher's own body limit
+const maxBodySize = 1 << 30
+
+// The encoder and decoder are safe for concurrent EncodeAll and DecodeAll calls
+var (
+	bodyEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	bodyDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxBodySize))
+)
+
+// storeResponseBody stores a zstd-compressed response body under the SHA-256 of its content,
+// once however many responses share it, and returns the hash
+func storeResponseBody(ctx context.Context, queries *db.Queries, content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	err := queries.InsertHttpResponseBody(ctx, db.InsertHttpResponseBodyParams{
+		ContentHash: hash,
+		Content:     bodyEncoder.EncodeAll(content, nil),
+		Size:        int64(len(content)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store response body: %w", err)
+	}
+	return hash, nil
+}
+
+// responseBody returns the body of a stored response: the content kept inline by rows written before
+// bodies were shared, or else the decompressed shared body
+func responseBody(inline, stored []byte) ([]byte, error) {
+	if inline != nil || stored == nil {
+		return inline, nil
+	}
+	content, err := bodyDecoder.DecodeAll(stored, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress response body: %w", err)
+	}
+	return content, nil
+}
+
+// releaseResponseBodies deletes the shared bodies no response refers to any more. A response written
+// concurrently may still claim one, and the foreign key then keeps the body, which is not an error
+func releaseResponseBodies(ctx context.Context, queries *db.Queries, hashes []*string) error {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, hash := range hashes {
+		if hash != nil && !seen[*hash] {
+			seen[*hash] = true
+			unique = append(unique, *hash)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+
+	_, err := queries.DeleteUnreferencedHttpResponseBodies(ctx, unique)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"garden3/internal/adapter/secondary/postgres/generated/db"
+)
+
+// recordingDB is a db.DBTX that records the arguments of every statement it executes
+type recordingDB struct {
+	execs   [][]interface{}
+	execErr error
+}
+
+func (d *recordingDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.execs = append(d.execs, args)
+	return pgconn.NewCommandTag("DELETE 0"), d.execErr
+}
+
+func (d *recordingDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *recordingDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	panic("unexpected query")
+}
+
+func TestStoreResponseBody(t *testing.T) {
+	recorder := &recordingDB{}
+	content := bytes.Repeat([]byte("<p>a compressible body</p>"), 100)
+
+	hash, err := storeResponseBody(context.Background(), db.New(recorder), content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	if hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("hash = %s, want the hex SHA-256 of the content", hash)
+	}
+	if len(recorder.execs) != 1 {
+		t.Fatalf("expected 1 insert, got %d", len(recorder.execs))
+	}
+	args := recorder.execs[0]
+	stored := args[1].([]byte)
+	if args[0] != hash || args[2] != int64(len(content)) {
+		t.Errorf("inserted hash %v and size %v, want %s and %d", args[0], args[2], hash, len(content))
+	}
+	if len(stored) >= len(content) {
+		t.Errorf("expected the body to be compressed, stored %d of %d bytes", len(stored), len(content))
+	}
+
+	body, err := responseBody(nil, stored)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(body, content) {
+		t.Error("expected the stored body to decompress to the content")
+	}
+
+	recorder.execErr = errors.New("connection lost")
+	if _, err := storeResponseBody(context.Background(), db.New(recorder), content); err == nil {
+		t.Error("expected a failed insert to be returned")
+	}
+}
+
+func TestResponseBody(t *testing.T) {
+	compressed := bodyEncoder.EncodeAll([]byte("shared"), nil)
+
+	testCases := []struct {
+		name    string
+		inline  []byte
+		stored  []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "inline content wins", inline: []byte("inline"), stored: compressed, want: []byte("inline")},
+		{name: "empty inline content wins", inline: []byte{}, stored: compressed, want: []byte{}},
+		{name: "shared body", stored: compressed, want: []byte("shared")},
+		{name: "no body", want: nil},
+		{name: "corrupt shared body", stored: []byte("not zstd"), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := responseBody(tc.inline, tc.stored)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("responseBody() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !bytes.Equal(got, tc.want) || (got == nil) != (tc.want == nil) {
+				t.Errorf("responseBody() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReleaseResponseBodies(t *testing.T) {
+	a, b := "a", "b"
+
+	recorder := &recordingDB{}
+	if err := releaseResponseBodies(context.Background(), db.New(recorder), []*string{&a, nil, &b, &a}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorder.execs) != 1 {
+		t.Fatalf("expected 1 delete, got %d", len(recorder.execs))
+	}
+	if hashes := recorder.execs[0][0].([]string); len(hashes) != 2 || hashes[0] != "a" || hashes[1] != "b" {
+		t.Errorf("deleted %q, want each hash once", hashes)
+	}
+
+	recorder = &recordingDB{}
+	if err := releaseResponseBodies(context.Background(), db.New(recorder), []*string{nil}); err != nil || len(recorder.execs) != 0 {
+		t.Errorf("expected nothing deleted without hashes, got %d deletes and %v", len(recorder.execs), err)
+	}
+
+	recorder = &recordingDB{execErr: &pgconn.PgError{Code: "23503"}}
+	if err := releaseResponseBodies(context.Background(), db.New(recorder), []*string{&a}); err != nil {
+		t.Errorf("expected a body claimed concurrently to be kept without an error, got %v", err)
+	}
+
+	recorder = &recordingDB{execErr: errors.New("connection lost")}
+	if err := releaseResponseBodies(context.Background(), db.New(recorder), []*string{&a}); err == nil {
+		t.Error("expected other errors to be returned")
+	}
+}
+
+func TestPruneHttpResponses(t *testing.T) {
+	pool := testPool(t)
+	repo := NewBookmarkRepository(pool)
+	ctx := context.Background()
+	bookmarkID := testBookmark(t, repo)
+
+	// From oldest to newest: the last success is followed by three failures
+	start := time.Now().Add(-time.Hour)
+	statuses := []int32{200, 200, 500, 404, 503}
+	for i, status := range statuses {
+		content := []byte{byte(i)}
+		if err := repo.InsertHttpResponse(ctx, bookmarkID, status, "{}", content, nil, "", start.Add(time.Duration(i)*time.Minute), nil); err != nil {
+			t.Fatalf("failed to insert response %d: %v", i, err)
+		}
+	}
+
+	pruned, err := repo.PruneHttpResponses(ctx, bookmarkID, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d responses, want 2", pruned)
+	}
+
+	rows, err := pool.Query(ctx, "SELECT status_code FROM http_responses WHERE bookmark_id = $1 ORDER BY fetch_date", bookmarkID)
+	if err != nil {
+		t.Fatalf("failed to list responses: %v", err)
+	}
+	kept, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+	if err != nil {
+		t.Fatalf("failed to list responses: %v", err)
+	}
+	if want := []int32{200, 404, 503}; len(kept) != len(want) || kept[0] != want[0] || kept[1] != want[1] || kept[2] != want[2] {
+		t.Errorf("kept %v, want the latest success and the 2 latest responses %v", kept, want)
+	}
+
+	var bodies int
+	err = pool.QueryRow(ctx, "SELECT count(*) FROM http_response_bodies WHERE content = ANY($1)", [][]byte{
+		bodyEncoder.EncodeAll([]byte{0}, nil),
+		bodyEncoder.EncodeAll([]byte{2}, nil),
+	}).Scan(&bodies)
+	if err != nil {
+		t.Fatalf("failed to count bodies: %v", err)
+	}
+	if bodies != 0 {
+		t.Errorf("expected the bodies of pruned responses to be deleted, %d remain", bodies)
+	}
+}
+
+func TestMigrateInlineHttpResponses(t *testing.T) {
+	pool := testPool(t)
+	repo := NewBookmarkRepository(pool)
+	ctx := context.Background()
+	bookmarkID := testBookmark(t, repo)
+
+	for i := 0; i < 3; i++ {
+		_, err := pool.Exec(ctx, "INSERT INTO http_responses (bookmark_id, status_code, headers, content, fetch_date) VALUES ($1, 200, '{}', $2, $3)",
+			bookmarkID, []byte{'v', byte('0' + i)}, time.Now().Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatalf("failed to insert inline response: %v", err)
+		}
+	}
+	_, err := pool.Exec(ctx, "UPDATE http_responses SET original_content = $2, original_content_type = 'text/html; charset=ISO-8859-1' WHERE bookmark_id = $1 AND content = 'v2'",
+		bookmarkID, []byte("v\xb2"))
+	if err != nil {
+		t.Fatalf("failed to set original content: %v", err)
+	}
+
+	// Other inline rows in the database are moved too, so batches are stepped until one comes back short
+	var after uuid.UUID
+	for batches := 0; ; batches++ {
+		if batches > 1000 {
+			t.Fatal("migration did not finish")
+		}
+		moved, last, err := repo.MigrateInlineHttpResponses(ctx, after, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if moved > 2 {
+			t.Fatalf("moved %d responses in a batch of 2", moved)
+		}
+		if moved > 0 && last.String() <= after.String() {
+			t.Fatalf("batch ended at %s, not after %s", last, after)
+		}
+		if moved < 2 {
+			break
+		}
+		after = last
+	}
+
+	var inline int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM http_responses WHERE bookmark_id = $1 AND (content IS NOT NULL OR original_content IS NOT NULL OR content_hash IS NULL)", bookmarkID).Scan(&inline); err != nil {
+		t.Fatalf("failed to count inline responses: %v", err)
+	}
+	if inline != 0 {
+		t.Errorf("%d responses are still stored inline", inline)
+	}
+
+	latest, err := repo.GetLatestHttpResponse(ctx, bookmarkID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latest == nil || string(latest.Content) != "v2" {
+		t.Fatalf("expected the moved body of the latest response, got %+v", latest)
+	}
+
+	original, contentType, err := repo.GetOriginalHttpResponseBody(ctx, latest.ResponseID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(original) != "v\xb2" || contentType != "text/html; charset=ISO-8859-1" {
+		t.Errorf("expected the moved original body, got %q with content type %q", original, contentType)
+	}
+}
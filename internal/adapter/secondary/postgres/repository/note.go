@@ -505,6 +505,8 @@ func (r *NoteRepository) SearchSimilarNotes(ctx context.Context, embedding []flo
 		LEFT JOIN item_tags it ON i.id = it.item_id
 		LEFT JOIN tags t ON it.tag_id = t.id
 		WHERE isi.strategy = $1
+			AND isi.embedding_model = public.active_embedding_model()
+			AND isi.embedding_dimensions = vector_dims($2::vector)
 		GROUP BY i.id, i.title, i.created, i.modified, isi.embedding
 		ORDER BY isi.embedding <=> $2::vector
 		LIMIT $3
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"garden3/internal/adapter/secondary/postgres/generated/db"
 	"garden3/internal/domain/entity"
@@ -23,12 +25,17 @@ func NewObservationRepository(pool *pgxpool.Pool) output.ObservationRepository {
 }
 
 func (r *observationRepository) Create(ctx context.Context, data []byte, obsType, source, tags string, ref uuid.UUID) (*entity.Observation, error) {
+	var pgRef pgtype.UUID
+	if ref != uuid.Nil {
+		pgRef = convertUUIDToPgUUID(ref)
+	}
+
 	row, err := r.queries.CreateObservationWithReturn(ctx, db.CreateObservationWithReturnParams{
 		Data:   data,
 		Type:   &obsType,
 		Source: &source,
 		Tags:   &tags,
-		Ref:    convertUUIDToPgUUID(ref),
+		Ref:    pgRef,
 	})
 	if err != nil {
 		return nil, err
@@ -54,6 +61,39 @@ func (r *observationRepository) GetFeedbackStats(ctx context.Context, bookmarkID
 	return stats, nil
 }
 
+func (r *observationRepository) ListFeedback(ctx context.Context) ([]entity.FeedbackData, error) {
+	rows, err := r.queries.ListFeedbackObservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback := make([]entity.FeedbackData, 0, len(rows))
+	for _, data := range rows {
+		var entry entity.FeedbackData
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		feedback = append(feedback, entry)
+	}
+	return feedback, nil
+}
+
+func (r *observationRepository) ListByType(ctx context.Context, obsType string, limit int32) ([]entity.Observation, error) {
+	rows, err := r.queries.ListObservationsByType(ctx, db.ListObservationsByTypeParams{
+		Type:     &obsType,
+		RowLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	observations := make([]entity.Observation, len(rows))
+	for i, row := range rows {
+		observations[i] = toEntityObservation(row)
+	}
+	return observations, nil
+}
+
 func (r *observationRepository) DeleteBookmarkContentReference(ctx context.Context, referenceID, bookmarkID uuid.UUID) error {
 	return r.queries.DeleteBookmarkContentReference(ctx, db.DeleteBookmarkContentReferenceParams{
 		ID:         referenceID,
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAnnotationNotFound is returned when an annotation does not exist
+	ErrAnnotationNotFound = errors.New("annotation not found")
+
+	// ErrInvalidAnnotation is returned when an annotation has neither a quote nor a valid position range
+	ErrInvalidAnnotation = errors.New("invalid annotation selector")
+
+	// ErrQuoteNotFound is returned when the quote of a new annotation does not occur in the reader text
+	ErrQuoteNotFound = errors.New("quote not found in reader content")
+
+	// ErrNoReaderContent is returned when a bookmark has no processed content to annotate
+	ErrNoReaderContent = errors.New("bookmark has no reader content")
+)
+
+// AnchorStatus describes how an annotation was located in the current reader text
+type AnchorStatus string
+
+const (
+	// AnchorExact means the quote is still at its recorded position
+	AnchorExact AnchorStatus = "exact"
+	// AnchorMoved means the quote was found unchanged at another position
+	AnchorMoved AnchorStatus = "moved"
+	// AnchorFuzzy means the quote only matched after ignoring whitespace and Markdown formatting,
+	// or the text between its prefix and suffix was taken in its place
+	AnchorFuzzy AnchorStatus = "fuzzy"
+	// AnchorOrphaned means the quote could not be found any more
+	AnchorOrphaned AnchorStatus = "orphaned"
+)
+
+// AnnotationSelector locates a highlight in the reader Markdown of a bookmark both by quote, with some
+// surrounding text to tell repeated quotes apart, and by position. Positions count Unicode code points
+type AnnotationSelector struct {
+	Exact  string `json:"exact"`
+	Prefix string `json:"prefix"`
+	Suffix string `json:"suffix"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+}
+
+// AnnotationAnchor is where an annotation's selector resolves in the current reader text
+type AnnotationAnchor struct {
+	Status AnchorStatus `json:"status"`
+	Start  int          `json:"start"`
+	End    int          `json:"end"`
+}
+
+// BookmarkAnnotation represents a highlight on the reader text of a bookmark with an optional note.
+// Note holds entity links as [[entity-id]], ProcessedNote renders them as Markdown links
+type BookmarkAnnotation struct {
+	AnnotationID  uuid.UUID          `json:"annotation_id"`
+	BookmarkID    uuid.UUID          `json:"bookmark_id"`
+	Selector      AnnotationSelector `json:"selector"`
+	Note          *string            `json:"note,omitempty"`
+	ProcessedNote *string            `json:"processed_note,omitempty"`
+	Tags          []string           `json:"tags"`
+	Anchor        *AnnotationAnchor  `json:"anchor,omitempty"`
+	BookmarkURL   *string            `json:"bookmark_url,omitempty"`
+	BookmarkTitle *string            `json:"bookmark_title,omitempty"`
+	Similarity    *float64           `json:"similarity,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// CreateAnnotationInput represents the input for annotating a bookmark. Either Exact or both Start and
+// End must be given; the missing parts of the selector are taken from the reader text
+type CreateAnnotationInput struct {
+	Exact  string
+	Prefix *string
+	Suffix *string
+	Start  *int
+	End    *int
+	Note   *string
+	Tags   []string
+}
+
+// UpdateAnnotationInput represents the input for updating an annotation. Giving Exact or Start and End
+// moves the highlight, which is resolved against the reader text as on creation
+type UpdateAnnotationInput struct {
+	Exact  *string
+	Prefix *string
+	Suffix *string
+	Start  *int
+	End    *int
+	Note   *string
+	Tags   *[]string
+}
+
+// AnnotationFilters represents filters for listing annotations across bookmarks
+type AnnotationFilters struct {
+	Search *string
+	Tag    *string
+	Page   int32
+	Limit  int32
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrArchiveTooLarge is returned when a page snapshot exceeds the archive size limit
+	ErrArchiveTooLarge = errors.New("archive too large")
+
+	// ErrArchiveStoreFull is returned when storing an archive would exceed the archive store's size limit
+	ErrArchiveStoreFull = errors.New("archive store is full")
+
+	// ErrArchiveNotFound is returned when a bookmark has no archive in the requested format
+	ErrArchiveNotFound = errors.New("archive not found")
+)
+
+// ArchiveFormat identifies one of the stored forms of a page archive
+type ArchiveFormat string
+
+const (
+	// ArchiveHTML is a single HTML document with its images, stylesheets and fonts inlined
+	ArchiveHTML ArchiveFormat = "html"
+	// ArchiveWARC is a gzipped WARC file holding the page and every resource fetched for it
+	ArchiveWARC ArchiveFormat = "warc"
+)
+
+// BookmarkArchive represents a stored offline copy of a bookmarked page
+type BookmarkArchive struct {
+	ArchiveID       uuid.UUID `json:"archive_id"`
+	BookmarkID      uuid.UUID `json:"bookmark_id"`
+	URL             string    `json:"url"`
+	HTMLHash        string    `json:"html_hash"`
+	HTMLSize        int64     `json:"html_size"`
+	WARCHash        *string   `json:"warc_hash,omitempty"`
+	WARCSize        *int64    `json:"warc_size,omitempty"`
+	ResourceCount   int32     `json:"resource_count"`
+	FailedResources int32     `json:"failed_resources"`
+	ArchivedAt      time.Time `json:"archived_at"`
+}
@@ -1,11 +1,30 @@
 package entity
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrInvalidBookmarkURL is returned when a bookmark URL cannot be canonicalized
+	ErrInvalidBookmarkURL = errors.New("invalid bookmark URL")
+
+	// ErrDuplicateBookmark is returned when an update would collide with another bookmark's URL
+	ErrDuplicateBookmark = errors.New("another bookmark already uses this URL")
+
+	// ErrBookmarkNotFound is returned when a bookmark does not exist
+	ErrBookmarkNotFound = errors.New("bookmark not found")
+
+	// ErrFetchTooLarge is returned when a response body exceeds the fetcher's size limit
+	ErrFetchTooLarge = errors.New("response body too large")
+
+	// ErrFetchDisallowed is returned when robots.txt does not allow fetching a URL
+	ErrFetchDisallowed = errors.New("disallowed by robots.txt")
+)
+
 // Bookmark represents a basic bookmark
 type Bookmark struct {
 	BookmarkID   uuid.UUID
@@ -15,11 +34,12 @@ type Bookmark struct {
 
 // BookmarkWithTitle represents a bookmark with its title
 type BookmarkWithTitle struct {
-	BookmarkID   uuid.UUID `json:"bookmark_id"`
-	URL          string    `json:"url"`
-	CreationDate time.Time `json:"creation_date"`
-	Title        *string   `json:"title,omitempty"`
-	Summary      *string   `json:"summary,omitempty"`
+	BookmarkID   uuid.UUID      `json:"bookmark_id"`
+	URL          string         `json:"url"`
+	CreationDate time.Time      `json:"creation_date"`
+	Title        *string        `json:"title,omitempty"`
+	Summary      *string        `json:"summary,omitempty"`
+	Chunk        *ChunkPosition `json:"chunk,omitempty"`
 }
 
 // BookmarkDetails represents a complete bookmark with all relations
@@ -33,12 +53,17 @@ type BookmarkDetails struct {
 	Title        *string                `json:"title,omitempty"`
 	LynxContent  *string                `json:"lynx,omitempty"`
 	ReaderContent *string               `json:"reader,omitempty"`
+	ContentStrategy *string             `json:"content_strategy,omitempty"`
 	Summary      *string                `json:"summary,omitempty"`
 	StatusCode   *int32                 `json:"status_code,omitempty"`
 	Headers      *string                `json:"headers,omitempty"`
 	HTTPContent  []byte                 `json:"content,omitempty"`
 	FetchDate    *time.Time             `json:"fetch_date,omitempty"`
+	FetchProfile *string                `json:"fetch_profile,omitempty"`
 	Questions    []BookmarkQuestion     `json:"questions,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+	Metadata     *BookmarkMetadata      `json:"metadata,omitempty"`
+	LinkChecks   []LinkCheck            `json:"link_checks,omitempty"`
 }
 
 // BookmarkQuestion represents a Q&A pair for a bookmark
@@ -47,6 +72,25 @@ type BookmarkQuestion struct {
 	Content string    `json:"content"`
 }
 
+// QuestionAnswer represents a question about a bookmark and its answer
+type QuestionAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// GenerateQuestionsInput represents input for generating Q&A pairs
+type GenerateQuestionsInput struct {
+	Count int
+	Force bool
+}
+
+// GenerateQuestionsResult represents the Q&A pairs stored for a bookmark
+type GenerateQuestionsResult struct {
+	PromptVersion string             `json:"prompt_version"`
+	Generated     bool               `json:"generated"`
+	Questions     []BookmarkQuestion `json:"questions"`
+}
+
 // BookmarkContentReference represents content reference with embeddings
 type BookmarkContentReference struct {
 	ID         uuid.UUID
@@ -62,6 +106,10 @@ type BookmarkFilters struct {
 	SearchQuery       *string
 	StartCreationDate *time.Time
 	EndCreationDate   *time.Time
+	FailedStage       *string
+	Author            *string
+	Site              *string
+	PublishedYear     *int32
 	Page              int32
 	Limit             int32
 }
@@ -84,18 +132,83 @@ type DeleteQuestionInput struct {
 	Answer      string
 }
 
+// CreateBookmarkInput represents input for creating a bookmark
+type CreateBookmarkInput struct {
+	URL          string
+	Title        *string
+	CategoryID   *uuid.UUID
+	CreationDate *time.Time
+	SourceURI    *string
+	RawSource    json.RawMessage
+	Tags         []string
+	// ArchivedContent is already-extracted article text, stored as reader content so the page is not refetched
+	ArchivedContent *string
+}
+
+// NewBookmark represents a bookmark and the related rows that are stored with it in one transaction
+type NewBookmark struct {
+	URL           string
+	CreationDate  time.Time
+	Title         *string
+	CategoryID    *uuid.UUID
+	SourceURI     *string
+	RawSource     json.RawMessage
+	Tags          []string
+	ReaderContent *string
+}
+
+// UpdateBookmarkInput represents input for updating a bookmark
+type UpdateBookmarkInput struct {
+	URL        *string
+	Title      *string
+	CategoryID *uuid.UUID
+}
+
+// CreateBookmarkResult represents the result of creating a bookmark
+// Created is false when an existing bookmark with the same canonical URL was returned
+type CreateBookmarkResult struct {
+	Bookmark BookmarkWithTitle `json:"bookmark"`
+	Created  bool              `json:"created"`
+}
+
 // ProcessingResult represents the result of processing operations
 type ProcessingResult struct {
-	Message string  `json:"message"`
-	Content *string `json:"content,omitempty"`
+	Message   string            `json:"message"`
+	Content   *string           `json:"content,omitempty"`
+	Extractor *string           `json:"extractor,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// ExtractedDocument is the readable content of a page, with the name of the
+// extractor that produced it and the metadata that extractor found
+type ExtractedDocument struct {
+	Extractor string
+	Title     string
+	Content   string
+	Metadata  map[string]string
 }
 
 // FetchResult represents the result of fetching bookmark content
 type FetchResult struct {
-	StatusCode int32  `json:"status_code"`
-	Headers    string `json:"headers"`
-	Content    []byte `json:"content"`
-	Message    string `json:"message"`
+	StatusCode   int32   `json:"status_code"`
+	Headers      string  `json:"headers"`
+	Content      []byte  `json:"content"`
+	Message      string  `json:"message"`
+	FetchProfile *string `json:"fetch_profile,omitempty"`
+}
+
+// BookmarkURLMigration reports the stored bookmark URLs rewritten to their canonical form and the
+// duplicate bookmarks merged into the oldest bookmark of their URL
+type BookmarkURLMigration struct {
+	Canonicalized int
+	Merged        int
+}
+
+// ResponseStorageMigration reports the stored HTTP responses moved to shared, compressed bodies
+// and the responses deleted by the retention policy
+type ResponseStorageMigration struct {
+	Migrated int
+	Pruned   int64
 }
 
 // EmbeddingResult represents the result of creating embeddings
@@ -108,7 +221,6 @@ type EmbeddingResult struct {
 type SummaryEmbeddingResult struct {
 	IDs     []uuid.UUID `json:"ids"`
 	Summary string      `json:"summary"`
-	Warning *string     `json:"warning,omitempty"`
 }
 
 // TitleExtractionResult represents the result of title extraction
@@ -117,8 +229,27 @@ type TitleExtractionResult struct {
 	Title *string
 }
 
-// Embedding represents a text embedding
+// Embedding represents a text embedding. Chunk locates the embedded text in the text it was cut from
 type Embedding struct {
 	Text      string
 	Embedding []float32
+	Chunk     *ChunkPosition
+}
+
+// ChunkPosition locates a chunk in the document it was cut from. Start and End count Unicode code points,
+// like annotation positions, and Headings is the path of Markdown headings the chunk sits under
+type ChunkPosition struct {
+	Index    int      `json:"index"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	Headings []string `json:"headings,omitempty"`
+	Tokens   int      `json:"tokens"`
+}
+
+// EmbeddingProgress reports how far a bulk embedding got. Remaining is estimated from the pace so far
+type EmbeddingProgress struct {
+	Done      int
+	Total     int
+	Elapsed   time.Duration
+	Remaining time.Duration
 }
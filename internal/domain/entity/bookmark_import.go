@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnsupportedImportFormat is returned when no parser is registered for the requested format
+	ErrUnsupportedImportFormat = errors.New("unsupported import format")
+
+	// ErrImportNotResumable is returned when a resume targets a missing, finished, running or mismatched import
+	ErrImportNotResumable = errors.New("import cannot be resumed")
+)
+
+// ImportFormat identifies a bookmark interchange format
+type ImportFormat string
+
+const (
+	ImportFormatNetscape   ImportFormat = "netscape"
+	ImportFormatPocketHTML ImportFormat = "pocket-html"
+	ImportFormatPocketCSV  ImportFormat = "pocket-csv"
+	ImportFormatPinboard   ImportFormat = "pinboard"
+	ImportFormatRaindrop   ImportFormat = "raindrop"
+	ImportFormatWallabag   ImportFormat = "wallabag"
+)
+
+// ImportStatus represents the state of a bookmark import
+type ImportStatus string
+
+const (
+	ImportRunning     ImportStatus = "running"
+	ImportCompleted   ImportStatus = "completed"
+	ImportInterrupted ImportStatus = "interrupted"
+	ImportFailed      ImportStatus = "failed"
+)
+
+// ImportedBookmark represents one entry read from a bookmark interchange file
+type ImportedBookmark struct {
+	URL         string
+	Title       *string
+	Description *string
+	AddDate     *time.Time
+	Folders     []string
+	Tags        []string
+	RawEntry    json.RawMessage
+	// ArchivedContent is article text saved by the exporting service, ArchivedHTML tells whether it still needs extraction
+	ArchivedContent *string
+	ArchivedHTML    bool
+}
+
+// ImportBookmarksInput represents input for importing a bookmark file
+// ResumeImportID continues an earlier import of the same file, skipping the entries it already processed
+type ImportBookmarksInput struct {
+	Format         ImportFormat
+	Source         string
+	ResumeImportID *uuid.UUID
+}
+
+// ImportError represents an entry that could not be imported
+type ImportError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// ImportDuplicate represents an imported entry that matched an existing bookmark
+type ImportDuplicate struct {
+	URL        string    `json:"url"`
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+}
+
+// ImportResult represents the progress and outcome of a bookmark import
+// Total counts every entry read from the file so far, which is where a resumed import continues
+type ImportResult struct {
+	ImportID         uuid.UUID         `json:"import_id"`
+	Format           ImportFormat      `json:"format"`
+	Source           *string           `json:"source,omitempty"`
+	Status           ImportStatus      `json:"status"`
+	Total            int               `json:"total"`
+	Created          int               `json:"created"`
+	Duplicates       int               `json:"duplicates"`
+	Failed           int               `json:"failed"`
+	Errors           []ImportError     `json:"errors,omitempty"`
+	DuplicateEntries []ImportDuplicate `json:"duplicate_entries,omitempty"`
+	Error            *string           `json:"error,omitempty"`
+	StartedAt        time.Time         `json:"started_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	FinishedAt       *time.Time        `json:"finished_at,omitempty"`
+}
+
+// ExportBookmark represents a bookmark written to an interchange file
+type ExportBookmark struct {
+	BookmarkID   uuid.UUID
+	URL          string
+	Title        *string
+	CategoryID   *uuid.UUID
+	CategoryName *string
+	CreationDate time.Time
+}
+
+// ExportFolder represents a group of exported bookmarks and the folders of its subcategories,
+// an empty Name holds uncategorized bookmarks
+type ExportFolder struct {
+	Name      string
+	Bookmarks []ExportBookmark
+	Children  []ExportFolder
+}
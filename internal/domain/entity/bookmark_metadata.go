@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PageMetadata represents what a page says about itself in its head: OpenGraph,
+// Twitter card and standard meta tags, link relations and schema.org JSON-LD
+type PageMetadata struct {
+	CanonicalURL *string           `json:"canonical_url,omitempty"`
+	Title        *string           `json:"title,omitempty"`
+	Description  *string           `json:"description,omitempty"`
+	SiteName     *string           `json:"site_name,omitempty"`
+	Author       *string           `json:"author,omitempty"`
+	PublishedAt  *time.Time        `json:"published_at,omitempty"`
+	Language     *string           `json:"language,omitempty"`
+	FaviconURL   *string           `json:"favicon_url,omitempty"`
+	ImageURL     *string           `json:"image_url,omitempty"`
+	ContentType  *string           `json:"content_type,omitempty"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	JSONLD       []json.RawMessage `json:"json_ld,omitempty"`
+}
+
+// BookmarkMetadata represents the stored page metadata of a bookmark with
+// reading statistics computed from its readable content, and the name and
+// metadata of the extractor that produced that content
+type BookmarkMetadata struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	PageMetadata
+	WordCount          int32             `json:"word_count"`
+	ReadingTimeMinutes int32             `json:"reading_time_minutes"`
+	Extractor          *string           `json:"extractor,omitempty"`
+	ExtractorMetadata  map[string]string `json:"extractor_metadata,omitempty"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSuggestionReviewed is returned when reviewing a category suggestion that is no longer pending
+var ErrSuggestionReviewed = errors.New("category suggestion already reviewed")
+
+// SuggestionStatus is the review state of a category suggestion
+type SuggestionStatus string
+
+const (
+	SuggestionPending  SuggestionStatus = "pending"
+	SuggestionAccepted SuggestionStatus = "accepted"
+	SuggestionRejected SuggestionStatus = "rejected"
+	SuggestionAuto     SuggestionStatus = "auto"
+)
+
+// IsSuggestionStatus reports whether s names a suggestion status
+func IsSuggestionStatus(s string) bool {
+	switch SuggestionStatus(s) {
+	case SuggestionPending, SuggestionAccepted, SuggestionRejected, SuggestionAuto:
+		return true
+	}
+	return false
+}
+
+// CategoryProfile summarizes what a category looks like to the categorizer: the mean of the summary
+// embeddings of its bookmarks and of the embedding of SourceText, its name and sources
+type CategoryProfile struct {
+	CategoryID    uuid.UUID `json:"category_id"`
+	Name          string    `json:"name"`
+	SourceText    string    `json:"source_text"`
+	BookmarkCount int32     `json:"bookmark_count"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CategoryProfileRefresh reports a rebuild of the category profiles
+type CategoryProfileRefresh struct {
+	Profiles        int `json:"profiles"`
+	Removed         int `json:"removed"`
+	EmbeddedSources int `json:"embedded_sources"`
+}
+
+// CategoryMatch is the similarity between a bookmark's summary and a category profile
+type CategoryMatch struct {
+	CategoryID uuid.UUID
+	Name       string
+	Similarity float64
+}
+
+// CategorySuggestion is a category proposed for a bookmark. Confidence is the cosine similarity between
+// the bookmark's summary embedding and the category profile; suggestions at or above the auto-assign
+// threshold are applied right away with status auto, the others wait for review as pending
+type CategorySuggestion struct {
+	SuggestionID uuid.UUID        `json:"suggestion_id"`
+	BookmarkID   uuid.UUID        `json:"bookmark_id"`
+	URL          string           `json:"url"`
+	Title        *string          `json:"title"`
+	CategoryID   uuid.UUID        `json:"category_id"`
+	CategoryName string           `json:"category_name"`
+	Confidence   float64          `json:"confidence"`
+	Status       SuggestionStatus `json:"status"`
+	CreatedAt    time.Time        `json:"created_at"`
+	ReviewedAt   *time.Time       `json:"reviewed_at,omitempty"`
+}
+
+// CategorySuggestionFilters represents filters for listing category suggestions
+type CategorySuggestionFilters struct {
+	Status SuggestionStatus
+	Page   int32
+	Limit  int32
+}
@@ -2,14 +2,24 @@ package entity
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/google/uuid"
 )
 
-// Category represents a classification category
+var (
+	// ErrCategoryNotFound is returned when a category or the parent it is moved under does not exist
+	ErrCategoryNotFound = errors.New("category not found")
+
+	// ErrCategoryCycle is returned when a category would be moved under itself or one of its descendants
+	ErrCategoryCycle = errors.New("category cannot be moved under itself or its descendants")
+)
+
+// Category represents a classification category. Categories without a parent are at the top level
 type Category struct {
 	CategoryID uuid.UUID
 	Name       string
+	ParentID   *uuid.UUID
 }
 
 // CategorySource represents a source for a category
@@ -20,10 +30,13 @@ type CategorySource struct {
 	RawSource  json.RawMessage
 }
 
-// CategoryWithSources represents a category with all its sources
+// CategoryWithSources represents a category with all its sources. BookmarkCount counts the bookmarks filed
+// directly under the category, SubtreeBookmarkCount those filed under it or any of its descendants
 type CategoryWithSources struct {
-	Category Category
-	Sources  []CategorySource
+	Category             Category
+	Sources              []CategorySource
+	BookmarkCount        int64
+	SubtreeBookmarkCount int64
 }
 
 // CreateCategorySourceInput represents the input for creating a category source
@@ -44,3 +57,8 @@ type MergeCategoriesInput struct {
 	SourceID uuid.UUID
 	TargetID uuid.UUID
 }
+
+// MoveCategoryInput represents the input for moving a category, a nil ParentID moves it to the top level
+type MoveCategoryInput struct {
+	ParentID *uuid.UUID
+}
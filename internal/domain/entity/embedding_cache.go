@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// CachedEmbedding is a vector kept for a text and model, found again by Key, a hash of both
+type CachedEmbedding struct {
+	Key       string
+	Model     string
+	Embedding []float32
+}
+
+// EmbeddingCacheUsage describes the stored vectors: how many there are, the hits counted on them and when
+// the least recently used one was last used
+type EmbeddingCacheUsage struct {
+	Entries   int64      `json:"entries"`
+	Hits      int64      `json:"hits"`
+	OldestUse *time.Time `json:"oldest_use,omitempty"`
+}
+
+// EmbeddingCacheStats reports how well the embedding cache works. Hits, Misses and Evicted count the texts
+// looked up and the vectors evicted since Since, when the server started. Usage describes what is stored
+type EmbeddingCacheStats struct {
+	Enabled bool                `json:"enabled"`
+	Hits    int64               `json:"hits"`
+	Misses  int64               `json:"misses"`
+	HitRate float64             `json:"hit_rate"`
+	Evicted int64               `json:"evicted"`
+	Since   time.Time           `json:"since"`
+	Usage   EmbeddingCacheUsage `json:"usage"`
+}
+
+// EmbeddingCacheEviction counts the vectors an eviction removed, unused for longer than the maximum age or
+// beyond the maximum number of entries
+type EmbeddingCacheEviction struct {
+	Unused int64 `json:"unused"`
+	Excess int64 `json:"excess"`
+}
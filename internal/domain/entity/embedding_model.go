@@ -0,0 +1,134 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidEmbeddingModel is returned when a model configuration cannot be used to embed texts
+	ErrInvalidEmbeddingModel = errors.New("invalid embedding model")
+
+	// ErrEmbeddingMigrationOpen is returned when starting a migration while another one has not finished
+	ErrEmbeddingMigrationOpen = errors.New("an embedding migration is already in progress")
+
+	// ErrEmbeddingMigrationNotFound is returned when a migration does not exist
+	ErrEmbeddingMigrationNotFound = errors.New("embedding migration not found")
+
+	// ErrEmbeddingMigrationFinished is returned when changing a migration that was cut over or cancelled
+	ErrEmbeddingMigrationFinished = errors.New("embedding migration already finished")
+
+	// ErrEmbeddingMigrationNotReady is returned when cutting over before every vector has been staged
+	ErrEmbeddingMigrationNotReady = errors.New("embedding migration has not staged every vector yet")
+
+	// ErrEmbeddingModelMismatch is returned when embedding a search query while the live model is not the
+	// active one, as its vectors cannot be compared with the stored ones
+	ErrEmbeddingModelMismatch = errors.New("the live embedding model is not the active one")
+)
+
+// EmbeddingMigrationStatus is the state of a re-embedding migration
+type EmbeddingMigrationStatus string
+
+const (
+	// MigrationRunning migrations are embedding the corpus with the target model
+	MigrationRunning EmbeddingMigrationStatus = "running"
+	// MigrationReady migrations have staged a vector for every row and wait for the cutover
+	MigrationReady EmbeddingMigrationStatus = "ready"
+	// MigrationCompleted migrations were cut over, their model is the active one
+	MigrationCompleted EmbeddingMigrationStatus = "completed"
+	// MigrationCancelled migrations were dropped along with their staged vectors
+	MigrationCancelled EmbeddingMigrationStatus = "cancelled"
+)
+
+// Tables holding embeddings that a migration re-embeds
+const (
+	EmbeddingTableContentReferences = "bookmark_content_references"
+	EmbeddingTableAnnotations       = "bookmark_annotations"
+	EmbeddingTableSessionSummaries  = "session_summaries"
+	EmbeddingTableItems             = "item_semantic_index"
+)
+
+// EmbeddingModelConfig selects an embedding provider and model. Empty fields take the provider defaults
+type EmbeddingModelConfig struct {
+	Provider   string
+	URL        string
+	Model      string
+	Dimensions int
+	APIKey     string
+}
+
+// EmbeddingModelUsage counts the vectors of a table made by one model
+type EmbeddingModelUsage struct {
+	Table      string  `json:"table"`
+	Model      *string `json:"model"`
+	Dimensions *int32  `json:"dimensions"`
+	Vectors    int64   `json:"vectors"`
+}
+
+// EmbeddingModelStatus describes the model searches compare against, the model new texts are embedded
+// with and the vectors stored per model. The two models only differ after changing the embedding
+// configuration without a migration, until the next start
+type EmbeddingModelStatus struct {
+	ActiveModel string                `json:"active_model"`
+	LiveModel   string                `json:"live_model"`
+	Usage       []EmbeddingModelUsage `json:"usage"`
+	Migration   *EmbeddingMigration   `json:"migration,omitempty"`
+}
+
+// EmbeddingMigration re-embeds the corpus with a target model. New vectors are staged next to the stored
+// ones, which keep serving searches until the cutover swaps them in and makes the target model active.
+// Remaining counts the rows left when last checked and ETASeconds estimates their time from the pace so far
+type EmbeddingMigration struct {
+	MigrationID      uuid.UUID                `json:"migration_id"`
+	SourceModel      *string                  `json:"source_model"`
+	TargetProvider   string                   `json:"target_provider"`
+	TargetURL        string                   `json:"target_url"`
+	TargetModel      string                   `json:"target_model"`
+	TargetDimensions int                      `json:"target_dimensions"`
+	TargetAPIKey     string                   `json:"-"`
+	Status           EmbeddingMigrationStatus `json:"status"`
+	Done             int                      `json:"done"`
+	Remaining        int                      `json:"remaining"`
+	ETASeconds       int64                    `json:"eta_seconds,omitempty"`
+	Error            *string                  `json:"error,omitempty"`
+	StartedAt        time.Time                `json:"started_at"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+	FinishedAt       *time.Time               `json:"finished_at,omitempty"`
+}
+
+// Target returns the model configuration the migration embeds with
+func (m EmbeddingMigration) Target() EmbeddingModelConfig {
+	return EmbeddingModelConfig{
+		Provider:   m.TargetProvider,
+		URL:        m.TargetURL,
+		Model:      m.TargetModel,
+		Dimensions: m.TargetDimensions,
+		APIKey:     m.TargetAPIKey,
+	}
+}
+
+// EmbeddingMigrationRow is a stored embedding still to be made with the target model. Text and ExtraText
+// are the parts of the row the embedding is made from, SourceHash identifies them
+type EmbeddingMigrationRow struct {
+	Table      string
+	RowID      uuid.UUID
+	Text       string
+	ExtraText  string
+	SourceHash string
+}
+
+// StagedEmbedding is a vector made with the target model for a row of the corpus
+type StagedEmbedding struct {
+	Table      string
+	RowID      uuid.UUID
+	SourceHash string
+	Embedding  []float32
+}
+
+// EmbeddingCutover reports the vectors a cutover swapped in
+type EmbeddingCutover struct {
+	Migration *EmbeddingMigration `json:"migration"`
+	Applied   int64               `json:"applied"`
+}
@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidEvaluation is returned when an evaluation has no score, a score out of range, or refers
+	// to content that is not a summary or the generated questions of the bookmark
+	ErrInvalidEvaluation = errors.New("invalid evaluation")
+)
+
+// Evaluation scores range from MinEvaluationScore to MaxEvaluationScore
+const (
+	MinEvaluationScore = 1
+	MaxEvaluationScore = 5
+)
+
+// BookmarkEvaluation represents a manual review of a bookmark's summary and generated questions. The
+// strategy, model and prompt version that produced the evaluated content are recorded with it, so
+// scores stay comparable after the content is regenerated
+type BookmarkEvaluation struct {
+	ID                     uuid.UUID   `json:"id"`
+	BookmarkID             uuid.UUID   `json:"bookmark_id"`
+	FailedFetch            bool        `json:"failed_fetch"`
+	SummaryID              *uuid.UUID  `json:"summary_id,omitempty"`
+	SummaryEval            *int32      `json:"summary_eval,omitempty"`
+	SummaryComments        *string     `json:"summary_comments,omitempty"`
+	SummaryStrategy        *string     `json:"summary_strategy,omitempty"`
+	SummaryModel           *string     `json:"summary_model,omitempty"`
+	SummaryPromptVersion   *string     `json:"summary_prompt_version,omitempty"`
+	QuestionsEval          *int32      `json:"questions_eval,omitempty"`
+	QuestionsIDs           []uuid.UUID `json:"questions_ids,omitempty"`
+	QuestionsStrategy      *string     `json:"questions_strategy,omitempty"`
+	QuestionsModel         *string     `json:"questions_model,omitempty"`
+	QuestionsPromptVersion *string     `json:"questions_prompt_version,omitempty"`
+	CreatedAt              time.Time   `json:"created_at"`
+}
+
+// CreateEvaluationInput represents the input for evaluating a bookmark. Without SummaryID the latest
+// summary is evaluated, and without QuestionsIDs the questions of the current prompt version
+type CreateEvaluationInput struct {
+	FailedFetch     *bool
+	SummaryID       *uuid.UUID
+	SummaryEval     *int32
+	SummaryComments *string
+	QuestionsEval   *int32
+	QuestionsIDs    []uuid.UUID
+}
+
+// ContentGeneration identifies what produced a stored summary or question
+type ContentGeneration struct {
+	ID            uuid.UUID
+	Strategy      *string
+	Model         *string
+	PromptVersion *string
+}
+
+// EvaluationAggregate summarizes the scores given to content from one strategy, model and prompt
+// version, within one period when the report is split by period
+type EvaluationAggregate struct {
+	Strategy         *string    `json:"strategy"`
+	Model            *string    `json:"model"`
+	PromptVersion    *string    `json:"prompt_version"`
+	PeriodStart      *time.Time `json:"period_start,omitempty"`
+	Evaluations      int64      `json:"evaluations"`
+	AverageScore     float64    `json:"average_score"`
+	MinScore         int32      `json:"min_score"`
+	MaxScore         int32      `json:"max_score"`
+	FirstEvaluatedAt time.Time  `json:"first_evaluated_at"`
+	LastEvaluatedAt  time.Time  `json:"last_evaluated_at"`
+}
+
+// EvaluationReport holds the average summary and question scores of the evaluations in a time range
+type EvaluationReport struct {
+	Summary       []EvaluationAggregate `json:"summary"`
+	Questions     []EvaluationAggregate `json:"questions"`
+	Evaluations   int64                 `json:"evaluations"`
+	FailedFetches int64                 `json:"failed_fetches"`
+}
+
+// EvaluationReportFilters represents filters for the evaluation report. Period is day, week or month
+type EvaluationReportFilters struct {
+	Since  *time.Time
+	Until  *time.Time
+	Period *string
+}
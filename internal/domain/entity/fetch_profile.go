@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidFetchProfile is returned when a fetch profile has an invalid domain or setting
+	ErrInvalidFetchProfile = errors.New("invalid fetch profile")
+
+	// ErrInvalidCookieFile is returned when an imported cookie jar is not a Netscape cookies.txt file
+	ErrInvalidCookieFile = errors.New("invalid cookies.txt file")
+)
+
+// FetchProfile holds the settings applied when fetching pages of a domain and its subdomains
+type FetchProfile struct {
+	Domain    string            `json:"domain"`
+	UserAgent *string           `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Cookies   []FetchCookie     `json:"cookies,omitempty"`
+	TimeoutMs *int32            `json:"timeout_ms,omitempty"`
+	Proxy     *string           `json:"proxy,omitempty"`
+}
+
+// FetchCookie is a cookie sent with the requests of a fetch profile
+type FetchCookie struct {
+	Domain string `json:"domain"`
+	// HostOnly cookies are sent to Domain only, others to its subdomains as well
+	HostOnly bool       `json:"host_only"`
+	Path     string     `json:"path"`
+	Secure   bool       `json:"secure"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Name     string     `json:"name"`
+	Value    string     `json:"value,omitempty"`
+}
+
+// FetchProfileInput represents the editable settings of a fetch profile; cookies are imported separately
+type FetchProfileInput struct {
+	UserAgent *string
+	Headers   map[string]string
+	TimeoutMs *int32
+	Proxy     *string
+}
+
+// CookieImportResult represents the outcome of importing a cookie jar into a fetch profile
+type CookieImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkStatus is the verdict of a link check on a bookmarked page
+type LinkStatus string
+
+const (
+	LinkOK         LinkStatus = "ok"
+	LinkChanged    LinkStatus = "changed"
+	LinkRedirected LinkStatus = "redirected"
+	LinkParked     LinkStatus = "parked"
+	LinkGone       LinkStatus = "gone"
+	LinkError      LinkStatus = "error"
+)
+
+// LinkStatuses lists every link check verdict
+var LinkStatuses = []LinkStatus{
+	LinkOK,
+	LinkChanged,
+	LinkRedirected,
+	LinkParked,
+	LinkGone,
+	LinkError,
+}
+
+// IsLinkStatus reports whether status is a known link check verdict
+func IsLinkStatus(status string) bool {
+	for _, s := range LinkStatuses {
+		if string(s) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkCheck represents one re-fetch of a bookmarked URL by the link-rot monitor
+type LinkCheck struct {
+	CheckID      uuid.UUID  `json:"check_id"`
+	BookmarkID   uuid.UUID  `json:"bookmark_id"`
+	Status       LinkStatus `json:"status"`
+	Method       string     `json:"method"`
+	StatusCode   *int32     `json:"status_code,omitempty"`
+	FinalURL     *string    `json:"final_url,omitempty"`
+	Error        *string    `json:"error,omitempty"`
+	ETag         *string    `json:"etag,omitempty"`
+	LastModified *string    `json:"last_modified,omitempty"`
+	ContentHash  *string    `json:"content_hash,omitempty"`
+	ChangeRatio  *float64   `json:"change_ratio,omitempty"`
+	Diff         *string    `json:"diff,omitempty"`
+	CheckedAt    time.Time  `json:"checked_at"`
+	// Content is the readable text seen by the check, kept only when it differs from the previous check
+	Content *string `json:"-"`
+}
+
+// LinkIssue represents a bookmark whose latest link check found it dead, moved or changed
+type LinkIssue struct {
+	BookmarkID uuid.UUID `json:"bookmark_id"`
+	URL        string    `json:"url"`
+	Title      *string   `json:"title,omitempty"`
+	Check      LinkCheck `json:"check"`
+}
+
+// LinkIssueFilters represents filters for listing link issues
+type LinkIssueFilters struct {
+	Status *LinkStatus
+	Page   int32
+	Limit  int32
+}
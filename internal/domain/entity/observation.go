@@ -33,6 +33,7 @@ type FeedbackData struct {
 	Question     string       `json:"question"`
 	Answer       string       `json:"answer"`
 	BookmarkID   string       `json:"bookmarkId"`
+	ReferenceID  string       `json:"referenceId,omitempty"`
 	UserQuestion string       `json:"userQuestion"`
 	Similarity   float64      `json:"similarity"`
 	FeedbackType FeedbackType `json:"feedbackType"`
@@ -0,0 +1,155 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PipelineStage identifies one step of the bookmark ingestion pipeline
+type PipelineStage string
+
+const (
+	StageFetch         PipelineStage = "fetch"
+	StageReader        PipelineStage = "reader"
+	StageTitle         PipelineStage = "title"
+	StageChunkedReader PipelineStage = "chunked-reader"
+	StageSummaryReader PipelineStage = "summary-reader"
+	StageLynx          PipelineStage = "lynx"
+	StageQAPassage     PipelineStage = "qa-v2-passage"
+	StageMetadata      PipelineStage = "metadata"
+	StageArchive       PipelineStage = "archive"
+)
+
+// PipelineStages lists the ingestion stages in execution order
+var PipelineStages = []PipelineStage{
+	StageFetch,
+	StageReader,
+	StageMetadata,
+	StageTitle,
+	StageChunkedReader,
+	StageSummaryReader,
+	StageQAPassage,
+	StageArchive,
+}
+
+// StatusStages lists every stage reported by the bookmark status endpoint
+var StatusStages = []PipelineStage{
+	StageFetch,
+	StageLynx,
+	StageReader,
+	StageMetadata,
+	StageTitle,
+	StageChunkedReader,
+	StageSummaryReader,
+	StageQAPassage,
+	StageArchive,
+}
+
+// IsStatusStage reports whether the stage is tracked by the bookmark status endpoint
+func IsStatusStage(stage string) bool {
+	for _, s := range StatusStages {
+		if string(s) == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// StageStatus is the outcome of a stage run or the current state of a stage
+type StageStatus string
+
+const (
+	StageSucceeded StageStatus = "succeeded"
+	StageFailed    StageStatus = "failed"
+	StageSkipped   StageStatus = "skipped"
+	StagePending   StageStatus = "pending"
+)
+
+// PipelineJob represents a bookmark queued for ingestion starting at a given stage
+type PipelineJob struct {
+	BookmarkID uuid.UUID
+	From       PipelineStage
+}
+
+// PipelineStageResult represents the outcome of a single stage run
+type PipelineStageResult struct {
+	Stage    PipelineStage `json:"stage"`
+	Attempts int           `json:"attempts"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Error    *string       `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// PipelineResult represents the outcome of running the pipeline for a bookmark
+type PipelineResult struct {
+	BookmarkID uuid.UUID             `json:"bookmark_id"`
+	Stages     []PipelineStageResult `json:"stages"`
+}
+
+// StageRunInput represents input for recording a stage run
+type StageRunInput struct {
+	BookmarkID uuid.UUID
+	Stage      PipelineStage
+	Status     StageStatus
+	Message    *string
+	Error      *string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// StageRun represents one recorded execution of a processing stage
+type StageRun struct {
+	RunID      uuid.UUID     `json:"run_id"`
+	Stage      PipelineStage `json:"stage"`
+	Status     StageStatus   `json:"status"`
+	Message    *string       `json:"message,omitempty"`
+	Error      *string       `json:"error,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+}
+
+// StageRunSummary aggregates the recorded runs of one stage
+type StageRunSummary struct {
+	Latest        StageRun
+	Attempts      int
+	Failures      int
+	LastSuccessAt *time.Time
+}
+
+// StageArtifacts counts the rows a stage has produced for a bookmark
+type StageArtifacts struct {
+	Stage          PipelineStage
+	Count          int
+	LastProducedAt *time.Time
+}
+
+// FetchStatus represents the latest stored HTTP response of a bookmark
+type FetchStatus struct {
+	StatusCode *int32
+	FetchDate  *time.Time
+	Error      *string
+}
+
+// BookmarkStageStatus represents the current state of one stage for a bookmark
+type BookmarkStageStatus struct {
+	Stage         PipelineStage `json:"stage"`
+	Status        StageStatus   `json:"status"`
+	Attempts      int           `json:"attempts"`
+	Failures      int           `json:"failures"`
+	Artifacts     int           `json:"artifacts"`
+	StatusCode    *int32        `json:"status_code,omitempty"`
+	LastAttemptAt *time.Time    `json:"last_attempt_at,omitempty"`
+	LastSuccessAt *time.Time    `json:"last_success_at,omitempty"`
+	Message       *string       `json:"message,omitempty"`
+	Error         *string       `json:"error,omitempty"`
+}
+
+// BookmarkStatus represents the processing status and run history of a bookmark
+type BookmarkStatus struct {
+	BookmarkID uuid.UUID             `json:"bookmark_id"`
+	URL        string                `json:"url"`
+	Stages     []BookmarkStageStatus `json:"stages"`
+	History    []StageRun            `json:"history"`
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegenerationStatus is the outcome of a Q&A regeneration
+type RegenerationStatus string
+
+const (
+	RegenerationSucceeded RegenerationStatus = "succeeded"
+	RegenerationFailed    RegenerationStatus = "failed"
+)
+
+// QARejection is a generated Q&A pair a user rejected, through downvote or trash feedback or by deleting it.
+// ReferenceID is only known for rejections recorded since it was stored with the observation
+type QARejection struct {
+	ObservationID uuid.UUID  `json:"observation_id"`
+	Kind          string     `json:"kind"`
+	ReferenceID   *uuid.UUID `json:"reference_id,omitempty"`
+	Question      string     `json:"question"`
+	Answer        string     `json:"answer"`
+	RejectedAt    time.Time  `json:"rejected_at"`
+}
+
+// QARegenerationCandidate is a bookmark with rejected Q&A pairs that have not been replaced yet
+type QARegenerationCandidate struct {
+	BookmarkID      uuid.UUID `json:"bookmark_id"`
+	Rejections      int64     `json:"rejections"`
+	FirstRejectedAt time.Time `json:"first_rejected_at"`
+}
+
+// QARegenerationResult reports the replacement of a bookmark's rejected Q&A pairs. ObservationID is the
+// qa-regeneration observation recording it, whose parent is the first rejection
+type QARegenerationResult struct {
+	BookmarkID        uuid.UUID          `json:"bookmark_id"`
+	ObservationID     uuid.UUID          `json:"observation_id"`
+	PromptVersion     string             `json:"prompt_version"`
+	Rejections        []QARejection      `json:"rejections"`
+	RemovedReferences []uuid.UUID        `json:"removed_references"`
+	Questions         []BookmarkQuestion `json:"questions"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoFeedbackQueries is returned when no recorded user question has positive feedback to evaluate against
+var ErrNoFeedbackQueries = errors.New("no user questions with positive feedback")
+
+// RetrievalEvaluationInput configures a replay of the user questions recorded with Q&A feedback.
+// Strategy is the content reference strategy searched by similarity, qa-v2-passage by default,
+// Weights are the unified search weights, the defaults when nil, and K is the cut-off of every metric
+type RetrievalEvaluationInput struct {
+	Strategy   string
+	Weights    *SearchWeights
+	K          int
+	MaxQueries int
+}
+
+// FeedbackQuery is a recorded user question and the bookmarks whose latest feedback for it is an upvote
+type FeedbackQuery struct {
+	UserQuestion string
+	Relevant     []uuid.UUID
+}
+
+// RetrievalMetrics are the averages over all evaluated questions of one retriever
+type RetrievalMetrics struct {
+	RecallAtK float64 `json:"recall_at_k"`
+	MRR       float64 `json:"mrr"`
+	NDCG      float64 `json:"ndcg"`
+}
+
+// RetrievalQueryResult records how one user question fared. Ranks are 1-based positions of the first
+// relevant bookmark within the top K, or 0 when none was retrieved
+type RetrievalQueryResult struct {
+	UserQuestion string `json:"user_question"`
+	Relevant     int    `json:"relevant"`
+	SemanticRank int    `json:"semantic_rank"`
+	UnifiedRank  int    `json:"unified_rank"`
+}
+
+// RetrievalEvaluationReport is the outcome of a retrieval evaluation, stored as a retrieval-eval observation.
+// Semantic ranks bookmarks by embedding similarity of their Strategy references, Unified uses the
+// weighted search across all items, keeping only bookmarks
+type RetrievalEvaluationReport struct {
+	ObservationID  *uuid.UUID             `json:"observation_id,omitempty"`
+	EmbeddingModel string                 `json:"embedding_model"`
+	Strategy       string                 `json:"strategy"`
+	Weights        SearchWeights          `json:"weights"`
+	K              int                    `json:"k"`
+	Queries        int                    `json:"queries"`
+	Failed         int                    `json:"failed"`
+	Semantic       RetrievalMetrics       `json:"semantic"`
+	Unified        RetrievalMetrics       `json:"unified"`
+	Results        []RetrievalQueryResult `json:"results"`
+	EvaluatedAt    time.Time              `json:"evaluated_at"`
+}
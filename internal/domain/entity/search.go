@@ -13,9 +13,9 @@ type UnifiedSearchResult struct {
 
 // SearchWeights defines the weights for different search scoring factors
 type SearchWeights struct {
-	ExactMatchWeight float64
-	SimilarityWeight float64
-	RecencyWeight    float64
+	ExactMatchWeight float64 `json:"exact_match_weight"`
+	SimilarityWeight float64 `json:"similarity_weight"`
+	RecencyWeight    float64 `json:"recency_weight"`
 }
 
 // DefaultSearchWeights returns the default search weights
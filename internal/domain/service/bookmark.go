@@ -1,9 +1,14 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path"
 	"regexp"
 	"strings"
 	"time"
@@ -14,6 +19,11 @@ import (
 	"garden3/internal/port/output"
 )
 
+const (
+	defaultQuestionCount = 5
+	maxQuestionCount     = 20
+)
+
 // BookmarkService implements the BookmarkUseCase interface
 type BookmarkService struct {
 	repo            output.BookmarkRepository
@@ -21,6 +31,11 @@ type BookmarkService struct {
 	embeddingsService output.EmbeddingsService
 	aiService       output.AIService
 	contentProcessor output.ContentProcessor
+	configRepo      output.ConfigurationRepository
+	archiver        output.PageArchiver
+	archiveStore    output.ArchiveStore
+	// responseRetention is the number of fetched responses kept per bookmark, or 0 to keep all
+	responseRetention int
 }
 
 // NewBookmarkService creates a new bookmark service
@@ -30,6 +45,10 @@ func NewBookmarkService(
 	embeddingsService output.EmbeddingsService,
 	aiService output.AIService,
 	contentProcessor output.ContentProcessor,
+	configRepo output.ConfigurationRepository,
+	archiver output.PageArchiver,
+	archiveStore output.ArchiveStore,
+	responseRetention int,
 ) *BookmarkService {
 	return &BookmarkService{
 		repo:            repo,
@@ -37,6 +56,10 @@ func NewBookmarkService(
 		embeddingsService: embeddingsService,
 		aiService:       aiService,
 		contentProcessor: contentProcessor,
+		configRepo:      configRepo,
+		archiver:        archiver,
+		archiveStore:    archiveStore,
+		responseRetention: responseRetention,
 	}
 }
 
@@ -57,6 +80,10 @@ func (s *BookmarkService) ListBookmarks(ctx context.Context, filters entity.Book
 		filters.SearchQuery,
 		filters.StartCreationDate,
 		filters.EndCreationDate,
+		filters.FailedStage,
+		filters.Author,
+		filters.Site,
+		filters.PublishedYear,
 		limit,
 		offset,
 	)
@@ -70,6 +97,10 @@ func (s *BookmarkService) ListBookmarks(ctx context.Context, filters entity.Book
 		filters.SearchQuery,
 		filters.StartCreationDate,
 		filters.EndCreationDate,
+		filters.FailedStage,
+		filters.Author,
+		filters.Site,
+		filters.PublishedYear,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count bookmarks: %w", err)
@@ -105,7 +136,25 @@ func (s *BookmarkService) GetBookmarkDetails(ctx context.Context, bookmarkID uui
 		return nil, fmt.Errorf("failed to get bookmark questions: %w", err)
 	}
 
+	tags, err := s.repo.GetBookmarkTags(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark tags: %w", err)
+	}
+
+	metadata, err := s.repo.GetBookmarkMetadata(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark metadata: %w", err)
+	}
+
+	linkChecks, err := s.repo.ListLinkChecks(ctx, bookmarkID, linkCheckHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link checks: %w", err)
+	}
+
 	details.Questions = questions
+	details.Tags = tags
+	details.Metadata = metadata
+	details.LinkChecks = linkChecks
 	return details, nil
 }
 
@@ -114,16 +163,12 @@ func (s *BookmarkService) SearchSimilarBookmarks(ctx context.Context, query stri
 		strategy = "qa-v2-passage"
 	}
 
-	embeddings, err := s.embeddingsService.GetEmbedding(ctx, query)
+	embedding, err := s.embeddingsService.EmbedQuery(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("no embedding generated for query")
-	}
-
-	results, err := s.repo.SearchSimilarBookmarks(ctx, embeddings[0].Embedding, strategy, 10)
+	results, err := s.repo.SearchSimilarBookmarks(ctx, embedding, strategy, 10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search similar bookmarks: %w", err)
 	}
@@ -139,16 +184,13 @@ func (s *BookmarkService) UpdateBookmarkQuestion(ctx context.Context, input enti
 
 	newContent := fmt.Sprintf("%s?\n%s", input.NewQuestion, input.NewAnswer)
 
-	embeddings, err := s.embeddingsService.GetEmbedding(ctx, newContent)
+	model := s.embeddingsService.Model()
+	embedding, err := embedText(ctx, s.embeddingsService, newContent)
 	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
-	}
-
-	if len(embeddings) == 0 {
-		return fmt.Errorf("no embedding generated")
+		return err
 	}
 
-	err = s.repo.UpdateBookmarkQuestion(ctx, newContent, embeddings[0].Embedding, input.ReferenceID, input.BookmarkID)
+	err = s.repo.UpdateBookmarkQuestion(ctx, newContent, embedding, model, input.ReferenceID, input.BookmarkID)
 	if err != nil {
 		return fmt.Errorf("failed to update question: %w", err)
 	}
@@ -189,12 +231,13 @@ func (s *BookmarkService) DeleteBookmarkQuestion(ctx context.Context, input enti
 	}
 
 	observationData := map[string]interface{}{
-		"bookmarkId": input.BookmarkID.String(),
-		"title":      bookmark.Title,
-		"summary":    bookmark.Summary,
-		"question":   input.Question,
-		"answer":     input.Answer,
-		"timestamp":  time.Now().Format(time.RFC3339),
+		"bookmarkId":  input.BookmarkID.String(),
+		"referenceId": input.ReferenceID.String(),
+		"title":       bookmark.Title,
+		"summary":     bookmark.Summary,
+		"question":    input.Question,
+		"answer":      input.Answer,
+		"timestamp":   time.Now().Format(time.RFC3339),
 	}
 
 	dataJSON, err := json.Marshal(observationData)
@@ -210,7 +253,151 @@ func (s *BookmarkService) DeleteBookmarkQuestion(ctx context.Context, input enti
 	return nil
 }
 
-func (s *BookmarkService) FetchBookmarkContent(ctx context.Context, bookmarkID uuid.UUID) (*entity.FetchResult, error) {
+func (s *BookmarkService) GenerateBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID, input entity.GenerateQuestionsInput) (result *entity.GenerateQuestionsResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageQAPassage)
+	defer func() { run.finish(ctx, err) }()
+
+	count := input.Count
+	if count < 1 {
+		count = defaultQuestionCount
+	}
+	if count > maxQuestionCount {
+		count = maxQuestionCount
+	}
+
+	strategy := string(entity.StageQAPassage)
+	promptVersion := s.aiService.QuestionPromptVersion()
+
+	// The pipeline, the endpoint and the regeneration worker may run at once, and each would store its own
+	// pairs. A run waiting here finds the pairs of the previous one
+	unlock, err := s.repo.LockBookmarkQuestions(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock questions: %w", err)
+	}
+	defer unlock()
+
+	if !input.Force {
+		existing, err := s.repo.GetGeneratedQuestions(ctx, bookmarkID, strategy, promptVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get existing questions: %w", err)
+		}
+		if len(existing) > 0 {
+			run.note("Questions already generated with prompt " + promptVersion)
+			return &entity.GenerateQuestionsResult{
+				PromptVersion: promptVersion,
+				Generated:     false,
+				Questions:     existing,
+			}, nil
+		}
+	}
+
+	processedContent, err := s.repo.GetDocumentContent(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed content: %w", err)
+	}
+
+	if processedContent == nil {
+		return nil, fmt.Errorf("no processed content found")
+	}
+
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	content := *processedContent
+	if len(content) > 15000 {
+		content = strings.ToValidUTF8(content[:15000], "")
+	}
+
+	pairs, err := s.aiService.GenerateQuestions(ctx, content, bookmark.URL, count, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate questions: %w", err)
+	}
+
+	model := s.embeddingsService.Model()
+	questions, err := s.embedQuestionPairs(ctx, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	extra, err := json.Marshal(map[string]string{
+		"prompt_version": promptVersion,
+		"model":          s.aiService.Model(),
+		"generated_at":   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal question metadata: %w", err)
+	}
+
+	ids, err := s.repo.ReplaceGeneratedQuestions(ctx, bookmarkID, strategy, model, extra, questions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store questions: %w", err)
+	}
+
+	stored := make([]entity.BookmarkQuestion, len(ids))
+	for i, id := range ids {
+		stored[i] = entity.BookmarkQuestion{
+			ID:      id,
+			Content: questions[i].Text,
+		}
+	}
+
+	return &entity.GenerateQuestionsResult{
+		PromptVersion: promptVersion,
+		Generated:     true,
+		Questions:     stored,
+	}, nil
+}
+
+// embedText embeds a text whole, as a single vector. Only reader content is chunked, summaries, questions
+// and annotations are short enough to embed at once
+func embedText(ctx context.Context, embeddingsService output.EmbeddingsService, text string) ([]float32, error) {
+	vectors, err := embeddingsService.EmbedBatch(ctx, []string{text}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("no embedding generated")
+	}
+	return vectors[0], nil
+}
+
+// embedQuestionPairs embeds Q&A pairs in the "question?\nanswer" layout that UpdateBookmarkQuestion stores,
+// all in one batch
+func (s *BookmarkService) embedQuestionPairs(ctx context.Context, pairs []entity.QuestionAnswer) ([]entity.Embedding, error) {
+	texts := make([]string, len(pairs))
+	for i, pair := range pairs {
+		texts[i] = questionContent(pair)
+	}
+
+	vectors, err := s.embeddingsService.EmbedBatch(ctx, texts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("no embedding generated")
+	}
+
+	questions := make([]entity.Embedding, len(texts))
+	for i, text := range texts {
+		questions[i] = entity.Embedding{
+			Text:      text,
+			Embedding: vectors[i],
+		}
+	}
+	return questions, nil
+}
+
+// questionContent is the stored content of a Q&A reference
+func questionContent(pair entity.QuestionAnswer) string {
+	return fmt.Sprintf("%s?\n%s", strings.TrimSuffix(pair.Question, "?"), pair.Answer)
+}
+
+func (s *BookmarkService) FetchBookmarkContent(ctx context.Context, bookmarkID uuid.UUID) (result *entity.FetchResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageFetch)
+	defer func() { run.finish(ctx, err) }()
+
 	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookmark: %w", err)
@@ -218,33 +405,113 @@ func (s *BookmarkService) FetchBookmarkContent(ctx context.Context, bookmarkID u
 
 	url := sanitizeURL(bookmark.URL)
 
-	fetchCtx, cancel := context.WithTimeout(ctx, 25*time.Second)
+	options, err := s.fetchValidators(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := findFetchProfile(ctx, s.configRepo, url)
+	if err != nil {
+		return nil, err
+	}
+	options, timeoutMs := applyFetchProfile(profile, options, 25000)
+
+	var profileName *string
+	if profile != nil {
+		profileName = &profile.Domain
+		run.note(fmt.Sprintf("Using fetch profile %s", profile.Domain))
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
 	defer cancel()
 
-	response, err := s.httpFetcher.Fetch(fetchCtx, url, 25000)
+	response, err := s.httpFetcher.Fetch(fetchCtx, url, timeoutMs, options)
 	if err != nil {
 		errorContent := []byte(err.Error())
-		storeErr := s.repo.InsertHttpResponse(ctx, bookmarkID, 500, "{}", errorContent, time.Now())
+		storeErr := s.repo.InsertHttpResponse(ctx, bookmarkID, 500, "{}", errorContent, nil, "", time.Now(), profileName)
 		if storeErr != nil {
 			return nil, fmt.Errorf("fetch failed and failed to store error: %v, %w", err, storeErr)
 		}
+		if pruneErr := s.pruneHttpResponses(ctx, bookmarkID); pruneErr != nil {
+			return nil, fmt.Errorf("fetch failed and failed to prune http responses: %v, %w", err, pruneErr)
+		}
 		return nil, fmt.Errorf("failed to fetch content: %w", err)
 	}
 
-	err = s.repo.InsertHttpResponse(ctx, bookmarkID, response.StatusCode, response.Headers, response.Content, time.Now())
+	// The stored response is still current, so keep it rather than storing an empty body
+	if response.StatusCode == http.StatusNotModified {
+		run.note("Not modified since the last fetch")
+		return &entity.FetchResult{
+			StatusCode:   response.StatusCode,
+			Headers:      response.Headers,
+			Message:      "Content not modified since the last fetch",
+			FetchProfile: profileName,
+		}, nil
+	}
+
+	err = s.repo.InsertHttpResponse(ctx, bookmarkID, response.StatusCode, response.Headers, response.Content, response.Original, response.OriginalContentType, time.Now(), profileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store http response: %w", err)
 	}
+	if err := s.pruneHttpResponses(ctx, bookmarkID); err != nil {
+		return nil, fmt.Errorf("failed to prune http responses: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		run.fail(fmt.Sprintf("HTTP status %d", response.StatusCode))
+	} else if response.Charset != "" {
+		run.note(fmt.Sprintf("Transcoded from %s to UTF-8", response.Charset))
+	}
 
 	return &entity.FetchResult{
-		StatusCode: response.StatusCode,
-		Headers:    response.Headers,
-		Content:    response.Content,
-		Message:    "Fetch finished",
+		StatusCode:   response.StatusCode,
+		Headers:      response.Headers,
+		Content:      response.Content,
+		Message:      "Fetch finished",
+		FetchProfile: profileName,
+	}, nil
+}
+
+// fetchValidators returns the ETag and Last-Modified of the last successful fetch, so a re-fetch
+// of an unchanged page can be answered with 304 Not Modified
+func (s *BookmarkService) fetchValidators(ctx context.Context, bookmarkID uuid.UUID) (output.FetchOptions, error) {
+	status, err := s.repo.GetLatestFetchStatus(ctx, bookmarkID)
+	if err != nil {
+		return output.FetchOptions{}, fmt.Errorf("failed to get fetch status: %w", err)
+	}
+	if status == nil || status.StatusCode == nil || *status.StatusCode >= 300 {
+		return output.FetchOptions{}, nil
+	}
+
+	httpResp, err := s.repo.GetLatestHttpResponse(ctx, bookmarkID)
+	if err != nil {
+		return output.FetchOptions{}, fmt.Errorf("failed to get http response: %w", err)
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(httpResp.Headers), &headers); err != nil {
+		return output.FetchOptions{}, nil
+	}
+
+	return output.FetchOptions{
+		ETag:         getHeader(headers, "etag"),
+		LastModified: getHeader(headers, "last-modified"),
 	}, nil
 }
 
-func (s *BookmarkService) ProcessWithLynx(ctx context.Context, bookmarkID uuid.UUID) (*entity.ProcessingResult, error) {
+// pruneHttpResponses applies the response retention to a bookmark once a new response is stored
+func (s *BookmarkService) pruneHttpResponses(ctx context.Context, bookmarkID uuid.UUID) error {
+	if s.responseRetention < 1 {
+		return nil
+	}
+	_, err := s.repo.PruneHttpResponses(ctx, bookmarkID, s.responseRetention)
+	return err
+}
+
+func (s *BookmarkService) ProcessWithLynx(ctx context.Context, bookmarkID uuid.UUID) (result *entity.ProcessingResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageLynx)
+	defer func() { run.finish(ctx, err) }()
+
 	httpResp, err := s.repo.GetLatestHttpResponse(ctx, bookmarkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get http response: %w", err)
@@ -257,6 +524,7 @@ func (s *BookmarkService) ProcessWithLynx(ctx context.Context, bookmarkID uuid.U
 
 	contentType := getContentType(headers)
 	if !strings.Contains(contentType, "text") && !strings.Contains(contentType, "html") {
+		run.skip("Content is not HTML and cannot be processed")
 		return &entity.ProcessingResult{
 			Message: "Content is not HTML and cannot be processed",
 		}, nil
@@ -277,52 +545,77 @@ func (s *BookmarkService) ProcessWithLynx(ctx context.Context, bookmarkID uuid.U
 	}, nil
 }
 
-func (s *BookmarkService) ProcessWithReader(ctx context.Context, bookmarkID uuid.UUID) (*entity.ProcessingResult, error) {
+func (s *BookmarkService) ProcessWithReader(ctx context.Context, bookmarkID uuid.UUID) (result *entity.ProcessingResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageReader)
+	defer func() { run.finish(ctx, err) }()
+
 	httpResp, err := s.repo.GetLatestHttpResponse(ctx, bookmarkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get http response: %w", err)
 	}
 
+	// Error pages are not the document, and failed fetches are stored with their error as the body
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		message := fmt.Sprintf("HTTP status %d has no content to process", httpResp.StatusCode)
+		run.skip(message)
+		return &entity.ProcessingResult{
+			Message: message,
+		}, nil
+	}
+
 	var headers map[string]string
 	if err := json.Unmarshal([]byte(httpResp.Headers), &headers); err != nil {
 		return nil, fmt.Errorf("failed to parse headers: %w", err)
 	}
 
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
 	contentType := getContentType(headers)
-	if !strings.Contains(contentType, "text") && !strings.Contains(contentType, "html") {
+	strategy := documentStrategy(contentType, bookmark.URL, httpResp.Content)
+	if strategy == "" {
+		message := fmt.Sprintf("Content type %q cannot be processed", contentType)
+		run.skip(message)
 		return &entity.ProcessingResult{
-			Message: "Content is not HTML and cannot be processed",
+			Message: message,
 		}, nil
 	}
 
-	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	extracted, err := extractDocument(ctx, s.contentProcessor, strategy, contentType, bookmark.URL, httpResp.Content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get bookmark: %w", err)
-	}
-
-	var processedContent string
-	if strings.HasSuffix(sanitizeURL(bookmark.URL), "README.md") {
-		processedContent = string(httpResp.Content)
-	} else {
-		processedContent, err = s.contentProcessor.ProcessWithReader(ctx, httpResp.Content, bookmark.URL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to process with reader: %w", err)
-		}
+		return nil, fmt.Errorf("failed to process with %s: %w", strategy, err)
 	}
+	processedContent := extracted.Content
 
-	err = s.repo.InsertProcessedContent(ctx, bookmarkID, "reader", processedContent)
+	err = s.repo.InsertExtractedContent(ctx, bookmarkID, strategy, extracted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store processed content: %w", err)
 	}
 
-	return &entity.ProcessingResult{
+	result = &entity.ProcessingResult{
 		Message: "Bookmark processed successfully",
 		Content: &processedContent,
-	}, nil
+	}
+	if strategy == strategyReader {
+		result.Extractor = &extracted.Extractor
+		result.Metadata = extracted.Metadata
+		if extracted.Extractor != strategyReader {
+			run.note("Extracted with " + extracted.Extractor + " extractor")
+		}
+	} else {
+		run.note("Extracted " + strategy + " document")
+	}
+
+	return result, nil
 }
 
-func (s *BookmarkService) CreateEmbeddingChunks(ctx context.Context, bookmarkID uuid.UUID) (*entity.EmbeddingResult, error) {
-	processedContent, err := s.repo.GetProcessedContentByStrategy(ctx, bookmarkID, "reader")
+func (s *BookmarkService) CreateEmbeddingChunks(ctx context.Context, bookmarkID uuid.UUID) (result *entity.EmbeddingResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageChunkedReader)
+	defer func() { run.finish(ctx, err) }()
+
+	processedContent, err := s.repo.GetDocumentContent(ctx, bookmarkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get processed content: %w", err)
 	}
@@ -331,44 +624,28 @@ func (s *BookmarkService) CreateEmbeddingChunks(ctx context.Context, bookmarkID
 		return nil, fmt.Errorf("no processed content found")
 	}
 
-	content := *processedContent
-	if len(content) > 15000 {
-		content = content[:15000]
-	}
-
-	embeddings, err := s.embeddingsService.GetEmbedding(ctx, content)
+	model := s.embeddingsService.Model()
+	embeddings, err := s.embeddingsService.GetEmbedding(ctx, *processedContent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
-	var ids []uuid.UUID
-	maxChunks := 20
-	if len(embeddings) > maxChunks {
-		embeddings = embeddings[:maxChunks]
-	}
-
-	for _, emb := range embeddings {
-		id, err := s.repo.CreateEmbeddingChunk(ctx, bookmarkID, emb.Text, "chunked-reader", emb.Embedding)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create embedding chunk: %w", err)
-		}
-		ids = append(ids, id)
-	}
-
-	var warning *string
-	if len(embeddings) >= maxChunks {
-		msg := "The content was too large, only the first ~10000 characters were processed"
-		warning = &msg
+	ids, err := s.repo.ReplaceContentChunks(ctx, bookmarkID, "chunked-reader", model, embeddings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store embedding chunks: %w", err)
 	}
+	run.note(fmt.Sprintf("Embedded %d chunks", len(ids)))
 
 	return &entity.EmbeddingResult{
-		IDs:     ids,
-		Warning: warning,
+		IDs: ids,
 	}, nil
 }
 
-func (s *BookmarkService) CreateSummaryEmbedding(ctx context.Context, bookmarkID uuid.UUID) (*entity.SummaryEmbeddingResult, error) {
-	processedContent, err := s.repo.GetProcessedContentByStrategy(ctx, bookmarkID, "reader")
+func (s *BookmarkService) CreateSummaryEmbedding(ctx context.Context, bookmarkID uuid.UUID) (result *entity.SummaryEmbeddingResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageSummaryReader)
+	defer func() { run.finish(ctx, err) }()
+
+	processedContent, err := s.repo.GetDocumentContent(ctx, bookmarkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get processed content: %w", err)
 	}
@@ -382,51 +659,41 @@ func (s *BookmarkService) CreateSummaryEmbedding(ctx context.Context, bookmarkID
 		return nil, fmt.Errorf("failed to get bookmark: %w", err)
 	}
 
-	var summaryText string
-	var embeddings []entity.Embedding
-	wordCount := 300
-
-	for wordCount > 200 {
-		summaryText, err = s.aiService.GenerateSummary(ctx, *processedContent, bookmark.URL, wordCount)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate summary: %w", err)
-		}
-
-		embeddings, err = s.embeddingsService.GetEmbedding(ctx, summaryText)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate embedding: %w", err)
-		}
-
-		if len(embeddings) == 1 {
-			break
-		}
-
-		wordCount -= 10
+	summaryText, err := s.aiService.GenerateSummary(ctx, *processedContent, bookmark.URL, 300)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
 	}
 
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("failed to generate valid embedding")
+	model := s.embeddingsService.Model()
+	embedding, err := embedText(ctx, s.embeddingsService, summaryText)
+	if err != nil {
+		return nil, err
 	}
 
-	id, err := s.repo.CreateEmbeddingChunk(ctx, bookmarkID, embeddings[0].Text, "summary-reader", embeddings[0].Embedding)
+	extra, err := json.Marshal(map[string]string{
+		"prompt_version": s.aiService.SummaryPromptVersion(),
+		"model":          s.aiService.Model(),
+		"generated_at":   time.Now().Format(time.RFC3339),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create summary embedding: %w", err)
+		return nil, fmt.Errorf("failed to marshal summary metadata: %w", err)
 	}
 
-	var warning *string
-	if len(embeddings) > 1 {
-		msg := "The content was too large, only the first ~10000 characters were processed"
-		warning = &msg
+	id, err := s.repo.CreateEmbeddingChunkWithExtra(ctx, bookmarkID, summaryText, string(entity.StageSummaryReader), embedding, model, extra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create summary embedding: %w", err)
 	}
 
 	return &entity.SummaryEmbeddingResult{
 		IDs:     []uuid.UUID{id},
 		Summary: summaryText,
-		Warning: warning,
 	}, nil
 }
 
-func (s *BookmarkService) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (*entity.TitleExtractionResult, error) {
+func (s *BookmarkService) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (result *entity.TitleExtractionResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageTitle)
+	defer func() { run.finish(ctx, err) }()
+
 	titleData, err := s.repo.GetBookmarkTitle(ctx, bookmarkID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookmark title data: %w", err)
@@ -470,6 +737,7 @@ func (s *BookmarkService) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.
 		}
 	}
 
+	run.skip("No title found")
 	return &entity.TitleExtractionResult{
 		Data: details,
 	}, nil
@@ -491,6 +759,222 @@ func (s *BookmarkService) GetMissingReaderContent(ctx context.Context) ([]entity
 	return bookmarks, nil
 }
 
+func (s *BookmarkService) CreateBookmark(ctx context.Context, input entity.CreateBookmarkInput) (*entity.CreateBookmarkResult, error) {
+	canonical, err := canonicalizeURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.findBookmarkByURL(ctx, canonical, input.URL)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return s.existingBookmarkResult(ctx, existing.BookmarkID)
+	}
+
+	creationDate := time.Now()
+	if input.CreationDate != nil {
+		creationDate = *input.CreationDate
+	}
+
+	newBookmark := entity.NewBookmark{
+		URL:           canonical,
+		CreationDate:  creationDate,
+		CategoryID:    input.CategoryID,
+		SourceURI:     input.SourceURI,
+		RawSource:     input.RawSource,
+		Tags:          normalizeTags(input.Tags),
+		ReaderContent: input.ArchivedContent,
+	}
+	if input.Title != nil && strings.TrimSpace(*input.Title) != "" {
+		title := strings.TrimSpace(*input.Title)
+		newBookmark.Title = &title
+	}
+
+	created, err := s.repo.CreateBookmark(ctx, newBookmark)
+	if errors.Is(err, entity.ErrDuplicateBookmark) {
+		// A concurrent create of the same URL won between the lookup and the insert
+		existing, err := s.repo.GetBookmarkByURL(ctx, canonical)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up bookmark by url: %w", err)
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("failed to create bookmark: %w", entity.ErrDuplicateBookmark)
+		}
+		return s.existingBookmarkResult(ctx, existing.BookmarkID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bookmark: %w", err)
+	}
+
+	if input.ArchivedContent != nil {
+		// Record the imported content as the reader run so the status endpoint and pipeline treat it as done
+		run := s.beginStageRun(created.BookmarkID, entity.StageReader)
+		run.note("Archived content imported")
+		run.finish(ctx, nil)
+	}
+
+	bookmark, err := s.repo.GetBookmarkWithTitle(ctx, created.BookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get created bookmark: %w", err)
+	}
+
+	return &entity.CreateBookmarkResult{
+		Bookmark: *bookmark,
+		Created:  true,
+	}, nil
+}
+
+func (s *BookmarkService) UpdateBookmark(ctx context.Context, bookmarkID uuid.UUID, input entity.UpdateBookmarkInput) (*entity.BookmarkWithTitle, error) {
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	if input.URL != nil {
+		canonical, err := canonicalizeURL(*input.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		if canonical != bookmark.URL {
+			existing, err := s.findBookmarkByURL(ctx, canonical, *input.URL)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil && existing.BookmarkID != bookmarkID {
+				return nil, fmt.Errorf("%w: %s", entity.ErrDuplicateBookmark, existing.BookmarkID)
+			}
+
+			if err := s.repo.UpdateBookmarkURL(ctx, bookmarkID, canonical); err != nil {
+				if errors.Is(err, entity.ErrDuplicateBookmark) {
+					return nil, err
+				}
+				return nil, fmt.Errorf("failed to update bookmark url: %w", err)
+			}
+		}
+	}
+
+	if input.Title != nil {
+		if err := s.repo.ReplaceBookmarkTitle(ctx, bookmarkID, strings.TrimSpace(*input.Title), "user"); err != nil {
+			return nil, fmt.Errorf("failed to update bookmark title: %w", err)
+		}
+	}
+
+	if input.CategoryID != nil {
+		if err := s.repo.SetBookmarkCategory(ctx, bookmarkID, input.CategoryID); err != nil {
+			return nil, fmt.Errorf("failed to update bookmark category: %w", err)
+		}
+	}
+
+	updated, err := s.repo.GetBookmarkWithTitle(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated bookmark: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *BookmarkService) DeleteBookmark(ctx context.Context, bookmarkID uuid.UUID) error {
+	archives, err := s.repo.ListBookmarkArchives(ctx, bookmarkID)
+	if err != nil {
+		return fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	if err := s.repo.DeleteBookmark(ctx, bookmarkID); err != nil {
+		return fmt.Errorf("failed to delete bookmark: %w", err)
+	}
+
+	for i := range archives {
+		s.releaseArchiveBlobs(ctx, &archives[i])
+	}
+	return nil
+}
+
+func (s *BookmarkService) MigrateBookmarkURLs(ctx context.Context) (*entity.BookmarkURLMigration, error) {
+	result := &entity.BookmarkURLMigration{}
+
+	bookmarks, err := s.repo.ListBookmarkURLs(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to list bookmark urls: %w", err)
+	}
+
+	// Bookmarks come oldest first, so the first of each canonical URL is the one kept
+	groups := make(map[string][]entity.Bookmark)
+	var order []string
+	for _, bookmark := range bookmarks {
+		canonical, err := canonicalizeURL(bookmark.URL)
+		if err != nil {
+			continue
+		}
+		if _, ok := groups[canonical]; !ok {
+			order = append(order, canonical)
+		}
+		groups[canonical] = append(groups[canonical], bookmark)
+	}
+
+	for _, canonical := range order {
+		group := groups[canonical]
+		keep := group[0]
+		if len(group) == 1 && keep.URL == canonical {
+			continue
+		}
+
+		duplicateIDs := make([]uuid.UUID, 0, len(group)-1)
+		for _, duplicate := range group[1:] {
+			duplicateIDs = append(duplicateIDs, duplicate.BookmarkID)
+		}
+		if err := s.repo.MergeBookmarks(ctx, keep.BookmarkID, canonical, duplicateIDs); err != nil {
+			return result, fmt.Errorf("failed to merge bookmarks of %s: %w", canonical, err)
+		}
+		if keep.URL != canonical {
+			result.Canonicalized++
+		}
+		result.Merged += len(duplicateIDs)
+	}
+
+	if err := s.repo.CreateBookmarkURLIndex(ctx); err != nil {
+		return result, fmt.Errorf("failed to create bookmark url index: %w", err)
+	}
+	return result, nil
+}
+
+// existingBookmarkResult returns an existing bookmark as the result of a create that found its URL taken
+func (s *BookmarkService) existingBookmarkResult(ctx context.Context, bookmarkID uuid.UUID) (*entity.CreateBookmarkResult, error) {
+	bookmark, err := s.repo.GetBookmarkWithTitle(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing bookmark: %w", err)
+	}
+	return &entity.CreateBookmarkResult{
+		Bookmark: *bookmark,
+		Created:  false,
+	}, nil
+}
+
+// findBookmarkByURL looks a bookmark up by its canonical URL, falling back to
+// the trimmed URL as given since process_chat_room_bookmark stores URLs that way
+// until the next startup canonicalizes them
+func (s *BookmarkService) findBookmarkByURL(ctx context.Context, canonical, raw string) (*entity.Bookmark, error) {
+	existing, err := s.repo.GetBookmarkByURL(ctx, canonical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bookmark by url: %w", err)
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	trimmed := sanitizeURL(raw)
+	if trimmed == canonical {
+		return nil, nil
+	}
+
+	existing, err = s.repo.GetBookmarkByURL(ctx, trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up bookmark by url: %w", err)
+	}
+	return existing, nil
+}
+
 // Helper functions
 
 func sanitizeURL(url string) string {
@@ -498,10 +982,188 @@ func sanitizeURL(url string) string {
 	return strings.TrimSpace(url)
 }
 
+// trackingParams lists query parameters that only carry analytics state
+var trackingParams = map[string]bool{
+	"fbclid":               true,
+	"gclid":                true,
+	"gclsrc":               true,
+	"dclid":                true,
+	"msclkid":              true,
+	"yclid":                true,
+	"igshid":               true,
+	"mc_cid":               true,
+	"mc_eid":               true,
+	"_hsenc":               true,
+	"_hsmi":                true,
+	"mkt_tok":              true,
+	"ref_src":              true,
+	"ref_url":              true,
+	"spm":                  true,
+	"__twitter_impression": true,
+}
+
+// canonicalizeURL normalizes a URL so the same page always maps to the same
+// bookmark: lowercase scheme and host, no default port, no tracking params,
+// sorted query and no trailing slash except for the root path
+func canonicalizeURL(rawURL string) (string, error) {
+	trimmed := sanitizeURL(rawURL)
+	if trimmed == "" {
+		return "", fmt.Errorf("%w: url is required", entity.ErrInvalidBookmarkURL)
+	}
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "https://" + trimmed
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", entity.ErrInvalidBookmarkURL, err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("%w: unsupported scheme %q", entity.ErrInvalidBookmarkURL, u.Scheme)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return "", fmt.Errorf("%w: missing host", entity.ErrInvalidBookmarkURL)
+	}
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host = host + ":" + port
+	}
+
+	path := u.EscapedPath()
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") || trackingParams[strings.ToLower(key)] {
+			query.Del(key)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteString("@")
+	}
+	b.WriteString(host)
+	b.WriteString(path)
+	if encoded := query.Encode(); encoded != "" {
+		b.WriteString("?")
+		b.WriteString(encoded)
+	}
+	// Fragments are dropped unless they look like client-side routes
+	if strings.HasPrefix(u.Fragment, "!") || strings.HasPrefix(u.Fragment, "/") {
+		b.WriteString("#")
+		b.WriteString(u.EscapedFragment())
+	}
+
+	return b.String(), nil
+}
+
+// normalizeTags trims tag names and drops empty and repeated ones, keeping the first spelling
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Strategies of the processed_contents rows holding the extracted text of a bookmark
+const (
+	strategyReader   = "reader"
+	strategyPDF      = "pdf"
+	strategyEPUB     = "epub"
+	strategyText     = "text"
+	strategyMarkdown = "markdown"
+)
+
+// documentStrategy picks how fetched content is extracted. Servers often send
+// papers and books as application/octet-stream, so the file signature and URL
+// extension are checked when the Content-Type is not specific
+func documentStrategy(contentType, rawURL string, content []byte) string {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	extension, name := "", ""
+	if u, err := url.Parse(sanitizeURL(rawURL)); err == nil {
+		extension = strings.ToLower(path.Ext(u.Path))
+		name = path.Base(u.Path)
+	}
+	generic := mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream"
+
+	switch {
+	case mediaType == "application/pdf", bytes.HasPrefix(content, []byte("%PDF-")):
+		return strategyPDF
+	case mediaType == "application/epub+zip", generic && extension == ".epub":
+		return strategyEPUB
+	case mediaType == "text/markdown", mediaType == "text/x-markdown",
+		(generic || mediaType == "text/plain") && (extension == ".md" || extension == ".markdown"),
+		// A README.md is kept as markdown whatever it is served as, unless it is a rendered page,
+		// which the site extractors read instead
+		name == "README.md" && !strings.Contains(mediaType, "html"):
+		return strategyMarkdown
+	case mediaType == "text/plain", generic && extension == ".txt":
+		return strategyText
+	case strings.Contains(mediaType, "html"), strings.Contains(mediaType, "text"):
+		return strategyReader
+	}
+	return ""
+}
+
+// extractDocument converts fetched content into readable markdown with the processor for its document strategy
+func extractDocument(ctx context.Context, processor output.ContentProcessor, strategy, contentType, url string, content []byte) (*entity.ExtractedDocument, error) {
+	if strategy == strategyReader {
+		return processor.Extract(ctx, content, url)
+	}
+
+	var text string
+	var err error
+	switch strategy {
+	case strategyPDF:
+		text, err = processor.ProcessPDF(ctx, content, url)
+	case strategyEPUB:
+		text, err = processor.ProcessEPUB(ctx, content, url)
+	case strategyMarkdown:
+		text, err = processor.ProcessMarkdown(ctx, content, contentType, url)
+	case strategyText:
+		text, err = processor.ProcessPlainText(ctx, content, contentType, url)
+	default:
+		return nil, fmt.Errorf("content type %q cannot be processed", contentType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ExtractedDocument{
+		Extractor: strategy,
+		Content:   text,
+	}, nil
+}
+
 func getContentType(headers map[string]string) string {
+	return strings.ToLower(getHeader(headers, "content-type"))
+}
+
+// getHeader looks up a stored response header regardless of the case of its name
+func getHeader(headers map[string]string, name string) string {
 	for k, v := range headers {
-		if strings.ToLower(k) == "content-type" {
-			return strings.ToLower(v)
+		if strings.EqualFold(k, name) {
+			return v
 		}
 	}
 	return ""
@@ -0,0 +1,554 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+	"garden3/internal/port/output"
+)
+
+const (
+	// annotationContext is the number of characters kept on each side of a quote to tell repeated quotes apart
+	annotationContext = 32
+
+	// annotationSearchLimit is the number of annotations returned by a similarity search
+	annotationSearchLimit = 20
+)
+
+// entityLinkRegex matches the Markdown links entity references are rendered as
+var entityLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\(/entities/[^)]+\)`)
+
+// BookmarkAnnotationService implements the BookmarkAnnotationUseCase interface
+type BookmarkAnnotationService struct {
+	repo              output.BookmarkRepository
+	entityLinks       output.EntityLinkRepository
+	embeddingsService output.EmbeddingsService
+}
+
+// NewBookmarkAnnotationService creates a new annotation service. Entity links in notes are resolved with entityLinks
+func NewBookmarkAnnotationService(
+	repo output.BookmarkRepository,
+	entityLinks output.EntityLinkRepository,
+	embeddingsService output.EmbeddingsService,
+) *BookmarkAnnotationService {
+	return &BookmarkAnnotationService{
+		repo:              repo,
+		entityLinks:       entityLinks,
+		embeddingsService: embeddingsService,
+	}
+}
+
+func (s *BookmarkAnnotationService) CreateAnnotation(ctx context.Context, bookmarkID uuid.UUID, input entity.CreateAnnotationInput) (*entity.BookmarkAnnotation, error) {
+	text, err := s.readerText(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	if text == nil {
+		return nil, entity.ErrNoReaderContent
+	}
+
+	selector, err := resolveSelector(text, input.Exact, input.Prefix, input.Suffix, input.Start, input.End)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := normalizeTags(input.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	annotation := &entity.BookmarkAnnotation{
+		BookmarkID: bookmarkID,
+		Selector:   selector,
+		Note:       cleanNote(input.Note),
+		Tags:       tags,
+	}
+	if err := s.repo.InsertBookmarkAnnotation(ctx, annotation); err != nil {
+		return nil, fmt.Errorf("failed to create annotation: %w", err)
+	}
+
+	// References are recorded against the annotation, so the note is linked once it has an ID
+	if annotation.Note != nil {
+		linked, err := storeEntityLinks(ctx, s.entityLinks, *annotation.Note, "annotation", annotation.AnnotationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process note: %w", err)
+		}
+		if linked != *annotation.Note {
+			annotation.Note = &linked
+			if err := s.repo.UpdateBookmarkAnnotation(ctx, annotation, nil); err != nil {
+				return nil, fmt.Errorf("failed to update annotation note: %w", err)
+			}
+		}
+	}
+
+	if err := s.renderNote(ctx, annotation); err != nil {
+		return nil, err
+	}
+	s.embedAnnotation(ctx, annotation)
+
+	annotation.Anchor = &entity.AnnotationAnchor{Status: entity.AnchorExact, Start: selector.Start, End: selector.End}
+	return annotation, nil
+}
+
+func (s *BookmarkAnnotationService) GetAnnotation(ctx context.Context, annotationID uuid.UUID) (*entity.BookmarkAnnotation, error) {
+	annotation, err := s.repo.GetBookmarkAnnotation(ctx, annotationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotation: %w", err)
+	}
+	if annotation == nil {
+		return nil, entity.ErrAnnotationNotFound
+	}
+
+	text, err := s.readerText(ctx, annotation.BookmarkID)
+	if err != nil {
+		return nil, err
+	}
+	if text != nil {
+		anchor := anchorSelector(text, annotation.Selector)
+		annotation.Anchor = &anchor
+	}
+
+	if err := s.renderNote(ctx, annotation); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+func (s *BookmarkAnnotationService) UpdateAnnotation(ctx context.Context, annotationID uuid.UUID, input entity.UpdateAnnotationInput) (*entity.BookmarkAnnotation, error) {
+	annotation, err := s.repo.GetBookmarkAnnotation(ctx, annotationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotation: %w", err)
+	}
+	if annotation == nil {
+		return nil, entity.ErrAnnotationNotFound
+	}
+
+	text, err := s.readerText(ctx, annotation.BookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := input.Exact != nil || input.Start != nil || input.End != nil
+	if moved {
+		if text == nil {
+			return nil, entity.ErrNoReaderContent
+		}
+		exact := ""
+		if input.Exact != nil {
+			exact = *input.Exact
+		}
+		annotation.Selector, err = resolveSelector(text, exact, input.Prefix, input.Suffix, input.Start, input.End)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Note != nil {
+		if err := s.repo.DeleteAnnotationEntityReferences(ctx, annotationID); err != nil {
+			return nil, fmt.Errorf("failed to delete entity references: %w", err)
+		}
+		annotation.Note = cleanNote(input.Note)
+		if annotation.Note != nil {
+			linked, err := storeEntityLinks(ctx, s.entityLinks, *annotation.Note, "annotation", annotationID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process note: %w", err)
+			}
+			annotation.Note = &linked
+		}
+	}
+
+	var tags []string
+	if input.Tags != nil {
+		tags = normalizeTags(*input.Tags)
+		if tags == nil {
+			tags = []string{}
+		}
+	}
+
+	if err := s.repo.UpdateBookmarkAnnotation(ctx, annotation, tags); err != nil {
+		return nil, fmt.Errorf("failed to update annotation: %w", err)
+	}
+
+	if err := s.renderNote(ctx, annotation); err != nil {
+		return nil, err
+	}
+	if moved || input.Note != nil {
+		s.embedAnnotation(ctx, annotation)
+	}
+
+	if text != nil {
+		anchor := anchorSelector(text, annotation.Selector)
+		annotation.Anchor = &anchor
+	}
+	return annotation, nil
+}
+
+func (s *BookmarkAnnotationService) DeleteAnnotation(ctx context.Context, annotationID uuid.UUID) error {
+	if err := s.repo.DeleteBookmarkAnnotation(ctx, annotationID); err != nil {
+		return fmt.Errorf("failed to delete annotation: %w", err)
+	}
+	return nil
+}
+
+func (s *BookmarkAnnotationService) ListBookmarkAnnotations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkAnnotation, error) {
+	annotations, err := s.repo.ListBookmarkAnnotations(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", err)
+	}
+	if len(annotations) == 0 {
+		return annotations, nil
+	}
+
+	text, err := s.readerText(ctx, bookmarkID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range annotations {
+		if text != nil {
+			anchor := anchorSelector(text, annotations[i].Selector)
+			annotations[i].Anchor = &anchor
+		}
+		if err := s.renderNote(ctx, &annotations[i]); err != nil {
+			return nil, err
+		}
+	}
+	return annotations, nil
+}
+
+func (s *BookmarkAnnotationService) ListAnnotations(ctx context.Context, filters entity.AnnotationFilters) (*input.PaginatedResponse[entity.BookmarkAnnotation], error) {
+	page := filters.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := filters.Limit
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	annotations, err := s.repo.ListAnnotations(ctx, filters.Search, filters.Tag, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", err)
+	}
+	for i := range annotations {
+		if err := s.renderNote(ctx, &annotations[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	total, err := s.repo.CountAnnotations(ctx, filters.Search, filters.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count annotations: %w", err)
+	}
+
+	totalPages := int32((total + int64(limit) - 1) / int64(limit))
+
+	return &input.PaginatedResponse[entity.BookmarkAnnotation]{
+		Data:       annotations,
+		Total:      total,
+		Page:       page,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *BookmarkAnnotationService) SearchAnnotations(ctx context.Context, query string) ([]entity.BookmarkAnnotation, error) {
+	embedding, err := s.embeddingsService.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	annotations, err := s.repo.SearchSimilarAnnotations(ctx, embedding, annotationSearchLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search similar annotations: %w", err)
+	}
+	for i := range annotations {
+		if err := s.renderNote(ctx, &annotations[i]); err != nil {
+			return nil, err
+		}
+	}
+	return annotations, nil
+}
+
+// readerText loads the reader Markdown of a bookmark, returning nil if it has none
+func (s *BookmarkAnnotationService) readerText(ctx context.Context, bookmarkID uuid.UUID) ([]rune, error) {
+	content, err := s.repo.GetDocumentContent(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reader content: %w", err)
+	}
+	if content == nil || *content == "" {
+		return nil, nil
+	}
+	return []rune(*content), nil
+}
+
+// renderNote sets the display form of an annotation's note, with entity references as Markdown links
+func (s *BookmarkAnnotationService) renderNote(ctx context.Context, annotation *entity.BookmarkAnnotation) error {
+	if annotation.Note == nil {
+		return nil
+	}
+	rendered, err := renderEntityLinks(ctx, s.entityLinks, *annotation.Note)
+	if err != nil {
+		return fmt.Errorf("failed to process note: %w", err)
+	}
+	annotation.ProcessedNote = &rendered
+	return nil
+}
+
+// embedAnnotation stores the embedding of the quote and note of an annotation. The annotation is kept
+// when embedding fails, it is only left out of similarity searches until its next change
+func (s *BookmarkAnnotationService) embedAnnotation(ctx context.Context, annotation *entity.BookmarkAnnotation) {
+	text := annotationEmbeddingText(annotation.Selector.Exact, annotation.ProcessedNote)
+
+	model := s.embeddingsService.Model()
+	embedding, err := embedText(ctx, s.embeddingsService, text)
+	if err == nil {
+		err = s.repo.SetBookmarkAnnotationEmbedding(ctx, annotation.AnnotationID, embedding, model)
+	}
+	if err != nil {
+		log.Printf("Failed to embed annotation %s: %v", annotation.AnnotationID, err)
+	}
+}
+
+// annotationEmbeddingText is the text an annotation is embedded from: its quote followed by its note with
+// entity links reduced to their names
+func annotationEmbeddingText(exact string, processedNote *string) string {
+	if processedNote == nil {
+		return exact
+	}
+	return exact + "\n\n" + entityLinkRegex.ReplaceAllString(*processedNote, "$1")
+}
+
+// cleanNote trims a note, treating a blank one as no note
+func cleanNote(note *string) *string {
+	if note == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*note)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// resolveSelector builds the selector of a new or moved annotation. A quote is looked up in the text,
+// preferring the occurrence that matches the given context and is closest to the given start; a bare
+// position range takes its quote from the text. Either way the stored quote and context are the text's own
+func resolveSelector(text []rune, exact string, prefix, suffix *string, start, end *int) (entity.AnnotationSelector, error) {
+	var from, to int
+	switch {
+	case exact != "":
+		hint := entity.AnnotationSelector{Exact: exact}
+		if prefix != nil {
+			hint.Prefix = *prefix
+		}
+		if suffix != nil {
+			hint.Suffix = *suffix
+		}
+		if start != nil {
+			hint.Start = *start
+			hint.End = *start + len([]rune(exact))
+		}
+		if end != nil {
+			hint.End = *end
+		}
+		anchor := anchorSelector(text, hint)
+		if anchor.Status == entity.AnchorOrphaned {
+			return entity.AnnotationSelector{}, entity.ErrQuoteNotFound
+		}
+		from, to = anchor.Start, anchor.End
+	case start != nil && end != nil:
+		from, to = *start, *end
+		if from < 0 || to > len(text) || from >= to {
+			return entity.AnnotationSelector{}, entity.ErrInvalidAnnotation
+		}
+	default:
+		return entity.AnnotationSelector{}, entity.ErrInvalidAnnotation
+	}
+
+	return entity.AnnotationSelector{
+		Exact:  string(text[from:to]),
+		Prefix: string(text[max(0, from-annotationContext):from]),
+		Suffix: string(text[to:min(len(text), to+annotationContext)]),
+		Start:  from,
+		End:    to,
+	}, nil
+}
+
+// anchorSelector locates an annotation in the current reader text. Reprocessing a page can shift, reflow
+// or reformat its text, so the quote is tried at its recorded position, then anywhere with the closest
+// matching context, then ignoring whitespace and Markdown formatting, and last the text between an
+// unchanged prefix and suffix is taken for an edited quote
+func anchorSelector(text []rune, selector entity.AnnotationSelector) entity.AnnotationAnchor {
+	orphaned := entity.AnnotationAnchor{Status: entity.AnchorOrphaned, Start: selector.Start, End: selector.End}
+	exact := []rune(selector.Exact)
+	if len(exact) == 0 {
+		return orphaned
+	}
+
+	if selector.Start >= 0 && selector.End <= len(text) && selector.Start < selector.End &&
+		string(text[selector.Start:selector.End]) == selector.Exact {
+		return entity.AnnotationAnchor{Status: entity.AnchorExact, Start: selector.Start, End: selector.End}
+	}
+
+	prefix, suffix := []rune(selector.Prefix), []rune(selector.Suffix)
+	if start, ok := bestOccurrence(text, exact, prefix, suffix, selector.Start); ok {
+		return entity.AnnotationAnchor{Status: entity.AnchorMoved, Start: start, End: start + len(exact)}
+	}
+
+	normalized, positions := normalizeForAnchor(text)
+	normalizedExact, _ := normalizeForAnchor(exact)
+	if len(normalizedExact) > 0 {
+		normalizedPrefix, _ := normalizeForAnchor(prefix)
+		normalizedSuffix, _ := normalizeForAnchor(suffix)
+		hint := len(normalized)
+		if i := nearestIndex(positions, selector.Start); i >= 0 {
+			hint = i
+		}
+		if i, ok := bestOccurrence(normalized, normalizedExact, normalizedPrefix, normalizedSuffix, hint); ok {
+			return entity.AnnotationAnchor{
+				Status: entity.AnchorFuzzy,
+				Start:  positions[i],
+				End:    positions[i+len(normalizedExact)-1] + 1,
+			}
+		}
+	}
+
+	if start, end, ok := betweenContext(text, prefix, suffix, len(exact), selector.Start); ok {
+		return entity.AnnotationAnchor{Status: entity.AnchorFuzzy, Start: start, End: end}
+	}
+
+	return orphaned
+}
+
+// bestOccurrence finds the occurrence of quote whose surroundings share the most characters with prefix
+// and suffix, breaking ties by distance from hint
+func bestOccurrence(text, quote, prefix, suffix []rune, hint int) (int, bool) {
+	best, bestScore, bestDistance := -1, -1, 0
+	for i := indexRunes(text, quote, 0); i >= 0; i = indexRunes(text, quote, i+1) {
+		score := commonSuffix(text[:i], prefix) + commonPrefix(text[i+len(quote):], suffix)
+		distance := abs(i - hint)
+		if score > bestScore || (score == bestScore && distance < bestDistance) {
+			best, bestScore, bestDistance = i, score, distance
+		}
+	}
+	return best, best >= 0
+}
+
+// betweenContext finds the text between an occurrence of prefix and the next occurrence of suffix, as long
+// as its length stays within half to twice the quote's, preferring the one closest to hint
+func betweenContext(text, prefix, suffix []rune, quoteLen, hint int) (int, int, bool) {
+	if len(prefix) == 0 || len(suffix) == 0 {
+		return 0, 0, false
+	}
+
+	bestStart, bestEnd, bestDistance := -1, -1, 0
+	for i := indexRunes(text, prefix, 0); i >= 0; i = indexRunes(text, prefix, i+1) {
+		start := i + len(prefix)
+		window := text[start:min(len(text), start+2*quoteLen+len(suffix))]
+		j := indexRunes(window, suffix, 0)
+		if j < 0 || j*2 < quoteLen {
+			continue
+		}
+		distance := abs(start - hint)
+		if bestStart < 0 || distance < bestDistance {
+			bestStart, bestEnd, bestDistance = start, start+j, distance
+		}
+	}
+	return bestStart, bestEnd, bestStart >= 0
+}
+
+// normalizeForAnchor lowercases text, drops Markdown formatting characters and collapses whitespace,
+// returning the position in text of every character kept
+func normalizeForAnchor(text []rune) ([]rune, []int) {
+	normalized := make([]rune, 0, len(text))
+	positions := make([]int, 0, len(text))
+	space := true
+	for i, r := range text {
+		switch {
+		case strings.ContainsRune("*_`~#>[]\\", r):
+			continue
+		case unicode.IsSpace(r):
+			if !space {
+				normalized = append(normalized, ' ')
+				positions = append(positions, i)
+			}
+			space = true
+		default:
+			normalized = append(normalized, unicode.ToLower(r))
+			positions = append(positions, i)
+			space = false
+		}
+	}
+	if space && len(normalized) > 0 {
+		normalized = normalized[:len(normalized)-1]
+		positions = positions[:len(positions)-1]
+	}
+	return normalized, positions
+}
+
+// nearestIndex returns the index of the first position at or after target, or -1 if there is none
+func nearestIndex(positions []int, target int) int {
+	for i, position := range positions {
+		if position >= target {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexRunes returns the index of the first occurrence of needle in haystack at or after from, or -1
+func indexRunes(haystack, needle []rune, from int) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		if haystack[i] != needle[0] {
+			continue
+		}
+		match := true
+		for j := 1; j < len(needle); j++ {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// commonPrefix counts the characters a and b share at their start
+func commonPrefix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// commonSuffix counts the characters a and b share at their end
+func commonSuffix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"garden3/internal/domain/entity"
+)
+
+func TestResolveSelector(t *testing.T) {
+	text := []rune("The cat sat on the mat. The cat sat on the hat.")
+	intPtr := func(n int) *int { return &n }
+	strPtr := func(s string) *string { return &s }
+
+	testCases := []struct {
+		name    string
+		exact   string
+		suffix  *string
+		start   *int
+		end     *int
+		want    entity.AnnotationSelector
+		wantErr error
+	}{
+		{
+			name:  "first occurrence",
+			exact: "The cat sat",
+			want:  entity.AnnotationSelector{Exact: "The cat sat", Suffix: " on the mat. The cat sat on the ", Start: 0, End: 11},
+		},
+		{
+			name:   "occurrence told apart by suffix",
+			exact:  "The cat sat on the",
+			suffix: strPtr(" hat"),
+			want:   entity.AnnotationSelector{Exact: "The cat sat on the", Prefix: "The cat sat on the mat. ", Suffix: " hat.", Start: 24, End: 42},
+		},
+		{
+			name:  "position range",
+			start: intPtr(19),
+			end:   intPtr(22),
+			want:  entity.AnnotationSelector{Exact: "mat", Prefix: "The cat sat on the ", Suffix: ". The cat sat on the hat.", Start: 19, End: 22},
+		},
+		{name: "quote not in text", exact: "dog", wantErr: entity.ErrQuoteNotFound},
+		{name: "range out of bounds", start: intPtr(40), end: intPtr(60), wantErr: entity.ErrInvalidAnnotation},
+		{name: "no selector", wantErr: entity.ErrInvalidAnnotation},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveSelector(text, tc.exact, nil, tc.suffix, tc.start, tc.end)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("resolveSelector() error = %v, want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("resolveSelector() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAnchorSelector(t *testing.T) {
+	original := []rune("# Notes\n\nThe cat sat on the mat. The cat sat on the hat.\n\nIt was very happy about it.")
+	selector := func(exact string, occurrence int) entity.AnnotationSelector {
+		start := 0
+		for i := 0; i <= occurrence; i++ {
+			start = indexRunes(original, []rune(exact), start)
+			if i < occurrence {
+				start++
+			}
+		}
+		end := start + len([]rune(exact))
+		s, err := resolveSelector(original, "", nil, nil, &start, &end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	testCases := []struct {
+		name     string
+		text     string
+		selector entity.AnnotationSelector
+		status   entity.AnchorStatus
+		want     string
+	}{
+		{
+			name:     "unchanged",
+			text:     string(original),
+			selector: selector("sat on the mat", 0),
+			status:   entity.AnchorExact,
+			want:     "sat on the mat",
+		},
+		{
+			name:     "shifted by new text",
+			text:     "Intro.\n\n" + string(original),
+			selector: selector("sat on the mat", 0),
+			status:   entity.AnchorMoved,
+			want:     "sat on the mat",
+		},
+		{
+			name:     "repeated quote keeps its occurrence",
+			text:     "Intro.\n\n" + string(original),
+			selector: selector("The cat sat on the", 1),
+			status:   entity.AnchorMoved,
+			want:     "The cat sat on the",
+		},
+		{
+			name:     "reformatted",
+			text:     "# Notes\n\nThe cat sat on the mat. The cat sat on the hat.\n\nIt was **very**\nhappy about it.",
+			selector: selector("very happy", 0),
+			status:   entity.AnchorFuzzy,
+			want:     "very**\nhappy",
+		},
+		{
+			name:     "quote edited between unchanged context",
+			text:     "# Notes\n\nThe cat sat on the rug. The cat sat on the hat.\n\nIt was very happy about it.",
+			selector: selector("mat", 0),
+			status:   entity.AnchorFuzzy,
+			want:     "rug",
+		},
+		{
+			name:     "removed",
+			text:     "# Notes\n\nSomething else entirely.",
+			selector: selector("very happy", 0),
+			status:   entity.AnchorOrphaned,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			text := []rune(tc.text)
+			anchor := anchorSelector(text, tc.selector)
+			if anchor.Status != tc.status {
+				t.Fatalf("anchorSelector() status = %s, want %s", anchor.Status, tc.status)
+			}
+			if tc.status == entity.AnchorOrphaned {
+				return
+			}
+			if got := string(text[anchor.Start:anchor.End]); got != tc.want {
+				t.Errorf("anchorSelector() anchors %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
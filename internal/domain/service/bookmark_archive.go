@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+func (s *BookmarkService) ArchiveBookmark(ctx context.Context, bookmarkID uuid.UUID) (archive *entity.BookmarkArchive, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageArchive)
+	defer func() { run.finish(ctx, err) }()
+
+	if s.archiver == nil || s.archiveStore == nil {
+		run.skip("Archiving is disabled")
+		return nil, nil
+	}
+
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	fetchStatus, err := s.repo.GetLatestFetchStatus(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fetch status: %w", err)
+	}
+	if fetchStatus == nil || fetchStatus.StatusCode == nil || *fetchStatus.StatusCode >= 400 {
+		run.skip("No successful fetch to archive")
+		return nil, nil
+	}
+
+	httpResp, err := s.repo.GetLatestHttpResponse(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get http response: %w", err)
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(httpResp.Headers), &headers); err != nil {
+		return nil, fmt.Errorf("failed to parse headers: %w", err)
+	}
+	if documentStrategy(getContentType(headers), bookmark.URL, httpResp.Content) != strategyReader {
+		run.skip("Content is not HTML and cannot be archived")
+		return nil, nil
+	}
+
+	url := sanitizeURL(bookmark.URL)
+	profile, err := findFetchProfile(ctx, s.configRepo, url)
+	if err != nil {
+		return nil, err
+	}
+	options, _ := applyFetchProfile(profile, output.FetchOptions{}, 0)
+
+	original, originalContentType, err := s.repo.GetOriginalHttpResponseBody(ctx, httpResp.ResponseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get original response body: %w", err)
+	}
+
+	result, err := s.archiver.Archive(ctx, output.ArchivePage{
+		URL:                 url,
+		StatusCode:          httpResp.StatusCode,
+		Headers:             httpResp.Headers,
+		Content:             httpResp.Content,
+		FetchDate:           httpResp.FetchDate,
+		Original:            original,
+		OriginalContentType: originalContentType,
+	}, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive page: %w", err)
+	}
+
+	archive = &entity.BookmarkArchive{
+		BookmarkID:      bookmarkID,
+		URL:             url,
+		HTMLHash:        archiveBlobHash(result.HTML),
+		HTMLSize:        int64(len(result.HTML)),
+		ResourceCount:   int32(result.ResourceCount),
+		FailedResources: int32(result.FailedResources),
+	}
+	if len(result.WARC) > 0 {
+		warcHash := archiveBlobHash(result.WARC)
+		warcSize := int64(len(result.WARC))
+		archive.WARCHash = &warcHash
+		archive.WARCSize = &warcSize
+	}
+
+	// A file shared with an archive being deleted must not be removed between storing and recording it
+	unlock, err := s.repo.LockArchiveBlobs(ctx, archiveBlobs(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock archive files: %w", err)
+	}
+	defer unlock()
+
+	if _, err := s.archiveStore.Put(ctx, result.HTML); err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	if archive.WARCHash != nil {
+		if _, err := s.archiveStore.Put(ctx, result.WARC); err != nil {
+			s.deleteUnreferencedArchiveBlobs(ctx, archiveBlobs(archive))
+			return nil, fmt.Errorf("failed to store warc: %w", err)
+		}
+	}
+
+	if err := s.repo.InsertBookmarkArchive(ctx, archive); err != nil {
+		s.deleteUnreferencedArchiveBlobs(ctx, archiveBlobs(archive))
+		return nil, fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	run.note(fmt.Sprintf("%d resources inlined, %d failed", result.ResourceCount, result.FailedResources))
+	return archive, nil
+}
+
+func (s *BookmarkService) ListBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkArchive, error) {
+	archives, err := s.repo.ListBookmarkArchives(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	return archives, nil
+}
+
+func (s *BookmarkService) OpenBookmarkArchive(ctx context.Context, bookmarkID uuid.UUID, format entity.ArchiveFormat) (*entity.BookmarkArchive, io.ReadCloser, int64, error) {
+	if s.archiveStore == nil {
+		return nil, nil, 0, entity.ErrArchiveNotFound
+	}
+
+	archives, err := s.repo.ListBookmarkArchives(ctx, bookmarkID)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to list archives: %w", err)
+	}
+	if len(archives) == 0 {
+		return nil, nil, 0, entity.ErrArchiveNotFound
+	}
+
+	latest := archives[0]
+	hash := latest.HTMLHash
+	if format == entity.ArchiveWARC {
+		if latest.WARCHash == nil {
+			return nil, nil, 0, entity.ErrArchiveNotFound
+		}
+		hash = *latest.WARCHash
+	}
+
+	content, size, err := s.archiveStore.Open(ctx, hash)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to open archive: %w", err)
+	}
+	return &latest, content, size, nil
+}
+
+// releaseArchiveBlobs deletes the stored files of an archive that no archive row refers to any more.
+// Files are shared between archives with identical content, so each is only removed with its last reference
+func (s *BookmarkService) releaseArchiveBlobs(ctx context.Context, archive *entity.BookmarkArchive) {
+	if s.archiveStore == nil {
+		return
+	}
+
+	hashes := archiveBlobs(archive)
+	unlock, err := s.repo.LockArchiveBlobs(ctx, hashes)
+	if err != nil {
+		log.Printf("Failed to lock archive files %v: %v", hashes, err)
+		return
+	}
+	defer unlock()
+
+	s.deleteUnreferencedArchiveBlobs(ctx, hashes)
+}
+
+// deleteUnreferencedArchiveBlobs deletes the stored files no archive row refers to. The caller holds their locks
+func (s *BookmarkService) deleteUnreferencedArchiveBlobs(ctx context.Context, hashes []string) {
+	for _, hash := range hashes {
+		references, err := s.repo.CountArchiveBlobReferences(ctx, hash)
+		if err != nil {
+			log.Printf("Failed to count references to archive file %s: %v", hash, err)
+			continue
+		}
+		if references > 0 {
+			continue
+		}
+		if err := s.archiveStore.Delete(ctx, hash); err != nil {
+			log.Printf("Failed to delete archive file %s: %v", hash, err)
+		}
+	}
+}
+
+// archiveBlobs lists the stored files of an archive
+func archiveBlobs(archive *entity.BookmarkArchive) []string {
+	hashes := []string{archive.HTMLHash}
+	if archive.WARCHash != nil {
+		hashes = append(hashes, *archive.WARCHash)
+	}
+	return hashes
+}
+
+// archiveBlobHash is the name the archive store keeps data under, the hex SHA-256 of its content
+func archiveBlobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
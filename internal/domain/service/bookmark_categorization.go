@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+	"garden3/internal/port/output"
+)
+
+const (
+	defaultCategorizeThreshold = 0.85
+
+	// maxCategorySourceText bounds the text embedded for a category's name and sources
+	maxCategorySourceText = 4000
+)
+
+// BookmarkCategorizationService implements the BookmarkCategorizationUseCase interface
+// It suggests categories for bookmarks by comparing their summary embedding with a profile of each category
+type BookmarkCategorizationService struct {
+	categoryRepo      output.CategoryRepository
+	bookmarkRepo      output.BookmarkRepository
+	embeddingsService output.EmbeddingsService
+	threshold         float64
+}
+
+// NewBookmarkCategorizationService creates a new bookmark categorization service. Suggestions whose confidence
+// reaches threshold are assigned without review
+func NewBookmarkCategorizationService(
+	categoryRepo output.CategoryRepository,
+	bookmarkRepo output.BookmarkRepository,
+	embeddingsService output.EmbeddingsService,
+	threshold float64,
+) *BookmarkCategorizationService {
+	if threshold <= 0 {
+		threshold = defaultCategorizeThreshold
+	}
+	return &BookmarkCategorizationService{
+		categoryRepo:      categoryRepo,
+		bookmarkRepo:      bookmarkRepo,
+		embeddingsService: embeddingsService,
+		threshold:         threshold,
+	}
+}
+
+func (s *BookmarkCategorizationService) RefreshCategoryProfiles(ctx context.Context) (*entity.CategoryProfileRefresh, error) {
+	categories, err := s.categoryRepo.ListCategoriesWithSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	stored, err := s.storedSourceTexts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.CategoryProfileRefresh{}
+	for _, category := range categories {
+		profile, embedded, err := s.refreshProfile(ctx, category, stored)
+		if err != nil {
+			return nil, err
+		}
+		if embedded {
+			result.EmbeddedSources++
+		}
+		if profile != nil {
+			result.Profiles++
+		} else if _, ok := stored[category.Category.CategoryID]; ok {
+			result.Removed++
+		}
+	}
+	return result, nil
+}
+
+func (s *BookmarkCategorizationService) GetBookmarksToCategorize(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	bookmarkIDs, err := s.categoryRepo.ListBookmarksToCategorize(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookmarks to categorize: %w", err)
+	}
+	return bookmarkIDs, nil
+}
+
+func (s *BookmarkCategorizationService) SuggestBookmarkCategory(ctx context.Context, bookmarkID uuid.UUID) (*entity.CategorySuggestion, error) {
+	matches, err := s.categoryRepo.RankCategoryProfiles(ctx, bookmarkID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank categories: %w", err)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	best := matches[0]
+
+	status := entity.SuggestionPending
+	if best.Similarity >= s.threshold {
+		details, err := s.bookmarkRepo.GetBookmarkDetails(ctx, bookmarkID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get bookmark: %w", err)
+		}
+		// A category chosen by hand is never replaced without review
+		if details.CategoryName == nil {
+			if err := s.bookmarkRepo.SetBookmarkCategory(ctx, bookmarkID, &best.CategoryID); err != nil {
+				return nil, fmt.Errorf("failed to assign category: %w", err)
+			}
+			status = entity.SuggestionAuto
+		}
+	}
+
+	suggestionID, err := s.categoryRepo.SaveCategorySuggestion(ctx, bookmarkID, best.CategoryID, best.Similarity, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save suggestion: %w", err)
+	}
+
+	suggestion, err := s.categoryRepo.GetCategorySuggestion(ctx, suggestionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggestion: %w", err)
+	}
+	return suggestion, nil
+}
+
+func (s *BookmarkCategorizationService) ListCategorySuggestions(ctx context.Context, filters entity.CategorySuggestionFilters) (*input.PaginatedResponse[entity.CategorySuggestion], error) {
+	status := filters.Status
+	if status == "" {
+		status = entity.SuggestionPending
+	}
+	page := filters.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := filters.Limit
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	suggestions, err := s.categoryRepo.ListCategorySuggestions(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suggestions: %w", err)
+	}
+
+	total, err := s.categoryRepo.CountCategorySuggestions(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count suggestions: %w", err)
+	}
+
+	totalPages := int32((total + int64(limit) - 1) / int64(limit))
+
+	return &input.PaginatedResponse[entity.CategorySuggestion]{
+		Data:       suggestions,
+		Total:      total,
+		Page:       page,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *BookmarkCategorizationService) ReviewCategorySuggestion(ctx context.Context, suggestionID uuid.UUID, accept bool) (*entity.CategorySuggestion, error) {
+	suggestion, err := s.categoryRepo.GetCategorySuggestion(ctx, suggestionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggestion: %w", err)
+	}
+	if suggestion == nil {
+		return nil, nil
+	}
+	if suggestion.Status != entity.SuggestionPending {
+		return nil, entity.ErrSuggestionReviewed
+	}
+
+	status := entity.SuggestionRejected
+	if accept {
+		status = entity.SuggestionAccepted
+	}
+
+	// The status is claimed before the category is assigned, so of two concurrent reviews only one applies
+	updated, err := s.categoryRepo.UpdateCategorySuggestionStatus(ctx, suggestionID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update suggestion: %w", err)
+	}
+	if !updated {
+		return nil, entity.ErrSuggestionReviewed
+	}
+
+	if accept {
+		if err := s.bookmarkRepo.SetBookmarkCategory(ctx, suggestion.BookmarkID, &suggestion.CategoryID); err != nil {
+			if reopenErr := s.categoryRepo.ReopenCategorySuggestion(ctx, suggestionID); reopenErr != nil {
+				log.Printf("Failed to reopen category suggestion %s: %v", suggestionID, reopenErr)
+			}
+			return nil, fmt.Errorf("failed to assign category: %w", err)
+		}
+		if err := s.refreshCategoryProfile(ctx, suggestion.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	suggestion, err = s.categoryRepo.GetCategorySuggestion(ctx, suggestionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggestion: %w", err)
+	}
+	return suggestion, nil
+}
+
+// refreshCategoryProfile rebuilds the profile of one category so an accepted bookmark counts right away
+func (s *BookmarkCategorizationService) refreshCategoryProfile(ctx context.Context, categoryID uuid.UUID) error {
+	categories, err := s.categoryRepo.ListCategoriesWithSources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	stored, err := s.storedSourceTexts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		if category.Category.CategoryID == categoryID {
+			_, _, err := s.refreshProfile(ctx, category, stored)
+			return err
+		}
+	}
+	return nil
+}
+
+// storedSourceTexts maps each profiled category to the source text its stored source embedding was made from
+func (s *BookmarkCategorizationService) storedSourceTexts(ctx context.Context) (map[uuid.UUID]string, error) {
+	profiles, err := s.categoryRepo.ListCategoryProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list category profiles: %w", err)
+	}
+
+	stored := make(map[uuid.UUID]string, len(profiles))
+	for _, profile := range profiles {
+		stored[profile.CategoryID] = profile.SourceText
+	}
+	return stored, nil
+}
+
+// refreshProfile rebuilds a category profile, embedding its name and sources again only when they changed.
+// It reports whether the sources were embedded
+func (s *BookmarkCategorizationService) refreshProfile(ctx context.Context, category entity.CategoryWithSources, stored map[uuid.UUID]string) (*entity.CategoryProfile, bool, error) {
+	text := categorySourceText(category)
+	model := s.embeddingsService.Model()
+
+	var sourceEmbedding []float32
+	if storedText, ok := stored[category.Category.CategoryID]; !ok || storedText != text {
+		embedding, err := embedText(ctx, s.embeddingsService, text)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to embed sources of category %s: %w", category.Category.Name, err)
+		}
+		sourceEmbedding = embedding
+	}
+
+	profile, err := s.categoryRepo.RefreshCategoryProfile(ctx, category.Category.CategoryID, text, sourceEmbedding, model)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to refresh profile of category %s: %w", category.Category.Name, err)
+	}
+	return profile, sourceEmbedding != nil, nil
+}
+
+// categorySourceText describes a category by its name, the URIs of its sources and the string values found
+// in their raw JSON, without repeats and cut to maxCategorySourceText bytes
+func categorySourceText(category entity.CategoryWithSources) string {
+	var parts []string
+	seen := make(map[string]bool)
+	add := func(value string) {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			return
+		}
+		seen[value] = true
+		parts = append(parts, value)
+	}
+
+	add(category.Category.Name)
+	for _, source := range category.Sources {
+		if source.SourceURI != nil {
+			add(*source.SourceURI)
+		}
+		var raw any
+		if len(source.RawSource) > 0 && json.Unmarshal(source.RawSource, &raw) == nil {
+			for _, value := range jsonStrings(raw) {
+				add(value)
+			}
+		}
+	}
+
+	text := strings.Join(parts, "\n")
+	if len(text) > maxCategorySourceText {
+		text = strings.ToValidUTF8(text[:maxCategorySourceText], "")
+	}
+	return text
+}
+
+// jsonStrings collects the string values of a decoded JSON document, visiting object keys in sorted order
+func jsonStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, jsonStrings(item)...)
+		}
+		return values
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var values []string
+		for _, key := range keys {
+			values = append(values, jsonStrings(v[key])...)
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"garden3/internal/domain/entity"
+)
+
+func TestCategorySourceText(t *testing.T) {
+	roomID := "!room:example.org"
+	empty := ""
+
+	testCases := []struct {
+		name     string
+		category entity.CategoryWithSources
+		want     string
+	}{
+		{
+			name:     "name only",
+			category: entity.CategoryWithSources{Category: entity.Category{Name: "Go"}},
+			want:     "Go",
+		},
+		{
+			name: "sources",
+			category: entity.CategoryWithSources{
+				Category: entity.Category{Name: "Go"},
+				Sources: []entity.CategorySource{
+					{SourceURI: &roomID, RawSource: []byte(`{"topic": "Gophers", "name": "Go", "aliases": ["#go:example.org", 3]}`)},
+					{SourceURI: &empty, RawSource: []byte(`null`)},
+					{SourceURI: &roomID},
+				},
+			},
+			want: "Go\n!room:example.org\n#go:example.org\nGophers",
+		},
+		{
+			name: "invalid raw source",
+			category: entity.CategoryWithSources{
+				Category: entity.Category{Name: "Go"},
+				Sources:  []entity.CategorySource{{RawSource: []byte(`{not json`)}},
+			},
+			want: "Go",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := categorySourceText(tc.category); got != tc.want {
+				t.Errorf("categorySourceText() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	long := entity.CategoryWithSources{Category: entity.Category{Name: strings.Repeat("é", maxCategorySourceText)}}
+	if got := categorySourceText(long); len(got) > maxCategorySourceText || !strings.HasPrefix(long.Category.Name, got) {
+		t.Errorf("categorySourceText() did not cut a long name on a character boundary")
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+func (s *BookmarkService) CreateBookmarkEvaluation(ctx context.Context, bookmarkID uuid.UUID, input entity.CreateEvaluationInput) (*entity.BookmarkEvaluation, error) {
+	if err := validateEvaluationInput(input); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetBookmark(ctx, bookmarkID); err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	evaluation := &entity.BookmarkEvaluation{
+		BookmarkID:      bookmarkID,
+		FailedFetch:     input.FailedFetch != nil && *input.FailedFetch,
+		SummaryEval:     input.SummaryEval,
+		SummaryComments: input.SummaryComments,
+		QuestionsEval:   input.QuestionsEval,
+	}
+
+	if input.SummaryEval != nil || input.SummaryComments != nil || input.SummaryID != nil {
+		summaryID := input.SummaryID
+		if summaryID == nil {
+			latest, err := s.repo.GetLatestContentReferenceID(ctx, bookmarkID, string(entity.StageSummaryReader))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get latest summary: %w", err)
+			}
+			if latest == nil {
+				return nil, fmt.Errorf("%w: bookmark has no summary", entity.ErrInvalidEvaluation)
+			}
+			summaryID = latest
+		}
+
+		generation, err := s.contentGeneration(ctx, bookmarkID, []uuid.UUID{*summaryID}, entity.StageSummaryReader)
+		if err != nil {
+			return nil, err
+		}
+		evaluation.SummaryID = summaryID
+		evaluation.SummaryStrategy = generation.Strategy
+		evaluation.SummaryModel = generation.Model
+		evaluation.SummaryPromptVersion = generation.PromptVersion
+	}
+
+	if input.QuestionsEval != nil || len(input.QuestionsIDs) > 0 {
+		questionIDs := input.QuestionsIDs
+		if len(questionIDs) == 0 {
+			questions, err := s.repo.GetGeneratedQuestions(ctx, bookmarkID, string(entity.StageQAPassage), s.aiService.QuestionPromptVersion())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get generated questions: %w", err)
+			}
+			if len(questions) == 0 {
+				return nil, fmt.Errorf("%w: bookmark has no generated questions", entity.ErrInvalidEvaluation)
+			}
+			for _, question := range questions {
+				questionIDs = append(questionIDs, question.ID)
+			}
+		}
+
+		generation, err := s.contentGeneration(ctx, bookmarkID, questionIDs, entity.StageQAPassage)
+		if err != nil {
+			return nil, err
+		}
+		evaluation.QuestionsIDs = questionIDs
+		evaluation.QuestionsStrategy = generation.Strategy
+		evaluation.QuestionsModel = generation.Model
+		evaluation.QuestionsPromptVersion = generation.PromptVersion
+	}
+
+	if err := s.repo.InsertBookmarkEvaluation(ctx, evaluation); err != nil {
+		return nil, fmt.Errorf("failed to insert evaluation: %w", err)
+	}
+
+	return evaluation, nil
+}
+
+func (s *BookmarkService) ListBookmarkEvaluations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkEvaluation, error) {
+	evaluations, err := s.repo.ListBookmarkEvaluations(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evaluations: %w", err)
+	}
+	return evaluations, nil
+}
+
+func (s *BookmarkService) GetEvaluationReport(ctx context.Context, filters entity.EvaluationReportFilters) (*entity.EvaluationReport, error) {
+	if filters.Period != nil {
+		switch *filters.Period {
+		case "day", "week", "month":
+		default:
+			return nil, fmt.Errorf("%w: period must be day, week or month", entity.ErrInvalidEvaluation)
+		}
+	}
+	if filters.Since != nil && filters.Until != nil && !filters.Since.Before(*filters.Until) {
+		return nil, fmt.Errorf("%w: since must be before until", entity.ErrInvalidEvaluation)
+	}
+
+	report, err := s.repo.GetEvaluationReport(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evaluation report: %w", err)
+	}
+	return report, nil
+}
+
+// contentGeneration looks up the content references being evaluated and returns what produced them
+func (s *BookmarkService) contentGeneration(ctx context.Context, bookmarkID uuid.UUID, ids []uuid.UUID, stage entity.PipelineStage) (*entity.ContentGeneration, error) {
+	generations, err := s.repo.GetContentGenerations(ctx, bookmarkID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get content generations: %w", err)
+	}
+	return sharedGeneration(ids, generations, stage)
+}
+
+// sharedGeneration checks that every evaluated ID is a reference of the stage's strategy and that they were
+// all generated together, so a single score can be attributed to one model and prompt version
+func sharedGeneration(ids []uuid.UUID, generations []entity.ContentGeneration, stage entity.PipelineStage) (*entity.ContentGeneration, error) {
+	byID := make(map[uuid.UUID]entity.ContentGeneration, len(generations))
+	for _, generation := range generations {
+		byID[generation.ID] = generation
+	}
+
+	var shared *entity.ContentGeneration
+	for _, id := range ids {
+		generation, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not content of this bookmark", entity.ErrInvalidEvaluation, id)
+		}
+		if generation.Strategy == nil || *generation.Strategy != string(stage) {
+			return nil, fmt.Errorf("%w: %s is not %s content", entity.ErrInvalidEvaluation, id, stage)
+		}
+		if shared == nil {
+			shared = &generation
+			continue
+		}
+		if !equalStringPtr(shared.Model, generation.Model) || !equalStringPtr(shared.PromptVersion, generation.PromptVersion) {
+			return nil, fmt.Errorf("%w: content was generated by different models or prompts", entity.ErrInvalidEvaluation)
+		}
+	}
+	return shared, nil
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// validateEvaluationInput requires at least one finding and keeps scores within range
+func validateEvaluationInput(input entity.CreateEvaluationInput) error {
+	failedFetch := input.FailedFetch != nil && *input.FailedFetch
+	if !failedFetch && input.SummaryEval == nil && input.QuestionsEval == nil {
+		return fmt.Errorf("%w: give failed_fetch, summary_eval or questions_eval", entity.ErrInvalidEvaluation)
+	}
+
+	if err := validateEvaluationScore("summary_eval", input.SummaryEval); err != nil {
+		return err
+	}
+	return validateEvaluationScore("questions_eval", input.QuestionsEval)
+}
+
+func validateEvaluationScore(name string, score *int32) error {
+	if score != nil && (*score < entity.MinEvaluationScore || *score > entity.MaxEvaluationScore) {
+		return fmt.Errorf("%w: %s must be between %d and %d", entity.ErrInvalidEvaluation, name, entity.MinEvaluationScore, entity.MaxEvaluationScore)
+	}
+	return nil
+}
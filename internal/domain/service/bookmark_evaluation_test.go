@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+func TestSharedGeneration(t *testing.T) {
+	str := func(s string) *string { return &s }
+	first, second, other := uuid.New(), uuid.New(), uuid.New()
+	question := func(id uuid.UUID, model, promptVersion string) entity.ContentGeneration {
+		return entity.ContentGeneration{
+			ID:            id,
+			Strategy:      str(string(entity.StageQAPassage)),
+			Model:         str(model),
+			PromptVersion: str(promptVersion),
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		ids         []uuid.UUID
+		generations []entity.ContentGeneration
+		wantPrompt  string
+		wantErr     bool
+	}{
+		{
+			name:        "same generation",
+			ids:         []uuid.UUID{first, second},
+			generations: []entity.ContentGeneration{question(first, "m", "qa-1"), question(second, "m", "qa-1")},
+			wantPrompt:  "qa-1",
+		},
+		{
+			name:        "mixed prompt versions",
+			ids:         []uuid.UUID{first, second},
+			generations: []entity.ContentGeneration{question(first, "m", "qa-1"), question(second, "m", "qa-2")},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown reference",
+			ids:         []uuid.UUID{first, other},
+			generations: []entity.ContentGeneration{question(first, "m", "qa-1")},
+			wantErr:     true,
+		},
+		{
+			name: "other strategy",
+			ids:  []uuid.UUID{first},
+			generations: []entity.ContentGeneration{{
+				ID:       first,
+				Strategy: str(string(entity.StageChunkedReader)),
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generation, err := sharedGeneration(tc.ids, tc.generations, entity.StageQAPassage)
+			if tc.wantErr {
+				if !errors.Is(err, entity.ErrInvalidEvaluation) {
+					t.Fatalf("sharedGeneration() error = %v, want ErrInvalidEvaluation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sharedGeneration() error = %v", err)
+			}
+			if generation.PromptVersion == nil || *generation.PromptVersion != tc.wantPrompt {
+				t.Errorf("sharedGeneration() prompt version = %v, want %s", generation.PromptVersion, tc.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestValidateEvaluationInput(t *testing.T) {
+	score := func(v int32) *int32 { return &v }
+	yes := true
+
+	testCases := []struct {
+		name    string
+		input   entity.CreateEvaluationInput
+		wantErr bool
+	}{
+		{name: "summary score", input: entity.CreateEvaluationInput{SummaryEval: score(4)}},
+		{name: "failed fetch only", input: entity.CreateEvaluationInput{FailedFetch: &yes}},
+		{name: "nothing evaluated", input: entity.CreateEvaluationInput{}, wantErr: true},
+		{name: "score too low", input: entity.CreateEvaluationInput{QuestionsEval: score(0)}, wantErr: true},
+		{name: "score too high", input: entity.CreateEvaluationInput{SummaryEval: score(6)}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateEvaluationInput(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateEvaluationInput() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
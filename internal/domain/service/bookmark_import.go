@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+	"garden3/internal/port/output"
+)
+
+const (
+	// maxImportReportEntries caps how many per-entry errors and duplicates an import keeps
+	maxImportReportEntries = 100
+
+	// importStaleAfter is how long a running import must be silent before it can be resumed
+	importStaleAfter = 2 * time.Minute
+)
+
+// BookmarkImportService implements the BookmarkImportUseCase interface
+type BookmarkImportService struct {
+	bookmarks        input.BookmarkUseCase
+	repo             output.BookmarkRepository
+	categories       output.CategoryRepository
+	contentProcessor output.ContentProcessor
+	parsers          map[entity.ImportFormat]output.BookmarkParser
+	writer           output.BookmarkWriter
+}
+
+// NewBookmarkImportService creates a new bookmark import service
+func NewBookmarkImportService(
+	bookmarks input.BookmarkUseCase,
+	repo output.BookmarkRepository,
+	categories output.CategoryRepository,
+	contentProcessor output.ContentProcessor,
+	parsers map[entity.ImportFormat]output.BookmarkParser,
+	writer output.BookmarkWriter,
+) *BookmarkImportService {
+	return &BookmarkImportService{
+		bookmarks:        bookmarks,
+		repo:             repo,
+		categories:       categories,
+		contentProcessor: contentProcessor,
+		parsers:          parsers,
+		writer:           writer,
+	}
+}
+
+func (s *BookmarkImportService) ImportBookmarks(ctx context.Context, r io.Reader, input entity.ImportBookmarksInput) (*entity.ImportResult, error) {
+	parser, ok := s.parsers[input.Format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", entity.ErrUnsupportedImportFormat, input.Format)
+	}
+
+	result, err := s.startImport(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	source := string(input.Format)
+	if result.Source != nil {
+		source = *result.Source
+	}
+
+	// A resumed import re-reads the file from the start and skips what was already processed
+	skip := result.Total
+	position := 0
+	categoryIDs := make(map[string]uuid.UUID)
+
+	parseErr := parser.Parse(ctx, r, func(entry entity.ImportedBookmark) error {
+		position++
+		if position <= skip {
+			return nil
+		}
+
+		bookmark, err := s.importEntry(ctx, entry, source, categoryIDs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			result.Failed++
+			if len(result.Errors) < maxImportReportEntries {
+				result.Errors = append(result.Errors, entity.ImportError{
+					URL:   entry.URL,
+					Error: err.Error(),
+				})
+			}
+		} else if bookmark.Created {
+			result.Created++
+		} else {
+			result.Duplicates++
+			if len(result.DuplicateEntries) < maxImportReportEntries {
+				result.DuplicateEntries = append(result.DuplicateEntries, entity.ImportDuplicate{
+					URL:        entry.URL,
+					BookmarkID: bookmark.Bookmark.BookmarkID,
+				})
+			}
+		}
+
+		// Progress is saved after every entry so that a resume never processes an entry twice
+		result.Total++
+		if err := s.repo.UpdateImport(ctx, result); err != nil {
+			return fmt.Errorf("failed to save import progress: %w", err)
+		}
+		return nil
+	})
+
+	switch {
+	case parseErr == nil:
+		now := time.Now()
+		result.Status = entity.ImportCompleted
+		result.FinishedAt = &now
+	case ctx.Err() != nil:
+		result.Status = entity.ImportInterrupted
+	default:
+		result.Status = entity.ImportFailed
+	}
+	if parseErr != nil {
+		errMsg := parseErr.Error()
+		result.Error = &errMsg
+	}
+
+	// The request context may already be cancelled, but the progress must still be saved for a resume
+	if err := s.repo.UpdateImport(context.WithoutCancel(ctx), result); err != nil {
+		return nil, fmt.Errorf("failed to save import progress: %w", err)
+	}
+
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to import bookmarks, resume with import %s: %w", result.ImportID, parseErr)
+	}
+
+	return result, nil
+}
+
+// startImport records a new import or reopens the one being resumed
+func (s *BookmarkImportService) startImport(ctx context.Context, input entity.ImportBookmarksInput) (*entity.ImportResult, error) {
+	if input.ResumeImportID == nil {
+		source := input.Source
+		if source == "" {
+			source = string(input.Format)
+		}
+
+		result, err := s.repo.CreateImport(ctx, input.Format, &source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create import: %w", err)
+		}
+		return result, nil
+	}
+
+	result, err := s.repo.GetImport(ctx, *input.ResumeImportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("%w: import %s not found", entity.ErrImportNotResumable, *input.ResumeImportID)
+	}
+
+	switch {
+	case result.Status == entity.ImportCompleted:
+		return nil, fmt.Errorf("%w: import %s already completed", entity.ErrImportNotResumable, result.ImportID)
+	case result.Status == entity.ImportRunning && time.Since(result.UpdatedAt) < importStaleAfter:
+		return nil, fmt.Errorf("%w: import %s is still running", entity.ErrImportNotResumable, result.ImportID)
+	case result.Format != input.Format:
+		return nil, fmt.Errorf("%w: import %s was started with format %s", entity.ErrImportNotResumable, result.ImportID, result.Format)
+	}
+
+	result.Status = entity.ImportRunning
+	result.Error = nil
+	if err := s.repo.UpdateImport(ctx, result); err != nil {
+		return nil, fmt.Errorf("failed to update import: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *BookmarkImportService) GetImport(ctx context.Context, importID uuid.UUID) (*entity.ImportResult, error) {
+	result, err := s.repo.GetImport(ctx, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return result, nil
+}
+
+func (s *BookmarkImportService) importEntry(ctx context.Context, entry entity.ImportedBookmark, source string, categoryIDs map[string]uuid.UUID) (*entity.CreateBookmarkResult, error) {
+	var categoryID *uuid.UUID
+	if len(entry.Folders) > 0 {
+		id, err := s.resolveCategory(ctx, entry.Folders, categoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		categoryID = &id
+	}
+
+	return s.bookmarks.CreateBookmark(ctx, entity.CreateBookmarkInput{
+		URL:             entry.URL,
+		Title:           entry.Title,
+		CategoryID:      categoryID,
+		CreationDate:    entry.AddDate,
+		SourceURI:       &source,
+		RawSource:       entry.RawEntry,
+		Tags:            entry.Tags,
+		ArchivedContent: s.archivedContent(ctx, entry),
+	})
+}
+
+// archivedContent converts saved article HTML to reader markdown, dropping it when extraction fails so the page is fetched instead
+func (s *BookmarkImportService) archivedContent(ctx context.Context, entry entity.ImportedBookmark) *string {
+	if entry.ArchivedContent == nil || !entry.ArchivedHTML {
+		return entry.ArchivedContent
+	}
+
+	content, err := s.contentProcessor.ProcessWithReader(ctx, []byte(*entry.ArchivedContent), entry.URL)
+	if err != nil || strings.TrimSpace(content) == "" {
+		log.Printf("Failed to extract archived content for %s, it will be fetched instead: %v", entry.URL, err)
+		return nil
+	}
+	return &content
+}
+
+// resolveCategory finds or creates the category for each folder along a path, nesting every folder under
+// the one before it, and returns the innermost. Results are cached by path for the import
+func (s *BookmarkImportService) resolveCategory(ctx context.Context, folders []string, cache map[string]uuid.UUID) (uuid.UUID, error) {
+	var parentID *uuid.UUID
+	for i, name := range folders {
+		key := folderPathKey(folders[:i+1])
+		if id, ok := cache[key]; ok {
+			parentID = &id
+			continue
+		}
+
+		category, err := s.categories.GetCategoryByName(ctx, name, parentID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		if category == nil {
+			category, err = s.categories.CreateCategory(ctx, name, parentID)
+			if err != nil {
+				return uuid.Nil, fmt.Errorf("failed to create category: %w", err)
+			}
+		}
+
+		cache[key] = category.CategoryID
+		parentID = &category.CategoryID
+	}
+
+	return *parentID, nil
+}
+
+// folderPathKey identifies a folder path case-insensitively
+func folderPathKey(folders []string) string {
+	return strings.ToLower(strings.Join(folders, "\x00"))
+}
+
+func (s *BookmarkImportService) ExportBookmarks(ctx context.Context, w io.Writer) error {
+	bookmarks, err := s.repo.ListBookmarksForExport(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list bookmarks: %w", err)
+	}
+
+	categories, err := s.categories.ListCategoriesWithSources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	if err := s.writer.Write(w, exportFolders(categories, bookmarks)); err != nil {
+		return fmt.Errorf("failed to write bookmarks: %w", err)
+	}
+
+	return nil
+}
+
+// exportFolders arranges bookmarks into the category tree, ordering folders by name and bookmarks by
+// creation date. Folders without bookmarks anywhere below them are left out, and uncategorized
+// bookmarks go into a folder with an empty name
+func exportFolders(categories []entity.CategoryWithSources, bookmarks []entity.ExportBookmark) []entity.ExportFolder {
+	byCategory := make(map[uuid.UUID][]entity.ExportBookmark)
+	var uncategorized []entity.ExportBookmark
+	for _, bookmark := range bookmarks {
+		if bookmark.CategoryID == nil {
+			uncategorized = append(uncategorized, bookmark)
+			continue
+		}
+		byCategory[*bookmark.CategoryID] = append(byCategory[*bookmark.CategoryID], bookmark)
+	}
+
+	known := make(map[uuid.UUID]bool, len(categories))
+	for _, category := range categories {
+		known[category.Category.CategoryID] = true
+	}
+
+	children := make(map[uuid.UUID][]entity.Category)
+	var roots []entity.Category
+	for _, category := range categories {
+		parentID := category.Category.ParentID
+		if parentID == nil || !known[*parentID] {
+			roots = append(roots, category.Category)
+			continue
+		}
+		children[*parentID] = append(children[*parentID], category.Category)
+	}
+
+	var build func(siblings []entity.Category) []entity.ExportFolder
+	build = func(siblings []entity.Category) []entity.ExportFolder {
+		folders := make([]entity.ExportFolder, 0, len(siblings))
+		for _, category := range siblings {
+			folder := entity.ExportFolder{
+				Name:      category.Name,
+				Bookmarks: byCategory[category.CategoryID],
+				Children:  build(children[category.CategoryID]),
+			}
+			if len(folder.Bookmarks) == 0 && len(folder.Children) == 0 {
+				continue
+			}
+			sortExportBookmarks(folder.Bookmarks)
+			folders = append(folders, folder)
+		}
+		sort.Slice(folders, func(i, j int) bool {
+			return strings.ToLower(folders[i].Name) < strings.ToLower(folders[j].Name)
+		})
+		return folders
+	}
+
+	folders := build(roots)
+	if len(uncategorized) > 0 {
+		sortExportBookmarks(uncategorized)
+		folders = append([]entity.ExportFolder{{Bookmarks: uncategorized}}, folders...)
+	}
+	return folders
+}
+
+func sortExportBookmarks(bookmarks []entity.ExportBookmark) {
+	sort.Slice(bookmarks, func(i, j int) bool {
+		return bookmarks[i].CreationDate.Before(bookmarks[j].CreationDate)
+	})
+}
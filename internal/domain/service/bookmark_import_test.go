@@ -0,0 +1,58 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+func TestExportFolders(t *testing.T) {
+	programming, rust, empty, web := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	categories := []entity.CategoryWithSources{
+		{Category: entity.Category{CategoryID: programming, Name: "programming"}},
+		{Category: entity.Category{CategoryID: rust, Name: "Rust", ParentID: &programming}},
+		{Category: entity.Category{CategoryID: empty, Name: "Empty", ParentID: &programming}},
+		{Category: entity.Category{CategoryID: web, Name: "Web"}},
+	}
+	bookmarks := []entity.ExportBookmark{
+		{URL: "https://www.rust-lang.org/", CategoryID: &rust, CreationDate: time.Unix(2, 0)},
+		{URL: "https://doc.rust-lang.org/", CategoryID: &rust, CreationDate: time.Unix(1, 0)},
+		{URL: "https://example.com/", CreationDate: time.Unix(3, 0)},
+	}
+
+	folders := exportFolders(categories, bookmarks)
+
+	var describe func(folders []entity.ExportFolder, prefix string) []string
+	describe = func(folders []entity.ExportFolder, prefix string) []string {
+		var lines []string
+		for _, folder := range folders {
+			path := prefix + "/" + folder.Name
+			for _, bookmark := range folder.Bookmarks {
+				lines = append(lines, path+" "+bookmark.URL)
+			}
+			lines = append(lines, describe(folder.Children, path)...)
+		}
+		return lines
+	}
+
+	want := []string{
+		"/ https://example.com/",
+		"/programming/Rust https://doc.rust-lang.org/",
+		"/programming/Rust https://www.rust-lang.org/",
+	}
+	if got := describe(folders, ""); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("exportFolders() = %q, want %q", got, want)
+	}
+}
+
+func TestFolderPathKey(t *testing.T) {
+	if folderPathKey([]string{"Programming", "Go"}) != folderPathKey([]string{"programming", "GO"}) {
+		t.Errorf("folderPathKey() should ignore case")
+	}
+	if folderPathKey([]string{"a/b"}) == folderPathKey([]string{"a", "b"}) {
+		t.Errorf("folderPathKey() should keep folder boundaries")
+	}
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/publicsuffix"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+	"garden3/internal/port/output"
+)
+
+const (
+	// linkCheckTimeout is the time allowed for each request of a link check, in milliseconds
+	linkCheckTimeout = 25000
+
+	// linkChangeThreshold is the share of lines that must differ before a page counts as changed
+	linkChangeThreshold = 0.2
+
+	// maxDiffLines caps the removed and added lines kept with a link check
+	maxDiffLines = 200
+
+	// maxParkedWords is the most readable text a parked domain page is expected to carry
+	maxParkedWords = 150
+
+	// linkCheckHistory is the number of recent link checks included in bookmark details
+	linkCheckHistory = 10
+)
+
+// parkingHosts are domain marketplaces and parking services that expired domains redirect to
+var parkingHosts = map[string]bool{
+	"above.com":        true,
+	"afternic.com":     true,
+	"bodis.com":        true,
+	"buydomains.com":   true,
+	"dan.com":          true,
+	"domainmarket.com": true,
+	"hugedomains.com":  true,
+	"parkingcrew.net":  true,
+	"parklogic.com":    true,
+	"sedo.com":         true,
+	"sedoparking.com":  true,
+	"undeveloped.com":  true,
+}
+
+// parkingMarkers are phrases found on parked and for-sale domain pages
+var parkingMarkers = []string{
+	"this domain is for sale",
+	"this domain may be for sale",
+	"the domain name is for sale",
+	"buy this domain",
+	"this domain is parked",
+	"domain is parked free",
+	"parked free, courtesy of",
+	"this domain has expired",
+	"inquire about this domain",
+}
+
+// BookmarkLinkCheckService implements the BookmarkLinkCheckUseCase interface
+// It re-fetches bookmarked pages to find links that died, moved to another site or changed
+type BookmarkLinkCheckService struct {
+	repo             output.BookmarkRepository
+	httpFetcher      output.HTTPFetcher
+	contentProcessor output.ContentProcessor
+	configRepo       output.ConfigurationRepository
+	interval         time.Duration
+}
+
+// NewBookmarkLinkCheckService creates a new link check service that re-checks each bookmark once per interval
+func NewBookmarkLinkCheckService(
+	repo output.BookmarkRepository,
+	httpFetcher output.HTTPFetcher,
+	contentProcessor output.ContentProcessor,
+	configRepo output.ConfigurationRepository,
+	interval time.Duration,
+) *BookmarkLinkCheckService {
+	if interval <= 0 {
+		interval = 30 * 24 * time.Hour
+	}
+	return &BookmarkLinkCheckService{
+		repo:             repo,
+		httpFetcher:      httpFetcher,
+		contentProcessor: contentProcessor,
+		configRepo:       configRepo,
+		interval:         interval,
+	}
+}
+
+func (s *BookmarkLinkCheckService) CheckBookmarkLink(ctx context.Context, bookmarkID uuid.UUID) (*entity.LinkCheck, error) {
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	previousChecks, err := s.repo.ListLinkChecks(ctx, bookmarkID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous link check: %w", err)
+	}
+	var previous *entity.LinkCheck
+	if len(previousChecks) > 0 {
+		previous = &previousChecks[0]
+	}
+
+	check := &entity.LinkCheck{
+		BookmarkID: bookmarkID,
+		Method:     http.MethodHead,
+		CheckedAt:  time.Now(),
+	}
+	link := sanitizeURL(bookmark.URL)
+
+	// Check with the same cookies and headers as the original fetch, so gated pages do not look changed
+	profile, err := findFetchProfile(ctx, s.configRepo, link)
+	if err != nil {
+		return nil, err
+	}
+	options, timeoutMs := applyFetchProfile(profile, output.FetchOptions{}, linkCheckTimeout)
+
+	response, err := s.httpFetcher.Head(ctx, link, timeoutMs, options)
+	if err != nil || needsGet(link, response, previous) {
+		// A server ignoring HEAD may still honour a conditional GET
+		if previous != nil && previous.ContentHash != nil {
+			options.ETag = stringValue(previous.ETag)
+			options.LastModified = stringValue(previous.LastModified)
+		}
+		check.Method = http.MethodGet
+		response, err = s.httpFetcher.Fetch(ctx, link, timeoutMs, options)
+	}
+	if err != nil {
+		message := err.Error()
+		check.Status = entity.LinkError
+		check.Error = &message
+		return s.saveLinkCheck(ctx, check)
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(response.Headers), &headers); err != nil {
+		return nil, fmt.Errorf("failed to parse headers: %w", err)
+	}
+
+	check.StatusCode = &response.StatusCode
+	if response.URL != "" && response.URL != link {
+		check.FinalURL = &response.URL
+	}
+	if etag := getHeader(headers, "etag"); etag != "" {
+		check.ETag = &etag
+	}
+	if lastModified := getHeader(headers, "last-modified"); lastModified != "" {
+		check.LastModified = &lastModified
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone:
+		check.Status = entity.LinkGone
+	case response.StatusCode >= 400:
+		message := fmt.Sprintf("HTTP status %d", response.StatusCode)
+		check.Status = entity.LinkError
+		check.Error = &message
+	case check.FinalURL != nil && siteOf(*check.FinalURL) != siteOf(link):
+		check.Status = entity.LinkRedirected
+		if parkingHosts[siteOf(*check.FinalURL)] {
+			check.Status = entity.LinkParked
+		}
+	case previous != nil && (check.Method == http.MethodHead || response.StatusCode == http.StatusNotModified):
+		// The validators match the previous check, so the page was not downloaded again. A 304 without
+		// a previous check was not asked for and is classified like any other GET response
+		if check.ETag == nil {
+			check.ETag = previous.ETag
+		}
+		if check.LastModified == nil {
+			check.LastModified = previous.LastModified
+		}
+		check.Status = entity.LinkOK
+		check.ContentHash = previous.ContentHash
+		if previous.Status == entity.LinkParked {
+			check.Status = entity.LinkParked
+		}
+	default:
+		if err := s.compareContent(ctx, bookmark, check, getContentType(headers), response.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.saveLinkCheck(ctx, check)
+}
+
+func (s *BookmarkLinkCheckService) GetDueLinkChecks(ctx context.Context, limit int32) ([]entity.Bookmark, error) {
+	bookmarks, err := s.repo.GetBookmarksDueForLinkCheck(ctx, time.Now().Add(-s.interval), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmarks due for a link check: %w", err)
+	}
+	return bookmarks, nil
+}
+
+func (s *BookmarkLinkCheckService) ListLinkIssues(ctx context.Context, filters entity.LinkIssueFilters) (*input.PaginatedResponse[entity.LinkIssue], error) {
+	page := filters.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := filters.Limit
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	var status *string
+	if filters.Status != nil {
+		value := string(*filters.Status)
+		status = &value
+	}
+
+	issues, err := s.repo.ListLinkIssues(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link issues: %w", err)
+	}
+
+	total, err := s.repo.CountLinkIssues(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count link issues: %w", err)
+	}
+
+	totalPages := int32((total + int64(limit) - 1) / int64(limit))
+
+	return &input.PaginatedResponse[entity.LinkIssue]{
+		Data:       issues,
+		Total:      total,
+		Page:       page,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// compareContent classifies a downloaded page by its body, diffing its readable text against the last version seen
+func (s *BookmarkLinkCheckService) compareContent(ctx context.Context, bookmark *entity.Bookmark, check *entity.LinkCheck, contentType string, content []byte) error {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	check.ContentHash = &hash
+	check.Status = entity.LinkOK
+
+	strategy := documentStrategy(contentType, bookmark.URL, content)
+	if strategy == "" {
+		return nil
+	}
+
+	extracted, err := extractDocument(ctx, s.contentProcessor, strategy, contentType, bookmark.URL, content)
+	if err != nil {
+		// The link still answers, so an unreadable body is noted rather than failing the check
+		message := fmt.Sprintf("failed to process with %s: %v", strategy, err)
+		check.Error = &message
+		return nil
+	}
+
+	if strategy == strategyReader && isParkedPage(content, countWords(extracted.Content)) {
+		check.Status = entity.LinkParked
+		return nil
+	}
+
+	previous, err := s.repo.GetLatestLinkCheckContent(ctx, bookmark.BookmarkID)
+	if err != nil {
+		return fmt.Errorf("failed to get previous link check content: %w", err)
+	}
+	if previous == nil {
+		// The first check compares against the content saved by the pipeline
+		previous, err = s.repo.GetDocumentContent(ctx, bookmark.BookmarkID)
+		if err != nil {
+			return fmt.Errorf("failed to get processed content: %w", err)
+		}
+	}
+	if previous == nil {
+		check.Content = &extracted.Content
+		return nil
+	}
+
+	ratio, diff := diffLines(*previous, extracted.Content)
+	check.ChangeRatio = &ratio
+	if ratio > 0 {
+		check.Content = &extracted.Content
+	}
+	if diff != "" {
+		check.Diff = &diff
+	}
+	if ratio >= linkChangeThreshold {
+		check.Status = entity.LinkChanged
+	}
+
+	return nil
+}
+
+func (s *BookmarkLinkCheckService) saveLinkCheck(ctx context.Context, check *entity.LinkCheck) (*entity.LinkCheck, error) {
+	if err := s.repo.InsertLinkCheck(ctx, check); err != nil {
+		return nil, fmt.Errorf("failed to store link check: %w", err)
+	}
+	return check, nil
+}
+
+// needsGet reports whether a HEAD response leaves the state of the link open, so the page has to be downloaded
+func needsGet(link string, head *output.FetchResponse, previous *entity.LinkCheck) bool {
+	switch {
+	case head.StatusCode >= 400:
+		// Plenty of servers reject or mishandle HEAD, so error statuses are confirmed with a GET
+		return true
+	case head.URL != "" && siteOf(head.URL) != siteOf(link):
+		return false
+	case previous == nil || previous.ContentHash == nil:
+		return true
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(head.Headers), &headers); err != nil {
+		return true
+	}
+	etag, lastModified := getHeader(headers, "etag"), getHeader(headers, "last-modified")
+	if etag == "" && lastModified == "" {
+		return true
+	}
+	return etag != stringValue(previous.ETag) || lastModified != stringValue(previous.LastModified)
+}
+
+// siteOf returns the registrable domain of a URL, such as example.co.uk for https://www.example.co.uk/page
+func siteOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
+
+// isParkedPage reports whether a page looks like a parked or for-sale domain: a parking phrase with little text around it
+func isParkedPage(content []byte, words int32) bool {
+	if words > maxParkedWords {
+		return false
+	}
+	page := strings.ToLower(string(content))
+	for _, marker := range parkingMarkers {
+		if strings.Contains(page, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffLines compares two texts line by line, ignoring blank lines and moved lines. It returns the share of
+// lines that differ, and the removed lines prefixed with "- " followed by the added lines prefixed with "+ "
+func diffLines(previous, current string) (float64, string) {
+	oldLines, newLines := contentLines(previous), contentLines(current)
+	if len(oldLines)+len(newLines) == 0 {
+		return 0, ""
+	}
+
+	newCounts := make(map[string]int, len(newLines))
+	for _, line := range newLines {
+		newCounts[line]++
+	}
+	oldCounts := make(map[string]int, len(oldLines))
+	for _, line := range oldLines {
+		oldCounts[line]++
+	}
+
+	var changes []string
+	common := 0
+	for _, line := range oldLines {
+		if newCounts[line] > 0 {
+			newCounts[line]--
+			common++
+			continue
+		}
+		changes = append(changes, "- "+line)
+	}
+	for _, line := range newLines {
+		if oldCounts[line] > 0 {
+			oldCounts[line]--
+			continue
+		}
+		changes = append(changes, "+ "+line)
+	}
+
+	ratio := 1 - float64(2*common)/float64(len(oldLines)+len(newLines))
+	if len(changes) > maxDiffLines {
+		changes = append(changes[:maxDiffLines], fmt.Sprintf("... %d more changed lines", len(changes)-maxDiffLines))
+	}
+	return ratio, strings.Join(changes, "\n")
+}
+
+// contentLines splits text into trimmed, non-blank lines
+func contentLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
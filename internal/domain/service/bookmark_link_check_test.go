@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+func TestDiffLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		previous string
+		current  string
+		ratio    float64
+		diff     string
+	}{
+		{name: "identical apart from blank lines", previous: "# Title\n\nOne\nTwo", current: "# Title\nOne\n\n  Two  \n", ratio: 0, diff: ""},
+		{name: "moved lines", previous: "One\nTwo\nThree", current: "Three\nOne\nTwo", ratio: 0, diff: ""},
+		{name: "one line replaced", previous: "One\nTwo\nThree\nFour", current: "One\nTwo\nThree\nFive", ratio: 0.25, diff: "- Four\n+ Five"},
+		{name: "rewritten", previous: "Old text", current: "New text\nMore text", ratio: 1, diff: "- Old text\n+ New text\n+ More text"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ratio, diff := diffLines(tc.previous, tc.current)
+			if ratio != tc.ratio {
+				t.Errorf("diffLines() ratio = %v, want %v", ratio, tc.ratio)
+			}
+			if diff != tc.diff {
+				t.Errorf("diffLines() diff = %q, want %q", diff, tc.diff)
+			}
+		})
+	}
+}
+
+func TestNeedsGet(t *testing.T) {
+	etag := `"v1"`
+	hash := "abc"
+	previous := &entity.LinkCheck{ETag: &etag, ContentHash: &hash}
+
+	testCases := []struct {
+		name     string
+		head     output.FetchResponse
+		previous *entity.LinkCheck
+		want     bool
+	}{
+		{name: "head rejected", head: output.FetchResponse{StatusCode: 405, Headers: "{}"}, previous: previous, want: true},
+		{name: "not found is confirmed", head: output.FetchResponse{StatusCode: 404, Headers: "{}"}, previous: previous, want: true},
+		{name: "redirected to another site", head: output.FetchResponse{StatusCode: 200, Headers: "{}", URL: "https://www.hugedomains.com/domain_profile.cfm?d=example"}, want: false},
+		{name: "redirected within the site", head: output.FetchResponse{StatusCode: 200, Headers: `{"Etag":"\"v1\""}`, URL: "https://www.example.com/post"}, previous: previous, want: false},
+		{name: "never downloaded", head: output.FetchResponse{StatusCode: 200, Headers: `{"Etag":"\"v1\""}`}, want: true},
+		{name: "validators unchanged", head: output.FetchResponse{StatusCode: 200, Headers: `{"Etag":"\"v1\""}`}, previous: previous, want: false},
+		{name: "validators changed", head: output.FetchResponse{StatusCode: 200, Headers: `{"Etag":"\"v2\""}`}, previous: previous, want: true},
+		{name: "no validators", head: output.FetchResponse{StatusCode: 200, Headers: "{}"}, previous: previous, want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := needsGet("https://example.com/post", &tc.head, tc.previous); got != tc.want {
+				t.Errorf("needsGet() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// wordsPerMinute is the average silent reading speed used for reading time estimates
+const wordsPerMinute = 230
+
+func (s *BookmarkService) ExtractBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) (metadata *entity.BookmarkMetadata, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageMetadata)
+	defer func() { run.finish(ctx, err) }()
+
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	metadata = &entity.BookmarkMetadata{
+		BookmarkID: bookmarkID,
+	}
+
+	// Imported bookmarks may have readable content without ever being fetched
+	fetchStatus, err := s.repo.GetLatestFetchStatus(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fetch status: %w", err)
+	}
+	if fetchStatus != nil && fetchStatus.StatusCode != nil && *fetchStatus.StatusCode < 400 {
+		httpResp, err := s.repo.GetLatestHttpResponse(ctx, bookmarkID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get http response: %w", err)
+		}
+
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(httpResp.Headers), &headers); err != nil {
+			return nil, fmt.Errorf("failed to parse headers: %w", err)
+		}
+
+		if documentStrategy(getContentType(headers), bookmark.URL, httpResp.Content) == strategyReader {
+			page, err := s.contentProcessor.ExtractMetadata(ctx, httpResp.Content, bookmark.URL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract metadata: %w", err)
+			}
+			metadata.PageMetadata = *page
+		}
+	}
+
+	document, err := s.repo.GetExtractedDocument(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed content: %w", err)
+	}
+	if document != nil {
+		metadata.WordCount = countWords(document.Content)
+		metadata.ReadingTimeMinutes = readingTime(metadata.WordCount)
+		// Imported content was never extracted here, so it has no extractor
+		if document.Extractor != "" {
+			metadata.Extractor = &document.Extractor
+			metadata.ExtractorMetadata = document.Metadata
+		}
+	}
+
+	if err := s.repo.SaveBookmarkMetadata(ctx, metadata); err != nil {
+		return nil, fmt.Errorf("failed to store metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// countWords counts the words of extracted content, leaving out the title and attribution lines
+// added by the content processor and markdown punctuation such as list markers
+func countWords(content string) int32 {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# ") {
+		lines = lines[1:]
+		if len(lines) > 0 && strings.HasPrefix(lines[0], "(extracted from **") {
+			lines = lines[1:]
+		}
+	}
+
+	var words int32
+	for _, field := range strings.Fields(strings.Join(lines, "\n")) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	return words
+}
+
+// readingTime estimates the minutes needed to read a number of words, rounding up
+func readingTime(words int32) int32 {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountWords(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    int32
+		minutes int32
+	}{
+		{name: "reader layout", content: "# Title words\n(extracted from **https://example.com/**)\n\nOne two three.\n\n- four\n- five", want: 5, minutes: 1},
+		{name: "markdown punctuation", content: "## Heading\n\n---\n\n* item -- `code`", want: 3, minutes: 1},
+		{name: "empty", content: "# Only a title", want: 0, minutes: 0},
+		{name: "long", content: strings.Repeat("word ", 461), want: 461, minutes: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := countWords(tc.content)
+			if got != tc.want {
+				t.Errorf("countWords() = %d, want %d", got, tc.want)
+			}
+			if minutes := readingTime(got); minutes != tc.minutes {
+				t.Errorf("readingTime(%d) = %d, want %d", got, minutes, tc.minutes)
+			}
+		})
+	}
+}
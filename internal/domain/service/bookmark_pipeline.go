@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+	"garden3/internal/port/output"
+)
+
+const maxPipelineBackoff = 2 * time.Minute
+
+// BookmarkPipelineService implements the BookmarkPipelineUseCase interface
+// It chains the individual bookmark use cases into one ingestion run
+type BookmarkPipelineService struct {
+	bookmarks   input.BookmarkUseCase
+	repo        output.BookmarkRepository
+	maxAttempts int
+	baseBackoff time.Duration
+}
+
+// NewBookmarkPipelineService creates a new bookmark pipeline service
+func NewBookmarkPipelineService(bookmarks input.BookmarkUseCase, repo output.BookmarkRepository, maxAttempts int, baseBackoff time.Duration) *BookmarkPipelineService {
+	if maxAttempts < 1 {
+		maxAttempts = 3
+	}
+	if baseBackoff <= 0 {
+		baseBackoff = 2 * time.Second
+	}
+	return &BookmarkPipelineService{
+		bookmarks:   bookmarks,
+		repo:        repo,
+		maxAttempts: maxAttempts,
+		baseBackoff: baseBackoff,
+	}
+}
+
+// permanentError marks a stage failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (s *BookmarkPipelineService) ProcessBookmark(ctx context.Context, bookmarkID uuid.UUID, from entity.PipelineStage) (*entity.PipelineResult, error) {
+	if from == "" {
+		from = entity.StageFetch
+	}
+
+	result := &entity.PipelineResult{
+		BookmarkID: bookmarkID,
+	}
+
+	if from == entity.StageFetch {
+		archived, err := s.repo.HasArchivedContent(ctx, bookmarkID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for archived content: %w", err)
+		}
+		if archived {
+			// Imported bookmarks can carry their article text, which must not be replaced by a fetch
+			for _, stage := range []entity.PipelineStage{entity.StageFetch, entity.StageReader} {
+				result.Stages = append(result.Stages, entity.PipelineStageResult{
+					Stage:   stage,
+					Skipped: true,
+					Message: "Archived content imported",
+				})
+			}
+			from = entity.StageMetadata
+		}
+	}
+
+	started := false
+	for _, stage := range entity.PipelineStages {
+		if stage == from {
+			started = true
+		}
+		if !started {
+			continue
+		}
+
+		stageResult, proceed := s.runStage(ctx, bookmarkID, stage)
+		result.Stages = append(result.Stages, stageResult)
+
+		if stageResult.Error != nil {
+			return result, fmt.Errorf("stage %s failed after %d attempts: %s", stage, stageResult.Attempts, *stageResult.Error)
+		}
+		if !proceed {
+			break
+		}
+	}
+
+	if !started {
+		return nil, fmt.Errorf("unknown pipeline stage: %s", from)
+	}
+
+	return result, nil
+}
+
+func (s *BookmarkPipelineService) GetBackfillJobs(ctx context.Context) ([]entity.PipelineJob, error) {
+	missingHTTP, err := s.bookmarks.GetMissingHttpResponses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	missingReader, err := s.bookmarks.GetMissingReaderContent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	noReader := make(map[uuid.UUID]bool, len(missingReader))
+	for _, bookmark := range missingReader {
+		noReader[bookmark.BookmarkID] = true
+	}
+
+	seen := make(map[uuid.UUID]bool, len(missingHTTP))
+	jobs := make([]entity.PipelineJob, 0, len(missingHTTP)+len(missingReader))
+
+	for _, bookmark := range missingHTTP {
+		seen[bookmark.BookmarkID] = true
+		if !noReader[bookmark.BookmarkID] {
+			// Reader content without a response was imported, so there is nothing to fetch
+			continue
+		}
+		jobs = append(jobs, entity.PipelineJob{
+			BookmarkID: bookmark.BookmarkID,
+			From:       entity.StageFetch,
+		})
+	}
+
+	for _, bookmark := range missingReader {
+		if seen[bookmark.BookmarkID] {
+			continue
+		}
+		jobs = append(jobs, entity.PipelineJob{
+			BookmarkID: bookmark.BookmarkID,
+			From:       entity.StageReader,
+		})
+	}
+
+	return jobs, nil
+}
+
+// runStage executes a stage with retries, returning whether the pipeline should continue
+func (s *BookmarkPipelineService) runStage(ctx context.Context, bookmarkID uuid.UUID, stage entity.PipelineStage) (entity.PipelineStageResult, bool) {
+	start := time.Now()
+	result := entity.PipelineStageResult{
+		Stage: stage,
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		result.Attempts = attempt
+
+		proceed, message, err := s.executeStage(ctx, bookmarkID, stage)
+		if err == nil {
+			result.Duration = time.Since(start)
+			result.Message = message
+			result.Skipped = !proceed
+			return result, proceed
+		}
+
+		lastErr = err
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt == s.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			lastErr = ctx.Err()
+			attempt = s.maxAttempts
+		case <-time.After(s.backoff(attempt)):
+		}
+	}
+
+	errMsg := lastErr.Error()
+	result.Error = &errMsg
+	result.Duration = time.Since(start)
+	return result, false
+}
+
+// executeStage runs one stage once, returning whether later stages can run
+func (s *BookmarkPipelineService) executeStage(ctx context.Context, bookmarkID uuid.UUID, stage entity.PipelineStage) (bool, string, error) {
+	switch stage {
+	case entity.StageFetch:
+		result, err := s.bookmarks.FetchBookmarkContent(ctx, bookmarkID)
+		if errors.Is(err, entity.ErrFetchTooLarge) || errors.Is(err, entity.ErrFetchDisallowed) {
+			return false, "", permanentError{err}
+		}
+		if err != nil {
+			return false, "", err
+		}
+		if result.StatusCode >= 400 {
+			return false, "", permanentError{fmt.Errorf("unexpected HTTP status %d", result.StatusCode)}
+		}
+		return true, result.Message, nil
+
+	case entity.StageReader:
+		result, err := s.bookmarks.ProcessWithReader(ctx, bookmarkID)
+		if err != nil {
+			return false, "", err
+		}
+		// A nil content means the response was not processable, so nothing downstream can run
+		return result.Content != nil, result.Message, nil
+
+	case entity.StageMetadata:
+		result, err := s.bookmarks.ExtractBookmarkMetadata(ctx, bookmarkID)
+		if err != nil {
+			return false, "", err
+		}
+		return true, fmt.Sprintf("%d words", result.WordCount), nil
+
+	case entity.StageTitle:
+		result, err := s.bookmarks.GetBookmarkTitle(ctx, bookmarkID)
+		if err != nil {
+			return false, "", err
+		}
+		if result.Title != nil {
+			return true, *result.Title, nil
+		}
+		return true, "", nil
+
+	case entity.StageChunkedReader:
+		result, err := s.bookmarks.CreateEmbeddingChunks(ctx, bookmarkID)
+		if err != nil {
+			return false, "", err
+		}
+		if result.Warning != nil {
+			return true, *result.Warning, nil
+		}
+		return true, fmt.Sprintf("%d chunks embedded", len(result.IDs)), nil
+
+	case entity.StageSummaryReader:
+		if _, err := s.bookmarks.CreateSummaryEmbedding(ctx, bookmarkID); err != nil {
+			return false, "", err
+		}
+		return true, "", nil
+
+	case entity.StageQAPassage:
+		result, err := s.bookmarks.GenerateBookmarkQuestions(ctx, bookmarkID, entity.GenerateQuestionsInput{})
+		if err != nil {
+			return false, "", err
+		}
+		return true, fmt.Sprintf("%d questions stored with prompt %s", len(result.Questions), result.PromptVersion), nil
+
+	case entity.StageArchive:
+		result, err := s.bookmarks.ArchiveBookmark(ctx, bookmarkID)
+		if errors.Is(err, entity.ErrArchiveTooLarge) || errors.Is(err, entity.ErrArchiveStoreFull) {
+			return false, "", permanentError{err}
+		}
+		if err != nil {
+			return false, "", err
+		}
+		if result == nil {
+			return true, "", nil
+		}
+		return true, fmt.Sprintf("%d resources archived, %d failed", result.ResourceCount, result.FailedResources), nil
+	}
+
+	return false, "", permanentError{fmt.Errorf("unknown pipeline stage: %s", stage)}
+}
+
+// backoff returns the exponential delay before the next attempt
+func (s *BookmarkPipelineService) backoff(attempt int) time.Duration {
+	delay := s.baseBackoff << (attempt - 1)
+	if delay <= 0 || delay > maxPipelineBackoff {
+		return maxPipelineBackoff
+	}
+	return delay
+}
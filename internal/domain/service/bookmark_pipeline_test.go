@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/input"
+	"garden3/internal/port/output"
+)
+
+// pipelineBookmarks is a bookmark use case recording the stages the pipeline runs, of which only the
+// pipeline stages and backfill queries are implemented
+type pipelineBookmarks struct {
+	input.BookmarkUseCase
+	calls         []entity.PipelineStage
+	failures      map[entity.PipelineStage][]error
+	fetchStatus   int32
+	noContent     bool
+	missingHTTP   []entity.Bookmark
+	missingReader []entity.Bookmark
+}
+
+// run records a call of stage and returns the next failure queued for it, if any
+func (b *pipelineBookmarks) run(stage entity.PipelineStage) error {
+	b.calls = append(b.calls, stage)
+	if len(b.failures[stage]) == 0 {
+		return nil
+	}
+	err := b.failures[stage][0]
+	b.failures[stage] = b.failures[stage][1:]
+	return err
+}
+
+func (b *pipelineBookmarks) FetchBookmarkContent(ctx context.Context, bookmarkID uuid.UUID) (*entity.FetchResult, error) {
+	if err := b.run(entity.StageFetch); err != nil {
+		return nil, err
+	}
+	status := b.fetchStatus
+	if status == 0 {
+		status = 200
+	}
+	return &entity.FetchResult{StatusCode: status}, nil
+}
+
+func (b *pipelineBookmarks) ProcessWithReader(ctx context.Context, bookmarkID uuid.UUID) (*entity.ProcessingResult, error) {
+	if err := b.run(entity.StageReader); err != nil {
+		return nil, err
+	}
+	if b.noContent {
+		return &entity.ProcessingResult{Message: "No content"}, nil
+	}
+	content := "text"
+	return &entity.ProcessingResult{Content: &content}, nil
+}
+
+func (b *pipelineBookmarks) ExtractBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkMetadata, error) {
+	if err := b.run(entity.StageMetadata); err != nil {
+		return nil, err
+	}
+	return &entity.BookmarkMetadata{}, nil
+}
+
+func (b *pipelineBookmarks) GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (*entity.TitleExtractionResult, error) {
+	if err := b.run(entity.StageTitle); err != nil {
+		return nil, err
+	}
+	return &entity.TitleExtractionResult{}, nil
+}
+
+func (b *pipelineBookmarks) CreateEmbeddingChunks(ctx context.Context, bookmarkID uuid.UUID) (*entity.EmbeddingResult, error) {
+	if err := b.run(entity.StageChunkedReader); err != nil {
+		return nil, err
+	}
+	return &entity.EmbeddingResult{}, nil
+}
+
+func (b *pipelineBookmarks) CreateSummaryEmbedding(ctx context.Context, bookmarkID uuid.UUID) (*entity.SummaryEmbeddingResult, error) {
+	if err := b.run(entity.StageSummaryReader); err != nil {
+		return nil, err
+	}
+	return &entity.SummaryEmbeddingResult{}, nil
+}
+
+func (b *pipelineBookmarks) GenerateBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID, input entity.GenerateQuestionsInput) (*entity.GenerateQuestionsResult, error) {
+	if err := b.run(entity.StageQAPassage); err != nil {
+		return nil, err
+	}
+	return &entity.GenerateQuestionsResult{}, nil
+}
+
+func (b *pipelineBookmarks) ArchiveBookmark(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkArchive, error) {
+	if err := b.run(entity.StageArchive); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *pipelineBookmarks) GetMissingHttpResponses(ctx context.Context) ([]entity.Bookmark, error) {
+	return b.missingHTTP, nil
+}
+
+func (b *pipelineBookmarks) GetMissingReaderContent(ctx context.Context) ([]entity.Bookmark, error) {
+	return b.missingReader, nil
+}
+
+// pipelineRepository is a bookmark repository that only reports whether archived content was imported
+type pipelineRepository struct {
+	output.BookmarkRepository
+	archived bool
+}
+
+func (r *pipelineRepository) HasArchivedContent(ctx context.Context, bookmarkID uuid.UUID) (bool, error) {
+	return r.archived, nil
+}
+
+func TestProcessBookmark(t *testing.T) {
+	transient := errors.New("connection reset")
+	after := func(from entity.PipelineStage) []entity.PipelineStage {
+		for i, stage := range entity.PipelineStages {
+			if stage == from {
+				return entity.PipelineStages[i:]
+			}
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		name         string
+		from         entity.PipelineStage
+		archived     bool
+		failures     map[entity.PipelineStage][]error
+		fetchStatus  int32
+		noContent    bool
+		wantCalls    []entity.PipelineStage
+		wantSkipped  []entity.PipelineStage
+		wantAttempts map[entity.PipelineStage]int
+		wantErr      bool
+	}{
+		{
+			name:      "all stages in order",
+			wantCalls: entity.PipelineStages,
+		},
+		{
+			name:      "starts at the given stage",
+			from:      entity.StageReader,
+			wantCalls: after(entity.StageReader),
+		},
+		{
+			name:        "imported content skips fetch and reader",
+			archived:    true,
+			wantCalls:   after(entity.StageMetadata),
+			wantSkipped: []entity.PipelineStage{entity.StageFetch, entity.StageReader},
+		},
+		{
+			name:         "transient failures are retried",
+			failures:     map[entity.PipelineStage][]error{entity.StageTitle: {transient, transient}},
+			wantCalls:    append([]entity.PipelineStage{entity.StageFetch, entity.StageReader, entity.StageMetadata, entity.StageTitle, entity.StageTitle}, after(entity.StageTitle)...),
+			wantAttempts: map[entity.PipelineStage]int{entity.StageTitle: 3, entity.StageMetadata: 1},
+		},
+		{
+			name:         "retries run out",
+			failures:     map[entity.PipelineStage][]error{entity.StageMetadata: {transient, transient, transient}},
+			wantCalls:    []entity.PipelineStage{entity.StageFetch, entity.StageReader, entity.StageMetadata, entity.StageMetadata, entity.StageMetadata},
+			wantAttempts: map[entity.PipelineStage]int{entity.StageMetadata: 3},
+			wantErr:      true,
+		},
+		{
+			name:         "disallowed fetch is not retried",
+			failures:     map[entity.PipelineStage][]error{entity.StageFetch: {entity.ErrFetchDisallowed}},
+			wantCalls:    []entity.PipelineStage{entity.StageFetch},
+			wantAttempts: map[entity.PipelineStage]int{entity.StageFetch: 1},
+			wantErr:      true,
+		},
+		{
+			name:         "error status is not retried",
+			fetchStatus:  404,
+			wantCalls:    []entity.PipelineStage{entity.StageFetch},
+			wantAttempts: map[entity.PipelineStage]int{entity.StageFetch: 1},
+			wantErr:      true,
+		},
+		{
+			name:        "no reader content stops the pipeline",
+			noContent:   true,
+			wantCalls:   []entity.PipelineStage{entity.StageFetch, entity.StageReader},
+			wantSkipped: []entity.PipelineStage{entity.StageReader},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bookmarks := &pipelineBookmarks{failures: tc.failures, fetchStatus: tc.fetchStatus, noContent: tc.noContent}
+			s := NewBookmarkPipelineService(bookmarks, &pipelineRepository{archived: tc.archived}, 3, time.Millisecond)
+
+			result, err := s.ProcessBookmark(context.Background(), uuid.New(), tc.from)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ProcessBookmark() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(bookmarks.calls, tc.wantCalls) {
+				t.Errorf("ran %v, want %v", bookmarks.calls, tc.wantCalls)
+			}
+
+			var skipped []entity.PipelineStage
+			for _, stage := range result.Stages {
+				if stage.Skipped {
+					skipped = append(skipped, stage.Stage)
+				}
+				if want, ok := tc.wantAttempts[stage.Stage]; ok && stage.Attempts != want {
+					t.Errorf("stage %s took %d attempts, want %d", stage.Stage, stage.Attempts, want)
+				}
+			}
+			if !reflect.DeepEqual(skipped, tc.wantSkipped) {
+				t.Errorf("skipped %v, want %v", skipped, tc.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestProcessBookmarkUnknownStage(t *testing.T) {
+	s := NewBookmarkPipelineService(&pipelineBookmarks{}, &pipelineRepository{}, 3, time.Millisecond)
+	if _, err := s.ProcessBookmark(context.Background(), uuid.New(), "lynx"); err == nil {
+		t.Error("expected an error for a stage outside the pipeline")
+	}
+}
+
+func TestPipelineBackoff(t *testing.T) {
+	s := NewBookmarkPipelineService(&pipelineBookmarks{}, &pipelineRepository{}, 3, 2*time.Second)
+
+	testCases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 2 * time.Second},
+		{attempt: 2, want: 4 * time.Second},
+		{attempt: 3, want: 8 * time.Second},
+		{attempt: 7, want: maxPipelineBackoff},
+		{attempt: 80, want: maxPipelineBackoff},
+	}
+
+	for _, tc := range testCases {
+		if got := s.backoff(tc.attempt); got != tc.want {
+			t.Errorf("backoff(%d) = %s, want %s", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestGetBackfillJobs(t *testing.T) {
+	unfetched, imported, unread := uuid.New(), uuid.New(), uuid.New()
+	bookmarks := &pipelineBookmarks{
+		missingHTTP:   []entity.Bookmark{{BookmarkID: unfetched}, {BookmarkID: imported}},
+		missingReader: []entity.Bookmark{{BookmarkID: unfetched}, {BookmarkID: unread}},
+	}
+	s := NewBookmarkPipelineService(bookmarks, &pipelineRepository{}, 3, time.Millisecond)
+
+	jobs, err := s.GetBackfillJobs(context.Background())
+	if err != nil {
+		t.Fatalf("GetBackfillJobs() error = %v", err)
+	}
+
+	want := []entity.PipelineJob{
+		{BookmarkID: unfetched, From: entity.StageFetch},
+		{BookmarkID: unread, From: entity.StageReader},
+	}
+	if !reflect.DeepEqual(jobs, want) {
+		t.Errorf("GetBackfillJobs() = %v, want %v", jobs, want)
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// maxRegenerationAttempts is how often replacing a rejection may fail before the worker stops picking it up
+const maxRegenerationAttempts = 3
+
+func (s *BookmarkService) GetRejectedQuestionBookmarks(ctx context.Context, limit int32) ([]entity.QARegenerationCandidate, error) {
+	candidates, err := s.repo.ListBookmarksWithRejectedQuestions(ctx, maxRegenerationAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookmarks with rejected questions: %w", err)
+	}
+	return candidates, nil
+}
+
+func (s *BookmarkService) RegenerateRejectedQuestions(ctx context.Context, bookmarkID uuid.UUID) (result *entity.QARegenerationResult, err error) {
+	run := s.beginStageRun(bookmarkID, entity.StageQAPassage)
+	defer func() { run.finish(ctx, err) }()
+
+	// Concurrent runs would replace the same rejections twice, so a run waiting here lists only those the
+	// previous one left. Generation takes the same lock
+	unlock, err := s.repo.LockBookmarkQuestions(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock questions: %w", err)
+	}
+	defer unlock()
+
+	rejections, err := s.repo.ListRejectedQuestions(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rejected questions: %w", err)
+	}
+	if len(rejections) == 0 {
+		run.skip("No rejected questions to replace")
+		return nil, nil
+	}
+
+	result, err = s.replaceRejectedQuestions(ctx, bookmarkID, rejections)
+	if err != nil {
+		if _, recordErr := s.recordQARegeneration(ctx, bookmarkID, rejections, nil, err); recordErr != nil {
+			log.Printf("Failed to record failed question regeneration for bookmark %s: %v", bookmarkID, recordErr)
+		}
+		return nil, err
+	}
+
+	observationID, err := s.recordQARegeneration(ctx, bookmarkID, rejections, result, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.ObservationID = observationID
+
+	run.note(fmt.Sprintf("Replaced %d rejected questions", len(rejections)))
+	return result, nil
+}
+
+func (s *BookmarkService) replaceRejectedQuestions(ctx context.Context, bookmarkID uuid.UUID, rejections []entity.QARejection) (*entity.QARegenerationResult, error) {
+	processedContent, err := s.repo.GetDocumentContent(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed content: %w", err)
+	}
+	if processedContent == nil {
+		return nil, fmt.Errorf("no processed content found")
+	}
+
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	content := *processedContent
+	if len(content) > 15000 {
+		content = strings.ToValidUTF8(content[:15000], "")
+	}
+
+	rejected, rejectedIDs, rejectedContents := rejectedQuestions(rejections)
+	count := min(max(len(rejected), 1), maxQuestionCount)
+
+	pairs, err := s.aiService.GenerateQuestions(ctx, content, bookmark.URL, count, rejected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate questions: %w", err)
+	}
+
+	model := s.embeddingsService.Model()
+	questions, err := s.embedQuestionPairs(ctx, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	promptVersion := s.aiService.QuestionPromptVersion()
+	extra, err := json.Marshal(map[string]any{
+		"prompt_version":   promptVersion,
+		"model":            s.aiService.Model(),
+		"generated_at":     time.Now().Format(time.RFC3339),
+		"regenerated_from": rejectionIDs(rejections),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal question metadata: %w", err)
+	}
+
+	added, removed, err := s.repo.ReplaceRejectedQuestions(ctx, bookmarkID, string(entity.StageQAPassage), model, extra, questions, rejectedIDs, rejectedContents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store questions: %w", err)
+	}
+
+	stored := make([]entity.BookmarkQuestion, len(added))
+	for i, id := range added {
+		stored[i] = entity.BookmarkQuestion{
+			ID:      id,
+			Content: questions[i].Text,
+		}
+	}
+
+	return &entity.QARegenerationResult{
+		BookmarkID:        bookmarkID,
+		PromptVersion:     promptVersion,
+		Rejections:        rejections,
+		RemovedReferences: removed,
+		Questions:         stored,
+	}, nil
+}
+
+// recordQARegeneration stores a qa-regeneration observation listing the rejections it handled, linked to the
+// first of them. Rejections recorded with a succeeded regeneration are not picked up again
+func (s *BookmarkService) recordQARegeneration(ctx context.Context, bookmarkID uuid.UUID, rejections []entity.QARejection, result *entity.QARegenerationResult, regenerationErr error) (uuid.UUID, error) {
+	data := map[string]any{
+		"bookmarkId":  bookmarkID.String(),
+		"feedbackIds": rejectionIDs(rejections),
+		"rejected":    rejections,
+		"model":       s.aiService.Model(),
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}
+	if regenerationErr != nil {
+		data["status"] = entity.RegenerationFailed
+		data["error"] = regenerationErr.Error()
+	} else {
+		data["status"] = entity.RegenerationSucceeded
+		data["promptVersion"] = result.PromptVersion
+		data["removedReferenceIds"] = result.RemovedReferences
+		data["questions"] = result.Questions
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to marshal observation: %w", err)
+	}
+
+	observationID, err := s.repo.CreateLinkedObservation(ctx, dataJSON, "qa-regeneration", "qa-regeneration", "regeneration,question,answer", bookmarkID, rejections[0].ObservationID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create observation: %w", err)
+	}
+	return observationID, nil
+}
+
+// rejectionIDs lists the observation IDs of rejections as stored in observation data
+func rejectionIDs(rejections []entity.QARejection) []string {
+	ids := make([]string, len(rejections))
+	for i, rejection := range rejections {
+		ids[i] = rejection.ObservationID.String()
+	}
+	return ids
+}
+
+// rejectedQuestions turns rejections into the pairs given to the model as negative examples, along with the
+// reference IDs and stored contents that identify the rejected references still in place
+func rejectedQuestions(rejections []entity.QARejection) ([]entity.QuestionAnswer, []uuid.UUID, []string) {
+	var pairs []entity.QuestionAnswer
+	var ids []uuid.UUID
+	var contents []string
+	seen := make(map[string]bool)
+
+	for _, rejection := range rejections {
+		if rejection.ReferenceID != nil {
+			ids = append(ids, *rejection.ReferenceID)
+		}
+
+		question := strings.TrimSpace(rejection.Question)
+		answer := strings.TrimSpace(rejection.Answer)
+		if question == "" {
+			continue
+		}
+
+		// Feedback on retrieved passages carries the stored "question?\nanswer" content as the question,
+		// and its answer is the one given to the user rather than the rejected passage
+		if q, a, ok := strings.Cut(question, "\n"); ok {
+			question, answer = strings.TrimSpace(q), strings.TrimSpace(a)
+		}
+
+		pair := entity.QuestionAnswer{Question: question, Answer: answer}
+		content := questionContent(pair)
+		if seen[content] {
+			continue
+		}
+		seen[content] = true
+
+		pairs = append(pairs, pair)
+		contents = append(contents, content)
+		if raw := strings.TrimSpace(rejection.Question); raw != content && strings.Contains(raw, "\n") {
+			contents = append(contents, raw)
+		}
+	}
+	return pairs, ids, contents
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+func TestRejectedQuestions(t *testing.T) {
+	refID := uuid.New()
+	rejections := []entity.QARejection{
+		{ObservationID: uuid.New(), Kind: "delete", ReferenceID: &refID, Question: "What is Go", Answer: "A language"},
+		{ObservationID: uuid.New(), Kind: "downvote", Question: "What is Go?\nA language", Answer: "Go is a programming language"},
+		{ObservationID: uuid.New(), Kind: "trash", Question: "  ", Answer: "Nothing to show"},
+		{ObservationID: uuid.New(), Kind: "downvote", Question: "Why use Go?", Answer: "It is simple"},
+	}
+
+	pairs, ids, contents := rejectedQuestions(rejections)
+
+	wantPairs := []entity.QuestionAnswer{
+		{Question: "What is Go", Answer: "A language"},
+		{Question: "Why use Go?", Answer: "It is simple"},
+	}
+	if len(pairs) != len(wantPairs) {
+		t.Fatalf("rejectedQuestions() returned %d pairs, want %d", len(pairs), len(wantPairs))
+	}
+	for i, want := range wantPairs {
+		if pairs[i] != want {
+			t.Errorf("rejectedQuestions() pair %d = %+v, want %+v", i, pairs[i], want)
+		}
+	}
+
+	if len(ids) != 1 || ids[0] != refID {
+		t.Errorf("rejectedQuestions() ids = %v, want only %s", ids, refID)
+	}
+
+	wantContents := []string{"What is Go?\nA language", "Why use Go?\nIt is simple"}
+	if strings.Join(contents, "|") != strings.Join(wantContents, "|") {
+		t.Errorf("rejectedQuestions() contents = %q, want %q", contents, wantContents)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+const stageHistoryLimit = 50
+
+// stageRun tracks one execution of a processing stage so it can be recorded in the bookmark history
+type stageRun struct {
+	repo       output.BookmarkRepository
+	bookmarkID uuid.UUID
+	stage      entity.PipelineStage
+	startedAt  time.Time
+	status     entity.StageStatus
+	message    *string
+	failure    *string
+}
+
+func (s *BookmarkService) beginStageRun(bookmarkID uuid.UUID, stage entity.PipelineStage) *stageRun {
+	return &stageRun{
+		repo:       s.repo,
+		bookmarkID: bookmarkID,
+		stage:      stage,
+		startedAt:  time.Now(),
+		status:     entity.StageSucceeded,
+	}
+}
+
+// note attaches an informational message to the run
+func (r *stageRun) note(message string) {
+	r.message = &message
+}
+
+// skip marks the run as finished without producing anything for later stages
+func (r *stageRun) skip(message string) {
+	r.status = entity.StageSkipped
+	r.message = &message
+}
+
+// fail marks the run as failed even though the stage itself returned no error
+func (r *stageRun) fail(message string) {
+	r.status = entity.StageFailed
+	r.failure = &message
+}
+
+// finish records the run; recording errors are logged so they never mask the stage result
+func (r *stageRun) finish(ctx context.Context, err error) {
+	run := entity.StageRunInput{
+		BookmarkID: r.bookmarkID,
+		Stage:      r.stage,
+		Status:     r.status,
+		Message:    r.message,
+		Error:      r.failure,
+		StartedAt:  r.startedAt,
+		FinishedAt: time.Now(),
+	}
+	if err != nil {
+		errMsg := err.Error()
+		run.Status = entity.StageFailed
+		run.Error = &errMsg
+	}
+
+	if recordErr := r.repo.InsertStageRun(context.WithoutCancel(ctx), run); recordErr != nil {
+		log.Printf("Failed to record %s run for bookmark %s: %v", r.stage, r.bookmarkID, recordErr)
+	}
+}
+
+func (s *BookmarkService) GetBookmarkStatus(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkStatus, error) {
+	bookmark, err := s.repo.GetBookmark(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+
+	summaries, err := s.repo.GetStageRunSummaries(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage runs: %w", err)
+	}
+
+	artifacts, err := s.repo.GetStageArtifacts(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage artifacts: %w", err)
+	}
+
+	fetchStatus, err := s.repo.GetLatestFetchStatus(ctx, bookmarkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fetch status: %w", err)
+	}
+
+	history, err := s.repo.ListStageRuns(ctx, bookmarkID, stageHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage history: %w", err)
+	}
+
+	summaryByStage := make(map[entity.PipelineStage]entity.StageRunSummary, len(summaries))
+	for _, summary := range summaries {
+		summaryByStage[summary.Latest.Stage] = summary
+	}
+
+	artifactsByStage := make(map[entity.PipelineStage]entity.StageArtifacts, len(artifacts))
+	for _, artifact := range artifacts {
+		artifactsByStage[artifact.Stage] = artifact
+	}
+
+	stages := make([]entity.BookmarkStageStatus, 0, len(entity.StatusStages))
+	for _, stage := range entity.StatusStages {
+		status := entity.BookmarkStageStatus{
+			Stage:  stage,
+			Status: entity.StagePending,
+		}
+
+		artifact, hasArtifacts := artifactsByStage[stage]
+		if hasArtifacts {
+			status.Artifacts = artifact.Count
+		}
+
+		if summary, ok := summaryByStage[stage]; ok {
+			// Recorded runs are authoritative for the current state
+			status.Status = summary.Latest.Status
+			status.Attempts = summary.Attempts
+			status.Failures = summary.Failures
+			status.LastAttemptAt = &summary.Latest.StartedAt
+			status.LastSuccessAt = summary.LastSuccessAt
+			status.Message = summary.Latest.Message
+			status.Error = summary.Latest.Error
+		} else if hasArtifacts && artifact.Count > 0 {
+			// Stages run before history was recorded only leave their output behind
+			status.Status = entity.StageSucceeded
+			status.LastSuccessAt = artifact.LastProducedAt
+		}
+
+		if stage == entity.StageFetch && fetchStatus != nil {
+			status.StatusCode = fetchStatus.StatusCode
+			if status.LastAttemptAt == nil {
+				status.LastAttemptAt = fetchStatus.FetchDate
+			}
+			if _, ok := summaryByStage[stage]; !ok && fetchStatus.StatusCode != nil && *fetchStatus.StatusCode >= 400 {
+				// Fetches from before runs were recorded only left their response, and older failed ones a
+				// status-500 response with the error as body. The failed-stage filter of ListBookmarks and
+				// CountBookmarks applies the same rule
+				status.Status = entity.StageFailed
+				status.LastSuccessAt = nil
+				status.Error = fetchStatus.Error
+				if status.Error == nil {
+					errMsg := fmt.Sprintf("HTTP status %d", *fetchStatus.StatusCode)
+					status.Error = &errMsg
+				}
+			}
+		}
+
+		stages = append(stages, status)
+	}
+
+	return &entity.BookmarkStatus{
+		BookmarkID: bookmark.BookmarkID,
+		URL:        bookmark.URL,
+		Stages:     stages,
+		History:    history,
+	}, nil
+}
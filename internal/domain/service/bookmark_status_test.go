@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// statusRepository is a bookmark repository holding the fetch runs and latest response of one bookmark,
+// of which only the status methods are implemented
+type statusRepository struct {
+	output.BookmarkRepository
+	runs        []entity.StageRunSummary
+	artifacts   []entity.StageArtifacts
+	fetchStatus *entity.FetchStatus
+}
+
+func (r *statusRepository) GetBookmark(ctx context.Context, bookmarkID uuid.UUID) (*entity.Bookmark, error) {
+	return &entity.Bookmark{BookmarkID: bookmarkID}, nil
+}
+
+func (r *statusRepository) GetStageRunSummaries(ctx context.Context, bookmarkID uuid.UUID) ([]entity.StageRunSummary, error) {
+	return r.runs, nil
+}
+
+func (r *statusRepository) GetStageArtifacts(ctx context.Context, bookmarkID uuid.UUID) ([]entity.StageArtifacts, error) {
+	return r.artifacts, nil
+}
+
+func (r *statusRepository) GetLatestFetchStatus(ctx context.Context, bookmarkID uuid.UUID) (*entity.FetchStatus, error) {
+	return r.fetchStatus, nil
+}
+
+func (r *statusRepository) ListStageRuns(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.StageRun, error) {
+	return nil, nil
+}
+
+func TestGetBookmarkStatusFetch(t *testing.T) {
+	now := time.Now()
+	fetchRun := func(status entity.StageStatus) []entity.StageRunSummary {
+		return []entity.StageRunSummary{{Latest: entity.StageRun{Stage: entity.StageFetch, Status: status, StartedAt: now}, Attempts: 1}}
+	}
+	response := func(statusCode int32, fetchError *string) *entity.FetchStatus {
+		return &entity.FetchStatus{StatusCode: &statusCode, FetchDate: &now, Error: fetchError}
+	}
+	fetched := []entity.StageArtifacts{{Stage: entity.StageFetch, Count: 1, LastProducedAt: &now}}
+	fetchError := "connection refused"
+
+	// These cases match the bookmarks of the repository's failed-stage filter test
+	testCases := []struct {
+		name        string
+		runs        []entity.StageRunSummary
+		artifacts   []entity.StageArtifacts
+		fetchStatus *entity.FetchStatus
+		want        entity.StageStatus
+		wantError   string
+	}{
+		{name: "failed run", runs: fetchRun(entity.StageFailed), want: entity.StageFailed},
+		{name: "recovered run", runs: fetchRun(entity.StageSucceeded), want: entity.StageSucceeded},
+		{
+			name:        "error response after a successful run",
+			runs:        fetchRun(entity.StageSucceeded),
+			artifacts:   fetched,
+			fetchStatus: response(404, nil),
+			want:        entity.StageSucceeded,
+		},
+		{
+			name:        "legacy failed fetch",
+			artifacts:   fetched,
+			fetchStatus: response(500, &fetchError),
+			want:        entity.StageFailed,
+			wantError:   fetchError,
+		},
+		{
+			name:        "legacy error status",
+			artifacts:   fetched,
+			fetchStatus: response(403, nil),
+			want:        entity.StageFailed,
+			wantError:   "HTTP status 403",
+		},
+		{name: "legacy fetch", artifacts: fetched, fetchStatus: response(200, nil), want: entity.StageSucceeded},
+		{name: "never fetched", want: entity.StagePending},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &BookmarkService{repo: &statusRepository{runs: tc.runs, artifacts: tc.artifacts, fetchStatus: tc.fetchStatus}}
+
+			status, err := s.GetBookmarkStatus(context.Background(), uuid.New())
+			if err != nil {
+				t.Fatalf("GetBookmarkStatus() error = %v", err)
+			}
+
+			fetch := status.Stages[0]
+			if fetch.Stage != entity.StageFetch {
+				t.Fatalf("first stage is %s, want fetch", fetch.Stage)
+			}
+			if fetch.Status != tc.want {
+				t.Errorf("fetch status = %s, want %s", fetch.Status, tc.want)
+			}
+			if tc.wantError != "" && (fetch.Error == nil || *fetch.Error != tc.wantError) {
+				t.Errorf("fetch error = %v, want %q", fetch.Error, tc.wantError)
+			}
+		})
+	}
+}
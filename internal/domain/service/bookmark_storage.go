@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"garden3/internal/domain/entity"
+)
+
+// responseMigrationBatch is the number of stored responses moved per repository call
+const responseMigrationBatch = 50
+
+func (s *BookmarkService) MigrateHttpResponseStorage(ctx context.Context) (*entity.ResponseStorageMigration, error) {
+	result := &entity.ResponseStorageMigration{}
+
+	// Prune first so responses about to be deleted are not compressed for nothing
+	if s.responseRetention > 0 {
+		bookmarkIDs, err := s.repo.ListBookmarksOverResponseRetention(ctx, s.responseRetention)
+		if err != nil {
+			return result, fmt.Errorf("failed to list bookmarks over retention: %w", err)
+		}
+		for _, bookmarkID := range bookmarkIDs {
+			pruned, err := s.repo.PruneHttpResponses(ctx, bookmarkID, s.responseRetention)
+			if err != nil {
+				return result, fmt.Errorf("failed to prune http responses: %w", err)
+			}
+			result.Pruned += pruned
+		}
+	}
+
+	var after uuid.UUID
+	for {
+		moved, last, err := s.repo.MigrateInlineHttpResponses(ctx, after, responseMigrationBatch)
+		result.Migrated += moved
+		if err != nil {
+			return result, fmt.Errorf("failed to migrate http responses: %w", err)
+		}
+		if moved < responseMigrationBatch {
+			return result, nil
+		}
+		after = last
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"garden3/internal/port/output"
+)
+
+// storageRepository is a bookmark repository holding stored responses, of which only the storage
+// migration methods are implemented
+type storageRepository struct {
+	output.BookmarkRepository
+	overRetention []uuid.UUID
+	pruned        map[uuid.UUID]int
+	inline        int
+	moved         int
+	batches       []int
+	afters        []uuid.UUID
+	failAt        int
+}
+
+func (r *storageRepository) ListBookmarksOverResponseRetention(ctx context.Context, keep int) ([]uuid.UUID, error) {
+	return r.overRetention, nil
+}
+
+func (r *storageRepository) PruneHttpResponses(ctx context.Context, bookmarkID uuid.UUID, keep int) (int64, error) {
+	r.pruned[bookmarkID] = keep
+	return 3, nil
+}
+
+// responseID stands in for the ID of the nth inline response, in the order the migration pages through them
+func responseID(n int) uuid.UUID {
+	var id uuid.UUID
+	id[14], id[15] = byte(n>>8), byte(n)
+	return id
+}
+
+func (r *storageRepository) MigrateInlineHttpResponses(ctx context.Context, after uuid.UUID, limit int) (int, uuid.UUID, error) {
+	r.batches = append(r.batches, limit)
+	r.afters = append(r.afters, after)
+	moved := min(limit, r.inline)
+	if r.failAt > 0 && len(r.batches) == r.failAt {
+		moved = 1
+		r.inline -= moved
+		r.moved += moved
+		return moved, responseID(r.moved), errors.New("connection lost")
+	}
+	r.inline -= moved
+	r.moved += moved
+	return moved, responseID(r.moved), nil
+}
+
+func TestMigrateHttpResponseStorage(t *testing.T) {
+	testCases := []struct {
+		name         string
+		inline       int
+		failAt       int
+		wantBatches  int
+		wantMigrated int
+		wantErr      bool
+	}{
+		{name: "partial last batch", inline: 2*responseMigrationBatch + 7, wantBatches: 3, wantMigrated: 2*responseMigrationBatch + 7},
+		{name: "full last batch", inline: 2 * responseMigrationBatch, wantBatches: 3, wantMigrated: 2 * responseMigrationBatch},
+		{name: "nothing inline", wantBatches: 1},
+		{name: "failed batch", inline: 3 * responseMigrationBatch, failAt: 2, wantBatches: 2, wantMigrated: responseMigrationBatch + 1, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storageRepository{
+				overRetention: []uuid.UUID{uuid.New(), uuid.New()},
+				pruned:        map[uuid.UUID]int{},
+				inline:        tc.inline,
+				failAt:        tc.failAt,
+			}
+			s := &BookmarkService{repo: repo, responseRetention: 5}
+
+			result, err := s.MigrateHttpResponseStorage(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("MigrateHttpResponseStorage() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(repo.batches) != tc.wantBatches || result.Migrated != tc.wantMigrated {
+				t.Errorf("migrated %d responses in %d batches, want %d in %d", result.Migrated, len(repo.batches), tc.wantMigrated, tc.wantBatches)
+			}
+			for i, limit := range repo.batches {
+				if limit != responseMigrationBatch {
+					t.Errorf("batch of %d, want %d", limit, responseMigrationBatch)
+				}
+				if want := responseID(i * responseMigrationBatch); repo.afters[i] != want {
+					t.Errorf("batch %d started after %s, want %s", i, repo.afters[i], want)
+				}
+			}
+			if result.Pruned != 6 || len(repo.pruned) != 2 {
+				t.Errorf("pruned %d responses of %d bookmarks, want 6 of 2", result.Pruned, len(repo.pruned))
+			}
+			for bookmarkID, keep := range repo.pruned {
+				if keep != 5 {
+					t.Errorf("bookmark %s pruned to %d responses, want 5", bookmarkID, keep)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+func TestCanonicalizeURL(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "lowercases scheme and host",
+			input: "HTTPS://Example.COM/Path",
+			want:  "https://example.com/Path",
+		},
+		{
+			name:  "adds root path",
+			input: "https://example.com",
+			want:  "https://example.com/",
+		},
+		{
+			name:  "strips trailing slash",
+			input: "https://example.com/blog/post/",
+			want:  "https://example.com/blog/post",
+		},
+		{
+			name:  "strips default port",
+			input: "http://example.com:80/a",
+			want:  "http://example.com/a",
+		},
+		{
+			name:  "keeps non-default port",
+			input: "https://example.com:8443/a",
+			want:  "https://example.com:8443/a",
+		},
+		{
+			name:  "removes tracking params and sorts the rest",
+			input: "https://example.com/a?utm_source=x&b=2&fbclid=abc&a=1&UTM_Campaign=y",
+			want:  "https://example.com/a?a=1&b=2",
+		},
+		{
+			name:  "drops plain fragments",
+			input: "https://example.com/a#section",
+			want:  "https://example.com/a",
+		},
+		{
+			name:  "keeps hash routes",
+			input: "https://example.com/#/inbox",
+			want:  "https://example.com/#/inbox",
+		},
+		{
+			name:  "adds https when scheme is missing",
+			input: "  example.com/page  ",
+			want:  "https://example.com/page",
+		},
+		{
+			name:    "rejects unsupported schemes",
+			input:   "ftp://example.com/file",
+			wantErr: true,
+		},
+		{
+			name:    "rejects empty input",
+			input:   "   ",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := canonicalizeURL(tc.input)
+			if tc.wantErr {
+				if !errors.Is(err, entity.ErrInvalidBookmarkURL) {
+					t.Fatalf("expected ErrInvalidBookmarkURL, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("canonicalizeURL(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDocumentStrategy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		url         string
+		content     string
+		want        string
+	}{
+		{name: "html", contentType: "text/html; charset=utf-8", url: "https://example.com/", want: strategyReader},
+		{name: "pdf content type", contentType: "application/pdf", url: "https://arxiv.org/pdf/2401.00001", want: strategyPDF},
+		{name: "pdf signature with generic type", contentType: "application/octet-stream", url: "https://example.com/download?id=1", content: "%PDF-1.7\n", want: strategyPDF},
+		{name: "epub by extension", contentType: "application/octet-stream", url: "https://example.com/book.epub", want: strategyEPUB},
+		{name: "epub content type", contentType: "application/epub+zip", url: "https://example.com/book", want: strategyEPUB},
+		{name: "markdown served as plain text", contentType: "text/plain; charset=utf-8", url: "https://raw.githubusercontent.com/a/b/main/README.md", want: strategyMarkdown},
+		{name: "markdown content type", contentType: "text/markdown", url: "https://example.com/notes", want: strategyMarkdown},
+		{name: "readme with another text type", contentType: "text/x-web-markdown", url: "https://example.com/project/README.md", want: strategyMarkdown},
+		{name: "rendered readme page", contentType: "text/html; charset=utf-8", url: "https://github.com/a/b/blob/main/README.md", want: strategyReader},
+		{name: "plain text", contentType: "text/plain", url: "https://www.rfc-editor.org/rfc/rfc9110.txt", want: strategyText},
+		{name: "unsupported", contentType: "image/png", url: "https://example.com/a.png", want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := documentStrategy(tc.contentType, tc.url, []byte(tc.content)); got != tc.want {
+				t.Errorf("documentStrategy() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// readerRepository is a bookmark repository holding the latest response of one bookmark and recording
+// the content and stage runs stored for it
+type readerRepository struct {
+	output.BookmarkRepository
+	response  output.HTTPResponse
+	extracted []string
+	runs      []entity.StageRunInput
+}
+
+func (r *readerRepository) GetLatestHttpResponse(ctx context.Context, bookmarkID uuid.UUID) (*output.HTTPResponse, error) {
+	return &r.response, nil
+}
+
+func (r *readerRepository) GetBookmark(ctx context.Context, bookmarkID uuid.UUID) (*entity.Bookmark, error) {
+	return &entity.Bookmark{BookmarkID: bookmarkID, URL: "https://example.com/notes.txt"}, nil
+}
+
+func (r *readerRepository) InsertExtractedContent(ctx context.Context, bookmarkID uuid.UUID, strategy string, document *entity.ExtractedDocument) error {
+	r.extracted = append(r.extracted, strategy)
+	return nil
+}
+
+func (r *readerRepository) InsertStageRun(ctx context.Context, run entity.StageRunInput) error {
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+// textProcessor is a content processor that only passes plain text through
+type textProcessor struct {
+	output.ContentProcessor
+}
+
+func (textProcessor) ProcessPlainText(ctx context.Context, content []byte, contentType, sourceURL string) (string, error) {
+	return string(content), nil
+}
+
+func TestProcessWithReaderSkipsErrorResponses(t *testing.T) {
+	testCases := []struct {
+		name        string
+		statusCode  int32
+		wantStored  bool
+		wantSkipped bool
+	}{
+		{name: "ok", statusCode: 200, wantStored: true},
+		{name: "not found page", statusCode: 404, wantSkipped: true},
+		{name: "failed fetch", statusCode: 500, wantSkipped: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &readerRepository{response: output.HTTPResponse{
+				StatusCode: tc.statusCode,
+				Headers:    `{"Content-Type": "text/plain"}`,
+				Content:    []byte("Some notes worth keeping."),
+			}}
+			s := &BookmarkService{repo: repo, contentProcessor: textProcessor{}}
+
+			result, err := s.ProcessWithReader(context.Background(), uuid.New())
+			if err != nil {
+				t.Fatalf("ProcessWithReader() error = %v", err)
+			}
+			if stored := len(repo.extracted) > 0; stored != tc.wantStored {
+				t.Errorf("stored content = %v, want %v", stored, tc.wantStored)
+			}
+			if (result.Content == nil) != tc.wantSkipped {
+				t.Errorf("content = %v, want skipped %v", result.Content, tc.wantSkipped)
+			}
+			if len(repo.runs) != 1 || (repo.runs[0].Status == entity.StageSkipped) != tc.wantSkipped {
+				t.Errorf("stage runs = %+v, want skipped %v", repo.runs, tc.wantSkipped)
+			}
+		})
+	}
+}
+
+// urlRepository is a bookmark repository holding bookmark URLs, of which only the URL migration and
+// delete methods are implemented
+type urlRepository struct {
+	output.BookmarkRepository
+	bookmarks []entity.Bookmark
+	merges    map[uuid.UUID][]uuid.UUID
+	urls      map[uuid.UUID]string
+	indexed   bool
+}
+
+func (r *urlRepository) ListBookmarkURLs(ctx context.Context) ([]entity.Bookmark, error) {
+	return r.bookmarks, nil
+}
+
+func (r *urlRepository) MergeBookmarks(ctx context.Context, keepID uuid.UUID, url string, duplicateIDs []uuid.UUID) error {
+	r.merges[keepID] = duplicateIDs
+	r.urls[keepID] = url
+	return nil
+}
+
+func (r *urlRepository) CreateBookmarkURLIndex(ctx context.Context) error {
+	r.indexed = true
+	return nil
+}
+
+func (r *urlRepository) ListBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkArchive, error) {
+	return nil, nil
+}
+
+func (r *urlRepository) DeleteBookmark(ctx context.Context, bookmarkID uuid.UUID) error {
+	for _, bookmark := range r.bookmarks {
+		if bookmark.BookmarkID == bookmarkID {
+			return nil
+		}
+	}
+	return entity.ErrBookmarkNotFound
+}
+
+func TestMigrateBookmarkURLs(t *testing.T) {
+	oldest, newer, trailing, canonical, other := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	repo := &urlRepository{
+		bookmarks: []entity.Bookmark{
+			{BookmarkID: oldest, URL: "HTTP://Example.com/a?utm_source=x"},
+			{BookmarkID: newer, URL: "http://example.com/a"},
+			{BookmarkID: trailing, URL: "https://example.org/b/"},
+			{BookmarkID: canonical, URL: "https://example.net/c"},
+			{BookmarkID: other, URL: "not a url"},
+		},
+		merges: map[uuid.UUID][]uuid.UUID{},
+		urls:   map[uuid.UUID]string{},
+	}
+	s := &BookmarkService{repo: repo}
+
+	result, err := s.MigrateBookmarkURLs(context.Background())
+	if err != nil {
+		t.Fatalf("MigrateBookmarkURLs() error = %v", err)
+	}
+	if result.Canonicalized != 2 || result.Merged != 1 {
+		t.Errorf("canonicalized %d and merged %d, want 2 and 1", result.Canonicalized, result.Merged)
+	}
+	if got := repo.merges[oldest]; len(got) != 1 || got[0] != newer {
+		t.Errorf("merged %v into the oldest bookmark, want [%s]", got, newer)
+	}
+	if repo.urls[oldest] != "http://example.com/a" || repo.urls[trailing] != "https://example.org/b" {
+		t.Errorf("rewrote urls to %v", repo.urls)
+	}
+	if _, ok := repo.merges[canonical]; ok {
+		t.Errorf("rewrote already canonical bookmark %s", canonical)
+	}
+	if !repo.indexed {
+		t.Errorf("unique url index not created")
+	}
+}
+
+func TestDeleteBookmark(t *testing.T) {
+	existing := uuid.New()
+	s := &BookmarkService{repo: &urlRepository{bookmarks: []entity.Bookmark{{BookmarkID: existing}}}}
+
+	if err := s.DeleteBookmark(context.Background(), existing); err != nil {
+		t.Errorf("DeleteBookmark() of an existing bookmark error = %v", err)
+	}
+	if err := s.DeleteBookmark(context.Background(), uuid.New()); !errors.Is(err, entity.ErrBookmarkNotFound) {
+		t.Errorf("DeleteBookmark() of a missing bookmark error = %v, want %v", err, entity.ErrBookmarkNotFound)
+	}
+}
+
+// questionRepository is a bookmark repository holding the generated Q&A pairs of one bookmark, of which
+// only the question generation methods are implemented
+type questionRepository struct {
+	output.BookmarkRepository
+	lock     sync.Mutex
+	mu       sync.Mutex
+	stored   []entity.BookmarkQuestion
+	replaces int
+}
+
+func (r *questionRepository) LockBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID) (func(), error) {
+	r.lock.Lock()
+	return r.lock.Unlock, nil
+}
+
+func (r *questionRepository) GetGeneratedQuestions(ctx context.Context, bookmarkID uuid.UUID, strategy, promptVersion string) ([]entity.BookmarkQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stored, nil
+}
+
+func (r *questionRepository) GetDocumentContent(ctx context.Context, bookmarkID uuid.UUID) (*string, error) {
+	content := "# Reader content"
+	return &content, nil
+}
+
+func (r *questionRepository) GetBookmark(ctx context.Context, bookmarkID uuid.UUID) (*entity.Bookmark, error) {
+	return &entity.Bookmark{BookmarkID: bookmarkID, URL: "https://example.com"}, nil
+}
+
+func (r *questionRepository) ReplaceGeneratedQuestions(ctx context.Context, bookmarkID uuid.UUID, strategy, model string, extra json.RawMessage, questions []entity.Embedding) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replaces++
+	r.stored = make([]entity.BookmarkQuestion, len(questions))
+	ids := make([]uuid.UUID, len(questions))
+	for i, question := range questions {
+		ids[i] = uuid.New()
+		r.stored[i] = entity.BookmarkQuestion{ID: ids[i], Content: question.Text}
+	}
+	return ids, nil
+}
+
+func (r *questionRepository) InsertStageRun(ctx context.Context, run entity.StageRunInput) error {
+	return nil
+}
+
+// questionAI generates one Q&A pair per call, counting the calls
+type questionAI struct {
+	output.AIService
+	calls atomic.Int32
+}
+
+func (a *questionAI) GenerateQuestions(ctx context.Context, content, url string, count int, rejected []entity.QuestionAnswer) ([]entity.QuestionAnswer, error) {
+	n := a.calls.Add(1)
+	return []entity.QuestionAnswer{{Question: fmt.Sprintf("Question %d", n), Answer: "Answer"}}, nil
+}
+
+func (a *questionAI) Model() string                 { return "llm" }
+func (a *questionAI) QuestionPromptVersion() string { return "qa-v2-passage-1" }
+
+// fixedEmbeddings embeds every text as the same vector
+type fixedEmbeddings struct {
+	output.EmbeddingsService
+}
+
+func (e fixedEmbeddings) EmbedBatch(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{1, 0}
+	}
+	return vectors, nil
+}
+
+func (e fixedEmbeddings) Model() string { return "embedder" }
+
+func TestGenerateBookmarkQuestions(t *testing.T) {
+	repo := &questionRepository{}
+	ai := &questionAI{}
+	s := &BookmarkService{repo: repo, aiService: ai, embeddingsService: fixedEmbeddings{}}
+	bookmarkID := uuid.New()
+
+	// The pipeline, the endpoint and the regeneration worker may ask at once
+	results := make([]*entity.GenerateQuestionsResult, 3)
+	errs := make([]error, len(results))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = s.GenerateBookmarkQuestions(context.Background(), bookmarkID, entity.GenerateQuestionsInput{})
+		}()
+	}
+	wg.Wait()
+
+	generated := 0
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error: %v", errs[i])
+		}
+		if result.Generated {
+			generated++
+		}
+		if len(result.Questions) != 1 || result.Questions[0].Content != "Question 1?\nAnswer" {
+			t.Errorf("expected every caller to get the one stored pair, got %+v", result.Questions)
+		}
+	}
+	if generated != 1 || ai.calls.Load() != 1 || repo.replaces != 1 {
+		t.Errorf("expected the pairs to be generated and stored once, got %d generated results, %d model calls and %d replacements", generated, ai.calls.Load(), repo.replaces)
+	}
+
+	result, err := s.GenerateBookmarkQuestions(context.Background(), bookmarkID, entity.GenerateQuestionsInput{Force: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Generated || repo.replaces != 2 || result.Questions[0].Content != "Question 2?\nAnswer" {
+		t.Errorf("expected force to replace the pairs, got %+v after %d replacements", result, repo.replaces)
+	}
+}
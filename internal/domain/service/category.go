@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 	"garden3/internal/domain/entity"
@@ -51,6 +52,60 @@ func (s *CategoryService) MergeCategories(ctx context.Context, input entity.Merg
 	return nil
 }
 
+func (s *CategoryService) MoveCategory(ctx context.Context, categoryID uuid.UUID, input entity.MoveCategoryInput) error {
+	if err := s.checkParent(ctx, categoryID, input.ParentID); err != nil {
+		return err
+	}
+
+	moved, err := s.repo.MoveCategory(ctx, categoryID, input.ParentID)
+	if err != nil {
+		return fmt.Errorf("failed to move category: %w", err)
+	}
+	if !moved {
+		return entity.ErrCategoryCycle
+	}
+	return nil
+}
+
+func (s *CategoryService) ReparentCategoryChildren(ctx context.Context, categoryID uuid.UUID, input entity.MoveCategoryInput) ([]uuid.UUID, error) {
+	if err := s.checkParent(ctx, categoryID, input.ParentID); err != nil {
+		return nil, err
+	}
+
+	moved, err := s.repo.ReparentCategoryChildren(ctx, categoryID, input.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reparent category children: %w", err)
+	}
+	return moved, nil
+}
+
+// checkParent verifies that a category and its new parent exist and that the parent lies outside the
+// category's subtree
+func (s *CategoryService) checkParent(ctx context.Context, categoryID uuid.UUID, parentID *uuid.UUID) error {
+	subtree, err := s.repo.GetCategorySubtreeIDs(ctx, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get category subtree: %w", err)
+	}
+	if len(subtree) == 0 {
+		return entity.ErrCategoryNotFound
+	}
+	if parentID == nil {
+		return nil
+	}
+	if slices.Contains(subtree, *parentID) {
+		return entity.ErrCategoryCycle
+	}
+
+	parent, err := s.repo.GetCategorySubtreeIDs(ctx, *parentID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent category: %w", err)
+	}
+	if len(parent) == 0 {
+		return entity.ErrCategoryNotFound
+	}
+	return nil
+}
+
 func (s *CategoryService) CreateCategorySource(ctx context.Context, input entity.CreateCategorySourceInput) (*entity.CategorySource, error) {
 	source, err := s.repo.CreateCategorySource(ctx, input.CategoryID, input.SourceURI, input.RawSource)
 	if err != nil {
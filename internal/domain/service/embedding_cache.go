@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// EmbeddingCacheService implements the EmbeddingCacheUseCase interface
+// The cache keeps a vector per model and text, so re-running a pipeline stage on unchanged text does not
+// call the model again. Eviction keeps it to vectors used within maxAge and to at most maxEntries of them
+type EmbeddingCacheService struct {
+	cache      output.EmbeddingCache
+	maxEntries int
+	maxAge     time.Duration
+}
+
+// NewEmbeddingCacheService creates a new embedding cache service. A zero maxEntries or maxAge does not limit
+// the cache by that measure
+func NewEmbeddingCacheService(cache output.EmbeddingCache, maxEntries int, maxAge time.Duration) *EmbeddingCacheService {
+	return &EmbeddingCacheService{
+		cache:      cache,
+		maxEntries: maxEntries,
+		maxAge:     maxAge,
+	}
+}
+
+func (s *EmbeddingCacheService) GetEmbeddingCacheStats(ctx context.Context) (*entity.EmbeddingCacheStats, error) {
+	stats, err := s.cache.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (s *EmbeddingCacheService) EvictEmbeddingCache(ctx context.Context) (*entity.EmbeddingCacheEviction, error) {
+	eviction, err := s.cache.Evict(ctx, s.maxEntries, s.maxAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evict embedding cache: %w", err)
+	}
+	return &eviction, nil
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+const (
+	// cutoverBatchSize is the number of rows embedded at a time when catching up before a cutover
+	cutoverBatchSize = 256
+
+	// embeddingProbeText is embedded to check a model before migrating to it
+	embeddingProbeText = "garden embedding model check"
+)
+
+// EmbeddingModelService implements the EmbeddingModelUseCase interface
+// Stored embeddings are tagged with the model that made them and similarity searches only compare vectors of
+// the active model. A migration stages vectors of a new model next to the stored ones and swaps them in at
+// the cutover, so searches keep working on the old vectors until then
+type EmbeddingModelService struct {
+	repo        output.EmbeddingModelRepository
+	models      output.EmbeddingModels
+	entityLinks output.EntityLinkRepository
+}
+
+// NewEmbeddingModelService creates a new embedding model service. Entity links in annotation notes are
+// resolved with entityLinks when re-embedding annotations
+func NewEmbeddingModelService(
+	repo output.EmbeddingModelRepository,
+	models output.EmbeddingModels,
+	entityLinks output.EntityLinkRepository,
+) *EmbeddingModelService {
+	return &EmbeddingModelService{
+		repo:        repo,
+		models:      models,
+		entityLinks: entityLinks,
+	}
+}
+
+func (s *EmbeddingModelService) ActivateLiveModel(ctx context.Context) error {
+	active, err := s.repo.GetActiveModel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active embedding model: %w", err)
+	}
+
+	live := s.models.Live().Model
+	if live == "" {
+		return fmt.Errorf("%w: the embedding configuration names no model", entity.ErrInvalidEmbeddingModel)
+	}
+	if active == live {
+		s.models.Activate(active)
+		return nil
+	}
+
+	// A changed model only becomes active at the cutover of a migration. Until then its query vectors cannot
+	// be compared with the stored ones, so semantic searches are refused
+	if active != "" {
+		s.models.Activate(active)
+		log.Printf("Embedding model changed from %s to %s without a migration; semantic searches are refused and texts embedded with %s are left out of them until a migration to %s cuts over", active, live, live, live)
+		return nil
+	}
+
+	claimed, err := s.repo.ActivateModel(ctx, live)
+	if err != nil {
+		return fmt.Errorf("failed to activate embedding model: %w", err)
+	}
+	if claimed > 0 {
+		log.Printf("Recorded %d existing embeddings as made by %s", claimed, live)
+	}
+	s.models.Activate(live)
+	return nil
+}
+
+func (s *EmbeddingModelService) GetEmbeddingModelStatus(ctx context.Context) (*entity.EmbeddingModelStatus, error) {
+	active, err := s.repo.GetActiveModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active embedding model: %w", err)
+	}
+
+	usage, err := s.repo.ListModelUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embeddings: %w", err)
+	}
+
+	migration, err := s.repo.GetOpenMigration(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open migration: %w", err)
+	}
+
+	return &entity.EmbeddingModelStatus{
+		ActiveModel: active,
+		LiveModel:   s.models.Live().Model,
+		Usage:       usage,
+		Migration:   withMigrationETA(migration),
+	}, nil
+}
+
+func (s *EmbeddingModelService) StartEmbeddingMigration(ctx context.Context, target entity.EmbeddingModelConfig) (*entity.EmbeddingMigration, error) {
+	open, err := s.repo.GetOpenMigration(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open migration: %w", err)
+	}
+	if open != nil {
+		return nil, entity.ErrEmbeddingMigrationOpen
+	}
+
+	target, embedder, err := s.models.Open(target)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrInvalidEmbeddingModel, err)
+	}
+	if target.Model == "" {
+		return nil, fmt.Errorf("%w: no model given", entity.ErrInvalidEmbeddingModel)
+	}
+	if _, err := embedder.EmbedBatch(ctx, []string{embeddingProbeText}, nil); err != nil {
+		return nil, fmt.Errorf("%w: failed to embed with %s: %v", entity.ErrInvalidEmbeddingModel, target.Model, err)
+	}
+
+	remaining, err := s.repo.CountMigrationRows(ctx, uuid.Nil, target.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embeddings to migrate: %w", err)
+	}
+
+	migration, err := s.repo.CreateMigration(ctx, target, remaining)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration: %w", err)
+	}
+
+	log.Printf("Started embedding migration %s to %s with %d embeddings to make", migration.MigrationID, target.Model, remaining)
+	return migration, nil
+}
+
+func (s *EmbeddingModelService) GetEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error) {
+	migration, err := s.repo.GetMigration(ctx, migrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration: %w", err)
+	}
+	return withMigrationETA(migration), nil
+}
+
+func (s *EmbeddingModelService) ListEmbeddingMigrations(ctx context.Context, limit int32) ([]entity.EmbeddingMigration, error) {
+	migrations, err := s.repo.ListMigrations(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	for i := range migrations {
+		withMigrationETA(&migrations[i])
+	}
+	return migrations, nil
+}
+
+func (s *EmbeddingModelService) ProcessEmbeddingMigration(ctx context.Context, batchSize int32) (*entity.EmbeddingMigration, error) {
+	migration, err := s.repo.GetOpenMigration(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open migration: %w", err)
+	}
+	if migration == nil || migration.Status != entity.MigrationRunning {
+		return nil, nil
+	}
+
+	staged, stageErr := s.stageEmbeddings(ctx, migration, batchSize)
+	if stageErr != nil && ctx.Err() != nil {
+		return nil, stageErr
+	}
+
+	remaining, err := s.repo.CountMigrationRows(ctx, migration.MigrationID, migration.TargetModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embeddings to migrate: %w", err)
+	}
+
+	status := entity.MigrationRunning
+	if remaining == 0 {
+		status = entity.MigrationReady
+	}
+	var errMsg *string
+	if stageErr != nil {
+		msg := stageErr.Error()
+		errMsg = &msg
+	}
+
+	updated, err := s.repo.UpdateMigrationProgress(ctx, migration.MigrationID, staged, remaining, status, errMsg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update migration progress: %w", err)
+	}
+	if stageErr != nil {
+		return withMigrationETA(updated), stageErr
+	}
+	return withMigrationETA(updated), nil
+}
+
+func (s *EmbeddingModelService) CutoverEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingCutover, error) {
+	migration, err := s.openMigration(ctx, migrationID)
+	if err != nil {
+		return nil, err
+	}
+	if migration.Status != entity.MigrationReady {
+		return nil, entity.ErrEmbeddingMigrationNotReady
+	}
+
+	// Texts changed or added since the migration got ready still have to be embedded with the target model
+	for {
+		staged, err := s.stageEmbeddings(ctx, migration, cutoverBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if staged == 0 {
+			break
+		}
+	}
+
+	completed, applied, err := s.repo.CompleteMigration(ctx, migrationID, migration.TargetModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete migration: %w", err)
+	}
+	if completed == nil {
+		return nil, entity.ErrEmbeddingMigrationFinished
+	}
+
+	// Searches compare against the target model from here on, so texts must be embedded with it as well
+	if err := s.models.Switch(ctx, migration.Target()); err != nil {
+		return nil, fmt.Errorf("failed to switch the live embedding model to %s: %w", migration.TargetModel, err)
+	}
+
+	log.Printf("Embedding migration %s cut over to %s with %d embeddings", migrationID, migration.TargetModel, applied)
+	return &entity.EmbeddingCutover{
+		Migration: completed,
+		Applied:   applied,
+	}, nil
+}
+
+func (s *EmbeddingModelService) CancelEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error) {
+	if _, err := s.openMigration(ctx, migrationID); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := s.repo.CancelMigration(ctx, migrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel migration: %w", err)
+	}
+	if cancelled == nil {
+		return nil, entity.ErrEmbeddingMigrationFinished
+	}
+	return cancelled, nil
+}
+
+// openMigration returns a migration that is still running or ready
+func (s *EmbeddingModelService) openMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error) {
+	migration, err := s.repo.GetMigration(ctx, migrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration: %w", err)
+	}
+	if migration == nil {
+		return nil, entity.ErrEmbeddingMigrationNotFound
+	}
+	if migration.Status != entity.MigrationRunning && migration.Status != entity.MigrationReady {
+		return nil, entity.ErrEmbeddingMigrationFinished
+	}
+	return migration, nil
+}
+
+// stageEmbeddings embeds up to limit rows that have no vector of the migration's target model yet and stages
+// the vectors, returning how many were staged
+func (s *EmbeddingModelService) stageEmbeddings(ctx context.Context, migration *entity.EmbeddingMigration, limit int32) (int, error) {
+	rows, err := s.repo.ListMigrationRows(ctx, migration.MigrationID, migration.TargetModel, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list embeddings to migrate: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	texts := make([]string, len(rows))
+	for i, row := range rows {
+		texts[i], err = s.migrationText(ctx, row)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, embedder, err := s.models.Open(migration.Target())
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", migration.TargetModel, err)
+	}
+	vectors, err := embedder.EmbedBatch(ctx, texts, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed with %s: %w", migration.TargetModel, err)
+	}
+
+	staged := make([]entity.StagedEmbedding, len(rows))
+	for i, row := range rows {
+		staged[i] = entity.StagedEmbedding{
+			Table:      row.Table,
+			RowID:      row.RowID,
+			SourceHash: row.SourceHash,
+			Embedding:  vectors[i],
+		}
+	}
+	if err := s.repo.StageEmbeddings(ctx, migration.MigrationID, staged); err != nil {
+		return 0, fmt.Errorf("failed to stage embeddings: %w", err)
+	}
+	return len(rows), nil
+}
+
+// migrationText rebuilds the text a stored embedding was made from
+func (s *EmbeddingModelService) migrationText(ctx context.Context, row entity.EmbeddingMigrationRow) (string, error) {
+	switch row.Table {
+	case entity.EmbeddingTableAnnotations:
+		note := strings.TrimSpace(row.ExtraText)
+		if note == "" {
+			return annotationEmbeddingText(row.Text, nil), nil
+		}
+		rendered, err := renderEntityLinks(ctx, s.entityLinks, note)
+		if err != nil {
+			return "", fmt.Errorf("failed to process note of annotation %s: %w", row.RowID, err)
+		}
+		return annotationEmbeddingText(row.Text, &rendered), nil
+	case entity.EmbeddingTableItems:
+		return itemEmbeddingText(row.Text, row.ExtraText), nil
+	default:
+		return row.Text, nil
+	}
+}
+
+// itemEmbeddingText is the text an item is embedded from: its title and contents, separated by a blank line
+func itemEmbeddingText(title, contents string) string {
+	var parts []string
+	for _, part := range []string{title, contents} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// withMigrationETA estimates the time a running migration needs for its remaining rows from its pace so far
+func withMigrationETA(migration *entity.EmbeddingMigration) *entity.EmbeddingMigration {
+	if migration == nil {
+		return nil
+	}
+	migration.ETASeconds = 0
+	if migration.Status != entity.MigrationRunning || migration.Done == 0 || migration.Remaining == 0 {
+		return migration
+	}
+
+	elapsed := migration.UpdatedAt.Sub(migration.StartedAt)
+	if elapsed <= 0 {
+		return migration
+	}
+	eta := time.Duration(float64(elapsed) / float64(migration.Done) * float64(migration.Remaining))
+	migration.ETASeconds = int64(eta.Round(time.Second) / time.Second)
+	return migration
+}
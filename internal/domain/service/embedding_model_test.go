@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+func TestWithMigrationETA(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		migration entity.EmbeddingMigration
+		want      int64
+	}{
+		{
+			name:      "running migration keeps its pace",
+			migration: entity.EmbeddingMigration{Status: entity.MigrationRunning, Done: 100, Remaining: 300, StartedAt: start, UpdatedAt: start.Add(time.Minute)},
+			want:      180,
+		},
+		{
+			name:      "nothing done yet",
+			migration: entity.EmbeddingMigration{Status: entity.MigrationRunning, Remaining: 300, StartedAt: start, UpdatedAt: start.Add(time.Minute)},
+			want:      0,
+		},
+		{
+			name:      "ready migration",
+			migration: entity.EmbeddingMigration{Status: entity.MigrationReady, Done: 400, StartedAt: start, UpdatedAt: start.Add(time.Minute), ETASeconds: 5},
+			want:      0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := withMigrationETA(&tc.migration).ETASeconds; got != tc.want {
+				t.Errorf("withMigrationETA() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	if withMigrationETA(nil) != nil {
+		t.Errorf("withMigrationETA(nil) should be nil")
+	}
+}
+
+func TestMigrationEmbeddingTexts(t *testing.T) {
+	note := "see [Go](/entities/123)"
+	if got := annotationEmbeddingText("quote", &note); got != "quote\n\nsee Go" {
+		t.Errorf("annotationEmbeddingText() = %q", got)
+	}
+	if got := annotationEmbeddingText("quote", nil); got != "quote" {
+		t.Errorf("annotationEmbeddingText() without note = %q", got)
+	}
+
+	if got := itemEmbeddingText(" Title ", "Contents\n"); got != "Title\n\nContents" {
+		t.Errorf("itemEmbeddingText() = %q", got)
+	}
+	if got := itemEmbeddingText("", "Contents"); got != "Contents" {
+		t.Errorf("itemEmbeddingText() without title = %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+var (
+	// Matches [[text]] or [[display][text]]
+	entityRefRegex = regexp.MustCompile(`\[\[(.*?)(?:\]\[([^\]]+))?\]\]`)
+	uuidRegex      = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// storeEntityLinks converts entity references to entity IDs
+// Converts:
+// - [[Entity Name]] to [[entity-id]]
+// - [[Display Text][Entity Name]] to [[Display Text][entity-id]]
+func storeEntityLinks(ctx context.Context, repo output.EntityLinkRepository, content string, sourceType string, sourceID uuid.UUID) (string, error) {
+	if content == "" {
+		return content, nil
+	}
+
+	processedContent := content
+	matches := entityRefRegex.FindAllStringSubmatch(content, -1)
+
+	for _, match := range matches {
+		original := match[0]
+		firstPart := match[1]
+		secondPart := ""
+		if len(match) > 2 {
+			secondPart = match[2]
+		}
+
+		var entityID uuid.UUID
+		var displayText string
+
+		if secondPart != "" {
+			// Format: [[Display Text][Entity Name or ID]]
+			if uuidRegex.MatchString(secondPart) {
+				// Second part is already a UUID
+				parsedUUID, err := uuid.Parse(secondPart)
+				if err != nil {
+					continue
+				}
+				entityID = parsedUUID
+				displayText = firstPart
+
+				// Verify the entity exists
+				_, err = repo.GetEntityByID(ctx, entityID)
+				if err != nil {
+					// Entity doesn't exist, skip this reference
+					continue
+				}
+			} else {
+				// Second part is an entity name
+				existingEntity, err := repo.GetEntityByName(ctx, secondPart)
+				if err != nil || existingEntity == nil {
+					// Create new entity
+					createdEntityID, err := repo.CreateEntity(ctx, secondPart, "general", nil, make(map[string]interface{}))
+					if err != nil {
+						continue
+					}
+					entityID = *createdEntityID
+				} else {
+					entityID = existingEntity.EntityID
+				}
+				displayText = firstPart
+			}
+
+			// Replace with [[Display Text][entity-id]]
+			replacement := fmt.Sprintf("[[%s][%s]]", displayText, entityID.String())
+			processedContent = strings.Replace(processedContent, original, replacement, 1)
+		} else {
+			// Format: [[Entity Name]]
+			existingEntity, err := repo.GetEntityByName(ctx, firstPart)
+			if err != nil || existingEntity == nil {
+				// Create new entity
+				createdEntityID, err := repo.CreateEntity(ctx, firstPart, "general", nil, make(map[string]interface{}))
+				if err != nil {
+					continue
+				}
+				entityID = *createdEntityID
+			} else {
+				entityID = existingEntity.EntityID
+			}
+			displayText = firstPart
+
+			// Replace with [[entity-id]]
+			replacement := fmt.Sprintf("[[%s]]", entityID.String())
+			processedContent = strings.Replace(processedContent, original, replacement, 1)
+		}
+
+		// Record the reference
+		ref := entity.EntityReference{
+			SourceType:    sourceType,
+			SourceID:      sourceID,
+			EntityID:      entityID,
+			ReferenceText: displayText,
+			Position:      nil,
+		}
+		if err := repo.CreateEntityReference(ctx, ref); err != nil {
+			// Log error but continue
+			log.Printf("Failed to create entity reference to %s from %s %s: %v", entityID, sourceType, sourceID, err)
+		}
+	}
+
+	return processedContent, nil
+}
+
+// renderEntityLinks converts entity IDs to display text with markdown links
+// Converts:
+// - [[entity-id]] to [Entity Name](/entities/entity-id)
+// - [[Display Text][entity-id]] to [Display Text](/entities/entity-id)
+func renderEntityLinks(ctx context.Context, repo output.EntityLinkRepository, content string) (string, error) {
+	if content == "" {
+		return content, nil
+	}
+
+	processedContent := content
+	matches := entityRefRegex.FindAllStringSubmatch(content, -1)
+
+	for _, match := range matches {
+		original := match[0]
+		firstPart := match[1]
+		secondPart := ""
+		if len(match) > 2 {
+			secondPart = match[2]
+		}
+
+		if secondPart != "" {
+			// Format: [[display][entity-id]]
+			entityID, err := uuid.Parse(secondPart)
+			if err != nil {
+				continue
+			}
+
+			entity, err := repo.GetEntityByID(ctx, entityID)
+			if err == nil && entity != nil {
+				replacement := fmt.Sprintf("[%s](/entities/%s)", firstPart, secondPart)
+				processedContent = strings.Replace(processedContent, original, replacement, 1)
+			}
+		} else {
+			// Format: [[entity-id]]
+			entityID, err := uuid.Parse(firstPart)
+			if err != nil {
+				continue
+			}
+
+			entity, err := repo.GetEntityByID(ctx, entityID)
+			if err == nil && entity != nil {
+				replacement := fmt.Sprintf("[%s](/entities/%s)", entity.Name, firstPart)
+				processedContent = strings.Replace(processedContent, original, replacement, 1)
+			}
+		}
+	}
+
+	return processedContent, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+const (
+	// fetchProfileKeyPrefix prefixes the configuration keys holding fetch profiles, followed by the domain
+	fetchProfileKeyPrefix = "fetch.profile."
+
+	// maxFetchProfileTimeout is the longest request timeout a profile may set, in milliseconds
+	maxFetchProfileTimeout = 120000
+)
+
+// FetchProfileService manages the per-domain fetch profiles stored in configurations
+type FetchProfileService struct {
+	configRepo   output.ConfigurationRepository
+	cookieParser output.CookieParser
+}
+
+// NewFetchProfileService creates a new fetch profile service
+func NewFetchProfileService(configRepo output.ConfigurationRepository, cookieParser output.CookieParser) *FetchProfileService {
+	return &FetchProfileService{
+		configRepo:   configRepo,
+		cookieParser: cookieParser,
+	}
+}
+
+func (s *FetchProfileService) ListFetchProfiles(ctx context.Context) ([]entity.FetchProfile, error) {
+	profiles, err := loadFetchProfiles(ctx, s.configRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range profiles {
+		redactCookies(&profiles[i])
+	}
+	return profiles, nil
+}
+
+func (s *FetchProfileService) GetFetchProfile(ctx context.Context, domain string) (*entity.FetchProfile, error) {
+	profile, err := s.getProfile(ctx, domain)
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	redactCookies(profile)
+	return profile, nil
+}
+
+func (s *FetchProfileService) SaveFetchProfile(ctx context.Context, domain string, input entity.FetchProfileInput) (*entity.FetchProfile, error) {
+	if err := validateFetchProfileInput(input); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.getProfile(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &entity.FetchProfile{Domain: normalizeFetchDomain(domain)}
+	}
+
+	profile.UserAgent = input.UserAgent
+	if profile.UserAgent != nil && *profile.UserAgent == "" {
+		profile.UserAgent = nil
+	}
+	profile.Headers = input.Headers
+	profile.TimeoutMs = input.TimeoutMs
+	profile.Proxy = input.Proxy
+	if profile.Proxy != nil && *profile.Proxy == "" {
+		profile.Proxy = nil
+	}
+
+	if err := s.saveProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	redactCookies(profile)
+	return profile, nil
+}
+
+func (s *FetchProfileService) DeleteFetchProfile(ctx context.Context, domain string) error {
+	domain = normalizeFetchDomain(domain)
+	if !isValidFetchDomain(domain) {
+		return fmt.Errorf("domain %q: %w", domain, entity.ErrInvalidFetchProfile)
+	}
+
+	if err := s.configRepo.Delete(ctx, fetchProfileKeyPrefix+domain); err != nil {
+		return fmt.Errorf("failed to delete fetch profile: %w", err)
+	}
+	return nil
+}
+
+func (s *FetchProfileService) ImportFetchProfileCookies(ctx context.Context, domain string, r io.Reader) (*entity.CookieImportResult, error) {
+	cookies, err := s.cookieParser.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cookies: %w", err)
+	}
+
+	profile, err := s.getProfile(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &entity.FetchProfile{Domain: normalizeFetchDomain(domain)}
+	}
+
+	// Browsers export every cookie they hold, so only keep the ones that would be sent to this domain
+	result := &entity.CookieImportResult{}
+	now := time.Now()
+	profile.Cookies = nil
+	for _, cookie := range cookies {
+		if !cookieAppliesTo(cookie, profile.Domain) || (cookie.Expires != nil && cookie.Expires.Before(now)) {
+			result.Skipped++
+			continue
+		}
+		profile.Cookies = append(profile.Cookies, cookie)
+		result.Imported++
+	}
+
+	if err := s.saveProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getProfile loads the profile stored for a domain, returning nil if none exists
+func (s *FetchProfileService) getProfile(ctx context.Context, domain string) (*entity.FetchProfile, error) {
+	domain = normalizeFetchDomain(domain)
+	if !isValidFetchDomain(domain) {
+		return nil, fmt.Errorf("domain %q: %w", domain, entity.ErrInvalidFetchProfile)
+	}
+
+	config, err := s.configRepo.GetByKey(ctx, fetchProfileKeyPrefix+domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fetch profile: %w", err)
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	var profile entity.FetchProfile
+	if err := json.Unmarshal([]byte(config.Value), &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse fetch profile %s: %w", config.Key, err)
+	}
+	profile.Domain = domain
+	return &profile, nil
+}
+
+// saveProfile stores a profile as a secret configuration, as its cookies and headers may carry credentials
+func (s *FetchProfileService) saveProfile(ctx context.Context, profile *entity.FetchProfile) error {
+	value, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fetch profile: %w", err)
+	}
+
+	if _, err := s.configRepo.Upsert(ctx, fetchProfileKeyPrefix+profile.Domain, string(value), true, time.Now()); err != nil {
+		return fmt.Errorf("failed to store fetch profile: %w", err)
+	}
+	return nil
+}
+
+// loadFetchProfiles returns all stored fetch profiles, skipping any that cannot be parsed
+func loadFetchProfiles(ctx context.Context, configRepo output.ConfigurationRepository) ([]entity.FetchProfile, error) {
+	configs, err := configRepo.GetByPrefix(ctx, fetchProfileKeyPrefix, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fetch profiles: %w", err)
+	}
+
+	profiles := make([]entity.FetchProfile, 0, len(configs))
+	for _, config := range configs {
+		var profile entity.FetchProfile
+		if err := json.Unmarshal([]byte(config.Value), &profile); err != nil {
+			log.Printf("Skipping fetch profile %s: %v", config.Key, err)
+			continue
+		}
+		profile.Domain = strings.TrimPrefix(config.Key, fetchProfileKeyPrefix)
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// findFetchProfile returns the profile of the most specific domain matching a URL's host, or nil if none matches
+func findFetchProfile(ctx context.Context, configRepo output.ConfigurationRepository, rawURL string) (*entity.FetchProfile, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, nil
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	profiles, err := loadFetchProfiles(ctx, configRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *entity.FetchProfile
+	for i := range profiles {
+		if domainMatches(host, profiles[i].Domain) && (best == nil || len(profiles[i].Domain) > len(best.Domain)) {
+			best = &profiles[i]
+		}
+	}
+	return best, nil
+}
+
+// applyFetchProfile returns the fetch options and timeout a profile asks for, on top of the given defaults
+func applyFetchProfile(profile *entity.FetchProfile, options output.FetchOptions, timeoutMs int) (output.FetchOptions, int) {
+	if profile == nil {
+		return options, timeoutMs
+	}
+
+	if profile.UserAgent != nil {
+		options.UserAgent = *profile.UserAgent
+	}
+	options.Headers = profile.Headers
+	options.Cookies = profile.Cookies
+	if profile.Proxy != nil {
+		options.Proxy = *profile.Proxy
+	}
+	if profile.TimeoutMs != nil {
+		timeoutMs = int(*profile.TimeoutMs)
+	}
+	return options, timeoutMs
+}
+
+func validateFetchProfileInput(input entity.FetchProfileInput) error {
+	if input.TimeoutMs != nil && (*input.TimeoutMs < 1 || *input.TimeoutMs > maxFetchProfileTimeout) {
+		return fmt.Errorf("timeout must be between 1 and %d ms: %w", maxFetchProfileTimeout, entity.ErrInvalidFetchProfile)
+	}
+
+	if input.Proxy != nil && *input.Proxy != "" {
+		proxy, err := url.Parse(*input.Proxy)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("proxy %q is not a URL: %w", *input.Proxy, entity.ErrInvalidFetchProfile)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("proxy scheme %q is not http, https or socks5: %w", proxy.Scheme, entity.ErrInvalidFetchProfile)
+		}
+	}
+
+	if input.UserAgent != nil && !httpguts.ValidHeaderFieldValue(*input.UserAgent) {
+		return fmt.Errorf("user agent is not a valid header value: %w", entity.ErrInvalidFetchProfile)
+	}
+
+	for name, value := range input.Headers {
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("header %q is not valid: %w", name, entity.ErrInvalidFetchProfile)
+		}
+	}
+
+	return nil
+}
+
+func normalizeFetchDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+func isValidFetchDomain(domain string) bool {
+	return domain != "" && !strings.ContainsAny(domain, "/:@?#* \t")
+}
+
+// domainMatches reports whether host is domain or one of its subdomains
+func domainMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// cookieAppliesTo reports whether a cookie would be sent to the profile domain or any of its subdomains
+func cookieAppliesTo(cookie entity.FetchCookie, domain string) bool {
+	if domainMatches(cookie.Domain, domain) {
+		return true
+	}
+	return !cookie.HostOnly && domainMatches(domain, cookie.Domain)
+}
+
+func redactCookies(profile *entity.FetchProfile) {
+	for i := range profile.Cookies {
+		profile.Cookies[i].Value = ""
+	}
+}
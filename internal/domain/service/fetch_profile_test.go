@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"garden3/internal/domain/entity"
+)
+
+func TestCookieAppliesTo(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cookie entity.FetchCookie
+		domain string
+		want   bool
+	}{
+		{name: "same domain", cookie: entity.FetchCookie{Domain: "medium.com", HostOnly: true}, domain: "medium.com", want: true},
+		{name: "subdomain cookie", cookie: entity.FetchCookie{Domain: "www.medium.com", HostOnly: true}, domain: "medium.com", want: true},
+		{name: "parent domain cookie", cookie: entity.FetchCookie{Domain: "medium.com"}, domain: "blog.medium.com", want: true},
+		{name: "parent host-only cookie", cookie: entity.FetchCookie{Domain: "medium.com", HostOnly: true}, domain: "blog.medium.com", want: false},
+		{name: "suffix is not a subdomain", cookie: entity.FetchCookie{Domain: "notmedium.com"}, domain: "medium.com", want: false},
+		{name: "other site", cookie: entity.FetchCookie{Domain: "lwn.net"}, domain: "medium.com", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cookieAppliesTo(tc.cookie, tc.domain); got != tc.want {
+				t.Errorf("cookieAppliesTo() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"strings"
 
 	"garden3/internal/domain/entity"
 	"garden3/internal/port/input"
@@ -26,156 +24,14 @@ func NewNoteService(repo output.NoteRepository, embeddingsService output.Embeddi
 	}
 }
 
-var (
-	// Matches [[text]] or [[display][text]]
-	entityRefRegex = regexp.MustCompile(`\[\[(.*?)(?:\]\[([^\]]+))?\]\]`)
-	uuidRegex      = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
-)
-
-// processContentForStorage converts entity references to entity IDs
-// Converts:
-// - [[Entity Name]] to [[entity-id]]
-// - [[Display Text][Entity Name]] to [[Display Text][entity-id]]
+// processContentForStorage converts entity references to entity IDs, recording them for the note
 func (s *NoteService) processContentForStorage(ctx context.Context, content string, sourceType string, sourceID uuid.UUID) (string, error) {
-	if content == "" {
-		return content, nil
-	}
-
-	processedContent := content
-	matches := entityRefRegex.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		original := match[0]
-		firstPart := match[1]
-		secondPart := ""
-		if len(match) > 2 {
-			secondPart = match[2]
-		}
-
-		var entityID uuid.UUID
-		var displayText string
-
-		if secondPart != "" {
-			// Format: [[Display Text][Entity Name or ID]]
-			if uuidRegex.MatchString(secondPart) {
-				// Second part is already a UUID
-				parsedUUID, err := uuid.Parse(secondPart)
-				if err != nil {
-					continue
-				}
-				entityID = parsedUUID
-				displayText = firstPart
-
-				// Verify the entity exists
-				_, err = s.repo.GetEntityByID(ctx, entityID)
-				if err != nil {
-					// Entity doesn't exist, skip this reference
-					continue
-				}
-			} else {
-				// Second part is an entity name
-				existingEntity, err := s.repo.GetEntityByName(ctx, secondPart)
-				if err != nil || existingEntity == nil {
-					// Create new entity
-					createdEntityID, err := s.repo.CreateEntity(ctx, secondPart, "general", nil, make(map[string]interface{}))
-					if err != nil {
-						continue
-					}
-					entityID = *createdEntityID
-				} else {
-					entityID = existingEntity.EntityID
-				}
-				displayText = firstPart
-			}
-
-			// Replace with [[Display Text][entity-id]]
-			replacement := fmt.Sprintf("[[%s][%s]]", displayText, entityID.String())
-			processedContent = strings.Replace(processedContent, original, replacement, 1)
-		} else {
-			// Format: [[Entity Name]]
-			existingEntity, err := s.repo.GetEntityByName(ctx, firstPart)
-			if err != nil || existingEntity == nil {
-				// Create new entity
-				createdEntityID, err := s.repo.CreateEntity(ctx, firstPart, "general", nil, make(map[string]interface{}))
-				if err != nil {
-					continue
-				}
-				entityID = *createdEntityID
-			} else {
-				entityID = existingEntity.EntityID
-			}
-			displayText = firstPart
-
-			// Replace with [[entity-id]]
-			replacement := fmt.Sprintf("[[%s]]", entityID.String())
-			processedContent = strings.Replace(processedContent, original, replacement, 1)
-		}
-
-		// Record the reference
-		ref := entity.EntityReference{
-			SourceType:    sourceType,
-			SourceID:      sourceID,
-			EntityID:      entityID,
-			ReferenceText: displayText,
-			Position:      nil,
-		}
-		if err := s.repo.CreateEntityReference(ctx, ref); err != nil {
-			// Log error but continue
-			fmt.Printf("Error creating entity reference: %v\n", err)
-		}
-	}
-
-	return processedContent, nil
+	return storeEntityLinks(ctx, s.repo, content, sourceType, sourceID)
 }
 
-// processContentForDisplay converts entity IDs to display text with markdown links
-// Converts:
-// - [[entity-id]] to [Entity Name](/entities/entity-id)
-// - [[Display Text][entity-id]] to [Display Text](/entities/entity-id)
+// processContentForDisplay converts entity IDs to markdown links
 func (s *NoteService) processContentForDisplay(ctx context.Context, content string) (string, error) {
-	if content == "" {
-		return content, nil
-	}
-
-	processedContent := content
-	matches := entityRefRegex.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		original := match[0]
-		firstPart := match[1]
-		secondPart := ""
-		if len(match) > 2 {
-			secondPart = match[2]
-		}
-
-		if secondPart != "" {
-			// Format: [[display][entity-id]]
-			entityID, err := uuid.Parse(secondPart)
-			if err != nil {
-				continue
-			}
-
-			entity, err := s.repo.GetEntityByID(ctx, entityID)
-			if err == nil && entity != nil {
-				replacement := fmt.Sprintf("[%s](/entities/%s)", firstPart, secondPart)
-				processedContent = strings.Replace(processedContent, original, replacement, 1)
-			}
-		} else {
-			// Format: [[entity-id]]
-			entityID, err := uuid.Parse(firstPart)
-			if err != nil {
-				continue
-			}
-
-			entity, err := s.repo.GetEntityByID(ctx, entityID)
-			if err == nil && entity != nil {
-				replacement := fmt.Sprintf("[%s](/entities/%s)", entity.Name, firstPart)
-				processedContent = strings.Replace(processedContent, original, replacement, 1)
-			}
-		}
-	}
-
-	return processedContent, nil
+	return renderEntityLinks(ctx, s.repo, content)
 }
 
 func (s *NoteService) GetNote(ctx context.Context, noteID uuid.UUID) (*entity.FullNote, error) {
@@ -414,16 +270,12 @@ func (s *NoteService) SearchSimilarNotes(ctx context.Context, query string, stra
 		strategy = "qa-v2-passage"
 	}
 
-	embeddings, err := s.embeddingsService.GetEmbedding(ctx, query)
+	embedding, err := s.embeddingsService.EmbedQuery(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("no embedding generated for query")
-	}
-
-	results, err := s.repo.SearchSimilarNotes(ctx, embeddings[0].Embedding, strategy, 10)
+	results, err := s.repo.SearchSimilarNotes(ctx, embedding, strategy, 10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search similar notes: %w", err)
 	}
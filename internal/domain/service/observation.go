@@ -34,6 +34,9 @@ func (s *ObservationService) StoreFeedback(ctx context.Context, input entity.Sto
 		FeedbackType: input.FeedbackType,
 		Timestamp:    time.Now(),
 	}
+	if input.ReferenceID != nil {
+		feedbackData.ReferenceID = input.ReferenceID.String()
+	}
 
 	// Marshal to JSON
 	data, err := json.Marshal(feedbackData)
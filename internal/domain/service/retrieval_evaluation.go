@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+const (
+	retrievalEvaluationType   = "retrieval-eval"
+	retrievalEvaluationSource = "retrieval-harness"
+
+	defaultRetrievalK = 10
+	maxRetrievalK     = 100
+
+	// semanticOverfetch asks for more references than K because a bookmark has several references per
+	// strategy, and the ranking keeps only the best one of each bookmark
+	semanticOverfetch = 5
+
+	// unifiedSearchLimit bounds the unified search, which ranks contacts, rooms and history with bookmarks
+	unifiedSearchLimit = 200
+)
+
+// RetrievalEvaluationService replays the user questions recorded with Q&A feedback against the current
+// retrieval setup and scores the rankings against the bookmarks users upvoted
+type RetrievalEvaluationService struct {
+	observationRepo   output.ObservationRepository
+	bookmarkRepo      output.BookmarkRepository
+	searchRepo        output.SearchRepository
+	embeddingsService output.EmbeddingsService
+}
+
+// NewRetrievalEvaluationService creates a new retrieval evaluation service
+func NewRetrievalEvaluationService(
+	observationRepo output.ObservationRepository,
+	bookmarkRepo output.BookmarkRepository,
+	searchRepo output.SearchRepository,
+	embeddingsService output.EmbeddingsService,
+) *RetrievalEvaluationService {
+	return &RetrievalEvaluationService{
+		observationRepo:   observationRepo,
+		bookmarkRepo:      bookmarkRepo,
+		searchRepo:        searchRepo,
+		embeddingsService: embeddingsService,
+	}
+}
+
+func (s *RetrievalEvaluationService) EvaluateRetrieval(ctx context.Context, input entity.RetrievalEvaluationInput) (*entity.RetrievalEvaluationReport, error) {
+	strategy := input.Strategy
+	if strategy == "" {
+		strategy = string(entity.StageQAPassage)
+	}
+
+	weights := entity.DefaultSearchWeights()
+	if input.Weights != nil {
+		weights = *input.Weights
+	}
+
+	k := input.K
+	if k < 1 {
+		k = defaultRetrievalK
+	}
+	if k > maxRetrievalK {
+		k = maxRetrievalK
+	}
+
+	feedback, err := s.observationRepo.ListFeedback(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feedback: %w", err)
+	}
+
+	queries := feedbackQueries(feedback)
+	if input.MaxQueries > 0 && len(queries) > input.MaxQueries {
+		queries = queries[len(queries)-input.MaxQueries:]
+	}
+	if len(queries) == 0 {
+		return nil, entity.ErrNoFeedbackQueries
+	}
+
+	// Semantic rankings are refused while the live model differs from the active one, so the live model
+	// is the one the stored vectors were made with
+	report := &entity.RetrievalEvaluationReport{
+		EmbeddingModel: s.embeddingsService.Model(),
+		Strategy:       strategy,
+		Weights:        weights,
+		K:              k,
+		Queries:        len(queries),
+		Results:        make([]entity.RetrievalQueryResult, 0, len(queries)),
+	}
+
+	for _, query := range queries {
+		semantic, err := s.semanticRanking(ctx, query.UserQuestion, strategy, k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay %q: %w", query.UserQuestion, err)
+		}
+		unified, err := s.unifiedRanking(ctx, query.UserQuestion, weights, k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay %q: %w", query.UserQuestion, err)
+		}
+
+		semanticMetrics, semanticRank := rankingMetrics(semantic, query.Relevant, k)
+		unifiedMetrics, unifiedRank := rankingMetrics(unified, query.Relevant, k)
+
+		report.Semantic.RecallAtK += semanticMetrics.RecallAtK
+		report.Semantic.MRR += semanticMetrics.MRR
+		report.Semantic.NDCG += semanticMetrics.NDCG
+		report.Unified.RecallAtK += unifiedMetrics.RecallAtK
+		report.Unified.MRR += unifiedMetrics.MRR
+		report.Unified.NDCG += unifiedMetrics.NDCG
+
+		report.Results = append(report.Results, entity.RetrievalQueryResult{
+			UserQuestion: query.UserQuestion,
+			Relevant:     len(query.Relevant),
+			SemanticRank: semanticRank,
+			UnifiedRank:  unifiedRank,
+		})
+	}
+
+	n := float64(len(queries))
+	report.Semantic = entity.RetrievalMetrics{
+		RecallAtK: report.Semantic.RecallAtK / n,
+		MRR:       report.Semantic.MRR / n,
+		NDCG:      report.Semantic.NDCG / n,
+	}
+	report.Unified = entity.RetrievalMetrics{
+		RecallAtK: report.Unified.RecallAtK / n,
+		MRR:       report.Unified.MRR / n,
+		NDCG:      report.Unified.NDCG / n,
+	}
+	report.EvaluatedAt = time.Now()
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	tags := fmt.Sprintf("evaluation,retrieval,%s", strategy)
+	observation, err := s.observationRepo.Create(ctx, data, retrievalEvaluationType, retrievalEvaluationSource, tags, uuid.Nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store report: %w", err)
+	}
+	report.ObservationID = &observation.ObservationID
+
+	return report, nil
+}
+
+func (s *RetrievalEvaluationService) ListRetrievalEvaluations(ctx context.Context, limit int32) ([]entity.RetrievalEvaluationReport, error) {
+	if limit < 1 {
+		limit = 20
+	}
+
+	observations, err := s.observationRepo.ListByType(ctx, retrievalEvaluationType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	reports := make([]entity.RetrievalEvaluationReport, 0, len(observations))
+	for _, observation := range observations {
+		var report entity.RetrievalEvaluationReport
+		if err := json.Unmarshal(observation.Data, &report); err != nil {
+			continue
+		}
+		observationID := observation.ObservationID
+		report.ObservationID = &observationID
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// semanticRanking ranks bookmarks by the embedding similarity of their references of a strategy to the question
+func (s *RetrievalEvaluationService) semanticRanking(ctx context.Context, question, strategy string, k int) ([]uuid.UUID, error) {
+	embedding, err := s.embeddingsService.EmbedQuery(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	results, err := s.bookmarkRepo.SearchSimilarBookmarks(ctx, embedding, strategy, int32(k*semanticOverfetch))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search similar bookmarks: %w", err)
+	}
+
+	ranked := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		ranked = append(ranked, result.BookmarkID)
+	}
+	return uniqueRanking(ranked, k), nil
+}
+
+// unifiedRanking ranks the bookmarks among the results of the weighted search across all items
+func (s *RetrievalEvaluationService) unifiedRanking(ctx context.Context, question string, weights entity.SearchWeights, k int) ([]uuid.UUID, error) {
+	results, err := s.searchRepo.SearchAll(ctx, question, weights.ExactMatchWeight, weights.SimilarityWeight, weights.RecencyWeight, unifiedSearchLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	ranked := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		if result.ItemType != "bookmark" {
+			continue
+		}
+		if id, err := uuid.Parse(result.ItemID); err == nil {
+			ranked = append(ranked, id)
+		}
+	}
+	return uniqueRanking(ranked, k), nil
+}
+
+// uniqueRanking keeps the first occurrence of each bookmark and cuts the ranking at k
+func uniqueRanking(ranked []uuid.UUID, k int) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ranked))
+	unique := make([]uuid.UUID, 0, k)
+	for _, id := range ranked {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+		if len(unique) == k {
+			break
+		}
+	}
+	return unique
+}
+
+// feedbackQueries groups feedback by user question, ignoring case and spacing. A bookmark is relevant to a
+// question when the latest feedback given on it for that question is an upvote. Questions keep the order
+// in which they were first asked, and those without a relevant bookmark are dropped
+func feedbackQueries(feedback []entity.FeedbackData) []entity.FeedbackQuery {
+	type votes struct {
+		question string
+		latest   map[uuid.UUID]entity.FeedbackType
+		order    []uuid.UUID
+	}
+
+	byQuestion := make(map[string]*votes)
+	var keys []string
+	for _, entry := range feedback {
+		key := strings.ToLower(strings.Join(strings.Fields(entry.UserQuestion), " "))
+		if key == "" {
+			continue
+		}
+		bookmarkID, err := uuid.Parse(entry.BookmarkID)
+		if err != nil {
+			continue
+		}
+
+		v, ok := byQuestion[key]
+		if !ok {
+			v = &votes{question: strings.TrimSpace(entry.UserQuestion), latest: make(map[uuid.UUID]entity.FeedbackType)}
+			byQuestion[key] = v
+			keys = append(keys, key)
+		}
+		if _, ok := v.latest[bookmarkID]; !ok {
+			v.order = append(v.order, bookmarkID)
+		}
+		v.latest[bookmarkID] = entry.FeedbackType
+	}
+
+	queries := make([]entity.FeedbackQuery, 0, len(keys))
+	for _, key := range keys {
+		v := byQuestion[key]
+		var relevant []uuid.UUID
+		for _, bookmarkID := range v.order {
+			if v.latest[bookmarkID] == entity.FeedbackUpvote {
+				relevant = append(relevant, bookmarkID)
+			}
+		}
+		if len(relevant) > 0 {
+			queries = append(queries, entity.FeedbackQuery{UserQuestion: v.question, Relevant: relevant})
+		}
+	}
+	return queries
+}
+
+// rankingMetrics scores the top k of a ranking against the relevant bookmarks with binary relevance, and
+// returns the 1-based rank of the first relevant bookmark, or 0 if none is in the top k
+func rankingMetrics(ranked, relevant []uuid.UUID, k int) (entity.RetrievalMetrics, int) {
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	isRelevant := make(map[uuid.UUID]bool, len(relevant))
+	for _, id := range relevant {
+		isRelevant[id] = true
+	}
+
+	var metrics entity.RetrievalMetrics
+	var hits, firstRank int
+	var dcg float64
+	for i, id := range ranked {
+		if !isRelevant[id] {
+			continue
+		}
+		hits++
+		dcg += 1 / math.Log2(float64(i+2))
+		if firstRank == 0 {
+			firstRank = i + 1
+		}
+	}
+
+	var idcg float64
+	for i := 0; i < min(len(isRelevant), k); i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+
+	if len(isRelevant) > 0 {
+		metrics.RecallAtK = float64(hits) / float64(len(isRelevant))
+	}
+	if firstRank > 0 {
+		metrics.MRR = 1 / float64(firstRank)
+	}
+	if idcg > 0 {
+		metrics.NDCG = dcg / idcg
+	}
+	return metrics, firstRank
+}
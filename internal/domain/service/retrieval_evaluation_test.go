@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+	"garden3/internal/port/output"
+)
+
+// feedbackObservations is an observation repository that lists recorded feedback and stores reports
+type feedbackObservations struct {
+	output.ObservationRepository
+	feedback []entity.FeedbackData
+}
+
+func (r *feedbackObservations) ListFeedback(ctx context.Context) ([]entity.FeedbackData, error) {
+	return r.feedback, nil
+}
+
+func (r *feedbackObservations) Create(ctx context.Context, data []byte, obsType, source, tags string, ref uuid.UUID) (*entity.Observation, error) {
+	return &entity.Observation{ObservationID: uuid.New()}, nil
+}
+
+// rankedBookmarks is a bookmark repository whose similarity search returns fixed bookmarks
+type rankedBookmarks struct {
+	output.BookmarkRepository
+	results []entity.BookmarkWithTitle
+}
+
+func (r *rankedBookmarks) SearchSimilarBookmarks(ctx context.Context, embedding []float32, strategy string, limit int32) ([]entity.BookmarkWithTitle, error) {
+	return r.results, nil
+}
+
+// emptySearch is a search repository that finds nothing
+type emptySearch struct {
+	output.SearchRepository
+}
+
+func (r emptySearch) SearchAll(ctx context.Context, query string, exactMatchWeight, similarityWeight, recencyWeight float64, limit int32) ([]entity.UnifiedSearchResult, error) {
+	return nil, nil
+}
+
+// switchableEmbeddings is an embeddings service whose model can change while the service is in use
+type switchableEmbeddings struct {
+	output.EmbeddingsService
+	model string
+}
+
+func (e *switchableEmbeddings) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return []float32{1, 0}, nil
+}
+
+func (e *switchableEmbeddings) Model() string { return e.model }
+
+func TestEvaluateRetrievalRecordsCurrentModel(t *testing.T) {
+	bookmarkID := uuid.New()
+	observations := &feedbackObservations{feedback: []entity.FeedbackData{
+		{BookmarkID: bookmarkID.String(), UserQuestion: "how do I prune roses", FeedbackType: entity.FeedbackUpvote},
+	}}
+	embeddings := &switchableEmbeddings{model: "first-model"}
+	s := NewRetrievalEvaluationService(observations, &rankedBookmarks{results: []entity.BookmarkWithTitle{{BookmarkID: bookmarkID}}}, emptySearch{}, embeddings)
+
+	for _, model := range []string{"first-model", "second-model"} {
+		embeddings.model = model
+		report, err := s.EvaluateRetrieval(context.Background(), entity.RetrievalEvaluationInput{})
+		if err != nil {
+			t.Fatalf("EvaluateRetrieval() error = %v", err)
+		}
+		if report.EmbeddingModel != model {
+			t.Errorf("report names model %q, want %q", report.EmbeddingModel, model)
+		}
+		if report.Queries != 1 {
+			t.Errorf("replayed %d queries, want 1", report.Queries)
+		}
+	}
+}
+
+func TestFeedbackQueries(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	feedback := []entity.FeedbackData{
+		{UserQuestion: "How do I  deploy?", BookmarkID: first.String(), FeedbackType: entity.FeedbackUpvote},
+		{UserQuestion: "how do i deploy?", BookmarkID: second.String(), FeedbackType: entity.FeedbackUpvote},
+		{UserQuestion: "How do I deploy?", BookmarkID: second.String(), FeedbackType: entity.FeedbackDownvote},
+		{UserQuestion: "Only disliked", BookmarkID: first.String(), FeedbackType: entity.FeedbackTrash},
+		{UserQuestion: "", BookmarkID: first.String(), FeedbackType: entity.FeedbackUpvote},
+		{UserQuestion: "Bad id", BookmarkID: "not-a-uuid", FeedbackType: entity.FeedbackUpvote},
+	}
+
+	queries := feedbackQueries(feedback)
+	if len(queries) != 1 {
+		t.Fatalf("feedbackQueries() returned %d queries, want 1", len(queries))
+	}
+	if queries[0].UserQuestion != "How do I  deploy?" {
+		t.Errorf("feedbackQueries() question = %q, want the first wording", queries[0].UserQuestion)
+	}
+	if len(queries[0].Relevant) != 1 || queries[0].Relevant[0] != first {
+		t.Errorf("feedbackQueries() relevant = %v, want only %s", queries[0].Relevant, first)
+	}
+}
+
+func TestRankingMetrics(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	testCases := []struct {
+		name       string
+		ranked     []uuid.UUID
+		relevant   []uuid.UUID
+		k          int
+		wantRank   int
+		wantMRR    float64
+		wantNDCG   float64
+		wantRecall float64
+	}{
+		{
+			name:       "relevant first",
+			ranked:     []uuid.UUID{a, b, c},
+			relevant:   []uuid.UUID{a},
+			k:          3,
+			wantRank:   1,
+			wantMRR:    1,
+			wantNDCG:   1,
+			wantRecall: 1,
+		},
+		{
+			name:       "relevant second",
+			ranked:     []uuid.UUID{b, a, c},
+			relevant:   []uuid.UUID{a},
+			k:          3,
+			wantRank:   2,
+			wantMRR:    0.5,
+			wantNDCG:   1 / math.Log2(3),
+			wantRecall: 1,
+		},
+		{
+			name:       "half retrieved",
+			ranked:     []uuid.UUID{a, b},
+			relevant:   []uuid.UUID{a, d},
+			k:          2,
+			wantRank:   1,
+			wantMRR:    1,
+			wantNDCG:   1 / (1 + 1/math.Log2(3)),
+			wantRecall: 0.5,
+		},
+		{
+			name:     "beyond k",
+			ranked:   []uuid.UUID{b, c, a},
+			relevant: []uuid.UUID{a},
+			k:        2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics, rank := rankingMetrics(tc.ranked, tc.relevant, tc.k)
+			if rank != tc.wantRank {
+				t.Errorf("rankingMetrics() rank = %d, want %d", rank, tc.wantRank)
+			}
+			if math.Abs(metrics.MRR-tc.wantMRR) > 1e-9 {
+				t.Errorf("rankingMetrics() MRR = %f, want %f", metrics.MRR, tc.wantMRR)
+			}
+			if math.Abs(metrics.NDCG-tc.wantNDCG) > 1e-9 {
+				t.Errorf("rankingMetrics() nDCG = %f, want %f", metrics.NDCG, tc.wantNDCG)
+			}
+			if math.Abs(metrics.RecallAtK-tc.wantRecall) > 1e-9 {
+				t.Errorf("rankingMetrics() recall = %f, want %f", metrics.RecallAtK, tc.wantRecall)
+			}
+		})
+	}
+}
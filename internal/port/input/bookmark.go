@@ -2,6 +2,7 @@ package input
 
 import (
 	"context"
+	"io"
 
 	"github.com/google/uuid"
 	"garden3/internal/domain/entity"
@@ -36,6 +37,48 @@ type BookmarkUseCase interface {
 	// ProcessWithReader processes bookmark content using reader mode
 	ProcessWithReader(ctx context.Context, bookmarkID uuid.UUID) (*entity.ProcessingResult, error)
 
+	// MigrateBookmarkURLs rewrites stored bookmark URLs to their canonical form, merges bookmarks whose URLs
+	// turn out the same into the oldest one and then enforces unique URLs
+	MigrateBookmarkURLs(ctx context.Context) (*entity.BookmarkURLMigration, error)
+
+	// ExtractBookmarkMetadata parses the fetched page for OpenGraph, Twitter card, meta tag and JSON-LD
+	// metadata, computes word count and reading time from the readable content, and stores the result
+	ExtractBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkMetadata, error)
+
+	// ArchiveBookmark stores an offline copy of the fetched page, as a self-contained HTML snapshot and a WARC file.
+	// It returns nil when archiving is disabled or the content is not HTML
+	ArchiveBookmark(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkArchive, error)
+
+	// ListBookmarkArchives retrieves the stored archives of a bookmark, newest first
+	ListBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkArchive, error)
+
+	// OpenBookmarkArchive opens the latest archive of a bookmark in the given format, returning its record,
+	// content and size. The caller closes the content
+	OpenBookmarkArchive(ctx context.Context, bookmarkID uuid.UUID, format entity.ArchiveFormat) (*entity.BookmarkArchive, io.ReadCloser, int64, error)
+
+	// CreateBookmarkEvaluation records a review of a bookmark's summary and generated questions, together with
+	// the strategy, model and prompt version that produced them
+	CreateBookmarkEvaluation(ctx context.Context, bookmarkID uuid.UUID, input entity.CreateEvaluationInput) (*entity.BookmarkEvaluation, error)
+
+	// ListBookmarkEvaluations retrieves the evaluations of a bookmark, newest first
+	ListBookmarkEvaluations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkEvaluation, error)
+
+	// GetEvaluationReport averages evaluation scores by strategy, model and prompt version, optionally per period
+	GetEvaluationReport(ctx context.Context, filters entity.EvaluationReportFilters) (*entity.EvaluationReport, error)
+
+	// GetRejectedQuestionBookmarks retrieves bookmarks whose Q&A pairs were downvoted, trashed or deleted
+	// and have not been replaced yet, oldest rejection first
+	GetRejectedQuestionBookmarks(ctx context.Context, limit int32) ([]entity.QARegenerationCandidate, error)
+
+	// RegenerateRejectedQuestions replaces the rejected Q&A pairs of a bookmark with new ones generated with the
+	// rejected pairs as negative examples, and records a qa-regeneration observation linked to the feedback.
+	// It returns nil when nothing was rejected
+	RegenerateRejectedQuestions(ctx context.Context, bookmarkID uuid.UUID) (*entity.QARegenerationResult, error)
+
+	// MigrateHttpResponseStorage applies the response retention to every bookmark and moves responses stored
+	// before bodies were shared into compressed, content-addressed storage
+	MigrateHttpResponseStorage(ctx context.Context) (*entity.ResponseStorageMigration, error)
+
 	// CreateEmbeddingChunks creates chunked embeddings for bookmark content
 	CreateEmbeddingChunks(ctx context.Context, bookmarkID uuid.UUID) (*entity.EmbeddingResult, error)
 
@@ -45,9 +88,25 @@ type BookmarkUseCase interface {
 	// GetBookmarkTitle extracts and stores the bookmark title
 	GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (*entity.TitleExtractionResult, error)
 
+	// GenerateBookmarkQuestions generates and embeds Q&A pairs for the qa-v2-passage strategy
+	GenerateBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID, input entity.GenerateQuestionsInput) (*entity.GenerateQuestionsResult, error)
+
+	// GetBookmarkStatus reports the processing state of each stage and the recent run history
+	GetBookmarkStatus(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkStatus, error)
+
 	// GetMissingHttpResponses retrieves bookmarks without HTTP responses
 	GetMissingHttpResponses(ctx context.Context) ([]entity.Bookmark, error)
 
 	// GetMissingReaderContent retrieves bookmarks without reader-processed content
 	GetMissingReaderContent(ctx context.Context) ([]entity.Bookmark, error)
+
+	// CreateBookmark canonicalizes the URL and creates a bookmark, returning the existing one on duplicates
+	CreateBookmark(ctx context.Context, input entity.CreateBookmarkInput) (*entity.CreateBookmarkResult, error)
+
+	// UpdateBookmark updates a bookmark's URL, title or category
+	UpdateBookmark(ctx context.Context, bookmarkID uuid.UUID, input entity.UpdateBookmarkInput) (*entity.BookmarkWithTitle, error)
+
+	// DeleteBookmark deletes a bookmark and all of its processed data. Returns entity.ErrBookmarkNotFound when
+	// the bookmark does not exist
+	DeleteBookmark(ctx context.Context, bookmarkID uuid.UUID) error
 }
@@ -0,0 +1,32 @@
+package input
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// BookmarkAnnotationUseCase defines the highlight and annotation operations on the reader text of bookmarks
+type BookmarkAnnotationUseCase interface {
+	// CreateAnnotation highlights a quote or position range of a bookmark's reader text, with an optional note and tags
+	CreateAnnotation(ctx context.Context, bookmarkID uuid.UUID, input entity.CreateAnnotationInput) (*entity.BookmarkAnnotation, error)
+
+	// GetAnnotation retrieves an annotation, anchored in the current reader text
+	GetAnnotation(ctx context.Context, annotationID uuid.UUID) (*entity.BookmarkAnnotation, error)
+
+	// UpdateAnnotation changes the highlight, note or tags of an annotation
+	UpdateAnnotation(ctx context.Context, annotationID uuid.UUID, input entity.UpdateAnnotationInput) (*entity.BookmarkAnnotation, error)
+
+	// DeleteAnnotation deletes an annotation and its entity references
+	DeleteAnnotation(ctx context.Context, annotationID uuid.UUID) error
+
+	// ListBookmarkAnnotations retrieves the annotations of a bookmark in reading order, anchored in the current reader text
+	ListBookmarkAnnotations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkAnnotation, error)
+
+	// ListAnnotations retrieves annotations across all bookmarks, newest first
+	ListAnnotations(ctx context.Context, filters entity.AnnotationFilters) (*PaginatedResponse[entity.BookmarkAnnotation], error)
+
+	// SearchAnnotations retrieves the annotations whose quote and note are most similar to a query
+	SearchAnnotations(ctx context.Context, query string) ([]entity.BookmarkAnnotation, error)
+}
@@ -0,0 +1,31 @@
+package input
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// BookmarkCategorizationUseCase defines the business operations for suggesting bookmark categories
+type BookmarkCategorizationUseCase interface {
+	// RefreshCategoryProfiles rebuilds the profile of every category from the summary embeddings of its
+	// bookmarks and the embedding of its name and sources
+	RefreshCategoryProfiles(ctx context.Context) (*entity.CategoryProfileRefresh, error)
+
+	// GetBookmarksToCategorize retrieves uncategorized bookmarks with a summary and no open suggestion
+	GetBookmarksToCategorize(ctx context.Context, limit int32) ([]uuid.UUID, error)
+
+	// SuggestBookmarkCategory suggests the category whose profile is closest to the bookmark's summary,
+	// assigning it when the confidence reaches the threshold and the bookmark has no category yet.
+	// It returns nil when the bookmark has no summary embedding or no category has a profile
+	SuggestBookmarkCategory(ctx context.Context, bookmarkID uuid.UUID) (*entity.CategorySuggestion, error)
+
+	// ListCategorySuggestions retrieves category suggestions with a status, pending by default
+	ListCategorySuggestions(ctx context.Context, filters entity.CategorySuggestionFilters) (*PaginatedResponse[entity.CategorySuggestion], error)
+
+	// ReviewCategorySuggestion accepts or rejects a pending suggestion. Accepting assigns the category and
+	// adds the bookmark to its profile; a rejected category is not suggested for the bookmark again.
+	// It returns nil if the suggestion does not exist
+	ReviewCategorySuggestion(ctx context.Context, suggestionID uuid.UUID, accept bool) (*entity.CategorySuggestion, error)
+}
@@ -0,0 +1,21 @@
+package input
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// BookmarkImportUseCase defines the import and export operations for bookmark interchange files
+type BookmarkImportUseCase interface {
+	// ImportBookmarks reads a bookmark file and creates a bookmark for every new entry, saving progress so it can be resumed
+	ImportBookmarks(ctx context.Context, r io.Reader, input entity.ImportBookmarksInput) (*entity.ImportResult, error)
+
+	// GetImport retrieves the progress of an import, returning nil if none exists
+	GetImport(ctx context.Context, importID uuid.UUID) (*entity.ImportResult, error)
+
+	// ExportBookmarks writes every bookmark grouped by category as a Netscape bookmark file
+	ExportBookmarks(ctx context.Context, w io.Writer) error
+}
@@ -0,0 +1,20 @@
+package input
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// BookmarkLinkCheckUseCase defines the link-rot monitoring operations for bookmarks
+type BookmarkLinkCheckUseCase interface {
+	// CheckBookmarkLink re-fetches a bookmark's URL and records whether it died, moved to another site or changed
+	CheckBookmarkLink(ctx context.Context, bookmarkID uuid.UUID) (*entity.LinkCheck, error)
+
+	// GetDueLinkChecks returns up to limit bookmarks whose last link check is older than the check interval
+	GetDueLinkChecks(ctx context.Context, limit int32) ([]entity.Bookmark, error)
+
+	// ListLinkIssues retrieves bookmarks whose latest link check found them dead, parked, redirected or changed
+	ListLinkIssues(ctx context.Context, filters entity.LinkIssueFilters) (*PaginatedResponse[entity.LinkIssue], error)
+}
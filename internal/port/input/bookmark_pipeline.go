@@ -0,0 +1,17 @@
+package input
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// BookmarkPipelineUseCase defines the automatic ingestion operations for bookmarks
+type BookmarkPipelineUseCase interface {
+	// ProcessBookmark runs the ingestion stages for a bookmark starting at the given stage
+	ProcessBookmark(ctx context.Context, bookmarkID uuid.UUID, from entity.PipelineStage) (*entity.PipelineResult, error)
+
+	// GetBackfillJobs returns the bookmarks whose ingestion never completed
+	GetBackfillJobs(ctx context.Context) ([]entity.PipelineJob, error)
+}
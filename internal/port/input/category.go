@@ -18,9 +18,16 @@ type CategoryUseCase interface {
 	// UpdateCategory updates a category's name
 	UpdateCategory(ctx context.Context, categoryID uuid.UUID, name string) error
 
-	// MergeCategories merges two categories
+	// MergeCategories merges two categories, the children of the source move under the target
 	MergeCategories(ctx context.Context, input entity.MergeCategoriesInput) error
 
+	// MoveCategory moves a category with its subtree under another category or to the top level
+	MoveCategory(ctx context.Context, categoryID uuid.UUID, input entity.MoveCategoryInput) error
+
+	// ReparentCategoryChildren moves the children of a category under another category or to the top level,
+	// returning the IDs of the moved categories
+	ReparentCategoryChildren(ctx context.Context, categoryID uuid.UUID, input entity.MoveCategoryInput) ([]uuid.UUID, error)
+
 	// CreateCategorySource adds a source to a category
 	CreateCategorySource(ctx context.Context, input entity.CreateCategorySourceInput) (*entity.CategorySource, error)
 
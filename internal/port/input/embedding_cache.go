@@ -0,0 +1,17 @@
+package input
+
+import (
+	"context"
+
+	"garden3/internal/domain/entity"
+)
+
+// EmbeddingCacheUseCase defines the operations for watching and trimming the cache of embedded texts
+type EmbeddingCacheUseCase interface {
+	// GetEmbeddingCacheStats returns the hit rate of the cache since the start and the cached vectors
+	GetEmbeddingCacheStats(ctx context.Context) (*entity.EmbeddingCacheStats, error)
+
+	// EvictEmbeddingCache removes the vectors unused for longer than the configured maximum age, then those
+	// beyond the configured maximum number of entries, least recently used first
+	EvictEmbeddingCache(ctx context.Context) (*entity.EmbeddingCacheEviction, error)
+}
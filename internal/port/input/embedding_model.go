@@ -0,0 +1,41 @@
+package input
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// EmbeddingModelUseCase defines the operations for tracking the model of stored embeddings and migrating the
+// corpus to another model
+type EmbeddingModelUseCase interface {
+	// ActivateLiveModel makes the live model the one similarity searches compare against when no model is
+	// active yet. A model that differs from the active one is left inactive until a migration cuts over to it
+	ActivateLiveModel(ctx context.Context) error
+
+	// GetEmbeddingModelStatus returns the active and live models, the stored vectors per model and the open
+	// migration, if any
+	GetEmbeddingModelStatus(ctx context.Context) (*entity.EmbeddingModelStatus, error)
+
+	// StartEmbeddingMigration checks that the target model embeds texts and starts re-embedding the corpus
+	// with it
+	StartEmbeddingMigration(ctx context.Context, target entity.EmbeddingModelConfig) (*entity.EmbeddingMigration, error)
+
+	// GetEmbeddingMigration returns a migration, or nil if it does not exist
+	GetEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error)
+
+	// ListEmbeddingMigrations returns the latest migrations, newest first
+	ListEmbeddingMigrations(ctx context.Context, limit int32) ([]entity.EmbeddingMigration, error)
+
+	// ProcessEmbeddingMigration stages the vectors of up to batchSize rows for the running migration, marking
+	// it ready once no row is left. Returns nil when no migration is running
+	ProcessEmbeddingMigration(ctx context.Context, batchSize int32) (*entity.EmbeddingMigration, error)
+
+	// CutoverEmbeddingMigration embeds the rows changed since a ready migration staged them, swaps the staged
+	// vectors in, makes the target model active and switches the live model to it
+	CutoverEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingCutover, error)
+
+	// CancelEmbeddingMigration stops an open migration and drops its staged vectors
+	CancelEmbeddingMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error)
+}
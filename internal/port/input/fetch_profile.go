@@ -0,0 +1,26 @@
+package input
+
+import (
+	"context"
+	"io"
+
+	"garden3/internal/domain/entity"
+)
+
+// FetchProfileUseCase defines the operations for managing per-domain fetch profiles
+type FetchProfileUseCase interface {
+	// ListFetchProfiles returns all fetch profiles, without cookie values
+	ListFetchProfiles(ctx context.Context) ([]entity.FetchProfile, error)
+
+	// GetFetchProfile returns the profile of a domain without cookie values, or nil if none exists
+	GetFetchProfile(ctx context.Context, domain string) (*entity.FetchProfile, error)
+
+	// SaveFetchProfile creates or replaces the settings of a domain's profile, keeping its cookies
+	SaveFetchProfile(ctx context.Context, domain string, input entity.FetchProfileInput) (*entity.FetchProfile, error)
+
+	// DeleteFetchProfile deletes the profile of a domain
+	DeleteFetchProfile(ctx context.Context, domain string) error
+
+	// ImportFetchProfileCookies replaces a profile's cookies with those of a cookies.txt file that belong to its domain
+	ImportFetchProfileCookies(ctx context.Context, domain string, r io.Reader) (*entity.CookieImportResult, error)
+}
@@ -0,0 +1,17 @@
+package input
+
+import (
+	"context"
+
+	"garden3/internal/domain/entity"
+)
+
+// RetrievalEvaluationUseCase defines the offline evaluation of retrieval against recorded Q&A feedback
+type RetrievalEvaluationUseCase interface {
+	// EvaluateRetrieval replays the user questions with positive feedback against the current embedding model,
+	// strategy and search weights, computes recall@k, MRR and nDCG, and stores the report as an observation
+	EvaluateRetrieval(ctx context.Context, input entity.RetrievalEvaluationInput) (*entity.RetrievalEvaluationReport, error)
+
+	// ListRetrievalEvaluations retrieves the newest stored reports
+	ListRetrievalEvaluations(ctx context.Context, limit int32) ([]entity.RetrievalEvaluationReport, error)
+}
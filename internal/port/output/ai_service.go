@@ -2,10 +2,25 @@ package output
 
 import (
 	"context"
+
+	"garden3/internal/domain/entity"
 )
 
 // AIService defines the interface for AI operations like summarization
 type AIService interface {
 	// GenerateSummary generates a summary of the given content
 	GenerateSummary(ctx context.Context, content, url string, maxWords int) (string, error)
+
+	// GenerateQuestions generates question/answer pairs that the given content answers. Rejected pairs are
+	// given to the model as examples not to repeat
+	GenerateQuestions(ctx context.Context, content, url string, count int, rejected []entity.QuestionAnswer) ([]entity.QuestionAnswer, error)
+
+	// Model names the model that generates summaries and questions
+	Model() string
+
+	// SummaryPromptVersion identifies the prompt used by GenerateSummary
+	SummaryPromptVersion() string
+
+	// QuestionPromptVersion identifies the prompt used by GenerateQuestions
+	QuestionPromptVersion() string
 }
@@ -0,0 +1,20 @@
+package output
+
+import (
+	"context"
+	"io"
+
+	"garden3/internal/domain/entity"
+)
+
+// BookmarkParser defines the interface for reading bookmark interchange files
+type BookmarkParser interface {
+	// Parse reads entries from r, calling emit for each one as soon as it is complete
+	Parse(ctx context.Context, r io.Reader, emit func(entity.ImportedBookmark) error) error
+}
+
+// BookmarkWriter defines the interface for writing bookmark interchange files
+type BookmarkWriter interface {
+	// Write writes the folders and their bookmarks to w
+	Write(w io.Writer, folders []entity.ExportFolder) error
+}
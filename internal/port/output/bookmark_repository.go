@@ -2,6 +2,7 @@ package output
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,10 +15,10 @@ type BookmarkRepository interface {
 	GetBookmark(ctx context.Context, bookmarkID uuid.UUID) (*entity.Bookmark, error)
 
 	// ListBookmarks retrieves filtered and paginated bookmarks
-	ListBookmarks(ctx context.Context, categoryID *uuid.UUID, searchQuery *string, startDate *time.Time, endDate *time.Time, limit, offset int32) ([]entity.BookmarkWithTitle, error)
+	ListBookmarks(ctx context.Context, categoryID *uuid.UUID, searchQuery *string, startDate *time.Time, endDate *time.Time, failedStage *string, author *string, site *string, publishedYear *int32, limit, offset int32) ([]entity.BookmarkWithTitle, error)
 
 	// CountBookmarks returns the total count of bookmarks matching filters
-	CountBookmarks(ctx context.Context, categoryID *uuid.UUID, searchQuery *string, startDate *time.Time, endDate *time.Time) (int64, error)
+	CountBookmarks(ctx context.Context, categoryID *uuid.UUID, searchQuery *string, startDate *time.Time, endDate *time.Time, failedStage *string, author *string, site *string, publishedYear *int32) (int64, error)
 
 	// GetRandomBookmark retrieves a random bookmark ID
 	GetRandomBookmark(ctx context.Context) (uuid.UUID, error)
@@ -31,8 +32,8 @@ type BookmarkRepository interface {
 	// SearchSimilarBookmarks performs vector similarity search
 	SearchSimilarBookmarks(ctx context.Context, embedding []float32, strategy string, limit int32) ([]entity.BookmarkWithTitle, error)
 
-	// UpdateBookmarkQuestion updates a Q&A content reference
-	UpdateBookmarkQuestion(ctx context.Context, content string, embedding []float32, referenceID, bookmarkID uuid.UUID) error
+	// UpdateBookmarkQuestion updates a Q&A content reference with an embedding made by model
+	UpdateBookmarkQuestion(ctx context.Context, content string, embedding []float32, model string, referenceID, bookmarkID uuid.UUID) error
 
 	// DeleteBookmarkQuestion deletes a Q&A content reference
 	DeleteBookmarkQuestion(ctx context.Context, referenceID, bookmarkID uuid.UUID) error
@@ -43,20 +44,44 @@ type BookmarkRepository interface {
 	// CreateObservation creates an observation log entry
 	CreateObservation(ctx context.Context, data, observationType, source, tags, ref string) error
 
-	// InsertHttpResponse stores an HTTP response
-	InsertHttpResponse(ctx context.Context, bookmarkID uuid.UUID, statusCode int32, headers string, content []byte, fetchDate time.Time) error
+	// InsertHttpResponse stores an HTTP response along with the name of the fetch profile used, if any. A
+	// non-nil original is the body as received before transcoding, kept with its Content-Type for archives
+	InsertHttpResponse(ctx context.Context, bookmarkID uuid.UUID, statusCode int32, headers string, content, original []byte, originalContentType string, fetchDate time.Time, fetchProfile *string) error
 
 	// GetLatestHttpResponse retrieves the most recent HTTP response
 	GetLatestHttpResponse(ctx context.Context, bookmarkID uuid.UUID) (*HTTPResponse, error)
 
+	// GetOriginalHttpResponseBody retrieves the body and Content-Type a response was received with before it
+	// was transcoded, returning a nil body if it was stored unchanged
+	GetOriginalHttpResponseBody(ctx context.Context, responseID uuid.UUID) ([]byte, string, error)
+
 	// InsertProcessedContent stores processed content
 	InsertProcessedContent(ctx context.Context, bookmarkID uuid.UUID, strategyUsed, processedContent string) error
 
+	// InsertExtractedContent stores extracted document content with the extractor that produced it and its metadata
+	InsertExtractedContent(ctx context.Context, bookmarkID uuid.UUID, strategyUsed string, document *entity.ExtractedDocument) error
+
 	// GetProcessedContentByStrategy retrieves processed content by strategy
 	GetProcessedContentByStrategy(ctx context.Context, bookmarkID uuid.UUID, strategy string) (*string, error)
 
-	// CreateEmbeddingChunk creates a content reference with embedding
-	CreateEmbeddingChunk(ctx context.Context, bookmarkID uuid.UUID, content, strategy string, embedding []float32) (uuid.UUID, error)
+	// GetDocumentContent retrieves the most recently extracted text of a bookmark, preferring reader output
+	// over pdf, epub, text and markdown documents extracted at the same time, returning nil if none exists
+	GetDocumentContent(ctx context.Context, bookmarkID uuid.UUID) (*string, error)
+
+	// GetExtractedDocument retrieves the document GetDocumentContent returns with the extractor that produced
+	// it and its metadata, returning nil if none exists
+	GetExtractedDocument(ctx context.Context, bookmarkID uuid.UUID) (*entity.ExtractedDocument, error)
+
+	// CreateEmbeddingChunk creates a content reference with an embedding made by model. Embeddings of an
+	// unnamed model are stored untagged
+	CreateEmbeddingChunk(ctx context.Context, bookmarkID uuid.UUID, content, strategy string, embedding []float32, model string) (uuid.UUID, error)
+
+	// CreateEmbeddingChunkWithExtra creates a content reference with an embedding made by model and generation metadata
+	CreateEmbeddingChunkWithExtra(ctx context.Context, bookmarkID uuid.UUID, content, strategy string, embedding []float32, model string, extra json.RawMessage) (uuid.UUID, error)
+
+	// ReplaceContentChunks replaces every reference of a strategy with the chunks of a document embedded by
+	// model, recording the position of each chunk in its extra
+	ReplaceContentChunks(ctx context.Context, bookmarkID uuid.UUID, strategy, model string, chunks []entity.Embedding) ([]uuid.UUID, error)
 
 	// GetBookmarkTitle retrieves bookmark with title-related data
 	GetBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID) (*TitleData, error)
@@ -69,15 +94,202 @@ type BookmarkRepository interface {
 
 	// GetMissingReaderContent retrieves bookmarks without reader content
 	GetMissingReaderContent(ctx context.Context) ([]entity.Bookmark, error)
+
+	// GetBookmarkByURL retrieves a bookmark by exact URL, returning nil if none exists
+	GetBookmarkByURL(ctx context.Context, url string) (*entity.Bookmark, error)
+
+	// GetBookmarkWithTitle retrieves a bookmark together with its title
+	GetBookmarkWithTitle(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkWithTitle, error)
+
+	// CreateBookmark inserts a new bookmark with its title, category, source, tags and reader content in one transaction.
+	// Returns entity.ErrDuplicateBookmark when another bookmark already has the URL
+	CreateBookmark(ctx context.Context, bookmark entity.NewBookmark) (*entity.Bookmark, error)
+
+	// UpdateBookmarkURL changes the URL of a bookmark. Returns entity.ErrDuplicateBookmark when another bookmark
+	// already has the URL
+	UpdateBookmarkURL(ctx context.Context, bookmarkID uuid.UUID, url string) error
+
+	// ReplaceBookmarkTitle replaces every stored title of a bookmark with a single title
+	ReplaceBookmarkTitle(ctx context.Context, bookmarkID uuid.UUID, title, source string) error
+
+	// SetBookmarkCategory replaces the category of a bookmark, nil removes it
+	SetBookmarkCategory(ctx context.Context, bookmarkID uuid.UUID, categoryID *uuid.UUID) error
+
+	// DeleteBookmark deletes a bookmark and all of its dependent rows. Returns entity.ErrBookmarkNotFound when
+	// the bookmark does not exist
+	DeleteBookmark(ctx context.Context, bookmarkID uuid.UUID) error
+
+	// ListBookmarkURLs retrieves the URL of every bookmark, oldest bookmark first
+	ListBookmarkURLs(ctx context.Context) ([]entity.Bookmark, error)
+
+	// MergeBookmarks moves the rows of the duplicate bookmarks to the kept one, deletes the duplicates and sets
+	// the URL of the kept bookmark, in one transaction
+	MergeBookmarks(ctx context.Context, keepID uuid.UUID, url string, duplicateIDs []uuid.UUID) error
+
+	// CreateBookmarkURLIndex creates the unique index on bookmark URLs unless it exists
+	CreateBookmarkURLIndex(ctx context.Context) error
+
+	// InsertStageRun records one execution of a processing stage
+	InsertStageRun(ctx context.Context, run entity.StageRunInput) error
+
+	// ListStageRuns retrieves the most recent stage runs of a bookmark
+	ListStageRuns(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.StageRun, error)
+
+	// GetStageRunSummaries retrieves the latest run and attempt counts for each stage of a bookmark
+	GetStageRunSummaries(ctx context.Context, bookmarkID uuid.UUID) ([]entity.StageRunSummary, error)
+
+	// GetStageArtifacts counts the rows each stage has produced for a bookmark
+	GetStageArtifacts(ctx context.Context, bookmarkID uuid.UUID) ([]entity.StageArtifacts, error)
+
+	// HasArchivedContent reports whether a bookmark has reader content but was never fetched
+	HasArchivedContent(ctx context.Context, bookmarkID uuid.UUID) (bool, error)
+
+	// GetLatestFetchStatus retrieves the status of the most recent HTTP response, returning nil if none exists
+	GetLatestFetchStatus(ctx context.Context, bookmarkID uuid.UUID) (*entity.FetchStatus, error)
+
+	// GetGeneratedQuestions retrieves the Q&A references of a strategy produced with the given prompt version
+	GetGeneratedQuestions(ctx context.Context, bookmarkID uuid.UUID, strategy, promptVersion string) ([]entity.BookmarkQuestion, error)
+
+	// ReplaceGeneratedQuestions replaces the references of a strategy with Q&A pairs freshly embedded by model,
+	// keeping the pairs the user edited or left feedback on
+	ReplaceGeneratedQuestions(ctx context.Context, bookmarkID uuid.UUID, strategy, model string, extra json.RawMessage, questions []entity.Embedding) ([]uuid.UUID, error)
+
+	// LockBookmarkQuestions waits for and holds the lock on the generated Q&A pairs of a bookmark until the
+	// returned function is called
+	LockBookmarkQuestions(ctx context.Context, bookmarkID uuid.UUID) (func(), error)
+
+	// ListBookmarksWithRejectedQuestions retrieves bookmarks with rejected Q&A pairs that were not replaced yet,
+	// skipping rejections whose replacement already failed maxAttempts times, oldest rejection first
+	ListBookmarksWithRejectedQuestions(ctx context.Context, maxAttempts, limit int32) ([]entity.QARegenerationCandidate, error)
+
+	// ListRejectedQuestions retrieves the rejected Q&A pairs of a bookmark that were not replaced yet, oldest first
+	ListRejectedQuestions(ctx context.Context, bookmarkID uuid.UUID) ([]entity.QARejection, error)
+
+	// ReplaceRejectedQuestions adds Q&A pairs freshly embedded by model to a strategy and removes the references
+	// of that strategy matching the rejected IDs or contents, returning the added and removed reference IDs
+	ReplaceRejectedQuestions(ctx context.Context, bookmarkID uuid.UUID, strategy, model string, extra json.RawMessage, questions []entity.Embedding, rejectedIDs []uuid.UUID, rejectedContents []string) ([]uuid.UUID, []uuid.UUID, error)
+
+	// CreateLinkedObservation creates an observation with a reference and a parent observation
+	CreateLinkedObservation(ctx context.Context, data []byte, observationType, source, tags string, ref, parent uuid.UUID) (uuid.UUID, error)
+
+	// ListBookmarksForExport retrieves every bookmark with one title and category name
+	ListBookmarksForExport(ctx context.Context) ([]entity.ExportBookmark, error)
+
+	// GetBookmarkTags retrieves the tag names of a bookmark
+	GetBookmarkTags(ctx context.Context, bookmarkID uuid.UUID) ([]string, error)
+
+	// SaveBookmarkMetadata creates or replaces the page metadata of a bookmark, setting UpdatedAt
+	SaveBookmarkMetadata(ctx context.Context, metadata *entity.BookmarkMetadata) error
+
+	// GetBookmarkMetadata retrieves the page metadata of a bookmark, returning nil if none exists
+	GetBookmarkMetadata(ctx context.Context, bookmarkID uuid.UUID) (*entity.BookmarkMetadata, error)
+
+	// CreateImport records the start of a bookmark import
+	CreateImport(ctx context.Context, format entity.ImportFormat, source *string) (*entity.ImportResult, error)
+
+	// GetImport retrieves the progress of a bookmark import, returning nil if none exists
+	GetImport(ctx context.Context, importID uuid.UUID) (*entity.ImportResult, error)
+
+	// UpdateImport saves the counters, report and status of a bookmark import
+	UpdateImport(ctx context.Context, result *entity.ImportResult) error
+
+	// InsertLinkCheck records the outcome of a link check, setting its ID
+	InsertLinkCheck(ctx context.Context, check *entity.LinkCheck) error
+
+	// ListLinkChecks retrieves the most recent link checks of a bookmark, newest first
+	ListLinkChecks(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.LinkCheck, error)
+
+	// GetLatestLinkCheckContent retrieves the readable text last seen by a link check, returning nil if none exists
+	GetLatestLinkCheckContent(ctx context.Context, bookmarkID uuid.UUID) (*string, error)
+
+	// GetBookmarksDueForLinkCheck retrieves bookmarks last checked, or saved if never checked, before the given time
+	GetBookmarksDueForLinkCheck(ctx context.Context, checkedBefore time.Time, limit int32) ([]entity.Bookmark, error)
+
+	// ListLinkIssues retrieves bookmarks whose latest link check is not ok, optionally with one status
+	ListLinkIssues(ctx context.Context, status *string, limit, offset int32) ([]entity.LinkIssue, error)
+
+	// CountLinkIssues counts bookmarks whose latest link check is not ok, optionally with one status
+	CountLinkIssues(ctx context.Context, status *string) (int64, error)
+
+	// InsertBookmarkArchive records a stored page archive, setting its ID and archive date
+	InsertBookmarkArchive(ctx context.Context, archive *entity.BookmarkArchive) error
+
+	// ListBookmarkArchives retrieves the archives of a bookmark, newest first
+	ListBookmarkArchives(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkArchive, error)
+
+	// CountArchiveBlobReferences counts the archives that use a stored file, as HTML snapshot or WARC
+	CountArchiveBlobReferences(ctx context.Context, hash string) (int64, error)
+
+	// LockArchiveBlobs holds a lock on each stored archive file until the returned function is called, so
+	// that storing and recording a file cannot interleave with counting its references and deleting it
+	LockArchiveBlobs(ctx context.Context, hashes []string) (func(), error)
+
+	// PruneHttpResponses deletes all but the latest keep responses of a bookmark, always keeping the latest
+	// successful one, and removes bodies no response refers to any more. It returns the number deleted
+	PruneHttpResponses(ctx context.Context, bookmarkID uuid.UUID, keep int) (int64, error)
+
+	// ListBookmarksOverResponseRetention lists the bookmarks that have more than keep stored responses
+	ListBookmarksOverResponseRetention(ctx context.Context, keep int) ([]uuid.UUID, error)
+
+	// MigrateInlineHttpResponses moves the bodies of up to limit responses after the given response ID stored
+	// before bodies were shared into compressed, content-addressed storage, returning how many were moved and
+	// the ID of the last one to continue after
+	MigrateInlineHttpResponses(ctx context.Context, after uuid.UUID, limit int) (int, uuid.UUID, error)
+
+	// InsertBookmarkAnnotation stores an annotation with its tags, setting its ID and dates
+	InsertBookmarkAnnotation(ctx context.Context, annotation *entity.BookmarkAnnotation) error
+
+	// UpdateBookmarkAnnotation stores the selector and note of an annotation and, unless tags is nil, replaces its tags
+	UpdateBookmarkAnnotation(ctx context.Context, annotation *entity.BookmarkAnnotation, tags []string) error
+
+	// SetBookmarkAnnotationEmbedding stores the embedding an annotation is searched by, made by model
+	SetBookmarkAnnotationEmbedding(ctx context.Context, annotationID uuid.UUID, embedding []float32, model string) error
+
+	// GetBookmarkAnnotation retrieves an annotation, returning nil if it does not exist
+	GetBookmarkAnnotation(ctx context.Context, annotationID uuid.UUID) (*entity.BookmarkAnnotation, error)
+
+	// ListBookmarkAnnotations retrieves the annotations of a bookmark in reading order
+	ListBookmarkAnnotations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkAnnotation, error)
+
+	// ListAnnotations retrieves annotations across bookmarks, newest first, optionally filtered by text and tag
+	ListAnnotations(ctx context.Context, search, tag *string, limit, offset int32) ([]entity.BookmarkAnnotation, error)
+
+	// CountAnnotations counts the annotations ListAnnotations would return without paging
+	CountAnnotations(ctx context.Context, search, tag *string) (int64, error)
+
+	// SearchSimilarAnnotations retrieves the annotations closest to an embedding
+	SearchSimilarAnnotations(ctx context.Context, embedding []float32, limit int32) ([]entity.BookmarkAnnotation, error)
+
+	// DeleteBookmarkAnnotation deletes an annotation with its tags and entity references, returning ErrAnnotationNotFound if it does not exist
+	DeleteBookmarkAnnotation(ctx context.Context, annotationID uuid.UUID) error
+
+	// DeleteAnnotationEntityReferences deletes the entity references recorded for an annotation's note
+	DeleteAnnotationEntityReferences(ctx context.Context, annotationID uuid.UUID) error
+
+	// InsertBookmarkEvaluation stores an evaluation, filling in its ID and creation time
+	InsertBookmarkEvaluation(ctx context.Context, evaluation *entity.BookmarkEvaluation) error
+
+	// ListBookmarkEvaluations retrieves the evaluations of a bookmark, newest first
+	ListBookmarkEvaluations(ctx context.Context, bookmarkID uuid.UUID) ([]entity.BookmarkEvaluation, error)
+
+	// GetLatestContentReferenceID retrieves the newest content reference of a strategy, returning nil if there is none
+	GetLatestContentReferenceID(ctx context.Context, bookmarkID uuid.UUID, strategy string) (*uuid.UUID, error)
+
+	// GetContentGenerations retrieves what produced the given content references of a bookmark, skipping unknown IDs
+	GetContentGenerations(ctx context.Context, bookmarkID uuid.UUID, ids []uuid.UUID) ([]entity.ContentGeneration, error)
+
+	// GetEvaluationReport aggregates evaluation scores by strategy, model, prompt version and optionally period
+	GetEvaluationReport(ctx context.Context, filters entity.EvaluationReportFilters) (*entity.EvaluationReport, error)
 }
 
 // HTTPResponse represents an HTTP response from the database
 type HTTPResponse struct {
-	ResponseID uuid.UUID
-	StatusCode int32
-	Headers    string
-	Content    []byte
-	FetchDate  time.Time
+	ResponseID   uuid.UUID
+	StatusCode   int32
+	Headers      string
+	Content      []byte
+	FetchDate    time.Time
+	FetchProfile *string
 }
 
 // TitleData represents bookmark data for title extraction
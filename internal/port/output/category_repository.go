@@ -16,12 +16,31 @@ type CategoryRepository interface {
 	// GetCategory retrieves a category by ID
 	GetCategory(ctx context.Context, categoryID uuid.UUID) (*entity.Category, error)
 
+	// GetCategoryByName retrieves a category by case-insensitive name among the children of parentID, or among
+	// top-level categories for a nil parentID, returning nil if none exists
+	GetCategoryByName(ctx context.Context, name string, parentID *uuid.UUID) (*entity.Category, error)
+
+	// CreateCategory creates a new category under parentID, or at the top level for a nil parentID
+	CreateCategory(ctx context.Context, name string, parentID *uuid.UUID) (*entity.Category, error)
+
 	// UpdateCategory updates a category's name
 	UpdateCategory(ctx context.Context, categoryID uuid.UUID, name string) error
 
-	// MergeCategories calls the merge_categories stored procedure
+	// MergeCategories moves the children of the source category under the target, then calls the
+	// merge_categories stored procedure. A target inside the source's subtree first takes the source's place
 	MergeCategories(ctx context.Context, sourceID, targetID uuid.UUID) error
 
+	// GetCategorySubtreeIDs retrieves the IDs of a category and all of its descendants
+	GetCategorySubtreeIDs(ctx context.Context, categoryID uuid.UUID) ([]uuid.UUID, error)
+
+	// MoveCategory moves a category under parentID, or to the top level for a nil parentID. It returns false
+	// without moving when parentID is the category itself or one of its descendants
+	MoveCategory(ctx context.Context, categoryID uuid.UUID, parentID *uuid.UUID) (bool, error)
+
+	// ReparentCategoryChildren moves the children of a category under parentID, or to the top level for a nil
+	// parentID, returning the IDs moved. Nothing moves when parentID lies within the category's subtree
+	ReparentCategoryChildren(ctx context.Context, categoryID uuid.UUID, parentID *uuid.UUID) ([]uuid.UUID, error)
+
 	// CreateCategorySource creates a new category source
 	CreateCategorySource(ctx context.Context, categoryID uuid.UUID, sourceURI *string, rawSource json.RawMessage) (*entity.CategorySource, error)
 
@@ -33,4 +52,38 @@ type CategoryRepository interface {
 
 	// GetCategorySource retrieves a category source by ID
 	GetCategorySource(ctx context.Context, sourceID uuid.UUID) (*entity.CategorySource, error)
+
+	// ListCategoryProfiles retrieves the stored category profiles
+	ListCategoryProfiles(ctx context.Context) ([]entity.CategoryProfile, error)
+
+	// RefreshCategoryProfile recomputes the profile of model for a category from the summary embeddings of its
+	// bookmarks and the embedding of its sources, all made by model. A nil sourceEmbedding keeps the stored one.
+	// It returns nil and removes the profile when there is nothing to build it from
+	RefreshCategoryProfile(ctx context.Context, categoryID uuid.UUID, sourceText string, sourceEmbedding []float32, model string) (*entity.CategoryProfile, error)
+
+	// RankCategoryProfiles retrieves the category profiles closest to a bookmark's summary embedding, leaving out
+	// categories rejected for it
+	RankCategoryProfiles(ctx context.Context, bookmarkID uuid.UUID, limit int32) ([]entity.CategoryMatch, error)
+
+	// ListBookmarksToCategorize retrieves uncategorized bookmarks with a summary embedding and no open suggestion
+	ListBookmarksToCategorize(ctx context.Context, limit int32) ([]uuid.UUID, error)
+
+	// SaveCategorySuggestion replaces the pending suggestions of a bookmark with a new one
+	SaveCategorySuggestion(ctx context.Context, bookmarkID, categoryID uuid.UUID, confidence float64, status entity.SuggestionStatus) (uuid.UUID, error)
+
+	// GetCategorySuggestion retrieves a category suggestion by ID, returning nil if none exists
+	GetCategorySuggestion(ctx context.Context, suggestionID uuid.UUID) (*entity.CategorySuggestion, error)
+
+	// ListCategorySuggestions retrieves category suggestions with a status, newest first
+	ListCategorySuggestions(ctx context.Context, status entity.SuggestionStatus, limit, offset int32) ([]entity.CategorySuggestion, error)
+
+	// CountCategorySuggestions counts category suggestions with a status
+	CountCategorySuggestions(ctx context.Context, status entity.SuggestionStatus) (int64, error)
+
+	// UpdateCategorySuggestionStatus records the review of a category suggestion, returning false when it was
+	// no longer pending
+	UpdateCategorySuggestionStatus(ctx context.Context, suggestionID uuid.UUID, status entity.SuggestionStatus) (bool, error)
+
+	// ReopenCategorySuggestion puts a reviewed category suggestion back to pending
+	ReopenCategorySuggestion(ctx context.Context, suggestionID uuid.UUID) error
 }
@@ -2,9 +2,11 @@ package output
 
 import (
 	"context"
+
+	"garden3/internal/domain/entity"
 )
 
-// ContentProcessor defines the interface for processing HTML content
+// ContentProcessor defines the interface for extracting readable text from fetched content
 type ContentProcessor interface {
 	// ProcessWithLynx processes HTML content using lynx
 	ProcessWithLynx(ctx context.Context, htmlContent string) (string, error)
@@ -13,6 +15,24 @@ type ContentProcessor interface {
 	// url parameter is optional and used for attribution in the output
 	ProcessWithReader(ctx context.Context, htmlContent []byte, url string) (string, error)
 
+	// Extract processes HTML content with the site extractor matching url, falling back to reader mode
+	Extract(ctx context.Context, htmlContent []byte, url string) (*entity.ExtractedDocument, error)
+
+	// ExtractMetadata reads OpenGraph, Twitter card, meta tag, link and JSON-LD metadata from an HTML page
+	ExtractMetadata(ctx context.Context, htmlContent []byte, url string) (*entity.PageMetadata, error)
+
+	// ProcessPDF extracts the text of a PDF document, laid out like reader output
+	ProcessPDF(ctx context.Context, content []byte, url string) (string, error)
+
+	// ProcessEPUB extracts the chapters of an EPUB book in reading order as markdown
+	ProcessEPUB(ctx context.Context, content []byte, url string) (string, error)
+
+	// ProcessPlainText decodes a plain text document using the charset of contentType
+	ProcessPlainText(ctx context.Context, content []byte, contentType, url string) (string, error)
+
+	// ProcessMarkdown decodes a Markdown document, using its leading heading as the title
+	ProcessMarkdown(ctx context.Context, content []byte, contentType, url string) (string, error)
+
 	// ProcessURL fetches and processes content from a URL
 	ProcessURL(ctx context.Context, url string) (string, error)
 }
@@ -0,0 +1,13 @@
+package output
+
+import (
+	"io"
+
+	"garden3/internal/domain/entity"
+)
+
+// CookieParser defines the interface for reading exported browser cookie jars
+type CookieParser interface {
+	// Parse reads all cookies from r
+	Parse(r io.Reader) ([]entity.FetchCookie, error)
+}
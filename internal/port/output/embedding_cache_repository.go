@@ -0,0 +1,27 @@
+package output
+
+import (
+	"context"
+	"time"
+
+	"garden3/internal/domain/entity"
+)
+
+// EmbeddingCacheRepository defines the data access operations for the embedding cache
+type EmbeddingCacheRepository interface {
+	// TouchEmbeddings returns the cached vectors of the given keys by key, counting a hit and marking them
+	// used. Keys without a vector are left out
+	TouchEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error)
+
+	// StoreEmbeddings caches vectors, replacing those already cached under their keys
+	StoreEmbeddings(ctx context.Context, embeddings []entity.CachedEmbedding) error
+
+	// GetUsage describes the cached vectors
+	GetUsage(ctx context.Context) (entity.EmbeddingCacheUsage, error)
+
+	// EvictUnused removes the vectors last used before the given time and returns how many it removed
+	EvictUnused(ctx context.Context, usedBefore time.Time) (int64, error)
+
+	// EvictExcess removes all but the maxEntries most recently used vectors and returns how many it removed
+	EvictExcess(ctx context.Context, maxEntries int32) (int64, error)
+}
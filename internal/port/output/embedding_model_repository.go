@@ -0,0 +1,58 @@
+package output
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// EmbeddingModelRepository defines the data access operations for the models of stored embeddings and the
+// migrations between them
+type EmbeddingModelRepository interface {
+	// GetActiveModel returns the model similarity searches compare against, or an empty string before a model
+	// was activated
+	GetActiveModel(ctx context.Context) (string, error)
+
+	// ActivateModel makes model the one similarity searches compare against. Vectors stored before their model
+	// was recorded are taken to be of it, the number of those is returned
+	ActivateModel(ctx context.Context, model string) (int64, error)
+
+	// ListModelUsage counts the stored vectors per table, model and dimensions
+	ListModelUsage(ctx context.Context) ([]entity.EmbeddingModelUsage, error)
+
+	// CreateMigration creates a running migration to the target model, recording the active model as its source
+	CreateMigration(ctx context.Context, target entity.EmbeddingModelConfig, remaining int) (*entity.EmbeddingMigration, error)
+
+	// GetMigration returns a migration, or nil if it does not exist
+	GetMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error)
+
+	// GetOpenMigration returns the running or ready migration, or nil if there is none
+	GetOpenMigration(ctx context.Context) (*entity.EmbeddingMigration, error)
+
+	// ListMigrations returns the latest migrations, newest first
+	ListMigrations(ctx context.Context, limit int32) ([]entity.EmbeddingMigration, error)
+
+	// CountMigrationRows counts the rows of the corpus that have no vector of the target model, neither
+	// stored nor staged by the migration from their current text
+	CountMigrationRows(ctx context.Context, migrationID uuid.UUID, targetModel string) (int, error)
+
+	// ListMigrationRows returns up to limit of the rows counted by CountMigrationRows
+	ListMigrationRows(ctx context.Context, migrationID uuid.UUID, targetModel string, limit int32) ([]entity.EmbeddingMigrationRow, error)
+
+	// StageEmbeddings stores vectors of a migration's target model next to the stored ones
+	StageEmbeddings(ctx context.Context, migrationID uuid.UUID, embeddings []entity.StagedEmbedding) error
+
+	// UpdateMigrationProgress adds staged to the done count of an open migration and records its remaining
+	// rows, status and last error. Returns nil if the migration is no longer open
+	UpdateMigrationProgress(ctx context.Context, migrationID uuid.UUID, staged, remaining int, status entity.EmbeddingMigrationStatus, errMsg *string) (*entity.EmbeddingMigration, error)
+
+	// CompleteMigration swaps the staged vectors made from unchanged texts into the corpus, makes the target
+	// model active and marks the migration completed in one transaction, returning the number of vectors
+	// swapped in. Returns nil if the migration is no longer open
+	CompleteMigration(ctx context.Context, migrationID uuid.UUID, targetModel string) (*entity.EmbeddingMigration, int64, error)
+
+	// CancelMigration marks an open migration cancelled and drops its staged vectors. Returns nil if the
+	// migration is no longer open
+	CancelMigration(ctx context.Context, migrationID uuid.UUID) (*entity.EmbeddingMigration, error)
+}
@@ -2,6 +2,7 @@ package output
 
 import (
 	"context"
+	"time"
 
 	"garden3/internal/domain/entity"
 )
@@ -11,4 +12,44 @@ type EmbeddingsService interface {
 	// GetEmbedding generates embeddings for the given text
 	// Returns chunked embeddings if text is too large
 	GetEmbedding(ctx context.Context, text string) ([]entity.Embedding, error)
+
+	// EmbedBatch generates one embedding per text without chunking, sending the texts in batches.
+	// Progress is called as batches finish when it is not nil
+	EmbedBatch(ctx context.Context, texts []string, progress func(entity.EmbeddingProgress)) ([][]float32, error)
+
+	// EmbedQuery generates one embedding for a search query. It fails with entity.ErrEmbeddingModelMismatch
+	// while the live model is not the active one
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+
+	// Model names the embedding model the vectors are made with, "" when it cannot tell
+	Model() string
+}
+
+// EmbeddingModels manages the model texts are embedded with
+type EmbeddingModels interface {
+	// Live returns the configuration of the model new texts are embedded with
+	Live() entity.EmbeddingModelConfig
+
+	// Open fills in the provider defaults of a model configuration and creates an embeddings service for it,
+	// independent of the live model
+	Open(config entity.EmbeddingModelConfig) (entity.EmbeddingModelConfig, EmbeddingsService, error)
+
+	// Activate records the active model, the one the stored vectors searches compare against are made with.
+	// Search queries are refused while the live model is another one
+	Activate(model string)
+
+	// Switch makes a model configuration the live and active one for every embedding service and stores it in
+	// the embedding configurations, so it stays live after a restart
+	Switch(ctx context.Context, config entity.EmbeddingModelConfig) error
+}
+
+// EmbeddingCache keeps the vectors of embedded texts so that embedding the same text with the same model
+// again does not call the model
+type EmbeddingCache interface {
+	// Stats reports the hit rate since the start and describes the cached vectors
+	Stats(ctx context.Context) (entity.EmbeddingCacheStats, error)
+
+	// Evict removes the vectors unused for longer than maxAge, then all but the maxEntries most recently
+	// used ones. A zero maxAge or maxEntries skips that step
+	Evict(ctx context.Context, maxEntries int, maxAge time.Duration) (entity.EmbeddingCacheEviction, error)
 }
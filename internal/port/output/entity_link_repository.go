@@ -0,0 +1,23 @@
+package output
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"garden3/internal/domain/entity"
+)
+
+// EntityLinkRepository defines the data access operations for resolving [[...]] entity links in text
+type EntityLinkRepository interface {
+	// GetEntityByID retrieves an entity by ID
+	GetEntityByID(ctx context.Context, entityID uuid.UUID) (*entity.Entity, error)
+
+	// GetEntityByName retrieves a non-deleted entity by name
+	GetEntityByName(ctx context.Context, name string) (*entity.Entity, error)
+
+	// CreateEntity creates an entity and returns its ID
+	CreateEntity(ctx context.Context, name, entityType string, description *string, properties map[string]interface{}) (*uuid.UUID, error)
+
+	// CreateEntityReference records that a piece of text links to an entity
+	CreateEntityReference(ctx context.Context, ref entity.EntityReference) error
+}
@@ -2,12 +2,37 @@ package output
 
 import (
 	"context"
+
+	"garden3/internal/domain/entity"
 )
 
 // HTTPFetcher defines the interface for fetching HTTP content
 type HTTPFetcher interface {
 	// Fetch retrieves content from a URL with timeout
-	Fetch(ctx context.Context, url string, timeout int) (*FetchResponse, error)
+	Fetch(ctx context.Context, url string, timeout int, options FetchOptions) (*FetchResponse, error)
+
+	// Head requests only the status and headers of a URL with timeout
+	Head(ctx context.Context, url string, timeout int, options FetchOptions) (*FetchResponse, error)
+}
+
+// FetchOptions holds the per-request settings of a fetch
+type FetchOptions struct {
+	// ETag and LastModified are the validators of an earlier response. When set the request is
+	// conditional, and an unchanged resource is answered with 304 Not Modified and no body
+	ETag         string
+	LastModified string
+
+	// UserAgent replaces the default User-Agent header when set
+	UserAgent string
+	// Headers are added to the request, replacing default headers of the same name
+	Headers map[string]string
+	// Cookies are sent to the hosts and paths they belong to, including after redirects
+	Cookies []entity.FetchCookie
+	// Proxy is an http, https or socks5 proxy URL to send the request through
+	Proxy string
+	// PublicOnly refuses to connect to loopback, link-local, private and other non-public addresses,
+	// for URLs taken from fetched content rather than given by the user
+	PublicOnly bool
 }
 
 // FetchResponse represents the response from an HTTP fetch operation
@@ -15,4 +40,12 @@ type FetchResponse struct {
 	StatusCode int32
 	Headers    string
 	Content    []byte
+	// URL is the address the response was served from after following redirects
+	URL string
+	// Charset is the encoding the body was transcoded to UTF-8 from, empty when it was left as is
+	Charset string
+	// Original is the body as received when it was transcoded, nil when Content holds it unchanged
+	Original []byte
+	// OriginalContentType is the Content-Type header a transcoded body was received with
+	OriginalContentType string
 }
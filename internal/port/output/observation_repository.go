@@ -9,12 +9,18 @@ import (
 
 // ObservationRepository defines persistence operations for observations
 type ObservationRepository interface {
-	// Create creates a new observation
+	// Create creates a new observation, without a reference when ref is uuid.Nil
 	Create(ctx context.Context, data []byte, obsType, source, tags string, ref uuid.UUID) (*entity.Observation, error)
 
 	// GetFeedbackStats retrieves feedback statistics for a bookmark
 	GetFeedbackStats(ctx context.Context, bookmarkID uuid.UUID) (*entity.FeedbackStats, error)
 
+	// ListFeedback retrieves every recorded Q&A feedback, oldest first, skipping entries that cannot be decoded
+	ListFeedback(ctx context.Context) ([]entity.FeedbackData, error)
+
+	// ListByType retrieves the newest observations of a type
+	ListByType(ctx context.Context, obsType string, limit int32) ([]entity.Observation, error)
+
 	// DeleteBookmarkContentReference deletes a bookmark content reference
 	DeleteBookmarkContentReference(ctx context.Context, referenceID, bookmarkID uuid.UUID) error
 }
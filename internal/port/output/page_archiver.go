@@ -0,0 +1,49 @@
+package output
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// PageArchiver defines the interface for building offline copies of web pages
+type PageArchiver interface {
+	// Archive downloads the subresources of an HTML page and inlines them into a single self-contained
+	// document, and writes a WARC file holding the page response and every resource fetched for it
+	Archive(ctx context.Context, page ArchivePage, options FetchOptions) (*ArchiveResult, error)
+}
+
+// ArchivePage is a fetched HTML page to archive
+type ArchivePage struct {
+	URL        string
+	StatusCode int32
+	// Headers are the response headers as stored with the fetch, a JSON object of strings
+	Headers   string
+	Content   []byte
+	FetchDate time.Time
+	// Original and OriginalContentType are the body and Content-Type the page was received with when the
+	// fetcher transcoded Content, kept for the WARC record. Original is nil when Content is unchanged
+	Original            []byte
+	OriginalContentType string
+}
+
+// ArchiveResult represents the archived forms of a page
+type ArchiveResult struct {
+	HTML            []byte
+	WARC            []byte
+	ResourceCount   int
+	FailedResources int
+}
+
+// ArchiveStore defines the interface for content-addressed storage of archive files
+type ArchiveStore interface {
+	// Put stores data under the hex SHA-256 of its content and returns that hash. Storing content
+	// that is already present succeeds without using more space
+	Put(ctx context.Context, data []byte) (string, error)
+
+	// Open returns a reader for the content stored under hash and its size
+	Open(ctx context.Context, hash string) (io.ReadCloser, int64, error)
+
+	// Delete removes the content stored under hash, succeeding if it does not exist
+	Delete(ctx context.Context, hash string) error
+}
@@ -96,6 +96,19 @@ CREATE EXTENSION IF NOT EXISTS vector WITH SCHEMA public;
 COMMENT ON EXTENSION vector IS 'vector data type and ivfflat and hnsw access methods';
 
 
+--
+-- Name: active_embedding_model(); Type: FUNCTION; Schema: public; Owner: gardener
+--
+
+CREATE FUNCTION public.active_embedding_model() RETURNS text
+    LANGUAGE sql STABLE
+    AS $$
+    SELECT name FROM public.embedding_models WHERE active
+$$;
+
+
+ALTER FUNCTION public.active_embedding_model() OWNER TO gardener;
+
 --
 -- Name: add_contact_tag(uuid, text); Type: FUNCTION; Schema: public; Owner: postgres
 --
@@ -2208,6 +2221,61 @@ CREATE TABLE public.alicia_meta (
 
 ALTER TABLE public.alicia_meta OWNER TO gardener;
 
+--
+-- Name: bookmark_annotation_tags; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_annotation_tags (
+    annotation_id uuid NOT NULL,
+    tag_id uuid NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_annotation_tags OWNER TO gardener;
+
+--
+-- Name: bookmark_annotations; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_annotations (
+    annotation_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    bookmark_id uuid NOT NULL,
+    quote_exact text NOT NULL,
+    quote_prefix text DEFAULT ''::text NOT NULL,
+    quote_suffix text DEFAULT ''::text NOT NULL,
+    position_start integer NOT NULL,
+    position_end integer NOT NULL,
+    note text,
+    embedding public.vector,
+    embedding_model text DEFAULT public.active_embedding_model(),
+    embedding_dimensions integer GENERATED ALWAYS AS (public.vector_dims(embedding)) STORED,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_annotations OWNER TO gardener;
+
+--
+-- Name: bookmark_archives; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_archives (
+    archive_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    bookmark_id uuid NOT NULL,
+    url text NOT NULL,
+    html_hash text NOT NULL,
+    html_size bigint NOT NULL,
+    warc_hash text,
+    warc_size bigint,
+    resource_count integer DEFAULT 0 NOT NULL,
+    failed_resources integer DEFAULT 0 NOT NULL,
+    archived_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_archives OWNER TO gardener;
+
 --
 -- Name: bookmark_category; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2221,6 +2289,23 @@ CREATE TABLE public.bookmark_category (
 
 ALTER TABLE public.bookmark_category OWNER TO gardener;
 
+--
+-- Name: bookmark_category_suggestions; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_category_suggestions (
+    suggestion_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    bookmark_id uuid NOT NULL,
+    category_id uuid NOT NULL,
+    confidence double precision NOT NULL,
+    status text DEFAULT 'pending'::text NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    reviewed_at timestamp without time zone
+);
+
+
+ALTER TABLE public.bookmark_category_suggestions OWNER TO gardener;
+
 --
 -- Name: bookmark_content_references; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2230,7 +2315,9 @@ CREATE TABLE public.bookmark_content_references (
     bookmark_id uuid,
     content text,
     strategy text,
-    embedding public.vector(1024),
+    embedding public.vector,
+    embedding_model text DEFAULT public.active_embedding_model(),
+    embedding_dimensions integer GENERATED ALWAYS AS (public.vector_dims(embedding)) STORED,
     created_at timestamp without time zone DEFAULT now(),
     extra jsonb DEFAULT '{}'::jsonb
 );
@@ -2253,12 +2340,93 @@ CREATE TABLE public.bookmark_evaluations (
     summary_eval integer,
     questions_eval integer,
     questions_ids jsonb,
-    created_at timestamp with time zone DEFAULT now() NOT NULL
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    summary_strategy text,
+    summary_model text,
+    summary_prompt_version text,
+    questions_strategy text,
+    questions_model text,
+    questions_prompt_version text
 );
 
 
 ALTER TABLE public.bookmark_evaluations OWNER TO gardener;
 
+--
+-- Name: bookmark_imports; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_imports (
+    import_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    format text NOT NULL,
+    source text,
+    status text NOT NULL,
+    processed integer DEFAULT 0 NOT NULL,
+    created integer DEFAULT 0 NOT NULL,
+    duplicates integer DEFAULT 0 NOT NULL,
+    failed integer DEFAULT 0 NOT NULL,
+    report jsonb DEFAULT '{}'::jsonb NOT NULL,
+    error text,
+    started_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    finished_at timestamp without time zone
+);
+
+
+ALTER TABLE public.bookmark_imports OWNER TO gardener;
+
+--
+-- Name: bookmark_link_checks; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_link_checks (
+    check_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    bookmark_id uuid NOT NULL,
+    status text NOT NULL,
+    method text NOT NULL,
+    status_code integer,
+    final_url text,
+    error text,
+    etag text,
+    last_modified text,
+    content_hash text,
+    content text,
+    change_ratio double precision,
+    diff text,
+    checked_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_link_checks OWNER TO gardener;
+
+--
+-- Name: bookmark_metadata; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_metadata (
+    bookmark_id uuid NOT NULL,
+    canonical_url text,
+    title text,
+    description text,
+    site_name text,
+    author text,
+    published_at timestamp without time zone,
+    language text,
+    favicon_url text,
+    image_url text,
+    content_type text,
+    properties jsonb DEFAULT '{}'::jsonb NOT NULL,
+    json_ld jsonb DEFAULT '[]'::jsonb NOT NULL,
+    word_count integer DEFAULT 0 NOT NULL,
+    reading_time_minutes integer DEFAULT 0 NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    extractor text,
+    extractor_metadata jsonb DEFAULT '{}'::jsonb NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_metadata OWNER TO gardener;
+
 --
 -- Name: bookmark_sources; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2273,6 +2441,36 @@ CREATE TABLE public.bookmark_sources (
 
 ALTER TABLE public.bookmark_sources OWNER TO gardener;
 
+--
+-- Name: bookmark_stage_runs; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_stage_runs (
+    run_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    bookmark_id uuid NOT NULL,
+    stage text NOT NULL,
+    status text NOT NULL,
+    message text,
+    error text,
+    started_at timestamp without time zone DEFAULT now() NOT NULL,
+    finished_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_stage_runs OWNER TO gardener;
+
+--
+-- Name: bookmark_tags; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.bookmark_tags (
+    bookmark_id uuid NOT NULL,
+    tag_id uuid NOT NULL
+);
+
+
+ALTER TABLE public.bookmark_tags OWNER TO gardener;
+
 --
 -- Name: bookmark_titles; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2348,12 +2546,30 @@ ALTER SEQUENCE public.browser_history_id_seq OWNED BY public.browser_history.id;
 
 CREATE TABLE public.categories (
     category_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
-    name text NOT NULL
+    name text NOT NULL,
+    parent_id uuid
 );
 
 
 ALTER TABLE public.categories OWNER TO gardener;
 
+--
+-- Name: category_profiles; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.category_profiles (
+    category_id uuid NOT NULL,
+    embedding public.vector NOT NULL,
+    embedding_model text DEFAULT public.active_embedding_model(),
+    source_text text NOT NULL,
+    source_embedding public.vector,
+    bookmark_count integer NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.category_profiles OWNER TO gardener;
+
 --
 -- Name: category_sources; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2792,6 +3008,76 @@ ALTER SEQUENCE public.dispatch_transcription_id_seq OWNER TO gardener;
 ALTER SEQUENCE public.dispatch_transcription_id_seq OWNED BY public.dispatch_transcription.id;
 
 
+--
+-- Name: embedding_cache; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.embedding_cache (
+    cache_key text NOT NULL,
+    model text NOT NULL,
+    embedding public.vector NOT NULL,
+    hits bigint DEFAULT 0 NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    last_used_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.embedding_cache OWNER TO gardener;
+
+--
+-- Name: embedding_migration_vectors; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.embedding_migration_vectors (
+    migration_id uuid NOT NULL,
+    table_name text NOT NULL,
+    row_id uuid NOT NULL,
+    source_hash text NOT NULL,
+    embedding public.vector NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.embedding_migration_vectors OWNER TO gardener;
+
+--
+-- Name: embedding_migrations; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.embedding_migrations (
+    migration_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
+    source_model text,
+    target_provider text NOT NULL,
+    target_url text DEFAULT ''::text NOT NULL,
+    target_model text NOT NULL,
+    target_dimensions integer DEFAULT 0 NOT NULL,
+    target_api_key text DEFAULT ''::text NOT NULL,
+    status text DEFAULT 'running'::text NOT NULL,
+    done integer DEFAULT 0 NOT NULL,
+    remaining integer DEFAULT 0 NOT NULL,
+    error text,
+    started_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    finished_at timestamp without time zone
+);
+
+
+ALTER TABLE public.embedding_migrations OWNER TO gardener;
+
+--
+-- Name: embedding_models; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.embedding_models (
+    name text NOT NULL,
+    active boolean DEFAULT false NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    activated_at timestamp without time zone
+);
+
+
+ALTER TABLE public.embedding_models OWNER TO gardener;
+
 --
 -- Name: entities; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2845,6 +3131,20 @@ CREATE TABLE public.entity_relationships (
 
 ALTER TABLE public.entity_relationships OWNER TO gardener;
 
+--
+-- Name: http_response_bodies; Type: TABLE; Schema: public; Owner: gardener
+--
+
+CREATE TABLE public.http_response_bodies (
+    content_hash text NOT NULL,
+    content bytea NOT NULL,
+    size bigint NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.http_response_bodies OWNER TO gardener;
+
 --
 -- Name: item_tags; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -2917,7 +3217,12 @@ CREATE TABLE public.http_responses (
     status_code integer,
     headers text,
     content bytea,
-    fetch_date timestamp without time zone
+    fetch_date timestamp without time zone,
+    fetch_profile text,
+    original_content bytea,
+    original_content_type text,
+    content_hash text,
+    original_content_hash text
 );
 
 
@@ -2930,7 +3235,9 @@ ALTER TABLE public.http_responses OWNER TO gardener;
 CREATE TABLE public.item_semantic_index (
     id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
     item_id uuid,
-    embedding public.vector(1024)
+    embedding public.vector,
+    embedding_model text DEFAULT public.active_embedding_model(),
+    embedding_dimensions integer GENERATED ALWAYS AS (public.vector_dims(embedding)) STORED
 );
 
 ALTER TABLE ONLY public.item_semantic_index REPLICA IDENTITY FULL;
@@ -3158,7 +3465,10 @@ CREATE TABLE public.processed_contents (
     processed_content_id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
     bookmark_id uuid,
     strategy_used text,
-    processed_content text
+    processed_content text,
+    created_at timestamp without time zone DEFAULT now(),
+    extractor text,
+    metadata jsonb DEFAULT '{}'::jsonb NOT NULL
 );
 
 
@@ -3272,7 +3582,9 @@ CREATE TABLE public.session_summaries (
     id uuid DEFAULT gen_random_uuid() NOT NULL,
     session_id uuid NOT NULL,
     summary text,
-    embedding public.vector(1024),
+    embedding public.vector,
+    embedding_model text DEFAULT public.active_embedding_model(),
+    embedding_dimensions integer GENERATED ALWAYS AS (public.vector_dims(embedding)) STORED,
     strategy text,
     created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
 );
@@ -3280,6 +3592,51 @@ CREATE TABLE public.session_summaries (
 
 ALTER TABLE public.session_summaries OWNER TO gardener;
 
+--
+-- Name: embedding_corpus; Type: VIEW; Schema: public; Owner: gardener
+--
+
+CREATE VIEW public.embedding_corpus AS
+ SELECT 'bookmark_content_references'::text AS table_name,
+    bcr.id AS row_id,
+    COALESCE(bcr.content, ''::text) AS text,
+    ''::text AS extra_text,
+    md5(COALESCE(bcr.content, ''::text)) AS source_hash,
+    bcr.embedding_model
+   FROM public.bookmark_content_references bcr
+  WHERE (bcr.embedding IS NOT NULL)
+UNION ALL
+ SELECT 'bookmark_annotations'::text AS table_name,
+    a.annotation_id AS row_id,
+    a.quote_exact AS text,
+    COALESCE(a.note, ''::text) AS extra_text,
+    md5(((a.quote_exact || chr(31)) || COALESCE(a.note, ''::text))) AS source_hash,
+    a.embedding_model
+   FROM public.bookmark_annotations a
+  WHERE (a.embedding IS NOT NULL)
+UNION ALL
+ SELECT 'session_summaries'::text AS table_name,
+    ss.id AS row_id,
+    COALESCE(ss.summary, ''::text) AS text,
+    ''::text AS extra_text,
+    md5(COALESCE(ss.summary, ''::text)) AS source_hash,
+    ss.embedding_model
+   FROM public.session_summaries ss
+  WHERE (ss.embedding IS NOT NULL)
+UNION ALL
+ SELECT 'item_semantic_index'::text AS table_name,
+    isi.id AS row_id,
+    COALESCE(i.title, ''::text) AS text,
+    COALESCE(i.contents, ''::text) AS extra_text,
+    md5(((COALESCE(i.title, ''::text) || chr(31)) || COALESCE(i.contents, ''::text))) AS source_hash,
+    isi.embedding_model
+   FROM (public.item_semantic_index isi
+     JOIN public.items i ON ((i.id = isi.item_id)))
+  WHERE (isi.embedding IS NOT NULL);
+
+
+ALTER VIEW public.embedding_corpus OWNER TO gardener;
+
 --
 -- Name: sessions; Type: TABLE; Schema: public; Owner: gardener
 --
@@ -3396,6 +3753,30 @@ ALTER TABLE ONLY public.alicia_meta
     ADD CONSTRAINT alicia_meta_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: bookmark_annotation_tags bookmark_annotation_tags_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_annotation_tags
+    ADD CONSTRAINT bookmark_annotation_tags_pkey PRIMARY KEY (annotation_id, tag_id);
+
+
+--
+-- Name: bookmark_annotations bookmark_annotations_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_annotations
+    ADD CONSTRAINT bookmark_annotations_pkey PRIMARY KEY (annotation_id);
+
+
+--
+-- Name: bookmark_archives bookmark_archives_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_archives
+    ADD CONSTRAINT bookmark_archives_pkey PRIMARY KEY (archive_id);
+
+
 --
 -- Name: bookmark_category bookmark_category_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3404,6 +3785,22 @@ ALTER TABLE ONLY public.bookmark_category
     ADD CONSTRAINT bookmark_category_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: bookmark_category_suggestions bookmark_category_suggestions_bookmark_id_category_id_key; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_category_suggestions
+    ADD CONSTRAINT bookmark_category_suggestions_bookmark_id_category_id_key UNIQUE (bookmark_id, category_id);
+
+
+--
+-- Name: bookmark_category_suggestions bookmark_category_suggestions_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_category_suggestions
+    ADD CONSTRAINT bookmark_category_suggestions_pkey PRIMARY KEY (suggestion_id);
+
+
 --
 -- Name: bookmark_evaluations bookmark_evaluations_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3412,6 +3809,30 @@ ALTER TABLE ONLY public.bookmark_evaluations
     ADD CONSTRAINT bookmark_evaluations_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: bookmark_imports bookmark_imports_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_imports
+    ADD CONSTRAINT bookmark_imports_pkey PRIMARY KEY (import_id);
+
+
+--
+-- Name: bookmark_link_checks bookmark_link_checks_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_link_checks
+    ADD CONSTRAINT bookmark_link_checks_pkey PRIMARY KEY (check_id);
+
+
+--
+-- Name: bookmark_metadata bookmark_metadata_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_metadata
+    ADD CONSTRAINT bookmark_metadata_pkey PRIMARY KEY (bookmark_id);
+
+
 --
 -- Name: bookmark_sources bookmark_sources_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3420,6 +3841,22 @@ ALTER TABLE ONLY public.bookmark_sources
     ADD CONSTRAINT bookmark_sources_pkey PRIMARY KEY (source_id);
 
 
+--
+-- Name: bookmark_stage_runs bookmark_stage_runs_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_stage_runs
+    ADD CONSTRAINT bookmark_stage_runs_pkey PRIMARY KEY (run_id);
+
+
+--
+-- Name: bookmark_tags bookmark_tags_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_tags
+    ADD CONSTRAINT bookmark_tags_pkey PRIMARY KEY (bookmark_id, tag_id);
+
+
 --
 -- Name: bookmark_titles bookmark_titles_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3460,6 +3897,14 @@ ALTER TABLE ONLY public.categories
     ADD CONSTRAINT categories_pkey PRIMARY KEY (category_id);
 
 
+--
+-- Name: category_profiles category_profiles_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.category_profiles
+    ADD CONSTRAINT category_profiles_pkey PRIMARY KEY (category_id);
+
+
 --
 -- Name: category_sources category_sources_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3636,6 +4081,38 @@ ALTER TABLE ONLY public.dispatch_transcription
     ADD CONSTRAINT dispatch_transcription_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: embedding_cache embedding_cache_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.embedding_cache
+    ADD CONSTRAINT embedding_cache_pkey PRIMARY KEY (cache_key);
+
+
+--
+-- Name: embedding_migration_vectors embedding_migration_vectors_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.embedding_migration_vectors
+    ADD CONSTRAINT embedding_migration_vectors_pkey PRIMARY KEY (migration_id, table_name, row_id);
+
+
+--
+-- Name: embedding_migrations embedding_migrations_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.embedding_migrations
+    ADD CONSTRAINT embedding_migrations_pkey PRIMARY KEY (migration_id);
+
+
+--
+-- Name: embedding_models embedding_models_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.embedding_models
+    ADD CONSTRAINT embedding_models_pkey PRIMARY KEY (name);
+
+
 --
 -- Name: entities entities_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3660,6 +4137,14 @@ ALTER TABLE ONLY public.entity_relationships
     ADD CONSTRAINT entity_relationships_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: http_response_bodies http_response_bodies_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.http_response_bodies
+    ADD CONSTRAINT http_response_bodies_pkey PRIMARY KEY (content_hash);
+
+
 --
 -- Name: http_responses http_responses_pkey; Type: CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -3916,6 +4401,41 @@ ALTER TABLE ONLY public.tags
     ADD CONSTRAINT tags_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: bookmark_annotation_tags_tag_id_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_annotation_tags_tag_id_idx ON public.bookmark_annotation_tags USING btree (tag_id);
+
+
+--
+-- Name: bookmark_annotations_bookmark_id_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_annotations_bookmark_id_idx ON public.bookmark_annotations USING btree (bookmark_id, position_start);
+
+
+--
+-- Name: bookmark_annotations_created_at_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_annotations_created_at_idx ON public.bookmark_annotations USING btree (created_at DESC);
+
+
+--
+-- Name: bookmark_archives_bookmark_id_archived_at_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_archives_bookmark_id_archived_at_idx ON public.bookmark_archives USING btree (bookmark_id, archived_at DESC);
+
+
+--
+-- Name: bookmark_category_suggestions_status_created_at_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_category_suggestions_status_created_at_idx ON public.bookmark_category_suggestions USING btree (status, created_at DESC);
+
+
 --
 -- Name: bookmark_evaluations_bookmark_id_idx; Type: INDEX; Schema: public; Owner: gardener
 --
@@ -3923,6 +4443,97 @@ ALTER TABLE ONLY public.tags
 CREATE INDEX bookmark_evaluations_bookmark_id_idx ON public.bookmark_evaluations USING btree (bookmark_id);
 
 
+--
+-- Name: bookmark_link_checks_bookmark_id_checked_at_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_link_checks_bookmark_id_checked_at_idx ON public.bookmark_link_checks USING btree (bookmark_id, checked_at DESC);
+
+
+--
+-- Name: bookmark_metadata_author_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_metadata_author_idx ON public.bookmark_metadata USING btree (lower(author));
+
+
+--
+-- Name: bookmark_metadata_published_at_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_metadata_published_at_idx ON public.bookmark_metadata USING btree (published_at);
+
+
+--
+-- Name: bookmark_metadata_site_name_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_metadata_site_name_idx ON public.bookmark_metadata USING btree (lower(site_name));
+
+
+--
+-- Name: bookmark_stage_runs_bookmark_id_stage_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX bookmark_stage_runs_bookmark_id_stage_idx ON public.bookmark_stage_runs USING btree (bookmark_id, stage, started_at DESC);
+
+
+--
+-- Name: bookmarks_url_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE UNIQUE INDEX bookmarks_url_idx ON public.bookmarks USING btree (url);
+
+
+--
+-- Name: categories_parent_id_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX categories_parent_id_idx ON public.categories USING btree (parent_id);
+
+
+--
+-- Name: embedding_cache_last_used_at_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX embedding_cache_last_used_at_idx ON public.embedding_cache USING btree (last_used_at);
+
+
+--
+-- Name: embedding_models_active_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE UNIQUE INDEX embedding_models_active_idx ON public.embedding_models USING btree (active) WHERE active;
+
+
+--
+-- Name: http_responses_bookmark_id_fetch_date_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX http_responses_bookmark_id_fetch_date_idx ON public.http_responses USING btree (bookmark_id, fetch_date DESC);
+
+
+--
+-- Name: http_responses_content_hash_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX http_responses_content_hash_idx ON public.http_responses USING btree (content_hash);
+
+
+--
+-- Name: http_responses_inline_content_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX http_responses_inline_content_idx ON public.http_responses USING btree (response_id) WHERE (content IS NOT NULL);
+
+
+--
+-- Name: http_responses_original_content_hash_idx; Type: INDEX; Schema: public; Owner: gardener
+--
+
+CREATE INDEX http_responses_original_content_hash_idx ON public.http_responses USING btree (original_content_hash) WHERE (original_content_hash IS NOT NULL);
+
+
 --
 -- Name: idx_browser_history_domain; Type: INDEX; Schema: public; Owner: gardener
 --
@@ -4312,6 +4923,38 @@ ALTER TABLE ONLY public.alicia_meta
     ADD CONSTRAINT alicia_meta_ref_fkey FOREIGN KEY (ref) REFERENCES public.alicia_message(id);
 
 
+--
+-- Name: bookmark_annotation_tags bookmark_annotation_tags_annotation_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_annotation_tags
+    ADD CONSTRAINT bookmark_annotation_tags_annotation_id_fkey FOREIGN KEY (annotation_id) REFERENCES public.bookmark_annotations(annotation_id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_annotation_tags bookmark_annotation_tags_tag_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_annotation_tags
+    ADD CONSTRAINT bookmark_annotation_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES public.tags(id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_annotations bookmark_annotations_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_annotations
+    ADD CONSTRAINT bookmark_annotations_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_archives bookmark_archives_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_archives
+    ADD CONSTRAINT bookmark_archives_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
 --
 -- Name: bookmark_category bookmark_category_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4328,6 +4971,22 @@ ALTER TABLE ONLY public.bookmark_category
     ADD CONSTRAINT bookmark_category_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.categories(category_id) ON DELETE CASCADE;
 
 
+--
+-- Name: bookmark_category_suggestions bookmark_category_suggestions_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_category_suggestions
+    ADD CONSTRAINT bookmark_category_suggestions_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_category_suggestions bookmark_category_suggestions_category_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_category_suggestions
+    ADD CONSTRAINT bookmark_category_suggestions_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.categories(category_id) ON DELETE CASCADE;
+
+
 --
 -- Name: bookmark_content_references bookmark_content_references_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4344,6 +5003,22 @@ ALTER TABLE ONLY public.bookmark_evaluations
     ADD CONSTRAINT bookmark_evaluations_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
 
 
+--
+-- Name: bookmark_link_checks bookmark_link_checks_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_link_checks
+    ADD CONSTRAINT bookmark_link_checks_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_metadata bookmark_metadata_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_metadata
+    ADD CONSTRAINT bookmark_metadata_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
 --
 -- Name: bookmark_sources bookmark_sources_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4352,6 +5027,30 @@ ALTER TABLE ONLY public.bookmark_sources
     ADD CONSTRAINT bookmark_sources_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
 
 
+--
+-- Name: bookmark_stage_runs bookmark_stage_runs_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_stage_runs
+    ADD CONSTRAINT bookmark_stage_runs_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_tags bookmark_tags_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_tags
+    ADD CONSTRAINT bookmark_tags_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
+
+
+--
+-- Name: bookmark_tags bookmark_tags_tag_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.bookmark_tags
+    ADD CONSTRAINT bookmark_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES public.tags(id) ON DELETE CASCADE;
+
+
 --
 -- Name: bookmark_titles bookmark_titles_bookmark_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4360,6 +5059,22 @@ ALTER TABLE ONLY public.bookmark_titles
     ADD CONSTRAINT bookmark_titles_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
 
 
+--
+-- Name: categories categories_parent_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES public.categories(category_id) ON DELETE SET NULL;
+
+
+--
+-- Name: category_profiles category_profiles_category_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.category_profiles
+    ADD CONSTRAINT category_profiles_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.categories(category_id) ON DELETE CASCADE;
+
+
 --
 -- Name: category_sources category_sources_category_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4488,6 +5203,14 @@ ALTER TABLE ONLY public.dispatch_transcription
     ADD CONSTRAINT dispatch_transcription_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES public.dispatch_transcription(id) ON DELETE SET NULL;
 
 
+--
+-- Name: embedding_migration_vectors embedding_migration_vectors_migration_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.embedding_migration_vectors
+    ADD CONSTRAINT embedding_migration_vectors_migration_id_fkey FOREIGN KEY (migration_id) REFERENCES public.embedding_migrations(migration_id) ON DELETE CASCADE;
+
+
 --
 -- Name: entity_references entity_references_entity_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4536,6 +5259,22 @@ ALTER TABLE ONLY public.http_responses
     ADD CONSTRAINT http_responses_bookmark_id_fkey FOREIGN KEY (bookmark_id) REFERENCES public.bookmarks(bookmark_id) ON DELETE CASCADE;
 
 
+--
+-- Name: http_responses http_responses_content_hash_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.http_responses
+    ADD CONSTRAINT http_responses_content_hash_fkey FOREIGN KEY (content_hash) REFERENCES public.http_response_bodies(content_hash);
+
+
+--
+-- Name: http_responses http_responses_original_content_hash_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
+--
+
+ALTER TABLE ONLY public.http_responses
+    ADD CONSTRAINT http_responses_original_content_hash_fkey FOREIGN KEY (original_content_hash) REFERENCES public.http_response_bodies(content_hash);
+
+
 --
 -- Name: item_semantic_index item_semantic_index_item_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: gardener
 --
@@ -4882,6 +5621,27 @@ GRANT ALL ON TABLE public.alicia_message TO repl_garden;
 GRANT ALL ON TABLE public.alicia_meta TO repl_garden;
 
 
+--
+-- Name: TABLE bookmark_annotation_tags; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_annotation_tags TO repl_garden;
+
+
+--
+-- Name: TABLE bookmark_annotations; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_annotations TO repl_garden;
+
+
+--
+-- Name: TABLE bookmark_archives; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_archives TO repl_garden;
+
+
 --
 -- Name: TABLE bookmark_category; Type: ACL; Schema: public; Owner: gardener
 --
@@ -4903,6 +5663,27 @@ GRANT ALL ON TABLE public.bookmark_content_references TO repl_garden;
 GRANT ALL ON TABLE public.bookmark_evaluations TO repl_garden;
 
 
+--
+-- Name: TABLE bookmark_imports; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_imports TO repl_garden;
+
+
+--
+-- Name: TABLE bookmark_link_checks; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_link_checks TO repl_garden;
+
+
+--
+-- Name: TABLE bookmark_metadata; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_metadata TO repl_garden;
+
+
 --
 -- Name: TABLE bookmark_sources; Type: ACL; Schema: public; Owner: gardener
 --
@@ -4910,6 +5691,20 @@ GRANT ALL ON TABLE public.bookmark_evaluations TO repl_garden;
 GRANT ALL ON TABLE public.bookmark_sources TO repl_garden;
 
 
+--
+-- Name: TABLE bookmark_stage_runs; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_stage_runs TO repl_garden;
+
+
+--
+-- Name: TABLE bookmark_tags; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.bookmark_tags TO repl_garden;
+
+
 --
 -- Name: TABLE bookmark_titles; Type: ACL; Schema: public; Owner: gardener
 --
@@ -5134,6 +5929,13 @@ GRANT ALL ON TABLE public.entity_references TO repl_garden;
 GRANT ALL ON TABLE public.entity_relationships TO repl_garden;
 
 
+--
+-- Name: TABLE http_response_bodies; Type: ACL; Schema: public; Owner: gardener
+--
+
+GRANT ALL ON TABLE public.http_response_bodies TO repl_garden;
+
+
 --
 -- Name: TABLE item_tags; Type: ACL; Schema: public; Owner: gardener
 --